@@ -16,17 +16,20 @@ import (
 	"resizr/internal/service"
 	"resizr/internal/storage"
 	"resizr/pkg/logger"
+	"resizr/pkg/version"
 
 	"go.uber.org/zap"
 )
 
 const (
 	// Application information
-	AppName    = "Resizr"
-	AppVersion = "0.0.1"
+	AppName = "Resizr"
 
 	// Graceful shutdown timeout
 	ShutdownTimeout = 30 * time.Second
+
+	// Final flush timeout for buffered download counts
+	AccessFlushTimeout = 5 * time.Second
 )
 
 func main() {
@@ -52,7 +55,7 @@ func run() error {
 	}
 
 	logger.Info("Starting RESIZR application",
-		zap.String("version", AppVersion),
+		zap.String("version", version.Version),
 		zap.String("port", cfg.Server.Port),
 		zap.Bool("development", cfg.IsDevelopment()))
 
@@ -89,7 +92,14 @@ func run() error {
 	if maxH <= 0 || maxH > 8192 {
 		maxH = 8192
 	}
-	processor := service.NewProcessorService(maxW, maxH)
+	// Source caps are bounded by the decompression-bomb budget during config validation
+	processor := service.NewProcessorService(maxW, maxH, cfg.Image.MaxSourceWidth, cfg.Image.MaxSourceHeight,
+		service.WithBufferPoolMaxSize(cfg.Image.BufferPoolMaxSize),
+		service.WithWatermarkFont(cfg.Watermark.FontPath),
+		service.WithDefaultContentType(cfg.Image.DefaultContentType),
+		service.WithSRGBConversion(cfg.Image.ConvertToSRGB),
+		service.WithMultiStepDownscale(cfg.Image.MultiStepDownscaleRatio),
+		service.WithGrayscalePreservation(cfg.Image.PreserveGrayscale))
 
 	// Initialize services
 	logger.Info("Initializing services...")
@@ -102,12 +112,30 @@ func run() error {
 	}
 
 	imageService := service.NewImageService(repo, dedupRepo, store, processor, cfg)
-	healthService := service.NewHealthService(repo, store, cfg, AppVersion)
+	healthService := service.NewHealthService(repo, store, cfg, version.Version)
 	statisticsService := service.NewStatisticsService(repo, dedupRepo, store, cfg)
+	auditService := service.NewAuditService(repo, cfg)
+
+	// Keep the statistics cache warm in the background until shutdown
+	refreshCtx, stopRefresh := context.WithCancel(context.Background())
+	defer stopRefresh()
+	statisticsService.StartBackgroundRefresh(refreshCtx)
+
+	// Sweep deduplication records no image references anymore
+	imageService.StartOrphanCleanup(refreshCtx)
+
+	// Persist buffered download counts in the background
+	imageService.StartAccessCountFlush(refreshCtx)
+
+	// Generate queued upload resolutions on the worker pool
+	imageService.StartProcessingQueue(refreshCtx)
+
+	// /readyz stays not-ready until Redis and S3 are confirmed reachable
+	healthService.StartReadinessChecks(refreshCtx)
 
 	// Initialize API router
 	logger.Info("Initializing API router...")
-	router := api.NewRouter(cfg, imageService, healthService, statisticsService)
+	router := api.NewRouter(cfg, imageService, healthService, statisticsService, auditService)
 
 	// Create HTTP server
 	server := &http.Server{
@@ -138,11 +166,18 @@ func run() error {
 	}
 
 	logger.Info(AppName+" application started successfully",
-		zap.String("version", AppVersion),
+		zap.String("version", version.Version),
 		zap.String("port", cfg.Server.Port))
 
 	// Wait for interrupt signal or server error
-	return waitForShutdown(server, serverErrChan)
+	err = waitForShutdown(server, serverErrChan)
+
+	// Persist the download counts still buffered before the repository closes
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), AccessFlushTimeout)
+	defer cancelFlush()
+	imageService.FlushAccessCounts(flushCtx)
+
+	return err
 }
 
 // waitForShutdown waits for shutdown signal and gracefully shuts down the server
@@ -184,5 +219,5 @@ func gracefulShutdown(server *http.Server) error {
 // Health check endpoint information for monitoring
 func init() {
 	// Register application info that can be used by monitoring systems
-	log.Printf("RESIZR %s initializing...", AppVersion)
+	log.Printf("RESIZR %s initializing...", version.Version)
 }
@@ -0,0 +1,288 @@
+package handlers
+
+import (
+	"net/http"
+
+	"resizr/internal/api/middleware"
+	"resizr/internal/models"
+	"resizr/internal/service"
+	"resizr/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// AdminHandler handles administrative HTTP requests that operate across images
+type AdminHandler struct {
+	imageService service.ImageService
+	maintenance  *middleware.MaintenanceMode
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(imageService service.ImageService, maintenance *middleware.MaintenanceMode) *AdminHandler {
+	return &AdminHandler{
+		imageService: imageService,
+		maintenance:  maintenance,
+	}
+}
+
+// AddResolutionToAll generates a resolution for every image that lacks it
+// POST /api/v1/admin/resolutions
+func (h *AdminHandler) AddResolutionToAll(c *gin.Context) {
+	ctx := c.Request.Context()
+	requestID := c.GetString("request_id")
+
+	var req models.AddResolutionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if respondBodyTooLarge(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:     "Invalid request body",
+			Message:   "Request body must be JSON with a 'resolution' field",
+			Code:      http.StatusBadRequest,
+			ErrorCode: models.ErrorCodeInvalidRequest,
+		})
+		return
+	}
+
+	logger.InfoWithContext(ctx, "Processing bulk add resolution request",
+		zap.String("resolution", req.Resolution),
+		zap.String("request_id", requestID))
+
+	result, err := h.imageService.AddResolutionToAll(ctx, req.Resolution)
+	if err != nil {
+		if validationErr, ok := err.(models.ValidationError); ok {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:     "Invalid resolution",
+				Message:   validationErr.Message,
+				Code:      http.StatusBadRequest,
+				ErrorCode: models.ErrorCodeFor(validationErr),
+			})
+			return
+		}
+
+		logger.ErrorWithContext(ctx, "Bulk add resolution failed",
+			zap.Error(err),
+			zap.String("request_id", requestID))
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:     "Bulk add resolution failed",
+			Message:   "Failed to add the resolution to stored images",
+			Code:      http.StatusInternalServerError,
+			ErrorCode: models.ErrorCodeInternal,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetCorruptMetadata reports metadata records that cannot be decoded
+// GET /api/v1/admin/corrupt-metadata
+func (h *AdminHandler) GetCorruptMetadata(c *gin.Context) {
+	report, err := h.imageService.FindCorruptMetadata(c.Request.Context())
+	if err != nil {
+		h.corruptMetadataFailed(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// RemoveCorruptMetadata deletes metadata records that cannot be decoded
+// DELETE /api/v1/admin/corrupt-metadata
+func (h *AdminHandler) RemoveCorruptMetadata(c *gin.Context) {
+	report, err := h.imageService.RemoveCorruptMetadata(c.Request.Context())
+	if err != nil {
+		h.corruptMetadataFailed(c, err)
+		return
+	}
+
+	logger.InfoWithContext(c.Request.Context(), "Corrupt metadata cleanup completed",
+		zap.Int("found", report.Count),
+		zap.Int("removed", report.Removed),
+		zap.String("request_id", c.GetString("request_id")))
+
+	c.JSON(http.StatusOK, report)
+}
+
+// corruptMetadataFailed responds to a failed corrupt metadata scan
+func (h *AdminHandler) corruptMetadataFailed(c *gin.Context, err error) {
+	logger.ErrorWithContext(c.Request.Context(), "Corrupt metadata scan failed",
+		zap.Error(err),
+		zap.String("request_id", c.GetString("request_id")))
+	c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+		Error:     "Corrupt metadata scan failed",
+		Message:   "Failed to scan stored metadata records",
+		Code:      http.StatusServiceUnavailable,
+		ErrorCode: models.ErrorCodeFor(err),
+	})
+}
+
+// GetOrphanedHashes reports deduplication records that no image references
+// GET /api/v1/admin/dedup/orphans
+func (h *AdminHandler) GetOrphanedHashes(c *gin.Context) {
+	report, err := h.imageService.FindOrphanedHashes(c.Request.Context())
+	if err != nil {
+		h.orphanedHashesFailed(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// RemoveOrphanedHashes deletes orphaned deduplication records and their lingering files
+// DELETE /api/v1/admin/dedup/orphans
+func (h *AdminHandler) RemoveOrphanedHashes(c *gin.Context) {
+	report, err := h.imageService.RemoveOrphanedHashes(c.Request.Context())
+	if err != nil {
+		h.orphanedHashesFailed(c, err)
+		return
+	}
+
+	logger.InfoWithContext(c.Request.Context(), "Orphaned hash cleanup completed",
+		zap.Int("found", report.Count),
+		zap.Int("removed", report.Removed),
+		zap.String("request_id", c.GetString("request_id")))
+
+	c.JSON(http.StatusOK, report)
+}
+
+// orphanedHashesFailed responds to a failed orphaned hash scan
+func (h *AdminHandler) orphanedHashesFailed(c *gin.Context, err error) {
+	logger.ErrorWithContext(c.Request.Context(), "Orphaned hash scan failed",
+		zap.Error(err),
+		zap.String("request_id", c.GetString("request_id")))
+	c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+		Error:     "Orphaned hash scan failed",
+		Message:   "Failed to scan deduplication records",
+		Code:      http.StatusServiceUnavailable,
+		ErrorCode: models.ErrorCodeFor(err),
+	})
+}
+
+// ExportMetadata streams every metadata record as NDJSON, or as a JSON array with format=json
+// GET /api/v1/admin/export
+func (h *AdminHandler) ExportMetadata(c *gin.Context) {
+	ctx := c.Request.Context()
+	requestID := c.GetString("request_id")
+
+	format := c.DefaultQuery("format", models.ExportFormatNDJSON)
+	var contentType string
+	switch format {
+	case models.ExportFormatNDJSON:
+		contentType = "application/x-ndjson"
+	case models.ExportFormatJSON:
+		contentType = "application/json"
+	default:
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:     "Invalid export format",
+			Message:   "format must be ndjson or json",
+			Code:      http.StatusBadRequest,
+			ErrorCode: models.ErrorCodeInvalidRequest,
+		})
+		return
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", `attachment; filename="resizr-metadata.`+format+`"`)
+
+	count, err := h.imageService.ExportMetadata(ctx, c.Writer, format)
+	if err != nil {
+		logger.ErrorWithContext(ctx, "Metadata export failed",
+			zap.Error(err),
+			zap.Int("exported", count),
+			zap.String("request_id", requestID))
+
+		// Once records are streamed the status is sent; the truncated body is all we can do
+		if !c.Writer.Written() {
+			c.Header("Content-Disposition", "")
+			c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+				Error:     "Metadata export failed",
+				Message:   "Failed to list stored metadata records",
+				Code:      http.StatusServiceUnavailable,
+				ErrorCode: models.ErrorCodeFor(err),
+			})
+		}
+		return
+	}
+
+	logger.InfoWithContext(ctx, "Metadata exported",
+		zap.Int("records", count),
+		zap.String("format", format),
+		zap.String("request_id", requestID))
+}
+
+// ImportMetadata restores metadata records from an NDJSON export
+// POST /api/v1/admin/import
+func (h *AdminHandler) ImportMetadata(c *gin.Context) {
+	ctx := c.Request.Context()
+	requestID := c.GetString("request_id")
+
+	result, err := h.imageService.ImportMetadata(ctx, c.Request.Body)
+	if err != nil {
+		if respondBodyTooLarge(c, err) {
+			return
+		}
+		if validationErr, ok := err.(models.ValidationError); ok {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:     "Invalid import data",
+				Message:   validationErr.Message,
+				Code:      http.StatusBadRequest,
+				ErrorCode: models.ErrorCodeFor(validationErr),
+			})
+			return
+		}
+
+		logger.ErrorWithContext(ctx, "Metadata import failed",
+			zap.Error(err),
+			zap.String("request_id", requestID))
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:     "Metadata import failed",
+			Message:   "Failed to restore metadata records",
+			Code:      http.StatusInternalServerError,
+			ErrorCode: models.ErrorCodeInternal,
+		})
+		return
+	}
+
+	logger.InfoWithContext(ctx, "Metadata imported",
+		zap.Int("total", result.Total),
+		zap.Int("imported", result.Imported),
+		zap.Int("failed", result.Failed),
+		zap.String("request_id", requestID))
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetMaintenance reports whether maintenance mode is on
+// GET /api/v1/admin/maintenance
+func (h *AdminHandler) GetMaintenance(c *gin.Context) {
+	c.JSON(http.StatusOK, models.MaintenanceStatus{Enabled: h.maintenance.Enabled()})
+}
+
+// SetMaintenance turns maintenance mode on or off without a restart
+// PUT /api/v1/admin/maintenance
+func (h *AdminHandler) SetMaintenance(c *gin.Context) {
+	var req models.MaintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if respondBodyTooLarge(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:     "Invalid request body",
+			Message:   "Request body must be JSON with a boolean 'enabled' field",
+			Code:      http.StatusBadRequest,
+			ErrorCode: models.ErrorCodeInvalidRequest,
+		})
+		return
+	}
+
+	h.maintenance.SetEnabled(*req.Enabled)
+
+	logger.InfoWithContext(c.Request.Context(), "Maintenance mode changed",
+		zap.Bool("enabled", *req.Enabled),
+		zap.String("request_id", c.GetString("request_id")))
+
+	c.JSON(http.StatusOK, models.MaintenanceStatus{Enabled: *req.Enabled})
+}
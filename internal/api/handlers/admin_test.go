@@ -0,0 +1,341 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"resizr/internal/api/middleware"
+	"resizr/internal/models"
+	"resizr/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminHandler_AddResolutionToAll(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		result         *models.BulkResolutionResult
+		serviceErr     error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name: "summary returned",
+			body: `{"resolution": "400x300"}`,
+			result: &models.BulkResolutionResult{
+				Resolution: "400x300",
+				Total:      3,
+				Processed:  1,
+				Skipped:    1,
+				Failed:     1,
+				Failures:   []models.BulkResolutionFailure{{ImageID: testutil.ValidUUID, Error: "resize failed"}},
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing resolution",
+			body:           `{}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   models.ErrorCodeInvalidRequest,
+		},
+		{
+			name:           "invalid resolution",
+			body:           `{"resolution": "huge"}`,
+			serviceErr:     models.ValidationError{Field: "resolution", Message: "invalid resolution format"},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   models.ErrorCodeInvalidResolution,
+		},
+		{
+			name:           "repository failure",
+			body:           `{"resolution": "400x300"}`,
+			serviceErr:     models.StorageError{Operation: "list_images", Backend: "Repository", Reason: "down"},
+			expectedStatus: http.StatusInternalServerError,
+			expectedCode:   models.ErrorCodeInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockImageService{
+				addResolutionToAllFunc: func(ctx context.Context, resolution string) (*models.BulkResolutionResult, error) {
+					if tt.serviceErr != nil {
+						return nil, tt.serviceErr
+					}
+					if tt.result == nil {
+						return nil, errors.New("unexpected call")
+					}
+					return tt.result, nil
+				},
+			}
+			handler := NewAdminHandler(mockService, middleware.NewMaintenanceMode(false))
+
+			req := testutil.CreateTestRequest("POST", "/api/v1/admin/resolutions", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			c, w := testutil.SetupTestContext(req)
+
+			handler.AddResolutionToAll(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			assert.NoError(t, testutil.ParseJSONResponse(w, &response))
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, float64(1), response["processed"])
+				assert.Equal(t, float64(1), response["skipped"])
+				assert.Equal(t, float64(1), response["failed"])
+				assert.Len(t, response["failures"], 1)
+			} else {
+				assert.Equal(t, tt.expectedCode, response["error_code"])
+			}
+		})
+	}
+}
+
+func TestAdminHandler_Maintenance(t *testing.T) {
+	maintenance := middleware.NewMaintenanceMode(false)
+	handler := NewAdminHandler(&mockImageService{}, maintenance)
+
+	get := func() bool {
+		c, w := testutil.SetupTestContext(testutil.CreateTestRequest("GET", "/api/v1/admin/maintenance", nil))
+		handler.GetMaintenance(c)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var status models.MaintenanceStatus
+		assert.NoError(t, testutil.ParseJSONResponse(w, &status))
+		return status.Enabled
+	}
+	put := func(body string) (int, map[string]interface{}) {
+		req := testutil.CreateTestRequest("PUT", "/api/v1/admin/maintenance", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		c, w := testutil.SetupTestContext(req)
+		handler.SetMaintenance(c)
+
+		var response map[string]interface{}
+		assert.NoError(t, testutil.ParseJSONResponse(w, &response))
+		return w.Code, response
+	}
+
+	assert.False(t, get())
+
+	code, response := put(`{"enabled": true}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, true, response["enabled"])
+	assert.True(t, maintenance.Enabled())
+	assert.True(t, get())
+
+	code, _ = put(`{"enabled": false}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.False(t, get())
+
+	code, response = put(`{}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, models.ErrorCodeInvalidRequest, response["error_code"])
+	assert.False(t, maintenance.Enabled())
+}
+
+func TestAdminHandler_CorruptMetadata(t *testing.T) {
+	report := &models.CorruptMetadataReport{
+		Count:   1,
+		Records: []models.CorruptRecord{{ID: testutil.ValidUUID, Reason: "invalid JSON"}},
+	}
+	mockService := &mockImageService{
+		findCorruptFunc: func(ctx context.Context) (*models.CorruptMetadataReport, error) {
+			return report, nil
+		},
+		removeCorruptFunc: func(ctx context.Context) (*models.CorruptMetadataReport, error) {
+			removed := *report
+			removed.Removed = 1
+			return &removed, nil
+		},
+	}
+	handler := NewAdminHandler(mockService, middleware.NewMaintenanceMode(false))
+
+	t.Run("report", func(t *testing.T) {
+		c, w := testutil.SetupTestContext(testutil.CreateTestRequest("GET", "/api/v1/admin/corrupt-metadata", nil))
+		handler.GetCorruptMetadata(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response models.CorruptMetadataReport
+		assert.NoError(t, testutil.ParseJSONResponse(w, &response))
+		assert.Equal(t, *report, response)
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		c, w := testutil.SetupTestContext(testutil.CreateTestRequest("DELETE", "/api/v1/admin/corrupt-metadata", nil))
+		handler.RemoveCorruptMetadata(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response models.CorruptMetadataReport
+		assert.NoError(t, testutil.ParseJSONResponse(w, &response))
+		assert.Equal(t, 1, response.Removed)
+	})
+
+	t.Run("scan failure", func(t *testing.T) {
+		failing := NewAdminHandler(&mockImageService{
+			findCorruptFunc: func(ctx context.Context) (*models.CorruptMetadataReport, error) {
+				return nil, models.StorageError{Operation: "find_corrupt_metadata", Backend: "Repository", Reason: "down"}
+			},
+		}, middleware.NewMaintenanceMode(false))
+
+		c, w := testutil.SetupTestContext(testutil.CreateTestRequest("GET", "/api/v1/admin/corrupt-metadata", nil))
+		failing.GetCorruptMetadata(c)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		var response map[string]interface{}
+		assert.NoError(t, testutil.ParseJSONResponse(w, &response))
+		assert.Equal(t, models.ErrorCodeStorageUnavailable, response["error_code"])
+	})
+}
+
+func TestAdminHandler_OrphanedHashes(t *testing.T) {
+	report := &models.OrphanedHashReport{
+		Count:  1,
+		Hashes: []models.OrphanedHash{{Hash: models.ImageHash{Algorithm: "SHA256", Value: "aaaa", Size: 10}, MasterImageID: testutil.ValidUUID}},
+	}
+	mockService := &mockImageService{
+		findOrphanedFunc: func(ctx context.Context) (*models.OrphanedHashReport, error) {
+			return report, nil
+		},
+		removeOrphanedFunc: func(ctx context.Context) (*models.OrphanedHashReport, error) {
+			removed := *report
+			removed.Removed = 1
+			return &removed, nil
+		},
+	}
+	handler := NewAdminHandler(mockService, middleware.NewMaintenanceMode(false))
+
+	t.Run("report", func(t *testing.T) {
+		c, w := testutil.SetupTestContext(testutil.CreateTestRequest("GET", "/api/v1/admin/dedup/orphans", nil))
+		handler.GetOrphanedHashes(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response models.OrphanedHashReport
+		assert.NoError(t, testutil.ParseJSONResponse(w, &response))
+		assert.Equal(t, *report, response)
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		c, w := testutil.SetupTestContext(testutil.CreateTestRequest("DELETE", "/api/v1/admin/dedup/orphans", nil))
+		handler.RemoveOrphanedHashes(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response models.OrphanedHashReport
+		assert.NoError(t, testutil.ParseJSONResponse(w, &response))
+		assert.Equal(t, 1, response.Removed)
+	})
+
+	t.Run("scan failure", func(t *testing.T) {
+		failing := NewAdminHandler(&mockImageService{
+			findOrphanedFunc: func(ctx context.Context) (*models.OrphanedHashReport, error) {
+				return nil, models.StorageError{Operation: "find_orphaned_hashes", Backend: "Repository", Reason: "down"}
+			},
+		}, middleware.NewMaintenanceMode(false))
+
+		c, w := testutil.SetupTestContext(testutil.CreateTestRequest("GET", "/api/v1/admin/dedup/orphans", nil))
+		failing.GetOrphanedHashes(c)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		var response map[string]interface{}
+		assert.NoError(t, testutil.ParseJSONResponse(w, &response))
+		assert.Equal(t, models.ErrorCodeStorageUnavailable, response["error_code"])
+	})
+}
+
+func TestAdminHandler_ExportMetadata(t *testing.T) {
+	tests := []struct {
+		name                string
+		query               string
+		serviceErr          error
+		expectedStatus      int
+		expectedContentType string
+	}{
+		{name: "ndjson by default", expectedStatus: http.StatusOK, expectedContentType: "application/x-ndjson"},
+		{name: "json array", query: "?format=json", expectedStatus: http.StatusOK, expectedContentType: "application/json"},
+		{name: "unknown format", query: "?format=csv", expectedStatus: http.StatusBadRequest},
+		{
+			name:           "repository failure before any record",
+			serviceErr:     models.StorageError{Operation: "list_images", Backend: "Repository", Reason: "down"},
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockImageService{
+				exportMetadataFunc: func(ctx context.Context, w io.Writer, format string) (int, error) {
+					if tt.serviceErr != nil {
+						return 0, tt.serviceErr
+					}
+					_, err := io.WriteString(w, `{"id":"`+testutil.ValidUUID+`"}`+"\n")
+					return 1, err
+				},
+			}
+			handler := NewAdminHandler(mockService, middleware.NewMaintenanceMode(false))
+
+			req := testutil.CreateTestRequest("GET", "/api/v1/admin/export"+tt.query, nil)
+			c, w := testutil.SetupTestContext(req)
+
+			handler.ExportMetadata(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, tt.expectedContentType, w.Header().Get("Content-Type"))
+				assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment")
+				assert.Contains(t, w.Body.String(), testutil.ValidUUID)
+			}
+		})
+	}
+}
+
+func TestAdminHandler_ImportMetadata(t *testing.T) {
+	tests := []struct {
+		name           string
+		serviceErr     error
+		expectedStatus int
+	}{
+		{name: "summary returned", expectedStatus: http.StatusOK},
+		{
+			name:           "unreadable body",
+			serviceErr:     models.ValidationError{Field: "body", Message: "token too long"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{name: "unexpected failure", serviceErr: errors.New("boom"), expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received string
+			mockService := &mockImageService{
+				importMetadataFunc: func(ctx context.Context, r io.Reader) (*models.MetadataImportResult, error) {
+					data, _ := io.ReadAll(r)
+					received = string(data)
+					if tt.serviceErr != nil {
+						return nil, tt.serviceErr
+					}
+					return &models.MetadataImportResult{Total: 1, Imported: 1}, nil
+				},
+			}
+			handler := NewAdminHandler(mockService, middleware.NewMaintenanceMode(false))
+
+			body := `{"id":"` + testutil.ValidUUID + `"}` + "\n"
+			req := testutil.CreateTestRequest("POST", "/api/v1/admin/import", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/x-ndjson")
+			c, w := testutil.SetupTestContext(req)
+
+			handler.ImportMetadata(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, body, received)
+			if tt.expectedStatus == http.StatusOK {
+				var response models.MetadataImportResult
+				assert.NoError(t, testutil.ParseJSONResponse(w, &response))
+				assert.Equal(t, 1, response.Imported)
+			}
+		})
+	}
+}
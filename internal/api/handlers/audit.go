@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"resizr/internal/models"
+	"resizr/internal/service"
+	"resizr/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// AuditHandler serves the stored audit trail
+type AuditHandler struct {
+	auditService service.AuditService
+}
+
+// NewAuditHandler creates a new audit handler
+func NewAuditHandler(auditService service.AuditService) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+	}
+}
+
+// List returns the most recent audit entries, newest first
+// GET /api/v1/admin/audit
+func (h *AuditHandler) List(c *gin.Context) {
+	ctx := c.Request.Context()
+	requestID := c.GetString("request_id")
+
+	limit := models.DefaultAuditLogLimit
+	if limitParam := c.Query("limit"); limitParam != "" {
+		parsed, err := strconv.Atoi(limitParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:     "Invalid limit parameter",
+				Message:   "limit must be an integer",
+				Code:      http.StatusBadRequest,
+				ErrorCode: models.ErrorCodeValidationFailed,
+			})
+			return
+		}
+		limit = parsed
+	}
+
+	result, err := h.auditService.List(ctx, limit)
+	if err != nil {
+		if validationErr, ok := err.(models.ValidationError); ok {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:     "Invalid limit parameter",
+				Message:   validationErr.Message,
+				Code:      http.StatusBadRequest,
+				ErrorCode: models.ErrorCodeFor(validationErr),
+			})
+			return
+		}
+
+		logger.ErrorWithContext(ctx, "Failed to list audit entries",
+			zap.Error(err),
+			zap.String("request_id", requestID))
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+			Error:     "Audit log unavailable",
+			Message:   "Failed to read stored audit entries",
+			Code:      http.StatusServiceUnavailable,
+			ErrorCode: models.ErrorCodeFor(err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
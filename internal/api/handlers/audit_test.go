@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"resizr/internal/api/middleware"
+	"resizr/internal/models"
+	"resizr/internal/service"
+	"resizr/internal/testutil"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockAuditService keeps recorded entries and serves them from List
+type mockAuditService struct {
+	entries []models.AuditEntry
+	listErr error
+}
+
+func (m *mockAuditService) Record(ctx context.Context, entry models.AuditEntry) {
+	m.entries = append(m.entries, entry)
+}
+
+func (m *mockAuditService) List(ctx context.Context, limit int) (*models.AuditLogResponse, error) {
+	if m.listErr != nil {
+		return nil, m.listErr
+	}
+	if limit < 1 || limit > models.MaxAuditLogLimit {
+		return nil, models.ValidationError{Field: "limit", Message: "limit out of range"}
+	}
+	return &models.AuditLogResponse{Entries: m.entries, Count: len(m.entries)}, nil
+}
+
+func TestImageHandler_AuditTrail(t *testing.T) {
+	mockService := &mockImageService{
+		processUploadFunc: func(ctx context.Context, input service.UploadInput) (*service.UploadResult, error) {
+			return &service.UploadResult{ImageID: testutil.ValidUUID, ProcessedResolutions: []string{"thumbnail"}}, nil
+		},
+		deleteImageFunc: func(ctx context.Context, imageID string) error {
+			return nil
+		},
+	}
+	handler := NewImageHandler(mockService, testutil.TestConfig())
+	audit := &mockAuditService{}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	authenticated := func(c *gin.Context) {
+		c.Set(middleware.AuthKeyIDKey, "0123456789abcdef")
+		c.Set("request_id", "req-1")
+	}
+	router.POST("/api/v1/images", authenticated, middleware.Audit(audit, "upload"), handler.Upload)
+	router.DELETE("/api/v1/images/:id", authenticated, middleware.Audit(audit, "delete"), handler.Delete)
+
+	upload := testutil.CreateMultipartRequest("POST", "/api/v1/images", nil, "image", "test.jpg", testutil.CreateTestImageData())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, upload)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, testutil.CreateTestRequest("DELETE", "/api/v1/images/"+testutil.ValidUUID, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	require.Len(t, audit.entries, 2)
+	for i, operation := range []string{"upload", "delete"} {
+		entry := audit.entries[i]
+		assert.Equal(t, operation, entry.Operation)
+		assert.Equal(t, testutil.ValidUUID, entry.ImageID, "the uploaded image ID is taken from the handler")
+		assert.Equal(t, "0123456789abcdef", entry.KeyFingerprint)
+		assert.Equal(t, "req-1", entry.RequestID)
+		assert.False(t, entry.Timestamp.IsZero())
+	}
+	assert.Equal(t, http.StatusCreated, audit.entries[0].Status)
+	assert.Equal(t, http.StatusOK, audit.entries[1].Status)
+}
+
+func TestAuditHandler_List(t *testing.T) {
+	audit := &mockAuditService{entries: []models.AuditEntry{
+		{Operation: "delete", ImageID: testutil.ValidUUID, Status: http.StatusOK},
+	}}
+	handler := NewAuditHandler(audit)
+
+	list := func(query string) *httptest.ResponseRecorder {
+		c, w := testutil.SetupTestContext(testutil.CreateTestRequest("GET", "/api/v1/admin/audit"+query, nil))
+		handler.List(c)
+		return w
+	}
+
+	t.Run("entries", func(t *testing.T) {
+		w := list("")
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response models.AuditLogResponse
+		require.NoError(t, testutil.ParseJSONResponse(w, &response))
+		assert.Equal(t, 1, response.Count)
+		assert.Equal(t, "delete", response.Entries[0].Operation)
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, list("?limit=abc").Code)
+		assert.Equal(t, http.StatusBadRequest, list("?limit=0").Code)
+	})
+
+	t.Run("storage failure", func(t *testing.T) {
+		audit.listErr = models.StorageError{Operation: "list_audit_entries", Backend: "Repository", Reason: "down"}
+		assert.Equal(t, http.StatusServiceUnavailable, list("").Code)
+	})
+}
@@ -52,9 +52,10 @@ func (h *AuthHandler) GenerateAPIKey(c *gin.Context) {
 			zap.String("request_id", requestID))
 
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "Key generation failed",
-			Message: "Failed to generate API key",
-			Code:    http.StatusInternalServerError,
+			Error:     "Key generation failed",
+			Message:   "Failed to generate API key",
+			Code:      http.StatusInternalServerError,
+			ErrorCode: models.ErrorCodeInternal,
 		})
 		return
 	}
@@ -90,6 +91,7 @@ func (h *AuthHandler) GetAuthStatus(c *gin.Context) {
 	if h.config.Auth.Enabled {
 		status["read_write_keys_count"] = len(h.config.Auth.ReadWriteKeys)
 		status["read_only_keys_count"] = len(h.config.Auth.ReadOnlyKeys)
+		status["admin_keys_count"] = len(h.config.Auth.AdminKeys)
 	}
 
 	c.JSON(http.StatusOK, status)
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"resizr/internal/models"
+	"resizr/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// respondBodyTooLarge answers 413 when err comes from a request body cut off by the
+// size limit middleware, and reports whether it did
+func respondBodyTooLarge(c *gin.Context, err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	if !errors.As(err, &maxBytesErr) {
+		return false
+	}
+
+	logger.WarnWithContext(c.Request.Context(), "Request body exceeds limit",
+		zap.Int64("max_size", maxBytesErr.Limit),
+		zap.String("request_id", c.GetString("request_id")))
+	c.JSON(http.StatusRequestEntityTooLarge, models.ErrorResponse{
+		Error:     "Request too large",
+		Message:   fmt.Sprintf("Request body exceeds maximum allowed size of %d bytes", maxBytesErr.Limit),
+		Code:      http.StatusRequestEntityTooLarge,
+		ErrorCode: models.ErrorCodeRequestTooLarge,
+	})
+	return true
+}
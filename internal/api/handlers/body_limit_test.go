@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"resizr/internal/api/middleware"
+	"resizr/internal/models"
+	"resizr/internal/testutil"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlers_BodyOverLimit(t *testing.T) {
+	mockService := &mockImageService{
+		importMetadataFunc: func(ctx context.Context, r io.Reader) (*models.MetadataImportResult, error) {
+			_, err := io.ReadAll(r)
+			return nil, fmt.Errorf("failed to read import data: %w", err)
+		},
+	}
+	imageHandler := NewImageHandler(mockService, testutil.TestConfig())
+	adminHandler := NewAdminHandler(mockService, middleware.NewMaintenanceMode(false))
+
+	// Each body is valid for its endpoint, but longer than the 16 byte limit
+	tests := []struct {
+		name    string
+		body    string
+		handler gin.HandlerFunc
+	}{
+		{"upload base64", `{"filename":"a.jpg","data":"` + strings.Repeat("A", 64) + `"}`, imageHandler.UploadBase64},
+		{"update", `{"filename":"renamed-photo.jpg"}`, imageHandler.Update},
+		{"add resolution", `{"resolution":"800x600"}`, imageHandler.AddResolution},
+		{"exists", `{"hash":"` + strings.Repeat("a", 64) + `"}`, imageHandler.Exists},
+		{"crop", `{"x":0,"y":0,"width":100,"height":100}`, imageHandler.Crop},
+		{"estimate", `{"resolution":"800x600","mode":"fit"}`, imageHandler.Estimate},
+		{"bulk add resolution", `{"resolution":"800x600"}`, adminHandler.AddResolutionToAll},
+		{"import metadata", `{"id":"` + testutil.ValidUUID + `"}`, adminHandler.ImportMetadata},
+		{"set maintenance", `{ "enabled" : true }`, adminHandler.SetMaintenance},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := testutil.CreateTestRequest("POST", "/api/v1/test", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			c, w := testutil.SetupTestContext(req)
+			c.AddParam("id", testutil.ValidUUID)
+			c.Request.Body = http.MaxBytesReader(w, c.Request.Body, 16)
+
+			tt.handler(c)
+
+			assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+			var response models.ErrorResponse
+			require.NoError(t, testutil.ParseJSONResponse(w, &response))
+			assert.Equal(t, models.ErrorCodeRequestTooLarge, response.ErrorCode)
+		})
+	}
+}
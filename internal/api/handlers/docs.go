@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"io/fs"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// swaggerUIPage renders Swagger UI against the embedded OpenAPI spec, loading the
+// pinned Swagger UI files from the binary rather than a CDN
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8" />
+  <title>RESIZR API Documentation</title>
+  <link rel="stylesheet" href="/docs/assets/swagger-ui.css" />
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="/docs/assets/swagger-ui-bundle.js"></script>
+  <script>
+    window.onload = function () {
+      window.ui = SwaggerUIBundle({
+        url: "/openapi.yaml",
+        dom_id: "#swagger-ui",
+      });
+    };
+  </script>
+</body>
+</html>
+`
+
+// DocsHandler serves the OpenAPI specification and Swagger UI
+type DocsHandler struct {
+	spec   []byte
+	assets http.FileSystem
+}
+
+// NewDocsHandler creates a new docs handler for the given OpenAPI spec and the
+// Swagger UI files in assets
+func NewDocsHandler(spec []byte, assets fs.FS) *DocsHandler {
+	return &DocsHandler{
+		spec:   spec,
+		assets: http.FS(assets),
+	}
+}
+
+// OpenAPISpec serves the raw OpenAPI specification
+// GET /openapi.yaml
+func (h *DocsHandler) OpenAPISpec(c *gin.Context) {
+	c.Data(http.StatusOK, "application/yaml; charset=utf-8", h.spec)
+}
+
+// SwaggerUI serves the interactive API documentation page
+// GET /docs
+func (h *DocsHandler) SwaggerUI(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUIPage))
+}
+
+// SwaggerUIAsset serves one of the Swagger UI files embedded in the binary
+// GET /docs/assets/:file
+func (h *DocsHandler) SwaggerUIAsset(c *gin.Context) {
+	// The files only change with a new release, so browsers may keep them
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.FileFromFS(c.Param("file"), h.assets)
+}
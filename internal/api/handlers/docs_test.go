@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"resizr"
+	"resizr/internal/testutil"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocsHandler_OpenAPISpec(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := NewDocsHandler(resizr.OpenAPISpec, resizr.SwaggerUIAssets)
+
+	req := httptest.NewRequest("GET", "/openapi.yaml", nil)
+	c, w := testutil.SetupTestContext(req)
+
+	handler.OpenAPISpec(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/yaml; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "openapi: 3.0.3")
+	assert.Equal(t, resizr.OpenAPISpec, w.Body.Bytes())
+}
+
+func TestDocsHandler_SwaggerUI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := NewDocsHandler([]byte("openapi: 3.0.3"), resizr.SwaggerUIAssets)
+
+	req := httptest.NewRequest("GET", "/docs", nil)
+	c, w := testutil.SetupTestContext(req)
+
+	handler.SwaggerUI(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "swagger-ui")
+	assert.Contains(t, w.Body.String(), `url: "/openapi.yaml"`)
+	assert.Contains(t, w.Body.String(), `src="/docs/assets/swagger-ui-bundle.js"`)
+	assert.Contains(t, w.Body.String(), `href="/docs/assets/swagger-ui.css"`)
+	assert.NotContains(t, w.Body.String(), "https://", "the page must not load anything from a CDN")
+}
+
+func TestDocsHandler_SwaggerUIAsset(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/docs/assets/:file", NewDocsHandler(resizr.OpenAPISpec, resizr.SwaggerUIAssets).SwaggerUIAsset)
+
+	tests := []struct {
+		file           string
+		expectedStatus int
+		expectedType   string
+		expectedBody   string
+	}{
+		{"swagger-ui-bundle.js", http.StatusOK, "text/javascript; charset=utf-8", "SwaggerUIBundle"},
+		{"swagger-ui.css", http.StatusOK, "text/css; charset=utf-8", ".swagger-ui"},
+		{"swagger-ui.js", http.StatusNotFound, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/docs/assets/"+tt.file, nil))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			assert.Equal(t, tt.expectedType, w.Header().Get("Content-Type"))
+			assert.Equal(t, "public, max-age=86400", w.Header().Get("Cache-Control"))
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+		})
+	}
+}
+
+func TestNewDocsHandler(t *testing.T) {
+	spec := []byte("openapi: 3.0.3")
+	handler := NewDocsHandler(spec, resizr.SwaggerUIAssets)
+
+	assert.NotNil(t, handler)
+	assert.Equal(t, spec, handler.spec)
+}
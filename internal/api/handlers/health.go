@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"time"
 
+	"resizr/internal/api/middleware"
 	"resizr/internal/models"
 	"resizr/internal/service"
 	"resizr/pkg/logger"
@@ -94,6 +95,54 @@ func (h *HealthHandler) Health(c *gin.Context) {
 	c.JSON(statusCode, response)
 }
 
+// Readiness reports whether the service should receive traffic
+// GET /readyz
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	if !h.healthService.IsReady() {
+		logger.DebugWithContext(c.Request.Context(), "Readiness probe: not ready",
+			zap.String("request_id", c.GetString("request_id")))
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status":    "not_ready",
+			"timestamp": time.Now(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "ready",
+		"timestamp": time.Now(),
+	})
+}
+
+// ResetCacheStats zeroes the cache hit/miss counters to start a new measurement window
+// POST /api/v1/admin/cache-stats/reset
+func (h *HealthHandler) ResetCacheStats(c *gin.Context) {
+	ctx := c.Request.Context()
+	requestID := c.GetString("request_id")
+
+	if err := h.healthService.ResetCacheStats(ctx); err != nil {
+		logger.ErrorWithContext(ctx, "Failed to reset cache statistics",
+			zap.Error(err),
+			zap.String("request_id", requestID))
+
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:     "Cache statistics reset failed",
+			Message:   err.Error(),
+			Code:      http.StatusInternalServerError,
+			ErrorCode: models.ErrorCodeInternal,
+		})
+		return
+	}
+
+	logger.InfoWithContext(ctx, "Cache statistics reset by admin",
+		zap.String("request_id", requestID))
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "reset",
+		"timestamp": time.Now(),
+	})
+}
+
 // Metrics handles the metrics endpoint (debug only)
 // GET /debug/vars
 func (h *HealthHandler) Metrics(c *gin.Context) {
@@ -110,12 +159,17 @@ func (h *HealthHandler) Metrics(c *gin.Context) {
 			zap.String("request_id", requestID))
 
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "Metrics unavailable",
-			Message: err.Error(),
-			Code:    http.StatusInternalServerError,
+			Error:     "Metrics unavailable",
+			Message:   err.Error(),
+			Code:      http.StatusInternalServerError,
+			ErrorCode: models.ErrorCodeInternal,
 		})
 		return
 	}
 
+	metrics["http"] = map[string]interface{}{
+		"panics_recovered": middleware.PanicsRecovered(),
+	}
+
 	c.JSON(http.StatusOK, metrics)
 }
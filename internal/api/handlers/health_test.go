@@ -18,6 +18,8 @@ import (
 type mockHealthService struct {
 	checkHealthFunc func(ctx context.Context) (*service.HealthStatus, error)
 	getMetricsFunc  func(ctx context.Context) (map[string]interface{}, error)
+	resetStatsFunc  func(ctx context.Context) error
+	ready           bool
 }
 
 func (m *mockHealthService) CheckHealth(ctx context.Context) (*service.HealthStatus, error) {
@@ -34,6 +36,19 @@ func (m *mockHealthService) GetMetrics(ctx context.Context) (map[string]interfac
 	return nil, nil
 }
 
+func (m *mockHealthService) ResetCacheStats(ctx context.Context) error {
+	if m.resetStatsFunc != nil {
+		return m.resetStatsFunc(ctx)
+	}
+	return nil
+}
+
+func (m *mockHealthService) IsReady() bool { return m.ready }
+
+func (m *mockHealthService) CheckReadiness(ctx context.Context) bool { return m.ready }
+
+func (m *mockHealthService) StartReadinessChecks(ctx context.Context) {}
+
 func TestHealthHandler_Health(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -242,6 +257,7 @@ func TestHealthHandler_Metrics(t *testing.T) {
 				assert.Contains(t, response, "requests")
 				assert.Contains(t, response, "errors")
 				assert.Contains(t, response, "memory_usage")
+				assert.Contains(t, response, "http", "panic counter should be exposed")
 			}
 		})
 	}
@@ -334,3 +350,63 @@ func TestHealthHandler_EdgeCases(t *testing.T) {
 		assert.Equal(t, "healthy", response["status"])
 	})
 }
+
+func TestHealthHandler_Readiness(t *testing.T) {
+	tests := []struct {
+		name           string
+		ready          bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "ready", ready: true, expectedStatus: http.StatusOK, expectedBody: "ready"},
+		{name: "not ready", ready: false, expectedStatus: http.StatusServiceUnavailable, expectedBody: "not_ready"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHealthHandler(&mockHealthService{ready: tt.ready})
+
+			req := testutil.CreateTestRequest("GET", "/readyz", nil)
+			c, w := testutil.SetupTestContext(req)
+
+			handler.Readiness(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			assert.NoError(t, testutil.ParseJSONResponse(w, &response))
+			assert.Equal(t, tt.expectedBody, response["status"])
+		})
+	}
+}
+
+func TestHealthHandler_ResetCacheStats(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+	}{
+		{name: "reset", expectedStatus: http.StatusOK},
+		{name: "unsupported", err: errors.New("repository does not support resetting cache statistics"), expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			handler := NewHealthHandler(&mockHealthService{
+				resetStatsFunc: func(ctx context.Context) error {
+					calls++
+					return tt.err
+				},
+			})
+
+			req := testutil.CreateTestRequest("POST", "/api/v1/admin/cache-stats/reset", nil)
+			c, w := testutil.SetupTestContext(req)
+
+			handler.ResetCacheStats(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, 1, calls)
+		})
+	}
+}
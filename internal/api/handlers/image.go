@@ -1,13 +1,19 @@
 package handlers
 
 import (
+	"archive/zip"
+	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
+	"resizr/internal/api/middleware"
 	"resizr/internal/config"
 	"resizr/internal/models"
 	"resizr/internal/service"
@@ -17,10 +23,21 @@ import (
 	"go.uber.org/zap"
 )
 
+// ChecksumHeader carries an optional client-computed SHA256 of the uploaded file
+const ChecksumHeader = "X-Checksum-SHA256"
+
+// ResolutionSubstitutedHeader names the resolution served in place of a missing one
+const ResolutionSubstitutedHeader = "X-Resolution-Substituted"
+
+// ImageIDHeader names the stored image a conditional upload matched
+const ImageIDHeader = "X-Image-ID"
+
 // ImageHandler handles image-related HTTP requests
 type ImageHandler struct {
 	imageService service.ImageService
 	config       *config.Config
+	presignCache *presignedURLCache
+	placeholder  *missingImagePlaceholder // nil when missing images answer 404
 }
 
 // NewImageHandler creates a new image handler
@@ -28,6 +45,8 @@ func NewImageHandler(imageService service.ImageService, config *config.Config) *
 	return &ImageHandler{
 		imageService: imageService,
 		config:       config,
+		presignCache: newPresignedURLCache(config.S3.URLCacheTTL, config.S3.URLCacheTTLByResolution),
+		placeholder:  newMissingImagePlaceholder(config),
 	}
 }
 
@@ -41,182 +60,1114 @@ func (h *ImageHandler) Upload(c *gin.Context) {
 		zap.String("request_id", requestID),
 		zap.String("client_ip", c.ClientIP()))
 
-	// Parse multipart form
-	if err := c.Request.ParseMultipartForm(h.config.Image.MaxFileSize); err != nil {
-		logger.ErrorWithContext(ctx, "Failed to parse multipart form",
-			zap.Error(err),
-			zap.String("request_id", requestID))
+	if h.uploadAlreadyStored(c, requestID) {
+		return
+	}
+
+	// Parse multipart form
+	if err := c.Request.ParseMultipartForm(h.config.Image.MaxFileSize); err != nil {
+		if respondBodyTooLarge(c, err) {
+			return
+		}
+
+		logger.ErrorWithContext(ctx, "Failed to parse multipart form",
+			zap.Error(err),
+			zap.String("request_id", requestID))
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:     "Invalid form data",
+			Message:   "Failed to parse multipart form",
+			Code:      http.StatusBadRequest,
+			ErrorCode: models.ErrorCodeInvalidRequest,
+		})
+		return
+	}
+
+	// Get uploaded file from the configured form field
+	fieldName := h.config.Image.UploadFieldName
+	if fieldName == "" {
+		fieldName = "image"
+	}
+	file, header, err := c.Request.FormFile(fieldName)
+	if err != nil {
+		logger.ErrorWithContext(ctx, "No image file in request",
+			zap.Error(err),
+			zap.String("field", fieldName),
+			zap.String("request_id", requestID))
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:     "Missing image file",
+			Message:   fmt.Sprintf("Request must contain an '%s' file field", fieldName),
+			Code:      http.StatusBadRequest,
+			ErrorCode: models.ErrorCodeMissingFile,
+		})
+		return
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			logger.WarnWithContext(ctx, "Failed to close file", zap.String("error", err.Error()))
+		}
+	}()
+
+	// Validate file size
+	if header.Size > h.config.Image.MaxFileSize {
+		logger.WarnWithContext(ctx, "File size exceeds limit",
+			zap.Int64("file_size", header.Size),
+			zap.Int64("max_size", h.config.Image.MaxFileSize),
+			zap.String("request_id", requestID))
+		c.JSON(http.StatusRequestEntityTooLarge, models.ErrorResponse{
+			Error:     "File too large",
+			Message:   fmt.Sprintf("File size %d bytes exceeds limit of %d bytes", header.Size, h.config.Image.MaxFileSize),
+			Code:      http.StatusRequestEntityTooLarge,
+			ErrorCode: models.ErrorCodeFileTooLarge,
+		})
+		return
+	}
+
+	// Parse additional resolutions from form
+	var req models.UploadRequest
+
+	// Get resolutions from form - handle both single and multiple field approaches
+	if values := c.Request.Form["resolutions"]; len(values) > 0 {
+		// Handle both multiple fields and comma-separated values
+		var allResolutions []string
+		for _, value := range values {
+			// Split each value by comma in case it contains multiple resolutions
+			splitValues := strings.Split(value, ",")
+			for _, splitValue := range splitValues {
+				trimmed := strings.TrimSpace(splitValue)
+				if trimmed != "" {
+					allResolutions = append(allResolutions, trimmed)
+				}
+			}
+		}
+		req.Resolutions = allResolutions
+	} else if err := c.ShouldBind(&req); err != nil {
+		logger.WarnWithContext(ctx, "Invalid resolution parameters",
+			zap.Error(err),
+			zap.String("request_id", requestID))
+		// Continue with empty resolutions - this is optional
+	}
+
+	// Read file data
+	fileData, err := io.ReadAll(file)
+	if err != nil {
+		logger.ErrorWithContext(ctx, "Failed to read file data",
+			zap.Error(err),
+			zap.String("request_id", requestID))
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:     "File read error",
+			Message:   "Failed to read uploaded file",
+			Code:      http.StatusInternalServerError,
+			ErrorCode: models.ErrorCodeInternal,
+		})
+		return
+	}
+
+	// Deduplication can be turned off per upload with dedup=false
+	disableDedup := false
+	if dedupValue := c.Request.FormValue("dedup"); dedupValue != "" {
+		parsed, err := strconv.ParseBool(dedupValue)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:     "Invalid dedup parameter",
+				Message:   "dedup must be true or false",
+				Code:      http.StatusBadRequest,
+				ErrorCode: models.ErrorCodeInvalidRequest,
+			})
+			return
+		}
+		disableDedup = !parsed
+	}
+
+	// The text watermark can be turned off per upload with watermark=false
+	disableWatermark := false
+	if watermarkValue := c.Request.FormValue("watermark"); watermarkValue != "" {
+		parsed, err := strconv.ParseBool(watermarkValue)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:     "Invalid watermark parameter",
+				Message:   "watermark must be true or false",
+				Code:      http.StatusBadRequest,
+				ErrorCode: models.ErrorCodeInvalidRequest,
+			})
+			return
+		}
+		disableWatermark = !parsed
+	}
+
+	// Optional client-provided SHA256, from the header or the form
+	expectedChecksum := c.GetHeader(ChecksumHeader)
+	if expectedChecksum == "" {
+		expectedChecksum = c.Request.FormValue("checksum")
+	}
+
+	// Process upload through service layer
+	result, err := h.imageService.ProcessUpload(ctx, service.UploadInput{
+		Filename:         header.Filename,
+		Data:             fileData,
+		Size:             header.Size,
+		Resolutions:      req.Resolutions,
+		ExpectedChecksum: expectedChecksum,
+		DisableDedup:     disableDedup,
+		DisableWatermark: disableWatermark,
+		Namespace:        h.dedupNamespace(c),
+	})
+
+	if err != nil {
+		h.handleServiceError(c, err, requestID, "upload failed")
+		return
+	}
+
+	h.respondUploaded(c, result, header.Filename, header.Size, requestID)
+}
+
+// UploadBase64 handles JSON uploads carrying the image as base64
+// POST /api/v1/images/base64
+func (h *ImageHandler) UploadBase64(c *gin.Context) {
+	ctx := c.Request.Context()
+	requestID := c.GetString("request_id")
+
+	logger.InfoWithContext(ctx, "Processing base64 image upload",
+		zap.String("request_id", requestID),
+		zap.String("client_ip", c.ClientIP()))
+
+	var req models.Base64UploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if respondBodyTooLarge(c, err) {
+			return
+		}
+
+		logger.WarnWithContext(ctx, "Invalid base64 upload body",
+			zap.Error(err),
+			zap.String("request_id", requestID))
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:     "Invalid request body",
+			Message:   "Request body must be JSON with 'filename' and 'data' fields",
+			Code:      http.StatusBadRequest,
+			ErrorCode: models.ErrorCodeInvalidRequest,
+		})
+		return
+	}
+
+	// Accept data URLs ("data:image/png;base64,...") as well as bare base64
+	encoded := strings.TrimSpace(req.Data)
+	if strings.HasPrefix(encoded, "data:") {
+		if comma := strings.IndexByte(encoded, ','); comma >= 0 && strings.HasSuffix(encoded[:comma], ";base64") {
+			encoded = encoded[comma+1:]
+		}
+	}
+
+	// Reject oversized payloads before allocating the decoded buffer
+	maxSize := h.config.Image.MaxFileSize
+	if int64(base64.StdEncoding.DecodedLen(len(encoded)))-2 > maxSize {
+		c.JSON(http.StatusRequestEntityTooLarge, models.ErrorResponse{
+			Error:     "File too large",
+			Message:   fmt.Sprintf("Decoded image exceeds limit of %d bytes", maxSize),
+			Code:      http.StatusRequestEntityTooLarge,
+			ErrorCode: models.ErrorCodeFileTooLarge,
+		})
+		return
+	}
+
+	fileData, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		logger.WarnWithContext(ctx, "Malformed base64 image data",
+			zap.Error(err),
+			zap.String("request_id", requestID))
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:     "Invalid image data",
+			Message:   "Field 'data' must be standard base64-encoded image bytes",
+			Code:      http.StatusBadRequest,
+			ErrorCode: models.ErrorCodeInvalidRequest,
+		})
+		return
+	}
+	if len(fileData) == 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:     "Missing image file",
+			Message:   "Field 'data' decodes to an empty file",
+			Code:      http.StatusBadRequest,
+			ErrorCode: models.ErrorCodeMissingFile,
+		})
+		return
+	}
+	size := int64(len(fileData))
+	if size > maxSize {
+		c.JSON(http.StatusRequestEntityTooLarge, models.ErrorResponse{
+			Error:     "File too large",
+			Message:   fmt.Sprintf("File size %d bytes exceeds limit of %d bytes", size, maxSize),
+			Code:      http.StatusRequestEntityTooLarge,
+			ErrorCode: models.ErrorCodeFileTooLarge,
+		})
+		return
+	}
+
+	// Resolutions may be listed individually or comma-separated, as in multipart uploads
+	var resolutions []string
+	for _, value := range req.Resolutions {
+		for _, splitValue := range strings.Split(value, ",") {
+			if trimmed := strings.TrimSpace(splitValue); trimmed != "" {
+				resolutions = append(resolutions, trimmed)
+			}
+		}
+	}
+
+	result, err := h.imageService.ProcessUpload(ctx, service.UploadInput{
+		Filename:         req.Filename,
+		Data:             fileData,
+		Size:             size,
+		Resolutions:      resolutions,
+		ExpectedChecksum: c.GetHeader(ChecksumHeader),
+		Namespace:        h.dedupNamespace(c),
+	})
+	if err != nil {
+		h.handleServiceError(c, err, requestID, "base64 upload failed")
+		return
+	}
+
+	h.respondUploaded(c, result, req.Filename, size, requestID)
+}
+
+// uploadAlreadyStored answers 304 when If-None-Match carries the SHA256 of content
+// already stored in the request's namespace, so sync tools do not create another
+// image record for it. Only deduplicated content is indexed by hash. It reports
+// whether a response was written.
+func (h *ImageHandler) uploadAlreadyStored(c *gin.Context, requestID string) bool {
+	value := strings.TrimSpace(c.GetHeader("If-None-Match"))
+	if value == "" {
+		return false
+	}
+
+	checksum := strings.Trim(strings.TrimPrefix(value, "W/"), `"`)
+	result, err := h.imageService.FindByHash(c.Request.Context(), checksum, 0, h.dedupNamespace(c))
+	if err != nil {
+		h.handleServiceError(c, err, requestID, "conditional upload lookup failed")
+		return true
+	}
+	if !result.Exists {
+		return false
+	}
+
+	logger.InfoWithContext(c.Request.Context(), "Conditional upload matched stored content",
+		zap.String("image_id", result.ImageID),
+		zap.String("hash", result.Hash),
+		zap.String("request_id", requestID))
+
+	c.Header("ETag", `"`+result.Hash+`"`)
+	c.Header(ImageIDHeader, result.ImageID)
+	c.AbortWithStatus(http.StatusNotModified)
+	return true
+}
+
+// respondUploaded writes the 201 response shared by multipart and base64 uploads
+func (h *ImageHandler) respondUploaded(c *gin.Context, result *service.UploadResult, filename string, size int64, requestID string) {
+	logger.InfoWithContext(c.Request.Context(), "Image upload completed successfully",
+		zap.String("image_id", result.ImageID),
+		zap.String("filename", filename),
+		zap.Int64("size", size),
+		zap.Strings("resolutions", result.ProcessedResolutions),
+		zap.String("request_id", requestID))
+
+	response := models.UploadResponse{
+		ID:                result.ImageID,
+		Message:           "Image uploaded successfully",
+		Resolutions:       result.ProcessedResolutions,
+		FailedResolutions: result.FailedResolutions,
+		QueuedResolutions: result.QueuedResolutions,
+
+		ContentTypeMismatch: result.ContentTypeMismatch,
+	}
+
+	c.Set(middleware.AuditImageIDKey, result.ImageID)
+	c.JSON(http.StatusCreated, response)
+}
+
+// Info handles image metadata requests
+// GET /api/v1/images/:id/info
+func (h *ImageHandler) Info(c *gin.Context) {
+	ctx := c.Request.Context()
+	requestID := c.GetString("request_id")
+	imageID := c.Param("id")
+
+	logger.DebugWithContext(ctx, "Getting image info",
+		zap.String("image_id", imageID),
+		zap.String("request_id", requestID))
+
+	// Validate UUID format
+	if !h.isValidUUID(imageID) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:     "Invalid image ID",
+			Message:   "Image ID must be a valid UUID",
+			Code:      http.StatusBadRequest,
+			ErrorCode: models.ErrorCodeInvalidImageID,
+		})
+		return
+	}
+
+	// Get image metadata
+	metadata, err := h.imageService.GetMetadata(ctx, imageID)
+	if err != nil {
+		h.handleServiceError(c, err, requestID, "get metadata failed")
+		return
+	}
+
+	// Convert to API response
+	response := metadata.ToInfoResponse()
+
+	// Download counters are informational, so the info is still served without them
+	access, err := h.imageService.GetAccessCounts(ctx, imageID)
+	if err != nil {
+		logger.WarnWithContext(ctx, "Failed to get access counts",
+			zap.String("image_id", imageID),
+			zap.String("request_id", requestID),
+			zap.Error(err))
+	}
+	response.Access = access
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Update handles image metadata update requests
+// PATCH /api/v1/images/:id
+func (h *ImageHandler) Update(c *gin.Context) {
+	ctx := c.Request.Context()
+	requestID := c.GetString("request_id")
+	imageID := c.Param("id")
+
+	logger.InfoWithContext(ctx, "Processing image metadata update",
+		zap.String("image_id", imageID),
+		zap.String("request_id", requestID))
+
+	// Validate UUID format
+	if !h.isValidUUID(imageID) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:     "Invalid image ID",
+			Message:   "Image ID must be a valid UUID",
+			Code:      http.StatusBadRequest,
+			ErrorCode: models.ErrorCodeInvalidImageID,
+		})
+		return
+	}
+
+	var req models.UpdateImageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if respondBodyTooLarge(c, err) {
+			return
+		}
+		logger.WarnWithContext(ctx, "Invalid update request body",
+			zap.Error(err),
+			zap.String("request_id", requestID))
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:     "Invalid request body",
+			Message:   "Request body must be JSON with a 'filename' field",
+			Code:      http.StatusBadRequest,
+			ErrorCode: models.ErrorCodeInvalidRequest,
+		})
+		return
+	}
+
+	metadata, err := h.imageService.UpdateFilename(ctx, imageID, req.Filename)
+	if err != nil {
+		h.handleServiceError(c, err, requestID, "update metadata failed")
+		return
+	}
+
+	logger.InfoWithContext(ctx, "Image metadata updated successfully",
+		zap.String("image_id", imageID),
+		zap.String("filename", metadata.Filename),
+		zap.String("request_id", requestID))
+
+	c.JSON(http.StatusOK, metadata.ToInfoResponse())
+}
+
+// AddResolution generates a resolution for an existing image
+// POST /api/v1/images/:id/resolutions?force=true
+func (h *ImageHandler) AddResolution(c *gin.Context) {
+	ctx := c.Request.Context()
+	requestID := c.GetString("request_id")
+	imageID := c.Param("id")
+
+	// Validate UUID format
+	if !h.isValidUUID(imageID) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:     "Invalid image ID",
+			Message:   "Image ID must be a valid UUID",
+			Code:      http.StatusBadRequest,
+			ErrorCode: models.ErrorCodeInvalidImageID,
+		})
+		return
+	}
+
+	var req models.AddResolutionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if respondBodyTooLarge(c, err) {
+			return
+		}
+		logger.WarnWithContext(ctx, "Invalid add resolution request body",
+			zap.Error(err),
+			zap.String("request_id", requestID))
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:     "Invalid request body",
+			Message:   "Request body must be JSON with a 'resolution' field",
+			Code:      http.StatusBadRequest,
+			ErrorCode: models.ErrorCodeInvalidRequest,
+		})
+		return
+	}
+
+	// Optional force flag regenerates an existing resolution
+	force := false
+	if forceParam := c.Query("force"); forceParam != "" {
+		parsed, err := strconv.ParseBool(forceParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:     "Invalid force parameter",
+				Message:   "force must be true or false",
+				Code:      http.StatusBadRequest,
+				ErrorCode: models.ErrorCodeInvalidRequest,
+			})
+			return
+		}
+		force = parsed
+	}
+
+	logger.InfoWithContext(ctx, "Processing add resolution request",
+		zap.String("image_id", imageID),
+		zap.String("resolution", req.Resolution),
+		zap.Bool("force", force),
+		zap.Int64("max_bytes", req.MaxBytes),
+		zap.String("request_id", requestID))
+
+	// A size budget stores the result as its own "<resolution>-max<bytes>" resolution
+	if req.MaxBytes != 0 {
+		if _, err := h.imageService.ProcessResolutionWithinSize(ctx, imageID, req.Resolution, req.MaxBytes, force); err != nil {
+			h.handleServiceError(c, err, requestID, "add size-capped resolution failed")
+			return
+		}
+	} else if err := h.imageService.ProcessResolution(ctx, imageID, req.Resolution, force); err != nil {
+		h.handleServiceError(c, err, requestID, "add resolution failed")
+		return
+	}
+
+	metadata, err := h.imageService.GetMetadata(ctx, imageID)
+	if err != nil {
+		h.handleServiceError(c, err, requestID, "get metadata failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, metadata.ToInfoResponse())
+}
+
+// Exists reports whether content with the given hash is already stored, so sync
+// clients can skip the upload
+// POST /api/v1/images/exists
+func (h *ImageHandler) Exists(c *gin.Context) {
+	ctx := c.Request.Context()
+	requestID := c.GetString("request_id")
+
+	var req models.ExistsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if respondBodyTooLarge(c, err) {
+			return
+		}
+		logger.WarnWithContext(ctx, "Invalid exists request body",
+			zap.Error(err),
+			zap.String("request_id", requestID))
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:     "Invalid request body",
+			Message:   "Request body must be JSON with a 'hash' field and an optional 'size' field",
+			Code:      http.StatusBadRequest,
+			ErrorCode: models.ErrorCodeInvalidRequest,
+		})
+		return
+	}
+
+	result, err := h.imageService.FindByHash(ctx, req.Hash, req.Size, h.dedupNamespace(c))
+	if err != nil {
+		h.handleServiceError(c, err, requestID, "hash lookup failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// FindByFilename returns the IDs of images uploaded under an original filename
+// GET /api/v1/images/by-filename/:name
+func (h *ImageHandler) FindByFilename(c *gin.Context) {
+	ctx := c.Request.Context()
+	requestID := c.GetString("request_id")
+
+	result, err := h.imageService.FindByFilename(ctx, c.Param("name"))
+	if err != nil {
+		h.handleServiceError(c, err, requestID, "filename lookup failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// StorageKeys returns the object keys an image's files are stored under
+// GET /api/v1/images/:id/keys
+func (h *ImageHandler) StorageKeys(c *gin.Context) {
+	ctx := c.Request.Context()
+	requestID := c.GetString("request_id")
+	imageID := c.Param("id")
+
+	if !h.isValidUUID(imageID) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:     "Invalid image ID",
+			Message:   "Image ID must be a valid UUID",
+			Code:      http.StatusBadRequest,
+			ErrorCode: models.ErrorCodeInvalidImageID,
+		})
+		return
+	}
+
+	result, err := h.imageService.GetStorageKeys(ctx, imageID)
+	if err != nil {
+		h.handleServiceError(c, err, requestID, "get storage keys failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// Verify checks that an image's stored files are intact
+// GET /api/v1/images/:id/verify
+func (h *ImageHandler) Verify(c *gin.Context) {
+	ctx := c.Request.Context()
+	requestID := c.GetString("request_id")
+	imageID := c.Param("id")
+
+	if !h.isValidUUID(imageID) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:     "Invalid image ID",
+			Message:   "Image ID must be a valid UUID",
+			Code:      http.StatusBadRequest,
+			ErrorCode: models.ErrorCodeInvalidImageID,
+		})
+		return
+	}
+
+	// Optional hash flag downloads the original and compares it to the recorded hash
+	checkHash := false
+	if hashParam := c.Query("hash"); hashParam != "" {
+		parsed, err := strconv.ParseBool(hashParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:     "Invalid hash parameter",
+				Message:   "hash must be true or false",
+				Code:      http.StatusBadRequest,
+				ErrorCode: models.ErrorCodeInvalidRequest,
+			})
+			return
+		}
+		checkHash = parsed
+	}
+
+	result, err := h.imageService.VerifyImage(ctx, imageID, checkHash)
+	if err != nil {
+		h.handleServiceError(c, err, requestID, "verify image failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// PurgeCache drops the cached metadata and presigned URLs of an image, after its
+// stored files were changed outside the service
+// POST /api/v1/images/:id/cache/purge
+func (h *ImageHandler) PurgeCache(c *gin.Context) {
+	ctx := c.Request.Context()
+	requestID := c.GetString("request_id")
+	imageID := c.Param("id")
+
+	if !h.isValidUUID(imageID) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:     "Invalid image ID",
+			Message:   "Image ID must be a valid UUID",
+			Code:      http.StatusBadRequest,
+			ErrorCode: models.ErrorCodeInvalidImageID,
+		})
+		return
+	}
+
+	if err := h.imageService.PurgeImageCache(ctx, imageID); err != nil {
+		h.handleServiceError(c, err, requestID, "purge image cache failed")
+		return
+	}
+	purged := h.presignCache.purgeImage(imageID)
+
+	logger.InfoWithContext(ctx, "Image cache purge completed",
+		zap.String("image_id", imageID),
+		zap.Int("presigned_urls_purged", purged),
+		zap.String("request_id", requestID))
+
+	c.JSON(http.StatusOK, models.CachePurgeResponse{
+		ID:                  imageID,
+		PresignedURLsPurged: purged,
+	})
+}
+
+// Crop stores a rectangle of the original as a new resolution
+// POST /api/v1/images/:id/crop
+func (h *ImageHandler) Crop(c *gin.Context) {
+	ctx := c.Request.Context()
+	requestID := c.GetString("request_id")
+	imageID := c.Param("id")
+
+	// Validate UUID format
+	if !h.isValidUUID(imageID) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:     "Invalid image ID",
+			Message:   "Image ID must be a valid UUID",
+			Code:      http.StatusBadRequest,
+			ErrorCode: models.ErrorCodeInvalidImageID,
+		})
+		return
+	}
+
+	var rect models.CropRect
+	if err := c.ShouldBindJSON(&rect); err != nil {
+		if respondBodyTooLarge(c, err) {
+			return
+		}
+		logger.WarnWithContext(ctx, "Invalid crop request body",
+			zap.Error(err),
+			zap.String("request_id", requestID))
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:     "Invalid request body",
+			Message:   "Request body must be JSON with 'x', 'y', 'width' and 'height' fields",
+			Code:      http.StatusBadRequest,
+			ErrorCode: models.ErrorCodeInvalidRequest,
+		})
+		return
+	}
+
+	logger.InfoWithContext(ctx, "Processing crop request",
+		zap.String("image_id", imageID),
+		zap.String("rect", rect.String()),
+		zap.String("request_id", requestID))
+
+	resolution, err := h.imageService.CropImage(ctx, imageID, rect)
+	if err != nil {
+		h.handleServiceError(c, err, requestID, "crop failed")
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.CropResponse{
+		ID:         imageID,
+		Resolution: resolution,
+		Dimensions: models.DimensionInfo{Width: rect.Width, Height: rect.Height},
+	})
+}
+
+// Estimate predicts the output dimensions of a resolution without generating it
+// POST /api/v1/images/:id/estimate
+func (h *ImageHandler) Estimate(c *gin.Context) {
+	ctx := c.Request.Context()
+	requestID := c.GetString("request_id")
+	imageID := c.Param("id")
+
+	// Validate UUID format
+	if !h.isValidUUID(imageID) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:     "Invalid image ID",
+			Message:   "Image ID must be a valid UUID",
+			Code:      http.StatusBadRequest,
+			ErrorCode: models.ErrorCodeInvalidImageID,
+		})
+		return
+	}
+
+	var req models.EstimateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if respondBodyTooLarge(c, err) {
+			return
+		}
+		logger.WarnWithContext(ctx, "Invalid estimate request body",
+			zap.Error(err),
+			zap.String("request_id", requestID))
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:     "Invalid request body",
+			Message:   "Request body must be JSON with a 'resolution' field and an optional 'mode' field",
+			Code:      http.StatusBadRequest,
+			ErrorCode: models.ErrorCodeInvalidRequest,
+		})
+		return
+	}
+
+	estimate, err := h.imageService.EstimateResize(ctx, imageID, req.Resolution, req.Mode)
+	if err != nil {
+		h.handleServiceError(c, err, requestID, "estimate resize failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, estimate)
+}
+
+// DownloadOriginal handles original image download
+// GET /api/v1/images/:id/original
+func (h *ImageHandler) DownloadOriginal(c *gin.Context) {
+	h.downloadImage(c, "original")
+}
+
+// DownloadThumbnail handles thumbnail download
+// GET /api/v1/images/:id/thumbnail
+func (h *ImageHandler) DownloadThumbnail(c *gin.Context) {
+	h.downloadImage(c, "thumbnail")
+}
+
+// downloadThumbnailAs serves the thumbnail in the format its extension names. The
+// first request generates the thumbnail if missing and stores the conversion as a
+// format variant; later requests stream the stored variant. During maintenance
+// only stored variants are served.
+// GET /api/v1/images/:id/thumbnail.:ext
+func (h *ImageHandler) downloadThumbnailAs(c *gin.Context, ext string) {
+	requestID := c.GetString("request_id")
+	imageID := c.Param("id")
+
+	format := strings.TrimPrefix(models.GetMimeTypeFromExtension("thumbnail."+ext), "image/")
+	if models.GetMimeTypeFromFormat(format) == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:     "Invalid thumbnail extension",
+			Message:   fmt.Sprintf("Unsupported extension '%s', must be one of: jpg, jpeg, png, gif, webp", ext),
+			Code:      http.StatusBadRequest,
+			ErrorCode: models.ErrorCodeValidationFailed,
+		})
+		return
+	}
+
+	if !h.isValidUUID(imageID) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:     "Invalid image ID",
+			Message:   "Image ID must be a valid UUID",
+			Code:      http.StatusBadRequest,
+			ErrorCode: models.ErrorCodeInvalidImageID,
+		})
+		return
+	}
+
+	if c.GetBool(middleware.MaintenanceKey) {
+		h.downloadStoredThumbnailAs(c, format)
+		return
+	}
+
+	if err := h.imageService.ProcessFormatVariant(c.Request.Context(), imageID, "thumbnail", format); err != nil {
+		if h.servePlaceholder(c, err) {
+			return
+		}
+		h.handleServiceError(c, err, requestID, "thumbnail format conversion failed")
+		return
+	}
+
+	h.downloadImageAs(c, "thumbnail", format)
+}
+
+// downloadStoredThumbnailAs serves a stored thumbnail conversion while maintenance
+// mode is on, answering 503 when it would have to be generated
+func (h *ImageHandler) downloadStoredThumbnailAs(c *gin.Context, format string) {
+	requestID := c.GetString("request_id")
+
+	stream, metadata, err := h.imageService.GetImageVariantStream(c.Request.Context(), c.Param("id"), "thumbnail", format)
+	if err != nil {
+		var notFoundErr models.NotFoundError
+		if errors.As(err, &notFoundErr) && (notFoundErr.Resource == "resolution" || notFoundErr.Resource == "format variant") {
+			c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+				Error:     "Service in maintenance",
+				Message:   fmt.Sprintf("The %s thumbnail has not been generated yet and cannot be stored during maintenance", format),
+				Code:      http.StatusServiceUnavailable,
+				ErrorCode: models.ErrorCodeMaintenance,
+			})
+			return
+		}
+		if h.servePlaceholder(c, err) {
+			return
+		}
+		h.handleServiceError(c, err, requestID, "get image stream failed")
+		return
+	}
+
+	h.serveImageStream(c, stream, metadata, "thumbnail", format)
+}
+
+// DownloadCustomResolution handles custom resolution download
+// GET /api/v1/images/:id/:resolution
+func (h *ImageHandler) DownloadCustomResolution(c *gin.Context) {
+	resolution := c.Param("resolution")
+
+	// Downloads never process or store: a size-capped resolution is created by the
+	// POST /resolutions write route and then downloaded by its stored name
+	if c.Query("max_bytes") != "" {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Invalid form data",
-			Message: "Failed to parse multipart form",
-			Code:    http.StatusBadRequest,
+			Error:     "Invalid query parameter",
+			Message:   "max_bytes is not supported on downloads; create the resolution with POST /api/v1/images/{id}/resolutions and download it by the returned name",
+			Code:      http.StatusBadRequest,
+			ErrorCode: models.ErrorCodeInvalidRequest,
 		})
 		return
 	}
 
-	// Get uploaded file
-	file, header, err := c.Request.FormFile("image")
-	if err != nil {
-		logger.ErrorWithContext(ctx, "No image file in request",
-			zap.Error(err),
-			zap.String("request_id", requestID))
+	// thumbnail.{ext} shares this route, as a path segment cannot mix text and a parameter
+	if ext, ok := strings.CutPrefix(resolution, "thumbnail."); ok {
+		h.downloadThumbnailAs(c, ext)
+		return
+	}
+
+	// Validate resolution format (e.g., "800x600", "800x600:alias", or just "alias")
+	if !h.isValidSize(resolution) {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Missing image file",
-			Message: "Request must contain an 'image' file field",
-			Code:    http.StatusBadRequest,
+			Error:     "Invalid resolution format",
+			Message:   "Resolution must be in format WIDTHxHEIGHT (e.g., 800x600), WIDTHxHEIGHT:alias (e.g., 800x600:small), or a valid alias",
+			Code:      http.StatusBadRequest,
+			ErrorCode: models.ErrorCodeInvalidResolution,
 		})
 		return
 	}
-	defer func() {
-		if err := file.Close(); err != nil {
-			logger.WarnWithContext(ctx, "Failed to close file", zap.String("error", err.Error()))
+
+	// ?fallback=nearest serves the closest stored resolution instead of a 404
+	if fallback := c.Query("fallback"); fallback != "" {
+		if fallback != "nearest" {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:     "Invalid fallback parameter",
+				Message:   "fallback must be 'nearest'",
+				Code:      http.StatusBadRequest,
+				ErrorCode: models.ErrorCodeInvalidRequest,
+			})
+			return
 		}
-	}()
 
-	// Validate file size
-	if header.Size > h.config.Image.MaxFileSize {
-		logger.WarnWithContext(ctx, "File size exceeds limit",
-			zap.Int64("file_size", header.Size),
-			zap.Int64("max_size", h.config.Image.MaxFileSize),
-			zap.String("request_id", requestID))
-		c.JSON(http.StatusRequestEntityTooLarge, models.ErrorResponse{
-			Error:   "File too large",
-			Message: fmt.Sprintf("File size %d bytes exceeds limit of %d bytes", header.Size, h.config.Image.MaxFileSize),
-			Code:    http.StatusRequestEntityTooLarge,
-		})
-		return
+		served, ok := h.nearestResolution(c, resolution)
+		if !ok {
+			return
+		}
+		if served != resolution {
+			c.Header(ResolutionSubstitutedHeader, served)
+			resolution = served
+		}
 	}
 
-	// Parse additional resolutions from form
-	var req models.UploadRequest
+	h.downloadImage(c, resolution)
+}
 
-	// Get resolutions from form - handle both single and multiple field approaches
-	if values := c.Request.Form["resolutions"]; len(values) > 0 {
-		// Handle both multiple fields and comma-separated values
-		var allResolutions []string
-		for _, value := range values {
-			// Split each value by comma in case it contains multiple resolutions
-			splitValues := strings.Split(value, ",")
-			for _, splitValue := range splitValues {
-				trimmed := strings.TrimSpace(splitValue)
-				if trimmed != "" {
-					allResolutions = append(allResolutions, trimmed)
-				}
-			}
+// nearestResolution returns resolution when the image has it, otherwise the stored
+// resolution closest to it. Aliases cannot be sized, so they are returned unchanged
+// and 404 as usual when missing. It writes the error response, or the missing
+// image placeholder, and returns false when the metadata cannot be loaded.
+func (h *ImageHandler) nearestResolution(c *gin.Context, resolution string) (string, bool) {
+	imageID := c.Param("id")
+	if !h.isValidUUID(imageID) {
+		return resolution, true // downloadImage reports the invalid ID
+	}
+
+	metadata, err := h.imageService.GetMetadata(c.Request.Context(), imageID)
+	if err != nil {
+		if h.servePlaceholder(c, err) {
+			return "", false
 		}
-		req.Resolutions = allResolutions
-	} else if err := c.ShouldBind(&req); err != nil {
-		logger.WarnWithContext(ctx, "Invalid resolution parameters",
-			zap.Error(err),
-			zap.String("request_id", requestID))
-		// Continue with empty resolutions - this is optional
+		h.handleServiceError(c, err, c.GetString("request_id"), "get metadata for nearest resolution failed")
+		return "", false
+	}
+	if metadata.HasResolution(resolution) {
+		return resolution, true
 	}
 
-	// Read file data
-	fileData, err := io.ReadAll(file)
+	requested, err := models.ParseResolution(resolution)
 	if err != nil {
-		logger.ErrorWithContext(ctx, "Failed to read file data",
-			zap.Error(err),
-			zap.String("request_id", requestID))
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "File read error",
-			Message: "Failed to read uploaded file",
-			Code:    http.StatusInternalServerError,
+		return resolution, true
+	}
+	nearest, found := metadata.NearestResolution(requested.Width, requested.Height)
+	if !found {
+		return resolution, true
+	}
+
+	logger.DebugWithContext(c.Request.Context(), "Serving nearest stored resolution",
+		zap.String("image_id", imageID),
+		zap.String("requested", resolution),
+		zap.String("served", nearest),
+		zap.String("request_id", c.GetString("request_id")))
+	return nearest, true
+}
+
+// DownloadFrame serves a single frame of an animated image as a still image
+// GET /api/v1/images/:id/:resolution/frame/:n
+func (h *ImageHandler) DownloadFrame(c *gin.Context) {
+	ctx := c.Request.Context()
+	requestID := c.GetString("request_id")
+	imageID := c.Param("id")
+	resolution := c.Param("resolution")
+
+	// Validate UUID format
+	if !h.isValidUUID(imageID) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:     "Invalid image ID",
+			Message:   "Image ID must be a valid UUID",
+			Code:      http.StatusBadRequest,
+			ErrorCode: models.ErrorCodeInvalidImageID,
 		})
 		return
 	}
 
-	// Process upload through service layer
-	result, err := h.imageService.ProcessUpload(ctx, service.UploadInput{
-		Filename:    header.Filename,
-		Data:        fileData,
-		Size:        header.Size,
-		Resolutions: req.Resolutions,
-	})
+	if resolution != "original" && resolution != "thumbnail" && !h.isValidSize(resolution) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:     "Invalid resolution format",
+			Message:   "Resolution must be original, thumbnail, WIDTHxHEIGHT (e.g., 800x600), WIDTHxHEIGHT:alias (e.g., 800x600:small), or a valid alias",
+			Code:      http.StatusBadRequest,
+			ErrorCode: models.ErrorCodeInvalidResolution,
+		})
+		return
+	}
 
-	if err != nil {
-		h.handleServiceError(c, err, requestID, "upload failed")
+	frame, err := strconv.Atoi(c.Param("n"))
+	if err != nil || frame < 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:     "Invalid frame index",
+			Message:   "Frame index must be a non-negative integer",
+			Code:      http.StatusBadRequest,
+			ErrorCode: models.ErrorCodeInvalidRequest,
+		})
 		return
 	}
 
-	logger.InfoWithContext(ctx, "Image upload completed successfully",
-		zap.String("image_id", result.ImageID),
-		zap.String("filename", header.Filename),
-		zap.Int64("size", header.Size),
-		zap.Strings("resolutions", result.ProcessedResolutions),
-		zap.String("request_id", requestID))
+	data, contentType, metadata, err := h.imageService.GetImageFrame(ctx, imageID, resolution, frame)
+	if err != nil {
+		if h.servePlaceholder(c, err) {
+			return
+		}
+		h.handleServiceError(c, err, requestID, "get image frame failed")
+		return
+	}
 
-	// Return success response
-	response := models.UploadResponse{
-		ID:          result.ImageID,
-		Message:     "Image uploaded successfully",
-		Resolutions: result.ProcessedResolutions,
+	filename := h.generateDownloadFilename(metadata.Filename, resolution)
+	if dot := strings.LastIndex(filename, "."); dot > 0 {
+		filename = filename[:dot]
 	}
+	filename = fmt.Sprintf("%s_frame%d.%s", filename, frame, models.GetExtensionFromMimeType(contentType))
 
-	c.JSON(http.StatusCreated, response)
+	c.Header("Cache-Control", "public, max-age=3600, immutable")
+	c.Header("ETag", fmt.Sprintf(`"%s-%s-frame%d"`, metadata.ID, resolution, frame))
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s"`, filename))
+	c.Data(http.StatusOK, contentType, data)
+
+	h.imageService.RecordAccess(ctx, imageID, resolution)
+
+	logger.InfoWithContext(ctx, "Image frame download completed",
+		zap.String("image_id", imageID),
+		zap.String("resolution", resolution),
+		zap.Int("frame", frame),
+		zap.Int("size", len(data)),
+		zap.String("request_id", requestID))
 }
 
-// Info handles image metadata requests
-// GET /api/v1/images/:id/info
-func (h *ImageHandler) Info(c *gin.Context) {
+// Archive streams a ZIP of the original and every stored resolution
+// GET /api/v1/images/:id/archive
+func (h *ImageHandler) Archive(c *gin.Context) {
 	ctx := c.Request.Context()
 	requestID := c.GetString("request_id")
 	imageID := c.Param("id")
 
-	logger.DebugWithContext(ctx, "Getting image info",
+	logger.DebugWithContext(ctx, "Processing image archive download",
 		zap.String("image_id", imageID),
 		zap.String("request_id", requestID))
 
 	// Validate UUID format
 	if !h.isValidUUID(imageID) {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Invalid image ID",
-			Message: "Image ID must be a valid UUID",
-			Code:    http.StatusBadRequest,
+			Error:     "Invalid image ID",
+			Message:   "Image ID must be a valid UUID",
+			Code:      http.StatusBadRequest,
+			ErrorCode: models.ErrorCodeInvalidImageID,
 		})
 		return
 	}
 
-	// Get image metadata
 	metadata, err := h.imageService.GetMetadata(ctx, imageID)
 	if err != nil {
 		h.handleServiceError(c, err, requestID, "get metadata failed")
 		return
 	}
 
-	// Convert to API response
-	response := metadata.ToInfoResponse()
-	c.JSON(http.StatusOK, response)
-}
+	base := metadata.Filename
+	if dot := strings.LastIndex(base, "."); dot > 0 {
+		base = base[:dot]
+	}
+	if base == "" {
+		base = "image"
+	}
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s.zip"`, base, imageID))
+	c.Status(http.StatusOK)
+
+	// Entries are copied straight from storage into the response, one at a time
+	zw := zip.NewWriter(c.Writer)
+	resolutions := append([]string{"original"}, metadata.Resolutions...)
+	entries := 0
+	for _, resolution := range resolutions {
+		written, err := h.writeArchiveEntry(c, zw, imageID, resolution)
+		if err != nil {
+			logger.ErrorWithContext(ctx, "Failed to stream archive entry",
+				zap.Error(err),
+				zap.String("image_id", imageID),
+				zap.String("resolution", resolution),
+				zap.String("request_id", requestID))
+			c.Abort()
+			return
+		}
+		if written {
+			entries++
+		}
+	}
 
-// DownloadOriginal handles original image download
-// GET /api/v1/images/:id/original
-func (h *ImageHandler) DownloadOriginal(c *gin.Context) {
-	h.downloadImage(c, "original")
-}
+	if err := zw.Close(); err != nil {
+		logger.ErrorWithContext(ctx, "Failed to finalize archive",
+			zap.Error(err),
+			zap.String("image_id", imageID),
+			zap.String("request_id", requestID))
+		c.Abort()
+		return
+	}
 
-// DownloadThumbnail handles thumbnail download
-// GET /api/v1/images/:id/thumbnail
-func (h *ImageHandler) DownloadThumbnail(c *gin.Context) {
-	h.downloadImage(c, "thumbnail")
+	logger.InfoWithContext(ctx, "Image archive download completed",
+		zap.String("image_id", imageID),
+		zap.Int("entries", entries),
+		zap.String("request_id", requestID))
 }
 
-// DownloadCustomResolution handles custom resolution download
-// GET /api/v1/images/:id/:resolution
-func (h *ImageHandler) DownloadCustomResolution(c *gin.Context) {
-	resolution := c.Param("resolution")
+// writeArchiveEntry copies one resolution into the archive. A resolution that
+// can no longer be read from storage is skipped; only write failures are returned
+func (h *ImageHandler) writeArchiveEntry(c *gin.Context, zw *zip.Writer, imageID, resolution string) (bool, error) {
+	ctx := c.Request.Context()
 
-	// Validate resolution format (e.g., "800x600", "800x600:alias", or just "alias")
-	if !h.isValidSize(resolution) {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Invalid resolution format",
-			Message: "Resolution must be in format WIDTHxHEIGHT (e.g., 800x600), WIDTHxHEIGHT:alias (e.g., 800x600:small), or a valid alias",
-			Code:    http.StatusBadRequest,
-		})
-		return
+	stream, metadata, err := h.imageService.GetImageStream(ctx, imageID, resolution)
+	if err != nil {
+		logger.WarnWithContext(ctx, "Skipping unreadable resolution in archive",
+			zap.Error(err),
+			zap.String("image_id", imageID),
+			zap.String("resolution", resolution))
+		return false, nil
 	}
+	defer func() {
+		if err := stream.Close(); err != nil {
+			logger.WarnWithContext(ctx, "Failed to close stream", zap.String("error", err.Error()))
+		}
+	}()
 
-	h.downloadImage(c, resolution)
+	entry, err := zw.Create(archiveEntryName(metadata, resolution))
+	if err != nil {
+		return false, err
+	}
+	if _, err := copyWithContext(ctx, entry, stream); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// archiveEntryName names an archive entry after its resolution, e.g. "800x600_small.png"
+func archiveEntryName(metadata *models.ImageMetadata, resolution string) string {
+	name := strings.ReplaceAll(resolution, ":", "_")
+	if ext := models.GetExtensionFromMimeType(metadata.GetContentType(resolution)); ext != "" {
+		return fmt.Sprintf("%s.%s", name, ext)
+	}
+	return name
 }
 
 // GeneratePresignedURL generates a pre-signed URL for image access
@@ -244,9 +1195,10 @@ func (h *ImageHandler) GeneratePresignedURL(c *gin.Context) {
 		parsed, err := strconv.Atoi(expiresInParam)
 		if err != nil || parsed <= 0 {
 			c.JSON(http.StatusBadRequest, models.ErrorResponse{
-				Error:   "Invalid expires_in parameter",
-				Message: "expires_in must be a positive integer (seconds)",
-				Code:    http.StatusBadRequest,
+				Error:     "Invalid expires_in parameter",
+				Message:   "expires_in must be a positive integer (seconds)",
+				Code:      http.StatusBadRequest,
+				ErrorCode: models.ErrorCodeInvalidRequest,
 			})
 			return
 		}
@@ -257,9 +1209,10 @@ func (h *ImageHandler) GeneratePresignedURL(c *gin.Context) {
 	maxExpiresIn := 7 * 24 * 3600 // 7 days in seconds
 	if expiresIn > maxExpiresIn {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "expires_in too large",
-			Message: fmt.Sprintf("Maximum expiration is %d seconds (7 days)", maxExpiresIn),
-			Code:    http.StatusBadRequest,
+			Error:     "expires_in too large",
+			Message:   fmt.Sprintf("Maximum expiration is %d seconds (7 days)", maxExpiresIn),
+			Code:      http.StatusBadRequest,
+			ErrorCode: models.ErrorCodeInvalidRequest,
 		})
 		return
 	}
@@ -273,9 +1226,10 @@ func (h *ImageHandler) GeneratePresignedURL(c *gin.Context) {
 	// Validate UUID format
 	if !h.isValidUUID(imageID) {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Invalid image ID",
-			Message: "Image ID must be a valid UUID",
-			Code:    http.StatusBadRequest,
+			Error:     "Invalid image ID",
+			Message:   "Image ID must be a valid UUID",
+			Code:      http.StatusBadRequest,
+			ErrorCode: models.ErrorCodeInvalidImageID,
 		})
 		return
 	}
@@ -292,9 +1246,10 @@ func (h *ImageHandler) GeneratePresignedURL(c *gin.Context) {
 	// Validate size exists (except for original)
 	if size != "original" && !metadata.HasResolution(size) {
 		c.JSON(http.StatusNotFound, models.ErrorResponse{
-			Error:   "Resolution not found",
-			Message: fmt.Sprintf("Resolution '%s' not available for this image", size),
-			Code:    http.StatusNotFound,
+			Error:     "Resolution not found",
+			Message:   fmt.Sprintf("Resolution '%s' not available for this image", size),
+			Code:      http.StatusNotFound,
+			ErrorCode: models.ErrorCodeResolutionNotFound,
 		})
 		return
 	}
@@ -302,9 +1257,10 @@ func (h *ImageHandler) GeneratePresignedURL(c *gin.Context) {
 	// Validate size format for custom resolutions (after checking availability)
 	if size != "original" && size != "thumbnail" && !h.isValidSize(size) {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Invalid size format",
-			Message: "Custom resolution must be in format WIDTHxHEIGHT (e.g., 800x600), WIDTHxHEIGHT:alias (e.g., 800x600:small), or a valid alias",
-			Code:    http.StatusBadRequest,
+			Error:     "Invalid size format",
+			Message:   "Custom resolution must be in format WIDTHxHEIGHT (e.g., 800x600), WIDTHxHEIGHT:alias (e.g., 800x600:small), or a valid alias",
+			Code:      http.StatusBadRequest,
+			ErrorCode: models.ErrorCodeInvalidResolution,
 		})
 		return
 	}
@@ -313,30 +1269,59 @@ func (h *ImageHandler) GeneratePresignedURL(c *gin.Context) {
 	storageKey := metadata.GetStorageKey(size)
 	duration := time.Duration(expiresIn) * time.Second
 
-	presignedURL, err := h.imageService.GeneratePresignedURL(ctx, storageKey, duration)
-	if err != nil {
-		h.handleServiceError(c, err, requestID, "generate presigned URL failed")
-		return
+	cacheKey := presignedURLCacheKey(storageKey, duration)
+	entry, hit := h.presignCache.get(cacheKey)
+	if hit {
+		c.Header("X-Cache", "HIT")
+	} else {
+		presignedURL, err := h.imageService.GeneratePresignedURL(ctx, storageKey, duration)
+		if err != nil {
+			h.handleServiceError(c, err, requestID, "generate presigned URL failed")
+			return
+		}
+		// Aliases use the window configured for their dimensions
+		ttl := h.presignCache.ttlFor(size, metadata.ResolveToDimensions(size))
+		entry = h.presignCache.put(cacheKey, imageID, presignedURL, duration, ttl)
+		c.Header("X-Cache", "MISS")
+	}
+	c.Header("Last-Modified", entry.generatedAt.UTC().Format(http.TimeFormat))
+
+	// Handing out a URL counts as an access for lifecycle decisions
+	h.imageService.TouchLastAccessed(ctx, imageID)
+
+	// The client already holds this exact URL
+	if hit {
+		if since, err := http.ParseTime(c.GetHeader("If-Modified-Since")); err == nil && !entry.generatedAt.Truncate(time.Second).After(since) {
+			c.AbortWithStatus(http.StatusNotModified)
+			return
+		}
 	}
 
-	expiresAt := time.Now().Add(duration)
+	remaining := int(entry.expiresAt.Sub(h.presignCache.now()).Round(time.Second) / time.Second)
 
 	logger.InfoWithContext(ctx, "Presigned URL generated successfully",
 		zap.String("image_id", imageID),
 		zap.String("size", size),
-		zap.Int("expires_in", expiresIn),
-		zap.Time("expires_at", expiresAt),
+		zap.Int("expires_in", remaining),
+		zap.Time("expires_at", entry.expiresAt),
+		zap.Bool("cache_hit", hit),
 		zap.String("request_id", requestID))
 
 	c.JSON(http.StatusOK, models.PresignedURLResponse{
-		URL:       presignedURL,
-		ExpiresAt: expiresAt,
-		ExpiresIn: expiresIn,
+		URL:       entry.url,
+		ExpiresAt: entry.expiresAt,
+		ExpiresIn: remaining,
 	})
 }
 
-// downloadImage is a common handler for all image downloads
+// downloadImage is a common handler for all image downloads; ?format= selects a
+// format variant of the resolution
 func (h *ImageHandler) downloadImage(c *gin.Context, resolution string) {
+	h.downloadImageAs(c, resolution, c.Query("format"))
+}
+
+// downloadImageAs serves a resolution in format, or in its own format when empty
+func (h *ImageHandler) downloadImageAs(c *gin.Context, resolution, format string) {
 	ctx := c.Request.Context()
 	requestID := c.GetString("request_id")
 	imageID := c.Param("id")
@@ -349,27 +1334,74 @@ func (h *ImageHandler) downloadImage(c *gin.Context, resolution string) {
 	// Validate UUID format
 	if !h.isValidUUID(imageID) {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Invalid image ID",
-			Message: "Image ID must be a valid UUID",
-			Code:    http.StatusBadRequest,
+			Error:     "Invalid image ID",
+			Message:   "Image ID must be a valid UUID",
+			Code:      http.StatusBadRequest,
+			ErrorCode: models.ErrorCodeInvalidImageID,
 		})
 		return
 	}
 
 	// Get image stream from service
-	stream, metadata, err := h.imageService.GetImageStream(ctx, imageID, resolution)
+	var stream io.ReadCloser
+	var metadata *models.ImageMetadata
+	var err error
+	if format != "" {
+		stream, metadata, err = h.imageService.GetImageVariantStream(ctx, imageID, resolution, format)
+	} else {
+		stream, metadata, err = h.imageService.GetImageStream(ctx, imageID, resolution)
+	}
 	if err != nil {
+		if h.servePlaceholder(c, err) {
+			return
+		}
 		h.handleServiceError(c, err, requestID, "get image stream failed")
 		return
 	}
+
+	h.serveImageStream(c, stream, metadata, resolution, format)
+}
+
+// serveImageStream streams a resolution to the client and closes the stream; format
+// names the format variant served, empty for the resolution's own format
+func (h *ImageHandler) serveImageStream(c *gin.Context, stream io.ReadCloser, metadata *models.ImageMetadata, resolution, format string) {
+	ctx := c.Request.Context()
+	requestID := c.GetString("request_id")
+	imageID := c.Param("id")
+
 	defer func() {
 		if err := stream.Close(); err != nil {
 			logger.WarnWithContext(ctx, "Failed to close stream", zap.String("error", err.Error()))
 		}
 	}()
 
+	// Strict downloads check the stored bytes, so a tampered object is never served as an image
+	var body io.Reader = stream
+	if h.config.Image.DownloadStrictContentType {
+		sniffed, sniffedBody, err := sniffStream(stream)
+		if err != nil {
+			h.handleServiceError(c, models.StorageError{Operation: "download", Backend: "S3", Reason: err.Error()}, requestID, "sniff image stream failed")
+			return
+		}
+		if !slices.Contains(h.config.Image.DownloadAllowedTypes, sniffed) {
+			logger.WarnWithContext(ctx, "Refusing to serve stored file with disallowed content type",
+				zap.String("image_id", imageID),
+				zap.String("resolution", resolution),
+				zap.String("content_type", sniffed),
+				zap.String("request_id", requestID))
+			c.JSON(http.StatusUnsupportedMediaType, models.ErrorResponse{
+				Error:     "Unsupported media type",
+				Message:   fmt.Sprintf("Stored file is %s, which is not an allowed download type", sniffed),
+				Code:      http.StatusUnsupportedMediaType,
+				ErrorCode: models.ErrorCodeUnsupportedMediaType,
+			})
+			return
+		}
+		body = sniffedBody
+	}
+
 	// Set response headers
-	h.setImageResponseHeaders(c, metadata, resolution)
+	h.setImageResponseHeaders(c, metadata, resolution, format)
 
 	// Stream image data to client
 	logger.DebugWithContext(ctx, "Streaming image to client",
@@ -378,9 +1410,17 @@ func (h *ImageHandler) downloadImage(c *gin.Context, resolution string) {
 		zap.String("mime_type", metadata.MimeType),
 		zap.String("request_id", requestID))
 
-	// Copy stream to response
-	bytesWritten, err := io.Copy(c.Writer, stream)
+	// Copy stream to response; a client disconnect cancels ctx and stops the storage read
+	bytesWritten, err := copyWithContext(ctx, c.Writer, body)
 	if err != nil {
+		if ctx.Err() != nil {
+			logger.InfoWithContext(ctx, "Image download aborted by client",
+				zap.String("image_id", imageID),
+				zap.String("resolution", resolution),
+				zap.Int64("bytes_streamed", bytesWritten),
+				zap.String("request_id", requestID))
+			return
+		}
 		logger.ErrorWithContext(ctx, "Failed to stream image data",
 			zap.Error(err),
 			zap.String("image_id", imageID),
@@ -389,6 +1429,8 @@ func (h *ImageHandler) downloadImage(c *gin.Context, resolution string) {
 		return
 	}
 
+	h.imageService.RecordAccess(ctx, imageID, resolution)
+
 	logger.InfoWithContext(ctx, "Image download completed",
 		zap.String("image_id", imageID),
 		zap.String("resolution", resolution),
@@ -396,17 +1438,31 @@ func (h *ImageHandler) downloadImage(c *gin.Context, resolution string) {
 		zap.String("request_id", requestID))
 }
 
-// setImageResponseHeaders sets appropriate headers for image responses
-func (h *ImageHandler) setImageResponseHeaders(c *gin.Context, metadata *models.ImageMetadata, resolution string) {
-	// Set content type based on image format
-	c.Header("Content-Type", metadata.MimeType)
+// setImageResponseHeaders sets appropriate headers for image responses; format names
+// the format variant served, empty for the resolution's own format
+func (h *ImageHandler) setImageResponseHeaders(c *gin.Context, metadata *models.ImageMetadata, resolution, format string) {
+	// Set content type based on the stored format of this resolution
+	contentType := metadata.GetContentType(resolution)
+	etag := fmt.Sprintf(`"%s-%s"`, metadata.ID, resolution)
+	if format != "" {
+		contentType = models.GetMimeTypeFromFormat(format)
+		etag = fmt.Sprintf(`"%s-%s-%s"`, metadata.ID, resolution, format)
+	}
+	c.Header("Content-Type", contentType)
 
 	// Set cache headers
 	c.Header("Cache-Control", "public, max-age=3600, immutable")
-	c.Header("ETag", fmt.Sprintf(`"%s-%s"`, metadata.ID, resolution))
+	c.Header("ETag", etag)
 
 	// Set content disposition for downloads
 	filename := h.generateDownloadFilename(metadata.Filename, resolution)
+	if contentType != metadata.MimeType {
+		// Converted resolutions (e.g. TIFF -> PNG) get the extension of their actual format
+		if dot := strings.LastIndex(filename, "."); dot > 0 {
+			filename = filename[:dot]
+		}
+		filename = fmt.Sprintf("%s.%s", filename, models.GetExtensionFromMimeType(contentType))
+	}
 	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s"`, filename))
 
 	// Set additional headers for browser compatibility
@@ -434,10 +1490,31 @@ func (h *ImageHandler) generateDownloadFilename(originalFilename, resolution str
 	return fmt.Sprintf("%s_%s.%s", base, resolution, ext)
 }
 
+// dedupNamespace returns the tenant namespace that scopes deduplication for the request
+func (h *ImageHandler) dedupNamespace(c *gin.Context) string {
+	switch h.config.Image.DedupNamespaceSource {
+	case "api_key":
+		return c.GetString(middleware.AuthKeyIDKey)
+	case "header":
+		return strings.TrimSpace(c.GetHeader(h.config.Image.DedupNamespaceHeader))
+	default:
+		return ""
+	}
+}
+
 // handleServiceError handles errors from the service layer
 func (h *ImageHandler) handleServiceError(c *gin.Context, err error, requestID, operation string) {
 	ctx := c.Request.Context()
 
+	// Past the route deadline the error is only a symptom; middleware.RouteTimeout answers 504
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		logger.WarnWithContext(ctx, "Service call stopped by the route timeout",
+			zap.Error(err),
+			zap.String("request_id", requestID),
+			zap.String("operation", operation))
+		return
+	}
+
 	switch e := err.(type) {
 	case models.ValidationError:
 		logger.WarnWithContext(ctx, "Validation error",
@@ -446,9 +1523,24 @@ func (h *ImageHandler) handleServiceError(c *gin.Context, err error, requestID,
 			zap.String("request_id", requestID),
 			zap.String("operation", operation))
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Validation failed",
-			Message: e.Error(),
-			Code:    http.StatusBadRequest,
+			Error:     "Validation failed",
+			Message:   e.Error(),
+			Code:      http.StatusBadRequest,
+			ErrorCode: models.ErrorCodeFor(err),
+		})
+
+	case models.MultiValidationError:
+		logger.WarnWithContext(ctx, "Validation errors",
+			zap.Int("field_errors", len(e.Errors)),
+			zap.String("message", e.Error()),
+			zap.String("request_id", requestID),
+			zap.String("operation", operation))
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:     "Validation failed",
+			Message:   e.Error(),
+			Code:      http.StatusBadRequest,
+			ErrorCode: models.ErrorCodeFor(err),
+			Details:   e.Errors,
 		})
 
 	case models.NotFoundError:
@@ -458,9 +1550,10 @@ func (h *ImageHandler) handleServiceError(c *gin.Context, err error, requestID,
 			zap.String("request_id", requestID),
 			zap.String("operation", operation))
 		c.JSON(http.StatusNotFound, models.ErrorResponse{
-			Error:   "Not found",
-			Message: e.Error(),
-			Code:    http.StatusNotFound,
+			Error:     "Not found",
+			Message:   e.Error(),
+			Code:      http.StatusNotFound,
+			ErrorCode: models.ErrorCodeFor(err),
 		})
 
 	case models.ProcessingError:
@@ -470,9 +1563,10 @@ func (h *ImageHandler) handleServiceError(c *gin.Context, err error, requestID,
 			zap.String("request_id", requestID),
 			zap.String("operation", operation))
 		c.JSON(http.StatusUnprocessableEntity, models.ErrorResponse{
-			Error:   "Processing failed",
-			Message: e.Error(),
-			Code:    http.StatusUnprocessableEntity,
+			Error:     "Processing failed",
+			Message:   e.Error(),
+			Code:      http.StatusUnprocessableEntity,
+			ErrorCode: models.ErrorCodeFor(err),
 		})
 
 	case models.StorageError:
@@ -483,9 +1577,60 @@ func (h *ImageHandler) handleServiceError(c *gin.Context, err error, requestID,
 			zap.String("request_id", requestID),
 			zap.String("operation", operation))
 		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
-			Error:   "Storage unavailable",
-			Message: "Temporary service unavailability",
-			Code:    http.StatusServiceUnavailable,
+			Error:     "Storage unavailable",
+			Message:   "Temporary service unavailability",
+			Code:      http.StatusServiceUnavailable,
+			ErrorCode: models.ErrorCodeFor(err),
+		})
+
+	case models.MalwareDetectedError:
+		logger.WarnWithContext(ctx, "Upload rejected by malware scanner",
+			zap.String("signature", e.Signature),
+			zap.String("request_id", requestID),
+			zap.String("operation", operation))
+		c.JSON(http.StatusUnprocessableEntity, models.ErrorResponse{
+			Error:     "Malware detected",
+			Message:   e.Error(),
+			Code:      http.StatusUnprocessableEntity,
+			ErrorCode: models.ErrorCodeFor(err),
+		})
+
+	case models.QueueFullError:
+		logger.WarnWithContext(ctx, "Processing queue full, rejecting upload",
+			zap.Int("capacity", e.Capacity),
+			zap.String("request_id", requestID),
+			zap.String("operation", operation))
+		c.Header("Retry-After", "5")
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+			Error:     "Processing queue full",
+			Message:   "Too many uploads are waiting to be processed, retry shortly",
+			Code:      http.StatusServiceUnavailable,
+			ErrorCode: models.ErrorCodeFor(err),
+		})
+
+	case models.CapacityExceededError:
+		logger.WarnWithContext(ctx, "Image capacity reached, rejecting upload",
+			zap.Int64("limit", e.Limit),
+			zap.String("request_id", requestID),
+			zap.String("operation", operation))
+		c.JSON(http.StatusInsufficientStorage, models.ErrorResponse{
+			Error:     "Image capacity reached",
+			Message:   e.Error(),
+			Code:      http.StatusInsufficientStorage,
+			ErrorCode: models.ErrorCodeFor(err),
+		})
+
+	case models.CorruptMetadataError:
+		logger.ErrorWithContext(ctx, "Corrupt metadata",
+			zap.String("image_id", e.ID),
+			zap.String("reason", e.Reason),
+			zap.String("request_id", requestID),
+			zap.String("operation", operation))
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:     "Corrupt metadata",
+			Message:   "The stored metadata for this image cannot be read",
+			Code:      http.StatusInternalServerError,
+			ErrorCode: models.ErrorCodeFor(err),
 		})
 
 	default:
@@ -494,9 +1639,10 @@ func (h *ImageHandler) handleServiceError(c *gin.Context, err error, requestID,
 			zap.String("request_id", requestID),
 			zap.String("operation", operation))
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "Internal server error",
-			Message: "An unexpected error occurred",
-			Code:    http.StatusInternalServerError,
+			Error:     "Internal server error",
+			Message:   "An unexpected error occurred",
+			Code:      http.StatusInternalServerError,
+			ErrorCode: models.ErrorCodeFor(err),
 		})
 	}
 }
@@ -510,7 +1656,10 @@ func (h *ImageHandler) isValidUUID(id string) bool {
 
 func (h *ImageHandler) isValidCustomResolution(resolution string) bool {
 	// Extract dimensions part (handles both "800x600" and "800x600:alias" formats)
-	dimensions := models.ExtractDimensions(resolution)
+	dimensions, alias := models.SplitResolutionAndAlias(resolution)
+	if alias != "" && !models.IsValidAlias(alias) {
+		return false
+	}
 
 	// Validate format: numbers + 'x' + numbers (e.g., "800x600")
 	parts := strings.Split(dimensions, "x")
@@ -544,22 +1693,8 @@ func (h *ImageHandler) isValidSize(size string) bool {
 }
 
 func (h *ImageHandler) isValidAlias(alias string) bool {
-	// Valid alias: alphanumeric characters, underscore, dash, no spaces
-	// Must be between 1-50 characters
-	if len(alias) == 0 || len(alias) > 50 {
-		return false
-	}
-
-	for _, char := range alias {
-		if !((char >= 'a' && char <= 'z') ||
-			(char >= 'A' && char <= 'Z') ||
-			(char >= '0' && char <= '9') ||
-			char == '_' || char == '-') {
-			return false
-		}
-	}
-
-	return true
+	// Valid alias: alphanumeric characters, underscore, dash, no spaces, 1-50 characters
+	return models.IsValidAlias(alias)
 }
 
 // Delete removes an entire image and all its resolutions
@@ -572,9 +1707,10 @@ func (h *ImageHandler) Delete(c *gin.Context) {
 	// Validate UUID format
 	if !h.isValidUUID(imageID) {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "validation_error",
-			Message: "Invalid image ID format",
-			Code:    http.StatusBadRequest,
+			Error:     "validation_error",
+			Message:   "Invalid image ID format",
+			Code:      http.StatusBadRequest,
+			ErrorCode: models.ErrorCodeInvalidImageID,
 		})
 		return
 	}
@@ -589,21 +1725,24 @@ func (h *ImageHandler) Delete(c *gin.Context) {
 		switch err.(type) {
 		case models.NotFoundError:
 			c.JSON(http.StatusNotFound, models.ErrorResponse{
-				Error:   "image_not_found",
-				Message: fmt.Sprintf("Image with ID %s not found", imageID),
-				Code:    http.StatusNotFound,
+				Error:     "image_not_found",
+				Message:   fmt.Sprintf("Image with ID %s not found", imageID),
+				Code:      http.StatusNotFound,
+				ErrorCode: models.ErrorCodeFor(err),
 			})
 		case models.ValidationError:
 			c.JSON(http.StatusBadRequest, models.ErrorResponse{
-				Error:   "validation_error",
-				Message: err.Error(),
-				Code:    http.StatusBadRequest,
+				Error:     "validation_error",
+				Message:   err.Error(),
+				Code:      http.StatusBadRequest,
+				ErrorCode: models.ErrorCodeFor(err),
 			})
 		default:
 			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-				Error:   "delete_failed",
-				Message: "Failed to delete image",
-				Code:    http.StatusInternalServerError,
+				Error:     "delete_failed",
+				Message:   "Failed to delete image",
+				Code:      http.StatusInternalServerError,
+				ErrorCode: models.ErrorCodeFor(err),
 			})
 		}
 		return
@@ -630,9 +1769,10 @@ func (h *ImageHandler) DeleteResolution(c *gin.Context) {
 	// Validate UUID format
 	if !h.isValidUUID(imageID) {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "validation_error",
-			Message: "Invalid image ID format",
-			Code:    http.StatusBadRequest,
+			Error:     "validation_error",
+			Message:   "Invalid image ID format",
+			Code:      http.StatusBadRequest,
+			ErrorCode: models.ErrorCodeInvalidImageID,
 		})
 		return
 	}
@@ -640,9 +1780,10 @@ func (h *ImageHandler) DeleteResolution(c *gin.Context) {
 	// Validate resolution format (basic check)
 	if resolution == "" {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "validation_error",
-			Message: "Resolution parameter is required",
-			Code:    http.StatusBadRequest,
+			Error:     "validation_error",
+			Message:   "Resolution parameter is required",
+			Code:      http.StatusBadRequest,
+			ErrorCode: models.ErrorCodeInvalidResolution,
 		})
 		return
 	}
@@ -658,21 +1799,24 @@ func (h *ImageHandler) DeleteResolution(c *gin.Context) {
 		switch err.(type) {
 		case models.NotFoundError:
 			c.JSON(http.StatusNotFound, models.ErrorResponse{
-				Error:   "resolution_not_found",
-				Message: fmt.Sprintf("Resolution %s not found for image %s", resolution, imageID),
-				Code:    http.StatusNotFound,
+				Error:     "resolution_not_found",
+				Message:   fmt.Sprintf("Resolution %s not found for image %s", resolution, imageID),
+				Code:      http.StatusNotFound,
+				ErrorCode: models.ErrorCodeFor(err),
 			})
 		case models.ValidationError:
 			c.JSON(http.StatusBadRequest, models.ErrorResponse{
-				Error:   "validation_error",
-				Message: err.Error(),
-				Code:    http.StatusBadRequest,
+				Error:     "validation_error",
+				Message:   err.Error(),
+				Code:      http.StatusBadRequest,
+				ErrorCode: models.ErrorCodeFor(err),
 			})
 		default:
 			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-				Error:   "delete_failed",
-				Message: "Failed to delete resolution",
-				Code:    http.StatusInternalServerError,
+				Error:     "delete_failed",
+				Message:   "Failed to delete resolution",
+				Code:      http.StatusInternalServerError,
+				ErrorCode: models.ErrorCodeFor(err),
 			})
 		}
 		return
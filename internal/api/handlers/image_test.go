@@ -1,33 +1,63 @@
 package handlers
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"resizr/internal/api/middleware"
 	"resizr/internal/models"
 	"resizr/internal/service"
 	"resizr/internal/testutil"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // Local mock to avoid import cycles
 type mockImageService struct {
 	processUploadFunc        func(ctx context.Context, input service.UploadInput) (*service.UploadResult, error)
 	getMetadataFunc          func(ctx context.Context, imageID string) (*models.ImageMetadata, error)
+	updateFilenameFunc       func(ctx context.Context, imageID, filename string) (*models.ImageMetadata, error)
 	getImageStreamFunc       func(ctx context.Context, imageID, resolution string) (io.ReadCloser, *models.ImageMetadata, error)
-	processResolutionFunc    func(ctx context.Context, imageID, resolution string) error
+	getVariantStreamFunc     func(ctx context.Context, imageID, resolution, format string) (io.ReadCloser, *models.ImageMetadata, error)
+	getImageFrameFunc        func(ctx context.Context, imageID, resolution string, frame int) ([]byte, string, *models.ImageMetadata, error)
+	processResolutionFunc    func(ctx context.Context, imageID, resolution string, force bool) error
+	processWithinSizeFunc    func(ctx context.Context, imageID, resolution string, maxBytes int64, force bool) (string, error)
+	processFormatVariantFunc func(ctx context.Context, imageID, resolution, format string) error
 	generatePresignedURLFunc func(ctx context.Context, storageKey string, expiration time.Duration) (string, error)
 	deleteImageFunc          func(ctx context.Context, imageID string) error
 	deleteResolutionFunc     func(ctx context.Context, imageID, resolution string) error
-	listImagesFunc           func(ctx context.Context, offset, limit int) ([]*models.ImageMetadata, int, error)
+	listImagesFunc           func(ctx context.Context, offset, limit int, sortBy string) ([]*models.ImageMetadata, int, error)
+	estimateResizeFunc       func(ctx context.Context, imageID, resolution, mode string) (*models.EstimateResponse, error)
+	addResolutionToAllFunc   func(ctx context.Context, resolution string) (*models.BulkResolutionResult, error)
+	findCorruptFunc          func(ctx context.Context) (*models.CorruptMetadataReport, error)
+	removeCorruptFunc        func(ctx context.Context) (*models.CorruptMetadataReport, error)
+	findOrphanedFunc         func(ctx context.Context) (*models.OrphanedHashReport, error)
+	removeOrphanedFunc       func(ctx context.Context) (*models.OrphanedHashReport, error)
+	cropImageFunc            func(ctx context.Context, imageID string, rect models.CropRect) (string, error)
+	findByHashFunc           func(ctx context.Context, checksum string, size int64, namespace string) (*models.ExistsResponse, error)
+	findByFilenameFunc       func(ctx context.Context, filename string) (*models.FilenameLookupResponse, error)
+	exportMetadataFunc       func(ctx context.Context, w io.Writer, format string) (int, error)
+	importMetadataFunc       func(ctx context.Context, r io.Reader) (*models.MetadataImportResult, error)
+	verifyImageFunc          func(ctx context.Context, imageID string, checkHash bool) (*models.VerificationResponse, error)
+	getStorageKeysFunc       func(ctx context.Context, imageID string) (*models.StorageKeysResponse, error)
+	purgeImageCacheFunc      func(ctx context.Context, imageID string) error
+	recordAccessFunc         func(ctx context.Context, imageID, resolution string)
+	getAccessCountsFunc      func(ctx context.Context, imageID string) (*models.AccessCounts, error)
+	touchLastAccessedFunc    func(ctx context.Context, imageID string)
 }
 
 func (m *mockImageService) ProcessUpload(ctx context.Context, input service.UploadInput) (*service.UploadResult, error) {
@@ -44,6 +74,13 @@ func (m *mockImageService) GetMetadata(ctx context.Context, imageID string) (*mo
 	return nil, nil
 }
 
+func (m *mockImageService) UpdateFilename(ctx context.Context, imageID, filename string) (*models.ImageMetadata, error) {
+	if m.updateFilenameFunc != nil {
+		return m.updateFilenameFunc(ctx, imageID, filename)
+	}
+	return nil, nil
+}
+
 func (m *mockImageService) GetImageStream(ctx context.Context, imageID, resolution string) (io.ReadCloser, *models.ImageMetadata, error) {
 	if m.getImageStreamFunc != nil {
 		return m.getImageStreamFunc(ctx, imageID, resolution)
@@ -51,13 +88,41 @@ func (m *mockImageService) GetImageStream(ctx context.Context, imageID, resoluti
 	return nil, nil, nil
 }
 
-func (m *mockImageService) ProcessResolution(ctx context.Context, imageID, resolution string) error {
+func (m *mockImageService) GetImageVariantStream(ctx context.Context, imageID, resolution, format string) (io.ReadCloser, *models.ImageMetadata, error) {
+	if m.getVariantStreamFunc != nil {
+		return m.getVariantStreamFunc(ctx, imageID, resolution, format)
+	}
+	return nil, nil, nil
+}
+
+func (m *mockImageService) GetImageFrame(ctx context.Context, imageID, resolution string, frame int) ([]byte, string, *models.ImageMetadata, error) {
+	if m.getImageFrameFunc != nil {
+		return m.getImageFrameFunc(ctx, imageID, resolution, frame)
+	}
+	return nil, "", nil, nil
+}
+
+func (m *mockImageService) ProcessResolution(ctx context.Context, imageID, resolution string, force bool) error {
 	if m.processResolutionFunc != nil {
-		return m.processResolutionFunc(ctx, imageID, resolution)
+		return m.processResolutionFunc(ctx, imageID, resolution, force)
+	}
+	return nil
+}
+
+func (m *mockImageService) ProcessFormatVariant(ctx context.Context, imageID, resolution, format string) error {
+	if m.processFormatVariantFunc != nil {
+		return m.processFormatVariantFunc(ctx, imageID, resolution, format)
 	}
 	return nil
 }
 
+func (m *mockImageService) ProcessResolutionWithinSize(ctx context.Context, imageID, resolution string, maxBytes int64, force bool) (string, error) {
+	if m.processWithinSizeFunc != nil {
+		return m.processWithinSizeFunc(ctx, imageID, resolution, maxBytes, force)
+	}
+	return models.SizeBudgetResolutionName(resolution, maxBytes), nil
+}
+
 func (m *mockImageService) GeneratePresignedURL(ctx context.Context, storageKey string, expiration time.Duration) (string, error) {
 	if m.generatePresignedURLFunc != nil {
 		return m.generatePresignedURLFunc(ctx, storageKey, expiration)
@@ -79,13 +144,142 @@ func (m *mockImageService) DeleteResolution(ctx context.Context, imageID, resolu
 	return nil
 }
 
-func (m *mockImageService) ListImages(ctx context.Context, offset, limit int) ([]*models.ImageMetadata, int, error) {
+func (m *mockImageService) ListImages(ctx context.Context, offset, limit int, sortBy string) ([]*models.ImageMetadata, int, error) {
 	if m.listImagesFunc != nil {
-		return m.listImagesFunc(ctx, offset, limit)
+		return m.listImagesFunc(ctx, offset, limit, sortBy)
 	}
 	return nil, 0, nil
 }
 
+func (m *mockImageService) ListImagesByDedupStatus(ctx context.Context, filter string, offset, limit int, sortBy string) (*models.ImageList, error) {
+	return &models.ImageList{Images: []*models.ImageMetadata{}}, nil
+}
+
+func (m *mockImageService) EstimateResize(ctx context.Context, imageID, resolution, mode string) (*models.EstimateResponse, error) {
+	if m.estimateResizeFunc != nil {
+		return m.estimateResizeFunc(ctx, imageID, resolution, mode)
+	}
+	return nil, nil
+}
+
+func (m *mockImageService) FindByHash(ctx context.Context, checksum string, size int64, namespace string) (*models.ExistsResponse, error) {
+	if m.findByHashFunc != nil {
+		return m.findByHashFunc(ctx, checksum, size, namespace)
+	}
+	return nil, nil
+}
+
+func (m *mockImageService) FindByFilename(ctx context.Context, filename string) (*models.FilenameLookupResponse, error) {
+	if m.findByFilenameFunc != nil {
+		return m.findByFilenameFunc(ctx, filename)
+	}
+	return nil, nil
+}
+
+func (m *mockImageService) GetStorageKeys(ctx context.Context, imageID string) (*models.StorageKeysResponse, error) {
+	if m.getStorageKeysFunc != nil {
+		return m.getStorageKeysFunc(ctx, imageID)
+	}
+	return nil, nil
+}
+
+func (m *mockImageService) PurgeImageCache(ctx context.Context, imageID string) error {
+	if m.purgeImageCacheFunc != nil {
+		return m.purgeImageCacheFunc(ctx, imageID)
+	}
+	return nil
+}
+
+func (m *mockImageService) VerifyImage(ctx context.Context, imageID string, checkHash bool) (*models.VerificationResponse, error) {
+	if m.verifyImageFunc != nil {
+		return m.verifyImageFunc(ctx, imageID, checkHash)
+	}
+	return nil, nil
+}
+
+func (m *mockImageService) ExportMetadata(ctx context.Context, w io.Writer, format string) (int, error) {
+	if m.exportMetadataFunc != nil {
+		return m.exportMetadataFunc(ctx, w, format)
+	}
+	return 0, nil
+}
+
+func (m *mockImageService) ImportMetadata(ctx context.Context, r io.Reader) (*models.MetadataImportResult, error) {
+	if m.importMetadataFunc != nil {
+		return m.importMetadataFunc(ctx, r)
+	}
+	return &models.MetadataImportResult{}, nil
+}
+
+func (m *mockImageService) CropImage(ctx context.Context, imageID string, rect models.CropRect) (string, error) {
+	if m.cropImageFunc != nil {
+		return m.cropImageFunc(ctx, imageID, rect)
+	}
+	return "", nil
+}
+
+func (m *mockImageService) AddResolutionToAll(ctx context.Context, resolution string) (*models.BulkResolutionResult, error) {
+	if m.addResolutionToAllFunc != nil {
+		return m.addResolutionToAllFunc(ctx, resolution)
+	}
+	return nil, nil
+}
+
+func (m *mockImageService) FindCorruptMetadata(ctx context.Context) (*models.CorruptMetadataReport, error) {
+	if m.findCorruptFunc != nil {
+		return m.findCorruptFunc(ctx)
+	}
+	return &models.CorruptMetadataReport{Records: []models.CorruptRecord{}}, nil
+}
+
+func (m *mockImageService) RemoveCorruptMetadata(ctx context.Context) (*models.CorruptMetadataReport, error) {
+	if m.removeCorruptFunc != nil {
+		return m.removeCorruptFunc(ctx)
+	}
+	return &models.CorruptMetadataReport{Records: []models.CorruptRecord{}}, nil
+}
+
+func (m *mockImageService) FindOrphanedHashes(ctx context.Context) (*models.OrphanedHashReport, error) {
+	if m.findOrphanedFunc != nil {
+		return m.findOrphanedFunc(ctx)
+	}
+	return &models.OrphanedHashReport{}, nil
+}
+
+func (m *mockImageService) RemoveOrphanedHashes(ctx context.Context) (*models.OrphanedHashReport, error) {
+	if m.removeOrphanedFunc != nil {
+		return m.removeOrphanedFunc(ctx)
+	}
+	return &models.OrphanedHashReport{}, nil
+}
+
+func (m *mockImageService) StartOrphanCleanup(ctx context.Context) {}
+
+func (m *mockImageService) RecordAccess(ctx context.Context, imageID, resolution string) {
+	if m.recordAccessFunc != nil {
+		m.recordAccessFunc(ctx, imageID, resolution)
+	}
+}
+
+func (m *mockImageService) GetAccessCounts(ctx context.Context, imageID string) (*models.AccessCounts, error) {
+	if m.getAccessCountsFunc != nil {
+		return m.getAccessCountsFunc(ctx, imageID)
+	}
+	return nil, nil
+}
+
+func (m *mockImageService) FlushAccessCounts(ctx context.Context) {}
+
+func (m *mockImageService) TouchLastAccessed(ctx context.Context, imageID string) {
+	if m.touchLastAccessedFunc != nil {
+		m.touchLastAccessedFunc(ctx, imageID)
+	}
+}
+
+func (m *mockImageService) StartAccessCountFlush(ctx context.Context) {}
+
+func (m *mockImageService) StartProcessingQueue(ctx context.Context) {}
+
 func TestImageHandler_Upload(t *testing.T) {
 	cfg := testutil.TestConfig()
 
@@ -193,6 +387,371 @@ func TestImageHandler_Upload(t *testing.T) {
 	}
 }
 
+func TestImageHandler_Upload_FailedResolutions(t *testing.T) {
+	mockService := &mockImageService{
+		processUploadFunc: func(ctx context.Context, input service.UploadInput) (*service.UploadResult, error) {
+			return &service.UploadResult{
+				ImageID:              testutil.ValidUUID,
+				ProcessedResolutions: []string{"thumbnail"},
+				FailedResolutions:    []string{"800x600"},
+			}, nil
+		},
+	}
+	handler := NewImageHandler(mockService, testutil.TestConfig())
+
+	req := testutil.CreateMultipartRequest("POST", "/api/v1/images", map[string]string{"resolutions": "800x600"}, "image", "test.jpg", testutil.CreateTestImageData())
+	c, w := testutil.SetupTestContext(req)
+
+	handler.Upload(c)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var response models.UploadResponse
+	require.NoError(t, testutil.ParseJSONResponse(w, &response))
+	assert.Equal(t, []string{"thumbnail"}, response.Resolutions)
+	assert.Equal(t, []string{"800x600"}, response.FailedResolutions)
+}
+
+func TestImageHandler_Upload_IfNoneMatch(t *testing.T) {
+	hash := strings.Repeat("ab", 32)
+	uploads := 0
+	var lookedUp string
+	mockService := &mockImageService{
+		findByHashFunc: func(ctx context.Context, checksum string, size int64, namespace string) (*models.ExistsResponse, error) {
+			lookedUp = checksum
+			if checksum != hash {
+				return &models.ExistsResponse{Hash: checksum}, nil
+			}
+			return &models.ExistsResponse{Exists: true, Hash: hash, ImageID: testutil.ValidUUID}, nil
+		},
+		processUploadFunc: func(ctx context.Context, input service.UploadInput) (*service.UploadResult, error) {
+			uploads++
+			return &service.UploadResult{ImageID: testutil.ValidUUID, ProcessedResolutions: []string{"thumbnail"}}, nil
+		},
+	}
+	handler := NewImageHandler(mockService, testutil.TestConfig())
+
+	upload := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := testutil.CreateMultipartRequest("POST", "/api/v1/images", nil, "image", "test.jpg", testutil.CreateTestImageData())
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		c, w := testutil.SetupTestContext(req)
+		handler.Upload(c)
+		return w
+	}
+
+	t.Run("stored content is not uploaded again", func(t *testing.T) {
+		w := upload(`"` + hash + `"`)
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Equal(t, hash, lookedUp)
+		assert.Equal(t, testutil.ValidUUID, w.Header().Get(ImageIDHeader))
+		assert.Equal(t, `"`+hash+`"`, w.Header().Get("ETag"))
+		assert.Empty(t, w.Body.String())
+		assert.Equal(t, 0, uploads)
+	})
+
+	t.Run("unknown hash uploads normally", func(t *testing.T) {
+		w := upload(`"` + strings.Repeat("cd", 32) + `"`)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, 1, uploads)
+	})
+
+	t.Run("no header skips the lookup", func(t *testing.T) {
+		lookedUp = ""
+		w := upload("")
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Empty(t, lookedUp)
+		assert.Equal(t, 2, uploads)
+	})
+
+	t.Run("malformed hash is rejected", func(t *testing.T) {
+		mockService.findByHashFunc = func(ctx context.Context, checksum string, size int64, namespace string) (*models.ExistsResponse, error) {
+			return nil, models.ValidationError{Field: "hash", Message: "Hash must be a 64-character hex-encoded SHA256 digest"}
+		}
+		w := upload(`"not-a-hash"`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, 2, uploads)
+	})
+}
+
+func TestImageHandler_Upload_RouteTimeout(t *testing.T) {
+	mockService := &mockImageService{
+		processUploadFunc: func(ctx context.Context, input service.UploadInput) (*service.UploadResult, error) {
+			// Processing gives up once the request deadline cancels its context
+			<-ctx.Done()
+			return nil, models.ProcessingError{Operation: "resize", Reason: "processing deadline exceeded"}
+		},
+	}
+	handler := NewImageHandler(mockService, testutil.TestConfig())
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/v1/images", middleware.RouteTimeout(20*time.Millisecond), handler.Upload)
+
+	req := testutil.CreateMultipartRequest("POST", "/api/v1/images", nil, "image", "test.jpg", testutil.CreateTestImageData())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	var response models.ErrorResponse
+	require.NoError(t, testutil.ParseJSONResponse(w, &response))
+	assert.Equal(t, models.ErrorCodeRequestTimeout, response.ErrorCode)
+}
+
+func TestImageHandler_Upload_CustomFieldName(t *testing.T) {
+	var received service.UploadInput
+	mockService := &mockImageService{
+		processUploadFunc: func(ctx context.Context, input service.UploadInput) (*service.UploadResult, error) {
+			received = input
+			return &service.UploadResult{ImageID: testutil.ValidUUID, ProcessedResolutions: []string{"thumbnail"}}, nil
+		},
+	}
+	cfg := testutil.TestConfig()
+	cfg.Image.UploadFieldName = "file"
+	handler := NewImageHandler(mockService, cfg)
+
+	t.Run("reads the configured field", func(t *testing.T) {
+		req := testutil.CreateMultipartRequest("POST", "/api/v1/images", nil, "file", "test.jpg", testutil.CreateTestImageData())
+		c, w := testutil.SetupTestContext(req)
+
+		handler.Upload(c)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "test.jpg", received.Filename)
+	})
+
+	t.Run("default field is no longer read", func(t *testing.T) {
+		req := testutil.CreateMultipartRequest("POST", "/api/v1/images", nil, "image", "test.jpg", testutil.CreateTestImageData())
+		c, w := testutil.SetupTestContext(req)
+
+		handler.Upload(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		var response models.ErrorResponse
+		require.NoError(t, testutil.ParseJSONResponse(w, &response))
+		assert.Equal(t, models.ErrorCodeMissingFile, response.ErrorCode)
+		assert.Contains(t, response.Message, "'file'")
+	})
+}
+
+func TestImageHandler_UploadBase64(t *testing.T) {
+	imageData := testutil.CreateTestImageData()
+	encoded := base64.StdEncoding.EncodeToString(imageData)
+
+	tests := []struct {
+		name           string
+		body           string
+		maxFileSize    int64
+		expectedStatus int
+		expectedCode   string
+		expectedInput  *service.UploadInput
+	}{
+		{
+			name:           "valid base64",
+			body:           `{"filename":"photo.jpg","data":"` + encoded + `","resolutions":["800x600, 1024x768","thumbnail"]}`,
+			expectedStatus: http.StatusCreated,
+			expectedInput: &service.UploadInput{
+				Filename:    "photo.jpg",
+				Data:        imageData,
+				Size:        int64(len(imageData)),
+				Resolutions: []string{"800x600", "1024x768", "thumbnail"},
+			},
+		},
+		{
+			name:           "data URL",
+			body:           `{"filename":"photo.jpg","data":"data:image/jpeg;base64,` + encoded + `"}`,
+			expectedStatus: http.StatusCreated,
+			expectedInput: &service.UploadInput{
+				Filename: "photo.jpg",
+				Data:     imageData,
+				Size:     int64(len(imageData)),
+			},
+		},
+		{
+			name:           "malformed base64",
+			body:           `{"filename":"photo.jpg","data":"not*valid*base64!"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   models.ErrorCodeInvalidRequest,
+		},
+		{
+			name:           "missing data",
+			body:           `{"filename":"photo.jpg"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   models.ErrorCodeInvalidRequest,
+		},
+		{
+			name:           "not JSON",
+			body:           `filename=photo.jpg`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   models.ErrorCodeInvalidRequest,
+		},
+		{
+			name:           "oversized decoded payload",
+			body:           `{"filename":"photo.jpg","data":"` + encoded + `"}`,
+			maxFileSize:    int64(len(imageData)) - 1,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedCode:   models.ErrorCodeFileTooLarge,
+		},
+		{
+			name:           "far oversized payload rejected before decoding",
+			body:           `{"filename":"photo.jpg","data":"` + encoded + `"}`,
+			maxFileSize:    8,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedCode:   models.ErrorCodeFileTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received *service.UploadInput
+			mockService := &mockImageService{
+				processUploadFunc: func(ctx context.Context, input service.UploadInput) (*service.UploadResult, error) {
+					received = &input
+					return &service.UploadResult{ImageID: testutil.ValidUUID, ProcessedResolutions: input.Resolutions}, nil
+				},
+			}
+			cfg := testutil.TestConfig()
+			if tt.maxFileSize > 0 {
+				cfg.Image.MaxFileSize = tt.maxFileSize
+			}
+			handler := NewImageHandler(mockService, cfg)
+
+			req := testutil.CreateTestRequest("POST", "/api/v1/images/base64", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			c, w := testutil.SetupTestContext(req)
+
+			handler.UploadBase64(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedInput != nil {
+				require.NotNil(t, received)
+				assert.Equal(t, *tt.expectedInput, *received)
+
+				var response models.UploadResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, testutil.ValidUUID, response.ID)
+				return
+			}
+
+			assert.Nil(t, received, "service must not be called for rejected payloads")
+			var response models.ErrorResponse
+			require.NoError(t, testutil.ParseJSONResponse(w, &response))
+			assert.Equal(t, tt.expectedCode, response.ErrorCode)
+		})
+	}
+}
+
+func TestImageHandler_UploadBase64_BodySizeBoundary(t *testing.T) {
+	cfg := testutil.TestConfig()
+	var uploaded int64
+	mockService := &mockImageService{
+		processUploadFunc: func(ctx context.Context, input service.UploadInput) (*service.UploadResult, error) {
+			uploaded = input.Size
+			return &service.UploadResult{ImageID: testutil.ValidUUID}, nil
+		},
+	}
+	handler := NewImageHandler(mockService, cfg)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.RequestSizeLimitByRoute(cfg.Server.MaxRequestBodySize, map[string]int64{
+		"/api/v1/images/base64": cfg.Base64UploadBodySize(),
+	}))
+	router.POST("/api/v1/images/base64", handler.UploadBase64)
+
+	upload := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v1/images/base64", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Content-Length", strconv.Itoa(len(body)))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	bodyOf := func(size int64) string {
+		data := make([]byte, size)
+		copy(data, testutil.CreateTestImageData())
+		return `{"filename":"photo.jpg","data":"` + base64.StdEncoding.EncodeToString(data) + `"}`
+	}
+
+	// An image of exactly MAX_FILE_SIZE is well over MAX_REQUEST_BODY_SIZE once encoded
+	body := bodyOf(cfg.Image.MaxFileSize)
+	require.Greater(t, int64(len(body)), cfg.Server.MaxRequestBodySize)
+	w := upload(body)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, cfg.Image.MaxFileSize, uploaded)
+
+	// One byte more is refused by the file size check, not the body limit
+	w = upload(bodyOf(cfg.Image.MaxFileSize + 1))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	var response models.ErrorResponse
+	require.NoError(t, testutil.ParseJSONResponse(w, &response))
+	assert.Equal(t, models.ErrorCodeFileTooLarge, response.ErrorCode)
+
+	// Bodies beyond the route's own limit are still cut off
+	w = upload(body + strings.Repeat(" ", int(cfg.Base64UploadBodySize())-len(body)+1))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	require.NoError(t, testutil.ParseJSONResponse(w, &response))
+	assert.Equal(t, models.ErrorCodeRequestTooLarge, response.ErrorCode)
+}
+
+func TestImageHandler_UploadBase64_ReportsAllFieldErrors(t *testing.T) {
+	var received service.UploadInput
+	mockService := &mockImageService{
+		processUploadFunc: func(ctx context.Context, input service.UploadInput) (*service.UploadResult, error) {
+			received = input
+			return nil, models.MultiValidationError{Errors: []models.ValidationError{
+				{Field: "filename", Message: "Filename is required"},
+				{Field: "resolutions", Message: "Invalid resolution format 'bogus'"},
+			}}
+		},
+	}
+	handler := NewImageHandler(mockService, testutil.TestConfig())
+
+	body := `{"data":"` + base64.StdEncoding.EncodeToString(testutil.CreateTestImageData()) + `","resolutions":["bogus"]}`
+	req := testutil.CreateTestRequest("POST", "/api/v1/images/base64", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	c, w := testutil.SetupTestContext(req)
+
+	handler.UploadBase64(c)
+
+	assert.Empty(t, received.Filename, "a missing filename is left to the service to report")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var response models.ErrorResponse
+	require.NoError(t, testutil.ParseJSONResponse(w, &response))
+	assert.Equal(t, models.ErrorCodeValidationFailed, response.ErrorCode)
+	require.Len(t, response.Details, 2)
+	assert.Equal(t, "filename", response.Details[0].Field)
+	assert.Equal(t, "resolutions", response.Details[1].Field)
+}
+
+func TestImageHandler_Upload_ContentTypeMismatch(t *testing.T) {
+	mismatch := &models.ContentTypeMismatch{Extension: ".png", ExtensionMimeType: "image/png", DetectedMimeType: "image/jpeg"}
+	mockService := &mockImageService{
+		processUploadFunc: func(ctx context.Context, input service.UploadInput) (*service.UploadResult, error) {
+			return &service.UploadResult{
+				ImageID:              testutil.ValidUUID,
+				ProcessedResolutions: []string{"thumbnail"},
+				ContentTypeMismatch:  mismatch,
+			}, nil
+		},
+	}
+	handler := NewImageHandler(mockService, testutil.TestConfig())
+
+	req := testutil.CreateMultipartRequest("POST", "/api/v1/images", nil, "image", "test.png", testutil.CreateTestImageData())
+	c, w := testutil.SetupTestContext(req)
+
+	handler.Upload(c)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var response models.UploadResponse
+	require.NoError(t, testutil.ParseJSONResponse(w, &response))
+	assert.Equal(t, mismatch, response.ContentTypeMismatch)
+}
+
 func TestImageHandler_Upload_EdgeCases(t *testing.T) {
 	cfg := testutil.TestConfig()
 	mockService := &mockImageService{}
@@ -218,105 +777,1415 @@ func TestImageHandler_Upload_EdgeCases(t *testing.T) {
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 	})
 
-	t.Run("multiple resolution fields", func(t *testing.T) {
-		formData := map[string]string{
-			"resolutions": "800x600",
-		}
+	t.Run("request body exceeds limit", func(t *testing.T) {
+		req := testutil.CreateMultipartRequest("POST", "/api/v1/images", map[string]string{}, "image", "test.jpg", testutil.CreateTestImageData())
+		c, w := testutil.SetupTestContext(req)
+		c.Request.Body = http.MaxBytesReader(w, c.Request.Body, 64)
+
+		handler.Upload(c)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+
+	t.Run("multiple resolution fields", func(t *testing.T) {
+		formData := map[string]string{
+			"resolutions": "800x600",
+		}
+
+		mockService.processUploadFunc = func(ctx context.Context, input service.UploadInput) (*service.UploadResult, error) {
+			assert.Contains(t, input.Resolutions, "800x600")
+			return &service.UploadResult{
+				ImageID:              testutil.ValidUUID,
+				ProcessedResolutions: []string{"original", "800x600"},
+			}, nil
+		}
+
+		req := testutil.CreateMultipartRequest("POST", "/api/v1/images", formData, "image", "test.jpg", testutil.CreateTestImageData())
+		c, w := testutil.SetupTestContext(req)
+
+		handler.Upload(c)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("checksum from header", func(t *testing.T) {
+		mockService.processUploadFunc = func(ctx context.Context, input service.UploadInput) (*service.UploadResult, error) {
+			assert.Equal(t, "abc123", input.ExpectedChecksum)
+			return &service.UploadResult{ImageID: testutil.ValidUUID}, nil
+		}
+
+		req := testutil.CreateMultipartRequest("POST", "/api/v1/images", map[string]string{"checksum": "ignored"}, "image", "test.jpg", testutil.CreateTestImageData())
+		req.Header.Set(ChecksumHeader, "abc123")
+		c, w := testutil.SetupTestContext(req)
+
+		handler.Upload(c)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("checksum from form field", func(t *testing.T) {
+		mockService.processUploadFunc = func(ctx context.Context, input service.UploadInput) (*service.UploadResult, error) {
+			assert.Equal(t, "def456", input.ExpectedChecksum)
+			return &service.UploadResult{ImageID: testutil.ValidUUID}, nil
+		}
+
+		req := testutil.CreateMultipartRequest("POST", "/api/v1/images", map[string]string{"checksum": "def456"}, "image", "test.jpg", testutil.CreateTestImageData())
+		c, w := testutil.SetupTestContext(req)
+
+		handler.Upload(c)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("dedup disabled per upload", func(t *testing.T) {
+		mockService.processUploadFunc = func(ctx context.Context, input service.UploadInput) (*service.UploadResult, error) {
+			assert.True(t, input.DisableDedup)
+			return &service.UploadResult{ImageID: testutil.ValidUUID}, nil
+		}
+
+		req := testutil.CreateMultipartRequest("POST", "/api/v1/images", map[string]string{"dedup": "false"}, "image", "test.jpg", testutil.CreateTestImageData())
+		c, w := testutil.SetupTestContext(req)
+
+		handler.Upload(c)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("invalid dedup value", func(t *testing.T) {
+		req := testutil.CreateMultipartRequest("POST", "/api/v1/images", map[string]string{"dedup": "maybe"}, "image", "test.jpg", testutil.CreateTestImageData())
+		c, w := testutil.SetupTestContext(req)
+
+		handler.Upload(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("checksum mismatch", func(t *testing.T) {
+		mockService.processUploadFunc = func(ctx context.Context, input service.UploadInput) (*service.UploadResult, error) {
+			return nil, models.ValidationError{Field: "checksum", Message: "Checksum mismatch"}
+		}
+
+		req := testutil.CreateMultipartRequest("POST", "/api/v1/images", map[string]string{"checksum": "def456"}, "image", "test.jpg", testutil.CreateTestImageData())
+		c, w := testutil.SetupTestContext(req)
+
+		handler.Upload(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		var response models.ErrorResponse
+		require.NoError(t, testutil.ParseJSONResponse(w, &response))
+		assert.Equal(t, models.ErrorCodeChecksumMismatch, response.ErrorCode)
+	})
+}
+
+func TestImageHandler_Upload_DedupNamespace(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		setup    func(c *gin.Context)
+		expected string
+	}{
+		{
+			name:     "global by default",
+			source:   "none",
+			setup:    func(c *gin.Context) { c.Request.Header.Set("X-Tenant-ID", "tenant-a") },
+			expected: "",
+		},
+		{
+			name:     "from header",
+			source:   "header",
+			setup:    func(c *gin.Context) { c.Request.Header.Set("X-Tenant-ID", " tenant-a ") },
+			expected: "tenant-a",
+		},
+		{
+			name:     "from authenticated api key",
+			source:   "api_key",
+			setup:    func(c *gin.Context) { c.Set(middleware.AuthKeyIDKey, "key-id") },
+			expected: "key-id",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testutil.TestConfig()
+			cfg.Image.DedupNamespaceSource = tt.source
+
+			mockService := &mockImageService{
+				processUploadFunc: func(ctx context.Context, input service.UploadInput) (*service.UploadResult, error) {
+					assert.Equal(t, tt.expected, input.Namespace)
+					return &service.UploadResult{ImageID: testutil.ValidUUID}, nil
+				},
+			}
+			handler := NewImageHandler(mockService, cfg)
+
+			req := testutil.CreateMultipartRequest("POST", "/api/v1/images", map[string]string{}, "image", "test.jpg", testutil.CreateTestImageData())
+			c, w := testutil.SetupTestContext(req)
+			tt.setup(c)
+
+			handler.Upload(c)
+
+			assert.Equal(t, http.StatusCreated, w.Code)
+		})
+	}
+}
+
+func TestImageHandler_Info(t *testing.T) {
+	tests := []struct {
+		name           string
+		imageID        string
+		setupMock      func(*mockImageService)
+		expectedStatus int
+		expectError    bool
+	}{
+		{
+			name:    "successful info retrieval",
+			imageID: testutil.ValidUUID,
+			setupMock: func(mock *mockImageService) {
+				mock.getMetadataFunc = func(ctx context.Context, imageID string) (*models.ImageMetadata, error) {
+					return testutil.CreateTestImageMetadata(), nil
+				}
+			},
+			expectedStatus: http.StatusOK,
+			expectError:    false,
+		},
+		{
+			name:           "invalid UUID",
+			imageID:        testutil.InvalidUUID,
+			setupMock:      func(mock *mockImageService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectError:    true,
+		},
+		{
+			name:    "image not found",
+			imageID: testutil.ValidUUID,
+			setupMock: func(mock *mockImageService) {
+				mock.getMetadataFunc = func(ctx context.Context, imageID string) (*models.ImageMetadata, error) {
+					return nil, models.NotFoundError{
+						Resource: "image",
+						ID:       imageID,
+					}
+				}
+			},
+			expectedStatus: http.StatusNotFound,
+			expectError:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockService := &mockImageService{}
+			tt.setupMock(mockService)
+
+			handler := NewImageHandler(mockService, testutil.TestConfig())
+
+			// Create test context with URL parameter
+			req := testutil.CreateTestRequest("GET", fmt.Sprintf("/api/v1/images/%s/info", tt.imageID), nil)
+			c, w := testutil.SetupTestContext(req)
+			c.AddParam("id", tt.imageID)
+
+			// Execute
+			handler.Info(c)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			err := testutil.ParseJSONResponse(w, &response)
+			assert.NoError(t, err)
+
+			if tt.expectError {
+				assert.Contains(t, response, "error")
+				assert.Contains(t, response, "message")
+			} else {
+				assert.Equal(t, tt.imageID, response["id"])
+				assert.Contains(t, response, "filename")
+				assert.Contains(t, response, "mime_type")
+				assert.Contains(t, response, "dimensions")
+				assert.Contains(t, response, "available_resolutions")
+			}
+		})
+	}
+}
+
+func TestImageHandler_Update(t *testing.T) {
+	tests := []struct {
+		name           string
+		imageID        string
+		body           string
+		setupMock      func(*mockImageService)
+		expectedStatus int
+	}{
+		{
+			name:    "successful rename",
+			imageID: testutil.ValidUUID,
+			body:    `{"filename": "holiday.jpg"}`,
+			setupMock: func(mock *mockImageService) {
+				mock.updateFilenameFunc = func(ctx context.Context, imageID, filename string) (*models.ImageMetadata, error) {
+					assert.Equal(t, testutil.ValidUUID, imageID)
+					assert.Equal(t, "holiday.jpg", filename)
+					metadata := testutil.CreateTestImageMetadata()
+					metadata.Filename = filename
+					return metadata, nil
+				}
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid UUID",
+			imageID:        testutil.InvalidUUID,
+			body:           `{"filename": "holiday.jpg"}`,
+			setupMock:      func(mock *mockImageService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "missing filename",
+			imageID:        testutil.ValidUUID,
+			body:           `{}`,
+			setupMock:      func(mock *mockImageService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:    "extension change rejected",
+			imageID: testutil.ValidUUID,
+			body:    `{"filename": "holiday.png"}`,
+			setupMock: func(mock *mockImageService) {
+				mock.updateFilenameFunc = func(ctx context.Context, imageID, filename string) (*models.ImageMetadata, error) {
+					return nil, models.ValidationError{Field: "filename", Message: "Filename extension must remain '.jpg'"}
+				}
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:    "image not found",
+			imageID: testutil.ValidUUID,
+			body:    `{"filename": "holiday.jpg"}`,
+			setupMock: func(mock *mockImageService) {
+				mock.updateFilenameFunc = func(ctx context.Context, imageID, filename string) (*models.ImageMetadata, error) {
+					return nil, models.NotFoundError{Resource: "image", ID: imageID}
+				}
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockImageService{}
+			tt.setupMock(mockService)
+
+			handler := NewImageHandler(mockService, testutil.TestConfig())
+
+			req := testutil.CreateTestRequest("PATCH", "/api/v1/images/"+tt.imageID, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			c, w := testutil.SetupTestContext(req)
+			c.AddParam("id", tt.imageID)
+
+			handler.Update(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			err := testutil.ParseJSONResponse(w, &response)
+			assert.NoError(t, err)
+
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, "holiday.jpg", response["filename"])
+			} else {
+				assert.Contains(t, response, "error")
+			}
+		})
+	}
+}
+
+func TestImageHandler_AddResolution(t *testing.T) {
+	tests := []struct {
+		name           string
+		imageID        string
+		query          string
+		body           string
+		expectedForce  bool
+		serviceErr     error
+		expectedStatus int
+	}{
+		{
+			name:           "add new resolution",
+			imageID:        testutil.ValidUUID,
+			body:           `{"resolution": "1024x768"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "force regenerate",
+			imageID:        testutil.ValidUUID,
+			query:          "?force=true",
+			body:           `{"resolution": "800x600"}`,
+			expectedForce:  true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid force value",
+			imageID:        testutil.ValidUUID,
+			query:          "?force=maybe",
+			body:           `{"resolution": "800x600"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "missing resolution",
+			imageID:        testutil.ValidUUID,
+			body:           `{}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid UUID",
+			imageID:        testutil.InvalidUUID,
+			body:           `{"resolution": "800x600"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid resolution",
+			imageID:        testutil.ValidUUID,
+			body:           `{"resolution": "huge"}`,
+			serviceErr:     models.ValidationError{Field: "resolution", Message: "invalid resolution format"},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			mockService := &mockImageService{
+				processResolutionFunc: func(ctx context.Context, imageID, resolution string, force bool) error {
+					called = true
+					assert.Equal(t, tt.expectedForce, force)
+					return tt.serviceErr
+				},
+				getMetadataFunc: func(ctx context.Context, imageID string) (*models.ImageMetadata, error) {
+					return testutil.CreateTestImageMetadata(), nil
+				},
+			}
+
+			handler := NewImageHandler(mockService, testutil.TestConfig())
+
+			req := testutil.CreateTestRequest("POST", "/api/v1/images/"+tt.imageID+"/resolutions"+tt.query, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			c, w := testutil.SetupTestContext(req)
+			c.AddParam("id", tt.imageID)
+
+			handler.AddResolution(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			assert.NoError(t, testutil.ParseJSONResponse(w, &response))
+			if tt.expectedStatus == http.StatusOK {
+				assert.True(t, called)
+				assert.Contains(t, response, "available_resolutions")
+			} else {
+				assert.Contains(t, response, "error")
+			}
+		})
+	}
+}
+
+func TestImageHandler_AddResolution_MaxBytes(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		serviceErr     error
+		expectedStatus int
+	}{
+		{
+			name:           "size-capped resolution",
+			body:           `{"resolution": "800x600", "max_bytes": 102400}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "budget rejected by service",
+			body:           `{"resolution": "800x600", "max_bytes": 10}`,
+			serviceErr:     models.ValidationError{Field: "max_bytes", Message: "max_bytes must be between 1024 and 10485760"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "budget cannot be met",
+			body:           `{"resolution": "800x600", "max_bytes": 2048}`,
+			serviceErr:     models.ProcessingError{Operation: "resize", Reason: "output does not fit in 2048 bytes even at quality 10"},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotMaxBytes int64
+			mockService := &mockImageService{
+				processResolutionFunc: func(ctx context.Context, imageID, resolution string, force bool) error {
+					t.Fatal("ProcessResolution should not be called with max_bytes")
+					return nil
+				},
+				processWithinSizeFunc: func(ctx context.Context, imageID, resolution string, maxBytes int64, force bool) (string, error) {
+					gotMaxBytes = maxBytes
+					return models.SizeBudgetResolutionName(resolution, maxBytes), tt.serviceErr
+				},
+				getMetadataFunc: func(ctx context.Context, imageID string) (*models.ImageMetadata, error) {
+					return testutil.CreateTestImageMetadata(), nil
+				},
+			}
+
+			handler := NewImageHandler(mockService, testutil.TestConfig())
+
+			req := testutil.CreateTestRequest("POST", "/api/v1/images/"+testutil.ValidUUID+"/resolutions", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			c, w := testutil.SetupTestContext(req)
+			c.AddParam("id", testutil.ValidUUID)
+
+			handler.AddResolution(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.NotZero(t, gotMaxBytes)
+		})
+	}
+}
+
+func TestImageHandler_DownloadRejectsMaxBytes(t *testing.T) {
+	mockService := &mockImageService{
+		processWithinSizeFunc: func(ctx context.Context, imageID, resolution string, maxBytes int64, force bool) (string, error) {
+			t.Fatal("downloads must not run the size search")
+			return "", nil
+		},
+		getImageStreamFunc: func(ctx context.Context, imageID, resolution string) (io.ReadCloser, *models.ImageMetadata, error) {
+			t.Fatal("a download with max_bytes must not be served")
+			return nil, nil, nil
+		},
+	}
+	handler := NewImageHandler(mockService, testutil.TestConfig())
+
+	req := testutil.CreateTestRequest("GET", fmt.Sprintf("/api/v1/images/%s/800x600?max_bytes=102400", testutil.ValidUUID), nil)
+	c, w := testutil.SetupTestContext(req)
+	c.AddParam("id", testutil.ValidUUID)
+	c.AddParam("resolution", "800x600")
+
+	handler.DownloadCustomResolution(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var response models.ErrorResponse
+	require.NoError(t, testutil.ParseJSONResponse(w, &response))
+	assert.Equal(t, models.ErrorCodeInvalidRequest, response.ErrorCode)
+	assert.Contains(t, response.Message, "POST /api/v1/images/{id}/resolutions")
+}
+
+func TestImageHandler_Estimate(t *testing.T) {
+	tests := []struct {
+		name           string
+		imageID        string
+		body           string
+		setupMock      func(*mockImageService)
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:    "successful estimate",
+			imageID: testutil.ValidUUID,
+			body:    `{"resolution": "800x600", "mode": "crop"}`,
+			setupMock: func(mock *mockImageService) {
+				mock.estimateResizeFunc = func(ctx context.Context, imageID, resolution, mode string) (*models.EstimateResponse, error) {
+					assert.Equal(t, testutil.ValidUUID, imageID)
+					assert.Equal(t, "800x600", resolution)
+					assert.Equal(t, "crop", mode)
+					return &models.EstimateResponse{
+						ImageID:        imageID,
+						Resolution:     resolution,
+						Mode:           mode,
+						Source:         models.DimensionInfo{Width: 1600, Height: 900},
+						Output:         models.DimensionInfo{Width: 800, Height: 600},
+						Content:        models.DimensionInfo{Width: 1066, Height: 600},
+						AspectHandling: "cropped",
+					}, nil
+				}
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid UUID",
+			imageID:        testutil.InvalidUUID,
+			body:           `{"resolution": "800x600"}`,
+			setupMock:      func(mock *mockImageService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   models.ErrorCodeInvalidImageID,
+		},
+		{
+			name:           "missing resolution",
+			imageID:        testutil.ValidUUID,
+			body:           `{"mode": "crop"}`,
+			setupMock:      func(mock *mockImageService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   models.ErrorCodeInvalidRequest,
+		},
+		{
+			name:    "invalid mode",
+			imageID: testutil.ValidUUID,
+			body:    `{"resolution": "800x600", "mode": "zoom"}`,
+			setupMock: func(mock *mockImageService) {
+				mock.estimateResizeFunc = func(ctx context.Context, imageID, resolution, mode string) (*models.EstimateResponse, error) {
+					return nil, models.ValidationError{Field: "mode", Message: "Invalid resize mode 'zoom'"}
+				}
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   models.ErrorCodeValidationFailed,
+		},
+		{
+			name:    "image not found",
+			imageID: testutil.ValidUUID,
+			body:    `{"resolution": "800x600"}`,
+			setupMock: func(mock *mockImageService) {
+				mock.estimateResizeFunc = func(ctx context.Context, imageID, resolution, mode string) (*models.EstimateResponse, error) {
+					return nil, models.NotFoundError{Resource: "image", ID: imageID}
+				}
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockImageService{}
+			tt.setupMock(mockService)
+
+			handler := NewImageHandler(mockService, testutil.TestConfig())
+
+			req := testutil.CreateTestRequest("POST", "/api/v1/images/"+tt.imageID+"/estimate", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			c, w := testutil.SetupTestContext(req)
+			c.AddParam("id", tt.imageID)
+
+			handler.Estimate(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			err := testutil.ParseJSONResponse(w, &response)
+			assert.NoError(t, err)
+
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, "cropped", response["aspect_handling"])
+				output := response["output"].(map[string]interface{})
+				assert.Equal(t, float64(800), output["width"])
+				assert.Equal(t, float64(600), output["height"])
+			} else {
+				assert.Contains(t, response, "error")
+				if tt.expectedCode != "" {
+					assert.Equal(t, tt.expectedCode, response["error_code"])
+				}
+			}
+		})
+	}
+}
+
+func TestImageHandler_Exists(t *testing.T) {
+	hash := strings.Repeat("ab", 32)
+
+	tests := []struct {
+		name           string
+		body           string
+		setupMock      func(*mockImageService)
+		expectedStatus int
+		expectedCode   string
+		expectedExists bool
+	}{
+		{
+			name: "present hash",
+			body: `{"hash": "` + hash + `", "size": 1024}`,
+			setupMock: func(mock *mockImageService) {
+				mock.findByHashFunc = func(ctx context.Context, checksum string, size int64, namespace string) (*models.ExistsResponse, error) {
+					assert.Equal(t, hash, checksum)
+					assert.Equal(t, int64(1024), size)
+					return &models.ExistsResponse{Exists: true, Hash: hash, ImageID: testutil.ValidUUID}, nil
+				}
+			},
+			expectedStatus: http.StatusOK,
+			expectedExists: true,
+		},
+		{
+			name: "absent hash",
+			body: `{"hash": "` + hash + `"}`,
+			setupMock: func(mock *mockImageService) {
+				mock.findByHashFunc = func(ctx context.Context, checksum string, size int64, namespace string) (*models.ExistsResponse, error) {
+					return &models.ExistsResponse{Hash: hash}, nil
+				}
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing hash",
+			body:           `{"size": 1024}`,
+			setupMock:      func(mock *mockImageService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   models.ErrorCodeInvalidRequest,
+		},
+		{
+			name: "malformed hash",
+			body: `{"hash": "not-a-hash"}`,
+			setupMock: func(mock *mockImageService) {
+				mock.findByHashFunc = func(ctx context.Context, checksum string, size int64, namespace string) (*models.ExistsResponse, error) {
+					return nil, models.ValidationError{Field: "hash", Message: "Hash must be a 64-character hex-encoded SHA256 digest"}
+				}
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   models.ErrorCodeValidationFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockImageService{}
+			tt.setupMock(mockService)
+
+			handler := NewImageHandler(mockService, testutil.TestConfig())
+
+			req := testutil.CreateTestRequest("POST", "/api/v1/images/exists", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			c, w := testutil.SetupTestContext(req)
+
+			handler.Exists(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			assert.NoError(t, testutil.ParseJSONResponse(w, &response))
+
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, tt.expectedExists, response["exists"])
+				if tt.expectedExists {
+					assert.Equal(t, testutil.ValidUUID, response["image_id"])
+				} else {
+					assert.NotContains(t, response, "image_id")
+				}
+			} else {
+				assert.Equal(t, tt.expectedCode, response["error_code"])
+			}
+		})
+	}
+}
+
+func TestImageHandler_StorageKeys(t *testing.T) {
+	tests := []struct {
+		name           string
+		imageID        string
+		serviceErr     error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "keys returned",
+			imageID:        testutil.ValidUUID,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid UUID",
+			imageID:        testutil.InvalidUUID,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   models.ErrorCodeInvalidImageID,
+		},
+		{
+			name:           "image not found",
+			imageID:        testutil.ValidUUID,
+			serviceErr:     models.NotFoundError{Resource: "image", ID: testutil.ValidUUID},
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   models.ErrorCodeImageNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expected := &models.StorageKeysResponse{
+				ID:          testutil.ValidUUID,
+				Bucket:      "test-bucket",
+				Original:    "images/" + testutil.ValidUUID + "/original.jpg",
+				Resolutions: map[string]string{"800x600": "images/" + testutil.ValidUUID + "/800x600.jpg"},
+			}
+			mockService := &mockImageService{
+				getStorageKeysFunc: func(ctx context.Context, imageID string) (*models.StorageKeysResponse, error) {
+					if tt.serviceErr != nil {
+						return nil, tt.serviceErr
+					}
+					return expected, nil
+				},
+			}
+			handler := NewImageHandler(mockService, testutil.TestConfig())
+
+			req := testutil.CreateTestRequest("GET", "/api/v1/images/"+tt.imageID+"/keys", nil)
+			c, w := testutil.SetupTestContext(req)
+			c.AddParam("id", tt.imageID)
+
+			handler.StorageKeys(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus != http.StatusOK {
+				var response map[string]interface{}
+				assert.NoError(t, testutil.ParseJSONResponse(w, &response))
+				assert.Equal(t, tt.expectedCode, response["error_code"])
+				return
+			}
+
+			var response models.StorageKeysResponse
+			assert.NoError(t, testutil.ParseJSONResponse(w, &response))
+			assert.Equal(t, *expected, response)
+		})
+	}
+}
+
+func TestImageHandler_Verify(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		serviceErr     error
+		expectedStatus int
+		expectedHash   bool
+	}{
+		{name: "existence only", expectedStatus: http.StatusOK},
+		{name: "with hash check", query: "?hash=true", expectedStatus: http.StatusOK, expectedHash: true},
+		{name: "invalid hash flag", query: "?hash=maybe", expectedStatus: http.StatusBadRequest},
+		{
+			name:           "image not found",
+			serviceErr:     models.NotFoundError{Resource: "image", ID: testutil.ValidUUID},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotHash bool
+			mockService := &mockImageService{
+				verifyImageFunc: func(ctx context.Context, imageID string, checkHash bool) (*models.VerificationResponse, error) {
+					gotHash = checkHash
+					if tt.serviceErr != nil {
+						return nil, tt.serviceErr
+					}
+					return &models.VerificationResponse{ID: imageID, Intact: true, HashChecked: checkHash}, nil
+				},
+			}
+			handler := NewImageHandler(mockService, testutil.TestConfig())
+
+			req := testutil.CreateTestRequest("GET", "/api/v1/images/"+testutil.ValidUUID+"/verify"+tt.query, nil)
+			c, w := testutil.SetupTestContext(req)
+			c.AddParam("id", testutil.ValidUUID)
+
+			handler.Verify(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedHash, gotHash)
+		})
+	}
+}
+
+func TestImageHandler_FindByFilename(t *testing.T) {
+	secondID := "650e8400-e29b-41d4-a716-446655440000"
+
+	tests := []struct {
+		name           string
+		filename       string
+		ids            []string
+		serviceErr     error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "one image",
+			filename:       "banner.jpg",
+			ids:            []string{testutil.ValidUUID},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "multiple images",
+			filename:       "photo.jpg",
+			ids:            []string{testutil.ValidUUID, secondID},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "no match",
+			filename:       "missing.jpg",
+			serviceErr:     models.NotFoundError{Resource: "image", ID: "missing.jpg"},
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   models.ErrorCodeImageNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockImageService{
+				findByFilenameFunc: func(ctx context.Context, filename string) (*models.FilenameLookupResponse, error) {
+					assert.Equal(t, tt.filename, filename)
+					if tt.serviceErr != nil {
+						return nil, tt.serviceErr
+					}
+					return &models.FilenameLookupResponse{Filename: filename, ImageIDs: tt.ids}, nil
+				},
+			}
+			handler := NewImageHandler(mockService, testutil.TestConfig())
+
+			req := testutil.CreateTestRequest("GET", "/api/v1/images/by-filename/"+tt.filename, nil)
+			c, w := testutil.SetupTestContext(req)
+			c.AddParam("name", tt.filename)
+
+			handler.FindByFilename(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus != http.StatusOK {
+				var response map[string]interface{}
+				assert.NoError(t, testutil.ParseJSONResponse(w, &response))
+				assert.Equal(t, tt.expectedCode, response["error_code"])
+				return
+			}
+
+			var response models.FilenameLookupResponse
+			assert.NoError(t, testutil.ParseJSONResponse(w, &response))
+			assert.Equal(t, tt.filename, response.Filename)
+			assert.Equal(t, tt.ids, response.ImageIDs)
+		})
+	}
+}
+
+func TestImageHandler_Crop(t *testing.T) {
+	tests := []struct {
+		name           string
+		imageID        string
+		body           string
+		setupMock      func(*mockImageService)
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:    "successful crop",
+			imageID: testutil.ValidUUID,
+			body:    `{"x": 10, "y": 20, "width": 300, "height": 200}`,
+			setupMock: func(mock *mockImageService) {
+				mock.cropImageFunc = func(ctx context.Context, imageID string, rect models.CropRect) (string, error) {
+					assert.Equal(t, models.CropRect{X: 10, Y: 20, Width: 300, Height: 200}, rect)
+					return rect.ResolutionName(), nil
+				}
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "invalid UUID",
+			imageID:        testutil.InvalidUUID,
+			body:           `{"x": 0, "y": 0, "width": 10, "height": 10}`,
+			setupMock:      func(mock *mockImageService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   models.ErrorCodeInvalidImageID,
+		},
+		{
+			name:           "missing size",
+			imageID:        testutil.ValidUUID,
+			body:           `{"x": 0, "y": 0}`,
+			setupMock:      func(mock *mockImageService) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   models.ErrorCodeInvalidRequest,
+		},
+		{
+			name:    "rectangle out of bounds",
+			imageID: testutil.ValidUUID,
+			body:    `{"x": 700, "y": 0, "width": 300, "height": 200}`,
+			setupMock: func(mock *mockImageService) {
+				mock.cropImageFunc = func(ctx context.Context, imageID string, rect models.CropRect) (string, error) {
+					return "", models.ValidationError{Field: "crop", Message: "Crop rectangle lies outside the source image"}
+				}
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   models.ErrorCodeValidationFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockImageService{}
+			tt.setupMock(mockService)
+
+			handler := NewImageHandler(mockService, testutil.TestConfig())
+
+			req := testutil.CreateTestRequest("POST", "/api/v1/images/"+tt.imageID+"/crop", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			c, w := testutil.SetupTestContext(req)
+			c.AddParam("id", tt.imageID)
+
+			handler.Crop(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			assert.NoError(t, testutil.ParseJSONResponse(w, &response))
+
+			if tt.expectedStatus == http.StatusCreated {
+				assert.Equal(t, "crop-10-20-300x200", response["resolution"])
+				dimensions := response["dimensions"].(map[string]interface{})
+				assert.Equal(t, float64(300), dimensions["width"])
+				assert.Equal(t, float64(200), dimensions["height"])
+			} else {
+				assert.Contains(t, response, "error")
+				assert.Equal(t, tt.expectedCode, response["error_code"])
+			}
+		})
+	}
+}
+
+func TestImageHandler_DownloadStopsOnClientDisconnect(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The client disconnects after the second chunk has been read from storage
+	stream := &endlessStream{onRead: func(reads int) {
+		if reads == 2 {
+			cancel()
+		}
+	}}
+	mockService := &mockImageService{
+		getImageStreamFunc: func(ctx context.Context, imageID, resolution string) (io.ReadCloser, *models.ImageMetadata, error) {
+			return stream, testutil.CreateTestImageMetadata(), nil
+		},
+	}
+	handler := NewImageHandler(mockService, testutil.TestConfig())
+
+	req := testutil.CreateTestRequest("GET", "/api/v1/images/"+testutil.ValidUUID+"/original", nil).WithContext(ctx)
+	c, _ := testutil.SetupTestContext(req)
+	c.AddParam("id", testutil.ValidUUID)
+
+	done := make(chan struct{})
+	go func() {
+		handler.DownloadOriginal(c)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("download kept streaming after the client disconnected")
+	}
+	assert.Equal(t, 2, stream.reads)
+	assert.True(t, stream.closed)
+}
+
+func TestImageHandler_DownloadAfterRename(t *testing.T) {
+	renamed := testutil.CreateTestImageMetadata()
+	renamed.Filename = "holiday.jpg"
+
+	mockService := &mockImageService{
+		getImageStreamFunc: func(ctx context.Context, imageID, resolution string) (io.ReadCloser, *models.ImageMetadata, error) {
+			return testutil.NewMockReadCloser(testutil.CreateTestImageData()), renamed, nil
+		},
+	}
+
+	handler := NewImageHandler(mockService, testutil.TestConfig())
+
+	req := testutil.CreateTestRequest("GET", fmt.Sprintf("/api/v1/images/%s/thumbnail", testutil.ValidUUID), nil)
+	c, w := testutil.SetupTestContext(req)
+	c.AddParam("id", testutil.ValidUUID)
+
+	handler.DownloadThumbnail(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `inline; filename="holiday_thumbnail.jpg"`, w.Header().Get("Content-Disposition"))
+}
+
+func TestImageHandler_DownloadStrictContentType(t *testing.T) {
+	tests := []struct {
+		name           string
+		data           []byte
+		allowed        []string
+		expectedStatus int
+	}{
+		{
+			name:           "allowed type served",
+			data:           testutil.CreateTestImageData(),
+			allowed:        []string{"image/jpeg", "image/png"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "tampered object rejected",
+			data:           []byte("<html><script>alert(1)</script></html>"),
+			allowed:        []string{"image/jpeg", "image/png"},
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:           "image type outside the allowlist rejected",
+			data:           testutil.CreateTestImageData(),
+			allowed:        []string{"image/png"},
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockImageService{
+				getImageStreamFunc: func(ctx context.Context, imageID, resolution string) (io.ReadCloser, *models.ImageMetadata, error) {
+					return testutil.NewMockReadCloser(tt.data), testutil.CreateTestImageMetadata(), nil
+				},
+			}
+			cfg := testutil.TestConfig()
+			cfg.Image.DownloadStrictContentType = true
+			cfg.Image.DownloadAllowedTypes = tt.allowed
+			handler := NewImageHandler(mockService, cfg)
+
+			req := testutil.CreateTestRequest("GET", fmt.Sprintf("/api/v1/images/%s/original", testutil.ValidUUID), nil)
+			c, w := testutil.SetupTestContext(req)
+			c.AddParam("id", testutil.ValidUUID)
+
+			handler.DownloadOriginal(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				// The sniffed bytes are still part of the body
+				assert.Equal(t, tt.data, w.Body.Bytes())
+				return
+			}
+			var response map[string]interface{}
+			assert.NoError(t, testutil.ParseJSONResponse(w, &response))
+			assert.Equal(t, models.ErrorCodeUnsupportedMediaType, response["error_code"])
+		})
+	}
+}
+
+func TestImageHandler_DownloadFormatVariant(t *testing.T) {
+	var requested []string
+	mockService := &mockImageService{
+		getVariantStreamFunc: func(ctx context.Context, imageID, resolution, format string) (io.ReadCloser, *models.ImageMetadata, error) {
+			requested = append(requested, resolution+"."+format)
+			if format == "png" {
+				return nil, nil, models.NotFoundError{Resource: "format variant", ID: imageID + "/" + resolution + "." + format}
+			}
+			return testutil.NewMockReadCloser(testutil.CreateTestImageData()), testutil.CreateTestImageMetadata(), nil
+		},
+	}
+	handler := NewImageHandler(mockService, testutil.TestConfig())
+
+	download := func(format string) *httptest.ResponseRecorder {
+		req := testutil.CreateTestRequest("GET", fmt.Sprintf("/api/v1/images/%s/800x600?format=%s", testutil.ValidUUID, format), nil)
+		c, w := testutil.SetupTestContext(req)
+		c.AddParam("id", testutil.ValidUUID)
+		c.AddParam("resolution", "800x600")
+		handler.DownloadCustomResolution(c)
+		return w
+	}
+
+	w := download("webp")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/webp", w.Header().Get("Content-Type"))
+	assert.Equal(t, fmt.Sprintf(`"%s-800x600-webp"`, testutil.ValidUUID), w.Header().Get("ETag"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), ".webp")
+
+	assert.Equal(t, http.StatusNotFound, download("png").Code)
+	assert.Equal(t, []string{"800x600.webp", "800x600.png"}, requested)
+}
+
+func TestImageHandler_DownloadThumbnailWithExtension(t *testing.T) {
+	var converted, served []string
+	mockService := &mockImageService{
+		processFormatVariantFunc: func(ctx context.Context, imageID, resolution, format string) error {
+			converted = append(converted, resolution+"."+format)
+			return nil
+		},
+		getVariantStreamFunc: func(ctx context.Context, imageID, resolution, format string) (io.ReadCloser, *models.ImageMetadata, error) {
+			served = append(served, resolution+"."+format)
+			return testutil.NewMockReadCloser(testutil.CreateTestImageData()), testutil.CreateTestImageMetadata(), nil
+		},
+	}
+	handler := NewImageHandler(mockService, testutil.TestConfig())
+
+	downloadWith := func(resolution string, maintenance bool) *httptest.ResponseRecorder {
+		req := testutil.CreateTestRequest("GET", fmt.Sprintf("/api/v1/images/%s/%s", testutil.ValidUUID, resolution), nil)
+		c, w := testutil.SetupTestContext(req)
+		c.AddParam("id", testutil.ValidUUID)
+		c.AddParam("resolution", resolution)
+		c.Set(middleware.MaintenanceKey, maintenance)
+		handler.DownloadCustomResolution(c)
+		return w
+	}
+	download := func(resolution string) *httptest.ResponseRecorder {
+		return downloadWith(resolution, false)
+	}
+
+	w := download("thumbnail.webp")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/webp", w.Header().Get("Content-Type"))
+	assert.Equal(t, fmt.Sprintf(`"%s-thumbnail-webp"`, testutil.ValidUUID), w.Header().Get("ETag"))
+
+	w = download("thumbnail.jpg")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/jpeg", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), ".jpg")
+
+	assert.Equal(t, []string{"thumbnail.webp", "thumbnail.jpeg"}, converted)
+	assert.Equal(t, converted, served, "the stored conversion is streamed")
+
+	t.Run("unsupported extension", func(t *testing.T) {
+		for _, resolution := range []string{"thumbnail.tiff", "thumbnail.bmp", "thumbnail."} {
+			w := download(resolution)
+			assert.Equal(t, http.StatusBadRequest, w.Code, resolution)
+			var response models.ErrorResponse
+			require.NoError(t, testutil.ParseJSONResponse(w, &response))
+			assert.Equal(t, models.ErrorCodeValidationFailed, response.ErrorCode)
+		}
+		assert.Len(t, converted, 2, "nothing is converted")
+	})
+
+	t.Run("invalid image ID", func(t *testing.T) {
+		req := testutil.CreateTestRequest("GET", "/api/v1/images/not-a-uuid/thumbnail.webp", nil)
+		c, w := testutil.SetupTestContext(req)
+		c.AddParam("id", "not-a-uuid")
+		c.AddParam("resolution", "thumbnail.webp")
+		handler.DownloadCustomResolution(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		var response models.ErrorResponse
+		require.NoError(t, testutil.ParseJSONResponse(w, &response))
+		assert.Equal(t, models.ErrorCodeInvalidImageID, response.ErrorCode)
+		assert.Len(t, converted, 2, "nothing is converted")
+	})
+
+	t.Run("maintenance serves only stored conversions", func(t *testing.T) {
+		converted, served = nil, nil
+
+		w := downloadWith("thumbnail.webp", true)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "image/webp", w.Header().Get("Content-Type"))
+		assert.Equal(t, []string{"thumbnail.webp"}, served)
+
+		mockService.getVariantStreamFunc = func(ctx context.Context, imageID, resolution, format string) (io.ReadCloser, *models.ImageMetadata, error) {
+			return nil, nil, models.NotFoundError{Resource: "format variant", ID: imageID + "/" + resolution + "." + format}
+		}
+		w = downloadWith("thumbnail.gif", true)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		var response models.ErrorResponse
+		require.NoError(t, testutil.ParseJSONResponse(w, &response))
+		assert.Equal(t, models.ErrorCodeMaintenance, response.ErrorCode)
+
+		mockService.getVariantStreamFunc = func(ctx context.Context, imageID, resolution, format string) (io.ReadCloser, *models.ImageMetadata, error) {
+			return nil, nil, models.NotFoundError{Resource: "image", ID: imageID}
+		}
+		assert.Equal(t, http.StatusNotFound, downloadWith("thumbnail.gif", true).Code)
+
+		assert.Empty(t, converted, "nothing is converted during maintenance")
+	})
+
+	t.Run("conversion failure", func(t *testing.T) {
+		mockService.processFormatVariantFunc = func(ctx context.Context, imageID, resolution, format string) error {
+			return models.NotFoundError{Resource: "image", ID: imageID}
+		}
+		assert.Equal(t, http.StatusNotFound, download("thumbnail.png").Code)
+	})
+}
+
+func TestImageHandler_DownloadRecordsAccess(t *testing.T) {
+	var recorded []string
+	mockService := &mockImageService{
+		getImageStreamFunc: func(ctx context.Context, imageID, resolution string) (io.ReadCloser, *models.ImageMetadata, error) {
+			if resolution == "800x600" {
+				return nil, nil, models.NotFoundError{Resource: "resolution", ID: imageID + "/" + resolution}
+			}
+			return testutil.NewMockReadCloser(testutil.CreateTestImageData()), testutil.CreateTestImageMetadata(), nil
+		},
+		recordAccessFunc: func(ctx context.Context, imageID, resolution string) {
+			recorded = append(recorded, imageID+"/"+resolution)
+		},
+		getAccessCountsFunc: func(ctx context.Context, imageID string) (*models.AccessCounts, error) {
+			counts := &models.AccessCounts{}
+			for _, entry := range recorded {
+				counts.Add(strings.TrimPrefix(entry, imageID+"/"), 1)
+			}
+			return counts, nil
+		},
+		getMetadataFunc: func(ctx context.Context, imageID string) (*models.ImageMetadata, error) {
+			return testutil.CreateTestImageMetadata(), nil
+		},
+	}
+	handler := NewImageHandler(mockService, testutil.TestConfig())
+
+	download := func(path string, serve func(*gin.Context), resolution string) int {
+		req := testutil.CreateTestRequest("GET", fmt.Sprintf("/api/v1/images/%s/%s", testutil.ValidUUID, path), nil)
+		c, w := testutil.SetupTestContext(req)
+		c.AddParam("id", testutil.ValidUUID)
+		if resolution != "" {
+			c.AddParam("resolution", resolution)
+		}
+		serve(c)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, download("thumbnail", handler.DownloadThumbnail, ""))
+	assert.Equal(t, http.StatusOK, download("thumbnail", handler.DownloadThumbnail, ""))
+	assert.Equal(t, http.StatusOK, download("original", handler.DownloadOriginal, ""))
+	assert.Equal(t, http.StatusNotFound, download("800x600", handler.DownloadCustomResolution, "800x600"))
+
+	// Failed downloads are not counted
+	assert.Equal(t, []string{
+		testutil.ValidUUID + "/thumbnail",
+		testutil.ValidUUID + "/thumbnail",
+		testutil.ValidUUID + "/original",
+	}, recorded)
+
+	// The info endpoint reports the counters
+	req := testutil.CreateTestRequest("GET", fmt.Sprintf("/api/v1/images/%s/info", testutil.ValidUUID), nil)
+	c, w := testutil.SetupTestContext(req)
+	c.AddParam("id", testutil.ValidUUID)
+	handler.Info(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var info models.InfoResponse
+	require.NoError(t, testutil.ParseJSONResponse(w, &info))
+	require.NotNil(t, info.Access)
+	assert.Equal(t, int64(3), info.Access.Total)
+	assert.Equal(t, map[string]int64{"thumbnail": 2, "original": 1}, info.Access.ByResolution)
+}
+
+func TestImageHandler_DownloadConvertedDerivative(t *testing.T) {
+	scan := testutil.CreateTestImageMetadata()
+	scan.Filename = "scan.tif"
+	scan.MimeType = "image/tiff"
+
+	mockService := &mockImageService{
+		getImageStreamFunc: func(ctx context.Context, imageID, resolution string) (io.ReadCloser, *models.ImageMetadata, error) {
+			return testutil.NewMockReadCloser(testutil.CreateTestImageData()), scan, nil
+		},
+	}
 
-		mockService.processUploadFunc = func(ctx context.Context, input service.UploadInput) (*service.UploadResult, error) {
-			assert.Contains(t, input.Resolutions, "800x600")
-			return &service.UploadResult{
-				ImageID:              testutil.ValidUUID,
-				ProcessedResolutions: []string{"original", "800x600"},
-			}, nil
-		}
+	handler := NewImageHandler(mockService, testutil.TestConfig())
 
-		req := testutil.CreateMultipartRequest("POST", "/api/v1/images", formData, "image", "test.jpg", testutil.CreateTestImageData())
-		c, w := testutil.SetupTestContext(req)
+	req := testutil.CreateTestRequest("GET", fmt.Sprintf("/api/v1/images/%s/thumbnail", testutil.ValidUUID), nil)
+	c, w := testutil.SetupTestContext(req)
+	c.AddParam("id", testutil.ValidUUID)
 
-		handler.Upload(c)
+	handler.DownloadThumbnail(c)
 
-		assert.Equal(t, http.StatusCreated, w.Code)
-	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	assert.Equal(t, `inline; filename="scan_thumbnail.png"`, w.Header().Get("Content-Disposition"))
 }
 
-func TestImageHandler_Info(t *testing.T) {
+func TestImageHandler_DownloadFrame(t *testing.T) {
+	animation := testutil.CreateTestImageMetadata()
+	animation.Filename = "spinner.gif"
+	animation.MimeType = "image/gif"
+
+	mockService := &mockImageService{
+		getImageFrameFunc: func(ctx context.Context, imageID, resolution string, frame int) ([]byte, string, *models.ImageMetadata, error) {
+			if frame > 2 {
+				return nil, "", nil, models.ValidationError{Field: "frame", Message: "Frame 3 is out of range; the image has 3 frame(s)"}
+			}
+			return []byte("frame-data"), "image/gif", animation, nil
+		},
+	}
+	handler := NewImageHandler(mockService, testutil.TestConfig())
+
 	tests := []struct {
 		name           string
-		imageID        string
-		setupMock      func(*mockImageService)
+		resolution     string
+		frame          string
 		expectedStatus int
-		expectError    bool
 	}{
-		{
-			name:    "successful info retrieval",
-			imageID: testutil.ValidUUID,
-			setupMock: func(mock *mockImageService) {
-				mock.getMetadataFunc = func(ctx context.Context, imageID string) (*models.ImageMetadata, error) {
-					return testutil.CreateTestImageMetadata(), nil
-				}
-			},
-			expectedStatus: http.StatusOK,
-			expectError:    false,
-		},
-		{
-			name:           "invalid UUID",
-			imageID:        testutil.InvalidUUID,
-			setupMock:      func(mock *mockImageService) {},
-			expectedStatus: http.StatusBadRequest,
-			expectError:    true,
-		},
-		{
-			name:    "image not found",
-			imageID: testutil.ValidUUID,
-			setupMock: func(mock *mockImageService) {
-				mock.getMetadataFunc = func(ctx context.Context, imageID string) (*models.ImageMetadata, error) {
-					return nil, models.NotFoundError{
-						Resource: "image",
-						ID:       imageID,
-					}
-				}
-			},
-			expectedStatus: http.StatusNotFound,
-			expectError:    true,
-		},
+		{name: "first frame of the original", resolution: "original", frame: "0", expectedStatus: http.StatusOK},
+		{name: "frame out of range", resolution: "original", frame: "3", expectedStatus: http.StatusBadRequest},
+		{name: "negative frame", resolution: "original", frame: "-1", expectedStatus: http.StatusBadRequest},
+		{name: "non-numeric frame", resolution: "original", frame: "first", expectedStatus: http.StatusBadRequest},
+		{name: "invalid resolution", resolution: "inv@lid", frame: "0", expectedStatus: http.StatusBadRequest},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Setup
-			mockService := &mockImageService{}
-			tt.setupMock(mockService)
-
-			handler := NewImageHandler(mockService, testutil.TestConfig())
-
-			// Create test context with URL parameter
-			req := testutil.CreateTestRequest("GET", fmt.Sprintf("/api/v1/images/%s/info", tt.imageID), nil)
+			req := testutil.CreateTestRequest("GET", fmt.Sprintf("/api/v1/images/%s/%s/frame/%s", testutil.ValidUUID, tt.resolution, tt.frame), nil)
 			c, w := testutil.SetupTestContext(req)
-			c.AddParam("id", tt.imageID)
+			c.AddParam("id", testutil.ValidUUID)
+			c.AddParam("resolution", tt.resolution)
+			c.AddParam("n", tt.frame)
 
-			// Execute
-			handler.Info(c)
+			handler.DownloadFrame(c)
 
-			// Assert
 			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, "image/gif", w.Header().Get("Content-Type"))
+				assert.Equal(t, `inline; filename="spinner_frame0.gif"`, w.Header().Get("Content-Disposition"))
+				assert.Equal(t, "frame-data", w.Body.String())
+			}
+		})
+	}
+}
 
-			var response map[string]interface{}
-			err := testutil.ParseJSONResponse(w, &response)
-			assert.NoError(t, err)
+func TestImageHandler_Archive(t *testing.T) {
+	metadata := testutil.CreateTestImageMetadata()
+	metadata.Filename = "holiday.jpg"
+	metadata.Resolutions = []string{"thumbnail", "800x600:small", "1200x900"}
 
-			if tt.expectError {
-				assert.Contains(t, response, "error")
-				assert.Contains(t, response, "message")
-			} else {
-				assert.Equal(t, tt.imageID, response["id"])
-				assert.Contains(t, response, "filename")
-				assert.Contains(t, response, "mime_type")
-				assert.Contains(t, response, "dimensions")
-				assert.Contains(t, response, "available_resolutions")
+	mockService := &mockImageService{
+		getMetadataFunc: func(ctx context.Context, imageID string) (*models.ImageMetadata, error) {
+			return metadata, nil
+		},
+		getImageStreamFunc: func(ctx context.Context, imageID, resolution string) (io.ReadCloser, *models.ImageMetadata, error) {
+			if resolution == "1200x900" {
+				return nil, nil, models.NotFoundError{Resource: "image", ID: imageID}
 			}
-		})
+			return testutil.NewMockReadCloser([]byte("data-" + resolution)), metadata, nil
+		},
+	}
+
+	handler := NewImageHandler(mockService, testutil.TestConfig())
+
+	req := testutil.CreateTestRequest("GET", fmt.Sprintf("/api/v1/images/%s/archive", testutil.ValidUUID), nil)
+	c, w := testutil.SetupTestContext(req)
+	c.AddParam("id", testutil.ValidUUID)
+
+	handler.Archive(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+	assert.Equal(t, fmt.Sprintf(`attachment; filename="holiday-%s.zip"`, testutil.ValidUUID), w.Header().Get("Content-Disposition"))
+
+	body := w.Body.Bytes()
+	archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	require.NoError(t, err)
+
+	contents := make(map[string]string)
+	var names []string
+	for _, f := range archive.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+		names = append(names, f.Name)
+		contents[f.Name] = string(data)
 	}
+
+	// The missing 1200x900 object is skipped rather than failing the archive
+	assert.Equal(t, []string{"original.jpg", "thumbnail.jpg", "800x600_small.jpg"}, names)
+	assert.Equal(t, "data-original", contents["original.jpg"])
+	assert.Equal(t, "data-800x600:small", contents["800x600_small.jpg"])
+}
+
+func TestImageHandler_ArchiveErrors(t *testing.T) {
+	t.Run("invalid image ID", func(t *testing.T) {
+		handler := NewImageHandler(&mockImageService{}, testutil.TestConfig())
+
+		req := testutil.CreateTestRequest("GET", "/api/v1/images/not-a-uuid/archive", nil)
+		c, w := testutil.SetupTestContext(req)
+		c.AddParam("id", "not-a-uuid")
+
+		handler.Archive(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("image not found", func(t *testing.T) {
+		mockService := &mockImageService{
+			getMetadataFunc: func(ctx context.Context, imageID string) (*models.ImageMetadata, error) {
+				return nil, models.NotFoundError{Resource: "image", ID: imageID}
+			},
+		}
+		handler := NewImageHandler(mockService, testutil.TestConfig())
+
+		req := testutil.CreateTestRequest("GET", fmt.Sprintf("/api/v1/images/%s/archive", testutil.ValidUUID), nil)
+		c, w := testutil.SetupTestContext(req)
+		c.AddParam("id", testutil.ValidUUID)
+
+		handler.Archive(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Empty(t, w.Header().Get("Content-Disposition"))
+	})
 }
 
 func TestImageHandler_DownloadMethods(t *testing.T) {
@@ -423,6 +2292,54 @@ func TestImageHandler_DownloadCustomResolution(t *testing.T) {
 	}
 }
 
+func TestImageHandler_DownloadCustomResolution_NearestFallback(t *testing.T) {
+	tests := []struct {
+		name           string
+		resolution     string
+		query          string
+		expectedStatus int
+		expectedServed string
+		substituted    string
+	}{
+		{name: "missing resolution served by nearest", resolution: "1024x768", query: "?fallback=nearest", expectedStatus: http.StatusOK, expectedServed: "800x600", substituted: "800x600"},
+		{name: "exact match is not substituted", resolution: "800x600", query: "?fallback=nearest", expectedStatus: http.StatusOK, expectedServed: "800x600"},
+		{name: "alias cannot be sized", resolution: "large", query: "?fallback=nearest", expectedStatus: http.StatusNotFound},
+		{name: "without fallback a missing resolution is 404", resolution: "1024x768", expectedStatus: http.StatusNotFound},
+		{name: "unknown fallback", resolution: "1024x768", query: "?fallback=largest", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata := testutil.CreateTestImageMetadata()
+			served := ""
+			mockService := &mockImageService{
+				getMetadataFunc: func(ctx context.Context, imageID string) (*models.ImageMetadata, error) {
+					return metadata, nil
+				},
+				getImageStreamFunc: func(ctx context.Context, imageID, resolution string) (io.ReadCloser, *models.ImageMetadata, error) {
+					if !metadata.HasResolution(resolution) {
+						return nil, nil, models.NotFoundError{Resource: "resolution", ID: imageID + "/" + resolution}
+					}
+					served = resolution
+					return testutil.NewMockReadCloser(testutil.CreateTestImageData()), metadata, nil
+				},
+			}
+			handler := NewImageHandler(mockService, testutil.TestConfig())
+
+			req := testutil.CreateTestRequest("GET", fmt.Sprintf("/api/v1/images/%s/%s%s", testutil.ValidUUID, tt.resolution, tt.query), nil)
+			c, w := testutil.SetupTestContext(req)
+			c.AddParam("id", testutil.ValidUUID)
+			c.AddParam("resolution", tt.resolution)
+
+			handler.DownloadCustomResolution(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedServed, served)
+			assert.Equal(t, tt.substituted, w.Header().Get(ResolutionSubstitutedHeader))
+		})
+	}
+}
+
 func TestImageHandler_GeneratePresignedURL(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -542,6 +2459,285 @@ func TestImageHandler_GeneratePresignedURL(t *testing.T) {
 	}
 }
 
+func TestImageHandler_LastAccessedAt(t *testing.T) {
+	accessed := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	metadata := testutil.CreateTestImageMetadata()
+
+	var touched []string
+	mockService := &mockImageService{
+		getMetadataFunc: func(ctx context.Context, imageID string) (*models.ImageMetadata, error) {
+			return metadata, nil
+		},
+		generatePresignedURLFunc: func(ctx context.Context, storageKey string, expiration time.Duration) (string, error) {
+			return "https://example.com/" + storageKey, nil
+		},
+		touchLastAccessedFunc: func(ctx context.Context, imageID string) {
+			touched = append(touched, imageID)
+			metadata.LastAccessedAt = accessed
+		},
+	}
+	handler := NewImageHandler(mockService, testutil.TestConfig())
+
+	info := func() map[string]interface{} {
+		req := testutil.CreateTestRequest("GET", fmt.Sprintf("/api/v1/images/%s/info", testutil.ValidUUID), nil)
+		c, w := testutil.SetupTestContext(req)
+		c.AddParam("id", testutil.ValidUUID)
+		handler.Info(c)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, testutil.ParseJSONResponse(w, &response))
+		return response
+	}
+
+	// Never accessed images have no timestamp
+	assert.NotContains(t, info(), "last_accessed_at")
+
+	req := testutil.CreateTestRequest("GET", fmt.Sprintf("/api/v1/images/%s/thumbnail/presigned-url", testutil.ValidUUID), nil)
+	c, w := testutil.SetupTestContext(req)
+	c.AddParam("id", testutil.ValidUUID)
+	c.AddParam("resolution", "thumbnail")
+	handler.GeneratePresignedURL(c)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{testutil.ValidUUID}, touched)
+	assert.Equal(t, "2025-01-01T12:00:00Z", info()["last_accessed_at"])
+}
+
+func TestImageHandler_GeneratePresignedURL_Cache(t *testing.T) {
+	cfg := testutil.TestConfig()
+	cfg.S3.URLCacheTTL = 5 * time.Minute
+
+	signed := 0
+	mockService := &mockImageService{
+		getMetadataFunc: func(ctx context.Context, imageID string) (*models.ImageMetadata, error) {
+			return testutil.CreateTestImageMetadata(), nil
+		},
+		generatePresignedURLFunc: func(ctx context.Context, storageKey string, expiration time.Duration) (string, error) {
+			signed++
+			return fmt.Sprintf("https://example.com/%s?sig=%d", storageKey, signed), nil
+		},
+	}
+
+	handler := NewImageHandler(mockService, cfg)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	handler.presignCache.now = func() time.Time { return now }
+
+	request := func(headers map[string]string) (*httptest.ResponseRecorder, models.PresignedURLResponse) {
+		req := testutil.CreateTestRequest("GET", fmt.Sprintf("/api/v1/images/%s/thumbnail/presigned-url?expires_in=3600", testutil.ValidUUID), nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		c, w := testutil.SetupTestContext(req)
+		c.AddParam("id", testutil.ValidUUID)
+		c.AddParam("resolution", "thumbnail")
+
+		handler.GeneratePresignedURL(c)
+
+		var response models.PresignedURLResponse
+		if w.Code == http.StatusOK {
+			require.NoError(t, testutil.ParseJSONResponse(w, &response))
+		}
+		return w, response
+	}
+
+	first, firstBody := request(nil)
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, "MISS", first.Header().Get("X-Cache"))
+	assert.Equal(t, 3600, firstBody.ExpiresIn)
+
+	// Within the cache window the same URL comes back with its remaining lifetime
+	now = now.Add(2 * time.Minute)
+	second, secondBody := request(nil)
+	assert.Equal(t, http.StatusOK, second.Code)
+	assert.Equal(t, "HIT", second.Header().Get("X-Cache"))
+	assert.Equal(t, firstBody.URL, secondBody.URL)
+	assert.True(t, firstBody.ExpiresAt.Equal(secondBody.ExpiresAt))
+	assert.Equal(t, 3600-120, secondBody.ExpiresIn)
+	assert.Equal(t, 1, signed)
+
+	// A client that already holds the URL gets 304
+	notModified, _ := request(map[string]string{"If-Modified-Since": first.Header().Get("Last-Modified")})
+	assert.Equal(t, http.StatusNotModified, notModified.Code)
+	assert.Equal(t, "HIT", notModified.Header().Get("X-Cache"))
+	assert.Empty(t, notModified.Body.String())
+
+	// After the window closes a new signature is generated
+	now = now.Add(4 * time.Minute)
+	third, thirdBody := request(map[string]string{"If-Modified-Since": first.Header().Get("Last-Modified")})
+	assert.Equal(t, http.StatusOK, third.Code)
+	assert.Equal(t, "MISS", third.Header().Get("X-Cache"))
+	assert.NotEqual(t, firstBody.URL, thirdBody.URL)
+	assert.Equal(t, 2, signed)
+}
+
+func TestImageHandler_GeneratePresignedURL_CacheTTLByResolution(t *testing.T) {
+	cfg := testutil.TestConfig()
+	cfg.S3.URLCacheTTL = 5 * time.Minute
+	cfg.S3.URLCacheTTLByResolution = map[string]time.Duration{
+		"original":  time.Minute,
+		"thumbnail": 30 * time.Minute,
+	}
+
+	signed := 0
+	mockService := &mockImageService{
+		getMetadataFunc: func(ctx context.Context, imageID string) (*models.ImageMetadata, error) {
+			return testutil.CreateTestImageMetadata(), nil
+		},
+		generatePresignedURLFunc: func(ctx context.Context, storageKey string, expiration time.Duration) (string, error) {
+			signed++
+			return fmt.Sprintf("https://example.com/%s?sig=%d", storageKey, signed), nil
+		},
+	}
+
+	handler := NewImageHandler(mockService, cfg)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	handler.presignCache.now = func() time.Time { return now }
+
+	request := func(resolution string) string {
+		req := testutil.CreateTestRequest("GET", fmt.Sprintf("/api/v1/images/%s/%s/presigned-url?expires_in=7200", testutil.ValidUUID, resolution), nil)
+		c, w := testutil.SetupTestContext(req)
+		c.AddParam("id", testutil.ValidUUID)
+		c.AddParam("resolution", resolution)
+
+		handler.GeneratePresignedURL(c)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		return w.Header().Get("X-Cache")
+	}
+
+	for _, resolution := range []string{"original", "thumbnail", "800x600"} {
+		assert.Equal(t, "MISS", request(resolution), resolution)
+	}
+
+	// Two minutes in: original (1m) expired, default (5m) and thumbnail (30m) still cached
+	now = now.Add(2 * time.Minute)
+	assert.Equal(t, "MISS", request("original"))
+	assert.Equal(t, "HIT", request("800x600"))
+	assert.Equal(t, "HIT", request("thumbnail"))
+
+	// Ten minutes in: only the thumbnail window is still open
+	now = now.Add(8 * time.Minute)
+	assert.Equal(t, "MISS", request("800x600"))
+	assert.Equal(t, "HIT", request("thumbnail"))
+}
+
+func TestImageHandler_PurgeCache(t *testing.T) {
+	cfg := testutil.TestConfig()
+	cfg.S3.URLCacheTTL = 5 * time.Minute
+
+	purged := 0
+	mockService := &mockImageService{
+		getMetadataFunc: func(ctx context.Context, imageID string) (*models.ImageMetadata, error) {
+			return testutil.CreateTestImageMetadata(), nil
+		},
+		generatePresignedURLFunc: func(ctx context.Context, storageKey string, expiration time.Duration) (string, error) {
+			return "https://example.com/" + storageKey, nil
+		},
+		purgeImageCacheFunc: func(ctx context.Context, imageID string) error {
+			purged++
+			return nil
+		},
+	}
+	handler := NewImageHandler(mockService, cfg)
+
+	presign := func() string {
+		req := testutil.CreateTestRequest("GET", fmt.Sprintf("/api/v1/images/%s/thumbnail/presigned-url", testutil.ValidUUID), nil)
+		c, w := testutil.SetupTestContext(req)
+		c.AddParam("id", testutil.ValidUUID)
+		c.AddParam("resolution", "thumbnail")
+		handler.GeneratePresignedURL(c)
+		require.Equal(t, http.StatusOK, w.Code)
+		return w.Header().Get("X-Cache")
+	}
+	purge := func(imageID string) *httptest.ResponseRecorder {
+		req := testutil.CreateTestRequest("POST", fmt.Sprintf("/api/v1/images/%s/cache/purge", imageID), nil)
+		c, w := testutil.SetupTestContext(req)
+		c.AddParam("id", imageID)
+		handler.PurgeCache(c)
+		return w
+	}
+
+	assert.Equal(t, "MISS", presign())
+	assert.Equal(t, "HIT", presign())
+
+	w := purge(testutil.ValidUUID)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response models.CachePurgeResponse
+	require.NoError(t, testutil.ParseJSONResponse(w, &response))
+	assert.Equal(t, testutil.ValidUUID, response.ID)
+	assert.Equal(t, 1, response.PresignedURLsPurged)
+	assert.Equal(t, 1, purged)
+
+	// Cached URLs are gone, so the next request signs again
+	assert.Equal(t, "MISS", presign())
+
+	t.Run("invalid id", func(t *testing.T) {
+		w := purge("not-a-uuid")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, 1, purged)
+	})
+
+	t.Run("service error", func(t *testing.T) {
+		mockService.purgeImageCacheFunc = func(ctx context.Context, imageID string) error {
+			return models.StorageError{Operation: "purge_cached_urls", Backend: "Repository", Reason: "down"}
+		}
+		w := purge(testutil.ValidUUID)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+}
+
+func TestPresignedURLCache(t *testing.T) {
+	t.Run("window is capped at half the URL lifetime", func(t *testing.T) {
+		cache := newPresignedURLCache(time.Hour, nil)
+		now := time.Now()
+		cache.now = func() time.Time { return now }
+
+		cache.put("k", "img", "https://example.com/a", 10*time.Minute, cache.ttlFor("original"))
+
+		now = now.Add(4 * time.Minute)
+		_, ok := cache.get("k")
+		assert.True(t, ok)
+
+		now = now.Add(time.Minute)
+		_, ok = cache.get("k")
+		assert.False(t, ok, "a URL with less than half its lifetime left is not reused")
+	})
+
+	t.Run("zero TTL disables caching", func(t *testing.T) {
+		cache := newPresignedURLCache(0, nil)
+		cache.put("k", "img", "https://example.com/a", time.Hour, cache.ttlFor("original"))
+
+		_, ok := cache.get("k")
+		assert.False(t, ok)
+	})
+
+	t.Run("per-resolution TTLs override the default", func(t *testing.T) {
+		cache := newPresignedURLCache(5*time.Minute, map[string]time.Duration{
+			"original":  time.Minute,
+			"thumbnail": 20 * time.Minute,
+			"800x600":   0,
+		})
+
+		assert.Equal(t, time.Minute, cache.ttlFor("original"))
+		assert.Equal(t, 20*time.Minute, cache.ttlFor("thumbnail"))
+		assert.Equal(t, time.Duration(0), cache.ttlFor("small", "800x600"))
+		assert.Equal(t, 5*time.Minute, cache.ttlFor("1024x768"))
+	})
+
+	t.Run("overrides enable caching with a zero default", func(t *testing.T) {
+		cache := newPresignedURLCache(0, map[string]time.Duration{"thumbnail": time.Minute})
+		cache.put("k", "img", "https://example.com/a", time.Hour, cache.ttlFor("thumbnail"))
+
+		_, ok := cache.get("k")
+		assert.True(t, ok)
+	})
+
+	t.Run("lifetime is part of the key", func(t *testing.T) {
+		assert.NotEqual(t, presignedURLCacheKey("images/a/original.jpg", time.Hour), presignedURLCacheKey("images/a/original.jpg", 2*time.Hour))
+	})
+}
+
 func TestImageHandler_ValidationHelpers(t *testing.T) {
 	handler := &ImageHandler{}
 
@@ -559,6 +2755,9 @@ func TestImageHandler_ValidationHelpers(t *testing.T) {
 	assert.False(t, handler.isValidCustomResolution("x600"))
 	assert.False(t, handler.isValidCustomResolution("800X600"))
 	assert.False(t, handler.isValidCustomResolution("abc x def"))
+	assert.True(t, handler.isValidCustomResolution("800x600:small"))
+	assert.False(t, handler.isValidCustomResolution("800x600:a/b"))
+	assert.False(t, handler.isValidCustomResolution("800x600:a b"))
 
 	// Test size validation
 	assert.True(t, handler.isValidSize("original"))
@@ -594,34 +2793,76 @@ func TestImageHandler_ErrorHandling(t *testing.T) {
 	handler := &ImageHandler{}
 
 	tests := []struct {
-		name         string
-		err          error
-		expectedCode int
+		name              string
+		err               error
+		expectedCode      int
+		expectedErrorCode string
 	}{
 		{
 			"validation error",
 			models.ValidationError{Field: "test", Message: "invalid"},
 			http.StatusBadRequest,
+			models.ErrorCodeValidationFailed,
+		},
+		{
+			"invalid resolution",
+			models.ValidationError{Field: "resolutions", Message: "invalid"},
+			http.StatusBadRequest,
+			models.ErrorCodeInvalidResolution,
 		},
 		{
 			"not found error",
 			models.NotFoundError{Resource: "image", ID: "123"},
 			http.StatusNotFound,
+			models.ErrorCodeImageNotFound,
+		},
+		{
+			"resolution not found",
+			models.NotFoundError{Resource: "resolution", ID: "123/800x600"},
+			http.StatusNotFound,
+			models.ErrorCodeResolutionNotFound,
 		},
 		{
 			"processing error",
 			models.ProcessingError{Operation: "resize", Reason: "invalid format"},
 			http.StatusUnprocessableEntity,
+			models.ErrorCodeProcessingFailed,
 		},
 		{
 			"storage error",
 			models.StorageError{Operation: "upload", Backend: "s3", Reason: "connection failed"},
 			http.StatusServiceUnavailable,
+			models.ErrorCodeStorageUnavailable,
+		},
+		{
+			"corrupt metadata",
+			models.CorruptMetadataError{ID: "123", Reason: "invalid JSON"},
+			http.StatusInternalServerError,
+			models.ErrorCodeCorruptMetadata,
+		},
+		{
+			"malware detected",
+			models.MalwareDetectedError{Signature: "Eicar-Test-Signature"},
+			http.StatusUnprocessableEntity,
+			models.ErrorCodeMalwareDetected,
+		},
+		{
+			"processing queue full",
+			models.QueueFullError{Capacity: 100},
+			http.StatusServiceUnavailable,
+			models.ErrorCodeQueueFull,
+		},
+		{
+			"image capacity reached",
+			models.CapacityExceededError{Limit: 1000},
+			http.StatusInsufficientStorage,
+			models.ErrorCodeCapacityExceeded,
 		},
 		{
 			"unknown error",
 			errors.New("unknown error"),
 			http.StatusInternalServerError,
+			models.ErrorCodeInternal,
 		},
 	}
 
@@ -640,6 +2881,7 @@ func TestImageHandler_ErrorHandling(t *testing.T) {
 			assert.Contains(t, response, "error")
 			assert.Contains(t, response, "message")
 			assert.Equal(t, float64(tt.expectedCode), response["code"])
+			assert.Equal(t, tt.expectedErrorCode, response["error_code"])
 		})
 	}
 }
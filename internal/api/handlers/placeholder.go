@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"net/http"
+	"os"
+
+	"resizr/internal/config"
+	"resizr/internal/models"
+	"resizr/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// PlaceholderHeader names the missing resource (image or resolution) a placeholder
+// was served in place of
+const PlaceholderHeader = "X-Image-Placeholder"
+
+// missingImagePlaceholder is a placeholder served with a 200 instead of a 404
+type missingImagePlaceholder struct {
+	data        []byte
+	contentType string
+}
+
+// newMissingImagePlaceholder loads the placeholder MISSING_IMAGE_PLACEHOLDER names,
+// or returns nil when downloads of missing images answer 404. An unreadable
+// placeholder file falls back to the transparent pixel.
+func newMissingImagePlaceholder(cfg *config.Config) *missingImagePlaceholder {
+	source := cfg.Image.MissingImagePlaceholder
+	if source == "" {
+		return nil
+	}
+
+	if source != config.MissingImagePlaceholderPixel {
+		data, err := os.ReadFile(source)
+		if err == nil {
+			return &missingImagePlaceholder{data: data, contentType: http.DetectContentType(data)}
+		}
+		logger.Warn("Failed to read missing image placeholder, serving a transparent pixel instead",
+			zap.String("path", source),
+			zap.Error(err))
+	}
+
+	return &missingImagePlaceholder{data: transparentPixel(), contentType: "image/png"}
+}
+
+// transparentPixel encodes a 1x1 fully transparent PNG
+func transparentPixel() []byte {
+	var encoded bytes.Buffer
+	_ = png.Encode(&encoded, image.NewNRGBA(image.Rect(0, 0, 1, 1))) // Cannot fail writing to memory
+	return encoded.Bytes()
+}
+
+// servePlaceholder answers a download whose image or resolution is missing with
+// the configured placeholder. It returns false, writing nothing, when no
+// placeholder is configured or err is not about a missing image or resolution.
+func (h *ImageHandler) servePlaceholder(c *gin.Context, err error) bool {
+	notFound, ok := err.(models.NotFoundError)
+	if h.placeholder == nil || !ok || (notFound.Resource != "image" && notFound.Resource != "resolution") {
+		return false
+	}
+
+	logger.DebugWithContext(c.Request.Context(), "Serving placeholder for missing image",
+		zap.String("resource", notFound.Resource),
+		zap.String("id", notFound.ID),
+		zap.String("request_id", c.GetString("request_id")))
+
+	// The image or resolution may exist later, so the placeholder must not be cached
+	c.Header("Cache-Control", "no-store")
+	c.Header(PlaceholderHeader, notFound.Resource)
+	c.Data(http.StatusOK, h.placeholder.contentType, h.placeholder.data)
+	return true
+}
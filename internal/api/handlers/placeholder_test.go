@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image/png"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"resizr/internal/config"
+	"resizr/internal/models"
+	"resizr/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImageHandler_DownloadMissingImagePlaceholder(t *testing.T) {
+	gifPath := filepath.Join(t.TempDir(), "missing.gif")
+	gifData := []byte("GIF89a\x01\x00\x01\x00\x00\x00\x00;")
+	require.NoError(t, os.WriteFile(gifPath, gifData, 0o600))
+
+	tests := []struct {
+		name                string
+		placeholder         string
+		err                 error
+		expectedStatus      int
+		expectedType        string
+		expectedPlaceholder string
+	}{
+		{
+			name:           "missing image answers 404 by default",
+			err:            models.NotFoundError{Resource: "image", ID: testutil.ValidUUID},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:                "missing image served as transparent pixel",
+			placeholder:         config.MissingImagePlaceholderPixel,
+			err:                 models.NotFoundError{Resource: "image", ID: testutil.ValidUUID},
+			expectedStatus:      http.StatusOK,
+			expectedType:        "image/png",
+			expectedPlaceholder: "image",
+		},
+		{
+			name:                "missing resolution served as configured image",
+			placeholder:         gifPath,
+			err:                 models.NotFoundError{Resource: "resolution", ID: testutil.ValidUUID + "/800x600"},
+			expectedStatus:      http.StatusOK,
+			expectedType:        "image/gif",
+			expectedPlaceholder: "resolution",
+		},
+		{
+			name:           "other errors are not replaced",
+			placeholder:    config.MissingImagePlaceholderPixel,
+			err:            models.StorageError{Operation: "download", Backend: "S3", Reason: "unreachable"},
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockImageService{
+				getImageStreamFunc: func(ctx context.Context, imageID, resolution string) (io.ReadCloser, *models.ImageMetadata, error) {
+					return nil, nil, tt.err
+				},
+			}
+			cfg := testutil.TestConfig()
+			cfg.Image.MissingImagePlaceholder = tt.placeholder
+			handler := NewImageHandler(mockService, cfg)
+
+			req := testutil.CreateTestRequest("GET", fmt.Sprintf("/api/v1/images/%s/800x600", testutil.ValidUUID), nil)
+			c, w := testutil.SetupTestContext(req)
+			c.AddParam("id", testutil.ValidUUID)
+			c.AddParam("resolution", "800x600")
+
+			handler.DownloadCustomResolution(c)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedPlaceholder, w.Header().Get(PlaceholderHeader))
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			assert.Equal(t, tt.expectedType, w.Header().Get("Content-Type"))
+			assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+			if tt.expectedType == "image/gif" {
+				assert.Equal(t, gifData, w.Body.Bytes())
+			}
+		})
+	}
+}
+
+func TestNewMissingImagePlaceholder(t *testing.T) {
+	cfg := testutil.TestConfig()
+	assert.Nil(t, newMissingImagePlaceholder(cfg))
+
+	cfg.Image.MissingImagePlaceholder = config.MissingImagePlaceholderPixel
+	pixel := newMissingImagePlaceholder(cfg)
+	require.NotNil(t, pixel)
+	decoded, err := png.Decode(bytes.NewReader(pixel.data))
+	require.NoError(t, err)
+	assert.Equal(t, 1, decoded.Bounds().Dx())
+	assert.Equal(t, 1, decoded.Bounds().Dy())
+	_, _, _, alpha := decoded.At(0, 0).RGBA()
+	assert.Zero(t, alpha)
+
+	// A file removed after startup falls back to the pixel
+	cfg.Image.MissingImagePlaceholder = filepath.Join(t.TempDir(), "gone.png")
+	fallback := newMissingImagePlaceholder(cfg)
+	require.NotNil(t, fallback)
+	assert.Equal(t, pixel.data, fallback.data)
+	assert.Equal(t, "image/png", fallback.contentType)
+}
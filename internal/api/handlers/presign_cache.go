@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"sync"
+	"time"
+)
+
+// presignedURLEntry is a signed URL and the window in which it is handed out again
+type presignedURLEntry struct {
+	imageID     string
+	url         string
+	generatedAt time.Time
+	expiresAt   time.Time // when the signature itself expires
+	reuseUntil  time.Time // end of the cache window
+}
+
+// presignedURLCache remembers recently signed URLs so clients polling the
+// presigned URL endpoint get the same URL back instead of a fresh signature
+type presignedURLCache struct {
+	mu           sync.Mutex
+	ttl          time.Duration
+	byResolution map[string]time.Duration // per-resolution overrides of ttl
+	entries      map[string]presignedURLEntry
+	now          func() time.Time
+}
+
+// newPresignedURLCache creates a cache. byResolution overrides ttl for the named
+// resolutions; a non-positive TTL disables caching for the resolutions it applies to.
+func newPresignedURLCache(ttl time.Duration, byResolution map[string]time.Duration) *presignedURLCache {
+	return &presignedURLCache{
+		ttl:          ttl,
+		byResolution: byResolution,
+		entries:      make(map[string]presignedURLEntry),
+		now:          time.Now,
+	}
+}
+
+// ttlFor returns the cache window for the first of names with an override,
+// falling back to the default TTL
+func (pc *presignedURLCache) ttlFor(names ...string) time.Duration {
+	for _, name := range names {
+		if ttl, ok := pc.byResolution[name]; ok {
+			return ttl
+		}
+	}
+	return pc.ttl
+}
+
+// enabled reports whether any resolution is cached at all
+func (pc *presignedURLCache) enabled() bool {
+	if pc.ttl > 0 {
+		return true
+	}
+	for _, ttl := range pc.byResolution {
+		if ttl > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// presignedURLCacheKey identifies a URL by object and requested lifetime
+func presignedURLCacheKey(storageKey string, expiresIn time.Duration) string {
+	return storageKey + "|" + expiresIn.String()
+}
+
+// get returns the cached entry for key while its cache window is open
+func (pc *presignedURLCache) get(key string) (presignedURLEntry, bool) {
+	if !pc.enabled() {
+		return presignedURLEntry{}, false
+	}
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	entry, ok := pc.entries[key]
+	if !ok || !pc.now().Before(entry.reuseUntil) {
+		return presignedURLEntry{}, false
+	}
+	return entry, true
+}
+
+// put caches a freshly signed URL for ttl, the window chosen with ttlFor. The
+// cache window never exceeds half of the URL's lifetime, so a reused URL always
+// stays valid for at least half of what the client asked for.
+func (pc *presignedURLCache) put(key, imageID, url string, lifetime, ttl time.Duration) presignedURLEntry {
+	now := pc.now()
+	entry := presignedURLEntry{
+		imageID:     imageID,
+		url:         url,
+		generatedAt: now,
+		expiresAt:   now.Add(lifetime),
+		reuseUntil:  now.Add(min(ttl, lifetime/2)),
+	}
+	if ttl <= 0 {
+		return entry
+	}
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	for k, e := range pc.entries {
+		if !now.Before(e.reuseUntil) {
+			delete(pc.entries, k)
+		}
+	}
+	pc.entries[key] = entry
+	return entry
+}
+
+// purgeImage drops every cached URL of an image and returns how many there were
+func (pc *presignedURLCache) purgeImage(imageID string) int {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	purged := 0
+	for k, e := range pc.entries {
+		if e.imageID == imageID {
+			delete(pc.entries, k)
+			purged++
+		}
+	}
+	return purged
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"net/http"
+	"strconv"
 
 	"resizr/internal/models"
 	"resizr/pkg/logger"
@@ -46,9 +47,10 @@ func (h *StatisticsHandler) GetComprehensiveStatistics(c *gin.Context) {
 			zap.Error(err),
 			zap.String("request_id", requestID))
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "Statistics retrieval failed",
-			Message: "Failed to retrieve system statistics",
-			Code:    http.StatusInternalServerError,
+			Error:     "Statistics retrieval failed",
+			Message:   "Failed to retrieve system statistics",
+			Code:      http.StatusInternalServerError,
+			ErrorCode: models.ErrorCodeInternal,
 		})
 		return
 	}
@@ -77,9 +79,10 @@ func (h *StatisticsHandler) GetImageStatistics(c *gin.Context) {
 			zap.Error(err),
 			zap.String("request_id", requestID))
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "Image statistics retrieval failed",
-			Message: "Failed to retrieve image statistics",
-			Code:    http.StatusInternalServerError,
+			Error:     "Image statistics retrieval failed",
+			Message:   "Failed to retrieve image statistics",
+			Code:      http.StatusInternalServerError,
+			ErrorCode: models.ErrorCodeInternal,
 		})
 		return
 	}
@@ -102,9 +105,10 @@ func (h *StatisticsHandler) GetStorageStatistics(c *gin.Context) {
 			zap.Error(err),
 			zap.String("request_id", requestID))
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "Storage statistics retrieval failed",
-			Message: "Failed to retrieve storage statistics",
-			Code:    http.StatusInternalServerError,
+			Error:     "Storage statistics retrieval failed",
+			Message:   "Failed to retrieve storage statistics",
+			Code:      http.StatusInternalServerError,
+			ErrorCode: models.ErrorCodeInternal,
 		})
 		return
 	}
@@ -127,9 +131,10 @@ func (h *StatisticsHandler) GetDeduplicationStatistics(c *gin.Context) {
 			zap.Error(err),
 			zap.String("request_id", requestID))
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "Deduplication statistics retrieval failed",
-			Message: "Failed to retrieve deduplication statistics",
-			Code:    http.StatusInternalServerError,
+			Error:     "Deduplication statistics retrieval failed",
+			Message:   "Failed to retrieve deduplication statistics",
+			Code:      http.StatusInternalServerError,
+			ErrorCode: models.ErrorCodeInternal,
 		})
 		return
 	}
@@ -137,6 +142,154 @@ func (h *StatisticsHandler) GetDeduplicationStatistics(c *gin.Context) {
 	c.JSON(http.StatusOK, stats)
 }
 
+// GetUploadHistogram returns per-day upload counts for a trailing window
+// GET /api/v1/statistics/uploads?days=30
+func (h *StatisticsHandler) GetUploadHistogram(c *gin.Context) {
+	ctx := c.Request.Context()
+	requestID := c.GetString("request_id")
+
+	logger.DebugWithContext(ctx, "Processing upload histogram request",
+		zap.String("request_id", requestID))
+
+	days := models.DefaultUploadHistogramDays
+	if daysParam := c.Query("days"); daysParam != "" {
+		parsed, err := strconv.Atoi(daysParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:     "Invalid days parameter",
+				Message:   "days must be an integer",
+				Code:      http.StatusBadRequest,
+				ErrorCode: models.ErrorCodeValidationFailed,
+			})
+			return
+		}
+		days = parsed
+	}
+
+	histogram, err := h.statisticsService.GetUploadHistogram(days)
+	if err != nil {
+		if validationErr, ok := err.(models.ValidationError); ok {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:     "Invalid days parameter",
+				Message:   validationErr.Message,
+				Code:      http.StatusBadRequest,
+				ErrorCode: models.ErrorCodeFor(validationErr),
+			})
+			return
+		}
+
+		logger.ErrorWithContext(ctx, "Failed to get upload histogram",
+			zap.Error(err),
+			zap.String("request_id", requestID))
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:     "Upload histogram retrieval failed",
+			Message:   "Failed to retrieve upload histogram",
+			Code:      http.StatusInternalServerError,
+			ErrorCode: models.ErrorCodeInternal,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, histogram)
+}
+
+// GetPopularImages returns the most downloaded images
+// GET /api/v1/statistics/popular?limit=10
+func (h *StatisticsHandler) GetPopularImages(c *gin.Context) {
+	ctx := c.Request.Context()
+	requestID := c.GetString("request_id")
+
+	logger.DebugWithContext(ctx, "Processing popular images request",
+		zap.String("request_id", requestID))
+
+	limit := models.DefaultPopularImagesLimit
+	if limitParam := c.Query("limit"); limitParam != "" {
+		parsed, err := strconv.Atoi(limitParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:     "Invalid limit parameter",
+				Message:   "limit must be an integer",
+				Code:      http.StatusBadRequest,
+				ErrorCode: models.ErrorCodeValidationFailed,
+			})
+			return
+		}
+		limit = parsed
+	}
+
+	popular, err := h.statisticsService.GetPopularImages(limit)
+	if err != nil {
+		if validationErr, ok := err.(models.ValidationError); ok {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:     "Invalid limit parameter",
+				Message:   validationErr.Message,
+				Code:      http.StatusBadRequest,
+				ErrorCode: models.ErrorCodeFor(validationErr),
+			})
+			return
+		}
+
+		logger.ErrorWithContext(ctx, "Failed to get popular images",
+			zap.Error(err),
+			zap.String("request_id", requestID))
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:     "Popular images retrieval failed",
+			Message:   "Failed to retrieve popular images",
+			Code:      http.StatusInternalServerError,
+			ErrorCode: models.ErrorCodeInternal,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, popular)
+}
+
+// GetHashInfo returns the images sharing a content hash
+// GET /api/v1/statistics/hash/{hash}?namespace=tenant
+func (h *StatisticsHandler) GetHashInfo(c *gin.Context) {
+	ctx := c.Request.Context()
+	requestID := c.GetString("request_id")
+	hash := c.Param("hash")
+
+	logger.DebugWithContext(ctx, "Processing hash info request",
+		zap.String("hash", hash),
+		zap.String("request_id", requestID))
+
+	info, err := h.statisticsService.GetHashInfo(hash, c.Query("namespace"))
+	if err != nil {
+		switch e := err.(type) {
+		case models.ValidationError:
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:     "Invalid hash",
+				Message:   e.Message,
+				Code:      http.StatusBadRequest,
+				ErrorCode: models.ErrorCodeFor(e),
+			})
+		case models.NotFoundError:
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:     "Hash not found",
+				Message:   "No images reference this content hash",
+				Code:      http.StatusNotFound,
+				ErrorCode: models.ErrorCodeFor(e),
+			})
+		default:
+			logger.ErrorWithContext(ctx, "Failed to get hash info",
+				zap.Error(err),
+				zap.String("hash", hash),
+				zap.String("request_id", requestID))
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:     "Hash info retrieval failed",
+				Message:   "Failed to retrieve deduplication info for hash",
+				Code:      http.StatusInternalServerError,
+				ErrorCode: models.ErrorCodeInternal,
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, info)
+}
+
 // RefreshStatistics forces a refresh of cached statistics
 // POST /api/v1/statistics/refresh
 func (h *StatisticsHandler) RefreshStatistics(c *gin.Context) {
@@ -153,9 +306,10 @@ func (h *StatisticsHandler) RefreshStatistics(c *gin.Context) {
 			zap.Error(err),
 			zap.String("request_id", requestID))
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "Statistics refresh failed",
-			Message: "Failed to refresh system statistics",
-			Code:    http.StatusInternalServerError,
+			Error:     "Statistics refresh failed",
+			Message:   "Failed to refresh system statistics",
+			Code:      http.StatusInternalServerError,
+			ErrorCode: models.ErrorCodeInternal,
 		})
 		return
 	}
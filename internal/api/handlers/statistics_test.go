@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -57,6 +59,34 @@ func (m *MockStatisticsService) RefreshStatistics() error {
 	return args.Error(0)
 }
 
+func (m *MockStatisticsService) StartBackgroundRefresh(ctx context.Context) {
+	m.Called(ctx)
+}
+
+func (m *MockStatisticsService) GetUploadHistogram(days int) (*models.UploadHistogram, error) {
+	args := m.Called(days)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UploadHistogram), args.Error(1)
+}
+
+func (m *MockStatisticsService) GetHashInfo(hash, namespace string) (*models.HashInfo, error) {
+	args := m.Called(hash, namespace)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.HashInfo), args.Error(1)
+}
+
+func (m *MockStatisticsService) GetPopularImages(limit int) (*models.PopularImages, error) {
+	args := m.Called(limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PopularImages), args.Error(1)
+}
+
 func createTestStatisticsHandler() (*StatisticsHandler, *MockStatisticsService) {
 	mockService := &MockStatisticsService{}
 	handler := NewStatisticsHandler(mockService)
@@ -324,3 +354,265 @@ func TestGetDeduplicationStatistics_ServiceError(t *testing.T) {
 
 	mockService.AssertExpectations(t)
 }
+
+func TestGetUploadHistogram_Success(t *testing.T) {
+	handler, mockService := createTestStatisticsHandler()
+	c, w := createTestContext("GET", "/api/v1/statistics/uploads?days=2")
+
+	expected := &models.UploadHistogram{
+		Days: 2,
+		Counts: []models.DailyUploadCount{
+			{Date: "2024-01-01", Count: 3},
+			{Date: "2024-01-02", Count: 0},
+		},
+	}
+	mockService.On("GetUploadHistogram", 2).Return(expected, nil)
+
+	handler.GetUploadHistogram(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var result models.UploadHistogram
+	err := json.Unmarshal(w.Body.Bytes(), &result)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Days)
+	assert.Equal(t, expected.Counts, result.Counts)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestGetUploadHistogram_DefaultDays(t *testing.T) {
+	handler, mockService := createTestStatisticsHandler()
+	c, w := createTestContext("GET", "/api/v1/statistics/uploads")
+
+	mockService.On("GetUploadHistogram", models.DefaultUploadHistogramDays).
+		Return(&models.UploadHistogram{Days: models.DefaultUploadHistogramDays}, nil)
+
+	handler.GetUploadHistogram(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestGetUploadHistogram_InvalidDays(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		setup func(m *MockStatisticsService)
+	}{
+		{
+			name:  "not a number",
+			query: "?days=abc",
+			setup: func(m *MockStatisticsService) {},
+		},
+		{
+			name:  "out of range",
+			query: "?days=1000",
+			setup: func(m *MockStatisticsService) {
+				m.On("GetUploadHistogram", 1000).Return(nil, models.ValidationError{Field: "days", Message: "days must be between 1 and 365"})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mockService := createTestStatisticsHandler()
+			c, w := createTestContext("GET", "/api/v1/statistics/uploads"+tt.query)
+			tt.setup(mockService)
+
+			handler.GetUploadHistogram(c)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+
+			var errorResponse models.ErrorResponse
+			err := json.Unmarshal(w.Body.Bytes(), &errorResponse)
+			assert.NoError(t, err)
+			assert.Equal(t, models.ErrorCodeValidationFailed, errorResponse.ErrorCode)
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetUploadHistogram_ServiceError(t *testing.T) {
+	handler, mockService := createTestStatisticsHandler()
+	c, w := createTestContext("GET", "/api/v1/statistics/uploads?days=7")
+
+	mockService.On("GetUploadHistogram", 7).Return(nil, errors.New("service error"))
+
+	handler.GetUploadHistogram(c)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestGetHashInfo_SharedHash(t *testing.T) {
+	handler, mockService := createTestStatisticsHandler()
+	hash := strings.Repeat("ab", 32)
+	c, w := createTestContext("GET", "/api/v1/statistics/hash/"+hash)
+	c.Params = gin.Params{{Key: "hash", Value: hash}}
+
+	info := &models.DeduplicationInfo{
+		Hash:           models.ImageHash{Algorithm: "SHA256", Value: hash, Size: 1000},
+		MasterImageID:  "img-1",
+		ReferenceCount: 3,
+		StorageKey:     "images/img-1/original.jpg",
+		ReferencingIDs: []string{"img-1", "img-2", "img-3"},
+		ResolutionRefs: map[string]*models.ResolutionReference{
+			"original": {Resolution: "original", ReferencingIDs: []string{"img-1", "img-2", "img-3"}, ReferenceCount: 3},
+		},
+	}
+	mockService.On("GetHashInfo", hash, "").Return(&models.HashInfo{DeduplicationInfo: info, BytesSaved: 2000}, nil)
+
+	handler.GetHashInfo(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var result map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, float64(3), result["reference_count"])
+	assert.Equal(t, []interface{}{"img-1", "img-2", "img-3"}, result["referencing_ids"])
+	assert.Equal(t, "images/img-1/original.jpg", result["storage_key"])
+	assert.Equal(t, float64(2000), result["bytes_saved"])
+	assert.Contains(t, result["resolution_refs"], "original")
+
+	mockService.AssertExpectations(t)
+}
+
+func TestGetHashInfo_PassesNamespace(t *testing.T) {
+	handler, mockService := createTestStatisticsHandler()
+	hash := strings.Repeat("ab", 32)
+	c, w := createTestContext("GET", "/api/v1/statistics/hash/"+hash+"?namespace=tenant-a")
+	c.Params = gin.Params{{Key: "hash", Value: hash}}
+
+	mockService.On("GetHashInfo", hash, "tenant-a").
+		Return(&models.HashInfo{DeduplicationInfo: &models.DeduplicationInfo{ReferenceCount: 1}}, nil)
+
+	handler.GetHashInfo(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestGetHashInfo_Errors(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		expectedCode int
+		errorCode    string
+	}{
+		{
+			name:         "invalid_hash",
+			err:          models.ValidationError{Field: "hash", Message: "hash must be a 64-character hex-encoded SHA256 digest"},
+			expectedCode: http.StatusBadRequest,
+			errorCode:    models.ErrorCodeValidationFailed,
+		},
+		{
+			name:         "unknown_hash",
+			err:          models.NotFoundError{Resource: "deduplication_info", ID: "SHA256:abc"},
+			expectedCode: http.StatusNotFound,
+			errorCode:    models.ErrorCodeNotFound,
+		},
+		{
+			name:         "repository_failure",
+			err:          errors.New("redis down"),
+			expectedCode: http.StatusInternalServerError,
+			errorCode:    models.ErrorCodeInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mockService := createTestStatisticsHandler()
+			c, w := createTestContext("GET", "/api/v1/statistics/hash/xyz")
+			c.Params = gin.Params{{Key: "hash", Value: "xyz"}}
+
+			mockService.On("GetHashInfo", "xyz", "").Return(nil, tt.err)
+
+			handler.GetHashInfo(c)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			var response models.ErrorResponse
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, tt.errorCode, response.ErrorCode)
+		})
+	}
+}
+
+func TestGetPopularImages_Success(t *testing.T) {
+	handler, mockService := createTestStatisticsHandler()
+	c, w := createTestContext("GET", "/api/v1/statistics/popular?limit=2")
+
+	expected := &models.PopularImages{
+		Limit: 2,
+		Images: []models.PopularImage{
+			{ImageID: "hot", AccessCounts: models.AccessCounts{Total: 7, ByResolution: map[string]int64{"original": 2, "thumbnail": 5}}},
+			{ImageID: "warm", AccessCounts: models.AccessCounts{Total: 3, ByResolution: map[string]int64{"800x600": 3}}},
+		},
+	}
+	mockService.On("GetPopularImages", 2).Return(expected, nil)
+
+	handler.GetPopularImages(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var result models.PopularImages
+	err := json.Unmarshal(w.Body.Bytes(), &result)
+	assert.NoError(t, err)
+	assert.Equal(t, *expected, result)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestGetPopularImages_DefaultLimit(t *testing.T) {
+	handler, mockService := createTestStatisticsHandler()
+	c, w := createTestContext("GET", "/api/v1/statistics/popular")
+
+	mockService.On("GetPopularImages", models.DefaultPopularImagesLimit).
+		Return(&models.PopularImages{Limit: models.DefaultPopularImagesLimit, Images: []models.PopularImage{}}, nil)
+
+	handler.GetPopularImages(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestGetPopularImages_InvalidLimit(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		setup func(m *MockStatisticsService)
+	}{
+		{
+			name:  "not a number",
+			query: "?limit=abc",
+			setup: func(m *MockStatisticsService) {},
+		},
+		{
+			name:  "out of range",
+			query: "?limit=0",
+			setup: func(m *MockStatisticsService) {
+				m.On("GetPopularImages", 0).Return(nil, models.ValidationError{Field: "limit", Message: "limit must be between 1 and 100"})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mockService := createTestStatisticsHandler()
+			c, w := createTestContext("GET", "/api/v1/statistics/popular"+tt.query)
+			tt.setup(mockService)
+
+			handler.GetPopularImages(c)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+
+			var errorResponse models.ErrorResponse
+			err := json.Unmarshal(w.Body.Bytes(), &errorResponse)
+			assert.NoError(t, err)
+			assert.Equal(t, models.ErrorCodeValidationFailed, errorResponse.ErrorCode)
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// sniffLen is how many leading bytes content sniffing considers
+const sniffLen = 512
+
+// contextReader fails reads once ctx is done, so a copy from storage stops as
+// soon as the client goes away instead of draining the rest of the object
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr *contextReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(p)
+}
+
+// copyWithContext copies src to dst until EOF, an error, or ctx cancellation
+func copyWithContext(ctx context.Context, dst io.Writer, src io.Reader) (int64, error) {
+	return io.Copy(dst, &contextReader{ctx: ctx, r: src})
+}
+
+// sniffStream detects the content type of src from its leading bytes. The returned
+// reader yields the whole stream, including the bytes consumed for sniffing.
+func sniffStream(src io.Reader) (string, io.Reader, error) {
+	buffered := bufio.NewReaderSize(src, sniffLen)
+	head, err := buffered.Peek(sniffLen)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return "", nil, err
+	}
+	return sniffContentType(head), buffered, nil
+}
+
+// sniffContentType extends http.DetectContentType with TIFF, which it does not recognise
+func sniffContentType(head []byte) string {
+	if bytes.HasPrefix(head, []byte("II*\x00")) || bytes.HasPrefix(head, []byte("MM\x00*")) {
+		return "image/tiff"
+	}
+	return http.DetectContentType(head)
+}
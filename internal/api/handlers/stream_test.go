@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// endlessStream yields data forever and counts reads; onRead runs after each read
+type endlessStream struct {
+	reads  int
+	closed bool
+	onRead func(reads int)
+}
+
+func (s *endlessStream) Read(p []byte) (int, error) {
+	s.reads++
+	n := copy(p, bytes.Repeat([]byte{0xAB}, len(p)))
+	if s.onRead != nil {
+		s.onRead(s.reads)
+	}
+	return n, nil
+}
+
+func (s *endlessStream) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestCopyWithContext(t *testing.T) {
+	t.Run("copies until EOF", func(t *testing.T) {
+		var dst bytes.Buffer
+		n, err := copyWithContext(context.Background(), &dst, strings.NewReader("image bytes"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(11), n)
+		assert.Equal(t, "image bytes", dst.String())
+	})
+
+	t.Run("stops reading once the context is canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		src := &endlessStream{onRead: func(reads int) {
+			if reads == 3 {
+				cancel()
+			}
+		}}
+
+		var dst bytes.Buffer
+		_, err := copyWithContext(ctx, &dst, src)
+
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 3, src.reads)
+	})
+
+	t.Run("does not read with an already canceled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		src := &endlessStream{}
+
+		n, err := copyWithContext(ctx, &bytes.Buffer{}, src)
+
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Zero(t, n)
+		assert.Zero(t, src.reads)
+	})
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"net/http"
+
+	"resizr/pkg/version"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VersionHandler serves the build information of the running binary
+type VersionHandler struct {
+	info version.Info
+}
+
+// NewVersionHandler creates a new version handler; the info is resolved once at startup
+func NewVersionHandler(info version.Info) *VersionHandler {
+	return &VersionHandler{
+		info: info,
+	}
+}
+
+// Version returns the version, git commit, build time and Go version
+// GET /api/v1/version
+func (h *VersionHandler) Version(c *gin.Context) {
+	c.JSON(http.StatusOK, h.info)
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"resizr/internal/testutil"
+	"resizr/pkg/version"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionHandler_Version(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := NewVersionHandler(version.Info{
+		Version:   "1.2.0",
+		Commit:    "0a1b2c3",
+		BuildTime: "2026-01-02T03:04:05Z",
+		GoVersion: "go1.25.1",
+	})
+
+	req := httptest.NewRequest("GET", "/api/v1/version", nil)
+	c, w := testutil.SetupTestContext(req)
+
+	handler.Version(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	assert.NoError(t, testutil.ParseJSONResponse(w, &response))
+	assert.Equal(t, "1.2.0", response["version"])
+	assert.Equal(t, "0a1b2c3", response["commit"])
+	assert.Equal(t, "2026-01-02T03:04:05Z", response["build_time"])
+	assert.Equal(t, "go1.25.1", response["go_version"])
+}
+
+func TestVersionHandler_BuildInfoFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := NewVersionHandler(version.Get())
+
+	req := httptest.NewRequest("GET", "/api/v1/version", nil)
+	c, w := testutil.SetupTestContext(req)
+
+	handler.Version(c)
+
+	var response map[string]interface{}
+	assert.NoError(t, testutil.ParseJSONResponse(w, &response))
+	for _, field := range []string{"version", "commit", "build_time", "go_version"} {
+		assert.NotEmpty(t, response[field], field)
+	}
+	assert.Equal(t, version.Version, response["version"])
+	assert.Equal(t, runtime.Version(), response["go_version"])
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"resizr/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditImageIDKey is the context key handlers set to the image an operation created,
+// for routes without an :id parameter such as uploads
+const AuditImageIDKey = "audit_image_id"
+
+// AuditRecorder receives the audit entries of write and admin operations
+type AuditRecorder interface {
+	Record(ctx context.Context, entry models.AuditEntry)
+}
+
+// Audit records operation once the rest of the chain has run, with the API key
+// fingerprint, the image and the response status. Requests rejected before this
+// middleware (authentication, permissions, maintenance) are not recorded.
+func Audit(recorder AuditRecorder, operation string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		imageID := c.Param("id")
+		if imageID == "" {
+			imageID = c.GetString(AuditImageIDKey)
+		}
+
+		recorder.Record(c.Request.Context(), models.AuditEntry{
+			Timestamp:      time.Now().UTC(),
+			Operation:      operation,
+			ImageID:        imageID,
+			KeyFingerprint: c.GetString(AuthKeyIDKey),
+			Status:         c.Writer.Status(),
+			ClientIP:       c.ClientIP(),
+			RequestID:      c.GetString("request_id"),
+		})
+	}
+}
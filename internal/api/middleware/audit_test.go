@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"resizr/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordedAudit []models.AuditEntry
+
+func (r *recordedAudit) Record(ctx context.Context, entry models.AuditEntry) {
+	*r = append(*r, entry)
+}
+
+func TestAudit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var recorded recordedAudit
+	maintenance := NewMaintenanceMode(false)
+
+	router := gin.New()
+	router.DELETE("/images/:id", RejectDuringMaintenance(maintenance), Audit(&recorded, "delete"), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/images/abc", nil))
+	require.Len(t, recorded, 1)
+	assert.Equal(t, "delete", recorded[0].Operation)
+	assert.Equal(t, "abc", recorded[0].ImageID)
+	assert.Equal(t, http.StatusNoContent, recorded[0].Status)
+	assert.Empty(t, recorded[0].KeyFingerprint, "no key without authentication")
+
+	// Requests rejected earlier in the chain never reach the audit
+	maintenance.SetEnabled(true)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/images/abc", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Len(t, recorded, 1)
+}
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"net/http"
 	"slices"
@@ -19,8 +20,12 @@ import (
 const (
 	PermissionRead      = "read"
 	PermissionReadWrite = "read-write"
+	PermissionAdmin     = "admin"
 )
 
+// AuthKeyIDKey is the context key for the authenticated API key's identifier
+const AuthKeyIDKey = "auth_key_id"
+
 // APIKeyAuth middleware validates API keys and sets permission level
 func APIKeyAuth(cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -43,9 +48,10 @@ func APIKeyAuth(cfg *config.Config) gin.HandlerFunc {
 				zap.String("header", cfg.Auth.KeyHeader))
 
 			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-				Error:   "Missing API key",
-				Message: "API key must be provided in " + cfg.Auth.KeyHeader + " header",
-				Code:    http.StatusUnauthorized,
+				Error:     "Missing API key",
+				Message:   "API key must be provided in " + cfg.Auth.KeyHeader + " header",
+				Code:      http.StatusUnauthorized,
+				ErrorCode: models.ErrorCodeMissingAPIKey,
 			})
 			c.Abort()
 			return
@@ -59,9 +65,10 @@ func APIKeyAuth(cfg *config.Config) gin.HandlerFunc {
 				zap.String("api_key_prefix", MaskAPIKey(apiKey)))
 
 			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-				Error:   "Invalid API key",
-				Message: "The provided API key is not valid",
-				Code:    http.StatusUnauthorized,
+				Error:     "Invalid API key",
+				Message:   "The provided API key is not valid",
+				Code:      http.StatusUnauthorized,
+				ErrorCode: models.ErrorCodeInvalidAPIKey,
 			})
 			c.Abort()
 			return
@@ -69,6 +76,7 @@ func APIKeyAuth(cfg *config.Config) gin.HandlerFunc {
 
 		// Set permission in context for use by other middleware/handlers
 		c.Set("auth_permission", permission)
+		c.Set(AuthKeyIDKey, APIKeyID(apiKey))
 
 		logger.DebugWithContext(c.Request.Context(), "API key authenticated",
 			zap.String("request_id", requestID),
@@ -101,9 +109,10 @@ func RequirePermission(required string) gin.HandlerFunc {
 				zap.String("required_permission", required))
 
 			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-				Error:   "Authentication error",
-				Message: "Internal authentication error",
-				Code:    http.StatusInternalServerError,
+				Error:     "Authentication error",
+				Message:   "Internal authentication error",
+				Code:      http.StatusInternalServerError,
+				ErrorCode: models.ErrorCodeInternal,
 			})
 			c.Abort()
 			return
@@ -117,9 +126,10 @@ func RequirePermission(required string) gin.HandlerFunc {
 				zap.String("required_permission", required))
 
 			c.JSON(http.StatusForbidden, models.ErrorResponse{
-				Error:   "Insufficient permissions",
-				Message: "This operation requires " + required + " permissions",
-				Code:    http.StatusForbidden,
+				Error:     "Insufficient permissions",
+				Message:   "This operation requires " + required + " permissions",
+				Code:      http.StatusForbidden,
+				ErrorCode: models.ErrorCodeForbidden,
 			})
 			c.Abort()
 			return
@@ -136,6 +146,11 @@ func RequirePermission(required string) gin.HandlerFunc {
 
 // validateAPIKey validates an API key and returns the permission level
 func validateAPIKey(apiKey string, authConfig config.AuthConfig) string {
+	// Check admin keys
+	if slices.Contains(authConfig.AdminKeys, apiKey) {
+		return PermissionAdmin
+	}
+
 	// Check read-write keys
 	if slices.Contains(authConfig.ReadWriteKeys, apiKey) {
 		return PermissionReadWrite
@@ -153,11 +168,14 @@ func validateAPIKey(apiKey string, authConfig config.AuthConfig) string {
 func hasPermission(userPermission, requiredPermission string) bool {
 	switch requiredPermission {
 	case PermissionRead:
-		// Both read and read-write permissions can access read operations
-		return userPermission == PermissionRead || userPermission == PermissionReadWrite
+		// Every permission level can access read operations
+		return userPermission == PermissionRead || userPermission == PermissionReadWrite || userPermission == PermissionAdmin
 	case PermissionReadWrite:
-		// Only read-write permission can access write operations
-		return userPermission == PermissionReadWrite
+		// Read-write and admin permissions can access write operations
+		return userPermission == PermissionReadWrite || userPermission == PermissionAdmin
+	case PermissionAdmin:
+		// Only admin permission can access admin operations
+		return userPermission == PermissionAdmin
 	default:
 		return false
 	}
@@ -171,6 +189,12 @@ func MaskAPIKey(apiKey string) string {
 	return apiKey[:8] + strings.Repeat("*", len(apiKey)-8)
 }
 
+// APIKeyID returns a stable, non-reversible identifier for an API key
+func APIKeyID(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:8])
+}
+
 // GenerateAPIKey generates a cryptographically secure API key
 func GenerateAPIKey() (string, error) {
 	// Generate 32 random bytes (256 bits)
@@ -117,7 +117,7 @@ func TestAPIKeyAuth(t *testing.T) {
 			router.Use(APIKeyAuth(cfg))
 			router.GET("/test", func(c *gin.Context) {
 				permission := c.GetString("auth_permission")
-				c.JSON(http.StatusOK, gin.H{"permission": permission})
+				c.JSON(http.StatusOK, gin.H{"permission": permission, "key_id": c.GetString(AuthKeyIDKey)})
 			})
 
 			// Create request
@@ -137,6 +137,11 @@ func TestAPIKeyAuth(t *testing.T) {
 				err := testutil.ParseJSONResponse(w, &response)
 				require.NoError(t, err)
 				assert.Equal(t, tt.expectedPerm, response["permission"])
+				if tt.authEnabled {
+					assert.Equal(t, APIKeyID(tt.requestValue), response["key_id"])
+				} else {
+					assert.Equal(t, "", response["key_id"])
+				}
 			} else {
 				var response models.ErrorResponse
 				err := testutil.ParseJSONResponse(w, &response)
@@ -254,6 +259,7 @@ func TestValidateAPIKey(t *testing.T) {
 		apiKey             string
 		readWriteKeys      []string
 		readOnlyKeys       []string
+		adminKeys          []string
 		expectedPermission string
 	}{
 		{
@@ -270,6 +276,14 @@ func TestValidateAPIKey(t *testing.T) {
 			readOnlyKeys:       []string{"ro-key-1", "ro-key-2"},
 			expectedPermission: PermissionRead,
 		},
+		{
+			name:               "valid admin key",
+			apiKey:             "admin-key-1",
+			readWriteKeys:      []string{"rw-key-1"},
+			readOnlyKeys:       []string{"ro-key-1"},
+			adminKeys:          []string{"admin-key-1"},
+			expectedPermission: PermissionAdmin,
+		},
 		{
 			name:               "invalid key",
 			apiKey:             "invalid-key",
@@ -291,6 +305,7 @@ func TestValidateAPIKey(t *testing.T) {
 			authConfig := config.AuthConfig{
 				ReadWriteKeys: tt.readWriteKeys,
 				ReadOnlyKeys:  tt.readOnlyKeys,
+				AdminKeys:     tt.adminKeys,
 			}
 
 			result := validateAPIKey(tt.apiKey, authConfig)
@@ -330,6 +345,24 @@ func TestHasPermission(t *testing.T) {
 			requiredPermission: PermissionReadWrite,
 			expected:           false,
 		},
+		{
+			name:               "admin user can read and write",
+			userPermission:     PermissionAdmin,
+			requiredPermission: PermissionReadWrite,
+			expected:           true,
+		},
+		{
+			name:               "admin user can access admin operations",
+			userPermission:     PermissionAdmin,
+			requiredPermission: PermissionAdmin,
+			expected:           true,
+		},
+		{
+			name:               "read-write user cannot access admin operations",
+			userPermission:     PermissionReadWrite,
+			requiredPermission: PermissionAdmin,
+			expected:           false,
+		},
 		{
 			name:               "invalid permission",
 			userPermission:     "invalid",
@@ -451,3 +484,12 @@ func TestValidateAPIKeyFormat(t *testing.T) {
 		})
 	}
 }
+
+func TestAPIKeyID(t *testing.T) {
+	id := APIKeyID("rw-key-1")
+
+	assert.Len(t, id, 16)
+	assert.Equal(t, id, APIKeyID("rw-key-1"))
+	assert.NotEqual(t, id, APIKeyID("rw-key-2"))
+	assert.NotContains(t, id, "rw-key")
+}
@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"resizr/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// DefaultCompressionMinSize is the smallest JSON body worth compressing
+const DefaultCompressionMinSize = 1024
+
+// Compression middleware gzip- or deflate-encodes JSON responses for clients that
+// accept it. Image bodies are already compressed and pass through untouched, as do
+// JSON bodies smaller than minSize (0 uses DefaultCompressionMinSize).
+func Compression(minSize int) gin.HandlerFunc {
+	if minSize <= 0 {
+		minSize = DefaultCompressionMinSize
+	}
+
+	return func(c *gin.Context) {
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"))
+		if encoding == "" || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+
+		writer := &compressWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		defer func() {
+			c.Writer = writer.ResponseWriter
+		}()
+
+		c.Next()
+
+		if !writer.buffering {
+			return
+		}
+
+		body := writer.buf.Bytes()
+		if len(body) < minSize {
+			_, _ = writer.ResponseWriter.Write(body)
+			return
+		}
+
+		compressed, err := compressBody(body, encoding)
+		if err != nil {
+			logger.WarnWithContext(c.Request.Context(), "Failed to compress response, sending it uncompressed",
+				zap.String("encoding", encoding),
+				zap.Error(err),
+				zap.String("request_id", c.GetString("request_id")))
+			_, _ = writer.ResponseWriter.Write(body)
+			return
+		}
+
+		header := writer.ResponseWriter.Header()
+		header.Set("Content-Encoding", encoding)
+		header.Add("Vary", "Accept-Encoding")
+		header.Del("Content-Length")
+		_, _ = writer.ResponseWriter.Write(compressed)
+	}
+}
+
+// compressWriter holds back JSON bodies so they can be compressed once the handler
+// is done; any other content type is written straight through
+type compressWriter struct {
+	gin.ResponseWriter
+	decided   bool
+	buffering bool
+	buf       bytes.Buffer
+}
+
+func (w *compressWriter) decide() {
+	if w.decided {
+		return
+	}
+	w.decided = true
+	w.buffering = strings.HasPrefix(w.ResponseWriter.Header().Get("Content-Type"), "application/json")
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	w.decide()
+	if w.buffering {
+		return w.buf.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Size reports the bytes written so far, including buffered ones
+func (w *compressWriter) Size() int {
+	if w.buffering {
+		return w.buf.Len()
+	}
+	return w.ResponseWriter.Size()
+}
+
+// Written reports whether the handler has produced a response
+func (w *compressWriter) Written() bool {
+	return w.buf.Len() > 0 || w.ResponseWriter.Written()
+}
+
+// negotiateEncoding picks gzip or deflate from an Accept-Encoding header, preferring
+// gzip, and returns "" when neither is acceptable. A zero q-value refuses an encoding.
+func negotiateEncoding(acceptEncoding string) string {
+	accepted := make(map[string]bool)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+				quality = parsed
+			}
+		}
+		accepted[name] = quality > 0
+	}
+
+	for _, encoding := range []string{"gzip", "deflate"} {
+		if accepted[encoding] {
+			return encoding
+		}
+	}
+	// A wildcard accepts gzip unless gzip itself was refused
+	if _, refused := accepted["gzip"]; accepted["*"] && !refused {
+		return "gzip"
+	}
+	return ""
+}
+
+// compressBody encodes body with the negotiated encoding
+func compressBody(body []byte, encoding string) ([]byte, error) {
+	var buf bytes.Buffer
+	var encoder io.WriteCloser
+	if encoding == "gzip" {
+		encoder = gzip.NewWriter(&buf)
+	} else {
+		// HTTP "deflate" is the zlib format, not a raw deflate stream
+		encoder = zlib.NewWriter(&buf)
+	}
+
+	if _, err := encoder.Write(body); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupCompressionRouter(minSize int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Compression(minSize))
+	router.GET("/large", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"data": strings.Repeat("resizr ", 500)})
+	})
+	router.GET("/small", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	router.GET("/image", func(c *gin.Context) {
+		c.Data(http.StatusOK, "image/jpeg", []byte(strings.Repeat("\xff", 4096)))
+	})
+	return router
+}
+
+func TestCompression_GzipLargeJSON(t *testing.T) {
+	router := setupCompressionRouter(1024)
+
+	req := httptest.NewRequest("GET", "/large", nil)
+	req.Header.Set("Accept-Encoding", "gzip, deflate, br")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+
+	reader, err := gzip.NewReader(w.Body)
+	require.NoError(t, err)
+	body, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `"data":"resizr resizr`)
+	assert.Less(t, w.Body.Len(), len(body))
+}
+
+func TestCompression_Deflate(t *testing.T) {
+	router := setupCompressionRouter(1024)
+
+	req := httptest.NewRequest("GET", "/large", nil)
+	req.Header.Set("Accept-Encoding", "deflate")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, "deflate", w.Header().Get("Content-Encoding"))
+	reader, err := zlib.NewReader(w.Body)
+	require.NoError(t, err)
+	body, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `"data":"resizr resizr`)
+}
+
+func TestCompression_Skipped(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		acceptEncoding string
+	}{
+		{"no Accept-Encoding", "/large", ""},
+		{"unsupported encoding", "/large", "br"},
+		{"gzip refused", "/large", "gzip;q=0, br"},
+		{"small payload", "/small", "gzip"},
+		{"image body", "/image", "gzip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := setupCompressionRouter(1024)
+
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Empty(t, w.Header().Get("Content-Encoding"))
+			if tt.path == "/image" {
+				assert.Equal(t, 4096, w.Body.Len())
+			} else {
+				assert.True(t, strings.HasPrefix(w.Body.String(), "{"))
+			}
+		})
+	}
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected string
+	}{
+		{"", ""},
+		{"gzip", "gzip"},
+		{"deflate, gzip", "gzip"},
+		{"GZIP;q=0.5", "gzip"},
+		{"gzip;q=0, deflate", "deflate"},
+		{"*", "gzip"},
+		{"gzip;q=0, *", ""},
+		{"br, identity", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			assert.Equal(t, tt.expected, negotiateEncoding(tt.header))
+		})
+	}
+}
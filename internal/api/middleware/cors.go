@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"net/http"
+	"strings"
 
 	"resizr/internal/config"
 
@@ -20,11 +21,18 @@ func CORS(cfg *config.Config) gin.HandlerFunc {
 		origin := c.Request.Header.Get("Origin")
 		var allowedOrigin bool
 
+		// Write and admin requests follow their own origin list when one is configured
+		restricted := cfg.CORS.WriteAllowedOrigins != nil && isWriteRequest(c.Request)
+		allowed := isAllowedOrigin
+		if restricted {
+			allowed = isAllowedWriteOrigin
+		}
+
 		// Check if origin is allowed and set CORS headers only for allowed origins
-		if origin != "" && isAllowedOrigin(origin, cfg) {
+		if origin != "" && allowed(origin, cfg) {
 			c.Header("Access-Control-Allow-Origin", origin)
 			allowedOrigin = true
-		} else if origin == "" && cfg.CORS.AllowAllOrigins {
+		} else if origin == "" && cfg.CORS.AllowAllOrigins && !restricted {
 			// Only set wildcard for non-origin requests if explicitly allowing all origins
 			c.Header("Access-Control-Allow-Origin", "*")
 			allowedOrigin = true
@@ -34,7 +42,7 @@ func CORS(cfg *config.Config) gin.HandlerFunc {
 		if allowedOrigin {
 			c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 			c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-ID, X-Requested-With")
-			c.Header("Access-Control-Expose-Headers", "X-Request-ID, Content-Length, Content-Type")
+			c.Header("Access-Control-Expose-Headers", "X-Request-ID, Content-Length, Content-Type, X-Cache")
 
 			// Set credentials header based on configuration
 			if cfg.CORS.AllowCredentials {
@@ -60,6 +68,31 @@ func CORS(cfg *config.Config) gin.HandlerFunc {
 	}
 }
 
+// isWriteRequest reports whether a request falls under the write/admin CORS policy:
+// anything under /api/v1/admin and any method other than GET or HEAD. Preflights
+// are classified by the method they ask permission for.
+func isWriteRequest(r *http.Request) bool {
+	if strings.HasPrefix(r.URL.Path, "/api/v1/admin") {
+		return true
+	}
+
+	method := r.Method
+	if method == http.MethodOptions {
+		method = r.Header.Get("Access-Control-Request-Method")
+	}
+	return method != "" && method != http.MethodGet && method != http.MethodHead
+}
+
+// isAllowedWriteOrigin checks an origin against CORS_WRITE_ALLOWED_ORIGINS
+func isAllowedWriteOrigin(origin string, cfg *config.Config) bool {
+	for _, allowed := range cfg.CORS.WriteAllowedOrigins {
+		if allowed == "*" || origin == allowed {
+			return true
+		}
+	}
+	return false
+}
+
 // isAllowedOrigin checks if the origin is allowed
 func isAllowedOrigin(origin string, cfg *config.Config) bool {
 	// If allow all origins is enabled, allow all origins
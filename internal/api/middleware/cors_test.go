@@ -343,7 +343,7 @@ func TestCORS_Headers(t *testing.T) {
 	assert.Equal(t, "https://example.com", w.Header().Get("Access-Control-Allow-Origin"))
 	assert.Equal(t, "GET, POST, PUT, DELETE, OPTIONS", w.Header().Get("Access-Control-Allow-Methods"))
 	assert.Equal(t, "Content-Type, Authorization, X-Request-ID, X-Requested-With", w.Header().Get("Access-Control-Allow-Headers"))
-	assert.Equal(t, "X-Request-ID, Content-Length, Content-Type", w.Header().Get("Access-Control-Expose-Headers"))
+	assert.Equal(t, "X-Request-ID, Content-Length, Content-Type, X-Cache", w.Header().Get("Access-Control-Expose-Headers"))
 	assert.Equal(t, "86400", w.Header().Get("Access-Control-Max-Age"))
 }
 
@@ -443,3 +443,95 @@ func TestCORS_EdgeCases(t *testing.T) {
 		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
 	})
 }
+
+func TestCORS_WriteAllowedOrigins(t *testing.T) {
+	cfg := &config.Config{
+		CORS: config.CORSConfig{
+			Enabled:             true,
+			AllowAllOrigins:     true,
+			WriteAllowedOrigins: []string{"https://admin.example.com"},
+		},
+	}
+
+	tests := []struct {
+		name            string
+		method          string
+		path            string
+		origin          string
+		preflightMethod string
+		expectedStatus  int
+		expectedOrigin  string
+	}{
+		{
+			name:           "download open to any origin",
+			method:         "GET",
+			path:           "/api/v1/images/abc/original",
+			origin:         "https://blog.example.org",
+			expectedStatus: http.StatusOK,
+			expectedOrigin: "https://blog.example.org",
+		},
+		{
+			name:           "write rejects an origin downloads allow",
+			method:         "POST",
+			path:           "/api/v1/images",
+			origin:         "https://blog.example.org",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:            "write preflight rejects an origin downloads allow",
+			method:          "OPTIONS",
+			path:            "/api/v1/images/abc",
+			origin:          "https://blog.example.org",
+			preflightMethod: "DELETE",
+			expectedStatus:  http.StatusForbidden,
+		},
+		{
+			name:            "download preflight allowed",
+			method:          "OPTIONS",
+			path:            "/api/v1/images/abc/original",
+			origin:          "https://blog.example.org",
+			preflightMethod: "GET",
+			expectedStatus:  http.StatusNoContent,
+			expectedOrigin:  "https://blog.example.org",
+		},
+		{
+			name:            "write preflight from the write origin",
+			method:          "OPTIONS",
+			path:            "/api/v1/images",
+			origin:          "https://admin.example.com",
+			preflightMethod: "POST",
+			expectedStatus:  http.StatusNoContent,
+			expectedOrigin:  "https://admin.example.com",
+		},
+		{
+			name:           "admin reads are restricted too",
+			method:         "GET",
+			path:           "/api/v1/admin/maintenance",
+			origin:         "https://blog.example.org",
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.Use(CORS(cfg))
+			router.Any("/api/v1/*path", func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Origin", tt.origin)
+			if tt.preflightMethod != "" {
+				req.Header.Set("Access-Control-Request-Method", tt.preflightMethod)
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedOrigin, w.Header().Get("Access-Control-Allow-Origin"))
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"resizr/internal/models"
+	"resizr/internal/testutil"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupMaintenanceRouter(maintenance *MaintenanceMode) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	writable := RejectDuringMaintenance(maintenance)
+
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/health", ok)
+	router.GET("/images/:id/info", ok)
+	router.GET("/images/:id/original", ok)
+	router.POST("/images", writable, ok)
+	router.POST("/images/:id/resolutions", writable, ok)
+	router.DELETE("/images/:id", writable, ok)
+	return router
+}
+
+func TestRejectDuringMaintenance(t *testing.T) {
+	maintenance := NewMaintenanceMode(true)
+	router := setupMaintenanceRouter(maintenance)
+
+	reads := []struct{ method, path string }{
+		{"GET", "/health"},
+		{"GET", "/images/abc/info"},
+		{"GET", "/images/abc/original"},
+	}
+	writes := []struct{ method, path string }{
+		{"POST", "/images"},
+		{"POST", "/images/abc/resolutions"},
+		{"DELETE", "/images/abc"},
+	}
+
+	for _, r := range reads {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(r.method, r.path, nil))
+		assert.Equal(t, http.StatusOK, w.Code, "%s %s", r.method, r.path)
+	}
+
+	for _, r := range writes {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(r.method, r.path, nil))
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code, "%s %s", r.method, r.path)
+
+		var response models.ErrorResponse
+		assert.NoError(t, testutil.ParseJSONResponse(w, &response))
+		assert.Equal(t, models.ErrorCodeMaintenance, response.ErrorCode)
+	}
+}
+
+func TestRejectDuringMaintenance_ToggledAtRuntime(t *testing.T) {
+	maintenance := NewMaintenanceMode(false)
+	router := setupMaintenanceRouter(maintenance)
+
+	upload := func() int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/images", nil))
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, upload())
+
+	maintenance.SetEnabled(true)
+	assert.True(t, maintenance.Enabled())
+	assert.Equal(t, http.StatusServiceUnavailable, upload())
+
+	maintenance.SetEnabled(false)
+	assert.Equal(t, http.StatusOK, upload())
+}
+
+func TestMarkMaintenance(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	maintenance := NewMaintenanceMode(false)
+	router := gin.New()
+	router.GET("/images/:id/:resolution", MarkMaintenance(maintenance), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"maintenance": c.GetBool(MaintenanceKey)})
+	})
+
+	marked := func() string {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/images/abc/thumbnail.png", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		return w.Body.String()
+	}
+
+	assert.JSONEq(t, `{"maintenance":false}`, marked())
+
+	maintenance.SetEnabled(true)
+	assert.JSONEq(t, `{"maintenance":true}`, marked())
+}
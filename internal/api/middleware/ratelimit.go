@@ -150,9 +150,10 @@ func (rl *RateLimiter) handleRateLimitExceeded(c *gin.Context, clientIP, endpoin
 	c.Header("Retry-After", "60")
 
 	c.JSON(http.StatusTooManyRequests, models.ErrorResponse{
-		Error:   "Rate limit exceeded",
-		Message: fmt.Sprintf("Too many requests. Limit: %d requests per minute", limit),
-		Code:    http.StatusTooManyRequests,
+		Error:     "Rate limit exceeded",
+		Message:   fmt.Sprintf("Too many requests. Limit: %d requests per minute", limit),
+		Code:      http.StatusTooManyRequests,
+		ErrorCode: models.ErrorCodeRateLimitExceeded,
 	})
 
 	c.Abort()
@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"resizr/internal/config"
+	"resizr/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Forwarded client IP headers, in order of preference
+const (
+	ForwardedForHeader = "X-Forwarded-For"
+	RealIPHeader       = "X-Real-IP"
+)
+
+// RealIP middleware resolves the client IP from X-Forwarded-For / X-Real-IP when the
+// immediate peer is one of TRUSTED_PROXIES and rewrites RemoteAddr so c.ClientIP(),
+// rate limiting and logs see the real client. Forwarded headers from untrusted
+// peers are dropped so they cannot be used to spoof the client IP.
+func RealIP(cfg *config.Config) gin.HandlerFunc {
+	trusted := ParseTrustedProxies(cfg.Server.TrustedProxies)
+
+	return func(c *gin.Context) {
+		peerIP, port := splitRemoteAddr(c.Request.RemoteAddr)
+
+		if peerIP != nil && isTrustedProxy(peerIP, trusted) {
+			if clientIP := forwardedClientIP(c.Request, trusted); clientIP != nil && !clientIP.Equal(peerIP) {
+				logger.DebugWithContext(c.Request.Context(), "Resolved forwarded client IP",
+					zap.String("peer_ip", peerIP.String()),
+					zap.String("client_ip", clientIP.String()))
+				c.Request.RemoteAddr = net.JoinHostPort(clientIP.String(), port)
+			}
+		}
+
+		// The resolved address now lives in RemoteAddr; never let later code re-read the headers
+		c.Request.Header.Del(ForwardedForHeader)
+		c.Request.Header.Del(RealIPHeader)
+
+		c.Next()
+	}
+}
+
+// ParseTrustedProxies parses IP addresses and CIDR ranges; a bare IP matches only itself.
+// Invalid entries are skipped (config validation rejects them up front).
+func ParseTrustedProxies(entries []string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(entries))
+	for _, entry := range entries {
+		if network, ok := parseProxyEntry(entry); ok {
+			networks = append(networks, network)
+		}
+	}
+	return networks
+}
+
+func parseProxyEntry(entry string) (*net.IPNet, bool) {
+	entry = strings.TrimSpace(entry)
+	if strings.Contains(entry, "/") {
+		_, network, err := net.ParseCIDR(entry)
+		return network, err == nil
+	}
+
+	ip := net.ParseIP(entry)
+	if ip == nil {
+		return nil, false
+	}
+	bits := 128
+	if v4 := ip.To4(); v4 != nil {
+		ip, bits = v4, 32
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, true
+}
+
+func isTrustedProxy(ip net.IP, trusted []*net.IPNet) bool {
+	for _, network := range trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedClientIP walks X-Forwarded-For from the nearest hop outwards and returns the
+// first address that is not a trusted proxy, falling back to X-Real-IP
+func forwardedClientIP(r *http.Request, trusted []*net.IPNet) net.IP {
+	if header := r.Header.Get(ForwardedForHeader); header != "" {
+		hops := strings.Split(header, ",")
+		var outermost net.IP
+		for i := len(hops) - 1; i >= 0; i-- {
+			ip := net.ParseIP(strings.TrimSpace(hops[i]))
+			if ip == nil {
+				// Anything beyond a malformed hop cannot be trusted
+				break
+			}
+			if !isTrustedProxy(ip, trusted) {
+				return ip
+			}
+			outermost = ip
+		}
+		if outermost != nil {
+			return outermost
+		}
+	}
+
+	return net.ParseIP(strings.TrimSpace(r.Header.Get(RealIPHeader)))
+}
+
+func splitRemoteAddr(remoteAddr string) (net.IP, string) {
+	host, port, err := net.SplitHostPort(strings.TrimSpace(remoteAddr))
+	if err != nil {
+		return net.ParseIP(strings.TrimSpace(remoteAddr)), "0"
+	}
+	return net.ParseIP(host), port
+}
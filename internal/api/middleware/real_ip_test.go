@@ -0,0 +1,155 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"resizr/internal/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRealIPRouter(trustedProxies []string) (*gin.Engine, *string) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{Server: config.ServerConfig{TrustedProxies: trustedProxies}}
+
+	var seen string
+	router := gin.New()
+	router.Use(RealIP(cfg))
+	router.GET("/ip", func(c *gin.Context) {
+		seen = c.ClientIP()
+		c.Status(http.StatusOK)
+	})
+	return router, &seen
+}
+
+func TestRealIP(t *testing.T) {
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		remoteAddr     string
+		headers        map[string]string
+		expectedIP     string
+	}{
+		{
+			name:           "trusted proxy forwards client ip",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteAddr:     "10.1.2.3:4567",
+			headers:        map[string]string{ForwardedForHeader: "203.0.113.7"},
+			expectedIP:     "203.0.113.7",
+		},
+		{
+			name:           "untrusted peer cannot spoof forwarded header",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteAddr:     "198.51.100.9:4567",
+			headers:        map[string]string{ForwardedForHeader: "203.0.113.7"},
+			expectedIP:     "198.51.100.9",
+		},
+		{
+			name:           "no trusted proxies ignores headers",
+			trustedProxies: nil,
+			remoteAddr:     "10.1.2.3:4567",
+			headers:        map[string]string{ForwardedForHeader: "203.0.113.7", RealIPHeader: "203.0.113.8"},
+			expectedIP:     "10.1.2.3",
+		},
+		{
+			name:           "chain of trusted proxies resolves first untrusted hop",
+			trustedProxies: []string{"10.0.0.0/8", "172.16.0.1"},
+			remoteAddr:     "10.0.0.2:80",
+			headers:        map[string]string{ForwardedForHeader: "1.1.1.1, 203.0.113.7, 172.16.0.1, 10.0.0.5"},
+			expectedIP:     "203.0.113.7",
+		},
+		{
+			name:           "all hops trusted uses outermost",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteAddr:     "10.0.0.2:80",
+			headers:        map[string]string{ForwardedForHeader: "10.9.9.9, 10.0.0.5"},
+			expectedIP:     "10.9.9.9",
+		},
+		{
+			name:           "real ip header used when forwarded for is absent",
+			trustedProxies: []string{"10.1.2.3"},
+			remoteAddr:     "10.1.2.3:4567",
+			headers:        map[string]string{RealIPHeader: "203.0.113.8"},
+			expectedIP:     "203.0.113.8",
+		},
+		{
+			name:           "malformed forwarded header falls back to peer",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteAddr:     "10.1.2.3:4567",
+			headers:        map[string]string{ForwardedForHeader: "garbage"},
+			expectedIP:     "10.1.2.3",
+		},
+		{
+			name:           "ipv6 proxy range",
+			trustedProxies: []string{"fd00::/8"},
+			remoteAddr:     "[fd00::1]:443",
+			headers:        map[string]string{ForwardedForHeader: "2001:db8::42"},
+			expectedIP:     "2001:db8::42",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, seen := newRealIPRouter(tt.trustedProxies)
+
+			req := httptest.NewRequest("GET", "/ip", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.expectedIP, *seen)
+		})
+	}
+}
+
+func TestRealIP_RateLimitKeysByResolvedIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{
+		Server:    config.ServerConfig{TrustedProxies: []string{"10.0.0.0/8"}},
+		RateLimit: config.RateLimitConfig{Upload: 1},
+	}
+
+	// Reset the rate limiter singleton so this test's config applies
+	globalRateLimiter = nil
+	once = sync.Once{}
+
+	router := gin.New()
+	router.Use(RealIP(cfg))
+	router.Use(RateLimit(cfg))
+	router.POST("/api/v1/images", func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+
+	send := func(clientIP string) int {
+		req := httptest.NewRequest("POST", "/api/v1/images", nil)
+		req.RemoteAddr = "10.0.0.2:1234"
+		req.Header.Set(ForwardedForHeader, clientIP)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Burst is 2x the per-minute limit; the third request from one client is rejected
+	assert.Equal(t, http.StatusCreated, send("203.0.113.1"))
+	assert.Equal(t, http.StatusCreated, send("203.0.113.1"))
+	assert.Equal(t, http.StatusTooManyRequests, send("203.0.113.1"))
+
+	// A different client behind the same proxy has its own bucket
+	assert.Equal(t, http.StatusCreated, send("203.0.113.2"))
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	networks := ParseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.10", "::1", "bogus", "300.1.1.1"})
+	assert.Len(t, networks, 3)
+	assert.Equal(t, "192.168.1.10/32", networks[1].String())
+	assert.Equal(t, "::1/128", networks[2].String())
+}
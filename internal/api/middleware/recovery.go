@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"runtime/debug"
+	"sync/atomic"
+
+	"resizr/internal/models"
+	"resizr/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// panicsRecovered counts handler panics caught by Recovery since startup
+var panicsRecovered int64
+
+// PanicsRecovered returns the number of handler panics recovered since startup
+func PanicsRecovered() int64 {
+	return atomic.LoadInt64(&panicsRecovered)
+}
+
+// Recovery middleware recovers from handler panics, logs them with a stack trace
+// and returns a generic 500 error envelope without internal details
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if recovered == http.ErrAbortHandler {
+				// Deliberate abort: let net/http handle it without logging a stack
+				panic(recovered)
+			}
+
+			atomic.AddInt64(&panicsRecovered, 1)
+
+			logger.ErrorWithContext(c.Request.Context(), "Panic recovered in request handler",
+				zap.Any("panic", recovered),
+				zap.String("method", c.Request.Method),
+				zap.String("path", c.Request.URL.Path),
+				zap.String("client_ip", c.ClientIP()),
+				zap.String("request_id", c.GetString(RequestIDKey)),
+				zap.ByteString("stack", debug.Stack()))
+
+			if c.Writer.Written() {
+				// Headers are already on the wire; the best we can do is stop the chain
+				c.Abort()
+				return
+			}
+
+			c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:     "Internal server error",
+				Message:   "An unexpected error occurred while processing the request",
+				Code:      http.StatusInternalServerError,
+				ErrorCode: models.ErrorCodeInternal,
+			})
+		}()
+
+		c.Next()
+	}
+}
@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"resizr/internal/models"
+	"resizr/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRecovery_HandlerPanic(t *testing.T) {
+	core, logs := observer.New(zapcore.ErrorLevel)
+	previous := logger.SetLogger(zap.New(core))
+	defer logger.SetLogger(previous)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Recovery())
+	router.Use(RequestID())
+	router.GET("/boom", func(c *gin.Context) {
+		panic("database password is hunter2")
+	})
+
+	before := PanicsRecovered()
+
+	req := httptest.NewRequest("GET", "/boom", nil)
+	req.Header.Set(RequestIDHeader, "panic-request-id")
+	w := httptest.NewRecorder()
+
+	assert.NotPanics(t, func() { router.ServeHTTP(w, req) })
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NotContains(t, w.Body.String(), "hunter2", "panic value must not leak to the client")
+	assert.NotContains(t, w.Body.String(), "goroutine", "stack trace must not leak to the client")
+
+	var response models.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, http.StatusInternalServerError, response.Code)
+	assert.Equal(t, models.ErrorCodeInternal, response.ErrorCode)
+
+	assert.Equal(t, before+1, PanicsRecovered())
+
+	entries := logs.FilterMessage("Panic recovered in request handler").All()
+	require.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, "panic-request-id", fields["request_id"])
+	assert.Equal(t, "/boom", fields["path"])
+	assert.Contains(t, fields["stack"], "goroutine")
+	assert.Contains(t, fields["panic"], "hunter2")
+}
+
+func TestRecovery_NoPanic(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Recovery())
+	router.GET("/ok", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	before := PanicsRecovered()
+
+	req := httptest.NewRequest("GET", "/ok", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, before, PanicsRecovered())
+}
+
+func TestRecovery_PanicAfterResponseStarted(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Recovery())
+	router.GET("/partial", func(c *gin.Context) {
+		c.String(http.StatusOK, "partial body")
+		panic("late failure")
+	})
+
+	req := httptest.NewRequest("GET", "/partial", nil)
+	w := httptest.NewRecorder()
+
+	assert.NotPanics(t, func() { router.ServeHTTP(w, req) })
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "partial body", w.Body.String())
+}
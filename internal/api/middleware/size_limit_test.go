@@ -2,7 +2,9 @@ package middleware
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -59,6 +61,7 @@ func TestRequestSizeLimit_ExceedsLimit(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "Request too large", response["error"])
 	assert.Contains(t, response["message"], "exceeds maximum allowed size")
+	assert.Equal(t, "REQUEST_TOO_LARGE", response["error_code"])
 }
 
 func TestRequestSizeLimit_ExactLimit(t *testing.T) {
@@ -81,6 +84,39 @@ func TestRequestSizeLimit_ExactLimit(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 }
 
+func TestRequestSizeLimitByRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestSizeLimitByRoute(100, map[string]int64{"/large/:id": 200}))
+	router.POST("/large/:id", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	router.POST("/small", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		path           string
+		size           int
+		expectedStatus int
+	}{
+		{"/large/1", 200, http.StatusOK},
+		{"/large/1", 201, http.StatusRequestEntityTooLarge},
+		{"/small", 100, http.StatusOK},
+		{"/small", 101, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", tt.path, strings.NewReader(strings.Repeat("a", tt.size)))
+		req.Header.Set("Content-Length", fmt.Sprintf("%d", tt.size))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, tt.expectedStatus, w.Code, "%s with %d bytes", tt.path, tt.size)
+	}
+}
+
 func TestRequestSizeLimit_GetRequestSkipped(t *testing.T) {
 	maxSize := int64(100) // Very small limit
 
@@ -444,3 +480,54 @@ func TestRequestSizeLimit_EmptyBody(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, w.Code)
 }
+
+func TestRequestSizeLimit_StreamedBodyExceedsLimit(t *testing.T) {
+	maxSize := int64(1024)
+	payload := strings.Repeat("a", 4096)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestSizeLimit(maxSize))
+	router.POST("/test", func(c *gin.Context) {
+		// Reading past the limit must fail even without a Content-Length header
+		if _, err := io.ReadAll(c.Request.Body); err != nil {
+			var maxBytesErr *http.MaxBytesError
+			assert.True(t, errors.As(err, &maxBytesErr))
+			c.Status(http.StatusRequestEntityTooLarge)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest("POST", "/test", strings.NewReader(payload))
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
+
+func TestRequestSizeLimit_MultipartWithinLimit(t *testing.T) {
+	cfg := testutil.TestConfig()
+	fileContent := bytes.Repeat([]byte{0xAA}, int(cfg.Image.MaxFileSize)) // File at the maximum allowed size
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestSizeLimit(cfg.Server.MaxRequestBodySize))
+	router.POST("/test", func(c *gin.Context) {
+		if _, _, err := c.Request.FormFile("image"); err != nil {
+			c.Status(http.StatusBadRequest)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	req := testutil.CreateMultipartRequest("POST", "/test", map[string]string{"resolutions": "800x600"}, "image", "test.jpg", fileContent)
+	req.Header.Set("Content-Length", fmt.Sprintf("%d", req.ContentLength))
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	// Multipart overhead on top of MaxFileSize must fit within the body limit
+	assert.Equal(t, http.StatusOK, w.Code)
+}
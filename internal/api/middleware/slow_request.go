@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"time"
+
+	"resizr/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// SlowRequestLog replaces the per-request access log: only requests taking longer
+// than threshold are logged, at warn level, with the time spent processing images
+// and talking to storage when the request did either
+func SlowRequestLog(threshold time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		ctx, timings := logger.WithTimings(c.Request.Context())
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		elapsed := time.Since(start)
+		if elapsed <= threshold {
+			return
+		}
+
+		fields := []zap.Field{
+			zap.String("method", c.Request.Method),
+			zap.String("path", c.Request.URL.Path),
+			zap.Int("status", c.Writer.Status()),
+			zap.String("client_ip", c.ClientIP()),
+			zap.Float64("duration_ms", float64(elapsed)/float64(time.Millisecond)),
+			zap.Duration("threshold", threshold),
+		}
+		fields = append(fields, timings.Fields()...)
+		logger.WarnWithContext(c.Request.Context(), "Slow request", fields...)
+	}
+}
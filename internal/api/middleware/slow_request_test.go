@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"resizr/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSlowRequestLog(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	previous := logger.SetLogger(zap.New(core))
+	defer logger.SetLogger(previous)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(SlowRequestLog(50 * time.Millisecond))
+	router.Use(RequestID())
+	router.GET("/fast", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	router.GET("/slow", func(c *gin.Context) {
+		ctx := c.Request.Context()
+		logger.TrackTiming(ctx, logger.TimingProcessing, time.Now().Add(-30*time.Millisecond))
+		logger.TrackTiming(ctx, logger.TimingStorage, time.Now().Add(-10*time.Millisecond))
+		time.Sleep(60 * time.Millisecond)
+		c.Status(http.StatusOK)
+	})
+
+	t.Run("fast request is not logged", func(t *testing.T) {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/fast", nil))
+
+		assert.Zero(t, logs.Len())
+	})
+
+	t.Run("slow request is logged with its timing breakdown", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/slow", nil)
+		req.Header.Set(RequestIDHeader, "slow-request-id")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		entries := logs.FilterMessage("Slow request").All()
+		require.Len(t, entries, 1)
+		assert.Equal(t, zapcore.WarnLevel, entries[0].Level)
+
+		fields := entries[0].ContextMap()
+		assert.Equal(t, "/slow", fields["path"])
+		assert.Equal(t, int64(http.StatusOK), fields["status"])
+		assert.Equal(t, "slow-request-id", fields["request_id"])
+		assert.GreaterOrEqual(t, fields["duration_ms"], 60.0)
+		assert.GreaterOrEqual(t, fields["processing_ms"], 30.0)
+		assert.GreaterOrEqual(t, fields["storage_ms"], 10.0)
+	})
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"resizr/internal/models"
+	"resizr/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// timeoutResponseGrace is how long past the route deadline the connection stays
+// writable, so the timeout response itself can still be sent
+const timeoutResponseGrace = 5 * time.Second
+
+// RouteTimeout bounds a route by timeout instead of the server-wide read/write
+// timeouts. The request context is cancelled at the deadline, which aborts image
+// processing in flight, and a handler that has not answered by then gets a 504.
+// The connection deadlines are moved to match, so a route may run longer or
+// shorter than the server default. A zero timeout leaves the route unbounded.
+func RouteTimeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		// Test recorders and some wrapped writers have no connection to extend
+		rc := http.NewResponseController(c.Writer)
+		deadline := time.Now().Add(timeout + timeoutResponseGrace)
+		if err := rc.SetReadDeadline(deadline); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			logger.DebugWithContext(ctx, "Failed to extend read deadline", zap.Error(err))
+		}
+		if err := rc.SetWriteDeadline(deadline); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			logger.DebugWithContext(ctx, "Failed to extend write deadline", zap.Error(err))
+		}
+
+		c.Next()
+
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) || c.Writer.Written() {
+			return
+		}
+
+		logger.WarnWithContext(ctx, "Request exceeded its route timeout",
+			zap.String("method", c.Request.Method),
+			zap.String("path", c.FullPath()),
+			zap.Duration("timeout", timeout),
+			zap.String("request_id", c.GetString("request_id")))
+		respondTimeout(c, timeout)
+	}
+}
+
+// respondTimeout answers 504 for a request whose route deadline has passed
+func respondTimeout(c *gin.Context, timeout time.Duration) {
+	c.AbortWithStatusJSON(http.StatusGatewayTimeout, models.ErrorResponse{
+		Error:     "Request timed out",
+		Message:   "The request did not complete within " + timeout.String(),
+		Code:      http.StatusGatewayTimeout,
+		ErrorCode: models.ErrorCodeRequestTimeout,
+	})
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"resizr/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouteTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	cancelled := make(chan error, 1)
+	router.POST("/process", RouteTimeout(20*time.Millisecond), func(c *gin.Context) {
+		// Stands in for processing that honours the request context
+		<-c.Request.Context().Done()
+		cancelled <- c.Request.Context().Err()
+	})
+	router.POST("/fast", RouteTimeout(time.Second), func(c *gin.Context) {
+		_, hasDeadline := c.Request.Context().Deadline()
+		c.JSON(http.StatusOK, gin.H{"deadline": hasDeadline})
+	})
+	router.POST("/answered", RouteTimeout(20*time.Millisecond), func(c *gin.Context) {
+		<-c.Request.Context().Done()
+		c.JSON(http.StatusAccepted, gin.H{"partial": true})
+	})
+	router.POST("/unbounded", RouteTimeout(0), func(c *gin.Context) {
+		_, hasDeadline := c.Request.Context().Deadline()
+		c.JSON(http.StatusOK, gin.H{"deadline": hasDeadline})
+	})
+
+	t.Run("over-deadline request is cancelled and gets 504", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		start := time.Now()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/process", nil))
+
+		assert.Less(t, time.Since(start), time.Second)
+		assert.ErrorIs(t, <-cancelled, context.DeadlineExceeded)
+		assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+
+		var response models.ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, models.ErrorCodeRequestTimeout, response.ErrorCode)
+		assert.Equal(t, http.StatusGatewayTimeout, response.Code)
+	})
+
+	t.Run("request within the deadline is untouched", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/fast", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"deadline":true}`, w.Body.String())
+	})
+
+	t.Run("response written by the handler is kept", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/answered", nil))
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+	})
+
+	t.Run("zero timeout sets no deadline", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/unbounded", nil))
+
+		assert.JSONEq(t, `{"deadline":false}`, w.Body.String())
+	})
+}
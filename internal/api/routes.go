@@ -1,11 +1,13 @@
 package api
 
 import (
+	"resizr"
 	"resizr/internal/api/handlers"
 	"resizr/internal/api/middleware"
 	"resizr/internal/config"
 	"resizr/internal/models"
 	"resizr/internal/service"
+	"resizr/pkg/version"
 
 	"github.com/gin-gonic/gin"
 )
@@ -18,10 +20,16 @@ type Router struct {
 	healthHandler     *handlers.HealthHandler
 	authHandler       *handlers.AuthHandler
 	statisticsHandler *handlers.StatisticsHandler
+	adminHandler      *handlers.AdminHandler
+	auditHandler      *handlers.AuditHandler
+	docsHandler       *handlers.DocsHandler
+	versionHandler    *handlers.VersionHandler
+	maintenance       *middleware.MaintenanceMode
+	auditService      service.AuditService
 }
 
 // NewRouter creates a new HTTP router with all routes configured
-func NewRouter(cfg *config.Config, imageService service.ImageService, healthService service.HealthService, statisticsService models.StatisticsService) *Router {
+func NewRouter(cfg *config.Config, imageService service.ImageService, healthService service.HealthService, statisticsService models.StatisticsService, auditService service.AuditService) *Router {
 	// Set Gin mode based on config
 	if cfg.IsDevelopment() {
 		gin.SetMode(gin.DebugMode)
@@ -30,12 +38,20 @@ func NewRouter(cfg *config.Config, imageService service.ImageService, healthServ
 	}
 
 	engine := gin.New()
+	// Forwarded headers are resolved by middleware.RealIP against TRUSTED_PROXIES;
+	// gin itself must not trust them from arbitrary peers
+	_ = engine.SetTrustedProxies(nil)
 
 	// Create handlers
 	imageHandler := handlers.NewImageHandler(imageService, cfg)
 	healthHandler := handlers.NewHealthHandler(healthService)
 	authHandler := handlers.NewAuthHandler(cfg)
 	statisticsHandler := handlers.NewStatisticsHandler(statisticsService)
+	maintenance := middleware.NewMaintenanceMode(cfg.Server.MaintenanceMode)
+	adminHandler := handlers.NewAdminHandler(imageService, maintenance)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	docsHandler := handlers.NewDocsHandler(resizr.OpenAPISpec, resizr.SwaggerUIAssets)
+	versionHandler := handlers.NewVersionHandler(version.Get())
 
 	router := &Router{
 		engine:            engine,
@@ -44,6 +60,12 @@ func NewRouter(cfg *config.Config, imageService service.ImageService, healthServ
 		healthHandler:     healthHandler,
 		authHandler:       authHandler,
 		statisticsHandler: statisticsHandler,
+		adminHandler:      adminHandler,
+		auditHandler:      auditHandler,
+		docsHandler:       docsHandler,
+		versionHandler:    versionHandler,
+		maintenance:       maintenance,
+		auditService:      auditService,
 	}
 
 	// Setup middleware and routes
@@ -55,9 +77,16 @@ func NewRouter(cfg *config.Config, imageService service.ImageService, healthServ
 
 // setupMiddleware configures all middleware
 func (r *Router) setupMiddleware() {
-	// Basic middleware
-	r.engine.Use(gin.Logger())
-	r.engine.Use(gin.Recovery())
+	// Basic middleware: log every request, or only slow ones when a threshold is set
+	if r.config.Server.SlowRequestThreshold > 0 {
+		r.engine.Use(middleware.SlowRequestLog(r.config.Server.SlowRequestThreshold))
+	} else {
+		r.engine.Use(gin.Logger())
+	}
+	r.engine.Use(middleware.Recovery())
+
+	// Resolve the real client IP before anything logs or rate limits by it
+	r.engine.Use(middleware.RealIP(r.config))
 
 	// Request ID middleware for tracing
 	r.engine.Use(middleware.RequestID())
@@ -68,18 +97,37 @@ func (r *Router) setupMiddleware() {
 	// Rate limiting middleware
 	r.engine.Use(middleware.RateLimit(r.config))
 
-	// Request size limit middleware
-	r.engine.Use(middleware.RequestSizeLimit(r.config.Image.MaxFileSize))
+	// Request body size limit middleware (leaves room for multipart overhead above MaxFileSize);
+	// base64 uploads carry the image a third larger, so their route gets its own limit
+	r.engine.Use(middleware.RequestSizeLimitByRoute(r.config.Server.MaxRequestBodySize, map[string]int64{
+		"/api/v1/images/base64": r.config.Base64UploadBodySize(),
+	}))
+
+	// Compress JSON responses for clients that accept it (image bodies pass through)
+	if r.config.Server.CompressionEnabled {
+		r.engine.Use(middleware.Compression(r.config.Server.CompressionMinSize))
+	}
 }
 
 // setupRoutes configures all API routes
 func (r *Router) setupRoutes() {
 	// Health check endpoint (no prefix, no auth)
 	r.engine.GET("/health", r.healthHandler.Health)
+	r.engine.GET("/readyz", r.healthHandler.Readiness)
+
+	// API documentation (no auth)
+	if r.config.Server.DocsEnabled {
+		r.engine.GET("/openapi.yaml", r.docsHandler.OpenAPISpec)
+		r.engine.GET("/docs", r.docsHandler.SwaggerUI)
+		r.engine.GET("/docs/assets/:file", r.docsHandler.SwaggerUIAsset)
+	}
 
 	// API v1 routes
 	v1 := r.engine.Group("/api/v1")
 	{
+		// Build information for deploy verification (no auth)
+		v1.GET("/version", r.versionHandler.Version)
+
 		// Authentication endpoints (no auth required)
 		auth := v1.Group("/auth")
 		{
@@ -91,23 +139,59 @@ func (r *Router) setupRoutes() {
 		images := v1.Group("/images")
 		images.Use(middleware.APIKeyAuth(r.config))
 		{
+			// Writes are rejected with 503 while maintenance mode is on
+			writable := middleware.RejectDuringMaintenance(r.maintenance)
+
+			// Processing may need longer than the server timeouts, downloads should fail fast
+			processing := middleware.RouteTimeout(r.config.Server.ProcessingRequestTimeout)
+			download := middleware.RouteTimeout(r.config.Server.DownloadRequestTimeout)
+
 			// Write operations (require read-write permission)
-			images.POST("", middleware.RequirePermission(middleware.PermissionReadWrite), r.imageHandler.Upload)
+			images.POST("", middleware.RequirePermission(middleware.PermissionReadWrite), writable, r.audit("upload"), processing, r.imageHandler.Upload)
+			images.POST("/base64", middleware.RequirePermission(middleware.PermissionReadWrite), writable, r.audit("upload_base64"), processing, r.imageHandler.UploadBase64)
+			images.POST("/:id/resolutions", middleware.RequirePermission(middleware.PermissionReadWrite), writable, r.audit("add_resolution"), processing, r.imageHandler.AddResolution)
+			images.POST("/:id/crop", middleware.RequirePermission(middleware.PermissionReadWrite), writable, r.audit("crop"), processing, r.imageHandler.Crop)
 
 			// Read operations (require read permission - both read-only and read-write keys work)
 			images.GET("/:id/info", middleware.RequirePermission(middleware.PermissionRead), r.imageHandler.Info)
-			images.GET("/:id/original", middleware.RequirePermission(middleware.PermissionRead), r.imageHandler.DownloadOriginal)
-			images.GET("/:id/thumbnail", middleware.RequirePermission(middleware.PermissionRead), r.imageHandler.DownloadThumbnail)
-			images.GET("/:id/:resolution", middleware.RequirePermission(middleware.PermissionRead), r.imageHandler.DownloadCustomResolution)
+			images.GET("/:id/original", middleware.RequirePermission(middleware.PermissionRead), download, r.imageHandler.DownloadOriginal)
+			images.GET("/:id/thumbnail", middleware.RequirePermission(middleware.PermissionRead), download, r.imageHandler.DownloadThumbnail)
+			images.GET("/:id/archive", middleware.RequirePermission(middleware.PermissionRead), download, r.imageHandler.Archive)
+			// thumbnail.{ext} stores its conversion, so it only serves stored ones during maintenance
+			images.GET("/:id/:resolution", middleware.RequirePermission(middleware.PermissionRead), middleware.MarkMaintenance(r.maintenance), download, r.imageHandler.DownloadCustomResolution)
+			images.GET("/:id/:resolution/frame/:n", middleware.RequirePermission(middleware.PermissionRead), download, r.imageHandler.DownloadFrame)
+
+			// Storage key mapping reveals the bucket layout (admin permission)
+			images.GET("/:id/keys", middleware.RequirePermission(middleware.PermissionAdmin), r.imageHandler.StorageKeys)
+
+			// Integrity check downloads the original when hashing (admin permission)
+			images.GET("/:id/verify", middleware.RequirePermission(middleware.PermissionAdmin), r.imageHandler.Verify)
+
+			// Cache purge after storage was changed by hand (admin permission)
+			images.POST("/:id/cache/purge", middleware.RequirePermission(middleware.PermissionAdmin), r.audit("purge_cache"), r.imageHandler.PurgeCache)
+
+			// Lookup by original filename (read permission, only when the index is maintained)
+			if r.config.Image.FilenameIndexEnabled {
+				images.GET("/by-filename/:name", middleware.RequirePermission(middleware.PermissionRead), r.imageHandler.FindByFilename)
+			}
+
+			// Content lookup by hash so sync clients can skip uploads (read permission)
+			images.POST("/exists", middleware.RequirePermission(middleware.PermissionRead), r.imageHandler.Exists)
+
+			// Dry-run resize estimation (read permission, nothing is generated or stored)
+			images.POST("/:id/estimate", middleware.RequirePermission(middleware.PermissionRead), r.imageHandler.Estimate)
 
 			// Presigned URL generation (require read permission)
 			images.GET("/:id/original/presigned-url", middleware.RequirePermission(middleware.PermissionRead), r.imageHandler.GeneratePresignedURL)
 			images.GET("/:id/thumbnail/presigned-url", middleware.RequirePermission(middleware.PermissionRead), r.imageHandler.GeneratePresignedURL)
 			images.GET("/:id/:resolution/presigned-url", middleware.RequirePermission(middleware.PermissionRead), r.imageHandler.GeneratePresignedURL)
 
+			// Metadata updates (require read-write permission)
+			images.PATCH("/:id", middleware.RequirePermission(middleware.PermissionReadWrite), writable, r.audit("rename"), r.imageHandler.Update)
+
 			// Delete operations (require read-write permission)
-			images.DELETE("/:id", middleware.RequirePermission(middleware.PermissionReadWrite), r.imageHandler.Delete)
-			images.DELETE("/:id/:resolution", middleware.RequirePermission(middleware.PermissionReadWrite), r.imageHandler.DeleteResolution)
+			images.DELETE("/:id", middleware.RequirePermission(middleware.PermissionReadWrite), writable, r.audit("delete"), r.imageHandler.Delete)
+			images.DELETE("/:id/:resolution", middleware.RequirePermission(middleware.PermissionReadWrite), writable, r.audit("delete_resolution"), r.imageHandler.DeleteResolution)
 		}
 
 		// Statistics endpoints (require read permission)
@@ -118,8 +202,32 @@ func (r *Router) setupRoutes() {
 			statistics.GET("/images", middleware.RequirePermission(middleware.PermissionRead), r.statisticsHandler.GetImageStatistics)
 			statistics.GET("/storage", middleware.RequirePermission(middleware.PermissionRead), r.statisticsHandler.GetStorageStatistics)
 			statistics.GET("/deduplication", middleware.RequirePermission(middleware.PermissionRead), r.statisticsHandler.GetDeduplicationStatistics)
+			statistics.GET("/uploads", middleware.RequirePermission(middleware.PermissionRead), r.statisticsHandler.GetUploadHistogram)
+			statistics.GET("/popular", middleware.RequirePermission(middleware.PermissionRead), r.statisticsHandler.GetPopularImages)
+			statistics.GET("/hash/:hash", middleware.RequirePermission(middleware.PermissionRead), r.statisticsHandler.GetHashInfo)
 			statistics.POST("/refresh", middleware.RequirePermission(middleware.PermissionReadWrite), r.statisticsHandler.RefreshStatistics)
 		}
+
+		// Admin endpoints (require admin permission)
+		admin := v1.Group("/admin")
+		admin.Use(middleware.APIKeyAuth(r.config))
+		{
+			admin.POST("/resolutions", middleware.RequirePermission(middleware.PermissionAdmin), middleware.RejectDuringMaintenance(r.maintenance), r.audit("add_resolution_to_all"), r.adminHandler.AddResolutionToAll)
+			admin.GET("/corrupt-metadata", middleware.RequirePermission(middleware.PermissionAdmin), r.adminHandler.GetCorruptMetadata)
+			admin.DELETE("/corrupt-metadata", middleware.RequirePermission(middleware.PermissionAdmin), middleware.RejectDuringMaintenance(r.maintenance), r.audit("remove_corrupt_metadata"), r.adminHandler.RemoveCorruptMetadata)
+			admin.GET("/dedup/orphans", middleware.RequirePermission(middleware.PermissionAdmin), r.adminHandler.GetOrphanedHashes)
+			admin.DELETE("/dedup/orphans", middleware.RequirePermission(middleware.PermissionAdmin), middleware.RejectDuringMaintenance(r.maintenance), r.audit("remove_orphaned_hashes"), r.adminHandler.RemoveOrphanedHashes)
+			admin.GET("/export", middleware.RequirePermission(middleware.PermissionAdmin), r.audit("export_metadata"), r.adminHandler.ExportMetadata)
+			admin.POST("/import", middleware.RequirePermission(middleware.PermissionAdmin), middleware.RejectDuringMaintenance(r.maintenance), r.audit("import_metadata"), r.adminHandler.ImportMetadata)
+			admin.POST("/cache-stats/reset", middleware.RequirePermission(middleware.PermissionAdmin), r.audit("reset_cache_stats"), r.healthHandler.ResetCacheStats)
+			admin.GET("/maintenance", middleware.RequirePermission(middleware.PermissionAdmin), r.adminHandler.GetMaintenance)
+			admin.PUT("/maintenance", middleware.RequirePermission(middleware.PermissionAdmin), r.audit("set_maintenance"), r.adminHandler.SetMaintenance)
+
+			// Stored audit trail (only when audit events are persisted)
+			if r.config.Audit.Enabled && r.config.Audit.Persist {
+				admin.GET("/audit", middleware.RequirePermission(middleware.PermissionAdmin), r.auditHandler.List)
+			}
+		}
 	}
 
 	// Optional: Metrics endpoint for monitoring
@@ -128,6 +236,14 @@ func (r *Router) setupRoutes() {
 	}
 }
 
+// audit records operation in the audit trail when AUDIT_LOG_ENABLED is set
+func (r *Router) audit(operation string) gin.HandlerFunc {
+	if !r.config.Audit.Enabled {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return middleware.Audit(r.auditService, operation)
+}
+
 // GetEngine returns the Gin engine
 func (r *Router) GetEngine() *gin.Engine {
 	return r.engine
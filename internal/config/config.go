@@ -2,14 +2,47 @@ package config
 
 import (
 	"fmt"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/icza/gox/imagex/colorx"
 	"github.com/joho/godotenv"
 )
 
+// multipartOverhead is the extra room allowed on top of MaxFileSize for
+// multipart boundaries, headers and form fields
+const multipartOverhead = 1 << 20 // 1MB
+
+// base64JSONOverhead is the extra room allowed on top of a base64-encoded image
+// for the JSON fields around it (filename, resolutions, data URL prefix)
+const base64JSONOverhead = 1 << 20 // 1MB
+
+// MaxSourcePixels is the decompression-bomb budget: the largest source image,
+// in pixels, that may be decoded into memory
+const MaxSourcePixels = 8192 * 8192
+
+// Redis topologies selected by REDIS_MODE
+const (
+	RedisModeSingle   = "single"
+	RedisModeCluster  = "cluster"
+	RedisModeSentinel = "sentinel"
+)
+
+// MissingImagePlaceholderPixel selects the built-in 1x1 transparent PNG as the
+// MISSING_IMAGE_PLACEHOLDER
+const MissingImagePlaceholderPixel = "pixel"
+
+// decodableFormats lists the input MIME types the image processor can decode
+var decodableFormats = []string{"image/jpeg", "image/png", "image/gif", "image/webp", "image/tiff"}
+
+// encodableFormats lists the output formats the image processor can write
+var encodableFormats = []string{"jpeg", "png", "gif", "webp"}
+
 // Config holds all application configuration
 type Config struct {
 	Server     ServerConfig
@@ -21,15 +54,29 @@ type Config struct {
 	Logger     LoggerConfig
 	CORS       CORSConfig
 	Canvas     CanvasConfig
+	Watermark  WatermarkConfig
+	Scanner    ScannerConfig
+	Queue      QueueConfig
 	Health     HealthConfig
 	Auth       AuthConfig
 	Statistics StatisticsConfig
+	Audit      AuditConfig
 }
 
 // ServerConfig holds HTTP server configuration
 type ServerConfig struct {
-	Port    string
-	GinMode string
+	Port                 string
+	GinMode              string
+	MaxRequestBodySize   int64         // Maximum request body size in bytes (must allow multipart overhead above MaxFileSize)
+	DocsEnabled          bool          // Serve the OpenAPI spec and Swagger UI
+	TrustedProxies       []string      // IPs/CIDRs whose X-Forwarded-For / X-Real-IP headers are honored
+	CompressionEnabled   bool          // Gzip/deflate JSON responses for clients that accept it
+	CompressionMinSize   int           // JSON bodies smaller than this many bytes are sent uncompressed
+	MaintenanceMode      bool          // Start with write endpoints rejected; can be toggled at runtime
+	SlowRequestThreshold time.Duration // When set, only requests slower than this are logged (0 logs every request)
+
+	ProcessingRequestTimeout time.Duration // Deadline of upload and processing routes (0 disables)
+	DownloadRequestTimeout   time.Duration // Deadline of image download routes (0 disables)
 }
 
 // RedisConfig holds Redis database configuration
@@ -39,17 +86,37 @@ type RedisConfig struct {
 	DB       int
 	PoolSize int
 	Timeout  time.Duration
+
+	Mode          string   // Topology: single (REDIS_URL), cluster or sentinel
+	ClusterAddrs  []string // Seed node addresses in cluster mode
+	MasterName    string   // Name of the Sentinel-monitored master in sentinel mode
+	SentinelAddrs []string // Sentinel addresses in sentinel mode
+
+	RetryAttempts int           // Extra attempts for metadata operations failing with transient errors (0 disables retries)
+	RetryBackoff  time.Duration // Delay before the first retry, doubled on each further attempt
 }
 
 // S3Config holds S3 storage configuration
 type S3Config struct {
-	Endpoint  string
-	AccessKey string
-	SecretKey string
-	Bucket    string
-	Region    string
-	UseSSL    bool
-	URLExpire time.Duration
+	Endpoint                 string
+	AccessKey                string
+	SecretKey                string
+	Bucket                   string
+	ReadBucket               string // Bucket downloads and presigned URLs are served from (defaults to Bucket)
+	Region                   string
+	UseSSL                   bool
+	URLExpire                time.Duration
+	URLCacheTTL              time.Duration            // How long a signed URL is handed out again to repeat requests (0 disables)
+	URLCacheTTLByResolution  map[string]time.Duration // URLCacheTTL overrides for original, presets and WIDTHxHEIGHT resolutions
+	BatchDeleteConcurrency   int
+	HealthCheckPrefix        string   // Key prefix of write-probe health objects, kept out of bucket listings
+	HealthWriteProbeDisabled bool     // Skip the put/delete write probe and only check listing
+	RequesterPays            bool     // Send x-amz-request-payer: requester on every request
+	RequestHeaders           []string // Extra "Name: Value" headers sent on every request
+	KeyHashPrefix            bool     // Store image keys as images/{hash}/{id}/... to spread S3 partitions
+	CDNBaseURL               string   // Public base URL object keys are served from instead of the S3 endpoint
+	CDNPresignedURLs         bool     // Rewrite presigned URLs to CDNBaseURL (the CDN must pass the signature through)
+	ForcePathStyle           bool     // Address buckets as endpoint/bucket/key instead of bucket.endpoint/key
 }
 
 // ImageConfig holds image processing configuration
@@ -58,17 +125,57 @@ type ImageConfig struct {
 	Quality                    int
 	CacheTTL                   time.Duration
 	GenerateDefaultResolutions bool
+	DefaultResolutionsByType   map[string][]string // Default resolutions generated per uploaded MIME type; unlisted types fall back to GenerateDefaultResolutions
+	DeduplicationEnabled       bool                // Share storage between byte-identical uploads
+	DedupNamespaceSource       string              // Tenant scope for deduplication: none, api_key, header
+	DedupNamespaceHeader       string              // Header carrying the tenant when DedupNamespaceSource is "header"
+	DedupOrphanCleanupInterval time.Duration       // How often orphaned deduplication records are swept (0 disables)
+	DedupFailureMode           string              // What uploads and deletes do when deduplication records cannot be read: skip, fail
+	MaxTotalImages             int64               // Stored images beyond which uploads needing new storage are rejected (0 = no limit)
+	FormatVariants             []string            // Extra formats every generated resolution is also stored in, in preference order
+	OutputFormat               string              // Format of generated resolutions: source (derivative of the upload's) or auto (smallest candidate)
+	CanonicalOriginalFormat    string              // Format originals are converted to before storage: jpeg, png, webp (empty stores them as uploaded)
+	AutoFormatCandidates       []string            // Formats tried by OutputFormat auto, in preference order on ties
+	AutoFormatMaxPixels        int                 // Largest resolution (width x height) auto tries candidates for (0 = no limit)
 	ResizeMode                 string
+	NoUpscale                  bool    // Serve the original for resolutions larger than the source instead of upscaling it
+	ConvertToSRGB              bool    // Convert sources with an embedded non-sRGB ICC profile to sRGB before resizing
+	ResampleFilter             string  // Interpolation filter: lanczos, bilinear, nearest, catmullrom
+	MultiStepDownscaleRatio    float64 // Downscale factor above which sources are halved in steps before the final resize (0 disables)
+	PreserveGrayscale          bool    // Encode opaque outputs without meaningful color as single-channel grayscale
+	JPEGSubsampling            string  // Chroma subsampling for JPEG output: 444, 422, 420
+	UploadPartialFailureMode   string  // What an upload does when a resolution fails: continue, fail
+	UploadFieldName            string  // Multipart form field the upload handler reads the file from
 	SupportedFormats           []string
 	DefaultResolutions         map[string]ResolutionConfig
-	MaxWidth                   int
-	MaxHeight                  int
+	ResolutionQuality          map[string]int // Quality overrides for WIDTHxHEIGHT resolutions; presets carry their own
+	MaxWidth                   int            // Maximum width of requested/generated resolutions
+	MaxHeight                  int            // Maximum height of requested/generated resolutions
+	MaxResolutionPixels        int            // Maximum width x height of requested/generated resolutions (0 = no limit)
+	MaxSourceWidth             int            // Maximum width of uploaded originals
+	MaxSourceHeight            int            // Maximum height of uploaded originals
+	MinSourceWidth             int            // Minimum width of uploaded originals (0 disables)
+	MinSourceHeight            int            // Minimum height of uploaded originals (0 disables)
+	AllowedAspectRatios        []string       // Accepted W:H aspect ratios of uploaded originals (empty allows any)
+	AspectRatioTolerance       float64        // Relative deviation from an allowed aspect ratio still accepted
+	ProcessingTimeout          time.Duration  // Upper bound for generating a single resolution (0 disables)
+	FilenameIndexEnabled       bool           // Maintain a filename -> image IDs index for lookups by original filename
+	BufferPoolMaxSize          int            // Largest encode buffer kept for reuse between processing calls (0 disables pooling)
+	RejectExtensionMismatch    bool           // Reject uploads whose filename extension disagrees with the sniffed content type
+	DefaultContentType         string         // Type assumed for decodable uploads whose format cannot be detected (empty rejects them)
+	DownloadStrictContentType  bool           // Sniff stored files before streaming and refuse types outside DownloadAllowedTypes
+	DownloadAllowedTypes       []string       // Content types downloads may serve when DownloadStrictContentType is set
+	MissingImagePlaceholder    string         // Served with a 200 for missing images and resolutions: pixel or an image file path (empty answers 404)
+	ListDefaultLimit           int            // Page size of image listings that omit the limit (0 = 50)
+	ListMaxLimit               int            // Largest page size of image listings; larger limits get the default (0 = 100)
+	ListDefaultSort            string         // Order of image listings that omit the sort: last_accessed_at, -last_accessed_at (empty keeps the repository order)
 }
 
 // ResolutionConfig defines image resolution parameters
 type ResolutionConfig struct {
-	Width  int `json:"width"`
-	Height int `json:"height"`
+	Width   int `json:"width"`
+	Height  int `json:"height"`
+	Quality int `json:"quality,omitempty"` // Overrides IMAGE_QUALITY when set
 }
 
 // RateLimitConfig holds rate limiting configuration
@@ -92,6 +199,9 @@ type CacheConfig struct {
 	Type      string        // Cache type: "redis" or "badger"
 	Directory string        // Directory for BadgerDB files (only used when type=badger)
 	TTL       time.Duration // Default TTL for cache entries
+
+	MetadataSize int           // Image metadata records kept in process (0 disables the metadata cache)
+	MetadataTTL  time.Duration // How long an in-process metadata record is served before re-reading it
 }
 
 // CORSConfig holds CORS configuration
@@ -100,6 +210,10 @@ type CORSConfig struct {
 	AllowAllOrigins  bool     // Allow all origins (*)
 	AllowedOrigins   []string // List of allowed origins
 	AllowCredentials bool     // Allow credentials in CORS requests
+
+	// WriteAllowedOrigins, when set, replaces the policy above for write and admin
+	// requests so downloads can stay open while mutations are restricted
+	WriteAllowedOrigins []string
 }
 
 // CanvasConfig holds canvas configuration
@@ -107,11 +221,37 @@ type CanvasConfig struct {
 	BackgroundColor string
 }
 
+// WatermarkConfig holds the text watermark drawn onto generated resolutions
+type WatermarkConfig struct {
+	Text     string  // Text drawn onto every generated resolution (empty disables)
+	FontSize float64 // Text height in pixels
+	Color    string  // HEX color of the text
+	Opacity  float64 // 0 (invisible) to 1 (opaque)
+	Position string  // center, top-left, top-right, bottom-left, bottom-right
+	FontPath string  // TrueType/OpenType font file; the embedded Go font is used when empty or unreadable
+}
+
+// ScannerConfig holds the malware scanner uploads are checked with before storage
+type ScannerConfig struct {
+	URL     string        // HTTP endpoint the upload is POSTed to (empty disables scanning)
+	Timeout time.Duration // Upper bound for a single scan request
+}
+
+// QueueConfig holds the worker queue upload resolutions are generated on
+type QueueConfig struct {
+	Enabled  bool // Generate upload resolutions on background workers instead of in the request
+	Workers  int  // Number of workers draining the queue
+	MaxDepth int  // Queued uploads beyond which new uploads are rejected with 503
+}
+
 // HealthConfig holds health check configuration
 type HealthConfig struct {
 	S3ChecksDisabled bool          // Disable S3 health checks to reduce API calls
 	S3ChecksInterval time.Duration // Interval for caching S3 health check results
 	CheckInterval    time.Duration // Docker health check interval (minimum 10s)
+
+	ReadinessInterval         time.Duration // Interval between background dependency checks backing /readyz
+	ReadinessFailureThreshold int           // Consecutive failed checks before /readyz reports not ready
 }
 
 // AuthConfig holds authentication configuration
@@ -119,13 +259,25 @@ type AuthConfig struct {
 	Enabled       bool     // Enable/disable authentication
 	ReadWriteKeys []string // API keys with read-write permissions
 	ReadOnlyKeys  []string // API keys with read-only permissions
+	AdminKeys     []string // API keys with admin permissions (includes read-write)
 	KeyHeader     string   // HTTP header name for API key
 }
 
+// AuditConfig holds the audit trail of write and admin operations
+type AuditConfig struct {
+	Enabled   bool          // Log write and admin operations as audit events
+	Persist   bool          // Also store the events in the repository for GET /api/v1/admin/audit
+	Retention time.Duration // How long stored events are kept (0 keeps them forever)
+}
+
 // StatisticsConfig holds statistics caching configuration
 type StatisticsConfig struct {
-	CacheEnabled bool          // Enable/disable statistics caching
-	CacheTTL     time.Duration // TTL for cached statistics
+	CacheEnabled    bool          // Enable/disable statistics caching
+	CacheTTL        time.Duration // TTL for cached statistics
+	RefreshInterval time.Duration // Interval for background cache refresh (0 disables)
+
+	AccessFlushInterval  time.Duration // Interval for persisting buffered download counts (0 writes every download through)
+	LastAccessedThrottle time.Duration // Minimum time between LastAccessedAt writes of one image (0 writes every access)
 }
 
 // Load loads configuration from environment variables
@@ -133,10 +285,21 @@ func Load() (*Config, error) {
 	// Load .env file if it exists (for development)
 	_ = godotenv.Load()
 
+	maxFileSize := int64(getEnvInt("MAX_FILE_SIZE", 10485760)) // 10MB default
+
 	config := &Config{
 		Server: ServerConfig{
-			Port:    getEnv("PORT", "8080"),
-			GinMode: getEnv("GIN_MODE", "release"),
+			Port:                 getEnv("PORT", "8080"),
+			GinMode:              getEnv("GIN_MODE", "release"),
+			MaxRequestBodySize:   int64(getEnvInt("MAX_REQUEST_BODY_SIZE", int(maxFileSize+multipartOverhead))),
+			TrustedProxies:       getEnvStringSlice("TRUSTED_PROXIES", []string{}),
+			CompressionEnabled:   getEnvBool("RESPONSE_COMPRESSION_ENABLED", true),
+			CompressionMinSize:   getEnvInt("RESPONSE_COMPRESSION_MIN_SIZE", 1024),
+			MaintenanceMode:      getEnvBool("MAINTENANCE_MODE", false),
+			SlowRequestThreshold: getEnvDuration("SLOW_REQUEST_THRESHOLD", 0),
+
+			ProcessingRequestTimeout: getEnvDuration("PROCESSING_REQUEST_TIMEOUT", 2*time.Minute),
+			DownloadRequestTimeout:   getEnvDuration("DOWNLOAD_REQUEST_TIMEOUT", 30*time.Second),
 		},
 		Redis: RedisConfig{
 			URL:      getEnv("REDIS_URL", "redis://localhost:6379"),
@@ -144,33 +307,101 @@ func Load() (*Config, error) {
 			DB:       getEnvInt("REDIS_DB", 0),
 			PoolSize: getEnvInt("REDIS_POOL_SIZE", 10),
 			Timeout:  time.Duration(getEnvInt("REDIS_TIMEOUT", 5)) * time.Second,
+
+			Mode:          getEnv("REDIS_MODE", RedisModeSingle),
+			ClusterAddrs:  getEnvStringSlice("REDIS_CLUSTER_ADDRS", nil),
+			MasterName:    getEnv("REDIS_MASTER_NAME", ""),
+			SentinelAddrs: getEnvStringSlice("REDIS_SENTINEL_ADDRS", nil),
+
+			RetryAttempts: getEnvInt("REDIS_RETRY_ATTEMPTS", 2),
+			RetryBackoff:  getEnvDuration("REDIS_RETRY_BACKOFF", 100*time.Millisecond),
 		},
 		Cache: CacheConfig{
 			Type:      getEnv("CACHE_TYPE", "redis"),
 			Directory: getEnv("CACHE_DIRECTORY", "./data/cache"),
 			TTL:       time.Duration(getEnvInt("CACHE_TTL", 3600)) * time.Second,
+
+			MetadataSize: getEnvInt("METADATA_CACHE_SIZE", 1000),
+			MetadataTTL:  time.Duration(getEnvInt("METADATA_CACHE_TTL", 5)) * time.Second,
 		},
 		S3: S3Config{
-			Endpoint:  getEnv("S3_ENDPOINT", "https://s3.amazonaws.com"),
-			AccessKey: getEnv("S3_ACCESS_KEY", ""),
-			SecretKey: getEnv("S3_SECRET_KEY", ""),
-			Bucket:    getEnv("S3_BUCKET", ""),
-			Region:    getEnv("S3_REGION", "us-east-1"),
-			UseSSL:    getEnvBool("S3_USE_SSL", true),
-			URLExpire: time.Duration(getEnvInt("S3_URL_EXPIRE", 3600)) * time.Second,
+			Endpoint:                 getEnv("S3_ENDPOINT", "https://s3.amazonaws.com"),
+			AccessKey:                getEnv("S3_ACCESS_KEY", ""),
+			SecretKey:                getEnv("S3_SECRET_KEY", ""),
+			Bucket:                   getEnv("S3_BUCKET", ""),
+			ReadBucket:               getEnv("S3_READ_BUCKET", ""),
+			Region:                   getEnv("S3_REGION", "us-east-1"),
+			UseSSL:                   getEnvBool("S3_USE_SSL", true),
+			URLExpire:                time.Duration(getEnvInt("S3_URL_EXPIRE", 3600)) * time.Second,
+			URLCacheTTL:              time.Duration(getEnvInt("S3_URL_CACHE_TTL", 300)) * time.Second,
+			BatchDeleteConcurrency:   getEnvInt("S3_BATCH_DELETE_CONCURRENCY", 3),
+			HealthCheckPrefix:        getEnv("S3_HEALTH_CHECK_PREFIX", "health-check/"),
+			HealthWriteProbeDisabled: getEnvBool("S3_HEALTH_WRITE_PROBE_DISABLE", false),
+			RequesterPays:            getEnvBool("S3_REQUESTER_PAYS", false),
+			RequestHeaders:           getEnvStringSlice("S3_REQUEST_HEADERS", []string{}),
+			KeyHashPrefix:            getEnvBool("S3_KEY_HASH_PREFIX", false),
+			CDNBaseURL:               strings.TrimRight(getEnv("CDN_BASE_URL", ""), "/"),
+			CDNPresignedURLs:         getEnvBool("CDN_PRESIGNED_PASSTHROUGH", false),
 		},
 		Image: ImageConfig{
-			MaxFileSize:                int64(getEnvInt("MAX_FILE_SIZE", 10485760)), // 10MB default
+			MaxFileSize:                maxFileSize,
 			Quality:                    getEnvInt("IMAGE_QUALITY", 85),
 			CacheTTL:                   time.Duration(getEnvInt("CACHE_TTL", 3600)) * time.Second,
 			GenerateDefaultResolutions: getEnvBool("GENERATE_DEFAULT_RESOLUTIONS", true),
+			DeduplicationEnabled:       getEnvBool("DEDUP_ENABLED", true),
+			DedupNamespaceSource:       strings.ToLower(getEnv("DEDUP_NAMESPACE_SOURCE", "none")),
+			DedupNamespaceHeader:       getEnv("DEDUP_NAMESPACE_HEADER", "X-Tenant-ID"),
+			DedupOrphanCleanupInterval: getEnvDuration("DEDUP_ORPHAN_CLEANUP_INTERVAL", 0),
+			DedupFailureMode:           strings.ToLower(getEnv("DEDUP_FAILURE_MODE", "skip")),
+			MaxTotalImages:             int64(getEnvInt("MAX_TOTAL_IMAGES", 0)),
+			FormatVariants:             getEnvStringSlice("GENERATE_FORMAT_VARIANTS", nil),
+			OutputFormat:               strings.ToLower(getEnv("IMAGE_OUTPUT_FORMAT", "source")),
+			CanonicalOriginalFormat:    strings.ToLower(getEnv("CANONICAL_ORIGINAL_FORMAT", "")),
+			AutoFormatCandidates:       getEnvStringSlice("IMAGE_AUTO_FORMAT_CANDIDATES", []string{"jpeg", "png"}),
+			AutoFormatMaxPixels:        getEnvInt("IMAGE_AUTO_FORMAT_MAX_PIXELS", 4194304),
 			ResizeMode:                 getEnv("RESIZE_MODE", "smart_fit"),
-			SupportedFormats:           []string{"image/jpeg", "image/png", "image/gif", "image/webp"},
+			NoUpscale:                  getEnvBool("IMAGE_NO_UPSCALE", false),
+			ConvertToSRGB:              getEnvBool("IMAGE_CONVERT_TO_SRGB", false),
+			ResampleFilter:             strings.ToLower(getEnv("IMAGE_RESAMPLE_FILTER", "lanczos")),
+			MultiStepDownscaleRatio:    getEnvFloat("IMAGE_MULTISTEP_DOWNSCALE_RATIO", 0),
+			PreserveGrayscale:          getEnvBool("IMAGE_PRESERVE_GRAYSCALE", false),
+			JPEGSubsampling:            getEnv("IMAGE_JPEG_SUBSAMPLING", "420"),
+			UploadPartialFailureMode:   strings.ToLower(getEnv("UPLOAD_PARTIAL_FAILURE_MODE", "continue")),
+			UploadFieldName:            strings.TrimSpace(getEnv("UPLOAD_FIELD_NAME", "image")),
+			SupportedFormats:           getEnvStringSlice("IMAGE_SUPPORTED_FORMATS", append([]string(nil), decodableFormats...)),
 			DefaultResolutions: map[string]ResolutionConfig{
 				"thumbnail": {Width: 150, Height: 150},
 			},
 			MaxWidth:  getEnvInt("IMAGE_MAX_WIDTH", 4096),
 			MaxHeight: getEnvInt("IMAGE_MAX_HEIGHT", 4096),
+
+			MaxResolutionPixels: getEnvInt("IMAGE_MAX_RESOLUTION_PIXELS", 0),
+
+			MaxSourceWidth:  getEnvInt("IMAGE_MAX_SOURCE_WIDTH", 8192),
+			MaxSourceHeight: getEnvInt("IMAGE_MAX_SOURCE_HEIGHT", 8192),
+			MinSourceWidth:  getEnvInt("IMAGE_MIN_WIDTH", 0),
+			MinSourceHeight: getEnvInt("IMAGE_MIN_HEIGHT", 0),
+
+			AllowedAspectRatios:  getEnvStringSlice("IMAGE_ALLOWED_ASPECT_RATIOS", nil),
+			AspectRatioTolerance: getEnvFloat("IMAGE_ASPECT_RATIO_TOLERANCE", 0.01),
+
+			ProcessingTimeout: time.Duration(getEnvInt("IMAGE_PROCESSING_TIMEOUT", 30)) * time.Second,
+
+			FilenameIndexEnabled: getEnvBool("FILENAME_INDEX_ENABLED", false),
+
+			BufferPoolMaxSize: getEnvInt("IMAGE_BUFFER_POOL_MAX_SIZE", 16777216),
+
+			RejectExtensionMismatch: getEnvBool("IMAGE_REJECT_EXTENSION_MISMATCH", false),
+
+			DefaultContentType: strings.ToLower(getEnv("DEFAULT_CONTENT_TYPE", "")),
+
+			DownloadStrictContentType: getEnvBool("DOWNLOAD_STRICT_CONTENT_TYPE", false),
+			DownloadAllowedTypes:      getEnvStringSlice("DOWNLOAD_ALLOWED_TYPES", append([]string(nil), decodableFormats...)),
+			MissingImagePlaceholder:   strings.TrimSpace(getEnv("MISSING_IMAGE_PLACEHOLDER", "")),
+
+			ListDefaultLimit: getEnvInt("LIST_DEFAULT_LIMIT", 50),
+			ListMaxLimit:     getEnvInt("LIST_MAX_LIMIT", 100),
+			ListDefaultSort:  strings.TrimSpace(getEnv("LIST_DEFAULT_SORT", "")),
 		},
 		RateLimit: RateLimitConfig{
 			Upload:   getEnvInt("RATE_LIMIT_UPLOAD", 10),
@@ -186,27 +417,79 @@ func Load() (*Config, error) {
 			AllowAllOrigins:  getEnvBool("CORS_ALLOW_ALL_ORIGINS", false),
 			AllowedOrigins:   getEnvStringSlice("CORS_ALLOWED_ORIGINS", []string{"*"}),
 			AllowCredentials: getEnvBool("CORS_ALLOW_CREDENTIALS", false),
+
+			WriteAllowedOrigins: getEnvStringSlice("CORS_WRITE_ALLOWED_ORIGINS", nil),
 		},
 		Canvas: CanvasConfig{
 			BackgroundColor: getEnv("BACKGROUND_COLOR", "#000000"),
 		},
+		Watermark: WatermarkConfig{
+			Text:     getEnv("WATERMARK_TEXT", ""),
+			FontSize: getEnvFloat("WATERMARK_FONT_SIZE", 24),
+			Color:    getEnv("WATERMARK_COLOR", "#FFFFFF"),
+			Opacity:  getEnvFloat("WATERMARK_OPACITY", 0.5),
+			Position: strings.ToLower(getEnv("WATERMARK_POSITION", "bottom-right")),
+			FontPath: getEnv("WATERMARK_FONT_PATH", ""),
+		},
+		Scanner: ScannerConfig{
+			URL:     getEnv("SCANNER_URL", ""),
+			Timeout: getEnvDuration("SCANNER_TIMEOUT", 30*time.Second),
+		},
+		Queue: QueueConfig{
+			Enabled:  getEnvBool("PROCESSING_QUEUE_ENABLED", false),
+			Workers:  getEnvInt("PROCESSING_QUEUE_WORKERS", 4),
+			MaxDepth: getEnvInt("PROCESSING_QUEUE_MAX_DEPTH", 100),
+		},
 		Health: HealthConfig{
 			S3ChecksDisabled: getEnvBool("S3_HEALTHCHECKS_DISABLE", false),
 			S3ChecksInterval: getS3HealthCheckInterval(),
 			CheckInterval:    getHealthCheckInterval(),
+
+			ReadinessInterval:         time.Duration(getEnvInt("READINESS_CHECK_INTERVAL", 5)) * time.Second,
+			ReadinessFailureThreshold: getEnvInt("READINESS_FAILURE_THRESHOLD", 3),
 		},
 		Auth: AuthConfig{
 			Enabled:       getEnvBool("AUTH_ENABLED", false),
 			ReadWriteKeys: getEnvStringSlice("AUTH_READWRITE_KEYS", []string{}),
 			ReadOnlyKeys:  getEnvStringSlice("AUTH_READONLY_KEYS", []string{}),
+			AdminKeys:     getEnvStringSlice("AUTH_ADMIN_KEYS", []string{}),
 			KeyHeader:     getEnv("AUTH_KEY_HEADER", "X-API-Key"),
 		},
 		Statistics: StatisticsConfig{
-			CacheEnabled: getEnvBool("STATISTICS_CACHE_ENABLED", true),
-			CacheTTL:     time.Duration(getEnvInt("STATISTICS_CACHE_TTL", 300)) * time.Second,
+			CacheEnabled:    getEnvBool("STATISTICS_CACHE_ENABLED", true),
+			CacheTTL:        time.Duration(getEnvInt("STATISTICS_CACHE_TTL", 300)) * time.Second,
+			RefreshInterval: time.Duration(getEnvInt("STATISTICS_REFRESH_INTERVAL", 0)) * time.Second,
+
+			AccessFlushInterval:  time.Duration(getEnvInt("STATISTICS_ACCESS_FLUSH_INTERVAL", 10)) * time.Second,
+			LastAccessedThrottle: time.Duration(getEnvInt("STATISTICS_LAST_ACCESSED_THROTTLE", 300)) * time.Second,
+		},
+		Audit: AuditConfig{
+			Enabled:   getEnvBool("AUDIT_LOG_ENABLED", false),
+			Persist:   getEnvBool("AUDIT_LOG_PERSIST", false),
+			Retention: time.Duration(getEnvInt("AUDIT_LOG_RETENTION_DAYS", 90)) * 24 * time.Hour,
 		},
 	}
 
+	// Reads go to the write bucket unless a replica is configured
+	if config.S3.ReadBucket == "" {
+		config.S3.ReadBucket = config.S3.Bucket
+	}
+
+	// Path-style addressing defaults to on for custom endpoints such as MinIO
+	config.S3.ForcePathStyle = getEnvBool("S3_FORCE_PATH_STYLE", config.S3.Endpoint != "https://s3.amazonaws.com")
+
+	// API docs default to enabled in development only
+	config.Server.DocsEnabled = getEnvBool("DOCS_ENABLED", config.IsDevelopment())
+
+	// Per-resolution presigned URL cache windows in seconds, e.g. "original=60,thumbnail=3600"
+	config.applyURLCacheTTLByResolution(getEnvStringSlice("S3_URL_CACHE_TTL_BY_RESOLUTION", nil))
+
+	// Per-resolution quality overrides, e.g. "thumbnail=70,1920x1080=90"
+	config.applyResolutionQuality(getEnvStringSlice("IMAGE_RESOLUTION_QUALITY", nil))
+
+	// Per-type default resolutions, e.g. "image/jpeg=thumbnail|800x600,image/png="
+	config.applyDefaultResolutionsByType(getEnvStringSlice("IMAGE_DEFAULT_RESOLUTIONS_BY_TYPE", nil))
+
 	// Validate required configuration
 	if err := config.Validate(); err != nil {
 		return nil, fmt.Errorf("configuration validation failed: %w", err)
@@ -227,6 +510,34 @@ func (c *Config) Validate() error {
 	if c.S3.SecretKey == "" {
 		return fmt.Errorf("S3_SECRET_KEY is required")
 	}
+	if c.S3.URLCacheTTL < 0 {
+		return fmt.Errorf("S3_URL_CACHE_TTL must not be negative")
+	}
+	for name, ttl := range c.S3.URLCacheTTLByResolution {
+		if _, preset := c.Image.DefaultResolutions[name]; name != "original" && !preset && !isDimensions(name) {
+			return fmt.Errorf("S3_URL_CACHE_TTL_BY_RESOLUTION key %q must be original, a preset name or WIDTHxHEIGHT", name)
+		}
+		if ttl < 0 {
+			return fmt.Errorf("S3_URL_CACHE_TTL_BY_RESOLUTION for %s must be a non-negative number of seconds", name)
+		}
+	}
+	for _, header := range c.S3.RequestHeaders {
+		if name, _, ok := strings.Cut(header, ":"); !ok || strings.TrimSpace(name) == "" {
+			return fmt.Errorf("S3_REQUEST_HEADERS entries must be Name: Value, got: %s", header)
+		}
+	}
+	if c.S3.CDNBaseURL != "" {
+		base, err := url.Parse(c.S3.CDNBaseURL)
+		if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" || base.RawQuery != "" {
+			return fmt.Errorf("CDN_BASE_URL must be an absolute http(s) URL without a query string")
+		}
+	}
+	if c.S3.CDNPresignedURLs && c.S3.CDNBaseURL == "" {
+		return fmt.Errorf("CDN_PRESIGNED_PASSTHROUGH requires CDN_BASE_URL")
+	}
+	if strings.HasPrefix(c.S3.HealthCheckPrefix, "images/") {
+		return fmt.Errorf("S3_HEALTH_CHECK_PREFIX must not be inside the images/ prefix")
+	}
 
 	// Validate server configuration
 	if c.Server.Port == "" {
@@ -241,8 +552,33 @@ func (c *Config) Validate() error {
 
 	// Validate Redis configuration (only if using Redis cache)
 	if c.Cache.Type == "redis" {
-		if c.Redis.URL == "" {
-			return fmt.Errorf("REDIS_URL is required when CACHE_TYPE=redis")
+		switch c.Redis.Mode {
+		case RedisModeSingle, "":
+			if c.Redis.URL == "" {
+				return fmt.Errorf("REDIS_URL is required when CACHE_TYPE=redis")
+			}
+		case RedisModeCluster:
+			if len(c.Redis.ClusterAddrs) == 0 {
+				return fmt.Errorf("REDIS_CLUSTER_ADDRS is required when REDIS_MODE=cluster")
+			}
+			if c.Redis.DB != 0 {
+				return fmt.Errorf("REDIS_DB must be 0 when REDIS_MODE=cluster")
+			}
+		case RedisModeSentinel:
+			if c.Redis.MasterName == "" {
+				return fmt.Errorf("REDIS_MASTER_NAME is required when REDIS_MODE=sentinel")
+			}
+			if len(c.Redis.SentinelAddrs) == 0 {
+				return fmt.Errorf("REDIS_SENTINEL_ADDRS is required when REDIS_MODE=sentinel")
+			}
+		default:
+			return fmt.Errorf("REDIS_MODE must be one of: %s, %s, %s", RedisModeSingle, RedisModeCluster, RedisModeSentinel)
+		}
+		if c.Redis.RetryAttempts < 0 {
+			return fmt.Errorf("REDIS_RETRY_ATTEMPTS cannot be negative")
+		}
+		if c.Redis.RetryBackoff < 0 {
+			return fmt.Errorf("REDIS_RETRY_BACKOFF cannot be negative")
 		}
 	}
 
@@ -250,6 +586,12 @@ func (c *Config) Validate() error {
 	if c.Cache.Type == "badger" && c.Cache.Directory == "" {
 		return fmt.Errorf("CACHE_DIRECTORY is required when CACHE_TYPE=badger")
 	}
+	if c.Cache.MetadataSize < 0 {
+		return fmt.Errorf("METADATA_CACHE_SIZE must not be negative")
+	}
+	if c.Cache.MetadataTTL < 0 {
+		return fmt.Errorf("METADATA_CACHE_TTL must not be negative")
+	}
 
 	// Validate image configuration
 	if c.Image.MaxFileSize <= 0 {
@@ -258,6 +600,23 @@ func (c *Config) Validate() error {
 	if c.Image.Quality < 1 || c.Image.Quality > 100 {
 		return fmt.Errorf("IMAGE_QUALITY must be between 1 and 100")
 	}
+	// A body limit equal to MAX_FILE_SIZE rejects a maximum-size upload once the
+	// multipart boundaries and headers are added
+	if c.Server.MaxRequestBodySize <= c.Image.MaxFileSize {
+		return fmt.Errorf("MAX_REQUEST_BODY_SIZE must be greater than MAX_FILE_SIZE to leave room for multipart overhead")
+	}
+	if c.Server.CompressionMinSize < 0 {
+		return fmt.Errorf("RESPONSE_COMPRESSION_MIN_SIZE must not be negative")
+	}
+	if c.Server.SlowRequestThreshold < 0 {
+		return fmt.Errorf("SLOW_REQUEST_THRESHOLD must not be negative")
+	}
+	if c.Server.ProcessingRequestTimeout < 0 {
+		return fmt.Errorf("PROCESSING_REQUEST_TIMEOUT must not be negative")
+	}
+	if c.Server.DownloadRequestTimeout < 0 {
+		return fmt.Errorf("DOWNLOAD_REQUEST_TIMEOUT must not be negative")
+	}
 
 	// Validate rate limit configuration
 	if c.RateLimit.Upload <= 0 || c.RateLimit.Download <= 0 || c.RateLimit.Info <= 0 {
@@ -270,6 +629,56 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("RESIZE_MODE must be one of: %s", strings.Join(validResizeModes, ", "))
 	}
 
+	// Validate resampling filter
+	validResampleFilters := []string{"lanczos", "bilinear", "nearest", "catmullrom"}
+	if !contains(validResampleFilters, c.Image.ResampleFilter) {
+		return fmt.Errorf("IMAGE_RESAMPLE_FILTER must be one of: %s", strings.Join(validResampleFilters, ", "))
+	}
+	if c.Image.MultiStepDownscaleRatio != 0 && c.Image.MultiStepDownscaleRatio < 2 {
+		return fmt.Errorf("IMAGE_MULTISTEP_DOWNSCALE_RATIO must be 0 (disabled) or at least 2")
+	}
+
+	// Validate JPEG chroma subsampling (empty keeps the 4:2:0 default)
+	validJPEGSubsampling := []string{"444", "422", "420"}
+	if c.Image.JPEGSubsampling != "" && !contains(validJPEGSubsampling, c.Image.JPEGSubsampling) {
+		return fmt.Errorf("IMAGE_JPEG_SUBSAMPLING must be one of: %s", strings.Join(validJPEGSubsampling, ", "))
+	}
+
+	// Validate partial failure handling (empty keeps the continue default)
+	validPartialFailureModes := []string{"continue", "fail"}
+	if c.Image.UploadPartialFailureMode != "" && !contains(validPartialFailureModes, c.Image.UploadPartialFailureMode) {
+		return fmt.Errorf("UPLOAD_PARTIAL_FAILURE_MODE must be one of: %s", strings.Join(validPartialFailureModes, ", "))
+	}
+
+	// The file field must not shadow the upload's other form fields (empty keeps "image")
+	reservedUploadFields := []string{"resolutions", "dedup", "watermark", "checksum"}
+	if contains(reservedUploadFields, c.Image.UploadFieldName) {
+		return fmt.Errorf("UPLOAD_FIELD_NAME must not be one of the reserved form fields: %s", strings.Join(reservedUploadFields, ", "))
+	}
+
+	if c.Image.ProcessingTimeout < 0 {
+		return fmt.Errorf("IMAGE_PROCESSING_TIMEOUT must not be negative")
+	}
+	if c.Image.DedupOrphanCleanupInterval < 0 {
+		return fmt.Errorf("DEDUP_ORPHAN_CLEANUP_INTERVAL must not be negative")
+	}
+	validDedupFailureModes := []string{"skip", "fail"}
+	if c.Image.DedupFailureMode != "" && !contains(validDedupFailureModes, c.Image.DedupFailureMode) {
+		return fmt.Errorf("DEDUP_FAILURE_MODE must be one of: %s", strings.Join(validDedupFailureModes, ", "))
+	}
+
+	// Validate deduplication namespace source (empty behaves like "none")
+	validNamespaceSources := []string{"none", "api_key", "header"}
+	if c.Image.DedupNamespaceSource != "" && !contains(validNamespaceSources, c.Image.DedupNamespaceSource) {
+		return fmt.Errorf("DEDUP_NAMESPACE_SOURCE must be one of: %s", strings.Join(validNamespaceSources, ", "))
+	}
+	if c.Image.DedupNamespaceSource == "api_key" && !c.Auth.Enabled {
+		return fmt.Errorf("DEDUP_NAMESPACE_SOURCE=api_key requires AUTH_ENABLED=true")
+	}
+	if c.Image.DedupNamespaceSource == "header" && c.Image.DedupNamespaceHeader == "" {
+		return fmt.Errorf("DEDUP_NAMESPACE_HEADER is required when DEDUP_NAMESPACE_SOURCE=header")
+	}
+
 	// Validate logger configuration
 	validLogLevels := []string{"debug", "info", "warn", "error"}
 	if !contains(validLogLevels, c.Logger.Level) {
@@ -281,6 +690,77 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("LOG_FORMAT must be one of: %s", strings.Join(validLogFormats, ", "))
 	}
 
+	// Validate trusted proxies (bare IPs or CIDR ranges)
+	for _, proxy := range c.Server.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			return fmt.Errorf("TRUSTED_PROXIES contains invalid IP or CIDR: %s", proxy)
+		}
+	}
+
+	// Validate readiness checks
+	if c.Health.ReadinessInterval < 0 {
+		return fmt.Errorf("READINESS_CHECK_INTERVAL must not be negative")
+	}
+	if c.Health.ReadinessFailureThreshold < 0 {
+		return fmt.Errorf("READINESS_FAILURE_THRESHOLD must not be negative")
+	}
+
+	// Validate statistics configuration
+	if c.Statistics.RefreshInterval < 0 {
+		return fmt.Errorf("STATISTICS_REFRESH_INTERVAL must not be negative")
+	}
+	if c.Statistics.AccessFlushInterval < 0 {
+		return fmt.Errorf("STATISTICS_ACCESS_FLUSH_INTERVAL must not be negative")
+	}
+	if c.Statistics.LastAccessedThrottle < 0 {
+		return fmt.Errorf("STATISTICS_LAST_ACCESSED_THROTTLE must not be negative")
+	}
+
+	// Validate audit trail configuration
+	if c.Audit.Persist && !c.Audit.Enabled {
+		return fmt.Errorf("AUDIT_LOG_PERSIST requires AUDIT_LOG_ENABLED")
+	}
+	if c.Audit.Retention < 0 {
+		return fmt.Errorf("AUDIT_LOG_RETENTION_DAYS must not be negative")
+	}
+
+	// Validate the text watermark (only when enabled)
+	if c.Watermark.Text != "" {
+		if c.Watermark.FontSize <= 0 {
+			return fmt.Errorf("WATERMARK_FONT_SIZE must be positive")
+		}
+		if c.Watermark.Opacity < 0 || c.Watermark.Opacity > 1 {
+			return fmt.Errorf("WATERMARK_OPACITY must be between 0 and 1")
+		}
+		if _, err := colorx.ParseHexColor(c.Watermark.Color); err != nil {
+			return fmt.Errorf("WATERMARK_COLOR must be a HEX color: %w", err)
+		}
+		validPositions := []string{"center", "top-left", "top-right", "bottom-left", "bottom-right"}
+		if !contains(validPositions, c.Watermark.Position) {
+			return fmt.Errorf("WATERMARK_POSITION must be one of: %s", strings.Join(validPositions, ", "))
+		}
+	}
+
+	// Validate the upload scanner (only when enabled)
+	if c.Scanner.URL != "" {
+		if parsed, err := url.Parse(c.Scanner.URL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("SCANNER_URL must be an absolute http(s) URL")
+		}
+		if c.Scanner.Timeout <= 0 {
+			return fmt.Errorf("SCANNER_TIMEOUT must be positive when SCANNER_URL is set")
+		}
+	}
+
+	// Validate the processing queue (only when enabled)
+	if c.Queue.Enabled {
+		if c.Queue.Workers <= 0 {
+			return fmt.Errorf("PROCESSING_QUEUE_WORKERS must be a positive integer")
+		}
+		if c.Queue.MaxDepth <= 0 {
+			return fmt.Errorf("PROCESSING_QUEUE_MAX_DEPTH must be a positive integer")
+		}
+	}
+
 	// Validate image max dimensions (must be positive)
 	if c.Image.MaxWidth <= 0 {
 		return fmt.Errorf("IMAGE_MAX_WIDTH must be a positive integer")
@@ -288,10 +768,183 @@ func (c *Config) Validate() error {
 	if c.Image.MaxHeight <= 0 {
 		return fmt.Errorf("IMAGE_MAX_HEIGHT must be a positive integer")
 	}
+	if c.Image.MaxResolutionPixels < 0 {
+		return fmt.Errorf("IMAGE_MAX_RESOLUTION_PIXELS must be zero (no limit) or a positive integer")
+	}
+
+	// Validate per-resolution quality overrides
+	for name, resolution := range c.Image.DefaultResolutions {
+		if resolution.Quality != 0 && (resolution.Quality < 1 || resolution.Quality > 100) {
+			return fmt.Errorf("IMAGE_RESOLUTION_QUALITY for %s must be between 1 and 100", name)
+		}
+	}
+	for name, quality := range c.Image.ResolutionQuality {
+		if !isDimensions(name) {
+			return fmt.Errorf("IMAGE_RESOLUTION_QUALITY key %q must be a preset name or WIDTHxHEIGHT", name)
+		}
+		if quality < 1 || quality > 100 {
+			return fmt.Errorf("IMAGE_RESOLUTION_QUALITY for %s must be between 1 and 100", name)
+		}
+	}
+
+	// Validate per-type default resolutions
+	for mimeType, resolutions := range c.Image.DefaultResolutionsByType {
+		if !contains(decodableFormats, mimeType) {
+			return fmt.Errorf("IMAGE_DEFAULT_RESOLUTIONS_BY_TYPE contains unsupported format %q, must be one of: %s", mimeType, strings.Join(decodableFormats, ", "))
+		}
+		for _, resolution := range resolutions {
+			dimensions, _, _ := strings.Cut(resolution, ":")
+			if _, ok := c.Image.DefaultResolutions[resolution]; !ok && !isDimensions(dimensions) {
+				return fmt.Errorf("IMAGE_DEFAULT_RESOLUTIONS_BY_TYPE resolution %q for %s must be a preset name or WIDTHxHEIGHT", resolution, mimeType)
+			}
+		}
+	}
+
+	// Validate accepted input formats
+	for _, format := range c.Image.SupportedFormats {
+		if !contains(decodableFormats, format) {
+			return fmt.Errorf("IMAGE_SUPPORTED_FORMATS contains unsupported format %q, must be one of: %s", format, strings.Join(decodableFormats, ", "))
+		}
+	}
+
+	// Validate the fallback type of uploads whose format cannot be detected
+	if c.Image.DefaultContentType != "" && !contains(decodableFormats, c.Image.DefaultContentType) {
+		return fmt.Errorf("DEFAULT_CONTENT_TYPE must be one of: %s", strings.Join(decodableFormats, ", "))
+	}
+
+	// Validate the content types strict downloads may serve
+	for _, mimeType := range c.Image.DownloadAllowedTypes {
+		if !contains(decodableFormats, mimeType) {
+			return fmt.Errorf("DOWNLOAD_ALLOWED_TYPES contains unsupported format %q, must be one of: %s", mimeType, strings.Join(decodableFormats, ", "))
+		}
+	}
+	if c.Image.DownloadStrictContentType && len(c.Image.DownloadAllowedTypes) == 0 {
+		return fmt.Errorf("DOWNLOAD_ALLOWED_TYPES cannot be empty when DOWNLOAD_STRICT_CONTENT_TYPE is enabled")
+	}
+
+	// Validate the placeholder file served for missing images
+	if placeholder := c.Image.MissingImagePlaceholder; placeholder != "" && placeholder != MissingImagePlaceholderPixel {
+		data, err := os.ReadFile(placeholder)
+		if err != nil {
+			return fmt.Errorf("MISSING_IMAGE_PLACEHOLDER: %w", err)
+		}
+		if contentType := http.DetectContentType(data); !strings.HasPrefix(contentType, "image/") {
+			return fmt.Errorf("MISSING_IMAGE_PLACEHOLDER must be %q or an image file, %s is %s", MissingImagePlaceholderPixel, placeholder, contentType)
+		}
+	}
+
+	// Validate format variants of generated resolutions
+	for _, format := range c.Image.FormatVariants {
+		if !contains(encodableFormats, format) {
+			return fmt.Errorf("GENERATE_FORMAT_VARIANTS contains unsupported format %q, must be one of: %s", format, strings.Join(encodableFormats, ", "))
+		}
+	}
+
+	// Validate automatic output format selection
+	if c.Image.OutputFormat != "" && c.Image.OutputFormat != "source" && c.Image.OutputFormat != "auto" {
+		return fmt.Errorf("IMAGE_OUTPUT_FORMAT must be one of: source, auto")
+	}
+	// GIF is left out: its palette would degrade photographic originals
+	validCanonicalFormats := []string{"jpeg", "png", "webp"}
+	if c.Image.CanonicalOriginalFormat != "" && !contains(validCanonicalFormats, c.Image.CanonicalOriginalFormat) {
+		return fmt.Errorf("CANONICAL_ORIGINAL_FORMAT must be one of: %s", strings.Join(validCanonicalFormats, ", "))
+	}
+	for _, format := range c.Image.AutoFormatCandidates {
+		if !contains(encodableFormats, format) {
+			return fmt.Errorf("IMAGE_AUTO_FORMAT_CANDIDATES contains unsupported format %q, must be one of: %s", format, strings.Join(encodableFormats, ", "))
+		}
+	}
+	if c.Image.OutputFormat == "auto" && len(c.Image.AutoFormatCandidates) == 0 {
+		return fmt.Errorf("IMAGE_AUTO_FORMAT_CANDIDATES must list at least one format when IMAGE_OUTPUT_FORMAT=auto")
+	}
+	if c.Image.AutoFormatMaxPixels < 0 {
+		return fmt.Errorf("IMAGE_AUTO_FORMAT_MAX_PIXELS must be zero (no limit) or a positive integer")
+	}
+
+	// Validate source image dimensions against the decompression-bomb budget
+	if c.Image.MaxSourceWidth <= 0 {
+		return fmt.Errorf("IMAGE_MAX_SOURCE_WIDTH must be a positive integer")
+	}
+	if c.Image.MaxSourceHeight <= 0 {
+		return fmt.Errorf("IMAGE_MAX_SOURCE_HEIGHT must be a positive integer")
+	}
+	if int64(c.Image.MaxSourceWidth)*int64(c.Image.MaxSourceHeight) > MaxSourcePixels {
+		return fmt.Errorf("IMAGE_MAX_SOURCE_WIDTH x IMAGE_MAX_SOURCE_HEIGHT must not exceed %d pixels", MaxSourcePixels)
+	}
+
+	// Validate the source minimums against the maximums
+	if c.Image.MaxTotalImages < 0 {
+		return fmt.Errorf("MAX_TOTAL_IMAGES must not be negative")
+	}
+
+	if c.Image.MinSourceWidth < 0 {
+		return fmt.Errorf("IMAGE_MIN_WIDTH must not be negative")
+	}
+	if c.Image.MinSourceHeight < 0 {
+		return fmt.Errorf("IMAGE_MIN_HEIGHT must not be negative")
+	}
+	if c.Image.MinSourceWidth > c.Image.MaxSourceWidth {
+		return fmt.Errorf("IMAGE_MIN_WIDTH must not exceed IMAGE_MAX_SOURCE_WIDTH")
+	}
+	if c.Image.MinSourceHeight > c.Image.MaxSourceHeight {
+		return fmt.Errorf("IMAGE_MIN_HEIGHT must not exceed IMAGE_MAX_SOURCE_HEIGHT")
+	}
+	if c.Image.BufferPoolMaxSize < 0 {
+		return fmt.Errorf("IMAGE_BUFFER_POOL_MAX_SIZE must not be negative")
+	}
+
+	// Validate the aspect ratio allowlist
+	for _, ratio := range c.Image.AllowedAspectRatios {
+		if _, err := ParseAspectRatio(ratio); err != nil {
+			return fmt.Errorf("IMAGE_ALLOWED_ASPECT_RATIOS: %w", err)
+		}
+	}
+	if c.Image.AspectRatioTolerance < 0 || c.Image.AspectRatioTolerance >= 1 {
+		return fmt.Errorf("IMAGE_ASPECT_RATIO_TOLERANCE must be between 0 and 1")
+	}
+
+	// Validate image listing defaults (zero keeps the built-in 50 and 100)
+	if c.Image.ListDefaultLimit < 0 {
+		return fmt.Errorf("LIST_DEFAULT_LIMIT must not be negative")
+	}
+	if c.Image.ListMaxLimit < 0 {
+		return fmt.Errorf("LIST_MAX_LIMIT must not be negative")
+	}
+	if c.Image.ListDefaultLimit > c.ListMaxLimit() {
+		return fmt.Errorf("LIST_DEFAULT_LIMIT must not exceed LIST_MAX_LIMIT")
+	}
+	validListSorts := []string{"last_accessed_at", "-last_accessed_at"}
+	if c.Image.ListDefaultSort != "" && !contains(validListSorts, c.Image.ListDefaultSort) {
+		return fmt.Errorf("LIST_DEFAULT_SORT must be one of: %s", strings.Join(validListSorts, ", "))
+	}
 
 	return nil
 }
 
+// ListDefaultLimit returns the page size of image listings that omit the limit
+func (c *Config) ListDefaultLimit() int {
+	if c.Image.ListDefaultLimit > 0 {
+		return c.Image.ListDefaultLimit
+	}
+	return min(50, c.ListMaxLimit())
+}
+
+// ListMaxLimit returns the largest page size of image listings
+func (c *Config) ListMaxLimit() int {
+	if c.Image.ListMaxLimit > 0 {
+		return c.Image.ListMaxLimit
+	}
+	return 100
+}
+
+// Base64UploadBodySize returns the request body limit of POST /api/v1/images/base64:
+// room for a MaxFileSize image base64-encoded plus its JSON fields, and never less
+// than MAX_REQUEST_BODY_SIZE
+func (c *Config) Base64UploadBodySize() int64 {
+	encoded := (c.Image.MaxFileSize + 2) / 3 * 4
+	return max(encoded+base64JSONOverhead, c.Server.MaxRequestBodySize)
+}
+
 // IsDevelopment returns true if running in development mode
 func (c *Config) IsDevelopment() bool {
 	return c.Server.GinMode == "debug" || c.Logger.Format == "console"
@@ -308,6 +961,121 @@ func (c *Config) GetResolution(name string) (ResolutionConfig, bool) {
 	return resolution, exists
 }
 
+// QualityFor returns the encoding quality of a resolution ("thumbnail", "800x600" or
+// "800x600:alias"): its configured override, or IMAGE_QUALITY
+func (c *Config) QualityFor(resolution string) int {
+	dimensions, _, _ := strings.Cut(resolution, ":")
+	if preset, ok := c.Image.DefaultResolutions[dimensions]; ok && preset.Quality > 0 {
+		return preset.Quality
+	}
+	if quality, ok := c.Image.ResolutionQuality[dimensions]; ok && quality > 0 {
+		return quality
+	}
+	return c.Image.Quality
+}
+
+// DefaultResolutionsFor returns the resolutions generated for every upload of
+// mimeType: its IMAGE_DEFAULT_RESOLUTIONS_BY_TYPE entry when listed, otherwise the
+// thumbnail when GenerateDefaultResolutions is set
+func (c *Config) DefaultResolutionsFor(mimeType string) []string {
+	if resolutions, ok := c.Image.DefaultResolutionsByType[mimeType]; ok {
+		return resolutions
+	}
+	if c.Image.GenerateDefaultResolutions {
+		return []string{"thumbnail"}
+	}
+	return nil
+}
+
+// applyDefaultResolutionsByType stores "type=res1|res2" entries; an empty list
+// disables the default resolutions for that type
+func (c *Config) applyDefaultResolutionsByType(entries []string) {
+	for _, entry := range entries {
+		mimeType, value, _ := strings.Cut(entry, "=")
+		resolutions := []string{}
+		for _, resolution := range strings.Split(value, "|") {
+			if resolution = strings.TrimSpace(resolution); resolution != "" {
+				resolutions = append(resolutions, resolution)
+			}
+		}
+		if c.Image.DefaultResolutionsByType == nil {
+			c.Image.DefaultResolutionsByType = make(map[string][]string)
+		}
+		c.Image.DefaultResolutionsByType[strings.ToLower(strings.TrimSpace(mimeType))] = resolutions
+	}
+}
+
+// applyResolutionQuality stores "name=quality" entries on the matching preset, or
+// as a WIDTHxHEIGHT override. Malformed entries get an invalid quality and fail validation.
+func (c *Config) applyResolutionQuality(entries []string) {
+	for _, entry := range entries {
+		name, value, _ := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		quality, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			quality = -1
+		}
+
+		if preset, ok := c.Image.DefaultResolutions[name]; ok {
+			preset.Quality = quality
+			c.Image.DefaultResolutions[name] = preset
+			continue
+		}
+		if c.Image.ResolutionQuality == nil {
+			c.Image.ResolutionQuality = make(map[string]int)
+		}
+		c.Image.ResolutionQuality[name] = quality
+	}
+}
+
+// applyURLCacheTTLByResolution parses "name=seconds" entries into per-resolution
+// presigned URL cache windows. Unparseable values are recorded as negative so
+// Validate reports them.
+func (c *Config) applyURLCacheTTLByResolution(entries []string) {
+	for _, entry := range entries {
+		name, value, _ := strings.Cut(entry, "=")
+		seconds, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			seconds = -1
+		}
+		if c.S3.URLCacheTTLByResolution == nil {
+			c.S3.URLCacheTTLByResolution = make(map[string]time.Duration)
+		}
+		c.S3.URLCacheTTLByResolution[strings.TrimSpace(name)] = time.Duration(seconds) * time.Second
+	}
+}
+
+// ParseAspectRatio parses a W:H aspect ratio such as "16:9" into width divided by height
+func ParseAspectRatio(s string) (float64, error) {
+	width, height, ok := strings.Cut(strings.TrimSpace(s), ":")
+	if !ok {
+		return 0, fmt.Errorf("aspect ratio %q must be in W:H format", s)
+	}
+	w, err := strconv.ParseFloat(strings.TrimSpace(width), 64)
+	if err != nil || w <= 0 {
+		return 0, fmt.Errorf("aspect ratio %q must have a positive width", s)
+	}
+	h, err := strconv.ParseFloat(strings.TrimSpace(height), 64)
+	if err != nil || h <= 0 {
+		return 0, fmt.Errorf("aspect ratio %q must have a positive height", s)
+	}
+	return w / h, nil
+}
+
+// isDimensions reports whether s is a WIDTHxHEIGHT string with positive sides
+func isDimensions(s string) bool {
+	width, height, ok := strings.Cut(s, "x")
+	if !ok {
+		return false
+	}
+	w, err := strconv.Atoi(width)
+	if err != nil || w <= 0 {
+		return false
+	}
+	h, err := strconv.Atoi(height)
+	return err == nil && h > 0
+}
+
 // IsSupportedFormat checks if the MIME type is supported
 func (c *Config) IsSupportedFormat(mimeType string) bool {
 	return contains(c.Image.SupportedFormats, mimeType)
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestLoad_DefaultValues(t *testing.T) {
@@ -31,20 +32,90 @@ func TestLoad_DefaultValues(t *testing.T) {
 	assert.Equal(t, 0, config.Redis.DB)
 	assert.Equal(t, 10, config.Redis.PoolSize)
 	assert.Equal(t, 5*time.Second, config.Redis.Timeout)
+	assert.Equal(t, RedisModeSingle, config.Redis.Mode)
+	assert.Empty(t, config.Redis.ClusterAddrs)
+	assert.Empty(t, config.Redis.MasterName)
+	assert.Empty(t, config.Redis.SentinelAddrs)
+	assert.Equal(t, 2, config.Redis.RetryAttempts)
+	assert.Equal(t, 100*time.Millisecond, config.Redis.RetryBackoff)
 	assert.Equal(t, "redis", config.Cache.Type)
 	assert.Equal(t, "./data/cache", config.Cache.Directory)
 	assert.Equal(t, 3600*time.Second, config.Cache.TTL)
+	assert.Equal(t, 1000, config.Cache.MetadataSize)
+	assert.Equal(t, 5*time.Second, config.Cache.MetadataTTL)
 	assert.Equal(t, "https://s3.amazonaws.com", config.S3.Endpoint)
 	assert.Equal(t, "test-bucket", config.S3.Bucket)
+	assert.Equal(t, "test-bucket", config.S3.ReadBucket)
 	assert.Equal(t, "us-east-1", config.S3.Region)
 	assert.True(t, config.S3.UseSSL)
 	assert.Equal(t, 3600*time.Second, config.S3.URLExpire)
+	assert.Equal(t, 300*time.Second, config.S3.URLCacheTTL)
+	assert.Nil(t, config.S3.URLCacheTTLByResolution)
+	assert.Equal(t, 3, config.S3.BatchDeleteConcurrency)
+	assert.Equal(t, "health-check/", config.S3.HealthCheckPrefix)
+	assert.False(t, config.S3.HealthWriteProbeDisabled)
+	assert.False(t, config.S3.RequesterPays)
+	assert.Empty(t, config.S3.RequestHeaders)
+	assert.False(t, config.S3.KeyHashPrefix)
+	assert.Empty(t, config.S3.CDNBaseURL)
+	assert.False(t, config.S3.CDNPresignedURLs)
+	assert.False(t, config.S3.ForcePathStyle)
 	assert.Equal(t, int64(10485760), config.Image.MaxFileSize)
+	assert.Equal(t, int64(11534336), config.Server.MaxRequestBodySize)
+	assert.False(t, config.Server.DocsEnabled)
+	assert.True(t, config.Server.CompressionEnabled)
+	assert.False(t, config.Server.MaintenanceMode)
+	assert.Equal(t, time.Duration(0), config.Server.SlowRequestThreshold)
+	assert.Equal(t, 2*time.Minute, config.Server.ProcessingRequestTimeout)
+	assert.Equal(t, 30*time.Second, config.Server.DownloadRequestTimeout)
+	assert.Equal(t, 1024, config.Server.CompressionMinSize)
+	assert.Empty(t, config.Server.TrustedProxies)
 	assert.Equal(t, 85, config.Image.Quality)
 	assert.True(t, config.Image.GenerateDefaultResolutions)
+	assert.True(t, config.Image.DeduplicationEnabled)
+	assert.Equal(t, "none", config.Image.DedupNamespaceSource)
+	assert.Equal(t, "X-Tenant-ID", config.Image.DedupNamespaceHeader)
+	assert.Equal(t, 30*time.Second, config.Image.ProcessingTimeout)
+	assert.Zero(t, config.Image.DedupOrphanCleanupInterval)
+	assert.Equal(t, "skip", config.Image.DedupFailureMode)
+	assert.False(t, config.Image.FilenameIndexEnabled)
+	assert.False(t, config.Image.RejectExtensionMismatch)
+	assert.Empty(t, config.Image.DefaultContentType)
+	assert.False(t, config.Image.DownloadStrictContentType)
+	assert.Equal(t, []string{"image/jpeg", "image/png", "image/gif", "image/webp", "image/tiff"}, config.Image.DownloadAllowedTypes)
 	assert.Equal(t, "smart_fit", config.Image.ResizeMode)
+	assert.False(t, config.Image.NoUpscale)
+	assert.False(t, config.Image.ConvertToSRGB)
+	assert.Equal(t, "lanczos", config.Image.ResampleFilter)
+	assert.Zero(t, config.Image.MultiStepDownscaleRatio)
+	assert.False(t, config.Image.PreserveGrayscale)
+	assert.Equal(t, "420", config.Image.JPEGSubsampling)
+	assert.Equal(t, "continue", config.Image.UploadPartialFailureMode)
+	assert.Equal(t, "image", config.Image.UploadFieldName)
+	assert.Zero(t, config.Image.DefaultResolutions["thumbnail"].Quality)
+	assert.Empty(t, config.Image.ResolutionQuality)
+	assert.Empty(t, config.Image.DefaultResolutionsByType)
 	assert.Equal(t, 4096, config.Image.MaxWidth)
 	assert.Equal(t, 4096, config.Image.MaxHeight)
+	assert.Equal(t, 0, config.Image.MaxResolutionPixels)
+	assert.Equal(t, 8192, config.Image.MaxSourceWidth)
+	assert.Equal(t, 8192, config.Image.MaxSourceHeight)
+	assert.Equal(t, 16777216, config.Image.BufferPoolMaxSize)
+	assert.Zero(t, config.Image.MinSourceWidth)
+	assert.Zero(t, config.Image.MaxTotalImages)
+	assert.Zero(t, config.Image.MinSourceHeight)
+	assert.Empty(t, config.Image.AllowedAspectRatios)
+	assert.Equal(t, 0.01, config.Image.AspectRatioTolerance)
+	assert.Equal(t, []string{"image/jpeg", "image/png", "image/gif", "image/webp", "image/tiff"}, config.Image.SupportedFormats)
+	assert.Empty(t, config.Image.FormatVariants)
+	assert.Equal(t, "source", config.Image.OutputFormat)
+	assert.Empty(t, config.Image.CanonicalOriginalFormat)
+	assert.Equal(t, []string{"jpeg", "png"}, config.Image.AutoFormatCandidates)
+	assert.Equal(t, 4194304, config.Image.AutoFormatMaxPixels)
+	assert.Equal(t, 50, config.Image.ListDefaultLimit)
+	assert.Equal(t, 100, config.Image.ListMaxLimit)
+	assert.Empty(t, config.Image.ListDefaultSort)
+	assert.Empty(t, config.Image.MissingImagePlaceholder)
 	assert.Equal(t, 10, config.RateLimit.Upload)
 	assert.Equal(t, 100, config.RateLimit.Download)
 	assert.Equal(t, 50, config.RateLimit.Info)
@@ -52,12 +123,22 @@ func TestLoad_DefaultValues(t *testing.T) {
 	assert.Empty(t, config.Auth.ReadWriteKeys)
 	assert.Empty(t, config.Auth.ReadOnlyKeys)
 	assert.Equal(t, "X-API-Key", config.Auth.KeyHeader)
+	assert.Equal(t, time.Duration(0), config.Statistics.RefreshInterval)
+	assert.Equal(t, 10*time.Second, config.Statistics.AccessFlushInterval)
+	assert.Equal(t, 5*time.Minute, config.Statistics.LastAccessedThrottle)
+	assert.Equal(t, AuditConfig{Retention: 90 * 24 * time.Hour}, config.Audit)
+	assert.Equal(t, WatermarkConfig{FontSize: 24, Color: "#FFFFFF", Opacity: 0.5, Position: "bottom-right"}, config.Watermark)
+	assert.Equal(t, ScannerConfig{Timeout: 30 * time.Second}, config.Scanner)
+	assert.Equal(t, QueueConfig{Workers: 4, MaxDepth: 100}, config.Queue)
+	assert.Equal(t, 5*time.Second, config.Health.ReadinessInterval)
+	assert.Equal(t, 3, config.Health.ReadinessFailureThreshold)
 	assert.Equal(t, "info", config.Logger.Level)
 	assert.Equal(t, "json", config.Logger.Format)
 	assert.True(t, config.CORS.Enabled)
 	assert.False(t, config.CORS.AllowAllOrigins)
 	assert.Equal(t, []string{"*"}, config.CORS.AllowedOrigins)
 	assert.False(t, config.CORS.AllowCredentials)
+	assert.Nil(t, config.CORS.WriteAllowedOrigins)
 }
 
 func TestLoad_CustomValues(t *testing.T) {
@@ -65,38 +146,122 @@ func TestLoad_CustomValues(t *testing.T) {
 
 	// Set custom environment variables
 	envVars := map[string]string{
-		"PORT":                         "9090",
-		"GIN_MODE":                     "debug",
-		"REDIS_URL":                    "redis://custom:6379",
-		"REDIS_PASSWORD":               "secret",
-		"REDIS_DB":                     "5",
-		"REDIS_POOL_SIZE":              "20",
-		"REDIS_TIMEOUT":                "10",
-		"CACHE_TYPE":                   "badger",
-		"CACHE_DIRECTORY":              "/tmp/cache",
-		"CACHE_TTL":                    "7200",
-		"S3_ENDPOINT":                  "http://localhost:9000",
-		"S3_ACCESS_KEY":                "custom-key",
-		"S3_SECRET_KEY":                "custom-secret",
-		"S3_BUCKET":                    "custom-bucket",
-		"S3_REGION":                    "eu-west-1",
-		"S3_USE_SSL":                   "false",
-		"S3_URL_EXPIRE":                "1800",
-		"MAX_FILE_SIZE":                "20971520", // 20MB
-		"IMAGE_QUALITY":                "95",
-		"GENERATE_DEFAULT_RESOLUTIONS": "false",
-		"RESIZE_MODE":                  "crop",
-		"IMAGE_MAX_WIDTH":              "8192",
-		"IMAGE_MAX_HEIGHT":             "8192",
-		"RATE_LIMIT_UPLOAD":            "5",
-		"RATE_LIMIT_DOWNLOAD":          "200",
-		"RATE_LIMIT_INFO":              "25",
-		"LOG_LEVEL":                    "debug",
-		"LOG_FORMAT":                   "console",
-		"CORS_ENABLED":                 "false",
-		"CORS_ALLOW_ALL_ORIGINS":       "true",
-		"CORS_ALLOWED_ORIGINS":         "https://example.com,https://test.com",
-		"CORS_ALLOW_CREDENTIALS":       "true",
+		"PORT":                              "9090",
+		"GIN_MODE":                          "debug",
+		"REDIS_URL":                         "redis://custom:6379",
+		"REDIS_PASSWORD":                    "secret",
+		"REDIS_DB":                          "5",
+		"REDIS_POOL_SIZE":                   "20",
+		"REDIS_TIMEOUT":                     "10",
+		"REDIS_RETRY_ATTEMPTS":              "4",
+		"REDIS_RETRY_BACKOFF":               "250ms",
+		"CACHE_TYPE":                        "badger",
+		"CACHE_DIRECTORY":                   "/tmp/cache",
+		"CACHE_TTL":                         "7200",
+		"METADATA_CACHE_SIZE":               "250",
+		"METADATA_CACHE_TTL":                "30",
+		"S3_ENDPOINT":                       "http://localhost:9000",
+		"S3_ACCESS_KEY":                     "custom-key",
+		"S3_SECRET_KEY":                     "custom-secret",
+		"S3_BUCKET":                         "custom-bucket",
+		"S3_READ_BUCKET":                    "replica-bucket",
+		"S3_REGION":                         "eu-west-1",
+		"S3_USE_SSL":                        "false",
+		"S3_URL_EXPIRE":                     "1800",
+		"S3_URL_CACHE_TTL":                  "60",
+		"S3_URL_CACHE_TTL_BY_RESOLUTION":    "original=30, thumbnail=3600,800x600=0",
+		"S3_BATCH_DELETE_CONCURRENCY":       "8",
+		"S3_HEALTH_CHECK_PREFIX":            "ops/health/",
+		"S3_HEALTH_WRITE_PROBE_DISABLE":     "true",
+		"S3_REQUESTER_PAYS":                 "true",
+		"S3_REQUEST_HEADERS":                "x-amz-expected-bucket-owner: 123456789012, X-Gateway-Token: abc",
+		"S3_KEY_HASH_PREFIX":                "true",
+		"S3_FORCE_PATH_STYLE":               "false",
+		"CDN_BASE_URL":                      "https://cdn.example.com/assets/",
+		"CDN_PRESIGNED_PASSTHROUGH":         "true",
+		"MAX_FILE_SIZE":                     "20971520", // 20MB
+		"MAX_REQUEST_BODY_SIZE":             "26214400", // 25MB
+		"RESPONSE_COMPRESSION_ENABLED":      "false",
+		"RESPONSE_COMPRESSION_MIN_SIZE":     "4096",
+		"MAINTENANCE_MODE":                  "true",
+		"SLOW_REQUEST_THRESHOLD":            "750ms",
+		"PROCESSING_REQUEST_TIMEOUT":        "5m",
+		"DOWNLOAD_REQUEST_TIMEOUT":          "10s",
+		"IMAGE_QUALITY":                     "95",
+		"GENERATE_DEFAULT_RESOLUTIONS":      "false",
+		"DEDUP_ENABLED":                     "false",
+		"DEDUP_NAMESPACE_SOURCE":            "Header",
+		"DEDUP_NAMESPACE_HEADER":            "X-Org",
+		"IMAGE_PROCESSING_TIMEOUT":          "5",
+		"DEDUP_ORPHAN_CLEANUP_INTERVAL":     "1h",
+		"DEDUP_FAILURE_MODE":                "Fail",
+		"FILENAME_INDEX_ENABLED":            "true",
+		"IMAGE_REJECT_EXTENSION_MISMATCH":   "true",
+		"DEFAULT_CONTENT_TYPE":              "Image/PNG",
+		"DOWNLOAD_STRICT_CONTENT_TYPE":      "true",
+		"DOWNLOAD_ALLOWED_TYPES":            "image/jpeg,image/png",
+		"RESIZE_MODE":                       "crop",
+		"IMAGE_NO_UPSCALE":                  "true",
+		"IMAGE_CONVERT_TO_SRGB":             "true",
+		"IMAGE_MAX_WIDTH":                   "8192",
+		"IMAGE_MAX_HEIGHT":                  "8192",
+		"IMAGE_MAX_RESOLUTION_PIXELS":       "16777216",
+		"IMAGE_MAX_SOURCE_WIDTH":            "6000",
+		"IMAGE_MAX_SOURCE_HEIGHT":           "4000",
+		"IMAGE_SUPPORTED_FORMATS":           "image/jpeg, image/tiff",
+		"GENERATE_FORMAT_VARIANTS":          "webp, jpeg",
+		"IMAGE_OUTPUT_FORMAT":               "AUTO",
+		"CANONICAL_ORIGINAL_FORMAT":         "WebP",
+		"IMAGE_AUTO_FORMAT_CANDIDATES":      "png,webp",
+		"IMAGE_AUTO_FORMAT_MAX_PIXELS":      "1000000",
+		"IMAGE_MIN_WIDTH":                   "200",
+		"MAX_TOTAL_IMAGES":                  "50000",
+		"IMAGE_MIN_HEIGHT":                  "100",
+		"IMAGE_BUFFER_POOL_MAX_SIZE":        "0",
+		"IMAGE_ALLOWED_ASPECT_RATIOS":       "1:1, 4:3,16:9",
+		"IMAGE_ASPECT_RATIO_TOLERANCE":      "0.05",
+		"IMAGE_RESAMPLE_FILTER":             "CatmullRom",
+		"IMAGE_MULTISTEP_DOWNSCALE_RATIO":   "3",
+		"IMAGE_PRESERVE_GRAYSCALE":          "true",
+		"IMAGE_JPEG_SUBSAMPLING":            "444",
+		"LIST_DEFAULT_LIMIT":                "20",
+		"LIST_MAX_LIMIT":                    "40",
+		"LIST_DEFAULT_SORT":                 "-last_accessed_at",
+		"MISSING_IMAGE_PLACEHOLDER":         "pixel",
+		"UPLOAD_PARTIAL_FAILURE_MODE":       "FAIL",
+		"UPLOAD_FIELD_NAME":                 "file",
+		"IMAGE_RESOLUTION_QUALITY":          "thumbnail=70, 1920x1080=90",
+		"IMAGE_DEFAULT_RESOLUTIONS_BY_TYPE": "image/jpeg=thumbnail|800x600:small, image/png=",
+		"RATE_LIMIT_UPLOAD":                 "5",
+		"RATE_LIMIT_DOWNLOAD":               "200",
+		"RATE_LIMIT_INFO":                   "25",
+		"LOG_LEVEL":                         "debug",
+		"LOG_FORMAT":                        "console",
+		"CORS_ENABLED":                      "false",
+		"CORS_ALLOW_ALL_ORIGINS":            "true",
+		"CORS_ALLOWED_ORIGINS":              "https://example.com,https://test.com",
+		"CORS_ALLOW_CREDENTIALS":            "true",
+		"CORS_WRITE_ALLOWED_ORIGINS":        "https://admin.example.com",
+		"STATISTICS_REFRESH_INTERVAL":       "120",
+		"STATISTICS_ACCESS_FLUSH_INTERVAL":  "5",
+		"STATISTICS_LAST_ACCESSED_THROTTLE": "60",
+		"AUDIT_LOG_ENABLED":                 "true",
+		"AUDIT_LOG_PERSIST":                 "true",
+		"AUDIT_LOG_RETENTION_DAYS":          "30",
+		"WATERMARK_TEXT":                    "PREVIEW",
+		"WATERMARK_FONT_SIZE":               "32",
+		"WATERMARK_COLOR":                   "#FF0000",
+		"WATERMARK_OPACITY":                 "0.8",
+		"WATERMARK_POSITION":                "Center",
+		"WATERMARK_FONT_PATH":               "/fonts/brand.ttf",
+		"SCANNER_URL":                       "http://clamav:3310/scan",
+		"SCANNER_TIMEOUT":                   "10s",
+		"PROCESSING_QUEUE_ENABLED":          "true",
+		"PROCESSING_QUEUE_WORKERS":          "8",
+		"PROCESSING_QUEUE_MAX_DEPTH":        "500",
+		"READINESS_CHECK_INTERVAL":          "15",
+		"READINESS_FAILURE_THRESHOLD":       "5",
+		"TRUSTED_PROXIES":                   "10.0.0.0/8, 192.168.1.10",
 	}
 
 	for key, value := range envVars {
@@ -117,38 +282,124 @@ func TestLoad_CustomValues(t *testing.T) {
 	assert.Equal(t, 5, config.Redis.DB)
 	assert.Equal(t, 20, config.Redis.PoolSize)
 	assert.Equal(t, 10*time.Second, config.Redis.Timeout)
+	assert.Equal(t, 4, config.Redis.RetryAttempts)
+	assert.Equal(t, 250*time.Millisecond, config.Redis.RetryBackoff)
 	assert.Equal(t, "badger", config.Cache.Type)
 	assert.Equal(t, "/tmp/cache", config.Cache.Directory)
 	assert.Equal(t, 7200*time.Second, config.Cache.TTL)
+	assert.Equal(t, 250, config.Cache.MetadataSize)
+	assert.Equal(t, 30*time.Second, config.Cache.MetadataTTL)
 	assert.Equal(t, "http://localhost:9000", config.S3.Endpoint)
 	assert.Equal(t, "custom-key", config.S3.AccessKey)
 	assert.Equal(t, "custom-secret", config.S3.SecretKey)
 	assert.Equal(t, "custom-bucket", config.S3.Bucket)
+	assert.Equal(t, "replica-bucket", config.S3.ReadBucket)
 	assert.Equal(t, "eu-west-1", config.S3.Region)
 	assert.False(t, config.S3.UseSSL)
 	assert.Equal(t, 1800*time.Second, config.S3.URLExpire)
+	assert.Equal(t, 60*time.Second, config.S3.URLCacheTTL)
+	assert.Equal(t, map[string]time.Duration{"original": 30 * time.Second, "thumbnail": time.Hour, "800x600": 0}, config.S3.URLCacheTTLByResolution)
+	assert.Equal(t, 8, config.S3.BatchDeleteConcurrency)
+	assert.Equal(t, "ops/health/", config.S3.HealthCheckPrefix)
+	assert.True(t, config.S3.HealthWriteProbeDisabled)
+	assert.True(t, config.S3.RequesterPays)
+	assert.Equal(t, []string{"x-amz-expected-bucket-owner: 123456789012", "X-Gateway-Token: abc"}, config.S3.RequestHeaders)
+	assert.True(t, config.S3.KeyHashPrefix)
+	assert.Equal(t, "https://cdn.example.com/assets", config.S3.CDNBaseURL)
+	assert.True(t, config.S3.CDNPresignedURLs)
+	assert.False(t, config.S3.ForcePathStyle)
 	assert.Equal(t, int64(20971520), config.Image.MaxFileSize)
+	assert.Equal(t, int64(26214400), config.Server.MaxRequestBodySize)
+	assert.True(t, config.Server.DocsEnabled)
+	assert.False(t, config.Server.CompressionEnabled)
+	assert.True(t, config.Server.MaintenanceMode)
+	assert.Equal(t, 750*time.Millisecond, config.Server.SlowRequestThreshold)
+	assert.Equal(t, 5*time.Minute, config.Server.ProcessingRequestTimeout)
+	assert.Equal(t, 10*time.Second, config.Server.DownloadRequestTimeout)
+	assert.Equal(t, 4096, config.Server.CompressionMinSize)
 	assert.Equal(t, 95, config.Image.Quality)
 	assert.False(t, config.Image.GenerateDefaultResolutions)
+	assert.False(t, config.Image.DeduplicationEnabled)
+	assert.Equal(t, "header", config.Image.DedupNamespaceSource)
+	assert.Equal(t, "X-Org", config.Image.DedupNamespaceHeader)
+	assert.Equal(t, 5*time.Second, config.Image.ProcessingTimeout)
+	assert.Equal(t, time.Hour, config.Image.DedupOrphanCleanupInterval)
+	assert.Equal(t, "fail", config.Image.DedupFailureMode)
+	assert.True(t, config.Image.FilenameIndexEnabled)
+	assert.True(t, config.Image.RejectExtensionMismatch)
+	assert.Equal(t, "image/png", config.Image.DefaultContentType)
+	assert.True(t, config.Image.DownloadStrictContentType)
+	assert.Equal(t, []string{"image/jpeg", "image/png"}, config.Image.DownloadAllowedTypes)
 	assert.Equal(t, "crop", config.Image.ResizeMode)
+	assert.True(t, config.Image.NoUpscale)
+	assert.True(t, config.Image.ConvertToSRGB)
+	assert.Equal(t, "catmullrom", config.Image.ResampleFilter)
+	assert.Equal(t, 3.0, config.Image.MultiStepDownscaleRatio)
+	assert.True(t, config.Image.PreserveGrayscale)
+	assert.Equal(t, "444", config.Image.JPEGSubsampling)
+	assert.Equal(t, "fail", config.Image.UploadPartialFailureMode)
+	assert.Equal(t, "file", config.Image.UploadFieldName)
+	assert.Equal(t, ResolutionConfig{Width: 150, Height: 150, Quality: 70}, config.Image.DefaultResolutions["thumbnail"])
+	assert.Equal(t, map[string]int{"1920x1080": 90}, config.Image.ResolutionQuality)
+	assert.Equal(t, map[string][]string{
+		"image/jpeg": {"thumbnail", "800x600:small"},
+		"image/png":  {},
+	}, config.Image.DefaultResolutionsByType)
 	assert.Equal(t, 8192, config.Image.MaxWidth)
 	assert.Equal(t, 8192, config.Image.MaxHeight)
+	assert.Equal(t, 16777216, config.Image.MaxResolutionPixels)
+	assert.Equal(t, 6000, config.Image.MaxSourceWidth)
+	assert.Equal(t, 4000, config.Image.MaxSourceHeight)
+	assert.Equal(t, 200, config.Image.MinSourceWidth)
+	assert.Equal(t, int64(50000), config.Image.MaxTotalImages)
+	assert.Equal(t, 100, config.Image.MinSourceHeight)
+	assert.Equal(t, []string{"1:1", "4:3", "16:9"}, config.Image.AllowedAspectRatios)
+	assert.Equal(t, 0.05, config.Image.AspectRatioTolerance)
+	assert.Equal(t, []string{"image/jpeg", "image/tiff"}, config.Image.SupportedFormats)
+	assert.Equal(t, []string{"webp", "jpeg"}, config.Image.FormatVariants)
+	assert.Equal(t, "auto", config.Image.OutputFormat)
+	assert.Equal(t, "webp", config.Image.CanonicalOriginalFormat)
+	assert.Equal(t, []string{"png", "webp"}, config.Image.AutoFormatCandidates)
+	assert.Equal(t, 1000000, config.Image.AutoFormatMaxPixels)
+	assert.Equal(t, 20, config.Image.ListDefaultLimit)
+	assert.Equal(t, 40, config.Image.ListMaxLimit)
+	assert.Equal(t, "-last_accessed_at", config.Image.ListDefaultSort)
+	assert.Equal(t, MissingImagePlaceholderPixel, config.Image.MissingImagePlaceholder)
 	assert.Equal(t, 5, config.RateLimit.Upload)
 	assert.Equal(t, 200, config.RateLimit.Download)
 	assert.Equal(t, 25, config.RateLimit.Info)
 	assert.Equal(t, "debug", config.Logger.Level)
 	assert.Equal(t, "console", config.Logger.Format)
+	assert.Equal(t, 15*time.Second, config.Health.ReadinessInterval)
+	assert.Equal(t, 5, config.Health.ReadinessFailureThreshold)
+	assert.Equal(t, []string{"10.0.0.0/8", "192.168.1.10"}, config.Server.TrustedProxies)
 	assert.False(t, config.CORS.Enabled)
 	assert.True(t, config.CORS.AllowAllOrigins)
 	assert.Equal(t, []string{"https://example.com", "https://test.com"}, config.CORS.AllowedOrigins)
 	assert.True(t, config.CORS.AllowCredentials)
+	assert.Equal(t, []string{"https://admin.example.com"}, config.CORS.WriteAllowedOrigins)
+	assert.Equal(t, 120*time.Second, config.Statistics.RefreshInterval)
+	assert.Equal(t, 5*time.Second, config.Statistics.AccessFlushInterval)
+	assert.Equal(t, AuditConfig{Enabled: true, Persist: true, Retention: 30 * 24 * time.Hour}, config.Audit)
+	assert.Equal(t, time.Minute, config.Statistics.LastAccessedThrottle)
+	assert.Equal(t, WatermarkConfig{
+		Text:     "PREVIEW",
+		FontSize: 32,
+		Color:    "#FF0000",
+		Opacity:  0.8,
+		Position: "center",
+		FontPath: "/fonts/brand.ttf",
+	}, config.Watermark)
+	assert.Equal(t, ScannerConfig{URL: "http://clamav:3310/scan", Timeout: 10 * time.Second}, config.Scanner)
+	assert.Equal(t, QueueConfig{Enabled: true, Workers: 8, MaxDepth: 500}, config.Queue)
 }
 
 func TestValidate_Success(t *testing.T) {
 	config := &Config{
 		Server: ServerConfig{
-			Port:    "8080",
-			GinMode: "release",
+			Port:               "8080",
+			GinMode:            "release",
+			MaxRequestBodySize: 11534336,
 		},
 		Cache: CacheConfig{
 			Type: "redis",
@@ -162,11 +413,14 @@ func TestValidate_Success(t *testing.T) {
 			Bucket:    "bucket",
 		},
 		Image: ImageConfig{
-			MaxFileSize: 10485760,
-			Quality:     85,
-			ResizeMode:  "smart_fit",
-			MaxWidth:    4096,
-			MaxHeight:   4096,
+			MaxFileSize:     10485760,
+			Quality:         85,
+			ResizeMode:      "smart_fit",
+			ResampleFilter:  "lanczos",
+			MaxWidth:        4096,
+			MaxHeight:       4096,
+			MaxSourceWidth:  8192,
+			MaxSourceHeight: 8192,
 		},
 		RateLimit: RateLimitConfig{
 			Upload:   10,
@@ -233,8 +487,11 @@ func TestValidate_MissingS3Config(t *testing.T) {
 			tt.config.Image.MaxFileSize = 10485760
 			tt.config.Image.Quality = 85
 			tt.config.Image.ResizeMode = "smart_fit"
+			tt.config.Image.ResampleFilter = "lanczos"
 			tt.config.Image.MaxWidth = 4096
 			tt.config.Image.MaxHeight = 4096
+			tt.config.Image.MaxSourceWidth = 8192
+			tt.config.Image.MaxSourceHeight = 8192
 			tt.config.RateLimit.Upload = 10
 			tt.config.RateLimit.Download = 100
 			tt.config.RateLimit.Info = 50
@@ -269,6 +526,66 @@ func TestValidate_InvalidCacheConfig(t *testing.T) {
 			},
 			errMsg: "REDIS_URL is required when CACHE_TYPE=redis",
 		},
+		{
+			name: "invalid redis mode",
+			modify: func(c *Config) {
+				c.Cache.Type = "redis"
+				c.Redis.Mode = "replica"
+			},
+			errMsg: "REDIS_MODE must be one of: single, cluster, sentinel",
+		},
+		{
+			name: "cluster mode without addresses",
+			modify: func(c *Config) {
+				c.Cache.Type = "redis"
+				c.Redis.Mode = RedisModeCluster
+			},
+			errMsg: "REDIS_CLUSTER_ADDRS is required when REDIS_MODE=cluster",
+		},
+		{
+			name: "cluster mode with a non-zero database",
+			modify: func(c *Config) {
+				c.Cache.Type = "redis"
+				c.Redis.Mode = RedisModeCluster
+				c.Redis.ClusterAddrs = []string{"node-1:6379"}
+				c.Redis.DB = 2
+			},
+			errMsg: "REDIS_DB must be 0 when REDIS_MODE=cluster",
+		},
+		{
+			name: "sentinel mode without master name",
+			modify: func(c *Config) {
+				c.Cache.Type = "redis"
+				c.Redis.Mode = RedisModeSentinel
+				c.Redis.SentinelAddrs = []string{"sentinel-1:26379"}
+			},
+			errMsg: "REDIS_MASTER_NAME is required when REDIS_MODE=sentinel",
+		},
+		{
+			name: "sentinel mode without addresses",
+			modify: func(c *Config) {
+				c.Cache.Type = "redis"
+				c.Redis.Mode = RedisModeSentinel
+				c.Redis.MasterName = "mymaster"
+			},
+			errMsg: "REDIS_SENTINEL_ADDRS is required when REDIS_MODE=sentinel",
+		},
+		{
+			name: "negative redis retry attempts",
+			modify: func(c *Config) {
+				c.Cache.Type = "redis"
+				c.Redis.RetryAttempts = -1
+			},
+			errMsg: "REDIS_RETRY_ATTEMPTS cannot be negative",
+		},
+		{
+			name: "negative redis retry backoff",
+			modify: func(c *Config) {
+				c.Cache.Type = "redis"
+				c.Redis.RetryBackoff = -time.Second
+			},
+			errMsg: "REDIS_RETRY_BACKOFF cannot be negative",
+		},
 		{
 			name: "missing cache directory when cache type is badger",
 			modify: func(c *Config) {
@@ -277,6 +594,20 @@ func TestValidate_InvalidCacheConfig(t *testing.T) {
 			},
 			errMsg: "CACHE_DIRECTORY is required when CACHE_TYPE=badger",
 		},
+		{
+			name: "negative metadata cache size",
+			modify: func(c *Config) {
+				c.Cache.MetadataSize = -1
+			},
+			errMsg: "METADATA_CACHE_SIZE must not be negative",
+		},
+		{
+			name: "negative metadata cache ttl",
+			modify: func(c *Config) {
+				c.Cache.MetadataTTL = -time.Second
+			},
+			errMsg: "METADATA_CACHE_TTL must not be negative",
+		},
 	}
 
 	for _, tt := range tests {
@@ -332,6 +663,176 @@ func TestValidate_ImageConfig(t *testing.T) {
 			},
 			errMsg: "RESIZE_MODE must be one of",
 		},
+		{
+			name: "invalid resample filter",
+			modify: func(c *Config) {
+				c.Image.ResampleFilter = "bicubic"
+			},
+			errMsg: "IMAGE_RESAMPLE_FILTER must be one of",
+		},
+		{
+			name: "multi-step downscale ratio below 2",
+			modify: func(c *Config) {
+				c.Image.MultiStepDownscaleRatio = 1.5
+			},
+			errMsg: "IMAGE_MULTISTEP_DOWNSCALE_RATIO must be 0 (disabled) or at least 2",
+		},
+		{
+			name: "negative presigned URL cache TTL",
+			modify: func(c *Config) {
+				c.S3.URLCacheTTL = -time.Second
+			},
+			errMsg: "S3_URL_CACHE_TTL must not be negative",
+		},
+		{
+			name: "unknown URL cache TTL resolution",
+			modify: func(c *Config) {
+				c.S3.URLCacheTTLByResolution = map[string]time.Duration{"huge": time.Minute}
+			},
+			errMsg: "S3_URL_CACHE_TTL_BY_RESOLUTION key \"huge\" must be original, a preset name or WIDTHxHEIGHT",
+		},
+		{
+			name: "negative URL cache TTL for a resolution",
+			modify: func(c *Config) {
+				c.S3.URLCacheTTLByResolution = map[string]time.Duration{"original": -time.Second}
+			},
+			errMsg: "S3_URL_CACHE_TTL_BY_RESOLUTION for original must be a non-negative number of seconds",
+		},
+		{
+			name: "malformed S3 request header",
+			modify: func(c *Config) {
+				c.S3.RequestHeaders = []string{"X-Gateway-Token"}
+			},
+			errMsg: "S3_REQUEST_HEADERS entries must be Name: Value",
+		},
+		{
+			name: "relative CDN base URL",
+			modify: func(c *Config) {
+				c.S3.CDNBaseURL = "cdn.example.com"
+			},
+			errMsg: "CDN_BASE_URL must be an absolute http(s) URL without a query string",
+		},
+		{
+			name: "CDN base URL with query",
+			modify: func(c *Config) {
+				c.S3.CDNBaseURL = "https://cdn.example.com?token=1"
+			},
+			errMsg: "CDN_BASE_URL must be an absolute http(s) URL without a query string",
+		},
+		{
+			name: "presigned passthrough without CDN",
+			modify: func(c *Config) {
+				c.S3.CDNPresignedURLs = true
+			},
+			errMsg: "CDN_PRESIGNED_PASSTHROUGH requires CDN_BASE_URL",
+		},
+		{
+			name: "health check prefix inside images",
+			modify: func(c *Config) {
+				c.S3.HealthCheckPrefix = "images/health/"
+			},
+			errMsg: "S3_HEALTH_CHECK_PREFIX must not be inside the images/ prefix",
+		},
+		{
+			name: "invalid JPEG subsampling",
+			modify: func(c *Config) {
+				c.Image.JPEGSubsampling = "411"
+			},
+			errMsg: "IMAGE_JPEG_SUBSAMPLING must be one of",
+		},
+		{
+			name: "invalid upload partial failure mode",
+			modify: func(c *Config) {
+				c.Image.UploadPartialFailureMode = "retry"
+			},
+			errMsg: "UPLOAD_PARTIAL_FAILURE_MODE must be one of",
+		},
+		{
+			name: "reserved upload field name",
+			modify: func(c *Config) {
+				c.Image.UploadFieldName = "resolutions"
+			},
+			errMsg: "UPLOAD_FIELD_NAME must not be one of the reserved form fields",
+		},
+		{
+			name: "preset quality out of range",
+			modify: func(c *Config) {
+				c.Image.DefaultResolutions = map[string]ResolutionConfig{"thumbnail": {Width: 150, Height: 150, Quality: 101}}
+			},
+			errMsg: "IMAGE_RESOLUTION_QUALITY for thumbnail must be between 1 and 100",
+		},
+		{
+			name: "resolution quality out of range",
+			modify: func(c *Config) {
+				c.Image.ResolutionQuality = map[string]int{"1920x1080": -1}
+			},
+			errMsg: "IMAGE_RESOLUTION_QUALITY for 1920x1080 must be between 1 and 100",
+		},
+		{
+			name: "resolution quality for unknown name",
+			modify: func(c *Config) {
+				c.Image.ResolutionQuality = map[string]int{"hero": 80}
+			},
+			errMsg: `IMAGE_RESOLUTION_QUALITY key "hero" must be a preset name or WIDTHxHEIGHT`,
+		},
+		{
+			name: "default resolutions for unsupported type",
+			modify: func(c *Config) {
+				c.Image.DefaultResolutionsByType = map[string][]string{"image/bmp": {"thumbnail"}}
+			},
+			errMsg: `IMAGE_DEFAULT_RESOLUTIONS_BY_TYPE contains unsupported format "image/bmp"`,
+		},
+		{
+			name: "default resolutions with unknown name",
+			modify: func(c *Config) {
+				c.Image.DefaultResolutionsByType = map[string][]string{"image/png": {"hero"}}
+			},
+			errMsg: `IMAGE_DEFAULT_RESOLUTIONS_BY_TYPE resolution "hero" for image/png must be a preset name or WIDTHxHEIGHT`,
+		},
+		{
+			name: "negative processing timeout",
+			modify: func(c *Config) {
+				c.Image.ProcessingTimeout = -time.Second
+			},
+			errMsg: "IMAGE_PROCESSING_TIMEOUT must not be negative",
+		},
+		{
+			name: "negative orphan cleanup interval",
+			modify: func(c *Config) {
+				c.Image.DedupOrphanCleanupInterval = -time.Minute
+			},
+			errMsg: "DEDUP_ORPHAN_CLEANUP_INTERVAL must not be negative",
+		},
+		{
+			name: "invalid dedup failure mode",
+			modify: func(c *Config) {
+				c.Image.DedupFailureMode = "retry"
+			},
+			errMsg: "DEDUP_FAILURE_MODE must be one of",
+		},
+		{
+			name: "invalid dedup namespace source",
+			modify: func(c *Config) {
+				c.Image.DedupNamespaceSource = "cookie"
+			},
+			errMsg: "DEDUP_NAMESPACE_SOURCE must be one of",
+		},
+		{
+			name: "api key dedup namespace without auth",
+			modify: func(c *Config) {
+				c.Auth.Enabled = false
+				c.Image.DedupNamespaceSource = "api_key"
+			},
+			errMsg: "DEDUP_NAMESPACE_SOURCE=api_key requires AUTH_ENABLED=true",
+		},
+		{
+			name: "header dedup namespace without header name",
+			modify: func(c *Config) {
+				c.Image.DedupNamespaceSource = "header"
+				c.Image.DedupNamespaceHeader = ""
+			},
+			errMsg: "DEDUP_NAMESPACE_HEADER is required",
+		},
 		{
 			name: "zero max width",
 			modify: func(c *Config) {
@@ -360,6 +861,374 @@ func TestValidate_ImageConfig(t *testing.T) {
 			},
 			errMsg: "IMAGE_MAX_HEIGHT must be a positive integer",
 		},
+		{
+			name: "negative max resolution pixels",
+			modify: func(c *Config) {
+				c.Image.MaxResolutionPixels = -1
+			},
+			errMsg: "IMAGE_MAX_RESOLUTION_PIXELS must be zero (no limit) or a positive integer",
+		},
+		{
+			name: "zero max source width",
+			modify: func(c *Config) {
+				c.Image.MaxSourceWidth = 0
+			},
+			errMsg: "IMAGE_MAX_SOURCE_WIDTH must be a positive integer",
+		},
+		{
+			name: "negative max source height",
+			modify: func(c *Config) {
+				c.Image.MaxSourceHeight = -1
+			},
+			errMsg: "IMAGE_MAX_SOURCE_HEIGHT must be a positive integer",
+		},
+		{
+			name: "undecodable supported format",
+			modify: func(c *Config) {
+				c.Image.SupportedFormats = []string{"image/jpeg", "image/bmp"}
+			},
+			errMsg: `IMAGE_SUPPORTED_FORMATS contains unsupported format "image/bmp"`,
+		},
+		{
+			name: "undecodable default content type",
+			modify: func(c *Config) {
+				c.Image.DefaultContentType = "application/octet-stream"
+			},
+			errMsg: "DEFAULT_CONTENT_TYPE must be one of: image/jpeg, image/png, image/gif, image/webp, image/tiff",
+		},
+		{
+			name: "unsupported download allowed type",
+			modify: func(c *Config) {
+				c.Image.DownloadAllowedTypes = []string{"image/png", "text/html"}
+			},
+			errMsg: `DOWNLOAD_ALLOWED_TYPES contains unsupported format "text/html"`,
+		},
+		{
+			name: "strict downloads without allowed types",
+			modify: func(c *Config) {
+				c.Image.DownloadStrictContentType = true
+				c.Image.DownloadAllowedTypes = nil
+			},
+			errMsg: "DOWNLOAD_ALLOWED_TYPES cannot be empty when DOWNLOAD_STRICT_CONTENT_TYPE is enabled",
+		},
+		{
+			name: "unencodable format variant",
+			modify: func(c *Config) {
+				c.Image.FormatVariants = []string{"webp", "avif"}
+			},
+			errMsg: `GENERATE_FORMAT_VARIANTS contains unsupported format "avif"`,
+		},
+		{
+			name: "invalid output format",
+			modify: func(c *Config) {
+				c.Image.OutputFormat = "webp"
+			},
+			errMsg: "IMAGE_OUTPUT_FORMAT must be one of: source, auto",
+		},
+		{
+			name: "invalid canonical original format",
+			modify: func(c *Config) {
+				c.Image.CanonicalOriginalFormat = "gif"
+			},
+			errMsg: "CANONICAL_ORIGINAL_FORMAT must be one of: jpeg, png, webp",
+		},
+		{
+			name: "unsupported auto format candidate",
+			modify: func(c *Config) {
+				c.Image.AutoFormatCandidates = []string{"jpeg", "avif"}
+			},
+			errMsg: `IMAGE_AUTO_FORMAT_CANDIDATES contains unsupported format "avif"`,
+		},
+		{
+			name: "auto output format without candidates",
+			modify: func(c *Config) {
+				c.Image.OutputFormat = "auto"
+				c.Image.AutoFormatCandidates = nil
+			},
+			errMsg: "IMAGE_AUTO_FORMAT_CANDIDATES must list at least one format when IMAGE_OUTPUT_FORMAT=auto",
+		},
+		{
+			name: "negative auto format pixel cap",
+			modify: func(c *Config) {
+				c.Image.AutoFormatMaxPixels = -1
+			},
+			errMsg: "IMAGE_AUTO_FORMAT_MAX_PIXELS must be zero (no limit) or a positive integer",
+		},
+		{
+			name: "source dimensions exceed decompression budget",
+			modify: func(c *Config) {
+				c.Image.MaxSourceWidth = 16384
+				c.Image.MaxSourceHeight = 8192
+			},
+			errMsg: "IMAGE_MAX_SOURCE_WIDTH x IMAGE_MAX_SOURCE_HEIGHT must not exceed",
+		},
+		{
+			name: "negative max total images",
+			modify: func(c *Config) {
+				c.Image.MaxTotalImages = -1
+			},
+			errMsg: "MAX_TOTAL_IMAGES must not be negative",
+		},
+		{
+			name: "negative minimum width",
+			modify: func(c *Config) {
+				c.Image.MinSourceWidth = -1
+			},
+			errMsg: "IMAGE_MIN_WIDTH must not be negative",
+		},
+		{
+			name: "negative minimum height",
+			modify: func(c *Config) {
+				c.Image.MinSourceHeight = -1
+			},
+			errMsg: "IMAGE_MIN_HEIGHT must not be negative",
+		},
+		{
+			name: "minimum width above source maximum",
+			modify: func(c *Config) {
+				c.Image.MinSourceWidth = c.Image.MaxSourceWidth + 1
+			},
+			errMsg: "IMAGE_MIN_WIDTH must not exceed IMAGE_MAX_SOURCE_WIDTH",
+		},
+		{
+			name: "minimum height above source maximum",
+			modify: func(c *Config) {
+				c.Image.MinSourceHeight = c.Image.MaxSourceHeight + 1
+			},
+			errMsg: "IMAGE_MIN_HEIGHT must not exceed IMAGE_MAX_SOURCE_HEIGHT",
+		},
+		{
+			name: "negative buffer pool size",
+			modify: func(c *Config) {
+				c.Image.BufferPoolMaxSize = -1
+			},
+			errMsg: "IMAGE_BUFFER_POOL_MAX_SIZE must not be negative",
+		},
+		{
+			name: "malformed aspect ratio",
+			modify: func(c *Config) {
+				c.Image.AllowedAspectRatios = []string{"16:9", "wide"}
+			},
+			errMsg: `IMAGE_ALLOWED_ASPECT_RATIOS: aspect ratio "wide" must be in W:H format`,
+		},
+		{
+			name: "negative list default limit",
+			modify: func(c *Config) {
+				c.Image.ListDefaultLimit = -1
+			},
+			errMsg: "LIST_DEFAULT_LIMIT must not be negative",
+		},
+		{
+			name: "negative list max limit",
+			modify: func(c *Config) {
+				c.Image.ListMaxLimit = -1
+			},
+			errMsg: "LIST_MAX_LIMIT must not be negative",
+		},
+		{
+			name: "list default limit above max",
+			modify: func(c *Config) {
+				c.Image.ListDefaultLimit = 60
+				c.Image.ListMaxLimit = 40
+			},
+			errMsg: "LIST_DEFAULT_LIMIT must not exceed LIST_MAX_LIMIT",
+		},
+		{
+			name: "list default limit above built-in max",
+			modify: func(c *Config) {
+				c.Image.ListDefaultLimit = 150
+			},
+			errMsg: "LIST_DEFAULT_LIMIT must not exceed LIST_MAX_LIMIT",
+		},
+		{
+			name: "invalid list default sort",
+			modify: func(c *Config) {
+				c.Image.ListDefaultSort = "filename"
+			},
+			errMsg: "LIST_DEFAULT_SORT must be one of: last_accessed_at, -last_accessed_at",
+		},
+		{
+			name: "missing image placeholder file not found",
+			modify: func(c *Config) {
+				c.Image.MissingImagePlaceholder = "testdata/does-not-exist.png"
+			},
+			errMsg: "MISSING_IMAGE_PLACEHOLDER: open testdata/does-not-exist.png: no such file or directory",
+		},
+		{
+			name: "missing image placeholder not an image",
+			modify: func(c *Config) {
+				c.Image.MissingImagePlaceholder = "config.go"
+			},
+			errMsg: `MISSING_IMAGE_PLACEHOLDER must be "pixel" or an image file, config.go is text/plain; charset=utf-8`,
+		},
+		{
+			name: "zero aspect ratio height",
+			modify: func(c *Config) {
+				c.Image.AllowedAspectRatios = []string{"16:0"}
+			},
+			errMsg: `IMAGE_ALLOWED_ASPECT_RATIOS: aspect ratio "16:0" must have a positive height`,
+		},
+		{
+			name: "aspect ratio tolerance out of range",
+			modify: func(c *Config) {
+				c.Image.AspectRatioTolerance = 1
+			},
+			errMsg: "IMAGE_ASPECT_RATIO_TOLERANCE must be between 0 and 1",
+		},
+		{
+			name: "request body limit below max file size",
+			modify: func(c *Config) {
+				c.Server.MaxRequestBodySize = c.Image.MaxFileSize - 1
+			},
+			errMsg: "MAX_REQUEST_BODY_SIZE must be greater than MAX_FILE_SIZE to leave room for multipart overhead",
+		},
+		{
+			name: "request body limit equal to max file size",
+			modify: func(c *Config) {
+				c.Server.MaxRequestBodySize = c.Image.MaxFileSize
+			},
+			errMsg: "MAX_REQUEST_BODY_SIZE must be greater than MAX_FILE_SIZE to leave room for multipart overhead",
+		},
+		{
+			name: "negative compression threshold",
+			modify: func(c *Config) {
+				c.Server.CompressionMinSize = -1
+			},
+			errMsg: "RESPONSE_COMPRESSION_MIN_SIZE must not be negative",
+		},
+		{
+			name: "negative slow request threshold",
+			modify: func(c *Config) {
+				c.Server.SlowRequestThreshold = -time.Second
+			},
+			errMsg: "SLOW_REQUEST_THRESHOLD must not be negative",
+		},
+		{
+			name: "negative processing request timeout",
+			modify: func(c *Config) {
+				c.Server.ProcessingRequestTimeout = -time.Second
+			},
+			errMsg: "PROCESSING_REQUEST_TIMEOUT must not be negative",
+		},
+		{
+			name: "negative download request timeout",
+			modify: func(c *Config) {
+				c.Server.DownloadRequestTimeout = -time.Second
+			},
+			errMsg: "DOWNLOAD_REQUEST_TIMEOUT must not be negative",
+		},
+		{
+			name: "negative statistics refresh interval",
+			modify: func(c *Config) {
+				c.Statistics.RefreshInterval = -time.Second
+			},
+			errMsg: "STATISTICS_REFRESH_INTERVAL must not be negative",
+		},
+		{
+			name: "negative access flush interval",
+			modify: func(c *Config) {
+				c.Statistics.AccessFlushInterval = -time.Second
+			},
+			errMsg: "STATISTICS_ACCESS_FLUSH_INTERVAL must not be negative",
+		},
+		{
+			name: "audit persistence without audit logging",
+			modify: func(c *Config) {
+				c.Audit.Persist = true
+			},
+			errMsg: "AUDIT_LOG_PERSIST requires AUDIT_LOG_ENABLED",
+		},
+		{
+			name: "negative audit retention",
+			modify: func(c *Config) {
+				c.Audit.Retention = -time.Hour
+			},
+			errMsg: "AUDIT_LOG_RETENTION_DAYS must not be negative",
+		},
+		{
+			name: "negative last accessed throttle",
+			modify: func(c *Config) {
+				c.Statistics.LastAccessedThrottle = -time.Second
+			},
+			errMsg: "STATISTICS_LAST_ACCESSED_THROTTLE must not be negative",
+		},
+		{
+			name: "watermark without font size",
+			modify: func(c *Config) {
+				c.Watermark = WatermarkConfig{Text: "PREVIEW", Color: "#FFFFFF", Opacity: 0.5, Position: "center"}
+			},
+			errMsg: "WATERMARK_FONT_SIZE must be positive",
+		},
+		{
+			name: "watermark opacity above one",
+			modify: func(c *Config) {
+				c.Watermark = WatermarkConfig{Text: "PREVIEW", FontSize: 24, Color: "#FFFFFF", Opacity: 1.5, Position: "center"}
+			},
+			errMsg: "WATERMARK_OPACITY must be between 0 and 1",
+		},
+		{
+			name: "invalid watermark color",
+			modify: func(c *Config) {
+				c.Watermark = WatermarkConfig{Text: "PREVIEW", FontSize: 24, Color: "white", Opacity: 0.5, Position: "center"}
+			},
+			errMsg: "WATERMARK_COLOR must be a HEX color",
+		},
+		{
+			name: "invalid watermark position",
+			modify: func(c *Config) {
+				c.Watermark = WatermarkConfig{Text: "PREVIEW", FontSize: 24, Color: "#FFFFFF", Opacity: 0.5, Position: "middle"}
+			},
+			errMsg: "WATERMARK_POSITION must be one of",
+		},
+		{
+			name: "relative scanner URL",
+			modify: func(c *Config) {
+				c.Scanner = ScannerConfig{URL: "clamav:3310", Timeout: time.Second}
+			},
+			errMsg: "SCANNER_URL must be an absolute http(s) URL",
+		},
+		{
+			name: "scanner without timeout",
+			modify: func(c *Config) {
+				c.Scanner = ScannerConfig{URL: "http://clamav:3310/scan"}
+			},
+			errMsg: "SCANNER_TIMEOUT must be positive when SCANNER_URL is set",
+		},
+		{
+			name: "queue without workers",
+			modify: func(c *Config) {
+				c.Queue = QueueConfig{Enabled: true, MaxDepth: 10}
+			},
+			errMsg: "PROCESSING_QUEUE_WORKERS must be a positive integer",
+		},
+		{
+			name: "queue without depth",
+			modify: func(c *Config) {
+				c.Queue = QueueConfig{Enabled: true, Workers: 2}
+			},
+			errMsg: "PROCESSING_QUEUE_MAX_DEPTH must be a positive integer",
+		},
+		{
+			name: "negative readiness check interval",
+			modify: func(c *Config) {
+				c.Health.ReadinessInterval = -time.Second
+			},
+			errMsg: "READINESS_CHECK_INTERVAL must not be negative",
+		},
+		{
+			name: "negative readiness failure threshold",
+			modify: func(c *Config) {
+				c.Health.ReadinessFailureThreshold = -1
+			},
+			errMsg: "READINESS_FAILURE_THRESHOLD must not be negative",
+		},
+		{
+			name: "invalid trusted proxy",
+			modify: func(c *Config) {
+				c.Server.TrustedProxies = []string{"10.0.0.0/8", "not-an-ip"}
+			},
+			errMsg: "TRUSTED_PROXIES contains invalid IP or CIDR: not-an-ip",
+		},
 	}
 
 	for _, tt := range tests {
@@ -534,6 +1403,95 @@ func TestGetResolution(t *testing.T) {
 	assert.False(t, exists)
 }
 
+func TestQualityFor(t *testing.T) {
+	config := &Config{
+		Image: ImageConfig{
+			Quality: 85,
+			DefaultResolutions: map[string]ResolutionConfig{
+				"thumbnail": {Width: 150, Height: 150, Quality: 70},
+			},
+			ResolutionQuality: map[string]int{"1920x1080": 90},
+		},
+	}
+
+	assert.Equal(t, 70, config.QualityFor("thumbnail"))
+	assert.Equal(t, 90, config.QualityFor("1920x1080"))
+	assert.Equal(t, 90, config.QualityFor("1920x1080:hero"))
+	assert.Equal(t, 85, config.QualityFor("800x600"))
+
+	config.Image.DefaultResolutions["thumbnail"] = ResolutionConfig{Width: 150, Height: 150}
+	assert.Equal(t, 85, config.QualityFor("thumbnail"))
+}
+
+func TestListLimits(t *testing.T) {
+	config := &Config{}
+	assert.Equal(t, 50, config.ListDefaultLimit())
+	assert.Equal(t, 100, config.ListMaxLimit())
+
+	config.Image.ListMaxLimit = 30
+	assert.Equal(t, 30, config.ListDefaultLimit())
+	assert.Equal(t, 30, config.ListMaxLimit())
+
+	config.Image.ListDefaultLimit = 10
+	assert.Equal(t, 10, config.ListDefaultLimit())
+}
+
+func TestBase64UploadBodySize(t *testing.T) {
+	config := &Config{}
+	config.Image.MaxFileSize = 10485760
+	config.Server.MaxRequestBodySize = 11534336
+
+	// 10MB encodes to ceil(10485760/3)*4 bytes, plus 1MB for the JSON fields
+	assert.Equal(t, int64(13981016+1048576), config.Base64UploadBodySize())
+
+	config.Server.MaxRequestBodySize = 20 << 20
+	assert.Equal(t, int64(20<<20), config.Base64UploadBodySize())
+}
+
+func TestDefaultResolutionsFor(t *testing.T) {
+	config := &Config{
+		Image: ImageConfig{
+			GenerateDefaultResolutions: true,
+			DefaultResolutionsByType: map[string][]string{
+				"image/jpeg": {"thumbnail", "800x600"},
+				"image/png":  {},
+			},
+		},
+	}
+
+	assert.Equal(t, []string{"thumbnail", "800x600"}, config.DefaultResolutionsFor("image/jpeg"))
+	assert.Empty(t, config.DefaultResolutionsFor("image/png"))
+	assert.Equal(t, []string{"thumbnail"}, config.DefaultResolutionsFor("image/gif"), "unlisted types fall back to the global flag")
+
+	config.Image.GenerateDefaultResolutions = false
+	assert.Empty(t, config.DefaultResolutionsFor("image/gif"))
+	assert.Equal(t, []string{"thumbnail", "800x600"}, config.DefaultResolutionsFor("image/jpeg"))
+}
+
+func TestApplyResolutionQuality_Malformed(t *testing.T) {
+	config := createValidConfig()
+	config.Image.DefaultResolutions = map[string]ResolutionConfig{"thumbnail": {Width: 150, Height: 150}}
+	config.applyResolutionQuality([]string{"thumbnail=high"})
+
+	err := config.Validate()
+	assert.ErrorContains(t, err, "IMAGE_RESOLUTION_QUALITY for thumbnail must be between 1 and 100")
+}
+
+func TestParseAspectRatio(t *testing.T) {
+	ratio, err := ParseAspectRatio("16:9")
+	assert.NoError(t, err)
+	assert.InDelta(t, 16.0/9.0, ratio, 1e-9)
+
+	ratio, err = ParseAspectRatio(" 1.91 : 1 ")
+	assert.NoError(t, err)
+	assert.InDelta(t, 1.91, ratio, 1e-9)
+
+	for _, invalid := range []string{"", "16x9", "a:9", "16:b", "-4:3", "4:0"} {
+		_, err := ParseAspectRatio(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
 func TestIsSupportedFormat(t *testing.T) {
 	config := &Config{
 		Image: ImageConfig{
@@ -654,6 +1612,101 @@ func TestLoad_ValidationError(t *testing.T) {
 	assert.Contains(t, err.Error(), "configuration validation failed")
 }
 
+func TestLoad_InvalidResampleFilter(t *testing.T) {
+	clearEnv()
+
+	_ = os.Setenv("S3_ACCESS_KEY", "key")
+	_ = os.Setenv("S3_SECRET_KEY", "secret")
+	_ = os.Setenv("S3_BUCKET", "bucket")
+	_ = os.Setenv("IMAGE_RESAMPLE_FILTER", "bicubic")
+	defer clearEnv()
+
+	_, err := Load()
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "IMAGE_RESAMPLE_FILTER must be one of")
+}
+
+func TestLoad_RedisModes(t *testing.T) {
+	tests := []struct {
+		name     string
+		envVars  map[string]string
+		expected RedisConfig
+	}{
+		{
+			name:    "single server",
+			envVars: map[string]string{"REDIS_MODE": "single", "REDIS_URL": "redis://cache:6379"},
+			expected: RedisConfig{
+				Mode: RedisModeSingle,
+				URL:  "redis://cache:6379",
+			},
+		},
+		{
+			name: "cluster",
+			envVars: map[string]string{
+				"REDIS_MODE":          "cluster",
+				"REDIS_CLUSTER_ADDRS": "node-1:6379, node-2:6379,node-3:6379",
+			},
+			expected: RedisConfig{
+				Mode:         RedisModeCluster,
+				URL:          "redis://localhost:6379",
+				ClusterAddrs: []string{"node-1:6379", "node-2:6379", "node-3:6379"},
+			},
+		},
+		{
+			name: "sentinel",
+			envVars: map[string]string{
+				"REDIS_MODE":           "sentinel",
+				"REDIS_MASTER_NAME":    "mymaster",
+				"REDIS_SENTINEL_ADDRS": "sentinel-1:26379,sentinel-2:26379",
+				"REDIS_DB":             "3",
+			},
+			expected: RedisConfig{
+				Mode:          RedisModeSentinel,
+				URL:           "redis://localhost:6379",
+				DB:            3,
+				MasterName:    "mymaster",
+				SentinelAddrs: []string{"sentinel-1:26379", "sentinel-2:26379"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv()
+			_ = os.Setenv("S3_BUCKET", "test-bucket")
+			_ = os.Setenv("S3_ACCESS_KEY", "test-key")
+			_ = os.Setenv("S3_SECRET_KEY", "test-secret")
+			for key, value := range tt.envVars {
+				_ = os.Setenv(key, value)
+			}
+			defer clearEnv()
+
+			config, err := Load()
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected.Mode, config.Redis.Mode)
+			assert.Equal(t, tt.expected.URL, config.Redis.URL)
+			assert.Equal(t, tt.expected.DB, config.Redis.DB)
+			assert.Equal(t, tt.expected.ClusterAddrs, config.Redis.ClusterAddrs)
+			assert.Equal(t, tt.expected.MasterName, config.Redis.MasterName)
+			assert.Equal(t, tt.expected.SentinelAddrs, config.Redis.SentinelAddrs)
+		})
+	}
+
+	t.Run("unknown mode is rejected", func(t *testing.T) {
+		clearEnv()
+		_ = os.Setenv("S3_BUCKET", "test-bucket")
+		_ = os.Setenv("S3_ACCESS_KEY", "test-key")
+		_ = os.Setenv("S3_SECRET_KEY", "test-secret")
+		_ = os.Setenv("REDIS_MODE", "replica")
+		defer clearEnv()
+
+		_, err := Load()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "REDIS_MODE must be one of")
+	})
+}
+
 func TestResolutionConfig(t *testing.T) {
 	config := ResolutionConfig{Width: 800, Height: 600}
 
@@ -666,8 +1719,9 @@ func TestResolutionConfig(t *testing.T) {
 func createValidConfig() *Config {
 	return &Config{
 		Server: ServerConfig{
-			Port:    "8080",
-			GinMode: "release",
+			Port:               "8080",
+			GinMode:            "release",
+			MaxRequestBodySize: 11534336,
 		},
 		Cache: CacheConfig{
 			Type: "redis",
@@ -681,11 +1735,14 @@ func createValidConfig() *Config {
 			Bucket:    "bucket",
 		},
 		Image: ImageConfig{
-			MaxFileSize: 10485760,
-			Quality:     85,
-			ResizeMode:  "smart_fit",
-			MaxWidth:    4096,
-			MaxHeight:   4096,
+			MaxFileSize:     10485760,
+			Quality:         85,
+			ResizeMode:      "smart_fit",
+			ResampleFilter:  "lanczos",
+			MaxWidth:        4096,
+			MaxHeight:       4096,
+			MaxSourceWidth:  8192,
+			MaxSourceHeight: 8192,
 		},
 		RateLimit: RateLimitConfig{
 			Upload:   10,
@@ -701,14 +1758,14 @@ func createValidConfig() *Config {
 
 func clearEnv() {
 	envVars := []string{
-		"PORT", "GIN_MODE", "REDIS_URL", "REDIS_PASSWORD", "REDIS_DB", "REDIS_POOL_SIZE", "REDIS_TIMEOUT",
-		"CACHE_TYPE", "CACHE_DIRECTORY", "CACHE_TTL", "S3_ENDPOINT", "S3_ACCESS_KEY", "S3_SECRET_KEY",
-		"S3_BUCKET", "S3_REGION", "S3_USE_SSL", "S3_URL_EXPIRE", "MAX_FILE_SIZE", "IMAGE_QUALITY",
-		"GENERATE_DEFAULT_RESOLUTIONS", "RESIZE_MODE", "IMAGE_MAX_WIDTH", "IMAGE_MAX_HEIGHT",
+		"PORT", "GIN_MODE", "MAX_REQUEST_BODY_SIZE", "DOCS_ENABLED", "STATISTICS_REFRESH_INTERVAL", "STATISTICS_ACCESS_FLUSH_INTERVAL", "STATISTICS_LAST_ACCESSED_THROTTLE", "AUDIT_LOG_ENABLED", "AUDIT_LOG_PERSIST", "AUDIT_LOG_RETENTION_DAYS", "WATERMARK_TEXT", "WATERMARK_FONT_SIZE", "WATERMARK_COLOR", "WATERMARK_OPACITY", "WATERMARK_POSITION", "WATERMARK_FONT_PATH", "SCANNER_URL", "SCANNER_TIMEOUT", "PROCESSING_QUEUE_ENABLED", "PROCESSING_QUEUE_WORKERS", "PROCESSING_QUEUE_MAX_DEPTH", "READINESS_CHECK_INTERVAL", "READINESS_FAILURE_THRESHOLD", "TRUSTED_PROXIES", "RESPONSE_COMPRESSION_ENABLED", "RESPONSE_COMPRESSION_MIN_SIZE", "MAINTENANCE_MODE", "SLOW_REQUEST_THRESHOLD", "PROCESSING_REQUEST_TIMEOUT", "DOWNLOAD_REQUEST_TIMEOUT", "REDIS_URL", "REDIS_PASSWORD", "REDIS_DB", "REDIS_POOL_SIZE", "REDIS_TIMEOUT", "REDIS_MODE", "REDIS_CLUSTER_ADDRS", "REDIS_MASTER_NAME", "REDIS_SENTINEL_ADDRS", "REDIS_RETRY_ATTEMPTS", "REDIS_RETRY_BACKOFF",
+		"CACHE_TYPE", "CACHE_DIRECTORY", "CACHE_TTL", "METADATA_CACHE_SIZE", "METADATA_CACHE_TTL", "S3_ENDPOINT", "S3_ACCESS_KEY", "S3_SECRET_KEY",
+		"S3_BUCKET", "S3_READ_BUCKET", "S3_REGION", "S3_USE_SSL", "S3_URL_EXPIRE", "S3_URL_CACHE_TTL", "S3_URL_CACHE_TTL_BY_RESOLUTION", "S3_BATCH_DELETE_CONCURRENCY", "S3_HEALTH_CHECK_PREFIX", "S3_HEALTH_WRITE_PROBE_DISABLE", "S3_REQUESTER_PAYS", "S3_REQUEST_HEADERS", "S3_KEY_HASH_PREFIX", "S3_FORCE_PATH_STYLE", "CDN_BASE_URL", "CDN_PRESIGNED_PASSTHROUGH", "MAX_FILE_SIZE", "IMAGE_QUALITY",
+		"GENERATE_DEFAULT_RESOLUTIONS", "DEDUP_ENABLED", "DEDUP_NAMESPACE_SOURCE", "DEDUP_NAMESPACE_HEADER", "DEDUP_ORPHAN_CLEANUP_INTERVAL", "DEDUP_FAILURE_MODE", "IMAGE_PROCESSING_TIMEOUT", "FILENAME_INDEX_ENABLED", "RESIZE_MODE", "IMAGE_NO_UPSCALE", "IMAGE_CONVERT_TO_SRGB", "IMAGE_MAX_WIDTH", "IMAGE_MAX_HEIGHT", "IMAGE_MAX_RESOLUTION_PIXELS", "IMAGE_MAX_SOURCE_WIDTH", "IMAGE_MAX_SOURCE_HEIGHT", "IMAGE_SUPPORTED_FORMATS", "GENERATE_FORMAT_VARIANTS", "IMAGE_OUTPUT_FORMAT", "CANONICAL_ORIGINAL_FORMAT", "IMAGE_AUTO_FORMAT_CANDIDATES", "IMAGE_AUTO_FORMAT_MAX_PIXELS", "IMAGE_MIN_WIDTH", "IMAGE_MIN_HEIGHT", "MAX_TOTAL_IMAGES", "IMAGE_BUFFER_POOL_MAX_SIZE", "IMAGE_ALLOWED_ASPECT_RATIOS", "IMAGE_ASPECT_RATIO_TOLERANCE", "IMAGE_RESAMPLE_FILTER", "IMAGE_MULTISTEP_DOWNSCALE_RATIO", "IMAGE_PRESERVE_GRAYSCALE", "IMAGE_JPEG_SUBSAMPLING", "LIST_DEFAULT_LIMIT", "LIST_MAX_LIMIT", "LIST_DEFAULT_SORT", "MISSING_IMAGE_PLACEHOLDER", "UPLOAD_PARTIAL_FAILURE_MODE", "UPLOAD_FIELD_NAME", "IMAGE_RESOLUTION_QUALITY", "IMAGE_DEFAULT_RESOLUTIONS_BY_TYPE", "IMAGE_REJECT_EXTENSION_MISMATCH", "DEFAULT_CONTENT_TYPE", "DOWNLOAD_STRICT_CONTENT_TYPE", "DOWNLOAD_ALLOWED_TYPES",
 		"RATE_LIMIT_UPLOAD", "RATE_LIMIT_DOWNLOAD", "RATE_LIMIT_INFO", "LOG_LEVEL", "LOG_FORMAT",
-		"CORS_ENABLED", "CORS_ALLOW_ALL_ORIGINS", "CORS_ALLOWED_ORIGINS", "CORS_ALLOW_CREDENTIALS",
+		"CORS_ENABLED", "CORS_ALLOW_ALL_ORIGINS", "CORS_ALLOWED_ORIGINS", "CORS_ALLOW_CREDENTIALS", "CORS_WRITE_ALLOWED_ORIGINS",
 		"S3_HEALTHCHECKS_DISABLE", "S3_HEALTHCHECKS_INTERVAL", "HEALTHCHECK_INTERVAL",
-		"AUTH_ENABLED", "AUTH_READWRITE_KEYS", "AUTH_READONLY_KEYS", "AUTH_KEY_HEADER",
+		"AUTH_ENABLED", "AUTH_READWRITE_KEYS", "AUTH_READONLY_KEYS", "AUTH_ADMIN_KEYS", "AUTH_KEY_HEADER",
 	}
 
 	for _, env := range envVars {
@@ -830,6 +1887,98 @@ func TestHealthCheckInterval_MinimumLimit(t *testing.T) {
 	}
 }
 
+func TestLoad_ForcePathStyle(t *testing.T) {
+	tests := []struct {
+		name     string
+		envVars  map[string]string
+		expected bool
+	}{
+		{
+			name:     "virtual-hosted on AWS by default",
+			envVars:  map[string]string{},
+			expected: false,
+		},
+		{
+			name:     "path-style on custom endpoints by default",
+			envVars:  map[string]string{"S3_ENDPOINT": "http://minio:9000"},
+			expected: true,
+		},
+		{
+			name:     "explicitly enabled on AWS",
+			envVars:  map[string]string{"S3_FORCE_PATH_STYLE": "true"},
+			expected: true,
+		},
+		{
+			name:     "explicitly disabled on a custom endpoint",
+			envVars:  map[string]string{"S3_ENDPOINT": "https://storage.example.com", "S3_FORCE_PATH_STYLE": "false"},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv()
+			_ = os.Setenv("S3_BUCKET", "test-bucket")
+			_ = os.Setenv("S3_ACCESS_KEY", "test-key")
+			_ = os.Setenv("S3_SECRET_KEY", "test-secret")
+			for key, value := range tt.envVars {
+				_ = os.Setenv(key, value)
+			}
+			defer clearEnv()
+
+			config, err := Load()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, config.S3.ForcePathStyle)
+		})
+	}
+}
+
+func TestLoad_DocsEnabled(t *testing.T) {
+	tests := []struct {
+		name     string
+		envVars  map[string]string
+		expected bool
+	}{
+		{
+			name:     "disabled by default in release mode",
+			envVars:  map[string]string{},
+			expected: false,
+		},
+		{
+			name:     "enabled by default in development",
+			envVars:  map[string]string{"GIN_MODE": "debug"},
+			expected: true,
+		},
+		{
+			name:     "explicitly enabled in release mode",
+			envVars:  map[string]string{"DOCS_ENABLED": "true"},
+			expected: true,
+		},
+		{
+			name:     "explicitly disabled in development",
+			envVars:  map[string]string{"GIN_MODE": "debug", "DOCS_ENABLED": "false"},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv()
+			_ = os.Setenv("S3_BUCKET", "test-bucket")
+			_ = os.Setenv("S3_ACCESS_KEY", "test-key")
+			_ = os.Setenv("S3_SECRET_KEY", "test-secret")
+			for key, value := range tt.envVars {
+				_ = os.Setenv(key, value)
+			}
+			defer clearEnv()
+
+			config, err := Load()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, config.Server.DocsEnabled)
+		})
+	}
+}
+
 func TestLoad_AuthConfiguration(t *testing.T) {
 	tests := []struct {
 		name              string
@@ -837,6 +1986,7 @@ func TestLoad_AuthConfiguration(t *testing.T) {
 		expectedEnabled   bool
 		expectedRWKeys    []string
 		expectedROKeys    []string
+		expectedAdminKeys []string
 		expectedKeyHeader string
 	}{
 		{
@@ -860,11 +2010,13 @@ func TestLoad_AuthConfiguration(t *testing.T) {
 				"AUTH_ENABLED":        "true",
 				"AUTH_READWRITE_KEYS": "rw-key-1,rw-key-2",
 				"AUTH_READONLY_KEYS":  "ro-key-1,ro-key-2,ro-key-3",
+				"AUTH_ADMIN_KEYS":     "admin-key-1",
 				"AUTH_KEY_HEADER":     "Authorization",
 			},
 			expectedEnabled:   true,
 			expectedRWKeys:    []string{"rw-key-1", "rw-key-2"},
 			expectedROKeys:    []string{"ro-key-1", "ro-key-2", "ro-key-3"},
+			expectedAdminKeys: []string{"admin-key-1"},
 			expectedKeyHeader: "Authorization",
 		},
 		{
@@ -900,6 +2052,11 @@ func TestLoad_AuthConfiguration(t *testing.T) {
 			assert.Equal(t, tt.expectedEnabled, config.Auth.Enabled)
 			assert.Equal(t, tt.expectedRWKeys, config.Auth.ReadWriteKeys)
 			assert.Equal(t, tt.expectedROKeys, config.Auth.ReadOnlyKeys)
+			if tt.expectedAdminKeys == nil {
+				assert.Empty(t, config.Auth.AdminKeys)
+			} else {
+				assert.Equal(t, tt.expectedAdminKeys, config.Auth.AdminKeys)
+			}
 			assert.Equal(t, tt.expectedKeyHeader, config.Auth.KeyHeader)
 		})
 	}
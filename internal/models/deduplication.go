@@ -6,13 +6,15 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
+	"strings"
 )
 
 // ImageHash represents a hash of image content for deduplication
 type ImageHash struct {
-	Algorithm string `json:"algorithm" redis:"algorithm"` // SHA256
-	Value     string `json:"value" redis:"value"`         // Hex-encoded hash
-	Size      int64  `json:"size" redis:"size"`           // Original file size for quick comparison
+	Algorithm string `json:"algorithm" redis:"algorithm"`           // SHA256
+	Value     string `json:"value" redis:"value"`                   // Hex-encoded hash
+	Size      int64  `json:"size" redis:"size"`                     // Original file size for quick comparison
+	Namespace string `json:"namespace,omitempty" redis:"namespace"` // Tenant scope; content is only shared within a namespace
 }
 
 // ResolutionReference tracks which images use a specific resolution
@@ -32,6 +34,22 @@ type DeduplicationInfo struct {
 	ResolutionRefs map[string]*ResolutionReference `json:"resolution_refs" redis:"resolution_refs"` // Per-resolution reference tracking
 }
 
+// OrphanedHash is a deduplication record that no image references anymore
+type OrphanedHash struct {
+	Hash          ImageHash `json:"hash"`
+	MasterImageID string    `json:"master_image_id,omitempty"`
+	StorageKey    string    `json:"storage_key,omitempty"`
+}
+
+// OrphanedHashReport lists orphaned deduplication records and, after a cleanup, how
+// many were removed and how many were left in place
+type OrphanedHashReport struct {
+	Count   int            `json:"count"`
+	Hashes  []OrphanedHash `json:"hashes"`
+	Removed int            `json:"removed"`
+	Skipped int            `json:"skipped"`
+}
+
 // CalculateImageHash calculates SHA-256 hash of image data
 func CalculateImageHash(data []byte) ImageHash {
 	hasher := sha256.New()
@@ -57,11 +75,31 @@ func _CalculateImageHashFromReader(reader io.Reader) (ImageHash, []byte, error)
 	return hash, data, nil
 }
 
+// ParseSHA256Hex normalizes a hex-encoded SHA256 digest (case-insensitive, optional "sha256:" prefix).
+// It returns false when the value is not a 64-character hex string.
+func ParseSHA256Hex(value string) (string, bool) {
+	value = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(value)), "sha256:")
+	if len(value) != sha256.Size*2 {
+		return "", false
+	}
+	if _, err := hex.DecodeString(value); err != nil {
+		return "", false
+	}
+	return value, true
+}
+
+// WithNamespace returns a copy of the hash scoped to the given tenant namespace
+func (ih ImageHash) WithNamespace(namespace string) ImageHash {
+	ih.Namespace = namespace
+	return ih
+}
+
 // Equals compares two ImageHash instances
 func (ih ImageHash) Equals(other ImageHash) bool {
 	return ih.Algorithm == other.Algorithm &&
 		ih.Value == other.Value &&
-		ih.Size == other.Size
+		ih.Size == other.Size &&
+		ih.Namespace == other.Namespace
 }
 
 // String returns string representation of the hash
@@ -69,8 +107,12 @@ func (ih ImageHash) String() string {
 	return fmt.Sprintf("%s:%s", ih.Algorithm, ih.Value)
 }
 
-// GetHashKey returns the key used to store hash mapping in repository
+// GetHashKey returns the key used to store hash mapping in repository.
+// Namespaced hashes get their own key so identical content is never shared across tenants.
 func (ih ImageHash) GetHashKey() string {
+	if ih.Namespace != "" {
+		return fmt.Sprintf("hash:ns:%s:%s:%s", ih.Namespace, ih.Algorithm, ih.Value)
+	}
 	return fmt.Sprintf("hash:%s:%s", ih.Algorithm, ih.Value)
 }
 
@@ -130,6 +172,14 @@ func (di *DeduplicationInfo) IsOrphaned() bool {
 	return di.ReferenceCount == 0 || len(di.ReferencingIDs) == 0
 }
 
+// BytesSaved returns the storage avoided by sharing this content instead of storing a copy per reference
+func (di *DeduplicationInfo) BytesSaved() int64 {
+	if di.ReferenceCount <= 1 {
+		return 0
+	}
+	return int64(di.ReferenceCount-1) * di.Hash.Size
+}
+
 // HasReference checks if an image ID references this content
 func (di *DeduplicationInfo) HasReference(imageID string) bool {
 	for _, id := range di.ReferencingIDs {
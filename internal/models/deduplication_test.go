@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -140,6 +141,32 @@ func TestImageHashGetHashKey(t *testing.T) {
 	}
 }
 
+func TestImageHashNamespace(t *testing.T) {
+	hash := CalculateImageHash([]byte("shared content"))
+	tenantA := hash.WithNamespace("tenant-a")
+	tenantB := hash.WithNamespace("tenant-b")
+
+	if hash.Namespace != "" {
+		t.Error("Expected WithNamespace to leave the original hash unscoped")
+	}
+
+	if tenantA.GetHashKey() == tenantB.GetHashKey() || tenantA.GetHashKey() == hash.GetHashKey() {
+		t.Error("Expected namespaced hashes to use distinct keys")
+	}
+
+	expected := "hash:ns:tenant-a:SHA256:" + hash.Value
+	if tenantA.GetHashKey() != expected {
+		t.Errorf("Expected %s, got %s", expected, tenantA.GetHashKey())
+	}
+
+	if tenantA.Equals(tenantB) {
+		t.Error("Expected hashes in different namespaces to not be equal")
+	}
+	if !tenantA.Equals(hash.WithNamespace("tenant-a")) {
+		t.Error("Expected hashes in the same namespace to be equal")
+	}
+}
+
 func TestDeduplicationInfoResolutionReference(t *testing.T) {
 	hash := ImageHash{Algorithm: "SHA256", Value: "test", Size: 100}
 	info := NewDeduplicationInfo(hash, "image-1", "storage/key")
@@ -216,3 +243,34 @@ func TestDeduplicationInfoResolutionReference(t *testing.T) {
 	info.RemoveResolutionReference("nonexistent", "image-1")
 	info.RemoveResolutionReference("1024x768", "nonexistent-image")
 }
+
+func TestParseSHA256Hex(t *testing.T) {
+	digest := strings.Repeat("a1", 32)
+
+	if value, ok := ParseSHA256Hex(digest); !ok || value != digest {
+		t.Errorf("Expected %s to parse unchanged, got %q (ok=%v)", digest, value, ok)
+	}
+
+	if value, ok := ParseSHA256Hex("  SHA256:" + strings.ToUpper(digest) + " "); !ok || value != digest {
+		t.Errorf("Expected prefixed upper-case digest to normalize, got %q (ok=%v)", value, ok)
+	}
+
+	for _, invalid := range []string{"", "a1b2", strings.Repeat("g0", 32), digest + "00"} {
+		if _, ok := ParseSHA256Hex(invalid); ok {
+			t.Errorf("Expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestDeduplicationInfoBytesSaved(t *testing.T) {
+	info := NewDeduplicationInfo(ImageHash{Algorithm: "SHA256", Value: "abc", Size: 500}, "img-1", "key")
+	if saved := info.BytesSaved(); saved != 0 {
+		t.Errorf("Expected no savings for a single reference, got %d", saved)
+	}
+
+	info.AddReference("img-2")
+	info.AddReference("img-3")
+	if saved := info.BytesSaved(); saved != 1000 {
+		t.Errorf("Expected 1000 bytes saved, got %d", saved)
+	}
+}
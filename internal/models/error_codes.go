@@ -0,0 +1,84 @@
+package models
+
+// Stable machine-readable error codes returned in ErrorResponse.ErrorCode
+const (
+	ErrorCodeValidationFailed     = "VALIDATION_FAILED"
+	ErrorCodeInvalidImageID       = "INVALID_IMAGE_ID"
+	ErrorCodeInvalidResolution    = "INVALID_RESOLUTION"
+	ErrorCodeInvalidFilename      = "INVALID_FILENAME"
+	ErrorCodeChecksumMismatch     = "CHECKSUM_MISMATCH"
+	ErrorCodeInvalidRequest       = "INVALID_REQUEST"
+	ErrorCodeMissingFile          = "MISSING_FILE"
+	ErrorCodeFileTooLarge         = "FILE_TOO_LARGE"
+	ErrorCodeRequestTooLarge      = "REQUEST_TOO_LARGE"
+	ErrorCodeImageNotFound        = "IMAGE_NOT_FOUND"
+	ErrorCodeResolutionNotFound   = "RESOLUTION_NOT_FOUND"
+	ErrorCodeNotFound             = "NOT_FOUND"
+	ErrorCodeProcessingFailed     = "PROCESSING_FAILED"
+	ErrorCodeStorageUnavailable   = "STORAGE_UNAVAILABLE"
+	ErrorCodeMissingAPIKey        = "MISSING_API_KEY"
+	ErrorCodeInvalidAPIKey        = "INVALID_API_KEY"
+	ErrorCodeForbidden            = "INSUFFICIENT_PERMISSIONS"
+	ErrorCodeRateLimitExceeded    = "RATE_LIMIT_EXCEEDED"
+	ErrorCodeInternal             = "INTERNAL_ERROR"
+	ErrorCodeMaintenance          = "MAINTENANCE_MODE"
+	ErrorCodeCorruptMetadata      = "CORRUPT_METADATA"
+	ErrorCodeUnsupportedMediaType = "UNSUPPORTED_MEDIA_TYPE"
+	ErrorCodeMalwareDetected      = "MALWARE_DETECTED"
+	ErrorCodeQueueFull            = "QUEUE_FULL"
+	ErrorCodeRequestTimeout       = "REQUEST_TIMEOUT"
+	ErrorCodeCapacityExceeded     = "CAPACITY_EXCEEDED"
+)
+
+// ErrorCodeFor derives the stable error code for a service-layer error
+func ErrorCodeFor(err error) string {
+	switch e := err.(type) {
+	case ValidationError:
+		switch e.Field {
+		case "image_id", "id":
+			return ErrorCodeInvalidImageID
+		case "resolution", "resolutions":
+			return ErrorCodeInvalidResolution
+		case "filename":
+			return ErrorCodeInvalidFilename
+		case "checksum":
+			return ErrorCodeChecksumMismatch
+		default:
+			return ErrorCodeValidationFailed
+		}
+	case MultiValidationError:
+		// The fields share a code only when they all map to the same one
+		code := ErrorCodeValidationFailed
+		for i, fieldErr := range e.Errors {
+			fieldCode := ErrorCodeFor(fieldErr)
+			if i > 0 && fieldCode != code {
+				return ErrorCodeValidationFailed
+			}
+			code = fieldCode
+		}
+		return code
+	case NotFoundError:
+		switch e.Resource {
+		case "image":
+			return ErrorCodeImageNotFound
+		case "resolution":
+			return ErrorCodeResolutionNotFound
+		default:
+			return ErrorCodeNotFound
+		}
+	case ProcessingError:
+		return ErrorCodeProcessingFailed
+	case StorageError:
+		return ErrorCodeStorageUnavailable
+	case CorruptMetadataError:
+		return ErrorCodeCorruptMetadata
+	case MalwareDetectedError:
+		return ErrorCodeMalwareDetected
+	case QueueFullError:
+		return ErrorCodeQueueFull
+	case CapacityExceededError:
+		return ErrorCodeCapacityExceeded
+	default:
+		return ErrorCodeInternal
+	}
+}
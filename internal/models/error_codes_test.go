@@ -0,0 +1,43 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorCodeFor(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{"generic validation error", ValidationError{Field: "data", Message: "required"}, ErrorCodeValidationFailed},
+		{"invalid image id", ValidationError{Field: "image_id", Message: "invalid"}, ErrorCodeInvalidImageID},
+		{"invalid id", ValidationError{Field: "id", Message: "invalid"}, ErrorCodeInvalidImageID},
+		{"invalid resolution", ValidationError{Field: "resolution", Message: "invalid"}, ErrorCodeInvalidResolution},
+		{"invalid resolutions", ValidationError{Field: "resolutions", Message: "invalid"}, ErrorCodeInvalidResolution},
+		{"invalid filename", ValidationError{Field: "filename", Message: "invalid"}, ErrorCodeInvalidFilename},
+		{"checksum mismatch", ValidationError{Field: "checksum", Message: "mismatch"}, ErrorCodeChecksumMismatch},
+		{"multiple resolution errors", MultiValidationError{Errors: []ValidationError{{Field: "resolutions"}, {Field: "resolution"}}}, ErrorCodeInvalidResolution},
+		{"mixed field errors", MultiValidationError{Errors: []ValidationError{{Field: "filename"}, {Field: "resolutions"}}}, ErrorCodeValidationFailed},
+		{"image not found", NotFoundError{Resource: "image", ID: "123"}, ErrorCodeImageNotFound},
+		{"resolution not found", NotFoundError{Resource: "resolution", ID: "123/800x600"}, ErrorCodeResolutionNotFound},
+		{"other resource not found", NotFoundError{Resource: "cached_url", ID: "123"}, ErrorCodeNotFound},
+		{"processing error", ProcessingError{Operation: "resize", Reason: "failed"}, ErrorCodeProcessingFailed},
+		{"storage error", StorageError{Operation: "upload", Backend: "S3", Reason: "timeout"}, ErrorCodeStorageUnavailable},
+		{"corrupt metadata", CorruptMetadataError{ID: "abc", Reason: "invalid JSON"}, ErrorCodeCorruptMetadata},
+		{"malware detected", MalwareDetectedError{Signature: "Eicar-Test-Signature"}, ErrorCodeMalwareDetected},
+		{"queue full", QueueFullError{Capacity: 100}, ErrorCodeQueueFull},
+		{"capacity exceeded", CapacityExceededError{Limit: 1000}, ErrorCodeCapacityExceeded},
+		{"unknown error", errors.New("boom"), ErrorCodeInternal},
+		{"nil error", nil, ErrorCodeInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ErrorCodeFor(tt.err))
+		})
+	}
+}
@@ -2,8 +2,11 @@ package models
 
 import (
 	"fmt"
+	"maps"
+	"math"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -21,9 +24,78 @@ type ImageMetadata struct {
 	Resolutions   []string  `json:"resolutions" redis:"resolutions"`
 	CreatedAt     time.Time `json:"created_at" redis:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at" redis:"updated_at"`
-	Hash          ImageHash `json:"hash" redis:"hash"`                       // Hash for deduplication
-	IsDeduped     bool      `json:"is_deduped" redis:"is_deduped"`           // True if this image shares storage with others
-	SharedImageID string    `json:"shared_image_id" redis:"shared_image_id"` // ID of the master image (if deduplicated)
+	Hash          ImageHash `json:"hash" redis:"hash"`                               // Hash for deduplication
+	IsDeduped     bool      `json:"is_deduped" redis:"is_deduped"`                   // True if this image shares storage with others
+	SharedImageID string    `json:"shared_image_id" redis:"shared_image_id"`         // ID of the master image (if deduplicated)
+	DedupDisabled bool      `json:"dedup_disabled,omitempty" redis:"dedup_disabled"` // Stored in isolation, never shared with other images
+
+	// WatermarkDisabled keeps WATERMARK_TEXT off every resolution of the image (upload field watermark=false)
+	WatermarkDisabled bool `json:"watermark_disabled,omitempty" redis:"watermark_disabled"`
+
+	// ResolutionDimensions holds the actual output size of each generated resolution,
+	// keyed by the dimensions part of the resolution (e.g. "800x600" or "thumbnail")
+	ResolutionDimensions map[string]DimensionInfo `json:"resolution_dimensions,omitempty" redis:"resolution_dimensions"`
+
+	// OriginalAliases lists the resolutions (by dimensions) served from the original file
+	// because generating them would only have upscaled it, see IMAGE_NO_UPSCALE
+	OriginalAliases []string `json:"original_aliases,omitempty" redis:"original_aliases"`
+
+	// FormatVariants lists, by dimensions, the formats a resolution is stored in besides
+	// its own (e.g. "webp"), generated for GENERATE_FORMAT_VARIANTS
+	FormatVariants map[string][]string `json:"format_variants,omitempty" redis:"format_variants"`
+
+	// ResolutionFormats holds, by dimensions, the format IMAGE_OUTPUT_FORMAT=auto encoded a
+	// resolution in when it differs from the image's derivative format (e.g. "png")
+	ResolutionFormats map[string]string `json:"resolution_formats,omitempty" redis:"resolution_formats"`
+
+	// DerivativeHashes holds, by dimensions, the SHA256 of each generated resolution file
+	// in the image's own storage, so an identical output can reuse the file
+	DerivativeHashes map[string]string `json:"derivative_hashes,omitempty" redis:"derivative_hashes"`
+
+	// DerivativeSources maps, by dimensions, resolutions whose output was byte-identical to
+	// a stored resolution to the dimensions of the resolution whose file they are served from
+	DerivativeSources map[string]string `json:"derivative_sources,omitempty" redis:"derivative_sources"`
+
+	// LastAccessedAt is the last download or presigned URL request, recorded at most
+	// once per STATISTICS_LAST_ACCESSED_THROTTLE; zero when never accessed
+	LastAccessedAt time.Time `json:"last_accessed_at,omitempty" redis:"last_accessed_at"`
+}
+
+// Image list sort orders; never accessed images count as the least recently accessed
+const (
+	ListSortLastAccessed     = "last_accessed_at"  // Least recently accessed first
+	ListSortLastAccessedDesc = "-last_accessed_at" // Most recently accessed first
+)
+
+// Image list deduplication filters, matched against IsDeduped
+const (
+	ListDedupAll     = "all"     // Every image
+	ListDedupUnique  = "unique"  // Images with their own stored content
+	ListDedupDeduped = "deduped" // Images sharing another image's stored content
+)
+
+// DedupStatusCounts counts the images of a listing by deduplication status
+type DedupStatusCounts struct {
+	Unique  int `json:"unique"`
+	Deduped int `json:"deduped"`
+	All     int `json:"all"`
+}
+
+// Add counts an image under its deduplication status
+func (c *DedupStatusCounts) Add(im *ImageMetadata) {
+	c.All++
+	if im.IsDeduped {
+		c.Deduped++
+	} else {
+		c.Unique++
+	}
+}
+
+// ImageList is one page of images filtered by deduplication status, with the
+// counts of every image per status
+type ImageList struct {
+	Images []*ImageMetadata  `json:"images"`
+	Counts DedupStatusCounts `json:"counts"`
 }
 
 // ResolutionConfig defines image resolution parameters
@@ -38,11 +110,227 @@ type UploadRequest struct {
 	Resolutions []string `form:"resolutions" json:"resolutions" binding:"omitempty"`
 }
 
+// Base64UploadRequest represents a JSON upload carrying the image as base64
+type Base64UploadRequest struct {
+	Filename    string   `json:"filename"` // Validated with the resolutions, so both are reported together
+	Data        string   `json:"data" binding:"required"`
+	Resolutions []string `json:"resolutions"`
+}
+
+// UpdateImageRequest represents the request payload for image metadata updates
+type UpdateImageRequest struct {
+	Filename string `json:"filename" binding:"required"`
+}
+
+// AddResolutionRequest represents the request payload for generating a resolution on an existing image
+type AddResolutionRequest struct {
+	Resolution string `json:"resolution" binding:"required"`
+	MaxBytes   int64  `json:"max_bytes,omitempty"` // Optional size budget for the encoded resolution
+}
+
+// SizeBudgetResolutionName returns the resolution a size-capped variant is stored
+// under, e.g. "800x600-max102400"
+func SizeBudgetResolutionName(resolution string, maxBytes int64) string {
+	return fmt.Sprintf("%s-max%d", resolution, maxBytes)
+}
+
+// BulkResolutionResult summarizes adding a resolution across all images
+type BulkResolutionResult struct {
+	Resolution string                  `json:"resolution"`
+	Total      int                     `json:"total"`
+	Processed  int                     `json:"processed"`
+	Skipped    int                     `json:"skipped"`
+	Failed     int                     `json:"failed"`
+	Failures   []BulkResolutionFailure `json:"failures,omitempty"`
+}
+
+// BulkResolutionFailure records why a single image could not get the resolution
+type BulkResolutionFailure struct {
+	ImageID string `json:"image_id"`
+	Error   string `json:"error"`
+}
+
+// Metadata export formats accepted by GET /api/v1/admin/export
+const (
+	ExportFormatNDJSON = "ndjson" // One JSON record per line (default)
+	ExportFormatJSON   = "json"   // A single JSON array
+)
+
+// MetadataImportResult summarizes restoring metadata records from an export
+type MetadataImportResult struct {
+	Total    int                     `json:"total"`
+	Imported int                     `json:"imported"`
+	Failed   int                     `json:"failed"`
+	Failures []MetadataImportFailure `json:"failures,omitempty"`
+}
+
+// MetadataImportFailure records why a single line of an import was not restored
+type MetadataImportFailure struct {
+	Line    int    `json:"line"`
+	ImageID string `json:"image_id,omitempty"`
+	Error   string `json:"error"`
+}
+
+// MaintenanceRequest switches maintenance mode on or off
+type MaintenanceRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// MaintenanceStatus reports whether write endpoints are currently rejected
+type MaintenanceStatus struct {
+	Enabled bool `json:"enabled"`
+}
+
+// CorruptRecord identifies a stored metadata record that cannot be decoded
+type CorruptRecord struct {
+	ID     string `json:"id"`
+	Reason string `json:"reason"`
+}
+
+// CorruptMetadataReport lists corrupt metadata records and, after a cleanup, how many were removed
+type CorruptMetadataReport struct {
+	Count   int             `json:"count"`
+	Records []CorruptRecord `json:"records"`
+	Removed int             `json:"removed"`
+}
+
+// EstimateRequest represents the request payload for a dry-run resize estimation
+type EstimateRequest struct {
+	Resolution string `json:"resolution" binding:"required"`
+	Mode       string `json:"mode,omitempty"`
+}
+
+// EstimateResponse describes the predicted output of a resize without generating it
+type EstimateResponse struct {
+	ImageID        string        `json:"image_id"`
+	Resolution     string        `json:"resolution"`
+	Mode           string        `json:"mode"`
+	Source         DimensionInfo `json:"source"`
+	Output         DimensionInfo `json:"output"`
+	Content        DimensionInfo `json:"content"`
+	AspectHandling string        `json:"aspect_handling"`
+}
+
+// CropRect is a region of the original image in source pixels
+type CropRect struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width" binding:"required"`
+	Height int `json:"height" binding:"required"`
+}
+
+// String formats the rectangle as XxY+WxH for logs and error messages
+func (r CropRect) String() string {
+	return fmt.Sprintf("%dx%d+%dx%d", r.X, r.Y, r.Width, r.Height)
+}
+
+// ResolutionName returns the resolution a crop is stored under, e.g. "crop-10-20-300x200"
+func (r CropRect) ResolutionName() string {
+	return fmt.Sprintf("crop-%d-%d-%dx%d", r.X, r.Y, r.Width, r.Height)
+}
+
+// CropResponse represents the response after cropping a region into a new resolution
+type CropResponse struct {
+	ID         string        `json:"id"`
+	Resolution string        `json:"resolution"`
+	Dimensions DimensionInfo `json:"dimensions"`
+}
+
+// ExistsRequest represents the request payload for checking whether content is already stored
+type ExistsRequest struct {
+	Hash string `json:"hash" binding:"required"` // Hex-encoded SHA256 of the full file, optionally "sha256:"-prefixed
+	Size int64  `json:"size,omitempty"`          // Optional file size in bytes; must match the stored content when set
+}
+
+// ExistsResponse reports whether content with a given hash is already stored
+type ExistsResponse struct {
+	Exists  bool   `json:"exists"`
+	Hash    string `json:"hash"`
+	ImageID string `json:"image_id,omitempty"` // Master image holding the content
+}
+
+// FilenameLookupResponse lists the images stored under an original filename
+type FilenameLookupResponse struct {
+	Filename string   `json:"filename"`
+	ImageIDs []string `json:"image_ids"`
+}
+
+// StorageKeysResponse maps an image's original and resolutions to the object keys
+// they are stored under, after deduplication and key hashing
+type StorageKeysResponse struct {
+	ID            string            `json:"id"`
+	Bucket        string            `json:"bucket"`
+	Deduplicated  bool              `json:"deduplicated"`
+	SharedImageID string            `json:"shared_image_id,omitempty"`
+	Original      string            `json:"original"`
+	Resolutions   map[string]string `json:"resolutions"`
+}
+
+// Audit log list bounds
+const (
+	DefaultAuditLogLimit = 100
+	MaxAuditLogLimit     = 1000
+)
+
+// AuditEntry records one write or admin operation
+type AuditEntry struct {
+	Timestamp      time.Time `json:"timestamp"`
+	Operation      string    `json:"operation"`
+	ImageID        string    `json:"image_id,omitempty"`
+	KeyFingerprint string    `json:"key_fingerprint,omitempty"` // Identifier of the API key used; empty when authentication is disabled
+	Status         int       `json:"status"`                    // HTTP status of the response
+	ClientIP       string    `json:"client_ip,omitempty"`
+	RequestID      string    `json:"request_id,omitempty"`
+}
+
+// AuditLogResponse lists persisted audit entries, newest first
+type AuditLogResponse struct {
+	Entries []AuditEntry `json:"entries"`
+	Count   int          `json:"count"`
+}
+
+// CachePurgeResponse reports the cache purge of one image
+type CachePurgeResponse struct {
+	ID                  string `json:"id"`
+	PresignedURLsPurged int    `json:"presigned_urls_purged"` // Presigned URLs this instance stopped handing out again
+}
+
+// VerificationResponse reports whether an image's stored files match its metadata.
+// Intact is false when any file is missing or the original's hash differs.
+type VerificationResponse struct {
+	ID          string                      `json:"id"`
+	Intact      bool                        `json:"intact"`
+	HashChecked bool                        `json:"hash_checked"`
+	Original    FileVerification            `json:"original"`
+	Resolutions map[string]FileVerification `json:"resolutions"`
+}
+
+// FileVerification is the outcome of checking one stored file
+type FileVerification struct {
+	Key     string `json:"key"`
+	Exists  bool   `json:"exists"`
+	Problem string `json:"problem,omitempty"`
+}
+
 // UploadResponse represents the response after successful image upload
 type UploadResponse struct {
 	ID          string   `json:"id"`
 	Message     string   `json:"message"`
 	Resolutions []string `json:"resolutions"`
+	// FailedResolutions lists requested resolutions that could not be generated
+	FailedResolutions []string `json:"failed_resolutions,omitempty"`
+	// QueuedResolutions lists resolutions the processing queue generates after the response
+	QueuedResolutions []string `json:"queued_resolutions,omitempty"`
+	// ContentTypeMismatch is set when the filename extension disagrees with the uploaded bytes
+	ContentTypeMismatch *ContentTypeMismatch `json:"content_type_mismatch,omitempty"`
+}
+
+// ContentTypeMismatch describes an upload whose filename extension names another
+// image type than its content; the detected type is the one stored and served
+type ContentTypeMismatch struct {
+	Extension         string `json:"extension"`
+	ExtensionMimeType string `json:"extension_mime_type"`
+	DetectedMimeType  string `json:"detected_mime_type"`
 }
 
 // InfoResponse represents the response for image info endpoint
@@ -54,6 +342,21 @@ type InfoResponse struct {
 	Dimensions           DimensionInfo `json:"dimensions"`
 	AvailableResolutions []string      `json:"available_resolutions"`
 	CreatedAt            time.Time     `json:"created_at"`
+
+	// ResolutionDimensions maps every available resolution to its output size
+	ResolutionDimensions map[string]DimensionInfo `json:"resolution_dimensions"`
+
+	// Access counts the downloads of the image; omitted when the counters are unavailable
+	Access *AccessCounts `json:"access,omitempty"`
+
+	// LastAccessedAt is omitted for images that were never accessed
+	LastAccessedAt *time.Time `json:"last_accessed_at,omitempty"`
+
+	// FormatVariants maps resolutions to the extra formats they can be downloaded in
+	FormatVariants map[string][]string `json:"format_variants,omitempty"`
+
+	// ResolutionFormats maps resolutions to the format IMAGE_OUTPUT_FORMAT=auto chose for them
+	ResolutionFormats map[string]string `json:"resolution_formats,omitempty"`
 }
 
 // PresignedURLResponse represents the response for presigned URL endpoint
@@ -71,9 +374,13 @@ type DimensionInfo struct {
 
 // ErrorResponse represents a standardized error response
 type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message"`
-	Code    int    `json:"code"`
+	Error     string `json:"error"`
+	Message   string `json:"message"`
+	Code      int    `json:"code"`                 // HTTP status code
+	ErrorCode string `json:"error_code,omitempty"` // Stable machine-readable code (see error_codes.go)
+
+	// Details lists every field error when a request failed validation on several fields
+	Details []ValidationError `json:"details,omitempty"`
 }
 
 // HealthResponse represents the health check response
@@ -105,6 +412,11 @@ type (
 		Message string `json:"message"`
 	}
 
+	// MultiValidationError reports several field validation errors of one request together
+	MultiValidationError struct {
+		Errors []ValidationError `json:"errors"`
+	}
+
 	// NotFoundError represents a resource not found error
 	NotFoundError struct {
 		Resource string `json:"resource"`
@@ -123,6 +435,27 @@ type (
 		Backend   string `json:"backend"`
 		Reason    string `json:"reason"`
 	}
+
+	// CorruptMetadataError represents a stored metadata record that cannot be decoded
+	CorruptMetadataError struct {
+		ID     string `json:"id"`
+		Reason string `json:"reason"`
+	}
+
+	// MalwareDetectedError represents an upload the malware scanner flagged as infected
+	MalwareDetectedError struct {
+		Signature string `json:"signature"`
+	}
+
+	// QueueFullError represents an upload turned away because the processing queue is full
+	QueueFullError struct {
+		Capacity int `json:"capacity"`
+	}
+
+	// CapacityExceededError represents an upload turned away because MAX_TOTAL_IMAGES are stored
+	CapacityExceededError struct {
+		Limit int64 `json:"limit"`
+	}
 )
 
 // Error implementations for custom error types
@@ -130,6 +463,36 @@ func (e ValidationError) Error() string {
 	return fmt.Sprintf("validation error on field '%s': %s", e.Field, e.Message)
 }
 
+func (e MultiValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, fieldErr := range e.Errors {
+		messages[i] = fmt.Sprintf("'%s': %s", fieldErr.Field, fieldErr.Message)
+	}
+	return fmt.Sprintf("validation errors on %d fields: %s", len(e.Errors), strings.Join(messages, "; "))
+}
+
+// Unwrap exposes the field errors, so errors.As finds the first ValidationError
+func (e MultiValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, fieldErr := range e.Errors {
+		errs[i] = fieldErr
+	}
+	return errs
+}
+
+// JoinValidationErrors returns nil for no errors, the error itself for one and a
+// MultiValidationError for several
+func JoinValidationErrors(errs []ValidationError) error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	default:
+		return MultiValidationError{Errors: errs}
+	}
+}
+
 func (e NotFoundError) Error() string {
 	return fmt.Sprintf("%s with ID '%s' not found", e.Resource, e.ID)
 }
@@ -142,6 +505,25 @@ func (e StorageError) Error() string {
 	return fmt.Sprintf("storage error during %s on %s: %s", e.Operation, e.Backend, e.Reason)
 }
 
+func (e CorruptMetadataError) Error() string {
+	return fmt.Sprintf("metadata for image '%s' is corrupt: %s", e.ID, e.Reason)
+}
+
+func (e MalwareDetectedError) Error() string {
+	if e.Signature == "" {
+		return "upload rejected: malware detected"
+	}
+	return fmt.Sprintf("upload rejected: malware detected (%s)", e.Signature)
+}
+
+func (e QueueFullError) Error() string {
+	return fmt.Sprintf("processing queue is full (%d uploads queued)", e.Capacity)
+}
+
+func (e CapacityExceededError) Error() string {
+	return fmt.Sprintf("image capacity reached (%d images stored)", e.Limit)
+}
+
 // Methods for ImageMetadata
 
 // GetDimensions returns the image dimensions
@@ -152,6 +534,412 @@ func (im *ImageMetadata) GetDimensions() DimensionInfo {
 	}
 }
 
+// SetResolutionDimensions records the output size of a generated resolution
+func (im *ImageMetadata) SetResolutionDimensions(resolution string, dimensions DimensionInfo) {
+	if im.ResolutionDimensions == nil {
+		im.ResolutionDimensions = make(map[string]DimensionInfo)
+	}
+	im.ResolutionDimensions[ExtractDimensions(resolution)] = dimensions
+}
+
+// GetResolutionDimensions returns the output size of a resolution (by dimensions or alias).
+// Metadata stored before dimensions were recorded falls back to the requested size.
+func (im *ImageMetadata) GetResolutionDimensions(resolution string) (DimensionInfo, bool) {
+	if resolution == "original" {
+		return im.GetDimensions(), true
+	}
+
+	var dimensions string
+	switch {
+	case im.HasResolution(resolution):
+		dimensions = im.ResolveToDimensions(resolution)
+	case im.hasStoredResolution(resolution):
+		// Exact stored entry, including the "dimensions:alias" form
+		dimensions = ExtractDimensions(resolution)
+	default:
+		return DimensionInfo{}, false
+	}
+
+	if info, ok := im.ResolutionDimensions[dimensions]; ok {
+		return info, true
+	}
+
+	config, err := ParseResolution(dimensions)
+	if err != nil {
+		return DimensionInfo{}, false
+	}
+	return DimensionInfo{Width: config.Width, Height: config.Height}, true
+}
+
+// hasStoredResolution reports whether resolution is exactly one of the stored entries
+func (im *ImageMetadata) hasStoredResolution(resolution string) bool {
+	for _, res := range im.Resolutions {
+		if res == resolution {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainsResolution reports whether the image already has resolution, either by
+// dimensions/alias or as the exact stored "dimensions:alias" entry
+func (im *ImageMetadata) ContainsResolution(resolution string) bool {
+	return im.HasResolution(resolution) || im.hasStoredResolution(resolution)
+}
+
+// AllResolutionDimensions returns the output size of the original and every stored resolution
+func (im *ImageMetadata) AllResolutionDimensions() map[string]DimensionInfo {
+	all := map[string]DimensionInfo{"original": im.GetDimensions()}
+	for _, res := range im.Resolutions {
+		if info, ok := im.GetResolutionDimensions(res); ok {
+			all[res] = info
+		}
+	}
+	return all
+}
+
+// pruneResolutionDimensions drops recorded sizes no stored resolution refers to anymore
+func (im *ImageMetadata) pruneResolutionDimensions() {
+	if len(im.ResolutionDimensions) == 0 {
+		return
+	}
+
+	inUse := make(map[string]bool, len(im.Resolutions))
+	for _, res := range im.Resolutions {
+		inUse[ExtractDimensions(res)] = true
+	}
+	for dimensions := range im.ResolutionDimensions {
+		if !inUse[dimensions] {
+			delete(im.ResolutionDimensions, dimensions)
+		}
+	}
+}
+
+// ServesOriginal reports whether a resolution (by dimensions or alias) is an alias of the
+// original, i.e. downloads of it return the original file
+func (im *ImageMetadata) ServesOriginal(resolution string) bool {
+	if resolution == "original" {
+		return false
+	}
+
+	dimensions := ExtractDimensions(im.ResolveToDimensions(resolution))
+	for _, aliased := range im.OriginalAliases {
+		if aliased == dimensions {
+			return true
+		}
+	}
+	return false
+}
+
+// SetServesOriginal records whether a resolution is served from the original file
+func (im *ImageMetadata) SetServesOriginal(resolution string, served bool) {
+	dimensions := ExtractDimensions(resolution)
+	remaining := []string{}
+	for _, aliased := range im.OriginalAliases {
+		if aliased != dimensions {
+			remaining = append(remaining, aliased)
+		}
+	}
+	if served {
+		remaining = append(remaining, dimensions)
+	}
+	if len(remaining) == 0 {
+		remaining = nil
+	}
+	im.OriginalAliases = remaining
+}
+
+// pruneOriginalAliases drops original aliases no stored resolution refers to anymore
+func (im *ImageMetadata) pruneOriginalAliases() {
+	for _, dimensions := range im.OriginalAliases {
+		inUse := false
+		for _, res := range im.Resolutions {
+			if ExtractDimensions(res) == dimensions {
+				inUse = true
+				break
+			}
+		}
+		if !inUse {
+			im.SetServesOriginal(dimensions, false)
+		}
+	}
+}
+
+// SetFormatVariants records the extra formats a resolution is stored in; an empty list
+// clears them
+func (im *ImageMetadata) SetFormatVariants(resolution string, formats []string) {
+	dimensions := ExtractDimensions(resolution)
+	if len(formats) == 0 {
+		delete(im.FormatVariants, dimensions)
+		if len(im.FormatVariants) == 0 {
+			im.FormatVariants = nil
+		}
+		return
+	}
+
+	if im.FormatVariants == nil {
+		im.FormatVariants = make(map[string][]string)
+	}
+	im.FormatVariants[dimensions] = append([]string(nil), formats...)
+}
+
+// GetFormatVariants returns the extra formats a resolution (by dimensions or alias) is stored in
+func (im *ImageMetadata) GetFormatVariants(resolution string) []string {
+	if resolution == "original" {
+		return nil
+	}
+	return im.FormatVariants[ExtractDimensions(im.ResolveToDimensions(resolution))]
+}
+
+// HasFormatVariant reports whether a resolution is stored in format besides its own
+func (im *ImageMetadata) HasFormatVariant(resolution, format string) bool {
+	for _, variant := range im.GetFormatVariants(resolution) {
+		if variant == format {
+			return true
+		}
+	}
+	return false
+}
+
+// AllFormatVariants returns the extra formats of every stored resolution that has any
+func (im *ImageMetadata) AllFormatVariants() map[string][]string {
+	var all map[string][]string
+	for _, res := range im.Resolutions {
+		if formats := im.FormatVariants[ExtractDimensions(res)]; len(formats) > 0 {
+			if all == nil {
+				all = make(map[string][]string)
+			}
+			all[res] = formats
+		}
+	}
+	return all
+}
+
+// pruneFormatVariants drops format variants no stored resolution refers to anymore
+func (im *ImageMetadata) pruneFormatVariants() {
+	inUse := make(map[string]bool, len(im.Resolutions))
+	for _, res := range im.Resolutions {
+		inUse[ExtractDimensions(res)] = true
+	}
+	for dimensions := range im.FormatVariants {
+		if !inUse[dimensions] {
+			im.SetFormatVariants(dimensions, nil)
+		}
+	}
+}
+
+// SetResolutionFormat records the format a resolution was encoded in; an empty format,
+// or the image's own derivative format, clears it
+func (im *ImageMetadata) SetResolutionFormat(resolution, format string) {
+	dimensions := ExtractDimensions(resolution)
+	if format == "" || GetMimeTypeFromFormat(format) == GetDerivativeMimeType(im.MimeType) {
+		delete(im.ResolutionFormats, dimensions)
+		if len(im.ResolutionFormats) == 0 {
+			im.ResolutionFormats = nil
+		}
+		return
+	}
+
+	if im.ResolutionFormats == nil {
+		im.ResolutionFormats = make(map[string]string)
+	}
+	im.ResolutionFormats[dimensions] = format
+}
+
+// GetResolutionFormat returns the format recorded for a resolution (by dimensions or
+// alias), or "" when it uses the image's derivative format
+func (im *ImageMetadata) GetResolutionFormat(resolution string) string {
+	if resolution == "original" {
+		return ""
+	}
+	return im.ResolutionFormats[ExtractDimensions(im.ResolveToDimensions(resolution))]
+}
+
+// AllResolutionFormats returns the recorded format of every stored resolution that has one
+func (im *ImageMetadata) AllResolutionFormats() map[string]string {
+	var all map[string]string
+	for _, res := range im.Resolutions {
+		if format := im.ResolutionFormats[ExtractDimensions(res)]; format != "" {
+			if all == nil {
+				all = make(map[string]string)
+			}
+			all[res] = format
+		}
+	}
+	return all
+}
+
+// pruneResolutionFormats drops recorded formats no stored resolution refers to anymore
+func (im *ImageMetadata) pruneResolutionFormats() {
+	inUse := make(map[string]bool, len(im.Resolutions))
+	for _, res := range im.Resolutions {
+		inUse[ExtractDimensions(res)] = true
+	}
+	for dimensions := range im.ResolutionFormats {
+		if !inUse[dimensions] {
+			im.SetResolutionFormat(dimensions, "")
+		}
+	}
+}
+
+// SetDerivativeHash records the SHA256 of the file generated for a resolution; an empty
+// hash clears it
+func (im *ImageMetadata) SetDerivativeHash(resolution, hash string) {
+	dimensions := ExtractDimensions(resolution)
+	if hash == "" {
+		delete(im.DerivativeHashes, dimensions)
+		if len(im.DerivativeHashes) == 0 {
+			im.DerivativeHashes = nil
+		}
+		return
+	}
+
+	if im.DerivativeHashes == nil {
+		im.DerivativeHashes = make(map[string]string)
+	}
+	im.DerivativeHashes[dimensions] = hash
+}
+
+// FindDerivativeByHash returns the dimensions of a generated file with the given SHA256
+// that a stored resolution is served from, or "" when there is none
+func (im *ImageMetadata) FindDerivativeByHash(hash string) string {
+	found := ""
+	for dimensions, stored := range im.DerivativeHashes {
+		if stored == hash && im.UsesDerivativeFile(dimensions) && (found == "" || dimensions < found) {
+			found = dimensions
+		}
+	}
+	return found
+}
+
+// SetDerivativeSource records that a resolution is served from the file generated for
+// source (by dimensions); an empty source, or the resolution itself, clears it
+func (im *ImageMetadata) SetDerivativeSource(resolution, source string) {
+	dimensions := ExtractDimensions(resolution)
+	if source == "" || source == dimensions {
+		delete(im.DerivativeSources, dimensions)
+		if len(im.DerivativeSources) == 0 {
+			im.DerivativeSources = nil
+		}
+		return
+	}
+
+	if im.DerivativeSources == nil {
+		im.DerivativeSources = make(map[string]string)
+	}
+	im.DerivativeSources[dimensions] = source
+}
+
+// DerivativeSource returns the dimensions of the resolution whose file a resolution (by
+// dimensions or alias) reuses, or "" when it has a file of its own
+func (im *ImageMetadata) DerivativeSource(resolution string) string {
+	if resolution == "original" {
+		return ""
+	}
+	return im.DerivativeSources[ExtractDimensions(im.ResolveToDimensions(resolution))]
+}
+
+// DerivativeFileDimensions returns the dimensions naming the generated file a resolution
+// is served from: those of its derivative source, or its own
+func (im *ImageMetadata) DerivativeFileDimensions(resolution string) string {
+	if source := im.DerivativeSource(resolution); source != "" {
+		return source
+	}
+	return im.ResolveToDimensions(resolution)
+}
+
+// UsesDerivativeFile reports whether a stored resolution is served from the file generated
+// for dimensions, either its own or as a derivative source
+func (im *ImageMetadata) UsesDerivativeFile(dimensions string) bool {
+	for _, res := range im.Resolutions {
+		if !im.ServesOriginal(res) && ExtractDimensions(im.DerivativeFileDimensions(res)) == dimensions {
+			return true
+		}
+	}
+	return false
+}
+
+// SharesDerivativeFile reports whether a stored resolution of other dimensions is served
+// from the same generated file as resolution
+func (im *ImageMetadata) SharesDerivativeFile(resolution string) bool {
+	own := ExtractDimensions(im.ResolveToDimensions(resolution))
+	file := ExtractDimensions(im.DerivativeFileDimensions(resolution))
+	for _, res := range im.Resolutions {
+		if ExtractDimensions(res) == own || im.ServesOriginal(res) {
+			continue
+		}
+		if ExtractDimensions(im.DerivativeFileDimensions(res)) == file {
+			return true
+		}
+	}
+	return false
+}
+
+// pruneDerivatives drops derivative sources of resolutions no longer stored, and the
+// hashes of files no stored resolution is served from anymore
+func (im *ImageMetadata) pruneDerivatives() {
+	inUse := make(map[string]bool, len(im.Resolutions))
+	for _, res := range im.Resolutions {
+		inUse[ExtractDimensions(res)] = true
+	}
+	for dimensions := range im.DerivativeSources {
+		if !inUse[dimensions] {
+			im.SetDerivativeSource(dimensions, "")
+		}
+	}
+	for dimensions := range im.DerivativeHashes {
+		if !im.UsesDerivativeFile(dimensions) {
+			im.SetDerivativeHash(dimensions, "")
+		}
+	}
+}
+
+// RemoveResolution removes an exact resolution entry and any size recorded only for it
+func (im *ImageMetadata) RemoveResolution(resolution string) {
+	remaining := []string{}
+	for _, res := range im.Resolutions {
+		if res != resolution {
+			remaining = append(remaining, res)
+		}
+	}
+	im.Resolutions = remaining
+	im.pruneResolutionDimensions()
+	im.pruneOriginalAliases()
+	im.pruneFormatVariants()
+	im.pruneResolutionFormats()
+	im.pruneDerivatives()
+	im.UpdatedAt = time.Now()
+}
+
+// NearestResolution returns the stored resolution closest to width x height, judged by
+// the combined log distance of area and aspect ratio. Aliased entries are returned by
+// their dimensions. It returns false when no resolution is stored.
+func (im *ImageMetadata) NearestResolution(width, height int) (string, bool) {
+	if width <= 0 || height <= 0 {
+		return "", false
+	}
+	targetArea := float64(width) * float64(height)
+	targetAspect := float64(width) / float64(height)
+
+	nearest := ""
+	bestScore := math.Inf(1)
+	for _, res := range im.Resolutions {
+		name := ExtractDimensions(res)
+		rc, err := ParseResolution(name)
+		if err != nil || rc.Width <= 0 || rc.Height <= 0 {
+			continue
+		}
+
+		area := float64(rc.Width) * float64(rc.Height)
+		aspect := float64(rc.Width) / float64(rc.Height)
+		score := math.Abs(math.Log(area/targetArea)) + math.Abs(math.Log(aspect/targetAspect))
+		if score < bestScore {
+			nearest, bestScore = name, score
+		}
+	}
+	return nearest, nearest != ""
+}
+
 // HasResolution checks if a specific resolution exists (by dimensions or alias)
 func (im *ImageMetadata) HasResolution(resolution string) bool {
 	// Don't allow access via the full "dimensions:alias" format from API
@@ -165,7 +953,7 @@ func (im *ImageMetadata) HasResolution(resolution string) bool {
 			return true
 		}
 		// Check if resolution matches an alias
-		if alias := ExtractAlias(res); alias != "" && alias == resolution {
+		if alias := ExtractAlias(res); alias != "" && strings.EqualFold(alias, resolution) {
 			return true
 		}
 		// Check if resolution matches dimensions part of an aliased resolution
@@ -176,12 +964,44 @@ func (im *ImageMetadata) HasResolution(resolution string) bool {
 	return false
 }
 
-// AddResolution adds a new resolution to the list
-func (im *ImageMetadata) AddResolution(resolution string) {
+// AddResolution adds a new resolution to the list. Re-adding an existing resolution
+// is a no-op; adding an alias already mapped to different dimensions is rejected.
+func (im *ImageMetadata) AddResolution(resolution string) error {
+	if err := im.CheckAliasConflict(resolution); err != nil {
+		return err
+	}
+
+	for _, res := range im.Resolutions {
+		if res == resolution {
+			return nil
+		}
+	}
+
 	if !im.HasResolution(resolution) {
 		im.Resolutions = append(im.Resolutions, resolution)
 		im.UpdatedAt = time.Now()
 	}
+	return nil
+}
+
+// CheckAliasConflict returns a ValidationError if the alias of resolution is already
+// mapped to different dimensions on this image
+func (im *ImageMetadata) CheckAliasConflict(resolution string) error {
+	dimensions, alias := SplitResolutionAndAlias(resolution)
+	if alias == "" {
+		return nil
+	}
+
+	for _, res := range im.Resolutions {
+		existingDimensions, existingAlias := SplitResolutionAndAlias(res)
+		if strings.EqualFold(existingAlias, alias) && existingDimensions != dimensions {
+			return ValidationError{
+				Field:   "resolution",
+				Message: fmt.Sprintf("Alias '%s' is already used by resolution %s on this image", alias, existingDimensions),
+			}
+		}
+	}
+	return nil
 }
 
 // GetFileExtension extracts file extension from filename
@@ -196,13 +1016,37 @@ func (im *ImageMetadata) GetFileExtension() string {
 // GetStorageKey generates the storage key for a specific resolution
 func (im *ImageMetadata) GetStorageKey(resolution string) string {
 	ext := im.GetFileExtension()
-	if resolution == "original" {
+	if resolution == "original" || im.ServesOriginal(resolution) {
 		return fmt.Sprintf("images/%s/original.%s", im.ID, ext)
 	}
 
 	// Always use dimensions for storage key to avoid duplicates
-	dimensions := im.ResolveToDimensions(resolution)
-	return fmt.Sprintf("images/%s/%s.%s", im.ID, dimensions, ext)
+	dimensions := im.DerivativeFileDimensions(resolution)
+	return fmt.Sprintf("images/%s/%s.%s", im.ID, dimensions, im.getDerivativeExtension(resolution))
+}
+
+// GetContentType returns the MIME type of the stored file for a resolution.
+// Originals keep their uploaded type; generated resolutions may be converted.
+func (im *ImageMetadata) GetContentType(resolution string) string {
+	if resolution == "original" || im.ServesOriginal(resolution) {
+		return im.MimeType
+	}
+	if format := im.GetResolutionFormat(resolution); format != "" {
+		return GetMimeTypeFromFormat(format)
+	}
+	return GetDerivativeMimeType(im.MimeType)
+}
+
+// getDerivativeExtension returns the file extension used for a generated resolution
+func (im *ImageMetadata) getDerivativeExtension(resolution string) string {
+	if format := im.GetResolutionFormat(resolution); format != "" {
+		return GetExtensionFromMimeType(GetMimeTypeFromFormat(format))
+	}
+	derivativeMimeType := GetDerivativeMimeType(im.MimeType)
+	if derivativeMimeType != im.MimeType {
+		return GetExtensionFromMimeType(derivativeMimeType)
+	}
+	return im.GetFileExtension()
 }
 
 // ResolveToDimensions resolves any resolution (alias or dimensions) to pure dimensions for storage
@@ -217,9 +1061,14 @@ func (im *ImageMetadata) ResolveToDimensions(resolution string) string {
 		return "thumbnail"
 	}
 
+	// Only well-formed aliases can match a stored resolution
+	if !IsValidAlias(resolution) {
+		return resolution
+	}
+
 	// Search for the resolution by alias and return its dimensions
 	for _, res := range im.Resolutions {
-		if alias := ExtractAlias(res); alias != "" && alias == resolution {
+		if alias := ExtractAlias(res); alias != "" && strings.EqualFold(alias, resolution) {
 			return ExtractDimensions(res)
 		}
 	}
@@ -237,6 +1086,11 @@ func (im *ImageMetadata) FindStoredResolution(resolution string) string {
 
 // ToInfoResponse converts ImageMetadata to InfoResponse
 func (im *ImageMetadata) ToInfoResponse() InfoResponse {
+	var lastAccessedAt *time.Time
+	if !im.LastAccessedAt.IsZero() {
+		lastAccessedAt = &im.LastAccessedAt
+	}
+
 	return InfoResponse{
 		ID:                   im.ID,
 		Filename:             im.Filename,
@@ -245,6 +1099,10 @@ func (im *ImageMetadata) ToInfoResponse() InfoResponse {
 		Dimensions:           im.GetDimensions(),
 		AvailableResolutions: append([]string{"original"}, im.Resolutions...),
 		CreatedAt:            im.CreatedAt,
+		ResolutionDimensions: im.AllResolutionDimensions(),
+		LastAccessedAt:       lastAccessedAt,
+		FormatVariants:       im.AllFormatVariants(),
+		ResolutionFormats:    im.AllResolutionFormats(),
 	}
 }
 
@@ -263,6 +1121,7 @@ func (im *ImageMetadata) IsValidMimeType() bool {
 		"image/png",
 		"image/gif",
 		"image/webp",
+		"image/tiff",
 	}
 
 	for _, validType := range validTypes {
@@ -321,6 +1180,16 @@ func ParseResolution(resolution string) (ResolutionConfig, error) {
 	// Extract alias if present
 	dimensions, alias := SplitResolutionAndAlias(resolution)
 
+	// Aliases end up in metadata and lookups, so restrict them to a safe character set.
+	// They are case-insensitive and kept in lower case.
+	if alias != "" && !IsValidAlias(alias) {
+		return ResolutionConfig{}, fmt.Errorf("invalid alias '%s': aliases must be 1-%d characters of letters, digits, '_' or '-'", alias, MaxAliasLength)
+	}
+	alias = strings.ToLower(alias)
+	if IsReservedAlias(alias) {
+		return ResolutionConfig{}, fmt.Errorf("invalid alias '%s': it is reserved by the /images/{id}/%s route", alias, alias)
+	}
+
 	// Parse custom resolution format: "WIDTHxHEIGHT"
 	resolutionRegex := regexp.MustCompile(`^(\d+)x(\d+)$`)
 	matches := resolutionRegex.FindStringSubmatch(dimensions)
@@ -382,6 +1251,8 @@ func GetMimeTypeFromExtension(filename string) string {
 		return "image/gif"
 	case ".webp":
 		return "image/webp"
+	case ".tif", ".tiff":
+		return "image/tiff"
 	default:
 		return ""
 	}
@@ -398,13 +1269,46 @@ func GetExtensionFromMimeType(mimeType string) string {
 		return "gif"
 	case "image/webp":
 		return "webp"
+	case "image/tiff":
+		return "tiff"
 	default:
 		return ""
 	}
 }
 
+// GetMimeTypeFromFormat returns the MIME type of an output format name such as "webp"
+func GetMimeTypeFromFormat(format string) string {
+	switch format {
+	case "jpeg":
+		return "image/jpeg"
+	case "png":
+		return "image/png"
+	case "gif":
+		return "image/gif"
+	case "webp":
+		return "image/webp"
+	default:
+		return ""
+	}
+}
+
+// GetDerivativeMimeType returns the MIME type used for generated resolutions of an
+// original with the given MIME type. Formats browsers cannot display are converted
+// to PNG; web formats are kept as-is.
+func GetDerivativeMimeType(mimeType string) string {
+	switch mimeType {
+	case "image/tiff":
+		return "image/png"
+	default:
+		return mimeType
+	}
+}
+
 // Utility functions for resolution alias handling
 
+// MaxAliasLength is the maximum number of characters in a resolution alias
+const MaxAliasLength = 50
+
 // SplitResolutionAndAlias splits a resolution string like "800x600:alias" into dimensions and alias
 func SplitResolutionAndAlias(resolution string) (dimensions, alias string) {
 	parts := strings.Split(resolution, ":")
@@ -426,6 +1330,56 @@ func ExtractDimensions(resolution string) string {
 	return dimensions
 }
 
+// IsValidAlias checks if an alias is 1-MaxAliasLength characters of letters, digits, '_' or '-'
+func IsValidAlias(alias string) bool {
+	if len(alias) == 0 || len(alias) > MaxAliasLength {
+		return false
+	}
+
+	for _, char := range alias {
+		if !((char >= 'a' && char <= 'z') ||
+			(char >= 'A' && char <= 'Z') ||
+			(char >= '0' && char <= '9') ||
+			char == '_' || char == '-') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// reservedAliases are the static path segments under /images/{id}; an alias with one
+// of these names would be shadowed by its route and could never be downloaded
+var reservedAliases = map[string]bool{
+	"original":  true,
+	"thumbnail": true,
+	"info":      true,
+	"archive":   true,
+	"keys":      true,
+	"verify":    true,
+}
+
+// IsReservedAlias reports whether an alias clashes with a static route segment,
+// including the thumbnail.{ext} downloads
+func IsReservedAlias(alias string) bool {
+	alias = strings.ToLower(alias)
+	return reservedAliases[alias] || strings.HasPrefix(alias, "thumbnail.")
+}
+
+// NormalizeResolution returns the canonical "WIDTHxHEIGHT[:alias]" form of a resolution:
+// whitespace around the colon and an empty alias are dropped and the alias is lower-cased.
+// Anything ParseResolution rejects, and names without a colon, are returned unchanged.
+func NormalizeResolution(resolution string) string {
+	if !strings.Contains(resolution, ":") {
+		return resolution
+	}
+	rc, err := ParseResolution(resolution)
+	if err != nil {
+		return resolution
+	}
+	return rc.String()
+}
+
 // IsValidDimensionFormat checks if a string is in the WIDTHxHEIGHT format
 func IsValidDimensionFormat(resolution string) bool {
 	resolutionRegex := regexp.MustCompile(`^(\d+)x(\d+)$`)
@@ -472,19 +1426,67 @@ func (im *ImageMetadata) GetActualStorageKey(resolution string) string {
 	if im.IsDeduped && im.SharedImageID != "" {
 		// Use shared image's storage key
 		ext := im.GetFileExtension()
-		if resolution == "original" {
+		if resolution == "original" || im.ServesOriginal(resolution) {
 			return fmt.Sprintf("images/%s/original.%s", im.SharedImageID, ext)
 		}
-		dimensions := im.ResolveToDimensions(resolution)
-		return fmt.Sprintf("images/%s/%s.%s", im.SharedImageID, dimensions, ext)
+		dimensions := im.DerivativeFileDimensions(resolution)
+		return fmt.Sprintf("images/%s/%s.%s", im.SharedImageID, dimensions, im.getDerivativeExtension(resolution))
 	}
 	// Use own storage key
 	return im.GetStorageKey(resolution)
 }
 
+// GetVariantStorageKey returns the storage key of a resolution in another format; it
+// differs from GetActualStorageKey only by the extension
+func (im *ImageMetadata) GetVariantStorageKey(resolution, format string) string {
+	key := im.GetActualStorageKey(resolution)
+	ext := GetExtensionFromMimeType(GetMimeTypeFromFormat(format))
+	if dot := strings.LastIndex(key, "."); dot > 0 && ext != "" {
+		return key[:dot+1] + ext
+	}
+	return key
+}
+
+// MatchesDedupFilter reports whether the image belongs in a listing with a
+// models.ListDedup* filter; an empty filter matches every image
+func (im *ImageMetadata) MatchesDedupFilter(filter string) bool {
+	switch filter {
+	case ListDedupUnique:
+		return !im.IsDeduped
+	case ListDedupDeduped:
+		return im.IsDeduped
+	default:
+		return true
+	}
+}
+
+// UsesDeduplication reports whether the image takes part in hash-based storage sharing
+func (im *ImageMetadata) UsesDeduplication() bool {
+	return im.Hash.Value != "" && !im.DedupDisabled
+}
+
 // MarkAsDeduped marks this image as sharing storage with another image
 func (im *ImageMetadata) MarkAsDeduped(sharedImageID string) {
 	im.IsDeduped = true
 	im.SharedImageID = sharedImageID
 	im.UpdatedAt = time.Now()
 }
+
+// Clone returns a deep copy of the metadata, so the copy can be changed without
+// affecting the original
+func (im *ImageMetadata) Clone() *ImageMetadata {
+	clone := *im
+	clone.Resolutions = slices.Clone(im.Resolutions)
+	clone.OriginalAliases = slices.Clone(im.OriginalAliases)
+	clone.ResolutionDimensions = maps.Clone(im.ResolutionDimensions)
+	clone.ResolutionFormats = maps.Clone(im.ResolutionFormats)
+	clone.DerivativeHashes = maps.Clone(im.DerivativeHashes)
+	clone.DerivativeSources = maps.Clone(im.DerivativeSources)
+	if im.FormatVariants != nil {
+		clone.FormatVariants = make(map[string][]string, len(im.FormatVariants))
+		for resolution, formats := range im.FormatVariants {
+			clone.FormatVariants[resolution] = slices.Clone(formats)
+		}
+	}
+	return &clone
+}
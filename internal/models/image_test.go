@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -52,6 +53,32 @@ func TestImageMetadata_HasResolution(t *testing.T) {
 	assert.False(t, metadata.HasResolution(""))
 }
 
+func TestImageMetadata_NearestResolution(t *testing.T) {
+	metadata := &ImageMetadata{Resolutions: []string{"thumbnail", "800x600:small", "1920x1080", "600x800"}}
+
+	tests := []struct {
+		name          string
+		width, height int
+		expected      string
+	}{
+		{name: "close in area and aspect", width: 1024, height: 768, expected: "800x600"},
+		{name: "aspect outweighs area", width: 1600, height: 900, expected: "1920x1080"},
+		{name: "portrait", width: 480, height: 640, expected: "600x800"},
+		{name: "small square", width: 100, height: 100, expected: "thumbnail"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nearest, found := metadata.NearestResolution(tt.width, tt.height)
+			assert.True(t, found)
+			assert.Equal(t, tt.expected, nearest)
+		})
+	}
+
+	_, found := (&ImageMetadata{}).NearestResolution(800, 600)
+	assert.False(t, found, "no stored resolutions")
+}
+
 func TestImageMetadata_AddResolution(t *testing.T) {
 	metadata := &ImageMetadata{
 		Resolutions: []string{"thumbnail"},
@@ -70,6 +97,29 @@ func TestImageMetadata_AddResolution(t *testing.T) {
 	assert.Equal(t, resolutionCount, len(metadata.Resolutions)) // Should not change
 }
 
+func TestImageMetadata_AddResolution_AliasCollision(t *testing.T) {
+	metadata := &ImageMetadata{
+		Resolutions: []string{"thumbnail", "800x600:small"},
+	}
+
+	// Same alias for different dimensions is rejected
+	err := metadata.AddResolution("400x300:small")
+	var validationErr ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "resolution", validationErr.Field)
+	assert.Equal(t, []string{"thumbnail", "800x600:small"}, metadata.Resolutions)
+
+	// Re-adding the identical aliased resolution is a no-op
+	assert.NoError(t, metadata.AddResolution("800x600:small"))
+	assert.Equal(t, []string{"thumbnail", "800x600:small"}, metadata.Resolutions)
+
+	// A different alias is accepted
+	assert.NoError(t, metadata.AddResolution("400x300:medium"))
+	assert.Contains(t, metadata.Resolutions, "400x300:medium")
+	assert.Equal(t, "400x300", metadata.FindStoredResolution("medium"))
+	assert.Equal(t, "800x600", metadata.FindStoredResolution("small"))
+}
+
 func TestImageMetadata_GetFileExtension(t *testing.T) {
 	tests := []struct {
 		filename string
@@ -112,6 +162,42 @@ func TestImageMetadata_GetStorageKey(t *testing.T) {
 	}
 }
 
+func TestImageMetadata_GetStorageKey_ConvertedDerivatives(t *testing.T) {
+	metadata := &ImageMetadata{
+		ID:       "test-uuid",
+		Filename: "scan.tif",
+		MimeType: "image/tiff",
+	}
+
+	assert.Equal(t, "images/test-uuid/original.tif", metadata.GetStorageKey("original"))
+	assert.Equal(t, "images/test-uuid/thumbnail.png", metadata.GetStorageKey("thumbnail"))
+	assert.Equal(t, "images/test-uuid/800x600.png", metadata.GetStorageKey("800x600"))
+
+	metadata.IsDeduped = true
+	metadata.SharedImageID = "shared-uuid"
+	assert.Equal(t, "images/shared-uuid/original.tif", metadata.GetActualStorageKey("original"))
+	assert.Equal(t, "images/shared-uuid/800x600.png", metadata.GetActualStorageKey("800x600"))
+}
+
+func TestImageMetadata_GetContentType(t *testing.T) {
+	jpeg := &ImageMetadata{MimeType: "image/jpeg"}
+	assert.Equal(t, "image/jpeg", jpeg.GetContentType("original"))
+	assert.Equal(t, "image/jpeg", jpeg.GetContentType("thumbnail"))
+
+	tiff := &ImageMetadata{MimeType: "image/tiff"}
+	assert.Equal(t, "image/tiff", tiff.GetContentType("original"))
+	assert.Equal(t, "image/png", tiff.GetContentType("thumbnail"))
+	assert.Equal(t, "image/png", tiff.GetContentType("800x600"))
+}
+
+func TestGetDerivativeMimeType(t *testing.T) {
+	assert.Equal(t, "image/jpeg", GetDerivativeMimeType("image/jpeg"))
+	assert.Equal(t, "image/png", GetDerivativeMimeType("image/png"))
+	assert.Equal(t, "image/gif", GetDerivativeMimeType("image/gif"))
+	assert.Equal(t, "image/webp", GetDerivativeMimeType("image/webp"))
+	assert.Equal(t, "image/png", GetDerivativeMimeType("image/tiff"))
+}
+
 func TestImageMetadata_ToInfoResponse(t *testing.T) {
 	metadata := &ImageMetadata{
 		ID:          "test-uuid",
@@ -312,6 +398,23 @@ func TestResolutionConfig_String(t *testing.T) {
 	assert.Equal(t, "800x600", config.String())
 }
 
+func TestCropRect_ResolutionName(t *testing.T) {
+	rect := CropRect{X: 10, Y: 20, Width: 300, Height: 200}
+	name := rect.ResolutionName()
+	assert.Equal(t, "crop-10-20-300x200", name)
+	assert.True(t, IsValidAlias(name))
+
+	metadata := &ImageMetadata{ID: "test-uuid", Filename: "test.jpg"}
+	assert.NoError(t, metadata.AddResolution(name))
+	metadata.SetResolutionDimensions(name, DimensionInfo{Width: 300, Height: 200})
+
+	assert.True(t, metadata.HasResolution(name))
+	assert.Equal(t, "images/test-uuid/crop-10-20-300x200.jpg", metadata.GetStorageKey(name))
+	dimensions, ok := metadata.GetResolutionDimensions(name)
+	assert.True(t, ok)
+	assert.Equal(t, DimensionInfo{Width: 300, Height: 200}, dimensions)
+}
+
 func TestResolutionConfig_IsSquare(t *testing.T) {
 	tests := []struct {
 		config   ResolutionConfig
@@ -360,6 +463,8 @@ func TestGetMimeTypeFromExtension(t *testing.T) {
 		{"test.GIF", "image/gif"},
 		{"test.webp", "image/webp"},
 		{"test.WEBP", "image/webp"},
+		{"test.tif", "image/tiff"},
+		{"test.TIFF", "image/tiff"},
 		{"test.bmp", ""},
 		{"test.pdf", ""},
 		{"test", ""},
@@ -382,6 +487,7 @@ func TestGetExtensionFromMimeType(t *testing.T) {
 		{"image/png", "png"},
 		{"image/gif", "gif"},
 		{"image/webp", "webp"},
+		{"image/tiff", "tiff"},
 		{"image/bmp", ""},
 		{"text/plain", ""},
 		{"application/pdf", ""},
@@ -451,6 +557,136 @@ func TestImageMetadata_GetActualStorageKey(t *testing.T) {
 	})
 }
 
+func TestImageMetadata_ServesOriginal(t *testing.T) {
+	metadata := &ImageMetadata{
+		ID:          "f47ac10b-58cc-4372-a567-0e02b2c3d479",
+		Filename:    "test.png",
+		MimeType:    "image/png",
+		Resolutions: []string{"800x600", "4000x3000:huge"},
+	}
+	metadata.SetServesOriginal("4000x3000:huge", true)
+
+	assert.Equal(t, []string{"4000x3000"}, metadata.OriginalAliases)
+	assert.True(t, metadata.ServesOriginal("huge"))
+	assert.True(t, metadata.ServesOriginal("4000x3000"))
+	assert.False(t, metadata.ServesOriginal("800x600"))
+	assert.False(t, metadata.ServesOriginal("original"))
+
+	assert.Equal(t, "images/f47ac10b-58cc-4372-a567-0e02b2c3d479/original.png", metadata.GetStorageKey("huge"))
+	assert.Equal(t, "images/f47ac10b-58cc-4372-a567-0e02b2c3d479/800x600.png", metadata.GetStorageKey("800x600"))
+
+	metadata.MarkAsDeduped("550e8400-e29b-41d4-a716-446655440000")
+	assert.Equal(t, "images/550e8400-e29b-41d4-a716-446655440000/original.png", metadata.GetActualStorageKey("huge"))
+
+	metadata.RemoveResolution("4000x3000:huge")
+	assert.Empty(t, metadata.OriginalAliases)
+	assert.False(t, metadata.ServesOriginal("4000x3000"))
+}
+
+func TestImageMetadata_FormatVariants(t *testing.T) {
+	metadata := &ImageMetadata{
+		ID:          "f47ac10b-58cc-4372-a567-0e02b2c3d479",
+		Filename:    "test.jpg",
+		MimeType:    "image/jpeg",
+		Resolutions: []string{"thumbnail", "800x600:small"},
+	}
+	metadata.SetFormatVariants("800x600:small", []string{"webp", "png"})
+
+	assert.True(t, metadata.HasFormatVariant("small", "webp"))
+	assert.True(t, metadata.HasFormatVariant("800x600", "png"))
+	assert.False(t, metadata.HasFormatVariant("thumbnail", "webp"))
+	assert.False(t, metadata.HasFormatVariant("original", "webp"))
+	assert.Equal(t, map[string][]string{"800x600:small": {"webp", "png"}}, metadata.AllFormatVariants())
+
+	assert.Equal(t, "images/f47ac10b-58cc-4372-a567-0e02b2c3d479/800x600.webp", metadata.GetVariantStorageKey("small", "webp"))
+	metadata.MarkAsDeduped("550e8400-e29b-41d4-a716-446655440000")
+	assert.Equal(t, "images/550e8400-e29b-41d4-a716-446655440000/800x600.png", metadata.GetVariantStorageKey("small", "png"))
+
+	metadata.RemoveResolution("800x600:small")
+	assert.Nil(t, metadata.FormatVariants)
+	assert.Nil(t, metadata.AllFormatVariants())
+}
+
+func TestImageMetadata_ResolutionFormats(t *testing.T) {
+	metadata := &ImageMetadata{
+		ID:          "f47ac10b-58cc-4372-a567-0e02b2c3d479",
+		Filename:    "test.jpg",
+		MimeType:    "image/jpeg",
+		Resolutions: []string{"thumbnail", "800x600:small"},
+	}
+	metadata.SetResolutionFormat("800x600:small", "png")
+	metadata.SetResolutionFormat("thumbnail", "jpeg")
+
+	assert.Equal(t, "png", metadata.GetResolutionFormat("small"))
+	assert.Equal(t, "", metadata.GetResolutionFormat("thumbnail"), "the derivative format is not recorded")
+	assert.Equal(t, map[string]string{"800x600:small": "png"}, metadata.AllResolutionFormats())
+
+	assert.Equal(t, "image/png", metadata.GetContentType("800x600"))
+	assert.Equal(t, "image/jpeg", metadata.GetContentType("thumbnail"))
+	assert.Equal(t, "image/jpeg", metadata.GetContentType("original"))
+	assert.Equal(t, "images/f47ac10b-58cc-4372-a567-0e02b2c3d479/800x600.png", metadata.GetStorageKey("small"))
+	assert.Equal(t, "images/f47ac10b-58cc-4372-a567-0e02b2c3d479/original.jpg", metadata.GetStorageKey("original"))
+	metadata.MarkAsDeduped("550e8400-e29b-41d4-a716-446655440000")
+	assert.Equal(t, "images/550e8400-e29b-41d4-a716-446655440000/800x600.png", metadata.GetActualStorageKey("small"))
+
+	metadata.RemoveResolution("800x600:small")
+	assert.Nil(t, metadata.ResolutionFormats)
+	assert.Nil(t, metadata.AllResolutionFormats())
+}
+
+func TestImageMetadata_DerivativeSources(t *testing.T) {
+	metadata := &ImageMetadata{
+		ID:          "f47ac10b-58cc-4372-a567-0e02b2c3d479",
+		Filename:    "test.jpg",
+		MimeType:    "image/jpeg",
+		Resolutions: []string{"thumbnail", "300x300:large", "100x100"},
+	}
+	metadata.SetDerivativeHash("thumbnail", "aaa")
+	metadata.SetDerivativeHash("100x100", "bbb")
+	metadata.SetDerivativeSource("300x300:large", "thumbnail")
+
+	assert.Equal(t, "thumbnail", metadata.FindDerivativeByHash("aaa"))
+	assert.Equal(t, "", metadata.FindDerivativeByHash("ccc"))
+	assert.Equal(t, "thumbnail", metadata.DerivativeSource("large"))
+	assert.Equal(t, "", metadata.DerivativeSource("thumbnail"))
+	assert.Equal(t, "images/f47ac10b-58cc-4372-a567-0e02b2c3d479/thumbnail.jpg", metadata.GetStorageKey("large"))
+	assert.True(t, metadata.SharesDerivativeFile("thumbnail"))
+	assert.True(t, metadata.SharesDerivativeFile("300x300"))
+	assert.False(t, metadata.SharesDerivativeFile("100x100"))
+
+	// The source's hash stays while its file is still served
+	metadata.RemoveResolution("thumbnail")
+	assert.Equal(t, "thumbnail", metadata.FindDerivativeByHash("aaa"))
+	assert.True(t, metadata.UsesDerivativeFile("thumbnail"))
+	assert.False(t, metadata.SharesDerivativeFile("300x300"))
+
+	metadata.RemoveResolution("300x300:large")
+	assert.Nil(t, metadata.DerivativeSources)
+	assert.Equal(t, map[string]string{"100x100": "bbb"}, metadata.DerivativeHashes)
+
+	// Pointing a resolution at itself clears its source
+	metadata.SetDerivativeSource("100x100", "100x100")
+	assert.Nil(t, metadata.DerivativeSources)
+}
+
+func TestImageMetadata_CloneDerivatives(t *testing.T) {
+	metadata := &ImageMetadata{
+		ID:          "f47ac10b-58cc-4372-a567-0e02b2c3d479",
+		Resolutions: []string{"thumbnail", "300x300:large"},
+	}
+	metadata.SetDerivativeHash("thumbnail", "aaa")
+	metadata.SetDerivativeSource("300x300:large", "thumbnail")
+
+	clone := metadata.Clone()
+	clone.SetDerivativeHash("300x300", "bbb")
+	clone.RemoveResolution("300x300:large")
+
+	assert.Equal(t, map[string]string{"thumbnail": "aaa"}, metadata.DerivativeHashes)
+	assert.Equal(t, map[string]string{"300x300": "thumbnail"}, metadata.DerivativeSources)
+	assert.Equal(t, "thumbnail", metadata.DerivativeSource("large"))
+	assert.Nil(t, clone.DerivativeSources)
+}
+
 func TestImageMetadata_MarkAsDeduped(t *testing.T) {
 	metadata := &ImageMetadata{
 		ID:        "f47ac10b-58cc-4372-a567-0e02b2c3d479",
@@ -465,6 +701,14 @@ func TestImageMetadata_MarkAsDeduped(t *testing.T) {
 	assert.True(t, time.Since(metadata.UpdatedAt) < time.Second)
 }
 
+func TestImageMetadata_UsesDeduplication(t *testing.T) {
+	hash := ImageHash{Algorithm: "SHA256", Value: "abc123", Size: 1024}
+
+	assert.True(t, (&ImageMetadata{Hash: hash}).UsesDeduplication())
+	assert.False(t, (&ImageMetadata{Hash: hash, DedupDisabled: true}).UsesDeduplication())
+	assert.False(t, (&ImageMetadata{}).UsesDeduplication())
+}
+
 func TestCustomErrorTypes(t *testing.T) {
 	t.Run("ValidationError", func(t *testing.T) {
 		err := ValidationError{
@@ -475,6 +719,15 @@ func TestCustomErrorTypes(t *testing.T) {
 		assert.Equal(t, expected, err.Error())
 	})
 
+	t.Run("MultiValidationError", func(t *testing.T) {
+		err := MultiValidationError{Errors: []ValidationError{
+			{Field: "filename", Message: "Filename is required"},
+			{Field: "resolutions", Message: "Invalid resolution format 'bogus'"},
+		}}
+		expected := "validation errors on 2 fields: 'filename': Filename is required; 'resolutions': Invalid resolution format 'bogus'"
+		assert.Equal(t, expected, err.Error())
+	})
+
 	t.Run("NotFoundError", func(t *testing.T) {
 		err := NotFoundError{
 			Resource: "image",
@@ -502,6 +755,15 @@ func TestCustomErrorTypes(t *testing.T) {
 		expected := "storage error during upload on S3: connection failed"
 		assert.Equal(t, expected, err.Error())
 	})
+
+	t.Run("CorruptMetadataError", func(t *testing.T) {
+		err := CorruptMetadataError{
+			ID:     "test-id",
+			Reason: "invalid JSON",
+		}
+		expected := "metadata for image 'test-id' is corrupt: invalid JSON"
+		assert.Equal(t, expected, err.Error())
+	})
 }
 
 func TestResponseStructures(t *testing.T) {
@@ -696,6 +958,13 @@ func TestResolutionAliases(t *testing.T) {
 			{"800x600", 800, 600, "", false},        // No alias should work
 			{":alias", 0, 0, "", true},              // No dimensions should fail
 			{"800x600:alias:extra", 0, 0, "", true}, // Multiple colons should fail
+			{"800x600:a/b", 0, 0, "", true},         // Slash would corrupt storage keys
+			{"800x600:..", 0, 0, "", true},          // Dots are not allowed
+			{"800x600:my alias", 0, 0, "", true},    // Inner whitespace is not allowed
+			{"800x600:café", 0, 0, "", true},        // Non-ASCII letters are not allowed
+			{"800x600:" + strings.Repeat("a", MaxAliasLength+1), 0, 0, "", true},
+			{"800x600:" + strings.Repeat("a", MaxAliasLength), 800, 600, strings.Repeat("a", MaxAliasLength), false},
+			{"800x600:Hero_Banner", 800, 600, "hero_banner", false}, // Aliases are lower-cased
 		}
 
 		for _, tc := range testCases {
@@ -1059,3 +1328,120 @@ func TestGetActualStorageKeyEdgeCases(t *testing.T) {
 		assert.Equal(t, "images/test-id/original.jpg", key)
 	})
 }
+
+func TestIsValidAlias(t *testing.T) {
+	assert.True(t, IsValidAlias("small"))
+	assert.True(t, IsValidAlias("hero-Banner_2x"))
+	assert.False(t, IsValidAlias(""))
+	assert.False(t, IsValidAlias("a/b"))
+	assert.False(t, IsValidAlias("a:b"))
+	assert.False(t, IsValidAlias("a b"))
+	assert.False(t, IsValidAlias(strings.Repeat("a", MaxAliasLength+1)))
+}
+
+func TestParseResolution_ReservedAliases(t *testing.T) {
+	for _, alias := range []string{"original", "thumbnail", "info", "archive", "keys", "verify", "Archive"} {
+		_, err := ParseResolution("800x600:" + alias)
+		if assert.Error(t, err, alias) {
+			assert.Contains(t, err.Error(), "reserved", alias)
+		}
+	}
+
+	_, err := ParseResolution("150x150:thumbnail.webp")
+	assert.Error(t, err)
+
+	assert.True(t, IsReservedAlias("thumbnail.webp"))
+	assert.True(t, IsReservedAlias("KEYS"))
+	assert.False(t, IsReservedAlias("thumbnails"))
+}
+
+func TestNormalizeResolution(t *testing.T) {
+	assert.Equal(t, "800x600:small", NormalizeResolution("800x600 : Small"))
+	assert.Equal(t, "800x600", NormalizeResolution("800x600:"))
+	assert.Equal(t, "800x600", NormalizeResolution("800x600"))
+	assert.Equal(t, "Small", NormalizeResolution("Small"))
+	assert.Equal(t, "800x600:a/b", NormalizeResolution("800x600:a/b"), "invalid resolutions are left to ParseResolution")
+}
+
+func TestImageMetadata_AliasLookupIgnoresCase(t *testing.T) {
+	metadata := &ImageMetadata{
+		Resolutions: []string{"800x600:small", "1024x768:Legacy"},
+	}
+
+	assert.True(t, metadata.HasResolution("Small"))
+	assert.Equal(t, "800x600", metadata.ResolveToDimensions("SMALL"))
+	assert.Equal(t, "1024x768", metadata.ResolveToDimensions("legacy"))
+	assert.Error(t, metadata.CheckAliasConflict("640x480:SMALL"))
+}
+
+func TestImageMetadata_ResolveToDimensions_InvalidAlias(t *testing.T) {
+	metadata := &ImageMetadata{
+		Resolutions: []string{"800x600:small", "thumbnail"},
+	}
+
+	assert.Equal(t, "800x600", metadata.ResolveToDimensions("small"))
+	assert.Equal(t, "../small", metadata.ResolveToDimensions("../small"))
+	assert.Equal(t, "800x600", metadata.FindStoredResolution("800x600"))
+}
+
+func TestImageMetadata_ResolutionDimensions(t *testing.T) {
+	metadata := NewImageMetadata("id", "photo.jpg", "image/jpeg", 1024, 1920, 1080)
+	assert.NoError(t, metadata.AddResolution("800x600:small"))
+	assert.NoError(t, metadata.AddResolution("thumbnail"))
+	assert.NoError(t, metadata.AddResolution("640x480"))
+	metadata.SetResolutionDimensions("800x600:small", DimensionInfo{Width: 800, Height: 600})
+	metadata.SetResolutionDimensions("thumbnail", DimensionInfo{Width: 150, Height: 150})
+
+	t.Run("lookup by dimensions or alias", func(t *testing.T) {
+		for _, resolution := range []string{"800x600", "small", "800x600:small"} {
+			info, ok := metadata.GetResolutionDimensions(resolution)
+			assert.True(t, ok, resolution)
+			assert.Equal(t, DimensionInfo{Width: 800, Height: 600}, info)
+		}
+	})
+
+	t.Run("original and legacy entries", func(t *testing.T) {
+		info, ok := metadata.GetResolutionDimensions("original")
+		assert.True(t, ok)
+		assert.Equal(t, DimensionInfo{Width: 1920, Height: 1080}, info)
+
+		// Not recorded: falls back to the requested size
+		info, ok = metadata.GetResolutionDimensions("640x480")
+		assert.True(t, ok)
+		assert.Equal(t, DimensionInfo{Width: 640, Height: 480}, info)
+
+		_, ok = metadata.GetResolutionDimensions("1024x768")
+		assert.False(t, ok)
+	})
+
+	t.Run("info response includes every resolution", func(t *testing.T) {
+		response := metadata.ToInfoResponse()
+		assert.Equal(t, map[string]DimensionInfo{
+			"original":      {Width: 1920, Height: 1080},
+			"800x600:small": {Width: 800, Height: 600},
+			"thumbnail":     {Width: 150, Height: 150},
+			"640x480":       {Width: 640, Height: 480},
+		}, response.ResolutionDimensions)
+	})
+
+	t.Run("remove resolution prunes its size", func(t *testing.T) {
+		metadata.RemoveResolution("800x600:small")
+		assert.NotContains(t, metadata.ResolutionDimensions, "800x600")
+		assert.Contains(t, metadata.ResolutionDimensions, "thumbnail")
+	})
+}
+
+func TestJoinValidationErrors(t *testing.T) {
+	assert.NoError(t, JoinValidationErrors(nil))
+
+	single := ValidationError{Field: "filename", Message: "Filename is required"}
+	assert.Equal(t, single, JoinValidationErrors([]ValidationError{single}))
+
+	second := ValidationError{Field: "resolutions", Message: "Invalid resolution format 'bogus'"}
+	err := JoinValidationErrors([]ValidationError{single, second})
+	assert.Equal(t, MultiValidationError{Errors: []ValidationError{single, second}}, err)
+
+	var first ValidationError
+	assert.ErrorAs(t, err, &first)
+	assert.Equal(t, single, first)
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 // StatisticsService defines the interface for statistics operations
 type StatisticsService interface {
@@ -9,8 +12,24 @@ type StatisticsService interface {
 	GetStorageStatistics() (*StorageStatistics, error)
 	GetDeduplicationStatistics() (*DeduplicationStatistics, error)
 	RefreshStatistics() error
+	StartBackgroundRefresh(ctx context.Context)
+	GetUploadHistogram(days int) (*UploadHistogram, error)
+	GetHashInfo(hash, namespace string) (*HashInfo, error)
+	GetPopularImages(limit int) (*PopularImages, error)
 }
 
+// Upload histogram window bounds, in days
+const (
+	DefaultUploadHistogramDays = 30
+	MaxUploadHistogramDays     = 365
+)
+
+// Popular images list bounds
+const (
+	DefaultPopularImagesLimit = 10
+	MaxPopularImagesLimit     = 100
+)
+
 // StatisticsOptions represents options for statistics retrieval
 type StatisticsOptions struct {
 	IncludeDetailedBreakdown  bool       `json:"include_detailed_breakdown"`
@@ -38,6 +57,15 @@ type ImageStatistics struct {
 	ImagesCreatedMonth int64            `json:"images_created_month"`
 	TotalResolutions   int64            `json:"total_resolutions"`
 	TopResolutions     []ResolutionStat `json:"top_resolutions"`
+
+	AverageDimensionsByFormat map[string]AverageDimensions `json:"average_dimensions_by_format"`
+	AverageSizeByFormat       map[string]float64           `json:"average_size_by_format_bytes"`
+}
+
+// AverageDimensions represents the mean width and height of a group of images
+type AverageDimensions struct {
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
 }
 
 // StorageStatistics represents storage usage statistics
@@ -78,6 +106,20 @@ type ResolutionStat struct {
 	Count      int64  `json:"count"`
 }
 
+// UploadHistogram represents per-day upload counts over a trailing window
+type UploadHistogram struct {
+	Days   int                `json:"days"`
+	Start  time.Time          `json:"start"`
+	End    time.Time          `json:"end"`
+	Counts []DailyUploadCount `json:"counts"`
+}
+
+// DailyUploadCount represents the number of images uploaded on a single day
+type DailyUploadCount struct {
+	Date  string `json:"date"`
+	Count int64  `json:"count"`
+}
+
 // HashStat represents statistics for a hash
 type HashStat struct {
 	Hash           string `json:"hash"`
@@ -86,6 +128,39 @@ type HashStat struct {
 	TotalSizeBytes int64  `json:"total_size_bytes"`
 }
 
+// HashInfo describes the logical images sharing one content hash
+type HashInfo struct {
+	*DeduplicationInfo
+	BytesSaved int64 `json:"bytes_saved"`
+}
+
+// AccessCounts represents how often an image was downloaded, in total and per resolution
+type AccessCounts struct {
+	Total        int64            `json:"total"`
+	ByResolution map[string]int64 `json:"by_resolution"`
+}
+
+// Add records count more downloads of resolution
+func (a *AccessCounts) Add(resolution string, count int64) {
+	if a.ByResolution == nil {
+		a.ByResolution = make(map[string]int64)
+	}
+	a.ByResolution[resolution] += count
+	a.Total += count
+}
+
+// PopularImage represents the download counters of one image
+type PopularImage struct {
+	ImageID string `json:"image_id"`
+	AccessCounts
+}
+
+// PopularImages lists the most downloaded images, most downloaded first
+type PopularImages struct {
+	Limit  int            `json:"limit"`
+	Images []PopularImage `json:"images"`
+}
+
 // TimeRange represents a time range for filtering statistics
 type TimeRange struct {
 	Start time.Time `json:"start"`
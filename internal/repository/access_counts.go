@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"sort"
+
+	"resizr/internal/models"
+)
+
+// accessCountPrefix prefixes the keys holding the download counters of an image
+const accessCountPrefix = "image:access:"
+
+// popularImagesKey is the Redis sorted set ranking image IDs by total downloads
+const popularImagesKey = "image:access:popular"
+
+// getAccessCountKey generates the counters key of an image
+func getAccessCountKey(id string) string {
+	return accessCountPrefix + id
+}
+
+// rankPopularImages orders images by total downloads, most downloaded first with ties
+// broken by ID, and keeps at most limit of them
+func rankPopularImages(images []models.PopularImage, limit int) []models.PopularImage {
+	sort.Slice(images, func(i, j int) bool {
+		if images[i].Total != images[j].Total {
+			return images[i].Total > images[j].Total
+		}
+		return images[i].ImageID < images[j].ImageID
+	})
+	if limit >= 0 && len(images) > limit {
+		images = images[:limit]
+	}
+	return images
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"resizr/internal/models"
+)
+
+// auditLogKey is the Redis sorted set holding audit entries scored by their time
+const auditLogKey = "audit:log"
+
+// auditEntryPrefix prefixes the BadgerDB keys of audit entries; keys sort by time
+const auditEntryPrefix = "audit:entry:"
+
+// AuditRepository is implemented by repositories that can persist the audit trail
+type AuditRepository interface {
+	// AppendAuditEntry stores an entry and drops entries older than retention (0 keeps them)
+	AppendAuditEntry(ctx context.Context, entry *models.AuditEntry, retention time.Duration) error
+
+	// ListAuditEntries returns up to limit entries, newest first
+	ListAuditEntries(ctx context.Context, limit int) ([]models.AuditEntry, error)
+}
+
+// auditSequence tells apart entries recorded in the same nanosecond
+var auditSequence atomic.Uint64
+
+// getAuditEntryKey generates a key that sorts entries chronologically
+func getAuditEntryKey(entry *models.AuditEntry) string {
+	return fmt.Sprintf("%s%020d:%06d", auditEntryPrefix, entry.Timestamp.UnixNano(), auditSequence.Add(1)%1000000)
+}
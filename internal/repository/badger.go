@@ -236,12 +236,37 @@ func (b *BadgerRepository) Health(ctx context.Context) error {
 	return nil
 }
 
+// CacheHealth checks the cache by writing, reading back and removing a probe entry
+func (b *BadgerRepository) CacheHealth(ctx context.Context) error {
+	testKey := "cache:health:" + fmt.Sprintf("%d", time.Now().UnixNano())
+
+	if err := b.Set(ctx, testKey, "ok", 10*time.Second); err != nil {
+		return fmt.Errorf("BadgerDB cache write failed: %w", err)
+	}
+	if _, err := b.Get(ctx, testKey); err != nil {
+		return fmt.Errorf("BadgerDB cache read failed: %w", err)
+	}
+
+	if err := b.Delete(ctx, testKey); err != nil {
+		logger.WarnWithContext(ctx, "Failed to cleanup cache health check key", zap.Error(err))
+		// Not a critical error
+	}
+
+	return nil
+}
+
 // Close closes the cache connection
 func (b *BadgerRepository) Close() error {
 	logger.Info("Closing BadgerDB cache repository")
 	return b.db.Close()
 }
 
+// ResetCacheStats zeroes the cache hit and miss counters
+func (b *BadgerRepository) ResetCacheStats() {
+	atomic.StoreInt64(&b.cacheHits, 0)
+	atomic.StoreInt64(&b.cacheMisses, 0)
+}
+
 // GetStats retrieves cache statistics
 func (b *BadgerRepository) GetStats(ctx context.Context) (*CacheStats, error) {
 	lsm, vlog := b.db.Size()
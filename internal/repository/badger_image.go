@@ -20,12 +20,17 @@ import (
 // in local BadgerDB files with no external dependencies.
 type BadgerImageRepository struct {
 	*BadgerRepository // Embed for Cache functionality
+
+	// filenameIndex maintains the filename -> image IDs lists on Store/Delete
+	filenameIndex bool
 }
 
 // Ensure BadgerImageRepository implements all interfaces
 var _ ImageRepository = (*BadgerImageRepository)(nil)
 var _ CacheRepository = (*BadgerImageRepository)(nil)
 var _ DeduplicationRepository = (*BadgerImageRepository)(nil)
+var _ CacheStatsResetter = (*BadgerImageRepository)(nil)
+var _ AuditRepository = (*BadgerImageRepository)(nil)
 
 // NewBadgerImageRepository creates a new BadgerDB-based ImageRepository
 func NewBadgerImageRepository(cfg *CacheConfig) (*BadgerImageRepository, error) {
@@ -59,8 +64,23 @@ func (b *BadgerImageRepository) Store(ctx context.Context, img *models.ImageMeta
 		return fmt.Errorf("failed to marshal metadata: %w", err)
 	}
 
-	// Store metadata (no TTL for metadata)
+	// Store metadata (no TTL for metadata), moving the image between filename index
+	// entries in the same transaction when it was renamed
 	err = b.db.Update(func(txn *badger.Txn) error {
+		if b.filenameIndex {
+			previous, err := b.storedFilename(txn, key)
+			if err != nil {
+				return err
+			}
+			if previous != "" && previous != img.Filename {
+				if err := b.updateFilenameIndex(txn, previous, img.ID, removeIndexedID); err != nil {
+					return err
+				}
+			}
+			if err := b.updateFilenameIndex(txn, img.Filename, img.ID, addIndexedID); err != nil {
+				return err
+			}
+		}
 		return txn.Set([]byte(key), data)
 	})
 
@@ -92,7 +112,10 @@ func (b *BadgerImageRepository) Get(ctx context.Context, id string) (*models.Ima
 		}
 
 		return item.Value(func(val []byte) error {
-			return json.Unmarshal(val, &metadata)
+			if err := json.Unmarshal(val, &metadata); err != nil {
+				return models.CorruptMetadataError{ID: id, Reason: err.Error()}
+			}
+			return nil
 		})
 	})
 
@@ -105,6 +128,12 @@ func (b *BadgerImageRepository) Get(ctx context.Context, id string) (*models.Ima
 				ID:       id,
 			}
 		}
+		if corruptErr, ok := err.(models.CorruptMetadataError); ok {
+			logger.ErrorWithContext(ctx, "Image metadata is corrupt",
+				zap.String("image_id", id),
+				zap.String("reason", corruptErr.Reason))
+			return nil, corruptErr
+		}
 		logger.ErrorWithContext(ctx, "Failed to get image metadata",
 			zap.String("image_id", id),
 			zap.Error(err))
@@ -171,8 +200,22 @@ func (b *BadgerImageRepository) Delete(ctx context.Context, id string) error {
 		// Continue with metadata deletion even if cache cleanup fails
 	}
 
-	// Delete metadata
+	// Delete metadata along with its filename index entry
 	err = b.db.Update(func(txn *badger.Txn) error {
+		if b.filenameIndex {
+			filename, err := b.storedFilename(txn, key)
+			if err != nil {
+				return err
+			}
+			if filename != "" {
+				if err := b.updateFilenameIndex(txn, filename, id, removeIndexedID); err != nil {
+					return err
+				}
+			}
+		}
+		if err := txn.Delete([]byte(getAccessCountKey(id))); err != nil {
+			return err
+		}
 		return txn.Delete([]byte(key))
 	})
 
@@ -208,6 +251,15 @@ func (b *BadgerImageRepository) Exists(_ctx context.Context, id string) (bool, e
 	return true, nil
 }
 
+// Count returns the number of stored images
+func (b *BadgerImageRepository) Count(ctx context.Context) (int64, error) {
+	count, err := b.countImages(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count images: %w", err)
+	}
+	return count, nil
+}
+
 // List retrieves multiple image metadata with pagination
 func (b *BadgerImageRepository) List(ctx context.Context, offset, limit int) ([]*models.ImageMetadata, error) {
 	logger.DebugWithContext(ctx, "Listing images",
@@ -261,14 +313,18 @@ func (b *BadgerImageRepository) List(ctx context.Context, offset, limit int) ([]
 			err = item.Value(func(val []byte) error {
 				var metadata models.ImageMetadata
 				if err := json.Unmarshal(val, &metadata); err != nil {
-					return err
+					return models.CorruptMetadataError{ID: id, Reason: err.Error()}
 				}
 				images = append(images, &metadata)
 				return nil
 			})
 
+			if corruptErr, ok := err.(models.CorruptMetadataError); ok {
+				skipCorruptRecord(ctx, corruptErr)
+				continue
+			}
 			if err != nil {
-				logger.WarnWithContext(ctx, "Failed to unmarshal metadata",
+				logger.WarnWithContext(ctx, "Failed to read metadata",
 					zap.String("image_id", id),
 					zap.Error(err))
 				continue
@@ -290,6 +346,156 @@ func (b *BadgerImageRepository) List(ctx context.Context, offset, limit int) ([]
 	return images, nil
 }
 
+// ListByDedupStatus retrieves a page of the images matching a dedup filter. Every
+// record is read to count the images per status.
+func (b *BadgerImageRepository) ListByDedupStatus(ctx context.Context, filter string, offset, limit int) ([]*models.ImageMetadata, models.DedupStatusCounts, error) {
+	logger.DebugWithContext(ctx, "Listing images by dedup status",
+		zap.String("filter", filter),
+		zap.Int("offset", offset),
+		zap.Int("limit", limit))
+
+	var counts models.DedupStatusCounts
+	images := []*models.ImageMetadata{}
+	prefix := []byte("image:metadata:")
+
+	err := b.db.View(func(txn *badger.Txn) error {
+		iter := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer iter.Close()
+
+		matched := 0
+		for iter.Seek(prefix); iter.ValidForPrefix(prefix); iter.Next() {
+			id := b.extractIDFromMetadataKey(string(iter.Item().Key()))
+			if id == "" {
+				continue
+			}
+
+			var metadata models.ImageMetadata
+			err := iter.Item().Value(func(val []byte) error {
+				if err := json.Unmarshal(val, &metadata); err != nil {
+					return models.CorruptMetadataError{ID: id, Reason: err.Error()}
+				}
+				return nil
+			})
+			if corruptErr, ok := err.(models.CorruptMetadataError); ok {
+				skipCorruptRecord(ctx, corruptErr)
+				continue
+			}
+			if err != nil {
+				logger.WarnWithContext(ctx, "Failed to read metadata",
+					zap.String("image_id", id),
+					zap.Error(err))
+				continue
+			}
+
+			counts.Add(&metadata)
+			if !metadata.MatchesDedupFilter(filter) {
+				continue
+			}
+			if matched >= offset && matched < offset+limit {
+				images = append(images, &metadata)
+			}
+			matched++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, counts, fmt.Errorf("failed to list images: %w", err)
+	}
+
+	return images, counts, nil
+}
+
+// FindCorrupt returns the metadata records that cannot be decoded
+func (b *BadgerImageRepository) FindCorrupt(ctx context.Context) ([]models.CorruptRecord, error) {
+	var records []models.CorruptRecord
+	prefix := "image:metadata:"
+
+	err := b.db.View(func(txn *badger.Txn) error {
+		iter := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer iter.Close()
+
+		for iter.Seek([]byte(prefix)); iter.ValidForPrefix([]byte(prefix)); iter.Next() {
+			item := iter.Item()
+			id := b.extractIDFromMetadataKey(string(item.Key()))
+
+			err := item.Value(func(val []byte) error {
+				var metadata models.ImageMetadata
+				if err := json.Unmarshal(val, &metadata); err != nil {
+					records = append(records, models.CorruptRecord{ID: id, Reason: err.Error()})
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan metadata: %w", err)
+	}
+
+	logger.DebugWithContext(ctx, "Corrupt metadata scan completed",
+		zap.Int("corrupt_records", len(records)))
+
+	return records, nil
+}
+
+// DeleteCorrupt removes a metadata record that cannot be decoded. Cached URLs are
+// cleaned up; the filename index entry cannot be located without a readable record.
+func (b *BadgerImageRepository) DeleteCorrupt(ctx context.Context, id string) error {
+	key := b.getMetadataKey(id)
+
+	err := b.db.Update(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(key))
+		if err != nil {
+			return err
+		}
+
+		corrupt := false
+		if err := item.Value(func(val []byte) error {
+			var metadata models.ImageMetadata
+			corrupt = json.Unmarshal(val, &metadata) != nil
+			return nil
+		}); err != nil {
+			return err
+		}
+		if !corrupt {
+			return models.ValidationError{
+				Field:   "record",
+				Message: "metadata record is not corrupt",
+			}
+		}
+
+		return txn.Delete([]byte(key))
+	})
+
+	if err != nil {
+		if err == badger.ErrKeyNotFound {
+			return models.NotFoundError{
+				Resource: "image",
+				ID:       id,
+			}
+		}
+		if _, ok := err.(models.ValidationError); ok {
+			return err
+		}
+		return fmt.Errorf("failed to delete corrupt metadata: %w", err)
+	}
+
+	if err := b.DeleteAllCachedURLs(ctx, id); err != nil {
+		logger.WarnWithContext(ctx, "Failed to cleanup cached URLs of corrupt record",
+			zap.String("image_id", id),
+			zap.Error(err))
+	}
+
+	logger.InfoWithContext(ctx, "Corrupt metadata record deleted",
+		zap.String("image_id", id))
+
+	return nil
+}
+
 // UpdateResolutions updates the resolutions list for an image
 func (b *BadgerImageRepository) UpdateResolutions(ctx context.Context, id string, resolutions []string) error {
 	logger.DebugWithContext(ctx, "Updating image resolutions",
@@ -335,6 +541,7 @@ func (b *BadgerImageRepository) GetStats(ctx context.Context) (*RepositoryStats,
 		StorageUsed: lsm + vlog,
 		Connections: ConnectionStats{
 			Active:  1, // BadgerDB is embedded
+			Total:   1,
 			MaxOpen: 1,
 		},
 		KeyCounts: map[string]int64{
@@ -383,8 +590,300 @@ func (b *BadgerImageRepository) DeleteCache(ctx context.Context, key string) err
 	return b.BadgerRepository.Delete(ctx, key)
 }
 
+// CacheHealth checks the cache layer
+func (b *BadgerImageRepository) CacheHealth(ctx context.Context) error {
+	return b.BadgerRepository.CacheHealth(ctx)
+}
+
 // Helper methods for metadata operations
 
+// EnableFilenameIndex makes Store and Delete maintain the filename index
+func (b *BadgerImageRepository) EnableFilenameIndex() {
+	b.filenameIndex = true
+}
+
+// FindByFilename returns the IDs of images stored under the original filename
+func (b *BadgerImageRepository) FindByFilename(ctx context.Context, filename string) ([]string, error) {
+	var ids []string
+	err := b.db.View(func(txn *badger.Txn) error {
+		var err error
+		ids, err = b.indexedIDs(txn, filename)
+		return err
+	})
+	if err != nil {
+		logger.ErrorWithContext(ctx, "Failed to look up filename index",
+			zap.String("filename", filename),
+			zap.Error(err))
+		return nil, fmt.Errorf("failed to look up filename: %w", err)
+	}
+
+	return ids, nil
+}
+
+// IncrementAccessCounts adds per-resolution download counts to the counters of an
+// image in one transaction
+func (b *BadgerImageRepository) IncrementAccessCounts(ctx context.Context, id string, counts map[string]int64) error {
+	err := b.db.Update(func(txn *badger.Txn) error {
+		if _, err := txn.Get([]byte(b.getMetadataKey(id))); err == badger.ErrKeyNotFound {
+			return models.NotFoundError{
+				Resource: "image",
+				ID:       id,
+			}
+		} else if err != nil {
+			return err
+		}
+
+		stored, err := b.storedAccessCounts(txn, id)
+		if err != nil {
+			return err
+		}
+		for resolution, count := range counts {
+			stored.Add(resolution, count)
+		}
+
+		data, err := json.Marshal(stored)
+		if err != nil {
+			return err
+		}
+		return txn.Set([]byte(getAccessCountKey(id)), data)
+	})
+
+	if _, ok := err.(models.NotFoundError); ok {
+		return err
+	}
+	if err != nil {
+		logger.ErrorWithContext(ctx, "Failed to increment access counts",
+			zap.String("image_id", id),
+			zap.Error(err))
+		return fmt.Errorf("failed to increment access counts: %w", err)
+	}
+
+	return nil
+}
+
+// GetAccessCounts retrieves the download counters of an image
+func (b *BadgerImageRepository) GetAccessCounts(ctx context.Context, id string) (*models.AccessCounts, error) {
+	var counts *models.AccessCounts
+	err := b.db.View(func(txn *badger.Txn) error {
+		var err error
+		counts, err = b.storedAccessCounts(txn, id)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get access counts: %w", err)
+	}
+
+	return counts, nil
+}
+
+// GetPopularImages returns up to limit images with the most downloads, most downloaded first
+func (b *BadgerImageRepository) GetPopularImages(ctx context.Context, limit int) ([]models.PopularImage, error) {
+	images := []models.PopularImage{}
+	prefix := []byte(accessCountPrefix)
+
+	err := b.db.View(func(txn *badger.Txn) error {
+		iter := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer iter.Close()
+
+		for iter.Seek(prefix); iter.ValidForPrefix(prefix); iter.Next() {
+			image := models.PopularImage{ImageID: strings.TrimPrefix(string(iter.Item().Key()), accessCountPrefix)}
+			if err := iter.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &image.AccessCounts)
+			}); err != nil {
+				logger.WarnWithContext(ctx, "Skipping unreadable access counters",
+					zap.String("image_id", image.ImageID),
+					zap.Error(err))
+				continue
+			}
+			images = append(images, image)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get popular images: %w", err)
+	}
+
+	return rankPopularImages(images, limit), nil
+}
+
+// TouchLastAccessed moves the LastAccessedAt of an image's metadata forward to at
+func (b *BadgerImageRepository) TouchLastAccessed(ctx context.Context, id string, at time.Time) error {
+	key := []byte(b.getMetadataKey(id))
+
+	err := b.db.Update(func(txn *badger.Txn) error {
+		item, err := txn.Get(key)
+		if err == badger.ErrKeyNotFound {
+			return models.NotFoundError{
+				Resource: "image",
+				ID:       id,
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		var metadata models.ImageMetadata
+		if err := item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &metadata)
+		}); err != nil {
+			return models.CorruptMetadataError{ID: id, Reason: err.Error()}
+		}
+		if !at.After(metadata.LastAccessedAt) {
+			return nil
+		}
+
+		metadata.LastAccessedAt = at.UTC()
+		data, err := json.Marshal(&metadata)
+		if err != nil {
+			return err
+		}
+		return txn.Set(key, data)
+	})
+
+	switch err.(type) {
+	case nil:
+		return nil
+	case models.NotFoundError, models.CorruptMetadataError:
+		return err
+	}
+	logger.ErrorWithContext(ctx, "Failed to update last accessed time",
+		zap.String("image_id", id),
+		zap.Error(err))
+	return fmt.Errorf("failed to update last accessed time: %w", err)
+}
+
+// AppendAuditEntry stores an entry under a time-ordered key; retention becomes the
+// key's TTL, so BadgerDB drops expired entries itself
+func (b *BadgerImageRepository) AppendAuditEntry(ctx context.Context, entry *models.AuditEntry, retention time.Duration) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+
+	err = b.db.Update(func(txn *badger.Txn) error {
+		e := badger.NewEntry([]byte(getAuditEntryKey(entry)), data)
+		if retention > 0 {
+			e = e.WithTTL(retention)
+		}
+		return txn.SetEntry(e)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to append audit entry: %w", err)
+	}
+	return nil
+}
+
+// ListAuditEntries returns up to limit audit entries, newest first
+func (b *BadgerImageRepository) ListAuditEntries(ctx context.Context, limit int) ([]models.AuditEntry, error) {
+	entries := []models.AuditEntry{}
+	if limit <= 0 {
+		return entries, nil
+	}
+	prefix := []byte(auditEntryPrefix)
+
+	err := b.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Reverse = true
+		iter := txn.NewIterator(opts)
+		defer iter.Close()
+
+		// Reverse iteration starts at the last key not after the seek key
+		for iter.Seek(append(prefix, 0xFF)); iter.ValidForPrefix(prefix) && len(entries) < limit; iter.Next() {
+			var entry models.AuditEntry
+			if err := iter.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &entry)
+			}); err != nil {
+				logger.WarnWithContext(ctx, "Skipping unreadable audit entry",
+					zap.String("key", string(iter.Item().Key())),
+					zap.Error(err))
+				continue
+			}
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+// storedAccessCounts returns the counters of an image, empty when it was never downloaded
+func (b *BadgerImageRepository) storedAccessCounts(txn *badger.Txn, id string) (*models.AccessCounts, error) {
+	counts := &models.AccessCounts{ByResolution: map[string]int64{}}
+
+	item, err := txn.Get([]byte(getAccessCountKey(id)))
+	if err == badger.ErrKeyNotFound {
+		return counts, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = item.Value(func(val []byte) error {
+		return json.Unmarshal(val, counts)
+	})
+	return counts, err
+}
+
+// storedFilename returns the filename of the metadata stored at key, or "" when there is none
+func (b *BadgerImageRepository) storedFilename(txn *badger.Txn, key string) (string, error) {
+	item, err := txn.Get([]byte(key))
+	if err == badger.ErrKeyNotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var stored models.ImageMetadata
+	if err := item.Value(func(val []byte) error {
+		return json.Unmarshal(val, &stored)
+	}); err != nil {
+		return "", err
+	}
+	return stored.Filename, nil
+}
+
+// indexedIDs returns the sorted image IDs indexed under filename
+func (b *BadgerImageRepository) indexedIDs(txn *badger.Txn, filename string) ([]string, error) {
+	item, err := txn.Get([]byte(getFilenameKey(filename)))
+	if err == badger.ErrKeyNotFound {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	err = item.Value(func(val []byte) error {
+		return json.Unmarshal(val, &ids)
+	})
+	return ids, err
+}
+
+// updateFilenameIndex applies change to the IDs indexed under filename, dropping the
+// entry once no image uses the filename anymore
+func (b *BadgerImageRepository) updateFilenameIndex(txn *badger.Txn, filename, id string, change func([]string, string) []string) error {
+	ids, err := b.indexedIDs(txn, filename)
+	if err != nil {
+		return err
+	}
+
+	ids = change(ids, id)
+	key := []byte(getFilenameKey(filename))
+	if len(ids) == 0 {
+		return txn.Delete(key)
+	}
+
+	data, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+	return txn.Set(key, data)
+}
+
 // getMetadataKey generates BadgerDB key for image metadata
 func (b *BadgerImageRepository) getMetadataKey(id string) string {
 	return fmt.Sprintf("image:metadata:%s", id)
@@ -652,6 +1151,45 @@ func (b *BadgerImageRepository) GetImageCountByFormat(ctx context.Context) (map[
 	return formatCounts, err
 }
 
+// getFormatAverages computes average dimensions and size per image format
+func (b *BadgerImageRepository) getFormatAverages(ctx context.Context) (map[string]models.AverageDimensions, map[string]float64, error) {
+	averager := newFormatAverager()
+	prefix := "image:metadata:"
+
+	err := b.db.View(func(txn *badger.Txn) error {
+		iter := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer iter.Close()
+
+		for iter.Seek([]byte(prefix)); iter.ValidForPrefix([]byte(prefix)); iter.Next() {
+			item := iter.Item()
+
+			err := item.Value(func(val []byte) error {
+				var metadata models.ImageMetadata
+				if err := json.Unmarshal(val, &metadata); err != nil {
+					return err
+				}
+
+				averager.add(metadata.MimeType, metadata.Width, metadata.Height, metadata.Size)
+				return nil
+			})
+
+			if err != nil {
+				logger.WarnWithContext(ctx, "Failed to unmarshal metadata during format averages",
+					zap.String("key", string(item.Key())),
+					zap.Error(err))
+				continue
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	averageDimensions, averageSizes := averager.averages()
+	return averageDimensions, averageSizes, nil
+}
+
 // GetImageStatistics retrieves detailed image statistics
 func (b *BadgerImageRepository) GetImageStatistics(ctx context.Context) (*models.ImageStatistics, error) {
 	// Get total count
@@ -690,15 +1228,23 @@ func (b *BadgerImageRepository) GetImageStatistics(ctx context.Context) (*models
 	imagesWeek, _ := b.GetImagesByTimeRange(ctx, weekStart, now)
 	imagesMonth, _ := b.GetImagesByTimeRange(ctx, monthStart, now)
 
+	// Get per-format averages
+	averageDimensions, averageSizes, err := b.getFormatAverages(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	stats := &models.ImageStatistics{
-		TotalImages:        totalImages,
-		ImagesByFormat:     formatCounts,
-		ResolutionCounts:   resolutionCounts,
-		TopResolutions:     resolutionStats,
-		TotalResolutions:   totalResolutions,
-		ImagesCreatedToday: imagesToday,
-		ImagesCreatedWeek:  imagesWeek,
-		ImagesCreatedMonth: imagesMonth,
+		TotalImages:               totalImages,
+		ImagesByFormat:            formatCounts,
+		ResolutionCounts:          resolutionCounts,
+		TopResolutions:            resolutionStats,
+		TotalResolutions:          totalResolutions,
+		ImagesCreatedToday:        imagesToday,
+		ImagesCreatedWeek:         imagesWeek,
+		ImagesCreatedMonth:        imagesMonth,
+		AverageDimensionsByFormat: averageDimensions,
+		AverageSizeByFormat:       averageSizes,
 	}
 
 	return stats, nil
@@ -838,6 +1384,44 @@ func (b *BadgerImageRepository) GetImagesByTimeRange(ctx context.Context, start,
 	return count, err
 }
 
+// GetUploadCountsByDay returns per-day upload counts for the given number of days starting at start
+func (b *BadgerImageRepository) GetUploadCountsByDay(ctx context.Context, start time.Time, days int) ([]models.DailyUploadCount, error) {
+	buckets := newDailyUploadBuckets(start, days)
+	prefix := "image:metadata:"
+
+	err := b.db.View(func(txn *badger.Txn) error {
+		iter := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer iter.Close()
+
+		for iter.Seek([]byte(prefix)); iter.ValidForPrefix([]byte(prefix)); iter.Next() {
+			item := iter.Item()
+
+			err := item.Value(func(val []byte) error {
+				var metadata models.ImageMetadata
+				if err := json.Unmarshal(val, &metadata); err != nil {
+					return err
+				}
+
+				buckets.add(metadata.CreatedAt)
+				return nil
+			})
+
+			if err != nil {
+				logger.WarnWithContext(ctx, "Failed to unmarshal metadata during upload histogram",
+					zap.String("key", string(item.Key())),
+					zap.Error(err))
+				continue
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return buckets.result(), nil
+}
+
 // GetStorageUsageByResolution returns storage usage per resolution
 func (b *BadgerImageRepository) GetStorageUsageByResolution(ctx context.Context) (map[string]int64, error) {
 	storageByResolution := make(map[string]int64)
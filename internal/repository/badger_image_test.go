@@ -0,0 +1,416 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"resizr/internal/models"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestBadgerImageRepository(t *testing.T) *BadgerImageRepository {
+	tempDir, err := os.MkdirTemp("", "badger_image_test")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(tempDir) })
+
+	repo, err := NewBadgerImageRepository(&CacheConfig{
+		Type:      CacheTypeBadger,
+		Directory: tempDir,
+		TTL:       5 * time.Minute,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+
+	return repo
+}
+
+func TestBadgerImageRepository_GetImageStatistics_FormatAverages(t *testing.T) {
+	repo := newTestBadgerImageRepository(t)
+	ctx := context.Background()
+
+	images := []*models.ImageMetadata{
+		{ID: "a1b2c3d4-0000-4000-8000-000000000001", Filename: "a.jpg", MimeType: "image/jpeg", Size: 1000, Width: 800, Height: 600},
+		{ID: "a1b2c3d4-0000-4000-8000-000000000002", Filename: "b.jpg", MimeType: "image/jpeg", Size: 3000, Width: 400, Height: 200},
+		{ID: "a1b2c3d4-0000-4000-8000-000000000003", Filename: "c.png", MimeType: "image/png", Size: 500, Width: 100, Height: 50},
+	}
+	for _, img := range images {
+		img.CreatedAt = time.Now()
+		img.UpdatedAt = img.CreatedAt
+		require.NoError(t, repo.Store(ctx, img))
+	}
+
+	stats, err := repo.GetImageStatistics(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(3), stats.TotalImages)
+	assert.Equal(t, models.AverageDimensions{Width: 600, Height: 400}, stats.AverageDimensionsByFormat["jpeg"])
+	assert.Equal(t, models.AverageDimensions{Width: 100, Height: 50}, stats.AverageDimensionsByFormat["png"])
+	assert.Equal(t, 2000.0, stats.AverageSizeByFormat["jpeg"])
+	assert.Equal(t, 500.0, stats.AverageSizeByFormat["png"])
+}
+
+func TestBadgerImageRepository_GetImageStatistics_Empty(t *testing.T) {
+	repo := newTestBadgerImageRepository(t)
+
+	stats, err := repo.GetImageStatistics(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(0), stats.TotalImages)
+	assert.Empty(t, stats.AverageDimensionsByFormat)
+	assert.Empty(t, stats.AverageSizeByFormat)
+}
+
+func TestBadgerImageRepository_GetUploadCountsByDay(t *testing.T) {
+	repo := newTestBadgerImageRepository(t)
+	ctx := context.Background()
+
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	createdAts := []time.Time{
+		start.Add(2 * time.Hour),
+		start.Add(20 * time.Hour),
+		start.AddDate(0, 0, 2).Add(time.Hour),
+		start.AddDate(0, 0, -1), // before the window
+		start.AddDate(0, 0, 5),  // after the window
+	}
+	for i, createdAt := range createdAts {
+		img := &models.ImageMetadata{
+			ID:        fmt.Sprintf("b1b2c3d4-0000-4000-8000-00000000000%d", i),
+			Filename:  "image.jpg",
+			MimeType:  "image/jpeg",
+			Size:      1000,
+			Width:     100,
+			Height:    100,
+			CreatedAt: createdAt,
+			UpdatedAt: createdAt,
+		}
+		require.NoError(t, repo.Store(ctx, img))
+	}
+
+	counts, err := repo.GetUploadCountsByDay(ctx, start, 3)
+	require.NoError(t, err)
+
+	assert.Equal(t, []models.DailyUploadCount{
+		{Date: "2024-05-01", Count: 2},
+		{Date: "2024-05-02", Count: 0},
+		{Date: "2024-05-03", Count: 1},
+	}, counts)
+}
+
+func TestBadgerImageRepository_ResolutionDimensionsRoundTrip(t *testing.T) {
+	repo := newTestBadgerImageRepository(t)
+	ctx := context.Background()
+
+	metadata := models.NewImageMetadata("a1b2c3d4-0000-4000-8000-000000000100", "photo.jpg", "image/jpeg", 2048, 1920, 1080)
+	require.NoError(t, metadata.AddResolution("800x800:square"))
+	metadata.SetResolutionDimensions("800x800:square", models.DimensionInfo{Width: 800, Height: 800})
+	require.NoError(t, repo.Store(ctx, metadata))
+
+	retrieved, err := repo.Get(ctx, metadata.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]models.DimensionInfo{"800x800": {Width: 800, Height: 800}}, retrieved.ResolutionDimensions)
+}
+
+func TestBadgerImageRepository_FindByFilename(t *testing.T) {
+	repo := newTestBadgerImageRepository(t)
+	repo.EnableFilenameIndex()
+	ctx := context.Background()
+
+	store := func(id, filename string) *models.ImageMetadata {
+		img := &models.ImageMetadata{ID: id, Filename: filename, MimeType: "image/jpeg", Size: 1000, Width: 800, Height: 600}
+		img.CreatedAt = time.Now()
+		img.UpdatedAt = img.CreatedAt
+		require.NoError(t, repo.Store(ctx, img))
+		return img
+	}
+	first := store("a1b2c3d4-0000-4000-8000-000000000002", "photo.jpg")
+	store("a1b2c3d4-0000-4000-8000-000000000001", "photo.jpg")
+	store("a1b2c3d4-0000-4000-8000-000000000003", "banner.jpg")
+
+	// One filename mapping to several images, sorted by ID
+	ids, err := repo.FindByFilename(ctx, "photo.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a1b2c3d4-0000-4000-8000-000000000001", "a1b2c3d4-0000-4000-8000-000000000002"}, ids)
+
+	// One filename mapping to a single image
+	ids, err = repo.FindByFilename(ctx, "banner.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a1b2c3d4-0000-4000-8000-000000000003"}, ids)
+
+	ids, err = repo.FindByFilename(ctx, "missing.jpg")
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+
+	// A rename moves the image to its new filename
+	first.Filename = "cover.jpg"
+	require.NoError(t, repo.Update(ctx, first))
+	ids, err = repo.FindByFilename(ctx, "photo.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a1b2c3d4-0000-4000-8000-000000000001"}, ids)
+	ids, err = repo.FindByFilename(ctx, "cover.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, []string{first.ID}, ids)
+
+	// Deleting removes the image from the index
+	require.NoError(t, repo.Delete(ctx, "a1b2c3d4-0000-4000-8000-000000000003"))
+	ids, err = repo.FindByFilename(ctx, "banner.jpg")
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+}
+
+func TestBadgerImageRepository_FindByFilename_IndexDisabled(t *testing.T) {
+	repo := newTestBadgerImageRepository(t)
+	ctx := context.Background()
+
+	img := &models.ImageMetadata{ID: "a1b2c3d4-0000-4000-8000-000000000001", Filename: "photo.jpg", MimeType: "image/jpeg", Size: 1000, Width: 800, Height: 600}
+	img.CreatedAt = time.Now()
+	img.UpdatedAt = img.CreatedAt
+	require.NoError(t, repo.Store(ctx, img))
+
+	ids, err := repo.FindByFilename(ctx, "photo.jpg")
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+}
+
+func TestBadgerImageRepository_CorruptMetadata(t *testing.T) {
+	const (
+		healthyID = "a1b2c3d4-0000-4000-8000-000000000001"
+		brokenID  = "a1b2c3d4-0000-4000-8000-000000000002"
+	)
+	repo := newTestBadgerImageRepository(t)
+	ctx := context.Background()
+
+	for _, id := range []string{healthyID, "a1b2c3d4-0000-4000-8000-000000000003"} {
+		require.NoError(t, repo.Store(ctx, models.NewImageMetadata(id, id+".jpg", "image/jpeg", 1024, 800, 600)))
+	}
+	require.NoError(t, repo.db.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(repo.getMetadataKey(brokenID)), []byte(`{"id": "`+brokenID+`", "size": "not a number"`))
+	}))
+
+	t.Run("get returns a typed error", func(t *testing.T) {
+		_, err := repo.Get(ctx, brokenID)
+
+		var corruptErr models.CorruptMetadataError
+		require.ErrorAs(t, err, &corruptErr)
+		assert.Equal(t, brokenID, corruptErr.ID)
+	})
+
+	t.Run("listing skips the corrupt record and counts it", func(t *testing.T) {
+		skipped := CorruptRecordsSkipped()
+
+		images, err := repo.List(ctx, 0, 10)
+
+		require.NoError(t, err)
+		assert.Len(t, images, 2)
+		assert.Equal(t, skipped+1, CorruptRecordsSkipped())
+	})
+
+	t.Run("scan flags only the corrupt record", func(t *testing.T) {
+		records, err := repo.FindCorrupt(ctx)
+
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+		assert.Equal(t, brokenID, records[0].ID)
+		assert.NotEmpty(t, records[0].Reason)
+	})
+
+	t.Run("healthy records are not removed as corrupt", func(t *testing.T) {
+		err := repo.DeleteCorrupt(ctx, healthyID)
+
+		assert.IsType(t, models.ValidationError{}, err)
+		_, err = repo.Get(ctx, healthyID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("corrupt record is removed", func(t *testing.T) {
+		require.NoError(t, repo.DeleteCorrupt(ctx, brokenID))
+
+		records, err := repo.FindCorrupt(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, records)
+		assert.IsType(t, models.NotFoundError{}, repo.DeleteCorrupt(ctx, brokenID))
+	})
+}
+
+func TestBadgerImageRepository_OrphanedHashes(t *testing.T) {
+	const (
+		liveID   = "a1b2c3d4-0000-4000-8000-000000000001"
+		orphanID = "a1b2c3d4-0000-4000-8000-000000000002"
+	)
+	repo := newTestBadgerImageRepository(t)
+	ctx := context.Background()
+
+	liveHash := models.ImageHash{Algorithm: "SHA256", Value: "aaaa", Size: 10}
+	require.NoError(t, repo.StoreDeduplicationInfo(ctx, models.NewDeduplicationInfo(liveHash, liveID, "images/"+liveID+"/original.jpg")))
+
+	orphanHash := models.ImageHash{Algorithm: "SHA256", Value: "bbbb", Size: 20}
+	orphan := models.NewDeduplicationInfo(orphanHash, orphanID, "images/"+orphanID+"/original.jpg")
+	orphan.RemoveReference(orphanID)
+	require.True(t, orphan.IsOrphaned())
+	require.NoError(t, repo.StoreDeduplicationInfo(ctx, orphan))
+
+	hashes, err := repo.GetOrphanedHashes(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []models.ImageHash{orphanHash}, hashes)
+
+	require.NoError(t, repo.DeleteDeduplicationInfo(ctx, orphanHash))
+
+	hashes, err = repo.GetOrphanedHashes(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, hashes)
+	_, err = repo.GetDeduplicationInfo(ctx, liveHash)
+	assert.NoError(t, err, "referenced records are kept")
+}
+
+func TestBadgerImageRepository_AccessCounts(t *testing.T) {
+	const (
+		hotID  = "a1b2c3d4-0000-4000-8000-000000000001"
+		warmID = "a1b2c3d4-0000-4000-8000-000000000002"
+		coldID = "a1b2c3d4-0000-4000-8000-000000000003"
+	)
+	repo := newTestBadgerImageRepository(t)
+	ctx := context.Background()
+
+	for _, id := range []string{hotID, warmID, coldID} {
+		require.NoError(t, repo.Store(ctx, models.NewImageMetadata(id, "photo.jpg", "image/jpeg", 1000, 800, 600)))
+	}
+
+	require.NoError(t, repo.IncrementAccessCounts(ctx, warmID, map[string]int64{"original": 2}))
+	require.NoError(t, repo.IncrementAccessCounts(ctx, hotID, map[string]int64{"thumbnail": 3, "original": 1}))
+	require.NoError(t, repo.IncrementAccessCounts(ctx, hotID, map[string]int64{"thumbnail": 2}))
+	require.NoError(t, repo.IncrementAccessCounts(ctx, coldID, map[string]int64{"800x600": 1}))
+
+	counts, err := repo.GetAccessCounts(ctx, hotID)
+	require.NoError(t, err)
+	assert.Equal(t, &models.AccessCounts{Total: 6, ByResolution: map[string]int64{"thumbnail": 5, "original": 1}}, counts)
+
+	// Most downloaded first, cut at the limit
+	popular, err := repo.GetPopularImages(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, popular, 2)
+	assert.Equal(t, hotID, popular[0].ImageID)
+	assert.Equal(t, int64(6), popular[0].Total)
+	assert.Equal(t, warmID, popular[1].ImageID)
+	assert.Equal(t, map[string]int64{"original": 2}, popular[1].ByResolution)
+
+	// Images without metadata are not counted
+	var notFound models.NotFoundError
+	assert.ErrorAs(t, repo.IncrementAccessCounts(ctx, "a1b2c3d4-0000-4000-8000-000000000009", map[string]int64{"original": 1}), &notFound)
+
+	// Deleting an image drops its counters
+	require.NoError(t, repo.Delete(ctx, hotID))
+	counts, err = repo.GetAccessCounts(ctx, hotID)
+	require.NoError(t, err)
+	assert.Zero(t, counts.Total)
+	popular, err = repo.GetPopularImages(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, popular, 2)
+	assert.Equal(t, []string{warmID, coldID}, []string{popular[0].ImageID, popular[1].ImageID})
+}
+
+func TestBadgerImageRepository_TouchLastAccessed(t *testing.T) {
+	repo := newTestBadgerImageRepository(t)
+	ctx := context.Background()
+
+	metadata := models.NewImageMetadata("a1b2c3d4-0000-4000-8000-000000000001", "photo.jpg", "image/jpeg", 1000, 800, 600)
+	require.NoError(t, repo.Store(ctx, metadata))
+
+	accessed := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, repo.TouchLastAccessed(ctx, metadata.ID, accessed))
+
+	retrieved, err := repo.Get(ctx, metadata.ID)
+	require.NoError(t, err)
+	assert.True(t, accessed.Equal(retrieved.LastAccessedAt))
+	assert.Equal(t, "photo.jpg", retrieved.Filename, "other fields are kept")
+
+	// The timestamp never moves backwards
+	require.NoError(t, repo.TouchLastAccessed(ctx, metadata.ID, accessed.Add(-time.Hour)))
+	retrieved, err = repo.Get(ctx, metadata.ID)
+	require.NoError(t, err)
+	assert.True(t, accessed.Equal(retrieved.LastAccessedAt))
+
+	var notFound models.NotFoundError
+	assert.ErrorAs(t, repo.TouchLastAccessed(ctx, "a1b2c3d4-0000-4000-8000-000000000009", accessed), &notFound)
+}
+
+func TestBadgerImageRepository_ListByDedupStatus(t *testing.T) {
+	repo := newTestBadgerImageRepository(t)
+	ctx := context.Background()
+
+	for i := 1; i <= 5; i++ {
+		metadata := models.NewImageMetadata(fmt.Sprintf("a1b2c3d4-0000-4000-8000-00000000000%d", i), "photo.jpg", "image/jpeg", 1000, 800, 600)
+		if i > 3 {
+			metadata.MarkAsDeduped("a1b2c3d4-0000-4000-8000-000000000001")
+		}
+		require.NoError(t, repo.Store(ctx, metadata))
+	}
+	expectedCounts := models.DedupStatusCounts{Unique: 3, Deduped: 2, All: 5}
+
+	t.Run("unique", func(t *testing.T) {
+		images, counts, err := repo.ListByDedupStatus(ctx, models.ListDedupUnique, 0, 10)
+		require.NoError(t, err)
+		assert.Equal(t, expectedCounts, counts)
+		require.Len(t, images, 3)
+		for _, img := range images {
+			assert.False(t, img.IsDeduped)
+		}
+	})
+
+	t.Run("deduped", func(t *testing.T) {
+		images, counts, err := repo.ListByDedupStatus(ctx, models.ListDedupDeduped, 0, 10)
+		require.NoError(t, err)
+		assert.Equal(t, expectedCounts, counts)
+		require.Len(t, images, 2)
+		for _, img := range images {
+			assert.True(t, img.IsDeduped)
+			assert.Equal(t, "a1b2c3d4-0000-4000-8000-000000000001", img.SharedImageID)
+		}
+	})
+
+	t.Run("all paginates over matches", func(t *testing.T) {
+		images, counts, err := repo.ListByDedupStatus(ctx, models.ListDedupAll, 3, 10)
+		require.NoError(t, err)
+		assert.Equal(t, expectedCounts, counts)
+		assert.Len(t, images, 2)
+
+		images, _, err = repo.ListByDedupStatus(ctx, models.ListDedupUnique, 1, 1)
+		require.NoError(t, err)
+		assert.Len(t, images, 1)
+	})
+}
+
+func TestBadgerImageRepository_AuditEntries(t *testing.T) {
+	repo := newTestBadgerImageRepository(t)
+	ctx := context.Background()
+
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i, operation := range []string{"upload", "rename", "delete"} {
+		require.NoError(t, repo.AppendAuditEntry(ctx, &models.AuditEntry{
+			Timestamp: start.Add(time.Duration(i) * time.Minute),
+			Operation: operation,
+			Status:    200,
+		}, time.Hour))
+	}
+	// Entries recorded in the same instant are all kept
+	require.NoError(t, repo.AppendAuditEntry(ctx, &models.AuditEntry{Timestamp: start.Add(2 * time.Minute), Operation: "crop"}, time.Hour))
+
+	entries, err := repo.ListAuditEntries(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+	assert.Equal(t, "upload", entries[3].Operation)
+	assert.True(t, entries[0].Timestamp.Equal(start.Add(2*time.Minute)))
+
+	entries, err = repo.ListAuditEntries(ctx, 2)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	// Audit entries are not images
+	count, err := repo.Count(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
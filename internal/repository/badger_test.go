@@ -256,6 +256,25 @@ func TestBadgerRepository_Health(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestBadgerRepository_CacheHealth(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "badger_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	repo, err := NewBadgerRepository(&CacheConfig{
+		Type:      CacheTypeBadger,
+		Directory: tempDir,
+		TTL:       5 * time.Minute,
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	assert.NoError(t, repo.CacheHealth(ctx))
+
+	require.NoError(t, repo.Close())
+	assert.ErrorContains(t, repo.CacheHealth(ctx), "BadgerDB cache write failed")
+}
+
 func TestBadgerRepository_GetStats(t *testing.T) {
 	// Create temporary directory for test
 	tempDir, err := os.MkdirTemp("", "badger_test")
@@ -278,3 +297,61 @@ func TestBadgerRepository_GetStats(t *testing.T) {
 	assert.NotNil(t, stats)
 	assert.GreaterOrEqual(t, stats.KeyCount, int64(0))
 }
+
+func TestBadgerRepository_ResetCacheStats(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "badger_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	repo, err := NewBadgerRepository(&CacheConfig{
+		Type:      CacheTypeBadger,
+		Directory: tempDir,
+		TTL:       5 * time.Minute,
+	})
+	require.NoError(t, err)
+	defer repo.Close()
+
+	ctx := context.Background()
+	require.NoError(t, repo.SetCachedURL(ctx, "img", "800x600", "https://example.com/a.jpg", time.Minute))
+
+	// Seed three hits and one miss
+	for i := 0; i < 3; i++ {
+		_, err := repo.GetCachedURL(ctx, "img", "800x600")
+		require.NoError(t, err)
+	}
+	_, err = repo.GetCachedURL(ctx, "img", "missing")
+	require.Error(t, err)
+
+	stats, err := repo.GetStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), stats.CacheHits)
+	assert.Equal(t, int64(1), stats.CacheMisses)
+	assert.Equal(t, 0.75, CacheHitRatio(stats.CacheHits, stats.CacheMisses))
+
+	repo.ResetCacheStats()
+
+	stats, err = repo.GetStats(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, stats.CacheHits)
+	assert.Zero(t, stats.CacheMisses)
+}
+
+func TestCacheHitRatio(t *testing.T) {
+	tests := []struct {
+		name   string
+		hits   int64
+		misses int64
+		want   float64
+	}{
+		{name: "no lookups", want: 0},
+		{name: "only misses", misses: 4, want: 0},
+		{name: "only hits", hits: 7, want: 1},
+		{name: "mixed", hits: 1000, misses: 50, want: 1000.0 / 1050.0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, CacheHitRatio(tt.hits, tt.misses), 1e-9)
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package repository
+
+// CacheStatsResetter is implemented by repositories whose cache hit and miss
+// counters can be zeroed, so the hit ratio can be measured over a chosen window
+type CacheStatsResetter interface {
+	ResetCacheStats()
+}
+
+// CacheHitRatio returns the fraction of cache lookups that were hits, between 0
+// and 1. It is 0 when no lookups have been recorded.
+func CacheHitRatio(hits, misses int64) float64 {
+	total := hits + misses
+	if total <= 0 {
+		return 0
+	}
+	return float64(hits) / float64(total)
+}
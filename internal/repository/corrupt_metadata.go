@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+	"sync/atomic"
+
+	"resizr/internal/models"
+	"resizr/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// corruptRecordsSkipped counts corrupt metadata records skipped by List since startup
+var corruptRecordsSkipped int64
+
+// CorruptRecordsSkipped returns the number of corrupt metadata records skipped by listings since startup
+func CorruptRecordsSkipped() int64 {
+	return atomic.LoadInt64(&corruptRecordsSkipped)
+}
+
+// skipCorruptRecord logs and counts a corrupt record left out of a listing
+func skipCorruptRecord(ctx context.Context, err models.CorruptMetadataError) {
+	atomic.AddInt64(&corruptRecordsSkipped, 1)
+	logger.WarnWithContext(ctx, "Skipping corrupt metadata record",
+		zap.String("image_id", err.ID),
+		zap.String("reason", err.Reason))
+}
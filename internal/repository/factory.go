@@ -60,6 +60,23 @@ type CacheStats struct {
 // NewImageRepository creates a new image repository
 // This creates either a Redis-only or BadgerDB-only repository based on CACHE_TYPE
 func NewImageRepository(cfg *config.Config) (ImageRepository, error) {
+	repo, err := newImageRepository(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Image.FilenameIndexEnabled {
+		if indexer, ok := repo.(FilenameIndexer); ok {
+			logger.Info("Filename index enabled")
+			indexer.EnableFilenameIndex()
+		}
+	}
+
+	return repo, nil
+}
+
+// newImageRepository creates the repository for the configured backend
+func newImageRepository(cfg *config.Config) (ImageRepository, error) {
 	logger.Info("Initializing image repository",
 		zap.String("type", cfg.Cache.Type))
 
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"slices"
+)
+
+// filenameIndexPrefix prefixes the keys mapping an original filename to its image IDs
+const filenameIndexPrefix = "image:filename:"
+
+// FilenameIndexer is implemented by repositories that can maintain the optional
+// filename -> image IDs index. The index is kept up to date on Store and Delete once
+// enabled; images stored while it was disabled are not indexed.
+type FilenameIndexer interface {
+	EnableFilenameIndex()
+}
+
+// getFilenameKey generates the index key for an original filename
+func getFilenameKey(filename string) string {
+	return filenameIndexPrefix + filename
+}
+
+// addIndexedID returns ids with id added, keeping the list sorted and free of duplicates
+func addIndexedID(ids []string, id string) []string {
+	position, found := slices.BinarySearch(ids, id)
+	if found {
+		return ids
+	}
+	return slices.Insert(ids, position, id)
+}
+
+// removeIndexedID returns ids without id
+func removeIndexedID(ids []string, id string) []string {
+	if position, found := slices.BinarySearch(ids, id); found {
+		return slices.Delete(ids, position, position+1)
+	}
+	return ids
+}
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIndexedIDs_AddAndRemove(t *testing.T) {
+	var ids []string
+	ids = addIndexedID(ids, "b")
+	ids = addIndexedID(ids, "a")
+	ids = addIndexedID(ids, "c")
+	ids = addIndexedID(ids, "a")
+	assert.Equal(t, []string{"a", "b", "c"}, ids)
+
+	ids = removeIndexedID(ids, "b")
+	ids = removeIndexedID(ids, "missing")
+	assert.Equal(t, []string{"a", "c"}, ids)
+
+	assert.Equal(t, "image:filename:photo.jpg", getFilenameKey("photo.jpg"))
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"strings"
+
+	"resizr/internal/models"
+)
+
+// formatTotals holds running sums for a single image format
+type formatTotals struct {
+	count       int64
+	totalWidth  int64
+	totalHeight int64
+	totalSize   int64
+}
+
+// formatAverager accumulates per-format dimension and size sums while
+// iterating image metadata and turns them into averages
+type formatAverager struct {
+	totals map[string]*formatTotals
+}
+
+// newFormatAverager creates an empty per-format accumulator
+func newFormatAverager() *formatAverager {
+	return &formatAverager{totals: make(map[string]*formatTotals)}
+}
+
+// add records one image of the given MIME type
+func (a *formatAverager) add(mimeType string, width, height int, size int64) {
+	format := strings.TrimPrefix(mimeType, "image/")
+	if format == "" {
+		return
+	}
+
+	t, exists := a.totals[format]
+	if !exists {
+		t = &formatTotals{}
+		a.totals[format] = t
+	}
+	t.count++
+	t.totalWidth += int64(width)
+	t.totalHeight += int64(height)
+	t.totalSize += size
+}
+
+// averages returns the mean dimensions and size for every recorded format
+func (a *formatAverager) averages() (map[string]models.AverageDimensions, map[string]float64) {
+	dimensions := make(map[string]models.AverageDimensions, len(a.totals))
+	sizes := make(map[string]float64, len(a.totals))
+
+	for format, t := range a.totals {
+		count := float64(t.count)
+		dimensions[format] = models.AverageDimensions{
+			Width:  float64(t.totalWidth) / count,
+			Height: float64(t.totalHeight) / count,
+		}
+		sizes[format] = float64(t.totalSize) / count
+	}
+
+	return dimensions, sizes
+}
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatAverager(t *testing.T) {
+	averager := newFormatAverager()
+	averager.add("image/jpeg", 800, 600, 1000)
+	averager.add("image/jpeg", 400, 200, 3000)
+	averager.add("image/png", 100, 50, 500)
+	averager.add("", 10, 10, 10)
+
+	dimensions, sizes := averager.averages()
+
+	assert.Len(t, dimensions, 2)
+	assert.Equal(t, 600.0, dimensions["jpeg"].Width)
+	assert.Equal(t, 400.0, dimensions["jpeg"].Height)
+	assert.Equal(t, 2000.0, sizes["jpeg"])
+	assert.Equal(t, 100.0, dimensions["png"].Width)
+	assert.Equal(t, 50.0, dimensions["png"].Height)
+	assert.Equal(t, 500.0, sizes["png"])
+}
+
+func TestFormatAverager_Empty(t *testing.T) {
+	dimensions, sizes := newFormatAverager().averages()
+
+	assert.NotNil(t, dimensions)
+	assert.NotNil(t, sizes)
+	assert.Empty(t, dimensions)
+	assert.Empty(t, sizes)
+}
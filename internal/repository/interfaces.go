@@ -24,9 +24,16 @@ type ImageRepository interface {
 	// Exists checks if image metadata exists
 	Exists(ctx context.Context, id string) (bool, error)
 
+	// Count returns the number of stored images
+	Count(ctx context.Context) (int64, error)
+
 	// List retrieves multiple image metadata with pagination
 	List(ctx context.Context, offset, limit int) ([]*models.ImageMetadata, error)
 
+	// ListByDedupStatus retrieves a page of the images matching a models.ListDedup* filter,
+	// with the counts of every image per deduplication status
+	ListByDedupStatus(ctx context.Context, filter string, offset, limit int) ([]*models.ImageMetadata, models.DedupStatusCounts, error)
+
 	// UpdateResolutions updates the resolutions list for an image
 	UpdateResolutions(ctx context.Context, id string, resolutions []string) error
 
@@ -48,9 +55,36 @@ type ImageRepository interface {
 	// GetImagesByTimeRange returns count of images created in time range
 	GetImagesByTimeRange(ctx context.Context, start, end time.Time) (int64, error)
 
+	// GetUploadCountsByDay returns per-day upload counts for the given number of days starting at start
+	GetUploadCountsByDay(ctx context.Context, start time.Time, days int) ([]models.DailyUploadCount, error)
+
 	// GetStorageUsageByResolution returns storage usage per resolution
 	GetStorageUsageByResolution(ctx context.Context) (map[string]int64, error)
 
+	// FindByFilename returns the IDs of images stored under an original filename;
+	// it only finds images indexed while the filename index was enabled
+	FindByFilename(ctx context.Context, filename string) ([]string, error)
+
+	// IncrementAccessCounts adds per-resolution download counts to an image's counters;
+	// it returns a NotFoundError when the image has no metadata
+	IncrementAccessCounts(ctx context.Context, id string, counts map[string]int64) error
+
+	// GetAccessCounts retrieves the download counters of an image
+	GetAccessCounts(ctx context.Context, id string) (*models.AccessCounts, error)
+
+	// GetPopularImages returns up to limit images with the most downloads, most downloaded first
+	GetPopularImages(ctx context.Context, limit int) ([]models.PopularImage, error)
+
+	// TouchLastAccessed moves an image's LastAccessedAt forward to at; it returns a
+	// NotFoundError when the image has no metadata
+	TouchLastAccessed(ctx context.Context, id string, at time.Time) error
+
+	// FindCorrupt returns the metadata records that cannot be decoded
+	FindCorrupt(ctx context.Context) ([]models.CorruptRecord, error)
+
+	// DeleteCorrupt removes a metadata record that cannot be decoded; healthy records are left alone
+	DeleteCorrupt(ctx context.Context, id string) error
+
 	// Health checks repository health
 	Health(ctx context.Context) error
 
@@ -129,6 +163,10 @@ type CacheRepository interface {
 
 	// DeleteCache removes any value from cache
 	DeleteCache(ctx context.Context, key string) error
+
+	// CacheHealth checks the cache layer on its own, independently of Health, which
+	// covers the metadata store
+	CacheHealth(ctx context.Context) error
 }
 
 // RepositoryStats represents repository statistics
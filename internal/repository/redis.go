@@ -2,9 +2,13 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"resizr/internal/config"
@@ -20,6 +24,9 @@ type RedisRepository struct {
 	client redis.Cmdable
 	config *config.RedisConfig
 
+	// filenameIndex maintains the filename -> image IDs sets on Store/Delete
+	filenameIndex bool
+
 	// Statistics (in-memory counters)
 	cacheHits   int64
 	cacheMisses int64
@@ -28,26 +35,16 @@ type RedisRepository struct {
 // NewRedisRepository creates a new Redis repository
 func NewRedisRepository(cfg *config.RedisConfig) (ImageRepository, error) {
 	logger.Info("Initializing Redis repository",
+		zap.String("mode", cfg.Mode),
 		zap.String("url", cfg.URL),
 		zap.Int("db", cfg.DB),
 		zap.Int("pool_size", cfg.PoolSize))
 
-	// Parse Redis URL and create client
-	opt, err := redis.ParseURL(cfg.URL)
+	client, err := newRedisClient(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("invalid Redis URL: %w", err)
+		return nil, err
 	}
 
-	// Override with config values
-	opt.Password = cfg.Password
-	opt.DB = cfg.DB
-	opt.PoolSize = cfg.PoolSize
-	opt.DialTimeout = cfg.Timeout
-	opt.ReadTimeout = cfg.Timeout
-	opt.WriteTimeout = cfg.Timeout
-
-	client := redis.NewClient(opt)
-
 	repo := &RedisRepository{
 		client: client,
 		config: cfg,
@@ -58,6 +55,7 @@ func NewRedisRepository(cfg *config.RedisConfig) (ImageRepository, error) {
 	defer cancel()
 
 	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
@@ -77,11 +75,20 @@ func (r *RedisRepository) Store(ctx context.Context, img *models.ImageMetadata)
 
 	key := r.getMetadataKey(img.ID)
 
+	// Remember the indexed filename so a rename can move the image between index sets
+	var previousFilename string
+	if r.filenameIndex {
+		previousFilename, _ = r.client.HGet(ctx, key, "filename").Result()
+	}
+
 	// Convert metadata to Redis hash fields
 	fields := r.metadataToFields(img)
 
 	// Store using HMSET for atomic operation
-	if err := r.client.HMSet(ctx, key, fields).Err(); err != nil {
+	err := r.withRetry(ctx, "store", func() error {
+		return r.client.HMSet(ctx, key, fields).Err()
+	})
+	if err != nil {
 		logger.ErrorWithContext(ctx, "Failed to store image metadata",
 			zap.String("image_id", img.ID),
 			zap.String("key", key),
@@ -89,6 +96,10 @@ func (r *RedisRepository) Store(ctx context.Context, img *models.ImageMetadata)
 		return fmt.Errorf("failed to store metadata: %w", err)
 	}
 
+	if r.filenameIndex {
+		r.indexFilename(ctx, img.ID, previousFilename, img.Filename)
+	}
+
 	logger.DebugWithContext(ctx, "Image metadata stored successfully",
 		zap.String("image_id", img.ID),
 		zap.String("key", key))
@@ -104,7 +115,18 @@ func (r *RedisRepository) Get(ctx context.Context, id string) (*models.ImageMeta
 	key := r.getMetadataKey(id)
 
 	// Get all fields from hash
-	fields, err := r.client.HGetAll(ctx, key).Result()
+	var fields map[string]string
+	err := r.withRetry(ctx, "get", func() (err error) {
+		fields, err = r.client.HGetAll(ctx, key).Result()
+		return err
+	})
+	if err != nil && isWrongTypeError(err) {
+		logger.ErrorWithContext(ctx, "Image metadata is corrupt",
+			zap.String("image_id", id),
+			zap.String("key", key),
+			zap.Error(err))
+		return nil, models.CorruptMetadataError{ID: id, Reason: "record is not a hash"}
+	}
 	if err != nil {
 		logger.ErrorWithContext(ctx, "Failed to get image metadata",
 			zap.String("image_id", id),
@@ -125,6 +147,13 @@ func (r *RedisRepository) Get(ctx context.Context, id string) (*models.ImageMeta
 
 	// Convert fields to metadata
 	metadata, err := r.fieldsToMetadata(fields)
+	if corruptErr, ok := err.(models.CorruptMetadataError); ok {
+		corruptErr.ID = id
+		logger.ErrorWithContext(ctx, "Image metadata is corrupt",
+			zap.String("image_id", id),
+			zap.String("reason", corruptErr.Reason))
+		return nil, corruptErr
+	}
 	if err != nil {
 		logger.ErrorWithContext(ctx, "Failed to parse image metadata",
 			zap.String("image_id", id),
@@ -170,8 +199,17 @@ func (r *RedisRepository) Delete(ctx context.Context, id string) error {
 
 	key := r.getMetadataKey(id)
 
+	var filename string
+	if r.filenameIndex {
+		filename, _ = r.client.HGet(ctx, key, "filename").Result()
+	}
+
 	// Delete metadata
-	deleted, err := r.client.Del(ctx, key).Result()
+	var deleted int64
+	err := r.withRetry(ctx, "delete", func() (err error) {
+		deleted, err = r.client.Del(ctx, key).Result()
+		return err
+	})
 	if err != nil {
 		logger.ErrorWithContext(ctx, "Failed to delete image metadata",
 			zap.String("image_id", id),
@@ -189,6 +227,14 @@ func (r *RedisRepository) Delete(ctx context.Context, id string) error {
 	// Clean up cached URLs for this image
 	_ = r.DeleteAllCachedURLs(ctx, id)
 
+	// Drop its download counters
+	r.client.Del(ctx, getAccessCountKey(id))
+	r.client.ZRem(ctx, popularImagesKey, id)
+
+	if filename != "" {
+		r.indexFilename(ctx, id, filename, "")
+	}
+
 	logger.InfoWithContext(ctx, "Image metadata deleted successfully",
 		zap.String("image_id", id))
 
@@ -199,7 +245,11 @@ func (r *RedisRepository) Delete(ctx context.Context, id string) error {
 func (r *RedisRepository) Exists(ctx context.Context, id string) (bool, error) {
 	key := r.getMetadataKey(id)
 
-	exists, err := r.client.Exists(ctx, key).Result()
+	var exists int64
+	err := r.withRetry(ctx, "exists", func() (err error) {
+		exists, err = r.client.Exists(ctx, key).Result()
+		return err
+	})
 	if err != nil {
 		return false, fmt.Errorf("failed to check existence: %w", err)
 	}
@@ -207,32 +257,24 @@ func (r *RedisRepository) Exists(ctx context.Context, id string) (bool, error) {
 	return exists > 0, nil
 }
 
+// Count returns the number of stored images
+func (r *RedisRepository) Count(ctx context.Context) (int64, error) {
+	count, err := r.countImages(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count images: %w", err)
+	}
+	return count, nil
+}
+
 // List retrieves multiple image metadata with pagination
 func (r *RedisRepository) List(ctx context.Context, offset, limit int) ([]*models.ImageMetadata, error) {
 	logger.DebugWithContext(ctx, "Listing images",
 		zap.Int("offset", offset),
 		zap.Int("limit", limit))
 
-	pattern := r.getMetadataKey("*")
-
-	// Scan for all metadata keys
-	var cursor uint64
-	var keys []string
-
-	for {
-		var scanKeys []string
-		var err error
-
-		scanKeys, cursor, err = r.client.Scan(ctx, cursor, pattern, 100).Result()
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan keys: %w", err)
-		}
-
-		keys = append(keys, scanKeys...)
-
-		if cursor == 0 {
-			break
-		}
+	keys, err := r.scanMetadataKeys(ctx)
+	if err != nil {
+		return nil, err
 	}
 
 	// Apply pagination
@@ -259,6 +301,10 @@ func (r *RedisRepository) List(ctx context.Context, offset, limit int) ([]*model
 		}
 
 		metadata, err := r.Get(ctx, id)
+		if corruptErr, ok := err.(models.CorruptMetadataError); ok {
+			skipCorruptRecord(ctx, corruptErr)
+			continue
+		}
 		if err != nil {
 			logger.WarnWithContext(ctx, "Failed to get metadata for key",
 				zap.String("key", key),
@@ -278,6 +324,54 @@ func (r *RedisRepository) List(ctx context.Context, offset, limit int) ([]*model
 	return images, nil
 }
 
+// ListByDedupStatus retrieves a page of the images matching a dedup filter. Every
+// record is read to count the images per status.
+func (r *RedisRepository) ListByDedupStatus(ctx context.Context, filter string, offset, limit int) ([]*models.ImageMetadata, models.DedupStatusCounts, error) {
+	logger.DebugWithContext(ctx, "Listing images by dedup status",
+		zap.String("filter", filter),
+		zap.Int("offset", offset),
+		zap.Int("limit", limit))
+
+	var counts models.DedupStatusCounts
+	keys, err := r.scanMetadataKeys(ctx)
+	if err != nil {
+		return nil, counts, err
+	}
+
+	images := []*models.ImageMetadata{}
+	matched := 0
+	for _, key := range keys {
+		id := r.extractIDFromKey(key)
+		if id == "" {
+			continue
+		}
+
+		metadata, err := r.Get(ctx, id)
+		if corruptErr, ok := err.(models.CorruptMetadataError); ok {
+			skipCorruptRecord(ctx, corruptErr)
+			continue
+		}
+		if err != nil {
+			logger.WarnWithContext(ctx, "Failed to get metadata for key",
+				zap.String("key", key),
+				zap.String("image_id", id),
+				zap.Error(err))
+			continue
+		}
+
+		counts.Add(metadata)
+		if !metadata.MatchesDedupFilter(filter) {
+			continue
+		}
+		if matched >= offset && matched < offset+limit {
+			images = append(images, metadata)
+		}
+		matched++
+	}
+
+	return images, counts, nil
+}
+
 // UpdateResolutions updates the resolutions list for an image
 func (r *RedisRepository) UpdateResolutions(ctx context.Context, id string, resolutions []string) error {
 	logger.DebugWithContext(ctx, "Updating image resolutions",
@@ -343,7 +437,7 @@ func (r *RedisRepository) GetCachedURL(ctx context.Context, imageID, resolution
 	if err != nil {
 		if err == redis.Nil {
 			// Cache miss
-			r.cacheMisses++
+			atomic.AddInt64(&r.cacheMisses, 1)
 			logger.DebugWithContext(ctx, "Cache miss for URL",
 				zap.String("image_id", imageID),
 				zap.String("resolution", resolution))
@@ -356,7 +450,7 @@ func (r *RedisRepository) GetCachedURL(ctx context.Context, imageID, resolution
 	}
 
 	// Cache hit
-	r.cacheHits++
+	atomic.AddInt64(&r.cacheHits, 1)
 	logger.DebugWithContext(ctx, "Cache hit for URL",
 		zap.String("image_id", imageID),
 		zap.String("resolution", resolution))
@@ -405,6 +499,12 @@ func (r *RedisRepository) DeleteCache(ctx context.Context, key string) error {
 	return r.client.Del(ctx, key).Err()
 }
 
+// ResetCacheStats zeroes the cache hit and miss counters
+func (r *RedisRepository) ResetCacheStats() {
+	atomic.StoreInt64(&r.cacheHits, 0)
+	atomic.StoreInt64(&r.cacheMisses, 0)
+}
+
 // GetStats retrieves repository statistics
 func (r *RedisRepository) GetStats(ctx context.Context) (*RepositoryStats, error) {
 	// Get Redis info
@@ -422,13 +522,10 @@ func (r *RedisRepository) GetStats(ctx context.Context) (*RepositoryStats, error
 
 	stats := &RepositoryStats{
 		TotalImages: totalImages,
-		CacheHits:   r.cacheHits,
-		CacheMisses: r.cacheMisses,
+		CacheHits:   atomic.LoadInt64(&r.cacheHits),
+		CacheMisses: atomic.LoadInt64(&r.cacheMisses),
 		StorageUsed: r.parseInfoValue(info, "used_memory"),
-		Connections: ConnectionStats{
-			Active:  int(r.parseInfoValue(info, "connected_clients")),
-			MaxOpen: r.config.PoolSize,
-		},
+		Connections: r.connectionStats(),
 		KeyCounts: map[string]int64{
 			"metadata": totalImages,
 			"cache":    r.countCacheKeys(ctx),
@@ -438,6 +535,23 @@ func (r *RedisRepository) GetStats(ctx context.Context) (*RepositoryStats, error
 	return stats, nil
 }
 
+// connectionStats reports the client's own connection pool: connections handed
+// out to commands are active, the rest are idle
+func (r *RedisRepository) connectionStats() ConnectionStats {
+	stats := ConnectionStats{MaxOpen: r.config.PoolSize}
+
+	client, ok := r.client.(redis.UniversalClient)
+	if !ok {
+		return stats
+	}
+
+	pool := client.PoolStats()
+	stats.Total = int(pool.TotalConns)
+	stats.Idle = int(pool.IdleConns)
+	stats.Active = stats.Total - stats.Idle
+	return stats
+}
+
 // Health checks repository health
 func (r *RedisRepository) Health(ctx context.Context) error {
 	// Simple ping test
@@ -459,14 +573,227 @@ func (r *RedisRepository) Health(ctx context.Context) error {
 	return nil
 }
 
+// CacheHealth checks the URL cache by writing, reading back and removing a probe entry
+func (r *RedisRepository) CacheHealth(ctx context.Context) error {
+	key := r.getCacheKey("health-check", fmt.Sprintf("%d", time.Now().UnixNano()))
+	if err := r.client.Set(ctx, key, "ok", 10*time.Second).Err(); err != nil {
+		return fmt.Errorf("Redis cache write failed: %w", err)
+	}
+	if err := r.client.Get(ctx, key).Err(); err != nil {
+		return fmt.Errorf("Redis cache read failed: %w", err)
+	}
+
+	if err := r.client.Del(ctx, key).Err(); err != nil {
+		logger.WarnWithContext(ctx, "Failed to cleanup cache health check key", zap.Error(err))
+		// Not a critical error
+	}
+
+	return nil
+}
+
 // Close closes the repository connection
 func (r *RedisRepository) Close() error {
-	if client, ok := r.client.(*redis.Client); ok {
+	if client, ok := r.client.(redis.UniversalClient); ok {
 		return client.Close()
 	}
 	return nil
 }
 
+// EnableFilenameIndex makes Store and Delete maintain the filename index
+func (r *RedisRepository) EnableFilenameIndex() {
+	r.filenameIndex = true
+}
+
+// FindByFilename returns the IDs of images stored under the original filename
+func (r *RedisRepository) FindByFilename(ctx context.Context, filename string) ([]string, error) {
+	ids, err := r.client.SMembers(ctx, getFilenameKey(filename)).Result()
+	if err != nil {
+		logger.ErrorWithContext(ctx, "Failed to look up filename index",
+			zap.String("filename", filename),
+			zap.Error(err))
+		return nil, fmt.Errorf("failed to look up filename: %w", err)
+	}
+
+	slices.Sort(ids)
+	return ids, nil
+}
+
+// indexFilename moves an image from the set of its previous filename to that of its
+// current one; an empty name skips that side. Failures only make lookups miss the
+// image, so they are logged instead of failing the metadata write.
+func (r *RedisRepository) indexFilename(ctx context.Context, id, previous, current string) {
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if previous != "" && previous != current {
+			pipe.SRem(ctx, getFilenameKey(previous), id)
+		}
+		if current != "" {
+			pipe.SAdd(ctx, getFilenameKey(current), id)
+		}
+		return nil
+	})
+	if err != nil {
+		logger.WarnWithContext(ctx, "Failed to update filename index",
+			zap.String("image_id", id),
+			zap.String("previous_filename", previous),
+			zap.String("filename", current),
+			zap.Error(err))
+	}
+}
+
+// IncrementAccessCounts adds per-resolution download counts to the counters hash of
+// an image and its total to the popularity ranking, in one transaction
+func (r *RedisRepository) IncrementAccessCounts(ctx context.Context, id string, counts map[string]int64) error {
+	exists, err := r.Exists(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return models.NotFoundError{
+			Resource: "image",
+			ID:       id,
+		}
+	}
+
+	key := getAccessCountKey(id)
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		var total int64
+		for resolution, count := range counts {
+			pipe.HIncrBy(ctx, key, resolution, count)
+			total += count
+		}
+		pipe.ZIncrBy(ctx, popularImagesKey, float64(total), id)
+		return nil
+	})
+	if err != nil {
+		logger.ErrorWithContext(ctx, "Failed to increment access counts",
+			zap.String("image_id", id),
+			zap.Error(err))
+		return fmt.Errorf("failed to increment access counts: %w", err)
+	}
+
+	return nil
+}
+
+// GetAccessCounts retrieves the download counters of an image
+func (r *RedisRepository) GetAccessCounts(ctx context.Context, id string) (*models.AccessCounts, error) {
+	fields, err := r.client.HGetAll(ctx, getAccessCountKey(id)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get access counts: %w", err)
+	}
+	return accessCountsFromFields(fields)
+}
+
+// GetPopularImages returns up to limit images from the popularity ranking, most
+// downloaded first
+func (r *RedisRepository) GetPopularImages(ctx context.Context, limit int) ([]models.PopularImage, error) {
+	if limit <= 0 {
+		return []models.PopularImage{}, nil
+	}
+
+	ranked, err := r.client.ZRevRangeWithScores(ctx, popularImagesKey, 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get popular images: %w", err)
+	}
+
+	images := make([]models.PopularImage, 0, len(ranked))
+	for _, entry := range ranked {
+		id, ok := entry.Member.(string)
+		if !ok {
+			continue
+		}
+		counts, err := r.GetAccessCounts(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		counts.Total = int64(entry.Score)
+		images = append(images, models.PopularImage{ImageID: id, AccessCounts: *counts})
+	}
+
+	return images, nil
+}
+
+// TouchLastAccessed sets the last_accessed_at field of an image's metadata hash. Only
+// that field is written, so concurrent metadata updates are not overwritten.
+func (r *RedisRepository) TouchLastAccessed(ctx context.Context, id string, at time.Time) error {
+	exists, err := r.Exists(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return models.NotFoundError{
+			Resource: "image",
+			ID:       id,
+		}
+	}
+
+	if err := r.client.HSet(ctx, r.getMetadataKey(id), "last_accessed_at", at.UTC().Format(time.RFC3339)).Err(); err != nil {
+		logger.ErrorWithContext(ctx, "Failed to update last accessed time",
+			zap.String("image_id", id),
+			zap.Error(err))
+		return fmt.Errorf("failed to update last accessed time: %w", err)
+	}
+
+	return nil
+}
+
+// accessCountsFromFields converts a counters hash into AccessCounts
+func accessCountsFromFields(fields map[string]string) (*models.AccessCounts, error) {
+	counts := &models.AccessCounts{ByResolution: make(map[string]int64, len(fields))}
+	for resolution := range fields {
+		count, err := parseIntField(fields, resolution)
+		if err != nil {
+			return nil, err
+		}
+		counts.Add(resolution, count)
+	}
+	return counts, nil
+}
+
+// AppendAuditEntry adds an entry to the audit sorted set and trims entries older
+// than retention in the same transaction
+func (r *RedisRepository) AppendAuditEntry(ctx context.Context, entry *models.AuditEntry, retention time.Duration) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, auditLogKey, &redis.Z{Score: float64(entry.Timestamp.UnixNano()), Member: data})
+		if retention > 0 {
+			cutoff := entry.Timestamp.Add(-retention).UnixNano()
+			pipe.ZRemRangeByScore(ctx, auditLogKey, "-inf", "("+strconv.FormatInt(cutoff, 10))
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to append audit entry: %w", err)
+	}
+	return nil
+}
+
+// ListAuditEntries returns up to limit entries of the audit sorted set, newest first
+func (r *RedisRepository) ListAuditEntries(ctx context.Context, limit int) ([]models.AuditEntry, error) {
+	entries := []models.AuditEntry{}
+	if limit <= 0 {
+		return entries, nil
+	}
+
+	members, err := r.client.ZRevRange(ctx, auditLogKey, 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+
+	for _, member := range members {
+		var entry models.AuditEntry
+		if err := json.Unmarshal([]byte(member), &entry); err != nil {
+			logger.WarnWithContext(ctx, "Skipping unreadable audit entry", zap.Error(err))
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
 // Helper methods
 
 // getMetadataKey generates Redis key for image metadata
@@ -479,6 +806,85 @@ func (r *RedisRepository) getCacheKey(imageID, resolution string) string {
 	return fmt.Sprintf("image:cache:%s:%s", imageID, resolution)
 }
 
+// scanMetadataKeys returns the keys of all stored metadata records
+func (r *RedisRepository) scanMetadataKeys(ctx context.Context) ([]string, error) {
+	keys, err := r.findKeysByPattern(ctx, r.getMetadataKey("*"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan keys: %w", err)
+	}
+	return keys, nil
+}
+
+// FindCorrupt returns the metadata records that cannot be decoded
+func (r *RedisRepository) FindCorrupt(ctx context.Context) ([]models.CorruptRecord, error) {
+	keys, err := r.scanMetadataKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []models.CorruptRecord
+	for _, key := range keys {
+		id := r.extractIDFromKey(key)
+		if id == "" {
+			continue
+		}
+
+		_, err := r.Get(ctx, id)
+		if corruptErr, ok := err.(models.CorruptMetadataError); ok {
+			records = append(records, models.CorruptRecord{ID: id, Reason: corruptErr.Reason})
+		}
+	}
+
+	logger.DebugWithContext(ctx, "Corrupt metadata scan completed",
+		zap.Int("corrupt_records", len(records)))
+
+	return records, nil
+}
+
+// DeleteCorrupt removes a metadata record that cannot be decoded. Cached URLs are
+// cleaned up; the filename index entry cannot be located without a readable record.
+func (r *RedisRepository) DeleteCorrupt(ctx context.Context, id string) error {
+	_, err := r.Get(ctx, id)
+	if err == nil {
+		return models.ValidationError{
+			Field:   "record",
+			Message: "metadata record is not corrupt",
+		}
+	}
+	if _, ok := err.(models.CorruptMetadataError); !ok {
+		return err
+	}
+
+	if err := r.client.Del(ctx, r.getMetadataKey(id)).Err(); err != nil {
+		return fmt.Errorf("failed to delete corrupt metadata: %w", err)
+	}
+
+	_ = r.DeleteAllCachedURLs(ctx, id)
+
+	logger.InfoWithContext(ctx, "Corrupt metadata record deleted",
+		zap.String("image_id", id))
+
+	return nil
+}
+
+// isWrongTypeError reports whether Redis rejected a command because the key holds another type
+func isWrongTypeError(err error) bool {
+	return strings.HasPrefix(err.Error(), "WRONGTYPE")
+}
+
+// parseIntField parses an optional integer hash field; an absent field is zero
+func parseIntField(fields map[string]string, name string) (int64, error) {
+	value := fields[name]
+	if value == "" {
+		return 0, nil
+	}
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s field", name)
+	}
+	return parsed, nil
+}
+
 // extractIDFromKey extracts image ID from Redis key
 func (r *RedisRepository) extractIDFromKey(key string) string {
 	parts := strings.Split(key, ":")
@@ -491,18 +897,25 @@ func (r *RedisRepository) extractIDFromKey(key string) string {
 // metadataToFields converts ImageMetadata to Redis hash fields
 func (r *RedisRepository) metadataToFields(img *models.ImageMetadata) map[string]interface{} {
 	fields := map[string]interface{}{
-		"id":              img.ID,
-		"original_key":    img.OriginalKey,
-		"filename":        img.Filename,
-		"mime_type":       img.MimeType,
-		"size":            img.Size,
-		"width":           img.Width,
-		"height":          img.Height,
-		"resolutions":     strings.Join(img.Resolutions, ","),
-		"created_at":      img.CreatedAt.Format(time.RFC3339),
-		"updated_at":      img.UpdatedAt.Format(time.RFC3339),
-		"is_deduped":      img.IsDeduped,
-		"shared_image_id": img.SharedImageID,
+		"id":                 img.ID,
+		"original_key":       img.OriginalKey,
+		"filename":           img.Filename,
+		"mime_type":          img.MimeType,
+		"size":               img.Size,
+		"width":              img.Width,
+		"height":             img.Height,
+		"resolutions":        strings.Join(img.Resolutions, ","),
+		"original_aliases":   strings.Join(img.OriginalAliases, ","),
+		"created_at":         img.CreatedAt.Format(time.RFC3339),
+		"updated_at":         img.UpdatedAt.Format(time.RFC3339),
+		"is_deduped":         img.IsDeduped,
+		"shared_image_id":    img.SharedImageID,
+		"dedup_disabled":     img.DedupDisabled,
+		"watermark_disabled": img.WatermarkDisabled,
+	}
+
+	if !img.LastAccessedAt.IsZero() {
+		fields["last_accessed_at"] = img.LastAccessedAt.Format(time.RFC3339)
 	}
 
 	// Add hash fields if hash is set
@@ -510,6 +923,43 @@ func (r *RedisRepository) metadataToFields(img *models.ImageMetadata) map[string
 		fields["hash_algorithm"] = img.Hash.Algorithm
 		fields["hash_value"] = img.Hash.Value
 		fields["hash_size"] = img.Hash.Size
+		fields["hash_namespace"] = img.Hash.Namespace
+	}
+
+	// Always written so removed resolutions don't leave stale sizes behind
+	fields["resolution_dimensions"] = ""
+	if len(img.ResolutionDimensions) > 0 {
+		if data, err := json.Marshal(img.ResolutionDimensions); err == nil {
+			fields["resolution_dimensions"] = string(data)
+		}
+	}
+
+	fields["format_variants"] = ""
+	if len(img.FormatVariants) > 0 {
+		if data, err := json.Marshal(img.FormatVariants); err == nil {
+			fields["format_variants"] = string(data)
+		}
+	}
+
+	fields["resolution_formats"] = ""
+	if len(img.ResolutionFormats) > 0 {
+		if data, err := json.Marshal(img.ResolutionFormats); err == nil {
+			fields["resolution_formats"] = string(data)
+		}
+	}
+
+	fields["derivative_hashes"] = ""
+	if len(img.DerivativeHashes) > 0 {
+		if data, err := json.Marshal(img.DerivativeHashes); err == nil {
+			fields["derivative_hashes"] = string(data)
+		}
+	}
+
+	fields["derivative_sources"] = ""
+	if len(img.DerivativeSources) > 0 {
+		if data, err := json.Marshal(img.DerivativeSources); err == nil {
+			fields["derivative_sources"] = string(data)
+		}
 	}
 
 	return fields
@@ -521,33 +971,47 @@ func (r *RedisRepository) fieldsToMetadata(fields map[string]string) (*models.Im
 
 	// Required fields
 	img.ID = fields["id"]
+	if img.ID == "" {
+		return nil, models.CorruptMetadataError{Reason: "missing id field"}
+	}
 	img.OriginalKey = fields["original_key"]
 	img.Filename = fields["filename"]
 	img.MimeType = fields["mime_type"]
 
-	// Parse numeric fields
-	if size, err := strconv.ParseInt(fields["size"], 10, 64); err == nil {
-		img.Size = size
+	// Parse numeric fields; a present but unparseable value means the record is corrupt
+	var err error
+	if img.Size, err = parseIntField(fields, "size"); err != nil {
+		return nil, models.CorruptMetadataError{ID: img.ID, Reason: err.Error()}
 	}
 
-	if width, err := strconv.Atoi(fields["width"]); err == nil {
-		img.Width = width
+	width, err := parseIntField(fields, "width")
+	if err != nil {
+		return nil, models.CorruptMetadataError{ID: img.ID, Reason: err.Error()}
 	}
+	img.Width = int(width)
 
-	if height, err := strconv.Atoi(fields["height"]); err == nil {
-		img.Height = height
+	height, err := parseIntField(fields, "height")
+	if err != nil {
+		return nil, models.CorruptMetadataError{ID: img.ID, Reason: err.Error()}
 	}
+	img.Height = int(height)
 
 	// Parse resolutions
 	if resolutionsStr := fields["resolutions"]; resolutionsStr != "" {
 		img.Resolutions = strings.Split(resolutionsStr, ",")
 	}
 
+	if aliasesStr := fields["original_aliases"]; aliasesStr != "" {
+		img.OriginalAliases = strings.Split(aliasesStr, ",")
+	}
+
 	// Parse timestamps
 	if createdAtStr := fields["created_at"]; createdAtStr != "" {
-		if createdAt, err := time.Parse(time.RFC3339, createdAtStr); err == nil {
-			img.CreatedAt = createdAt
+		createdAt, err := time.Parse(time.RFC3339, createdAtStr)
+		if err != nil {
+			return nil, models.CorruptMetadataError{ID: img.ID, Reason: "invalid created_at field"}
 		}
+		img.CreatedAt = createdAt
 	}
 
 	if updatedAtStr := fields["updated_at"]; updatedAtStr != "" {
@@ -556,6 +1020,12 @@ func (r *RedisRepository) fieldsToMetadata(fields map[string]string) (*models.Im
 		}
 	}
 
+	if lastAccessedStr := fields["last_accessed_at"]; lastAccessedStr != "" {
+		if lastAccessed, err := time.Parse(time.RFC3339, lastAccessedStr); err == nil {
+			img.LastAccessedAt = lastAccessed
+		}
+	}
+
 	// Parse deduplication fields
 	if isDedupedStr := fields["is_deduped"]; isDedupedStr != "" {
 		if isDeduped, err := strconv.ParseBool(isDedupedStr); err == nil {
@@ -565,6 +1035,54 @@ func (r *RedisRepository) fieldsToMetadata(fields map[string]string) (*models.Im
 
 	img.SharedImageID = fields["shared_image_id"]
 
+	if dedupDisabledStr := fields["dedup_disabled"]; dedupDisabledStr != "" {
+		if dedupDisabled, err := strconv.ParseBool(dedupDisabledStr); err == nil {
+			img.DedupDisabled = dedupDisabled
+		}
+	}
+
+	if watermarkDisabledStr := fields["watermark_disabled"]; watermarkDisabledStr != "" {
+		if watermarkDisabled, err := strconv.ParseBool(watermarkDisabledStr); err == nil {
+			img.WatermarkDisabled = watermarkDisabled
+		}
+	}
+
+	// Parse per-resolution output sizes (absent on metadata stored before they were recorded)
+	if dimensionsStr := fields["resolution_dimensions"]; dimensionsStr != "" {
+		var dimensions map[string]models.DimensionInfo
+		if err := json.Unmarshal([]byte(dimensionsStr), &dimensions); err == nil {
+			img.ResolutionDimensions = dimensions
+		}
+	}
+
+	if variantsStr := fields["format_variants"]; variantsStr != "" {
+		var variants map[string][]string
+		if err := json.Unmarshal([]byte(variantsStr), &variants); err == nil {
+			img.FormatVariants = variants
+		}
+	}
+
+	if formatsStr := fields["resolution_formats"]; formatsStr != "" {
+		var formats map[string]string
+		if err := json.Unmarshal([]byte(formatsStr), &formats); err == nil {
+			img.ResolutionFormats = formats
+		}
+	}
+
+	if hashesStr := fields["derivative_hashes"]; hashesStr != "" {
+		var hashes map[string]string
+		if err := json.Unmarshal([]byte(hashesStr), &hashes); err == nil {
+			img.DerivativeHashes = hashes
+		}
+	}
+
+	if sourcesStr := fields["derivative_sources"]; sourcesStr != "" {
+		var sources map[string]string
+		if err := json.Unmarshal([]byte(sourcesStr), &sources); err == nil {
+			img.DerivativeSources = sources
+		}
+	}
+
 	// Parse hash fields if they exist
 	if hashValue := fields["hash_value"]; hashValue != "" {
 		img.Hash.Value = hashValue
@@ -572,6 +1090,7 @@ func (r *RedisRepository) fieldsToMetadata(fields map[string]string) (*models.Im
 		if img.Hash.Algorithm == "" {
 			img.Hash.Algorithm = "SHA256" // Default algorithm
 		}
+		img.Hash.Namespace = fields["hash_namespace"]
 
 		if hashSizeStr := fields["hash_size"]; hashSizeStr != "" {
 			if hashSize, err := strconv.ParseInt(hashSizeStr, 10, 64); err == nil {
@@ -583,8 +1102,36 @@ func (r *RedisRepository) fieldsToMetadata(fields map[string]string) (*models.Im
 	return img, nil
 }
 
-// findKeysByPattern finds all keys matching a pattern
+// findKeysByPattern finds all keys matching a pattern. A cluster spreads keys
+// over its masters, so each master is scanned in turn.
 func (r *RedisRepository) findKeysByPattern(ctx context.Context, pattern string) ([]string, error) {
+	cluster, ok := r.client.(*redis.ClusterClient)
+	if !ok {
+		return scanKeys(ctx, r.client, pattern)
+	}
+
+	var (
+		mu   sync.Mutex
+		keys []string
+	)
+	err := cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+		nodeKeys, err := scanKeys(ctx, node, pattern)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		keys = append(keys, nodeKeys...)
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// scanKeys finds all keys matching a pattern on a single server
+func scanKeys(ctx context.Context, client redis.Cmdable, pattern string) ([]string, error) {
 	var cursor uint64
 	var keys []string
 
@@ -592,7 +1139,7 @@ func (r *RedisRepository) findKeysByPattern(ctx context.Context, pattern string)
 		var scanKeys []string
 		var err error
 
-		scanKeys, cursor, err = r.client.Scan(ctx, cursor, pattern, 100).Result()
+		scanKeys, cursor, err = client.Scan(ctx, cursor, pattern, 100).Result()
 		if err != nil {
 			return nil, err
 		}
@@ -644,6 +1191,8 @@ func (r *RedisRepository) parseInfoValue(info, key string) int64 {
 var _ ImageRepository = (*RedisRepository)(nil)
 var _ CacheRepository = (*RedisRepository)(nil)
 var _ DeduplicationRepository = (*RedisRepository)(nil)
+var _ CacheStatsResetter = (*RedisRepository)(nil)
+var _ AuditRepository = (*RedisRepository)(nil)
 
 // DeduplicationRepository implementation for Redis
 
@@ -655,6 +1204,7 @@ func (r *RedisRepository) StoreDeduplicationInfo(ctx context.Context, info *mode
 		"hash_algorithm":  info.Hash.Algorithm,
 		"hash_value":      info.Hash.Value,
 		"hash_size":       info.Hash.Size,
+		"hash_namespace":  info.Hash.Namespace,
 		"master_image_id": info.MasterImageID,
 		"reference_count": info.ReferenceCount,
 		"storage_key":     info.StorageKey,
@@ -685,6 +1235,7 @@ func (r *RedisRepository) GetDeduplicationInfo(ctx context.Context, hash models.
 		Hash: models.ImageHash{
 			Algorithm: data["hash_algorithm"],
 			Value:     data["hash_value"],
+			Namespace: data["hash_namespace"],
 		},
 		MasterImageID: data["master_image_id"],
 		StorageKey:    data["storage_key"],
@@ -761,7 +1312,7 @@ func (r *RedisRepository) GetOrphanedHashes(ctx context.Context) ([]models.Image
 	var orphanedHashes []models.ImageHash
 
 	// Get all deduplication keys
-	keys, err := r.client.Keys(ctx, "dedup:*").Result()
+	keys, err := r.findKeysByPattern(ctx, "dedup:*")
 	if err != nil {
 		return nil, err
 	}
@@ -784,6 +1335,7 @@ func (r *RedisRepository) GetOrphanedHashes(ctx context.Context) ([]models.Image
 				hash := models.ImageHash{
 					Algorithm: data["hash_algorithm"],
 					Value:     data["hash_value"],
+					Namespace: data["hash_namespace"],
 				}
 				if sizeStr, ok := data["hash_size"]; ok {
 					if size, err := strconv.ParseInt(sizeStr, 10, 64); err == nil {
@@ -849,6 +1401,7 @@ func (r *RedisRepository) GetImageStatistics(ctx context.Context) (*models.Image
 	monthStart := todayStart.AddDate(0, -1, 0)
 
 	var imagesToday, imagesWeek, imagesMonth int64
+	averager := newFormatAverager()
 
 	// Process each image
 	for _, key := range keys {
@@ -861,6 +1414,12 @@ func (r *RedisRepository) GetImageStatistics(ctx context.Context) (*models.Image
 		if mimeType, ok := data["mime_type"]; ok {
 			format := strings.TrimPrefix(mimeType, "image/")
 			formatCounts[format]++
+
+			// Accumulate per-format dimensions and size
+			width, _ := strconv.Atoi(data["width"])
+			height, _ := strconv.Atoi(data["height"])
+			size, _ := strconv.ParseInt(data["size"], 10, 64)
+			averager.add(mimeType, width, height, size)
 		}
 
 		// Calculate size statistics
@@ -943,15 +1502,19 @@ func (r *RedisRepository) GetImageStatistics(ctx context.Context) (*models.Image
 		totalResolutions += count
 	}
 
+	averageDimensions, averageSizes := averager.averages()
+
 	return &models.ImageStatistics{
-		TotalImages:        totalImages,
-		ImagesByFormat:     formatCounts,
-		ResolutionCounts:   resolutionCounts,
-		ImagesCreatedToday: imagesToday,
-		ImagesCreatedWeek:  imagesWeek,
-		ImagesCreatedMonth: imagesMonth,
-		TotalResolutions:   totalResolutions,
-		TopResolutions:     topResolutions,
+		TotalImages:               totalImages,
+		ImagesByFormat:            formatCounts,
+		ResolutionCounts:          resolutionCounts,
+		ImagesCreatedToday:        imagesToday,
+		ImagesCreatedWeek:         imagesWeek,
+		ImagesCreatedMonth:        imagesMonth,
+		TotalResolutions:          totalResolutions,
+		TopResolutions:            topResolutions,
+		AverageDimensionsByFormat: averageDimensions,
+		AverageSizeByFormat:       averageSizes,
 	}, nil
 }
 
@@ -1101,6 +1664,31 @@ func (r *RedisRepository) GetImagesByTimeRange(ctx context.Context, start, end t
 	return count, nil
 }
 
+// GetUploadCountsByDay returns per-day upload counts for the given number of days starting at start
+func (r *RedisRepository) GetUploadCountsByDay(ctx context.Context, start time.Time, days int) ([]models.DailyUploadCount, error) {
+	// Get all image metadata keys
+	keys, err := r.findKeysByPattern(ctx, r.getMetadataKey("*"))
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := newDailyUploadBuckets(start, days)
+
+	// Process each image
+	for _, key := range keys {
+		createdAtStr, err := r.client.HGet(ctx, key, "created_at").Result()
+		if err != nil {
+			continue
+		}
+
+		if createdAt, err := time.Parse(time.RFC3339, createdAtStr); err == nil {
+			buckets.add(createdAt)
+		}
+	}
+
+	return buckets.result(), nil
+}
+
 // GetStorageUsageByResolution returns storage usage per resolution
 func (r *RedisRepository) GetStorageUsageByResolution(ctx context.Context) (map[string]int64, error) {
 	// Get all image metadata keys
@@ -1152,7 +1740,7 @@ func (r *RedisRepository) GetStorageUsageByResolution(ctx context.Context) (map[
 // GetDeduplicationStatistics retrieves comprehensive deduplication statistics
 func (r *RedisRepository) GetDeduplicationStatistics(ctx context.Context) (*models.DeduplicationStatistics, error) {
 	// Get all deduplication keys
-	keys, err := r.client.Keys(ctx, "dedup:*").Result()
+	keys, err := r.findKeysByPattern(ctx, "dedup:*")
 	if err != nil {
 		return nil, err
 	}
@@ -1197,7 +1785,7 @@ func (r *RedisRepository) GetDeduplicationStatistics(ctx context.Context) (*mode
 func (r *RedisRepository) GetHashStatistics(ctx context.Context) ([]models.HashStat, error) {
 	var hashStats []models.HashStat
 
-	keys, err := r.client.Keys(ctx, "dedup:*").Result()
+	keys, err := r.findKeysByPattern(ctx, "dedup:*")
 	if err != nil {
 		return nil, err
 	}
@@ -1227,7 +1815,7 @@ func (r *RedisRepository) GetHashStatistics(ctx context.Context) ([]models.HashS
 
 // GetDuplicateCount returns total number of duplicate images
 func (r *RedisRepository) GetDuplicateCount(ctx context.Context) (int64, error) {
-	keys, err := r.client.Keys(ctx, "dedup:*").Result()
+	keys, err := r.findKeysByPattern(ctx, "dedup:*")
 	if err != nil {
 		return 0, err
 	}
@@ -1249,7 +1837,7 @@ func (r *RedisRepository) GetDuplicateCount(ctx context.Context) (int64, error)
 
 // GetUniqueHashCount returns number of unique hashes
 func (r *RedisRepository) GetUniqueHashCount(ctx context.Context) (int64, error) {
-	keys, err := r.client.Keys(ctx, "dedup:*").Result()
+	keys, err := r.findKeysByPattern(ctx, "dedup:*")
 	if err != nil {
 		return 0, err
 	}
@@ -1258,7 +1846,7 @@ func (r *RedisRepository) GetUniqueHashCount(ctx context.Context) (int64, error)
 
 // GetStorageSavedByDeduplication calculates total storage saved
 func (r *RedisRepository) GetStorageSavedByDeduplication(ctx context.Context) (int64, error) {
-	keys, err := r.client.Keys(ctx, "dedup:*").Result()
+	keys, err := r.findKeysByPattern(ctx, "dedup:*")
 	if err != nil {
 		return 0, err
 	}
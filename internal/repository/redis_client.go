@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"fmt"
+
+	"resizr/internal/config"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// newRedisClient creates the client for the configured Redis topology. Every mode
+// yields a redis.UniversalClient, so the repository works the same against a
+// single server, a cluster or a Sentinel-managed master.
+func newRedisClient(cfg *config.RedisConfig) (redis.UniversalClient, error) {
+	switch cfg.Mode {
+	case config.RedisModeCluster:
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:        cfg.ClusterAddrs,
+			Password:     cfg.Password,
+			PoolSize:     cfg.PoolSize,
+			DialTimeout:  cfg.Timeout,
+			ReadTimeout:  cfg.Timeout,
+			WriteTimeout: cfg.Timeout,
+		}), nil
+
+	case config.RedisModeSentinel:
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:    cfg.MasterName,
+			SentinelAddrs: cfg.SentinelAddrs,
+			Password:      cfg.Password,
+			DB:            cfg.DB,
+			PoolSize:      cfg.PoolSize,
+			DialTimeout:   cfg.Timeout,
+			ReadTimeout:   cfg.Timeout,
+			WriteTimeout:  cfg.Timeout,
+		}), nil
+
+	default:
+		// Parse Redis URL and override it with config values
+		opt, err := redis.ParseURL(cfg.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Redis URL: %w", err)
+		}
+
+		opt.Password = cfg.Password
+		opt.DB = cfg.DB
+		opt.PoolSize = cfg.PoolSize
+		opt.DialTimeout = cfg.Timeout
+		opt.ReadTimeout = cfg.Timeout
+		opt.WriteTimeout = cfg.Timeout
+
+		return redis.NewClient(opt), nil
+	}
+}
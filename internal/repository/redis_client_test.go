@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"resizr/internal/config"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRedisClient(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      config.RedisConfig
+		expected redis.UniversalClient
+	}{
+		{"single server", config.RedisConfig{Mode: config.RedisModeSingle, URL: "redis://localhost:6379"}, &redis.Client{}},
+		{"unset mode uses the url", config.RedisConfig{URL: "redis://localhost:6379"}, &redis.Client{}},
+		{"cluster", config.RedisConfig{Mode: config.RedisModeCluster, ClusterAddrs: []string{"node-1:6379"}}, &redis.ClusterClient{}},
+		{"sentinel", config.RedisConfig{Mode: config.RedisModeSentinel, MasterName: "mymaster", SentinelAddrs: []string{"sentinel-1:26379"}}, &redis.Client{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.Timeout = time.Second
+
+			client, err := newRedisClient(&tt.cfg)
+			require.NoError(t, err)
+			defer client.Close()
+
+			assert.IsType(t, tt.expected, client)
+		})
+	}
+
+	t.Run("invalid url", func(t *testing.T) {
+		_, err := newRedisClient(&config.RedisConfig{Mode: config.RedisModeSingle, URL: "not-a-url"})
+		assert.ErrorContains(t, err, "invalid Redis URL")
+	})
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"testing"
 	"time"
@@ -408,3 +409,195 @@ func TestRedisRepository_DeduplicationFlag(t *testing.T) {
 		assert.Empty(t, retrieved.SharedImageID)
 	})
 }
+
+func TestRedisRepository_ResolutionDimensionsFields(t *testing.T) {
+	repo := &RedisRepository{}
+
+	t.Run("round trip", func(t *testing.T) {
+		metadata := models.NewImageMetadata("test-image", "photo.jpg", "image/jpeg", 2048, 1920, 1080)
+		metadata.Resolutions = []string{"thumbnail", "800x600:small"}
+		metadata.SetResolutionDimensions("thumbnail", models.DimensionInfo{Width: 150, Height: 150})
+		metadata.SetResolutionDimensions("800x600:small", models.DimensionInfo{Width: 800, Height: 600})
+
+		fields := repo.metadataToFields(metadata)
+		stringFields := make(map[string]string, len(fields))
+		for key, value := range fields {
+			stringFields[key] = fmt.Sprint(value)
+		}
+
+		retrieved, err := repo.fieldsToMetadata(stringFields)
+		require.NoError(t, err)
+		assert.Equal(t, metadata.ResolutionDimensions, retrieved.ResolutionDimensions)
+	})
+
+	t.Run("empty map clears the field", func(t *testing.T) {
+		metadata := models.NewImageMetadata("test-image", "photo.jpg", "image/jpeg", 2048, 1920, 1080)
+
+		fields := repo.metadataToFields(metadata)
+		assert.Equal(t, "", fields["resolution_dimensions"])
+	})
+}
+
+func TestRedisRepository_DedupDisabledField(t *testing.T) {
+	repo := &RedisRepository{}
+
+	for _, disabled := range []bool{true, false} {
+		metadata := models.NewImageMetadataWithHash("test-image", "photo.jpg", "image/jpeg", 2048, 1920, 1080,
+			models.ImageHash{Algorithm: "SHA256", Value: "abc123", Size: 2048})
+		metadata.DedupDisabled = disabled
+		metadata.WatermarkDisabled = disabled
+
+		fields := repo.metadataToFields(metadata)
+		stringFields := make(map[string]string, len(fields))
+		for key, value := range fields {
+			stringFields[key] = fmt.Sprint(value)
+		}
+
+		retrieved, err := repo.fieldsToMetadata(stringFields)
+		require.NoError(t, err)
+		assert.Equal(t, disabled, retrieved.DedupDisabled)
+		assert.Equal(t, disabled, retrieved.WatermarkDisabled)
+		assert.Equal(t, "abc123", retrieved.Hash.Value)
+	}
+}
+
+func TestRedisRepository_HashNamespaceField(t *testing.T) {
+	repo := &RedisRepository{}
+
+	metadata := models.NewImageMetadataWithHash("test-image", "photo.jpg", "image/jpeg", 2048, 1920, 1080,
+		models.ImageHash{Algorithm: "SHA256", Value: "abc123", Size: 2048, Namespace: "tenant-a"})
+
+	fields := repo.metadataToFields(metadata)
+	stringFields := make(map[string]string, len(fields))
+	for key, value := range fields {
+		stringFields[key] = fmt.Sprint(value)
+	}
+
+	retrieved, err := repo.fieldsToMetadata(stringFields)
+	require.NoError(t, err)
+	assert.True(t, metadata.Hash.Equals(retrieved.Hash))
+	assert.Equal(t, metadata.Hash.GetHashKey(), retrieved.Hash.GetHashKey())
+}
+
+func TestRedisRepository_LastAccessedAtField(t *testing.T) {
+	repo := &RedisRepository{}
+
+	metadata := models.NewImageMetadata("test-image", "photo.jpg", "image/jpeg", 2048, 1920, 1080)
+	fields := repo.metadataToFields(metadata)
+	assert.NotContains(t, fields, "last_accessed_at", "never accessed images have no timestamp")
+
+	metadata.LastAccessedAt = time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	fields = repo.metadataToFields(metadata)
+	stringFields := make(map[string]string, len(fields))
+	for key, value := range fields {
+		stringFields[key] = fmt.Sprint(value)
+	}
+
+	retrieved, err := repo.fieldsToMetadata(stringFields)
+	require.NoError(t, err)
+	assert.True(t, metadata.LastAccessedAt.Equal(retrieved.LastAccessedAt))
+}
+
+func TestRedisRepository_FormatVariantsField(t *testing.T) {
+	repo := &RedisRepository{}
+
+	metadata := models.NewImageMetadata("test-image", "photo.jpg", "image/jpeg", 2048, 1920, 1080)
+	metadata.Resolutions = []string{"800x600"}
+	assert.Equal(t, "", repo.metadataToFields(metadata)["format_variants"])
+
+	metadata.SetFormatVariants("800x600", []string{"webp", "png"})
+	fields := repo.metadataToFields(metadata)
+	stringFields := make(map[string]string, len(fields))
+	for key, value := range fields {
+		stringFields[key] = fmt.Sprint(value)
+	}
+
+	retrieved, err := repo.fieldsToMetadata(stringFields)
+	require.NoError(t, err)
+	assert.Equal(t, metadata.FormatVariants, retrieved.FormatVariants)
+}
+
+func TestRedisRepository_ResolutionFormatsField(t *testing.T) {
+	repo := &RedisRepository{}
+
+	metadata := models.NewImageMetadata("test-image", "photo.jpg", "image/jpeg", 2048, 1920, 1080)
+	metadata.Resolutions = []string{"800x600"}
+	assert.Equal(t, "", repo.metadataToFields(metadata)["resolution_formats"])
+
+	metadata.SetResolutionFormat("800x600", "png")
+	fields := repo.metadataToFields(metadata)
+	stringFields := make(map[string]string, len(fields))
+	for key, value := range fields {
+		stringFields[key] = fmt.Sprint(value)
+	}
+
+	retrieved, err := repo.fieldsToMetadata(stringFields)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"800x600": "png"}, retrieved.ResolutionFormats)
+}
+
+func TestRedisRepository_DerivativeFields(t *testing.T) {
+	repo := &RedisRepository{}
+
+	metadata := models.NewImageMetadata("test-image", "photo.jpg", "image/jpeg", 2048, 1920, 1080)
+	metadata.Resolutions = []string{"thumbnail", "150x150"}
+	assert.Equal(t, "", repo.metadataToFields(metadata)["derivative_hashes"])
+	assert.Equal(t, "", repo.metadataToFields(metadata)["derivative_sources"])
+
+	metadata.SetDerivativeHash("thumbnail", "abc123")
+	metadata.SetDerivativeSource("150x150", "thumbnail")
+	fields := repo.metadataToFields(metadata)
+	stringFields := make(map[string]string, len(fields))
+	for key, value := range fields {
+		stringFields[key] = fmt.Sprint(value)
+	}
+
+	retrieved, err := repo.fieldsToMetadata(stringFields)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"thumbnail": "abc123"}, retrieved.DerivativeHashes)
+	assert.Equal(t, map[string]string{"150x150": "thumbnail"}, retrieved.DerivativeSources)
+}
+
+func TestRedisRepository_CorruptFields(t *testing.T) {
+	repo := &RedisRepository{}
+
+	valid := map[string]string{
+		"id":         "test-image",
+		"filename":   "photo.jpg",
+		"mime_type":  "image/jpeg",
+		"size":       "2048",
+		"width":      "1920",
+		"height":     "1080",
+		"created_at": "2025-01-01T00:00:00Z",
+	}
+	_, err := repo.fieldsToMetadata(valid)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		field string
+		value string
+	}{
+		{"missing id", "id", ""},
+		{"unparseable size", "size", "big"},
+		{"unparseable width", "width", "1920px"},
+		{"unparseable height", "height", "-"},
+		{"unparseable created_at", "created_at", "yesterday"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := make(map[string]string, len(valid))
+			for key, value := range valid {
+				fields[key] = value
+			}
+			fields[tt.field] = tt.value
+
+			_, err := repo.fieldsToMetadata(fields)
+
+			var corruptErr models.CorruptMetadataError
+			require.ErrorAs(t, err, &corruptErr)
+			assert.NotEmpty(t, corruptErr.Reason)
+		})
+	}
+}
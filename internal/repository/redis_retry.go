@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	"resizr/pkg/logger"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// withRetry runs op, retrying it with exponential backoff while it fails with a
+// transient error. It gives up early when ctx is done.
+func (r *RedisRepository) withRetry(ctx context.Context, operation string, op func() error) error {
+	attempts := 0
+	backoff := time.Duration(0)
+	if r.config != nil {
+		attempts = r.config.RetryAttempts
+		backoff = r.config.RetryBackoff
+	}
+
+	err := op()
+	for attempt := 1; attempt <= attempts && isRetryableRedisError(err); attempt++ {
+		logger.WarnWithContext(ctx, "Transient Redis error, retrying",
+			zap.String("operation", operation),
+			zap.Int("attempt", attempt),
+			zap.Duration("backoff", backoff),
+			zap.Error(err))
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+
+		err = op()
+		backoff *= 2
+	}
+	return err
+}
+
+// isRetryableRedisError reports whether err is a connection-level failure worth
+// retrying, as opposed to a missing key or a command Redis rejected
+func isRetryableRedisError(err error) bool {
+	if err == nil || errors.Is(err, redis.Nil) {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	message := err.Error()
+	return strings.Contains(message, "connection refused") ||
+		strings.Contains(message, "connection reset") ||
+		strings.Contains(message, "i/o timeout")
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"resizr/internal/config"
+	"resizr/internal/models"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyRedis fails the first failures hash reads with err, then serves fields
+type flakyRedis struct {
+	redis.Cmdable
+	err      error
+	failures int
+	fields   map[string]string
+	calls    int
+}
+
+func (f *flakyRedis) HGetAll(ctx context.Context, key string) *redis.StringStringMapCmd {
+	f.calls++
+	if f.calls <= f.failures {
+		return redis.NewStringStringMapResult(nil, f.err)
+	}
+	return redis.NewStringStringMapResult(f.fields, nil)
+}
+
+func newRetryingRedisRepository(client redis.Cmdable, attempts int) *RedisRepository {
+	return &RedisRepository{
+		client: client,
+		config: &config.RedisConfig{RetryAttempts: attempts, RetryBackoff: time.Millisecond},
+	}
+}
+
+func TestRedisRepository_RetriesTransientErrors(t *testing.T) {
+	connRefused := &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+	hash := map[string]string{
+		"id":          "3c7a8f9e-1234-4abc-8def-0123456789ab",
+		"filename":    "photo.jpg",
+		"mime_type":   "image/jpeg",
+		"size":        "1024",
+		"width":       "800",
+		"height":      "600",
+		"resolutions": "original",
+	}
+
+	t.Run("succeeds on retry", func(t *testing.T) {
+		client := &flakyRedis{err: connRefused, failures: 2, fields: hash}
+		repo := newRetryingRedisRepository(client, 2)
+
+		img, err := repo.Get(context.Background(), "3c7a8f9e-1234-4abc-8def-0123456789ab")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "photo.jpg", img.Filename)
+		assert.Equal(t, 3, client.calls)
+	})
+
+	t.Run("gives up after the configured attempts", func(t *testing.T) {
+		client := &flakyRedis{err: connRefused, failures: 5, fields: hash}
+		repo := newRetryingRedisRepository(client, 2)
+
+		_, err := repo.Get(context.Background(), "3c7a8f9e-1234-4abc-8def-0123456789ab")
+
+		assert.ErrorIs(t, err, syscall.ECONNREFUSED)
+		assert.Equal(t, 3, client.calls)
+	})
+
+	t.Run("not found is not retried", func(t *testing.T) {
+		client := &flakyRedis{fields: map[string]string{}}
+		repo := newRetryingRedisRepository(client, 2)
+
+		_, err := repo.Get(context.Background(), "missing")
+
+		assert.IsType(t, models.NotFoundError{}, err)
+		assert.Equal(t, 1, client.calls)
+	})
+
+	t.Run("command errors are not retried", func(t *testing.T) {
+		client := &flakyRedis{err: errors.New("ERR unknown command"), failures: 1, fields: hash}
+		repo := newRetryingRedisRepository(client, 2)
+
+		_, err := repo.Get(context.Background(), "3c7a8f9e-1234-4abc-8def-0123456789ab")
+
+		assert.Error(t, err)
+		assert.Equal(t, 1, client.calls)
+	})
+
+	t.Run("cancelled context stops retrying", func(t *testing.T) {
+		client := &flakyRedis{err: connRefused, failures: 5, fields: hash}
+		repo := newRetryingRedisRepository(client, 3)
+		repo.config.RetryBackoff = time.Hour
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := repo.Get(ctx, "3c7a8f9e-1234-4abc-8def-0123456789ab")
+
+		assert.Error(t, err)
+		assert.Equal(t, 1, client.calls)
+	})
+}
+
+func TestIsRetryableRedisError(t *testing.T) {
+	assert.True(t, isRetryableRedisError(&net.OpError{Op: "read", Err: syscall.ECONNRESET}))
+	assert.True(t, isRetryableRedisError(errors.New("dial tcp 10.0.0.1:6379: i/o timeout")))
+	assert.False(t, isRetryableRedisError(nil))
+	assert.False(t, isRetryableRedisError(redis.Nil))
+	assert.False(t, isRetryableRedisError(context.Canceled))
+	assert.False(t, isRetryableRedisError(errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")))
+}
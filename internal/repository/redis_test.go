@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"resizr/internal/config"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startFakeRedis serves a minimal RESP responder: SCAN returns no keys, INFO an
+// empty section and every other command OK. It is enough to exercise the
+// client's connection pool without a Redis server.
+func startFakeRedis(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveFakeRedis(conn)
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func serveFakeRedis(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	for {
+		// Each command is an array header followed by length-prefixed arguments
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		count, _ := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(header, "*")))
+
+		var command string
+		for i := 0; i < count; i++ {
+			if _, err := reader.ReadString('\n'); err != nil {
+				return
+			}
+			arg, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if i == 0 {
+				command = strings.ToUpper(strings.TrimSpace(arg))
+			}
+		}
+
+		reply := "+OK\r\n"
+		switch command {
+		case "PING":
+			reply = "+PONG\r\n"
+		case "SCAN":
+			reply = "*2\r\n$1\r\n0\r\n*0\r\n"
+		case "INFO":
+			reply = "$0\r\n\r\n"
+		}
+		if _, err := conn.Write([]byte(reply)); err != nil {
+			return
+		}
+	}
+}
+
+func TestRedisRepository_GetStats_PoolStats(t *testing.T) {
+	cfg := &config.RedisConfig{
+		URL:      "redis://" + startFakeRedis(t),
+		PoolSize: 4,
+		Timeout:  time.Second,
+	}
+	client, err := newRedisClient(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	repo := &RedisRepository{client: client, config: cfg}
+	ctx := context.Background()
+
+	before, err := repo.GetStats(ctx)
+	require.NoError(t, err)
+
+	// Hold two connections at once so the pool has to open a second one
+	require.NoError(t, client.Ping(ctx).Err())
+	err = client.Watch(ctx, func(tx *redis.Tx) error {
+		return client.Ping(ctx).Err()
+	}, "key")
+	require.NoError(t, err)
+
+	stats, err := repo.GetStats(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, stats.Connections.MaxOpen)
+	assert.Equal(t, 2, stats.Connections.Total)
+	assert.Equal(t, 2, stats.Connections.Idle)
+	assert.Zero(t, stats.Connections.Active)
+	assert.Greater(t, stats.Connections.Total, before.Connections.Total)
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"time"
+
+	"resizr/internal/models"
+)
+
+// uploadDateLayout is the day format used for histogram buckets
+const uploadDateLayout = "2006-01-02"
+
+// dailyUploadBuckets counts uploads per UTC day within a fixed window
+type dailyUploadBuckets struct {
+	start  time.Time
+	counts []int64
+}
+
+// newDailyUploadBuckets creates empty buckets covering days starting at the UTC day of start
+func newDailyUploadBuckets(start time.Time, days int) *dailyUploadBuckets {
+	start = start.UTC()
+	if days < 0 {
+		days = 0
+	}
+
+	return &dailyUploadBuckets{
+		start:  time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC),
+		counts: make([]int64, days),
+	}
+}
+
+// add records an upload at createdAt, ignoring times outside the window
+func (b *dailyUploadBuckets) add(createdAt time.Time) {
+	if createdAt.IsZero() || createdAt.Before(b.start) {
+		return
+	}
+
+	index := int(createdAt.UTC().Sub(b.start) / (24 * time.Hour))
+	if index < len(b.counts) {
+		b.counts[index]++
+	}
+}
+
+// result returns one entry per day in chronological order, including empty days
+func (b *dailyUploadBuckets) result() []models.DailyUploadCount {
+	result := make([]models.DailyUploadCount, len(b.counts))
+	for i, count := range b.counts {
+		result[i] = models.DailyUploadCount{
+			Date:  b.start.AddDate(0, 0, i).Format(uploadDateLayout),
+			Count: count,
+		}
+	}
+	return result
+}
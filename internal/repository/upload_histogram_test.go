@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"resizr/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDailyUploadBuckets(t *testing.T) {
+	start := time.Date(2024, 3, 10, 15, 30, 0, 0, time.UTC)
+	buckets := newDailyUploadBuckets(start, 3)
+
+	buckets.add(time.Date(2024, 3, 9, 23, 59, 0, 0, time.UTC)) // before window
+	buckets.add(time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC))
+	buckets.add(time.Date(2024, 3, 10, 22, 0, 0, 0, time.UTC))
+	buckets.add(time.Date(2024, 3, 12, 8, 0, 0, 0, time.UTC))
+	buckets.add(time.Date(2024, 3, 13, 0, 0, 0, 0, time.UTC)) // after window
+	buckets.add(time.Time{})
+
+	assert.Equal(t, []models.DailyUploadCount{
+		{Date: "2024-03-10", Count: 2},
+		{Date: "2024-03-11", Count: 0},
+		{Date: "2024-03-12", Count: 1},
+	}, buckets.result())
+}
+
+func TestDailyUploadBuckets_NonUTC(t *testing.T) {
+	zone := time.FixedZone("UTC+2", 2*60*60)
+	buckets := newDailyUploadBuckets(time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC), 2)
+
+	// 01:00 at UTC+2 on the 11th is 23:00 UTC on the 10th
+	buckets.add(time.Date(2024, 3, 11, 1, 0, 0, 0, zone))
+
+	result := buckets.result()
+	assert.Equal(t, int64(1), result[0].Count)
+	assert.Equal(t, int64(0), result[1].Count)
+}
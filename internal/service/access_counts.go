@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"resizr/internal/models"
+	"resizr/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// accessBuffer collects download counts in memory so a download costs no repository
+// write; flushes persist them as one increment per image. It also remembers when the
+// LastAccessedAt of each image was last written, to throttle those writes.
+type accessBuffer struct {
+	mu      sync.Mutex
+	pending map[string]map[string]int64
+
+	touched   map[string]time.Time
+	lastPrune time.Time
+}
+
+// newAccessBuffer creates an empty buffer
+func newAccessBuffer() *accessBuffer {
+	return &accessBuffer{
+		pending: make(map[string]map[string]int64),
+		touched: make(map[string]time.Time),
+	}
+}
+
+// add records count downloads of a resolution of an image
+func (a *accessBuffer) add(imageID, resolution string, count int64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	counts, exists := a.pending[imageID]
+	if !exists {
+		counts = make(map[string]int64)
+		a.pending[imageID] = counts
+	}
+	counts[resolution] += count
+}
+
+// drain returns the buffered counts and empties the buffer
+func (a *accessBuffer) drain() map[string]map[string]int64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	pending := a.pending
+	a.pending = make(map[string]map[string]int64)
+	return pending
+}
+
+// pendingFor returns a copy of the counts buffered for an image
+func (a *accessBuffer) pendingFor(imageID string) map[string]int64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	counts := make(map[string]int64, len(a.pending[imageID]))
+	for resolution, count := range a.pending[imageID] {
+		counts[resolution] = count
+	}
+	return counts
+}
+
+// claimTouch reports whether the LastAccessedAt of an image is due for a write at now,
+// and if so records now as its last write. Entries older than throttle are pruned
+// once per throttle period, so images that went cold do not accumulate.
+func (a *accessBuffer) claimTouch(imageID string, now time.Time, throttle time.Duration) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if now.Sub(a.lastPrune) >= throttle {
+		for id, at := range a.touched {
+			if now.Sub(at) >= throttle {
+				delete(a.touched, id)
+			}
+		}
+		a.lastPrune = now
+	}
+
+	if at, exists := a.touched[imageID]; exists && now.Sub(at) < throttle {
+		return false
+	}
+	a.touched[imageID] = now
+	return true
+}
+
+// releaseTouch forgets the last write of an image so the next access retries it
+func (a *accessBuffer) releaseTouch(imageID string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	delete(a.touched, imageID)
+}
+
+// RecordAccess counts a successful download of a resolution and touches the image's
+// LastAccessedAt. Counts are buffered and persisted by FlushAccessCounts, or written
+// through when STATISTICS_ACCESS_FLUSH_INTERVAL is 0.
+func (s *ImageServiceImpl) RecordAccess(ctx context.Context, imageID, resolution string) {
+	s.TouchLastAccessed(ctx, imageID)
+	s.access.add(imageID, resolution, 1)
+
+	if s.config.Statistics.AccessFlushInterval <= 0 {
+		s.FlushAccessCounts(ctx)
+	}
+}
+
+// TouchLastAccessed records that an image was accessed now. The write is skipped when
+// the image's LastAccessedAt was written less than STATISTICS_LAST_ACCESSED_THROTTLE ago,
+// so a hot image costs at most one metadata write per throttle period.
+func (s *ImageServiceImpl) TouchLastAccessed(ctx context.Context, imageID string) {
+	now := time.Now().UTC()
+	if throttle := s.config.Statistics.LastAccessedThrottle; throttle > 0 && !s.access.claimTouch(imageID, now, throttle) {
+		return
+	}
+
+	err := s.repo.TouchLastAccessed(ctx, imageID, now)
+	s.metaCache.invalidate(imageID)
+	if err == nil {
+		return
+	}
+	if _, ok := err.(models.NotFoundError); ok {
+		return
+	}
+
+	s.access.releaseTouch(imageID)
+	logger.WarnWithContext(ctx, "Failed to update last accessed time",
+		zap.String("image_id", imageID),
+		zap.Error(err))
+}
+
+// GetAccessCounts returns the download counters of an image, including downloads
+// not flushed yet
+func (s *ImageServiceImpl) GetAccessCounts(ctx context.Context, imageID string) (*models.AccessCounts, error) {
+	counts, err := s.repo.GetAccessCounts(ctx, imageID)
+	if err != nil {
+		return nil, models.StorageError{
+			Operation: "get_access_counts",
+			Backend:   "Repository",
+			Reason:    err.Error(),
+		}
+	}
+
+	for resolution, count := range s.access.pendingFor(imageID) {
+		counts.Add(resolution, count)
+	}
+	return counts, nil
+}
+
+// FlushAccessCounts persists the buffered download counts. Counts that fail to persist
+// are buffered again for the next flush; counts of deleted images are dropped.
+func (s *ImageServiceImpl) FlushAccessCounts(ctx context.Context) {
+	pending := s.access.drain()
+	if len(pending) == 0 {
+		return
+	}
+
+	var failed int
+	for imageID, counts := range pending {
+		err := s.repo.IncrementAccessCounts(ctx, imageID, counts)
+		if err == nil {
+			continue
+		}
+		if _, ok := err.(models.NotFoundError); ok {
+			continue
+		}
+
+		failed++
+		for resolution, count := range counts {
+			s.access.add(imageID, resolution, count)
+		}
+		logger.WarnWithContext(ctx, "Failed to persist access counts",
+			zap.String("image_id", imageID),
+			zap.Error(err))
+	}
+
+	logger.DebugWithContext(ctx, "Access counts flushed",
+		zap.Int("images", len(pending)),
+		zap.Int("failed", failed))
+}
+
+// StartAccessCountFlush persists buffered download counts on every
+// AccessFlushInterval until ctx is cancelled. It does nothing when counts are
+// written through. Callers flush once more at shutdown so no counts are lost.
+func (s *ImageServiceImpl) StartAccessCountFlush(ctx context.Context) {
+	interval := s.config.Statistics.AccessFlushInterval
+	if interval <= 0 {
+		logger.Debug("Access counts are written through, no background flush")
+		return
+	}
+
+	logger.Info("Starting access count flush",
+		zap.Duration("interval", interval))
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				logger.Info("Access count flush stopped")
+				return
+			case <-ticker.C:
+				s.FlushAccessCounts(ctx)
+			}
+		}
+	}()
+}
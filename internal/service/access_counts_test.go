@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"resizr/internal/models"
+	"resizr/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImageService_AccessCounts(t *testing.T) {
+	const (
+		imageID = "a1b2c3d4-0000-4000-8000-000000000001"
+		otherID = "a1b2c3d4-0000-4000-8000-000000000002"
+	)
+
+	// newService returns a service whose repository accumulates increments in stored
+	newService := func(flushInterval time.Duration, incrementErr func(id string) error) (*ImageServiceImpl, map[string]*models.AccessCounts) {
+		var mu sync.Mutex
+		stored := map[string]*models.AccessCounts{}
+		repo := &mockImageRepositoryForImageService{
+			incrementAccessCountsFunc: func(ctx context.Context, id string, counts map[string]int64) error {
+				if incrementErr != nil {
+					if err := incrementErr(id); err != nil {
+						return err
+					}
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				if stored[id] == nil {
+					stored[id] = &models.AccessCounts{}
+				}
+				for resolution, count := range counts {
+					stored[id].Add(resolution, count)
+				}
+				return nil
+			},
+			getAccessCountsFunc: func(ctx context.Context, id string) (*models.AccessCounts, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				counts := &models.AccessCounts{}
+				if stored[id] != nil {
+					for resolution, count := range stored[id].ByResolution {
+						counts.Add(resolution, count)
+					}
+				}
+				return counts, nil
+			},
+		}
+		cfg := testutil.TestConfig()
+		cfg.Statistics.AccessFlushInterval = flushInterval
+		return NewImageService(repo, &testutil.MockDeduplicationRepository{}, &mockStorageProviderForImageService{}, &mockProcessorServiceForImageService{}, cfg).(*ImageServiceImpl), stored
+	}
+	ctx := context.Background()
+
+	t.Run("downloads are buffered until flushed", func(t *testing.T) {
+		service, stored := newService(time.Minute, nil)
+
+		service.RecordAccess(ctx, imageID, "thumbnail")
+		service.RecordAccess(ctx, imageID, "thumbnail")
+		service.RecordAccess(ctx, imageID, "original")
+		service.RecordAccess(ctx, otherID, "original")
+		assert.Empty(t, stored, "recording a download must not write to the repository")
+
+		// Buffered downloads already show up in the counters
+		counts, err := service.GetAccessCounts(ctx, imageID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), counts.Total)
+
+		service.FlushAccessCounts(ctx)
+		assert.Equal(t, &models.AccessCounts{Total: 3, ByResolution: map[string]int64{"thumbnail": 2, "original": 1}}, stored[imageID])
+		assert.Equal(t, int64(1), stored[otherID].Total)
+
+		// Later downloads add to the persisted counts instead of double counting
+		service.RecordAccess(ctx, imageID, "thumbnail")
+		service.FlushAccessCounts(ctx)
+		counts, err = service.GetAccessCounts(ctx, imageID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), counts.Total)
+		assert.Equal(t, int64(3), counts.ByResolution["thumbnail"])
+	})
+
+	t.Run("failed flush keeps counts for the next one", func(t *testing.T) {
+		failing := true
+		service, stored := newService(time.Minute, func(id string) error {
+			if failing {
+				return errors.New("repository unavailable")
+			}
+			return nil
+		})
+
+		service.RecordAccess(ctx, imageID, "original")
+		service.FlushAccessCounts(ctx)
+		assert.Empty(t, stored)
+
+		failing = false
+		service.RecordAccess(ctx, imageID, "original")
+		service.FlushAccessCounts(ctx)
+		assert.Equal(t, int64(2), stored[imageID].Total)
+	})
+
+	t.Run("counts of deleted images are dropped", func(t *testing.T) {
+		service, _ := newService(time.Minute, func(id string) error {
+			return models.NotFoundError{Resource: "image", ID: id}
+		})
+
+		service.RecordAccess(ctx, imageID, "original")
+		service.FlushAccessCounts(ctx)
+		assert.Empty(t, service.access.pendingFor(imageID))
+	})
+
+	t.Run("zero interval writes through", func(t *testing.T) {
+		service, stored := newService(0, nil)
+
+		service.RecordAccess(ctx, imageID, "800x600")
+		assert.Equal(t, int64(1), stored[imageID].ByResolution["800x600"])
+	})
+}
+
+func TestImageService_StartAccessCountFlush(t *testing.T) {
+	flushed := make(chan map[string]int64, 10)
+	repo := &mockImageRepositoryForImageService{
+		incrementAccessCountsFunc: func(ctx context.Context, id string, counts map[string]int64) error {
+			flushed <- counts
+			return nil
+		},
+	}
+	cfg := testutil.TestConfig()
+	cfg.Statistics.AccessFlushInterval = 10 * time.Millisecond
+	service := NewImageService(repo, &testutil.MockDeduplicationRepository{}, &mockStorageProviderForImageService{}, &mockProcessorServiceForImageService{}, cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	service.StartAccessCountFlush(ctx)
+	service.RecordAccess(ctx, "a1b2c3d4-0000-4000-8000-000000000001", "thumbnail")
+
+	select {
+	case counts := <-flushed:
+		assert.Equal(t, map[string]int64{"thumbnail": 1}, counts)
+	case <-time.After(2 * time.Second):
+		t.Fatal("access counts were not flushed")
+	}
+}
+
+func TestImageService_TouchLastAccessed(t *testing.T) {
+	const imageID = "a1b2c3d4-0000-4000-8000-000000000001"
+
+	newService := func(throttle time.Duration, touchErr error) (ImageService, *[]time.Time) {
+		var touches []time.Time
+		repo := &mockImageRepositoryForImageService{
+			touchLastAccessedFunc: func(ctx context.Context, id string, at time.Time) error {
+				touches = append(touches, at)
+				return touchErr
+			},
+		}
+		cfg := testutil.TestConfig()
+		cfg.Statistics.AccessFlushInterval = time.Minute
+		cfg.Statistics.LastAccessedThrottle = throttle
+		return NewImageService(repo, &testutil.MockDeduplicationRepository{}, &mockStorageProviderForImageService{}, &mockProcessorServiceForImageService{}, cfg), &touches
+	}
+	ctx := context.Background()
+
+	t.Run("download updates the timestamp", func(t *testing.T) {
+		service, touches := newService(time.Minute, nil)
+
+		service.RecordAccess(ctx, imageID, "thumbnail")
+
+		require.Len(t, *touches, 1)
+		assert.WithinDuration(t, time.Now(), (*touches)[0], time.Second)
+	})
+
+	t.Run("repeated accesses are throttled", func(t *testing.T) {
+		service, touches := newService(time.Minute, nil)
+
+		service.RecordAccess(ctx, imageID, "thumbnail")
+		service.RecordAccess(ctx, imageID, "original")
+		service.TouchLastAccessed(ctx, imageID)
+		assert.Len(t, *touches, 1)
+
+		// Other images have their own throttle
+		service.TouchLastAccessed(ctx, "a1b2c3d4-0000-4000-8000-000000000002")
+		assert.Len(t, *touches, 2)
+	})
+
+	t.Run("zero throttle writes every access", func(t *testing.T) {
+		service, touches := newService(0, nil)
+
+		service.TouchLastAccessed(ctx, imageID)
+		service.TouchLastAccessed(ctx, imageID)
+		assert.Len(t, *touches, 2)
+	})
+
+	t.Run("failed write is retried on the next access", func(t *testing.T) {
+		service, touches := newService(time.Minute, errors.New("repository unavailable"))
+
+		service.TouchLastAccessed(ctx, imageID)
+		service.TouchLastAccessed(ctx, imageID)
+		assert.Len(t, *touches, 2)
+	})
+}
+
+func TestAccessBuffer_ClaimTouch(t *testing.T) {
+	buffer := newAccessBuffer()
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.True(t, buffer.claimTouch("a", start, time.Minute))
+	assert.False(t, buffer.claimTouch("a", start.Add(59*time.Second), time.Minute))
+	assert.True(t, buffer.claimTouch("b", start.Add(30*time.Second), time.Minute))
+	assert.True(t, buffer.claimTouch("a", start.Add(time.Minute), time.Minute))
+
+	// Entries past the throttle are pruned instead of kept forever
+	assert.True(t, buffer.claimTouch("c", start.Add(3*time.Minute), time.Minute))
+	assert.Len(t, buffer.touched, 1)
+	assert.Contains(t, buffer.touched, "c")
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"resizr/internal/config"
+	"resizr/internal/models"
+	"resizr/internal/repository"
+	"resizr/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// AuditServiceImpl implements the AuditService interface. Entries are written to
+// the "audit" logger and, when persistence is enabled, to the repository.
+type AuditServiceImpl struct {
+	repo      repository.AuditRepository // nil when entries are only logged
+	retention time.Duration
+}
+
+// NewAuditService creates an audit service. Persistence is skipped with a warning
+// when the repository cannot store audit entries.
+func NewAuditService(repo repository.ImageRepository, cfg *config.Config) AuditService {
+	service := &AuditServiceImpl{retention: cfg.Audit.Retention}
+
+	if cfg.Audit.Persist {
+		if auditRepo, ok := repo.(repository.AuditRepository); ok {
+			service.repo = auditRepo
+		} else {
+			logger.Warn("Repository cannot store audit entries, audit events are only logged")
+		}
+	}
+
+	return service
+}
+
+// Record logs entry and stores it when persistence is enabled
+func (s *AuditServiceImpl) Record(ctx context.Context, entry models.AuditEntry) {
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now().UTC()
+	}
+
+	logger.GetLogger().Named("audit").Info("Audit event",
+		zap.Time("timestamp", entry.Timestamp),
+		zap.String("operation", entry.Operation),
+		zap.String("image_id", entry.ImageID),
+		zap.String("key_fingerprint", entry.KeyFingerprint),
+		zap.Int("status", entry.Status),
+		zap.String("client_ip", entry.ClientIP),
+		zap.String("request_id", entry.RequestID))
+
+	if s.repo == nil {
+		return
+	}
+
+	// The entry is stored even when the request itself was cancelled or timed out
+	if err := s.repo.AppendAuditEntry(context.WithoutCancel(ctx), &entry, s.retention); err != nil {
+		logger.ErrorWithContext(ctx, "Failed to store audit entry",
+			zap.String("operation", entry.Operation),
+			zap.String("request_id", entry.RequestID),
+			zap.Error(err))
+	}
+}
+
+// List returns up to limit stored entries, newest first
+func (s *AuditServiceImpl) List(ctx context.Context, limit int) (*models.AuditLogResponse, error) {
+	if limit < 1 || limit > models.MaxAuditLogLimit {
+		return nil, models.ValidationError{
+			Field:   "limit",
+			Message: fmt.Sprintf("limit must be between 1 and %d", models.MaxAuditLogLimit),
+		}
+	}
+
+	entries := []models.AuditEntry{}
+	if s.repo != nil {
+		stored, err := s.repo.ListAuditEntries(ctx, limit)
+		if err != nil {
+			return nil, models.StorageError{
+				Operation: "list_audit_entries",
+				Backend:   "Repository",
+				Reason:    err.Error(),
+			}
+		}
+		entries = stored
+	}
+
+	return &models.AuditLogResponse{
+		Entries: entries,
+		Count:   len(entries),
+	}, nil
+}
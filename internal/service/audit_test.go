@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"resizr/internal/models"
+	"resizr/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// auditingRepository adds audit storage to the image repository mock
+type auditingRepository struct {
+	*mockImageRepositoryForImageService
+	entries   []models.AuditEntry
+	retention time.Duration
+	err       error
+}
+
+func (r *auditingRepository) AppendAuditEntry(ctx context.Context, entry *models.AuditEntry, retention time.Duration) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.entries = append([]models.AuditEntry{*entry}, r.entries...)
+	r.retention = retention
+	return nil
+}
+
+func (r *auditingRepository) ListAuditEntries(ctx context.Context, limit int) ([]models.AuditEntry, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.entries[:min(limit, len(r.entries))], nil
+}
+
+func TestAuditService(t *testing.T) {
+	ctx := context.Background()
+	newService := func(persist bool) (AuditService, *auditingRepository) {
+		repo := &auditingRepository{mockImageRepositoryForImageService: &mockImageRepositoryForImageService{}}
+		cfg := testutil.TestConfig()
+		cfg.Audit.Enabled = true
+		cfg.Audit.Persist = persist
+		cfg.Audit.Retention = 48 * time.Hour
+		return NewAuditService(repo, cfg), repo
+	}
+
+	t.Run("entries are stored newest first", func(t *testing.T) {
+		service, repo := newService(true)
+
+		service.Record(ctx, models.AuditEntry{Operation: "upload", ImageID: testutil.ValidUUID})
+		service.Record(ctx, models.AuditEntry{Operation: "delete", ImageID: testutil.ValidUUID})
+
+		assert.Equal(t, 48*time.Hour, repo.retention)
+		result, err := service.List(ctx, 10)
+		require.NoError(t, err)
+		require.Equal(t, 2, result.Count)
+		assert.Equal(t, "delete", result.Entries[0].Operation)
+		assert.Equal(t, "upload", result.Entries[1].Operation)
+		assert.False(t, result.Entries[0].Timestamp.IsZero(), "missing timestamps are filled in")
+
+		result, err = service.List(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Count)
+	})
+
+	t.Run("without persistence entries are only logged", func(t *testing.T) {
+		service, repo := newService(false)
+
+		service.Record(ctx, models.AuditEntry{Operation: "upload"})
+
+		assert.Empty(t, repo.entries)
+		result, err := service.List(ctx, 10)
+		require.NoError(t, err)
+		assert.Empty(t, result.Entries)
+	})
+
+	t.Run("limit is bounded", func(t *testing.T) {
+		service, _ := newService(true)
+
+		for _, limit := range []int{0, models.MaxAuditLogLimit + 1} {
+			_, err := service.List(ctx, limit)
+			assert.IsType(t, models.ValidationError{}, err)
+		}
+	})
+
+	t.Run("storage failures", func(t *testing.T) {
+		service, repo := newService(true)
+		repo.err = errors.New("connection refused")
+
+		// Recording never fails the request
+		service.Record(ctx, models.AuditEntry{Operation: "upload"})
+
+		_, err := service.List(ctx, 10)
+		assert.IsType(t, models.StorageError{}, err)
+	})
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"bytes"
+	"image/png"
+	"sync"
+)
+
+// DefaultBufferPoolMaxSize is the largest encode buffer kept for reuse by default
+const DefaultBufferPoolMaxSize = 16 * 1024 * 1024
+
+// bufferPool recycles encode buffers across ProcessImage calls so repeated
+// encodes don't regrow a buffer from scratch. Buffers that grew past maxRetained
+// are dropped instead of pooled, so a single huge image does not pin its memory.
+// It also pools the PNG encoder's scratch state through png.EncoderBufferPool.
+type bufferPool struct {
+	pool        sync.Pool
+	pngScratch  sync.Pool
+	maxRetained int
+}
+
+// newBufferPool creates a pool; a non-positive maxRetained disables pooling
+func newBufferPool(maxRetained int) *bufferPool {
+	return &bufferPool{
+		pool:        sync.Pool{New: func() any { return new(bytes.Buffer) }},
+		maxRetained: maxRetained,
+	}
+}
+
+// get returns an empty buffer
+func (bp *bufferPool) get() *bytes.Buffer {
+	if bp.maxRetained <= 0 {
+		return new(bytes.Buffer)
+	}
+	buf := bp.pool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+// put hands buf back for reuse. The contents are discarded first so no bytes of
+// one image can reach the next; callers must not touch buf or its Bytes afterwards.
+func (bp *bufferPool) put(buf *bytes.Buffer) {
+	if bp.maxRetained <= 0 || buf.Cap() > bp.maxRetained {
+		return
+	}
+	buf.Reset()
+	bp.pool.Put(buf)
+}
+
+// pngEncoder returns a PNG encoder that reuses pooled scratch buffers
+func (bp *bufferPool) pngEncoder() *png.Encoder {
+	encoder := &png.Encoder{CompressionLevel: png.DefaultCompression}
+	if bp.maxRetained > 0 {
+		encoder.BufferPool = (*pngBufferPool)(&bp.pngScratch)
+	}
+	return encoder
+}
+
+// pngBufferPool adapts a sync.Pool to png.EncoderBufferPool. The encoder fully
+// rewrites its scratch rows for every image, so nothing leaks between images.
+type pngBufferPool sync.Pool
+
+func (pp *pngBufferPool) Get() *png.EncoderBuffer {
+	buf, _ := (*sync.Pool)(pp).Get().(*png.EncoderBuffer)
+	return buf
+}
+
+func (pp *pngBufferPool) Put(buf *png.EncoderBuffer) {
+	(*sync.Pool)(pp).Put(buf)
+}
+
+// ProcessorOption customizes a processor created by NewProcessorService
+type ProcessorOption func(*ProcessorServiceImpl)
+
+// WithBufferPoolMaxSize sets the largest encode buffer kept for reuse between
+// calls; 0 disables pooling
+func WithBufferPoolMaxSize(maxSize int) ProcessorOption {
+	return func(p *ProcessorServiceImpl) {
+		p.buffers = newBufferPool(maxSize)
+	}
+}
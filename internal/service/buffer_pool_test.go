@@ -0,0 +1,121 @@
+package service
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"sync"
+	"testing"
+
+	"resizr/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBufferPool_ResetsBetweenUses(t *testing.T) {
+	pool := newBufferPool(1024)
+
+	buf := pool.get()
+	buf.WriteString("pixels of the previous image")
+	pool.put(buf)
+
+	for i := 0; i < 10; i++ {
+		reused := pool.get()
+		assert.Zero(t, reused.Len(), "pooled buffer must come back empty")
+		pool.put(reused)
+	}
+}
+
+func TestBufferPool_DropsOversizedBuffers(t *testing.T) {
+	pool := newBufferPool(64)
+
+	large := pool.get()
+	large.Write(make([]byte, 4096))
+	pool.put(large)
+
+	for i := 0; i < 10; i++ {
+		buf := pool.get()
+		assert.NotSame(t, large, buf, "a buffer above the retained size must not be reused")
+		pool.put(buf)
+	}
+}
+
+func TestBufferPool_Disabled(t *testing.T) {
+	pool := newBufferPool(0)
+
+	buf := pool.get()
+	buf.WriteString("data")
+	pool.put(buf)
+
+	assert.NotSame(t, buf, pool.get())
+	assert.Nil(t, pool.pngEncoder().BufferPool)
+}
+
+func TestProcessorService_PooledOutputsAreIndependent(t *testing.T) {
+	processor := NewProcessorService(4096, 4096, 8192, 8192, WithBufferPoolMaxSize(DefaultBufferPoolMaxSize))
+
+	resize := func(width, height int) []byte {
+		out, err := processor.ProcessImage(testutil.CreateTestPNG(64, 48), ResizeConfig{
+			Width:           width,
+			Height:          height,
+			Format:          "png",
+			Mode:            ResizeModeStretch,
+			BackgroundColor: "#FFFFFF",
+		})
+		require.NoError(t, err)
+		return out
+	}
+
+	first := resize(32, 24)
+	snapshot := bytes.Clone(first)
+
+	// Later encodes reuse the pooled buffer; earlier results must not change
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resize(16, 12)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, snapshot, first)
+	decoded, err := png.Decode(bytes.NewReader(first))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 32, 24), decoded.Bounds())
+}
+
+func BenchmarkProcessorService_BufferPool(b *testing.B) {
+	source := testutil.CreateTestPNG(1024, 768)
+	config := ResizeConfig{
+		Width:           640,
+		Height:          480,
+		Format:          "png",
+		Mode:            ResizeModeStretch,
+		BackgroundColor: "#FFFFFF",
+		Filter:          ResampleFilterNearest,
+	}
+
+	for _, bench := range []struct {
+		name    string
+		maxSize int
+	}{
+		{"pooled", DefaultBufferPoolMaxSize},
+		{"unpooled", 0},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			processor := NewProcessorService(4096, 4096, 8192, 8192, WithBufferPoolMaxSize(bench.maxSize))
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if _, err := processor.ProcessImage(source, config); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
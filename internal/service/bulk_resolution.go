@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"resizr/internal/models"
+	"resizr/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// bulkResolutionPageSize is how many images are listed per repository page
+	bulkResolutionPageSize = 100
+
+	// bulkResolutionConcurrency bounds how many images are resized at once
+	bulkResolutionConcurrency = 4
+)
+
+// AddResolutionToAll generates a resolution for every stored image that lacks it.
+// Images are listed page by page and processed with bounded concurrency; per-image
+// failures are collected in the result rather than aborting the run.
+func (s *ImageServiceImpl) AddResolutionToAll(ctx context.Context, resolution string) (*models.BulkResolutionResult, error) {
+	resolution = models.NormalizeResolution(resolution)
+	resolutionConfig, err := models.ParseResolution(resolution)
+	if err != nil {
+		return nil, models.ValidationError{
+			Field:   "resolution",
+			Message: err.Error(),
+		}
+	}
+	if resolutionConfig.Width > s.config.Image.MaxWidth || resolutionConfig.Height > s.config.Image.MaxHeight {
+		return nil, models.ValidationError{
+			Field:   "resolution",
+			Message: fmt.Sprintf("Requested resolution '%s' exceeds maximum configured %dx%d", resolution, s.config.Image.MaxWidth, s.config.Image.MaxHeight),
+		}
+	}
+	if err := s.checkResolutionArea("resolution", resolution, resolutionConfig); err != nil {
+		return nil, err
+	}
+
+	logger.InfoWithContext(ctx, "Adding resolution to all images",
+		zap.String("resolution", resolution),
+		zap.Int("concurrency", bulkResolutionConcurrency))
+
+	result := &models.BulkResolutionResult{Resolution: resolution}
+
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, bulkResolutionConcurrency)
+	)
+
+	for offset := 0; ; offset += bulkResolutionPageSize {
+		page, err := s.repo.List(ctx, offset, bulkResolutionPageSize)
+		if err != nil {
+			wg.Wait()
+			return nil, models.StorageError{
+				Operation: "list_images",
+				Backend:   "Repository",
+				Reason:    err.Error(),
+			}
+		}
+
+		for _, metadata := range page {
+			mu.Lock()
+			result.Total++
+			if metadata.ContainsResolution(resolution) {
+				result.Skipped++
+				mu.Unlock()
+				continue
+			}
+			mu.Unlock()
+
+			sem <- struct{}{}
+			wg.Add(1)
+			go func(imageID string) {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				processErr := s.ProcessResolution(ctx, imageID, resolution, false)
+
+				mu.Lock()
+				defer mu.Unlock()
+				if processErr != nil {
+					logger.WarnWithContext(ctx, "Failed to add resolution to image",
+						zap.String("image_id", imageID),
+						zap.String("resolution", resolution),
+						zap.Error(processErr))
+					result.Failed++
+					result.Failures = append(result.Failures, models.BulkResolutionFailure{
+						ImageID: imageID,
+						Error:   processErr.Error(),
+					})
+					return
+				}
+				result.Processed++
+			}(metadata.ID)
+		}
+
+		if len(page) < bulkResolutionPageSize {
+			break
+		}
+	}
+
+	wg.Wait()
+
+	logger.InfoWithContext(ctx, "Finished adding resolution to all images",
+		zap.String("resolution", resolution),
+		zap.Int("total", result.Total),
+		zap.Int("processed", result.Processed),
+		zap.Int("skipped", result.Skipped),
+		zap.Int("failed", result.Failed))
+
+	return result, nil
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"resizr/internal/models"
+	"resizr/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImageService_AddResolutionToAll(t *testing.T) {
+	// Seed more than one page of images: every third already has the resolution
+	// and one uses the alias for different dimensions
+	var images []*models.ImageMetadata
+	byID := make(map[string]*models.ImageMetadata)
+	for i := 0; i < bulkResolutionPageSize+20; i++ {
+		metadata := testutil.CreateTestImageMetadata()
+		metadata.ID = fmt.Sprintf("a1b2c3d4-0000-4000-8000-%012d", i)
+		metadata.Resolutions = []string{"thumbnail"}
+		if i%3 == 0 {
+			metadata.Resolutions = append(metadata.Resolutions, "400x300:card")
+		}
+		if i == 1 {
+			metadata.Resolutions = append(metadata.Resolutions, "800x600:card")
+		}
+		images = append(images, metadata)
+		byID[metadata.ID] = metadata
+	}
+
+	var mu sync.Mutex
+	updated := make(map[string]bool)
+	mockRepo := &mockImageRepositoryForImageService{
+		listFunc: func(ctx context.Context, offset, limit int) ([]*models.ImageMetadata, error) {
+			if offset >= len(images) {
+				return nil, nil
+			}
+			end := offset + limit
+			if end > len(images) {
+				end = len(images)
+			}
+			return images[offset:end], nil
+		},
+		getByIDFunc: func(ctx context.Context, id string) (*models.ImageMetadata, error) {
+			copied := *byID[id]
+			copied.Resolutions = append([]string(nil), byID[id].Resolutions...)
+			return &copied, nil
+		},
+		updateFunc: func(ctx context.Context, metadata *models.ImageMetadata) error {
+			mu.Lock()
+			defer mu.Unlock()
+			updated[metadata.ID] = true
+			return nil
+		},
+	}
+	mockStorage := &mockStorageProviderForImageService{
+		downloadFunc: func(ctx context.Context, key string) (io.ReadCloser, error) {
+			return testutil.NewMockReadCloser(testutil.CreateTestImageData()), nil
+		},
+		uploadFunc: func(ctx context.Context, key string, data io.Reader, size int64, contentType string) error {
+			return nil
+		},
+	}
+
+	var active, maxActive int32
+	mockProcessor := &mockProcessorServiceForImageService{
+		processImageFunc: func(data []byte, config ResizeConfig) ([]byte, error) {
+			current := atomic.AddInt32(&active, 1)
+			defer atomic.AddInt32(&active, -1)
+			for {
+				seen := atomic.LoadInt32(&maxActive)
+				if current <= seen || atomic.CompareAndSwapInt32(&maxActive, seen, current) {
+					break
+				}
+			}
+			return testutil.CreateTestImageData(), nil
+		},
+	}
+
+	service := NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, mockStorage, mockProcessor, testutil.TestConfig())
+
+	result, err := service.AddResolutionToAll(context.Background(), "400x300:card")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "400x300:card", result.Resolution)
+	assert.Equal(t, len(images), result.Total)
+	assert.Equal(t, 40, result.Skipped)
+	assert.Equal(t, 1, result.Failed)
+	assert.Equal(t, len(images)-40-1, result.Processed)
+	if assert.Len(t, result.Failures, 1) {
+		assert.Equal(t, images[1].ID, result.Failures[0].ImageID)
+		assert.Contains(t, result.Failures[0].Error, "Alias 'card' is already used")
+	}
+	assert.Len(t, updated, result.Processed)
+	assert.LessOrEqual(t, maxActive, int32(bulkResolutionConcurrency))
+}
+
+func TestImageService_AddResolutionToAll_Errors(t *testing.T) {
+	t.Run("invalid resolution", func(t *testing.T) {
+		service := NewImageService(&mockImageRepositoryForImageService{}, &mockDeduplicationRepositoryForImageService{}, &mockStorageProviderForImageService{}, &mockProcessorServiceForImageService{}, testutil.TestConfig())
+
+		for _, resolution := range []string{"huge", "9000x9000"} {
+			_, err := service.AddResolutionToAll(context.Background(), resolution)
+
+			var validationErr models.ValidationError
+			assert.ErrorAs(t, err, &validationErr)
+		}
+	})
+
+	t.Run("list failure", func(t *testing.T) {
+		mockRepo := &mockImageRepositoryForImageService{
+			listFunc: func(ctx context.Context, offset, limit int) ([]*models.ImageMetadata, error) {
+				return nil, errors.New("connection refused")
+			},
+		}
+		service := NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, &mockStorageProviderForImageService{}, &mockProcessorServiceForImageService{}, testutil.TestConfig())
+
+		_, err := service.AddResolutionToAll(context.Background(), "400x300")
+
+		var storageErr models.StorageError
+		assert.ErrorAs(t, err, &storageErr)
+	})
+}
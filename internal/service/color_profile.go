@@ -0,0 +1,422 @@
+package service
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"math"
+	"sort"
+
+	"resizr/pkg/logger"
+
+	"github.com/disintegration/imaging"
+	"go.uber.org/zap"
+)
+
+// maxICCProfileSize bounds the profile read from an upload; real RGB profiles are a few KB
+const maxICCProfileSize = 4 << 20
+
+// srgbToXYZ holds the sRGB colorants adapted to the D50 profile connection space,
+// as found in the standard sRGB IEC61966-2.1 profile
+var srgbToXYZ = [3][3]float64{
+	{0.4360747, 0.3850649, 0.1430804},
+	{0.2225045, 0.7168786, 0.0606169},
+	{0.0139322, 0.0971045, 0.7141733},
+}
+
+// xyzToSRGB converts D50 XYZ to linear sRGB
+var xyzToSRGB = invert3x3(srgbToXYZ)
+
+// WithSRGBConversion converts decoded sources carrying a non-sRGB ICC profile
+// (e.g. Adobe RGB, Display P3) to sRGB before resizing. Generated resolutions
+// never carry a profile, so without conversion such images render with washed-out
+// or shifted colors in browsers.
+func WithSRGBConversion(enabled bool) ProcessorOption {
+	return func(p *ProcessorServiceImpl) {
+		p.convertToSRGB = enabled
+	}
+}
+
+// iccCurve maps an encoded channel value in [0, 1] to linear light
+type iccCurve func(float64) float64
+
+// iccProfile is the matrix/TRC part of an RGB ICC profile
+type iccProfile struct {
+	toXYZ  [3][3]float64 // Columns are the red, green and blue colorants in D50 XYZ
+	curves [3]iccCurve   // Red, green and blue tone reproduction curves
+}
+
+// convertColorProfile returns img converted to sRGB when data embeds an RGB ICC
+// profile other than sRGB. Images without a usable profile are returned unchanged.
+func convertColorProfile(data []byte, format string, img image.Image) image.Image {
+	raw := extractICCProfile(data, format)
+	if raw == nil {
+		return img
+	}
+
+	profile, err := parseICCProfile(raw)
+	if err != nil {
+		logger.Warn("Ignoring unusable ICC profile",
+			zap.String("format", format),
+			zap.Error(err))
+		return img
+	}
+	if profile.isSRGB() {
+		return img
+	}
+
+	logger.Debug("Converting embedded ICC profile to sRGB",
+		zap.String("format", format),
+		zap.Int("profile_size", len(raw)))
+	return profile.convertToSRGB(img)
+}
+
+// extractICCProfile returns the ICC profile embedded in JPEG (APP2), PNG (iCCP)
+// or WebP (ICCP) data, or nil when there is none
+func extractICCProfile(data []byte, format string) []byte {
+	switch format {
+	case "jpeg":
+		return jpegICCProfile(data)
+	case "png":
+		return pngICCProfile(data)
+	case "webp":
+		return webpICCProfile(data)
+	}
+	return nil
+}
+
+// jpegICCProfile reassembles the ICC_PROFILE APP2 chunks that precede the scan data
+func jpegICCProfile(data []byte) []byte {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil
+	}
+
+	iccMarker := []byte("ICC_PROFILE\x00")
+	type chunk struct {
+		seq  byte
+		data []byte
+	}
+	var chunks []chunk
+	for pos := 2; pos+4 <= len(data); {
+		if data[pos] != 0xFF {
+			return nil
+		}
+		marker := data[pos+1]
+		if marker == 0xFF { // Fill byte
+			pos++
+			continue
+		}
+		if marker == 0xD9 || marker == 0xDA { // End of image or start of scan: no more metadata
+			break
+		}
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) { // Markers without a length
+			pos += 2
+			continue
+		}
+
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return nil
+		}
+		segment := data[pos+4 : end]
+		if marker == 0xE2 && len(segment) > len(iccMarker)+2 && bytes.HasPrefix(segment, iccMarker) {
+			chunks = append(chunks, chunk{seq: segment[len(iccMarker)], data: segment[len(iccMarker)+2:]})
+		}
+		pos = end
+	}
+	if len(chunks) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(chunks, func(i, j int) bool { return chunks[i].seq < chunks[j].seq })
+	var profile []byte
+	for _, c := range chunks {
+		profile = append(profile, c.data...)
+	}
+	return profile
+}
+
+// pngICCProfile inflates the iCCP chunk, which must precede the image data
+func pngICCProfile(data []byte) []byte {
+	const signatureLen = 8
+	for pos := signatureLen; pos+12 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		chunkType := string(data[pos+4 : pos+8])
+		end := pos + 12 + length
+		if length < 0 || end > len(data) {
+			return nil
+		}
+		if chunkType == "IDAT" {
+			return nil
+		}
+		if chunkType == "iCCP" {
+			body := data[pos+8 : pos+8+length]
+			nameEnd := bytes.IndexByte(body, 0)
+			if nameEnd < 0 || nameEnd+2 > len(body) || body[nameEnd+1] != 0 { // 0 is the only compression method
+				return nil
+			}
+			reader, err := zlib.NewReader(bytes.NewReader(body[nameEnd+2:]))
+			if err != nil {
+				return nil
+			}
+			defer reader.Close()
+			profile, err := io.ReadAll(io.LimitReader(reader, maxICCProfileSize))
+			if err != nil {
+				return nil
+			}
+			return profile
+		}
+		pos = end
+	}
+	return nil
+}
+
+// webpICCProfile returns the ICCP chunk of an extended-format WebP file
+func webpICCProfile(data []byte) []byte {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil
+	}
+	for pos := 12; pos+8 <= len(data); {
+		fourCC := string(data[pos : pos+4])
+		length := int(binary.LittleEndian.Uint32(data[pos+4:]))
+		end := pos + 8 + length
+		if length < 0 || end > len(data) {
+			return nil
+		}
+		if fourCC == "ICCP" {
+			return data[pos+8 : end]
+		}
+		pos = end + length%2 // Chunks are padded to an even size
+	}
+	return nil
+}
+
+// parseICCProfile reads the colorants and tone curves of an RGB matrix/TRC profile.
+// LUT-based profiles without colorant tags are not supported.
+func parseICCProfile(b []byte) (*iccProfile, error) {
+	const headerLen = 128
+	if len(b) < headerLen+4 || len(b) > maxICCProfileSize {
+		return nil, errors.New("profile too short or too large")
+	}
+	if colorSpace := string(b[16:20]); colorSpace != "RGB " {
+		return nil, fmt.Errorf("unsupported data color space %q", colorSpace)
+	}
+	if pcs := string(b[20:24]); pcs != "XYZ " {
+		return nil, fmt.Errorf("unsupported connection space %q", pcs)
+	}
+
+	count := int(binary.BigEndian.Uint32(b[headerLen:]))
+	if count > (len(b)-headerLen-4)/12 {
+		return nil, errors.New("truncated tag table")
+	}
+	tags := make(map[string][]byte, count)
+	for i := 0; i < count; i++ {
+		entry := b[headerLen+4+i*12:]
+		offset := int(binary.BigEndian.Uint32(entry[4:]))
+		size := int(binary.BigEndian.Uint32(entry[8:]))
+		if offset < 0 || size < 0 || offset+size > len(b) {
+			return nil, fmt.Errorf("tag %q out of bounds", entry[:4])
+		}
+		tags[string(entry[:4])] = b[offset : offset+size]
+	}
+
+	profile := &iccProfile{}
+	for channel, names := range [3][2]string{{"rXYZ", "rTRC"}, {"gXYZ", "gTRC"}, {"bXYZ", "bTRC"}} {
+		xyz, err := parseXYZTag(tags[names[0]])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", names[0], err)
+		}
+		for row := range xyz {
+			profile.toXYZ[row][channel] = xyz[row]
+		}
+		if profile.curves[channel], err = parseCurveTag(tags[names[1]]); err != nil {
+			return nil, fmt.Errorf("%s: %w", names[1], err)
+		}
+	}
+	return profile, nil
+}
+
+// parseXYZTag reads the single XYZNumber of an XYZType tag
+func parseXYZTag(tag []byte) ([3]float64, error) {
+	if len(tag) < 20 || string(tag[:4]) != "XYZ " {
+		return [3]float64{}, errors.New("missing or invalid XYZ tag")
+	}
+	return [3]float64{s15Fixed16(tag[8:]), s15Fixed16(tag[12:]), s15Fixed16(tag[16:])}, nil
+}
+
+// parseCurveTag reads a curveType or parametricCurveType tag
+func parseCurveTag(tag []byte) (iccCurve, error) {
+	if len(tag) < 12 {
+		return nil, errors.New("missing or invalid curve tag")
+	}
+
+	switch string(tag[:4]) {
+	case "curv":
+		n := int(binary.BigEndian.Uint32(tag[8:]))
+		if n > (len(tag)-12)/2 {
+			return nil, errors.New("truncated curve")
+		}
+		switch n {
+		case 0:
+			return func(x float64) float64 { return x }, nil
+		case 1:
+			gamma := float64(binary.BigEndian.Uint16(tag[12:])) / 256
+			return func(x float64) float64 { return math.Pow(x, gamma) }, nil
+		}
+		table := make([]float64, n)
+		for i := range table {
+			table[i] = float64(binary.BigEndian.Uint16(tag[12+2*i:])) / 65535
+		}
+		return func(x float64) float64 {
+			pos := x * float64(n-1)
+			i := min(int(pos), n-2)
+			return table[i] + (table[i+1]-table[i])*(pos-float64(i))
+		}, nil
+
+	case "para":
+		paramCounts := []int{1, 3, 4, 5, 7}
+		funcType := int(binary.BigEndian.Uint16(tag[8:]))
+		if funcType >= len(paramCounts) || len(tag) < 12+4*paramCounts[funcType] {
+			return nil, fmt.Errorf("unsupported parametric curve type %d", funcType)
+		}
+		var p [7]float64
+		for i := 0; i < paramCounts[funcType]; i++ {
+			p[i] = s15Fixed16(tag[12+4*i:])
+		}
+		g, a, b, c, d, e, f := p[0], p[1], p[2], p[3], p[4], p[5], p[6]
+		switch funcType {
+		case 0:
+			return func(x float64) float64 { return math.Pow(x, g) }, nil
+		case 1:
+			return func(x float64) float64 {
+				if x >= -b/a {
+					return math.Pow(a*x+b, g)
+				}
+				return 0
+			}, nil
+		case 2:
+			return func(x float64) float64 {
+				if x >= -b/a {
+					return math.Pow(a*x+b, g) + c
+				}
+				return c
+			}, nil
+		case 3:
+			return func(x float64) float64 {
+				if x >= d {
+					return math.Pow(a*x+b, g)
+				}
+				return c * x
+			}, nil
+		default:
+			return func(x float64) float64 {
+				if x >= d {
+					return math.Pow(a*x+b, g) + e
+				}
+				return c*x + f
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported curve type %q", tag[:4])
+}
+
+// isSRGB reports whether the profile matches sRGB closely enough that converting
+// would only add rounding error
+func (p *iccProfile) isSRGB() bool {
+	for row := range p.toXYZ {
+		for col := range p.toXYZ[row] {
+			if math.Abs(p.toXYZ[row][col]-srgbToXYZ[row][col]) > 0.003 {
+				return false
+			}
+		}
+	}
+	for _, curve := range p.curves {
+		for x := 0.0; x <= 1; x += 0.125 {
+			if math.Abs(curve(x)-srgbToLinear(x)) > 0.005 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// convertToSRGB maps every pixel through the profile's curves and colorants into
+// sRGB. Alpha is kept; colors outside the sRGB gamut are clipped.
+func (p *iccProfile) convertToSRGB(img image.Image) *image.NRGBA {
+	var toLinear [3][256]float64
+	for channel, curve := range p.curves {
+		for v := range toLinear[channel] {
+			toLinear[channel][v] = curve(float64(v) / 255)
+		}
+	}
+
+	// 4096 linear steps keep the dark end, where the sRGB curve is steepest, accurate to a level
+	const encodeSteps = 4095
+	var encode [encodeSteps + 1]uint8
+	for i := range encode {
+		encode[i] = uint8(math.Round(255 * linearToSRGB(float64(i)/encodeSteps)))
+	}
+
+	m := multiply3x3(xyzToSRGB, p.toXYZ)
+	out := imaging.Clone(img)
+	for i := 0; i+3 < len(out.Pix); i += 4 {
+		r := toLinear[0][out.Pix[i]]
+		g := toLinear[1][out.Pix[i+1]]
+		b := toLinear[2][out.Pix[i+2]]
+		for channel := 0; channel < 3; channel++ {
+			linear := m[channel][0]*r + m[channel][1]*g + m[channel][2]*b
+			out.Pix[i+channel] = encode[int(math.Round(min(max(linear, 0), 1)*encodeSteps))]
+		}
+	}
+	return out
+}
+
+// srgbToLinear is the sRGB decoding curve
+func srgbToLinear(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// linearToSRGB is the sRGB encoding curve
+func linearToSRGB(v float64) float64 {
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+// s15Fixed16 decodes the ICC signed 15.16 fixed-point number at the start of b
+func s15Fixed16(b []byte) float64 {
+	return float64(int32(binary.BigEndian.Uint32(b))) / 65536
+}
+
+func multiply3x3(a, b [3][3]float64) [3][3]float64 {
+	var out [3][3]float64
+	for i := range out {
+		for j := range out[i] {
+			for k := 0; k < 3; k++ {
+				out[i][j] += a[i][k] * b[k][j]
+			}
+		}
+	}
+	return out
+}
+
+func invert3x3(m [3][3]float64) [3][3]float64 {
+	a, b, c := m[0][0], m[0][1], m[0][2]
+	d, e, f := m[1][0], m[1][1], m[1][2]
+	g, h, i := m[2][0], m[2][1], m[2][2]
+	det := a*(e*i-f*h) - b*(d*i-f*g) + c*(d*h-e*g)
+	return [3][3]float64{
+		{(e*i - f*h) / det, (c*h - b*i) / det, (b*f - c*e) / det},
+		{(f*g - d*i) / det, (a*i - c*g) / det, (c*d - a*f) / det},
+		{(d*h - e*g) / det, (b*g - a*h) / det, (a*e - b*d) / det},
+	}
+}
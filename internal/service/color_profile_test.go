@@ -0,0 +1,212 @@
+package service
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// adobeRGBColorants are the Adobe RGB (1998) red, green and blue colorants in D50 XYZ
+var adobeRGBColorants = [3][3]float64{
+	{0.60974, 0.31111, 0.01947},
+	{0.20528, 0.62567, 0.06087},
+	{0.14919, 0.06322, 0.74457},
+}
+
+// srgbColorants are the sRGB red, green and blue colorants in D50 XYZ
+var srgbColorants = [3][3]float64{
+	{0.4360747, 0.2225045, 0.0139322},
+	{0.3850649, 0.7168786, 0.0971045},
+	{0.1430804, 0.0606169, 0.7141733},
+}
+
+func s15Fixed16Bytes(b []byte, v float64) []byte {
+	return binary.BigEndian.AppendUint32(b, uint32(int32(math.Round(v*65536))))
+}
+
+// gammaCurveTag is a curveType tag holding a single gamma value
+func gammaCurveTag(gamma float64) []byte {
+	tag := append([]byte("curv"), 0, 0, 0, 0, 0, 0, 0, 1)
+	tag = binary.BigEndian.AppendUint16(tag, uint16(math.Round(gamma*256)))
+	return append(tag, 0, 0) // Pad to a 4-byte boundary
+}
+
+// srgbCurveTag is the sRGB curve as a type 3 parametricCurveType tag
+func srgbCurveTag() []byte {
+	tag := append([]byte("para"), 0, 0, 0, 0, 0, 3, 0, 0)
+	for _, v := range []float64{2.4, 1 / 1.055, 0.055 / 1.055, 1 / 12.92, 0.04045} {
+		tag = s15Fixed16Bytes(tag, v)
+	}
+	return tag
+}
+
+// testICCProfile builds a minimal RGB matrix/TRC profile with one curve shared by all channels
+func testICCProfile(colorants [3][3]float64, curveTag []byte) []byte {
+	xyzTag := func(xyz [3]float64) []byte {
+		tag := append([]byte("XYZ "), 0, 0, 0, 0)
+		for _, v := range xyz {
+			tag = s15Fixed16Bytes(tag, v)
+		}
+		return tag
+	}
+
+	tags := []struct {
+		sig  string
+		data []byte
+	}{
+		{"rXYZ", xyzTag(colorants[0])},
+		{"gXYZ", xyzTag(colorants[1])},
+		{"bXYZ", xyzTag(colorants[2])},
+		{"rTRC", curveTag},
+		{"gTRC", curveTag},
+		{"bTRC", curveTag},
+	}
+
+	header := make([]byte, 128)
+	copy(header[12:], "mntr")
+	copy(header[16:], "RGB ")
+	copy(header[20:], "XYZ ")
+	copy(header[36:], "acsp")
+
+	table := binary.BigEndian.AppendUint32(nil, uint32(len(tags)))
+	var body []byte
+	offset := len(header) + 4 + 12*len(tags)
+	for _, tag := range tags {
+		table = append(table, tag.sig...)
+		table = binary.BigEndian.AppendUint32(table, uint32(offset+len(body)))
+		table = binary.BigEndian.AppendUint32(table, uint32(len(tag.data)))
+		body = append(body, tag.data...)
+	}
+
+	profile := append(append(header, table...), body...)
+	binary.BigEndian.PutUint32(profile, uint32(len(profile)))
+	return profile
+}
+
+// pngWithICCProfile encodes img as PNG with profile in an iCCP chunk before the image data
+func pngWithICCProfile(t *testing.T, img image.Image, profile []byte) []byte {
+	var encoded bytes.Buffer
+	require.NoError(t, png.Encode(&encoded, img))
+
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	_, err := zw.Write(profile)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	chunkData := append([]byte("test\x00\x00"), compressed.Bytes()...)
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(chunkData)))
+	chunk = append(chunk, "iCCP"...)
+	chunk = append(chunk, chunkData...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+
+	data := encoded.Bytes()
+	ihdrEnd := 8 + 12 + int(binary.BigEndian.Uint32(data[8:]))
+	return append(append(append([]byte{}, data[:ihdrEnd]...), chunk...), data[ihdrEnd:]...)
+}
+
+func solidImage(c color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+	}
+	return img
+}
+
+// processedCenter resizes data to 8x8 PNG and returns the center pixel
+func processedCenter(t *testing.T, processor ProcessorService, data []byte) color.NRGBA {
+	out, err := processor.ProcessImage(data, ResizeConfig{
+		Width: 8, Height: 8, Quality: 90, Format: "png", Mode: ResizeModeSmartFit, BackgroundColor: "#FFFFFF",
+	})
+	require.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(out))
+	require.NoError(t, err)
+	return color.NRGBAModel.Convert(img.At(4, 4)).(color.NRGBA)
+}
+
+func assertColorNear(t *testing.T, expected, actual color.NRGBA) {
+	t.Helper()
+	assert.InDelta(t, expected.R, actual.R, 2, "red")
+	assert.InDelta(t, expected.G, actual.G, 2, "green")
+	assert.InDelta(t, expected.B, actual.B, 2, "blue")
+	assert.Equal(t, expected.A, actual.A, "alpha")
+}
+
+func TestProcessImage_ConvertsICCProfileToSRGB(t *testing.T) {
+	source := color.NRGBA{R: 200, G: 100, B: 50, A: 255}
+	data := pngWithICCProfile(t, solidImage(source), testICCProfile(adobeRGBColorants, gammaCurveTag(2.2)))
+
+	t.Run("adobe rgb shifts toward srgb", func(t *testing.T) {
+		processor := NewProcessorService(4096, 4096, 8192, 8192, WithSRGBConversion(true))
+
+		// Adobe RGB (200, 100, 50) is a more saturated orange in sRGB
+		assertColorNear(t, color.NRGBA{R: 227, G: 100, B: 42, A: 255}, processedCenter(t, processor, data))
+	})
+
+	t.Run("disabled keeps the encoded values", func(t *testing.T) {
+		processor := NewProcessorService(4096, 4096, 8192, 8192)
+
+		assertColorNear(t, source, processedCenter(t, processor, data))
+	})
+
+	t.Run("srgb profile is left alone", func(t *testing.T) {
+		processor := NewProcessorService(4096, 4096, 8192, 8192, WithSRGBConversion(true))
+		withSRGB := pngWithICCProfile(t, solidImage(source), testICCProfile(srgbColorants, srgbCurveTag()))
+
+		profile, err := parseICCProfile(extractICCProfile(withSRGB, "png"))
+		require.NoError(t, err)
+		assert.True(t, profile.isSRGB())
+		assert.Equal(t, source, processedCenter(t, processor, withSRGB))
+	})
+
+	t.Run("unusable profile is ignored", func(t *testing.T) {
+		processor := NewProcessorService(4096, 4096, 8192, 8192, WithSRGBConversion(true))
+		broken := pngWithICCProfile(t, solidImage(source), []byte("not an icc profile"))
+
+		assertColorNear(t, source, processedCenter(t, processor, broken))
+	})
+}
+
+func TestExtractICCProfile(t *testing.T) {
+	profile := testICCProfile(adobeRGBColorants, gammaCurveTag(2.2))
+
+	t.Run("png iCCP chunk", func(t *testing.T) {
+		data := pngWithICCProfile(t, solidImage(color.NRGBA{A: 255}), profile)
+		assert.Equal(t, profile, extractICCProfile(data, "png"))
+	})
+
+	t.Run("jpeg APP2 chunks in sequence order", func(t *testing.T) {
+		var encoded bytes.Buffer
+		require.NoError(t, jpeg.Encode(&encoded, solidImage(color.NRGBA{A: 255}), nil))
+
+		half := len(profile) / 2
+		app2 := func(seq byte, part []byte) []byte {
+			segment := append([]byte{0xFF, 0xE2, 0, 0}, "ICC_PROFILE\x00"...)
+			segment = append(segment, seq, 2)
+			segment = append(segment, part...)
+			binary.BigEndian.PutUint16(segment[2:], uint16(len(segment)-2))
+			return segment
+		}
+		data := append([]byte{0xFF, 0xD8}, app2(2, profile[half:])...)
+		data = append(data, app2(1, profile[:half])...)
+		data = append(data, encoded.Bytes()[2:]...)
+
+		assert.Equal(t, profile, extractICCProfile(data, "jpeg"))
+	})
+
+	t.Run("no profile", func(t *testing.T) {
+		var encoded bytes.Buffer
+		require.NoError(t, png.Encode(&encoded, solidImage(color.NRGBA{A: 255})))
+		assert.Nil(t, extractICCProfile(encoded.Bytes(), "png"))
+	})
+}
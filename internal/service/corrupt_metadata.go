@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+
+	"resizr/internal/models"
+	"resizr/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// FindCorruptMetadata reports the metadata records that cannot be decoded
+func (s *ImageServiceImpl) FindCorruptMetadata(ctx context.Context) (*models.CorruptMetadataReport, error) {
+	records, err := s.repo.FindCorrupt(ctx)
+	if err != nil {
+		return nil, models.StorageError{
+			Operation: "find_corrupt_metadata",
+			Backend:   "Repository",
+			Reason:    err.Error(),
+		}
+	}
+
+	if records == nil {
+		records = []models.CorruptRecord{}
+	}
+
+	return &models.CorruptMetadataReport{Count: len(records), Records: records}, nil
+}
+
+// RemoveCorruptMetadata deletes every metadata record that cannot be decoded. The
+// images' files are left in storage since their keys cannot be read from the record.
+func (s *ImageServiceImpl) RemoveCorruptMetadata(ctx context.Context) (*models.CorruptMetadataReport, error) {
+	report, err := s.FindCorruptMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, record := range report.Records {
+		err := s.repo.DeleteCorrupt(ctx, record.ID)
+		s.metaCache.invalidate(record.ID)
+		if err != nil {
+			logger.WarnWithContext(ctx, "Failed to remove corrupt metadata record",
+				zap.String("image_id", record.ID),
+				zap.Error(err))
+			continue
+		}
+		report.Removed++
+	}
+
+	logger.InfoWithContext(ctx, "Corrupt metadata records removed",
+		zap.Int("found", report.Count),
+		zap.Int("removed", report.Removed))
+
+	return report, nil
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"resizr/internal/models"
+	"resizr/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImageService_RemoveCorruptMetadata(t *testing.T) {
+	var deleted []string
+	mockRepo := &mockImageRepositoryForImageService{
+		findCorruptFunc: func(ctx context.Context) ([]models.CorruptRecord, error) {
+			return []models.CorruptRecord{
+				{ID: "a1b2c3d4-0000-4000-8000-000000000001", Reason: "invalid JSON"},
+				{ID: "a1b2c3d4-0000-4000-8000-000000000002", Reason: "invalid size field"},
+			}, nil
+		},
+		deleteCorruptFunc: func(ctx context.Context, id string) error {
+			if id == "a1b2c3d4-0000-4000-8000-000000000002" {
+				return errors.New("delete failed")
+			}
+			deleted = append(deleted, id)
+			return nil
+		},
+	}
+	service := NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, &mockStorageProviderForImageService{}, &mockProcessorServiceForImageService{}, testutil.TestConfig())
+
+	report, err := service.RemoveCorruptMetadata(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Count)
+	assert.Len(t, report.Records, 2)
+	assert.Equal(t, 1, report.Removed)
+	assert.Equal(t, []string{"a1b2c3d4-0000-4000-8000-000000000001"}, deleted)
+}
+
+func TestImageService_FindCorruptMetadata(t *testing.T) {
+	t.Run("none found", func(t *testing.T) {
+		service := NewImageService(&mockImageRepositoryForImageService{}, &mockDeduplicationRepositoryForImageService{}, &mockStorageProviderForImageService{}, &mockProcessorServiceForImageService{}, testutil.TestConfig())
+
+		report, err := service.FindCorruptMetadata(context.Background())
+
+		require.NoError(t, err)
+		assert.Zero(t, report.Count)
+		assert.NotNil(t, report.Records)
+	})
+
+	t.Run("scan failure", func(t *testing.T) {
+		mockRepo := &mockImageRepositoryForImageService{
+			findCorruptFunc: func(ctx context.Context) ([]models.CorruptRecord, error) {
+				return nil, errors.New("connection refused")
+			},
+		}
+		service := NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, &mockStorageProviderForImageService{}, &mockProcessorServiceForImageService{}, testutil.TestConfig())
+
+		_, err := service.FindCorruptMetadata(context.Background())
+
+		assert.IsType(t, models.StorageError{}, err)
+	})
+}
+
+func TestImageService_GetMetadata_CorruptRecord(t *testing.T) {
+	mockRepo := &mockImageRepositoryForImageService{
+		getByIDFunc: func(ctx context.Context, id string) (*models.ImageMetadata, error) {
+			return nil, models.CorruptMetadataError{ID: id, Reason: "invalid JSON"}
+		},
+	}
+	service := NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, &mockStorageProviderForImageService{}, &mockProcessorServiceForImageService{}, testutil.TestConfig())
+
+	_, err := service.GetMetadata(context.Background(), testutil.ValidUUID)
+
+	assert.Equal(t, models.CorruptMetadataError{ID: testutil.ValidUUID, Reason: "invalid JSON"}, err)
+}
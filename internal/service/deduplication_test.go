@@ -1,7 +1,11 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"testing"
 
 	"resizr/internal/config"
@@ -32,6 +36,14 @@ func (t *testProcessorService) ProcessImage(data []byte, config ResizeConfig) ([
 	return data, nil
 }
 
+func (t *testProcessorService) ExtractFrame(data []byte, index int, config ResizeConfig) ([]byte, error) {
+	return data, nil
+}
+
+func (t *testProcessorService) ConvertFormat(data []byte, config ResizeConfig) ([]byte, error) {
+	return data, nil
+}
+
 func (t *testProcessorService) ValidateImage(data []byte, maxSize int64) error {
 	return nil
 }
@@ -403,3 +415,480 @@ func TestImageService_ErrorHandling(t *testing.T) {
 		assert.NoError(t, err)
 	})
 }
+
+func TestImageService_ProcessUpload_DedupDisabled(t *testing.T) {
+	// newIsolatedUploadService wires an in-memory deduplication repository so a
+	// second identical upload would be shared if deduplication were active
+	newIsolatedUploadService := func(cfg *config.Config) (ImageService, map[string][]byte, *int) {
+		stored := make(map[string]*models.DeduplicationInfo)
+		lookups := 0
+		dedupRepo := &testutil.MockDeduplicationRepository{
+			StoreDeduplicationInfoFunc: func(ctx context.Context, info *models.DeduplicationInfo) error {
+				stored[info.Hash.Value] = info
+				return nil
+			},
+			FindImageByHashFunc: func(ctx context.Context, hash models.ImageHash) (*models.DeduplicationInfo, error) {
+				lookups++
+				if info, ok := stored[hash.Value]; ok {
+					return info, nil
+				}
+				return nil, models.NotFoundError{Resource: "deduplication_info", ID: hash.String()}
+			},
+			GetDeduplicationInfoFunc: func(ctx context.Context, hash models.ImageHash) (*models.DeduplicationInfo, error) {
+				if info, ok := stored[hash.Value]; ok {
+					return info, nil
+				}
+				return nil, models.NotFoundError{Resource: "deduplication_info", ID: hash.String()}
+			},
+		}
+		uploads := make(map[string][]byte)
+		storage := &testutil.MockStorageProvider{
+			UploadFunc: func(ctx context.Context, key string, data io.Reader, contentType string) error {
+				body, err := io.ReadAll(data)
+				uploads[key] = body
+				return err
+			},
+		}
+		return NewImageService(&testutil.MockImageRepository{}, dedupRepo, storage, &testProcessorService{}, cfg), uploads, &lookups
+	}
+
+	data := testutil.CreateTestImageData()
+
+	assertIsolated := func(t *testing.T, svc ImageService, uploads map[string][]byte, disableDedup bool) {
+		first, err := svc.ProcessUpload(context.Background(), UploadInput{Filename: "a.jpg", Data: data, Size: int64(len(data)), DisableDedup: disableDedup})
+		assert.NoError(t, err)
+		second, err := svc.ProcessUpload(context.Background(), UploadInput{Filename: "b.jpg", Data: data, Size: int64(len(data)), DisableDedup: disableDedup})
+		assert.NoError(t, err)
+
+		assert.NotEqual(t, first.ImageID, second.ImageID)
+		assert.Contains(t, uploads, fmt.Sprintf("images/%s/original.jpg", first.ImageID))
+		assert.Contains(t, uploads, fmt.Sprintf("images/%s/original.jpg", second.ImageID))
+		assert.Contains(t, uploads, fmt.Sprintf("images/%s/thumbnail.jpg", first.ImageID))
+		assert.Contains(t, uploads, fmt.Sprintf("images/%s/thumbnail.jpg", second.ImageID))
+	}
+
+	t.Run("per_upload_flag", func(t *testing.T) {
+		cfg := testConfig()
+		cfg.Image.DeduplicationEnabled = true
+		svc, uploads, lookups := newIsolatedUploadService(cfg)
+
+		assertIsolated(t, svc, uploads, true)
+		assert.Zero(t, *lookups, "hash lookup should be bypassed")
+	})
+
+	t.Run("global_config", func(t *testing.T) {
+		cfg := testConfig()
+		cfg.Image.DeduplicationEnabled = false
+		svc, uploads, lookups := newIsolatedUploadService(cfg)
+
+		assertIsolated(t, svc, uploads, false)
+		assert.Zero(t, *lookups, "hash lookup should be bypassed")
+	})
+
+	t.Run("hash_still_recorded", func(t *testing.T) {
+		var saved *models.ImageMetadata
+		repo := &testutil.MockImageRepository{
+			StoreFunc: func(ctx context.Context, metadata *models.ImageMetadata) error {
+				saved = metadata
+				return nil
+			},
+		}
+		svc := NewImageService(repo, &testutil.MockDeduplicationRepository{}, &testutil.MockStorageProvider{}, &testProcessorService{}, testConfig())
+
+		_, err := svc.ProcessUpload(context.Background(), UploadInput{Filename: "a.jpg", Data: data, Size: int64(len(data)), DisableDedup: true})
+
+		assert.NoError(t, err)
+		if assert.NotNil(t, saved) {
+			assert.Equal(t, models.CalculateImageHash(data).Value, saved.Hash.Value)
+			assert.True(t, saved.DedupDisabled)
+			assert.False(t, saved.IsDeduped)
+			assert.False(t, saved.UsesDeduplication())
+		}
+	})
+}
+
+func TestImageService_DeleteImage_DedupDisabled(t *testing.T) {
+	const isolatedID = "f47ac10b-58cc-4372-a567-0e02b2c3d479"
+	hash := models.ImageHash{Value: "test-hash", Algorithm: "SHA256", Size: 1024}
+
+	mockRepo := &testutil.MockImageRepository{
+		GetFunc: func(ctx context.Context, id string) (*models.ImageMetadata, error) {
+			return &models.ImageMetadata{
+				ID:            id,
+				Filename:      "photo.jpg",
+				MimeType:      "image/jpeg",
+				Hash:          hash,
+				DedupDisabled: true,
+				Resolutions:   []string{"800x600"},
+			}, nil
+		},
+	}
+
+	// Another, shared image group has the same content hash
+	updated := false
+	mockDeduplicationRepo := &testutil.MockDeduplicationRepository{
+		GetDeduplicationInfoFunc: func(ctx context.Context, hash models.ImageHash) (*models.DeduplicationInfo, error) {
+			return &models.DeduplicationInfo{
+				MasterImageID:  "550e8400-e29b-41d4-a716-446655440000",
+				Hash:           hash,
+				ReferencingIDs: []string{"550e8400-e29b-41d4-a716-446655440000"},
+				ResolutionRefs: map[string]*models.ResolutionReference{
+					"800x600": {ReferencingIDs: []string{"550e8400-e29b-41d4-a716-446655440000"}},
+				},
+			}, nil
+		},
+		UpdateDeduplicationInfoFunc: func(ctx context.Context, info *models.DeduplicationInfo) error {
+			updated = true
+			return nil
+		},
+		DeleteDeduplicationInfoFunc: func(ctx context.Context, hash models.ImageHash) error {
+			updated = true
+			return nil
+		},
+	}
+
+	var deleted []string
+	mockStorage := &testutil.MockStorageProvider{
+		DeleteFunc: func(ctx context.Context, key string) error {
+			deleted = append(deleted, key)
+			return nil
+		},
+		ExistsFunc: func(ctx context.Context, key string) (bool, error) {
+			return true, nil
+		},
+	}
+
+	service := NewImageService(mockRepo, mockDeduplicationRepo, mockStorage, &testProcessorService{}, testConfig())
+
+	t.Run("delete_image", func(t *testing.T) {
+		deleted, updated = nil, false
+
+		assert.NoError(t, service.DeleteImage(context.Background(), isolatedID))
+		assert.Contains(t, deleted, "images/"+isolatedID+"/original.jpg")
+		assert.Contains(t, deleted, "images/"+isolatedID+"/800x600.jpg")
+		assert.False(t, updated, "shared deduplication info must not be touched")
+	})
+
+	t.Run("delete_resolution", func(t *testing.T) {
+		deleted, updated = nil, false
+
+		assert.NoError(t, service.DeleteResolution(context.Background(), isolatedID, "800x600"))
+		assert.Equal(t, []string{"images/" + isolatedID + "/800x600.jpg"}, deleted)
+		assert.False(t, updated, "shared deduplication info must not be touched")
+	})
+}
+
+func TestImageService_ProcessUpload_DedupNamespaces(t *testing.T) {
+	// In-memory repositories and storage so a real deduplication round trip can happen
+	images := make(map[string]*models.ImageMetadata)
+	dedup := make(map[string]*models.DeduplicationInfo)
+	objects := make(map[string][]byte)
+
+	repo := &testutil.MockImageRepository{
+		StoreFunc: func(ctx context.Context, metadata *models.ImageMetadata) error {
+			images[metadata.ID] = metadata
+			return nil
+		},
+		GetFunc: func(ctx context.Context, id string) (*models.ImageMetadata, error) {
+			if metadata, ok := images[id]; ok {
+				return metadata, nil
+			}
+			return nil, models.NotFoundError{Resource: "image", ID: id}
+		},
+	}
+	findDedup := func(ctx context.Context, hash models.ImageHash) (*models.DeduplicationInfo, error) {
+		if info, ok := dedup[hash.GetHashKey()]; ok {
+			return info, nil
+		}
+		return nil, models.NotFoundError{Resource: "deduplication_info", ID: hash.String()}
+	}
+	dedupRepo := &testutil.MockDeduplicationRepository{
+		StoreDeduplicationInfoFunc: func(ctx context.Context, info *models.DeduplicationInfo) error {
+			dedup[info.Hash.GetHashKey()] = info
+			return nil
+		},
+		UpdateDeduplicationInfoFunc: func(ctx context.Context, info *models.DeduplicationInfo) error {
+			dedup[info.Hash.GetHashKey()] = info
+			return nil
+		},
+		FindImageByHashFunc:      findDedup,
+		GetDeduplicationInfoFunc: findDedup,
+	}
+	storage := &testutil.MockStorageProvider{
+		UploadFunc: func(ctx context.Context, key string, data io.Reader, contentType string) error {
+			body, err := io.ReadAll(data)
+			objects[key] = body
+			return err
+		},
+		DownloadFunc: func(ctx context.Context, key string) (io.ReadCloser, error) {
+			body, ok := objects[key]
+			if !ok {
+				return nil, fmt.Errorf("object %s not found", key)
+			}
+			return io.NopCloser(bytes.NewReader(body)), nil
+		},
+		ExistsFunc: func(ctx context.Context, key string) (bool, error) {
+			_, ok := objects[key]
+			return ok, nil
+		},
+	}
+
+	cfg := testConfig()
+	cfg.Image.DeduplicationEnabled = true
+	svc := NewImageService(repo, dedupRepo, storage, &testProcessorService{}, cfg)
+
+	data := testutil.CreateTestImageData()
+	upload := func(namespace string) *models.ImageMetadata {
+		result, err := svc.ProcessUpload(context.Background(), UploadInput{
+			Filename:  "photo.jpg",
+			Data:      data,
+			Size:      int64(len(data)),
+			Namespace: namespace,
+		})
+		assert.NoError(t, err)
+		return images[result.ImageID]
+	}
+
+	tenantA := upload("tenant-a")
+	tenantB := upload("tenant-b")
+	tenantAAgain := upload("tenant-a")
+
+	// Identical content in another namespace is stored separately
+	assert.False(t, tenantB.IsDeduped)
+	assert.Equal(t, "tenant-b", tenantB.Hash.Namespace)
+	assert.Contains(t, objects, fmt.Sprintf("images/%s/original.jpg", tenantB.ID))
+
+	// ...while a second upload in the same namespace shares the first copy
+	assert.True(t, tenantAAgain.IsDeduped)
+	assert.Equal(t, tenantA.ID, tenantAAgain.SharedImageID)
+	assert.NotContains(t, objects, fmt.Sprintf("images/%s/original.jpg", tenantAAgain.ID))
+
+	assert.Len(t, dedup, 2)
+	assert.ElementsMatch(t, []string{tenantA.ID, tenantAAgain.ID}, dedup[tenantA.Hash.GetHashKey()].ReferencingIDs)
+	assert.Equal(t, []string{tenantB.ID}, dedup[tenantB.Hash.GetHashKey()].ReferencingIDs)
+}
+
+func TestImageService_DedupFailureMode(t *testing.T) {
+	const imageID = "f47ac10b-58cc-4372-a567-0e02b2c3d479"
+	unavailable := errors.New("dial tcp 127.0.0.1:6379: connect: connection refused")
+	data := testutil.CreateTestImageData()
+
+	newUploadService := func(mode string) (ImageService, *models.ImageMetadata, map[string][]byte, *int) {
+		cfg := testConfig()
+		cfg.Image.DeduplicationEnabled = true
+		cfg.Image.DedupFailureMode = mode
+
+		saved := &models.ImageMetadata{}
+		repo := &testutil.MockImageRepository{
+			StoreFunc: func(ctx context.Context, metadata *models.ImageMetadata) error {
+				*saved = *metadata
+				return nil
+			},
+		}
+		dedupStores := 0
+		dedupRepo := &testutil.MockDeduplicationRepository{
+			FindImageByHashFunc: func(ctx context.Context, hash models.ImageHash) (*models.DeduplicationInfo, error) {
+				return nil, unavailable
+			},
+			StoreDeduplicationInfoFunc: func(ctx context.Context, info *models.DeduplicationInfo) error {
+				dedupStores++
+				return nil
+			},
+		}
+		uploads := make(map[string][]byte)
+		storage := &testutil.MockStorageProvider{
+			UploadFunc: func(ctx context.Context, key string, data io.Reader, contentType string) error {
+				body, err := io.ReadAll(data)
+				uploads[key] = body
+				return err
+			},
+		}
+		return NewImageService(repo, dedupRepo, storage, &testProcessorService{}, cfg), saved, uploads, &dedupStores
+	}
+
+	newDeleteService := func(mode string) (ImageService, *[]string, *bool) {
+		cfg := testConfig()
+		cfg.Image.DedupFailureMode = mode
+
+		metadataDeleted := false
+		repo := &testutil.MockImageRepository{
+			GetFunc: func(ctx context.Context, id string) (*models.ImageMetadata, error) {
+				return &models.ImageMetadata{
+					ID:          id,
+					Filename:    "photo.jpg",
+					MimeType:    "image/jpeg",
+					Hash:        models.ImageHash{Value: "test-hash", Algorithm: "SHA256", Size: 1024},
+					Resolutions: []string{"800x600"},
+				}, nil
+			},
+			DeleteFunc: func(ctx context.Context, id string) error {
+				metadataDeleted = true
+				return nil
+			},
+		}
+		dedupRepo := &testutil.MockDeduplicationRepository{
+			GetDeduplicationInfoFunc: func(ctx context.Context, hash models.ImageHash) (*models.DeduplicationInfo, error) {
+				return nil, unavailable
+			},
+		}
+		var deleted []string
+		storage := &testutil.MockStorageProvider{
+			DeleteFunc: func(ctx context.Context, key string) error {
+				deleted = append(deleted, key)
+				return nil
+			},
+		}
+		return NewImageService(repo, dedupRepo, storage, &testProcessorService{}, cfg), &deleted, &metadataDeleted
+	}
+
+	t.Run("skip stores an isolated copy", func(t *testing.T) {
+		svc, saved, uploads, dedupStores := newUploadService("skip")
+
+		result, err := svc.ProcessUpload(context.Background(), UploadInput{Filename: "a.jpg", Data: data, Size: int64(len(data))})
+
+		assert.NoError(t, err)
+		assert.Contains(t, uploads, fmt.Sprintf("images/%s/original.jpg", result.ImageID))
+		assert.True(t, saved.DedupDisabled)
+		assert.False(t, saved.UsesDeduplication())
+		assert.Zero(t, *dedupStores, "an unreadable record must not be overwritten")
+	})
+
+	t.Run("fail rejects the upload before storing", func(t *testing.T) {
+		svc, saved, uploads, _ := newUploadService("fail")
+
+		_, err := svc.ProcessUpload(context.Background(), UploadInput{Filename: "a.jpg", Data: data, Size: int64(len(data))})
+
+		var storageErr models.StorageError
+		if assert.ErrorAs(t, err, &storageErr) {
+			assert.Equal(t, "find_dedup_info", storageErr.Operation)
+		}
+		assert.Empty(t, uploads)
+		assert.Empty(t, saved.ID)
+	})
+
+	t.Run("skip deletes the image standalone", func(t *testing.T) {
+		svc, deleted, metadataDeleted := newDeleteService("skip")
+
+		err := svc.DeleteImage(context.Background(), imageID)
+
+		assert.NoError(t, err)
+		assert.Contains(t, *deleted, fmt.Sprintf("images/%s/original.jpg", imageID))
+		assert.True(t, *metadataDeleted)
+	})
+
+	t.Run("fail keeps the image", func(t *testing.T) {
+		svc, deleted, metadataDeleted := newDeleteService("fail")
+
+		err := svc.DeleteImage(context.Background(), imageID)
+
+		var storageErr models.StorageError
+		if assert.ErrorAs(t, err, &storageErr) {
+			assert.Equal(t, "get_dedup_info", storageErr.Operation)
+		}
+		assert.Empty(t, *deleted)
+		assert.False(t, *metadataDeleted)
+	})
+}
+
+func TestImageService_ProcessUpload_MaxTotalImages(t *testing.T) {
+	images := make(map[string]*models.ImageMetadata)
+	dedup := make(map[string]*models.DeduplicationInfo)
+	objects := make(map[string][]byte)
+
+	repo := &testutil.MockImageRepository{
+		StoreFunc: func(ctx context.Context, metadata *models.ImageMetadata) error {
+			images[metadata.ID] = metadata
+			return nil
+		},
+		GetFunc: func(ctx context.Context, id string) (*models.ImageMetadata, error) {
+			if metadata, ok := images[id]; ok {
+				return metadata, nil
+			}
+			return nil, models.NotFoundError{Resource: "image", ID: id}
+		},
+		CountFunc: func(ctx context.Context) (int64, error) {
+			return int64(len(images)), nil
+		},
+	}
+	findDedup := func(ctx context.Context, hash models.ImageHash) (*models.DeduplicationInfo, error) {
+		if info, ok := dedup[hash.GetHashKey()]; ok {
+			return info, nil
+		}
+		return nil, models.NotFoundError{Resource: "deduplication_info", ID: hash.String()}
+	}
+	dedupRepo := &testutil.MockDeduplicationRepository{
+		StoreDeduplicationInfoFunc: func(ctx context.Context, info *models.DeduplicationInfo) error {
+			dedup[info.Hash.GetHashKey()] = info
+			return nil
+		},
+		UpdateDeduplicationInfoFunc: func(ctx context.Context, info *models.DeduplicationInfo) error {
+			dedup[info.Hash.GetHashKey()] = info
+			return nil
+		},
+		FindImageByHashFunc:      findDedup,
+		GetDeduplicationInfoFunc: findDedup,
+	}
+	storage := &testutil.MockStorageProvider{
+		UploadFunc: func(ctx context.Context, key string, data io.Reader, contentType string) error {
+			body, err := io.ReadAll(data)
+			objects[key] = body
+			return err
+		},
+		DownloadFunc: func(ctx context.Context, key string) (io.ReadCloser, error) {
+			body, ok := objects[key]
+			if !ok {
+				return nil, fmt.Errorf("object %s not found", key)
+			}
+			return io.NopCloser(bytes.NewReader(body)), nil
+		},
+		ExistsFunc: func(ctx context.Context, key string) (bool, error) {
+			_, ok := objects[key]
+			return ok, nil
+		},
+	}
+
+	cfg := testConfig()
+	cfg.Image.DeduplicationEnabled = true
+	cfg.Image.MaxTotalImages = 1
+	svc := NewImageService(repo, dedupRepo, storage, &testProcessorService{}, cfg)
+
+	upload := func(data []byte) (*UploadResult, error) {
+		return svc.ProcessUpload(context.Background(), UploadInput{
+			Filename: "photo.jpg",
+			Data:     data,
+			Size:     int64(len(data)),
+		})
+	}
+
+	data := testutil.CreateTestImageData()
+	first, err := upload(data)
+	assert.NoError(t, err)
+
+	t.Run("new content is rejected at capacity", func(t *testing.T) {
+		storedObjects := len(objects)
+
+		_, err := upload(append(append([]byte{}, data...), 0x00))
+
+		assert.Equal(t, models.CapacityExceededError{Limit: 1}, err)
+		assert.Len(t, images, 1)
+		assert.Len(t, objects, storedObjects)
+	})
+
+	t.Run("duplicates are accepted at capacity", func(t *testing.T) {
+		result, err := upload(data)
+
+		assert.NoError(t, err)
+		assert.True(t, images[result.ImageID].IsDeduped)
+		assert.Equal(t, first.ImageID, images[result.ImageID].SharedImageID)
+	})
+
+	t.Run("count failures reject the upload", func(t *testing.T) {
+		repo.CountFunc = func(ctx context.Context) (int64, error) {
+			return 0, errors.New("connection refused")
+		}
+
+		_, err := upload(append(append([]byte{}, data...), 0x01))
+
+		assert.IsType(t, models.StorageError{}, err)
+	})
+}
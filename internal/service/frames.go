@@ -0,0 +1,229 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+
+	"resizr/internal/models"
+
+	"golang.org/x/image/webp"
+)
+
+// ExtractFrame decodes frame index of an animated GIF or WebP, composed onto the
+// full canvas, and encodes it as a still image in config.Format (config.Quality
+// and config.JPEGSubsampling apply to lossy output). Still images have a single
+// frame 0. An index past the last frame is a ValidationError.
+func (p *ProcessorServiceImpl) ExtractFrame(data []byte, index int, config ResizeConfig) ([]byte, error) {
+	frame, count, err := p.decodeFrame(data, index)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode frame: %w", err)
+	}
+	if frame == nil {
+		return nil, models.ValidationError{
+			Field:   "frame",
+			Message: fmt.Sprintf("Frame %d is out of range; the image has %d frame(s)", index, count),
+		}
+	}
+
+	if config.Format == "webp" {
+		// A still WebP frame uses the lossless encoder rather than the JPEG fallback
+		// of encodeImage, so the response really is a WebP
+		return encodeWebPLossless(frame)
+	}
+	return p.encodeImage(frame, config.Format, config.Quality, config.JPEGSubsampling)
+}
+
+// ErrAnimatedSource is returned by ConvertFormat for images with several frames,
+// which no still format can hold
+var ErrAnimatedSource = errors.New("animated images cannot be converted to a still format")
+
+// ConvertFormat decodes a still image and encodes it in config.Format at its
+// original dimensions (config.Quality and config.JPEGSubsampling apply to lossy
+// output). Animated GIF and WebP sources fail with ErrAnimatedSource.
+func (p *ProcessorServiceImpl) ConvertFormat(data []byte, config ResizeConfig) ([]byte, error) {
+	img, count, err := p.decodeFrame(data, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode source image: %w", err)
+	}
+	if count > 1 {
+		return nil, ErrAnimatedSource
+	}
+
+	// Like resized outputs, the re-encoded original carries no ICC profile
+	if p.convertToSRGB {
+		if _, format, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+			img = convertColorProfile(data, format, img)
+		}
+	}
+
+	if config.Format == "webp" {
+		return encodeWebPLossless(img)
+	}
+	return p.encodeImage(img, config.Format, config.Quality, config.JPEGSubsampling)
+}
+
+// decodeFrame returns frame index of data and the number of frames. The frame is
+// nil when index is out of range.
+func (p *ProcessorServiceImpl) decodeFrame(data []byte, index int) (image.Image, int, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte("GIF8")):
+		anim, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return nil, 0, err
+		}
+		if index < 0 || index >= len(anim.Image) {
+			return nil, len(anim.Image), nil
+		}
+		var frame image.Image
+		composeGIFFrames(anim, func(i int, canvas *image.NRGBA) bool {
+			if i < index {
+				return true
+			}
+			frame = cloneNRGBA(canvas)
+			return false
+		})
+		return frame, len(anim.Image), nil
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		if frame, count, animated, err := decodeWebPFrame(data, index); animated || err != nil {
+			return frame, count, err
+		}
+	}
+
+	if index != 0 {
+		return nil, 1, nil
+	}
+	img, _, err := p.decodeImage(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	return img, 1, nil
+}
+
+// cloneNRGBA copies img so it survives later changes to the source canvas
+func cloneNRGBA(img *image.NRGBA) *image.NRGBA {
+	clone := image.NewNRGBA(img.Rect)
+	copy(clone.Pix, img.Pix)
+	return clone
+}
+
+// webpChunk is one chunk of a RIFF/WEBP container
+type webpChunk struct {
+	fourCC  string
+	payload []byte
+}
+
+// readWebPChunks splits chunk data (without the RIFF header) into chunks
+func readWebPChunks(data []byte) ([]webpChunk, error) {
+	var chunks []webpChunk
+	for len(data) > 0 {
+		if len(data) < 8 {
+			return nil, fmt.Errorf("truncated webp chunk header")
+		}
+		size := binary.LittleEndian.Uint32(data[4:8])
+		if uint64(size) > uint64(len(data)-8) {
+			return nil, fmt.Errorf("webp chunk %q overruns the file", data[:4])
+		}
+		chunks = append(chunks, webpChunk{fourCC: string(data[:4]), payload: data[8 : 8+size]})
+		next := 8 + int(size) + int(size%2)
+		if next > len(data) {
+			next = len(data)
+		}
+		data = data[next:]
+	}
+	return chunks, nil
+}
+
+// decodeWebPFrame composes frame index of an animated WebP file onto its canvas.
+// animated is false for still WebP files, which the caller decodes itself.
+func decodeWebPFrame(data []byte, index int) (frame image.Image, count int, animated bool, err error) {
+	chunks, err := readWebPChunks(data[12:])
+	if err != nil {
+		return nil, 0, false, err
+	}
+	if len(chunks) == 0 || chunks[0].fourCC != "VP8X" || len(chunks[0].payload) < 10 || chunks[0].payload[0]&0x02 == 0 {
+		return nil, 0, false, nil
+	}
+
+	vp8x := chunks[0].payload
+	canvasWidth := int(uint24(vp8x[4:])) + 1
+	canvasHeight := int(uint24(vp8x[7:])) + 1
+
+	var frames []webpChunk
+	for _, chunk := range chunks {
+		if chunk.fourCC == "ANMF" {
+			frames = append(frames, chunk)
+		}
+	}
+	if index < 0 || index >= len(frames) {
+		return nil, len(frames), true, nil
+	}
+
+	canvas := image.NewNRGBA(image.Rect(0, 0, canvasWidth, canvasHeight))
+	for i, chunk := range frames[:index+1] {
+		if len(chunk.payload) < 16 {
+			return nil, len(frames), true, fmt.Errorf("frame %d: truncated ANMF header", i)
+		}
+		header := chunk.payload[:16]
+		x, y := int(uint24(header[0:]))*2, int(uint24(header[3:]))*2
+		width, height := int(uint24(header[6:]))+1, int(uint24(header[9:]))+1
+		blend := header[15]&0x02 == 0
+		dispose := header[15]&0x01 != 0
+
+		img, err := decodeWebPFrameData(chunk.payload[16:], width, height)
+		if err != nil {
+			return nil, len(frames), true, fmt.Errorf("frame %d: %w", i, err)
+		}
+
+		rect := image.Rect(x, y, x+width, y+height)
+		op := draw.Src
+		if blend {
+			op = draw.Over
+		}
+		draw.Draw(canvas, rect, img, img.Bounds().Min, op)
+		if i == index {
+			break
+		}
+		if dispose {
+			draw.Draw(canvas, rect, image.Transparent, image.Point{}, draw.Src)
+		}
+	}
+	return canvas, len(frames), true, nil
+}
+
+// decodeWebPFrameData decodes the ALPH/VP8/VP8L chunks of one ANMF frame by
+// rewrapping them as a still WebP file
+func decodeWebPFrameData(frameData []byte, width, height int) (image.Image, error) {
+	chunks, err := readWebPChunks(frameData)
+	if err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	for _, chunk := range chunks {
+		if chunk.fourCC == "ALPH" {
+			// Lossy frames with alpha need an extended header to be decodable
+			vp8x := make([]byte, 10)
+			vp8x[0] = 0x10
+			putUint24(vp8x[4:], uint32(width-1))
+			putUint24(vp8x[7:], uint32(height-1))
+			writeRIFFChunk(&body, "VP8X", vp8x)
+			break
+		}
+	}
+	for _, chunk := range chunks {
+		switch chunk.fourCC {
+		case "ALPH", "VP8 ", "VP8L":
+			writeRIFFChunk(&body, chunk.fourCC, chunk.payload)
+		}
+	}
+	return webp.Decode(bytes.NewReader(wrapRIFF(body.Bytes())))
+}
+
+func uint24(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+}
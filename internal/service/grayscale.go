@@ -0,0 +1,79 @@
+package service
+
+import (
+	"image"
+	"image/draw"
+)
+
+// grayscaleTolerance is how far apart, in 8-bit steps, the channels of a pixel may
+// be while it still counts as gray. Decoding and resampling gray sources leaves
+// off-by-one differences between channels.
+const grayscaleTolerance = 2
+
+// WithGrayscalePreservation encodes opaque JPEG, WebP and PNG outputs without
+// meaningful color as single-channel grayscale instead of RGB. Gray photos stored
+// as full-color images otherwise carry two channels of redundant chroma.
+func WithGrayscalePreservation(enabled bool) ProcessorOption {
+	return func(p *ProcessorServiceImpl) {
+		p.preserveGray = enabled
+	}
+}
+
+// grayscaleFormat reports whether an output format has a grayscale encoding
+func grayscaleFormat(format string) bool {
+	switch format {
+	case "jpeg", "webp", "png", OutputFormatAuto:
+		return true
+	default:
+		return false
+	}
+}
+
+// toGrayscale returns img as *image.Gray when it is opaque and has no pixel with
+// meaningful color, and img unchanged otherwise
+func toGrayscale(img image.Image) image.Image {
+	switch img.(type) {
+	case *image.Gray, *image.Gray16:
+		return img
+	}
+	if !isOpaque(img) || !isGrayscale(img) {
+		return img
+	}
+
+	bounds := img.Bounds()
+	gray := image.NewGray(bounds)
+	draw.Draw(gray, bounds, img, bounds.Min, draw.Src)
+	return gray
+}
+
+// isGrayscale reports whether the channels of every pixel of img are within
+// grayscaleTolerance of each other
+func isGrayscale(img image.Image) bool {
+	if nrgba, ok := img.(*image.NRGBA); ok {
+		for y := 0; y < nrgba.Rect.Dy(); y++ {
+			row := nrgba.Pix[y*nrgba.Stride : y*nrgba.Stride+nrgba.Rect.Dx()*4]
+			for i := 0; i < len(row); i += 4 {
+				if !grayPixel(uint32(row[i]), uint32(row[i+1]), uint32(row[i+2])) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			if !grayPixel(r>>8, g>>8, b>>8) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// grayPixel reports whether 8-bit channel values are close enough to count as gray
+func grayPixel(r, g, b uint32) bool {
+	return max(r, g, b)-min(r, g, b) <= grayscaleTolerance
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// grayscaleJPEG encodes a gradient as a three-channel JPEG whose channels are equal
+func grayscaleJPEG(t *testing.T) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, 256, 256))
+	for y := 0; y < 256; y++ {
+		for x := 0; x < 256; x++ {
+			v := uint8((x + y) / 2)
+			img.SetNRGBA(x, y, color.NRGBA{R: v, G: v, B: v, A: 255})
+		}
+	}
+	var encoded bytes.Buffer
+	require.NoError(t, jpeg.Encode(&encoded, img, &jpeg.Options{Quality: 90}))
+	return encoded.Bytes()
+}
+
+func TestProcessImage_PreservesGrayscale(t *testing.T) {
+	source := grayscaleJPEG(t)
+	config := ResizeConfig{
+		Width: 128, Height: 128, Quality: 85, Format: "jpeg", Mode: ResizeModeStretch, BackgroundColor: "#FFFFFF",
+	}
+
+	rgb, err := NewProcessorService(4096, 4096, 8192, 8192).ProcessImage(source, config)
+	require.NoError(t, err)
+	gray, err := NewProcessorService(4096, 4096, 8192, 8192, WithGrayscalePreservation(true)).ProcessImage(source, config)
+	require.NoError(t, err)
+
+	assert.Less(t, len(gray), len(rgb))
+
+	decoded, err := jpeg.Decode(bytes.NewReader(gray))
+	require.NoError(t, err)
+	require.IsType(t, &image.Gray{}, decoded, "output should be a single-channel JPEG")
+	assert.Equal(t, image.Rect(0, 0, 128, 128), decoded.Bounds())
+	assert.InDelta(t, 128, decoded.(*image.Gray).GrayAt(64, 64).Y, 3)
+}
+
+func TestToGrayscale(t *testing.T) {
+	solid := func(c color.NRGBA) *image.NRGBA {
+		img := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+		for i := 0; i < len(img.Pix); i += 4 {
+			img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+		}
+		return img
+	}
+
+	t.Run("near-gray pixels convert", func(t *testing.T) {
+		converted := toGrayscale(solid(color.NRGBA{R: 100, G: 101, B: 99, A: 255}))
+		assert.IsType(t, &image.Gray{}, converted)
+	})
+
+	t.Run("one colored pixel keeps the image in color", func(t *testing.T) {
+		img := solid(color.NRGBA{R: 100, G: 100, B: 100, A: 255})
+		img.SetNRGBA(3, 3, color.NRGBA{R: 200, G: 20, B: 20, A: 255})
+		assert.Same(t, img, toGrayscale(img))
+	})
+
+	t.Run("transparency is kept", func(t *testing.T) {
+		img := solid(color.NRGBA{R: 100, G: 100, B: 100, A: 128})
+		assert.Same(t, img, toGrayscale(img))
+	})
+}
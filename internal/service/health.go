@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"runtime"
 	"sync"
 	"time"
@@ -23,8 +24,15 @@ type HealthServiceImpl struct {
 	version      string
 	s3HealthMu   sync.RWMutex
 	s3HealthData *cachedS3Health
+
+	readyMu           sync.RWMutex
+	ready             bool // Set after the first successful dependency check
+	readinessFailures int  // Consecutive failed dependency checks
 }
 
+// defaultReadinessInterval is used when HealthConfig.ReadinessInterval is unset
+const defaultReadinessInterval = 5 * time.Second
+
 // cachedS3Health holds cached S3 health check result
 type cachedS3Health struct {
 	status    string
@@ -53,7 +61,8 @@ func (s *HealthServiceImpl) CheckHealth(ctx context.Context) (*HealthStatus, err
 
 	services := make(map[string]string)
 
-	// Check Redis/Repository health
+	// Check Redis/Repository health; "metadata" reports the same check under a
+	// backend-neutral name, "redis" is kept for existing monitors
 	if err := s.repo.Health(ctx); err != nil {
 		logger.WarnWithContext(ctx, "Redis health check failed",
 			zap.Error(err))
@@ -61,6 +70,18 @@ func (s *HealthServiceImpl) CheckHealth(ctx context.Context) (*HealthStatus, err
 	} else {
 		services["redis"] = "connected"
 	}
+	services["metadata"] = services["redis"]
+
+	// Check the cache layer separately so a cache outage is not mistaken for the metadata store
+	if cache, ok := s.repo.(repository.CacheRepository); ok {
+		if err := cache.CacheHealth(ctx); err != nil {
+			logger.WarnWithContext(ctx, "Cache health check failed",
+				zap.Error(err))
+			services["cache"] = "unhealthy: " + err.Error()
+		} else {
+			services["cache"] = "connected"
+		}
+	}
 
 	// Check S3/Storage health (conditionally)
 	services["s3"] = s.checkS3Health(ctx)
@@ -111,12 +132,30 @@ func (s *HealthServiceImpl) GetMetrics(ctx context.Context) (map[string]interfac
 		"timestamp": time.Now().Unix(),
 	}
 
+	// Processing durations labeled by output format and target size bucket
+	metrics["processing"] = map[string]interface{}{
+		"durations": ProcessingDurations(),
+	}
+
+	// Upload processing queue, when resolutions are generated in the background
+	if queue, ok := ProcessingQueueMetrics(); ok {
+		metrics["queue"] = queue
+	}
+
 	// Try to get repository stats
 	if repoStats, err := s.repo.GetStats(ctx); err == nil && repoStats != nil {
 		metrics["repository"] = map[string]interface{}{
-			"total_images": repoStats.TotalImages,
-			"cache_hits":   repoStats.CacheHits,
-			"cache_misses": repoStats.CacheMisses,
+			"total_images":            repoStats.TotalImages,
+			"cache_hits":              repoStats.CacheHits,
+			"cache_misses":            repoStats.CacheMisses,
+			"cache_hit_ratio":         repository.CacheHitRatio(repoStats.CacheHits, repoStats.CacheMisses),
+			"corrupt_records_skipped": repository.CorruptRecordsSkipped(),
+			"connections": map[string]interface{}{
+				"active":   repoStats.Connections.Active,
+				"idle":     repoStats.Connections.Idle,
+				"total":    repoStats.Connections.Total,
+				"max_open": repoStats.Connections.MaxOpen,
+			},
 		}
 	}
 
@@ -127,6 +166,105 @@ func (s *HealthServiceImpl) GetMetrics(ctx context.Context) (map[string]interfac
 	return metrics, nil
 }
 
+// ResetCacheStats zeroes the repository's cache hit and miss counters so the hit
+// ratio reflects only lookups made from now on
+func (s *HealthServiceImpl) ResetCacheStats(ctx context.Context) error {
+	resetter, ok := s.repo.(repository.CacheStatsResetter)
+	if !ok {
+		return fmt.Errorf("repository does not support resetting cache statistics")
+	}
+
+	resetter.ResetCacheStats()
+	logger.InfoWithContext(ctx, "Cache statistics reset")
+	return nil
+}
+
+// IsReady reports whether dependencies have been confirmed healthy.
+// It is false until the first successful check and after ReadinessFailureThreshold consecutive failures.
+func (s *HealthServiceImpl) IsReady() bool {
+	s.readyMu.RLock()
+	defer s.readyMu.RUnlock()
+	return s.ready
+}
+
+// CheckReadiness checks Redis and S3 once and updates the readiness state
+func (s *HealthServiceImpl) CheckReadiness(ctx context.Context) bool {
+	err := s.checkDependencies(ctx)
+
+	threshold := s.config.Health.ReadinessFailureThreshold
+	if threshold < 1 {
+		threshold = 1
+	}
+
+	s.readyMu.Lock()
+	defer s.readyMu.Unlock()
+
+	if err == nil {
+		if !s.ready {
+			logger.InfoWithContext(ctx, "Dependencies healthy, service is ready")
+		}
+		s.ready = true
+		s.readinessFailures = 0
+		return true
+	}
+
+	s.readinessFailures++
+	logger.WarnWithContext(ctx, "Readiness dependency check failed",
+		zap.Error(err),
+		zap.Int("consecutive_failures", s.readinessFailures),
+		zap.Int("threshold", threshold))
+
+	if s.ready && s.readinessFailures >= threshold {
+		logger.ErrorWithContext(ctx, "Dependencies unhealthy, service is not ready",
+			zap.Error(err),
+			zap.Int("consecutive_failures", s.readinessFailures))
+		s.ready = false
+	}
+	return s.ready
+}
+
+// StartReadinessChecks checks dependencies immediately and then on every
+// ReadinessInterval until ctx is cancelled
+func (s *HealthServiceImpl) StartReadinessChecks(ctx context.Context) {
+	interval := s.config.Health.ReadinessInterval
+	if interval <= 0 {
+		interval = defaultReadinessInterval
+	}
+
+	logger.Info("Starting readiness checks",
+		zap.Duration("interval", interval),
+		zap.Int("failure_threshold", s.config.Health.ReadinessFailureThreshold))
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		s.CheckReadiness(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				logger.Info("Readiness checks stopped")
+				return
+			case <-ticker.C:
+				s.CheckReadiness(ctx)
+			}
+		}
+	}()
+}
+
+// checkDependencies returns an error if Redis or S3 (when S3 checks are enabled) is unhealthy
+func (s *HealthServiceImpl) checkDependencies(ctx context.Context) error {
+	if err := s.repo.Health(ctx); err != nil {
+		return fmt.Errorf("redis: %w", err)
+	}
+
+	if status := s.checkS3Health(ctx); status != "connected" && status != "disabled" {
+		return fmt.Errorf("s3: %s", status)
+	}
+
+	return nil
+}
+
 // checkS3Health performs S3 health check with caching and conditional logic
 func (s *HealthServiceImpl) checkS3Health(ctx context.Context) string {
 	// If S3 health checks are disabled, return a neutral status
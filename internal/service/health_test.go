@@ -5,6 +5,7 @@ import (
 	"errors"
 	"io"
 	"runtime"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -18,9 +19,10 @@ import (
 
 // Local mocks for health service testing
 type mockImageRepository struct {
-	healthFunc   func(ctx context.Context) error
-	closeFunc    func() error
-	getStatsFunc func(ctx context.Context) (*repository.RepositoryStats, error)
+	healthFunc      func(ctx context.Context) error
+	cacheHealthFunc func(ctx context.Context) error
+	closeFunc       func() error
+	getStatsFunc    func(ctx context.Context) (*repository.RepositoryStats, error)
 }
 
 func (m *mockImageRepository) Save(_ctx context.Context, _metadata *models.ImageMetadata) error {
@@ -42,9 +44,13 @@ func (m *mockImageRepository) Delete(_ctx context.Context, _id string) error { r
 func (m *mockImageRepository) Exists(_ctx context.Context, _id string) (bool, error) {
 	return false, nil
 }
+func (m *mockImageRepository) Count(_ctx context.Context) (int64, error) { return 0, nil }
 func (m *mockImageRepository) List(_ctx context.Context, _offset, _limit int) ([]*models.ImageMetadata, error) {
 	return nil, nil
 }
+func (m *mockImageRepository) ListByDedupStatus(_ctx context.Context, _filter string, _offset, _limit int) ([]*models.ImageMetadata, models.DedupStatusCounts, error) {
+	return nil, models.DedupStatusCounts{}, nil
+}
 func (m *mockImageRepository) HealthCheck(ctx context.Context) error {
 	if m.healthFunc != nil {
 		return m.healthFunc(ctx)
@@ -110,9 +116,33 @@ func (m *mockImageRepository) GetResolutionStatistics(ctx context.Context) ([]mo
 func (m *mockImageRepository) GetImagesByTimeRange(ctx context.Context, start, end time.Time) (int64, error) {
 	return 0, nil
 }
+func (m *mockImageRepository) GetUploadCountsByDay(ctx context.Context, start time.Time, days int) ([]models.DailyUploadCount, error) {
+	return []models.DailyUploadCount{}, nil
+}
 func (m *mockImageRepository) GetStorageUsageByResolution(ctx context.Context) (map[string]int64, error) {
 	return map[string]int64{}, nil
 }
+func (m *mockImageRepository) FindByFilename(ctx context.Context, filename string) ([]string, error) {
+	return []string{}, nil
+}
+func (m *mockImageRepository) FindCorrupt(ctx context.Context) ([]models.CorruptRecord, error) {
+	return nil, nil
+}
+func (m *mockImageRepository) DeleteCorrupt(ctx context.Context, id string) error {
+	return nil
+}
+func (m *mockImageRepository) IncrementAccessCounts(ctx context.Context, id string, counts map[string]int64) error {
+	return nil
+}
+func (m *mockImageRepository) GetAccessCounts(ctx context.Context, id string) (*models.AccessCounts, error) {
+	return &models.AccessCounts{}, nil
+}
+func (m *mockImageRepository) GetPopularImages(ctx context.Context, limit int) ([]models.PopularImage, error) {
+	return []models.PopularImage{}, nil
+}
+func (m *mockImageRepository) TouchLastAccessed(ctx context.Context, id string, at time.Time) error {
+	return nil
+}
 func (m *mockImageRepository) GetDeduplicationStatistics(ctx context.Context) (*models.DeduplicationStatistics, error) {
 	return &models.DeduplicationStatistics{}, nil
 }
@@ -151,6 +181,12 @@ func (m *mockImageRepository) GetCache(ctx context.Context, key string) (string,
 func (m *mockImageRepository) DeleteCache(ctx context.Context, key string) error {
 	return nil
 }
+func (m *mockImageRepository) CacheHealth(ctx context.Context) error {
+	if m.cacheHealthFunc != nil {
+		return m.cacheHealthFunc(ctx)
+	}
+	return nil
+}
 
 type mockStorageProvider struct {
 	healthFunc func(ctx context.Context) error
@@ -314,6 +350,41 @@ func TestHealthService_CheckHealth_AllUnhealthy(t *testing.T) {
 	assert.Equal(t, "healthy", status.Services["application"])
 }
 
+func TestHealthService_CheckHealth_CacheAndMetadataReportedSeparately(t *testing.T) {
+	tests := []struct {
+		name             string
+		metadataErr      error
+		cacheErr         error
+		expectedMetadata string
+		expectedCache    string
+	}{
+		{"cache down, metadata up", nil, errors.New("cache timeout"), "connected", "unhealthy: cache timeout"},
+		{"metadata down, cache up", errors.New("metadata read failed"), nil, "unhealthy: metadata read failed", "connected"},
+		{"both up", nil, nil, "connected", "connected"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &mockImageRepository{
+				healthFunc: func(ctx context.Context) error {
+					return tt.metadataErr
+				},
+				cacheHealthFunc: func(ctx context.Context) error {
+					return tt.cacheErr
+				},
+			}
+			service := NewHealthService(mockRepo, &mockStorageProvider{}, testutil.TestConfig(), "1.0.0")
+
+			status, err := service.CheckHealth(context.Background())
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedMetadata, status.Services["metadata"])
+			assert.Equal(t, tt.expectedCache, status.Services["cache"])
+			assert.Equal(t, tt.expectedMetadata, status.Services["redis"])
+		})
+	}
+}
+
 func TestHealthService_GetMetrics_Success(t *testing.T) {
 	mockRepo := &mockImageRepository{
 		getStatsFunc: func(ctx context.Context) (*repository.RepositoryStats, error) {
@@ -321,6 +392,7 @@ func TestHealthService_GetMetrics_Success(t *testing.T) {
 				TotalImages: 150,
 				CacheHits:   1000,
 				CacheMisses: 50,
+				Connections: repository.ConnectionStats{Active: 2, Idle: 3, Total: 5, MaxOpen: 10},
 			}, nil
 		},
 	}
@@ -359,11 +431,58 @@ func TestHealthService_GetMetrics_Success(t *testing.T) {
 	assert.Equal(t, int64(150), repoMetrics["total_images"])
 	assert.Equal(t, int64(1000), repoMetrics["cache_hits"])
 	assert.Equal(t, int64(50), repoMetrics["cache_misses"])
+	assert.InDelta(t, 1000.0/1050.0, repoMetrics["cache_hit_ratio"], 1e-9)
+	assert.Equal(t, map[string]interface{}{"active": 2, "idle": 3, "total": 5, "max_open": 10}, repoMetrics["connections"])
+
+	// Check processing duration metrics
+	processingMetrics, ok := metrics["processing"].(map[string]interface{})
+	assert.True(t, ok)
+	assert.IsType(t, map[string]map[string]ProcessingDurationSummary{}, processingMetrics["durations"])
 
 	// Check timestamp
 	assert.Greater(t, metrics["timestamp"].(int64), int64(0))
 }
 
+// resettableImageRepository counts ResetCacheStats calls on top of mockImageRepository
+type resettableImageRepository struct {
+	mockImageRepository
+	resets int
+}
+
+func (m *resettableImageRepository) ResetCacheStats() { m.resets++ }
+
+func TestHealthService_GetMetrics_ZeroCacheLookups(t *testing.T) {
+	mockRepo := &mockImageRepository{
+		getStatsFunc: func(ctx context.Context) (*repository.RepositoryStats, error) {
+			return &repository.RepositoryStats{TotalImages: 3}, nil
+		},
+	}
+	service := NewHealthService(mockRepo, &mockStorageProvider{}, testutil.TestConfig(), "1.0.0")
+
+	metrics, err := service.GetMetrics(context.Background())
+
+	assert.NoError(t, err)
+	repoMetrics := metrics["repository"].(map[string]interface{})
+	assert.Equal(t, float64(0), repoMetrics["cache_hit_ratio"])
+}
+
+func TestHealthService_ResetCacheStats(t *testing.T) {
+	t.Run("resets supporting repository", func(t *testing.T) {
+		repo := &resettableImageRepository{}
+		service := NewHealthService(repo, &mockStorageProvider{}, testutil.TestConfig(), "1.0.0")
+
+		assert.NoError(t, service.ResetCacheStats(context.Background()))
+		assert.Equal(t, 1, repo.resets)
+	})
+
+	t.Run("unsupported repository", func(t *testing.T) {
+		service := NewHealthService(&mockImageRepository{}, &mockStorageProvider{}, testutil.TestConfig(), "1.0.0")
+
+		err := service.ResetCacheStats(context.Background())
+		assert.ErrorContains(t, err, "does not support")
+	})
+}
+
 func TestHealthService_GetMetrics_RepositoryStatsError(t *testing.T) {
 	mockRepo := &mockImageRepository{
 		getStatsFunc: func(ctx context.Context) (*repository.RepositoryStats, error) {
@@ -724,3 +843,99 @@ func TestHealthService_S3ErrorCaching(t *testing.T) {
 	assert.Contains(t, status.Services["s3"], "unhealthy: S3 connection failed")
 	assert.Equal(t, 2, s3CheckCount, "Third check after cache expiry should call S3 again")
 }
+
+func TestHealthService_Readiness_Transitions(t *testing.T) {
+	var redisErr error
+	mockRepo := &mockImageRepository{
+		healthFunc: func(ctx context.Context) error {
+			return redisErr
+		},
+	}
+	mockStorage := &mockStorageProvider{}
+
+	cfg := testutil.TestConfig()
+	cfg.Health.ReadinessFailureThreshold = 2
+	service := NewHealthService(mockRepo, mockStorage, cfg, "1.0.0")
+	ctx := context.Background()
+
+	// Not ready before any check has run
+	assert.False(t, service.IsReady())
+
+	// Failing checks before the first success keep the service not ready
+	redisErr = errors.New("redis unreachable")
+	assert.False(t, service.CheckReadiness(ctx))
+	assert.False(t, service.IsReady())
+
+	// First successful check flips to ready
+	redisErr = nil
+	assert.True(t, service.CheckReadiness(ctx))
+	assert.True(t, service.IsReady())
+
+	// A single failure below the threshold is tolerated
+	redisErr = errors.New("redis blip")
+	assert.True(t, service.CheckReadiness(ctx))
+	assert.True(t, service.IsReady())
+
+	// A success resets the failure count
+	redisErr = nil
+	assert.True(t, service.CheckReadiness(ctx))
+	redisErr = errors.New("redis blip")
+	assert.True(t, service.CheckReadiness(ctx))
+
+	// Reaching the threshold of consecutive failures flips to not ready
+	assert.False(t, service.CheckReadiness(ctx))
+	assert.False(t, service.IsReady())
+
+	// Recovery flips back to ready
+	redisErr = nil
+	assert.True(t, service.CheckReadiness(ctx))
+	assert.True(t, service.IsReady())
+}
+
+func TestHealthService_Readiness_S3Unhealthy(t *testing.T) {
+	mockRepo := &mockImageRepository{}
+	mockStorage := &mockStorageProvider{
+		healthFunc: func(ctx context.Context) error {
+			return errors.New("bucket not accessible")
+		},
+	}
+
+	service := NewHealthService(mockRepo, mockStorage, testutil.TestConfig(), "1.0.0")
+	assert.False(t, service.CheckReadiness(context.Background()))
+
+	// With S3 checks disabled only Redis gates readiness
+	cfg := testutil.TestConfig()
+	cfg.Health.S3ChecksDisabled = true
+	service = NewHealthService(mockRepo, mockStorage, cfg, "1.0.0")
+	assert.True(t, service.CheckReadiness(context.Background()))
+}
+
+func TestHealthService_StartReadinessChecks(t *testing.T) {
+	var healthy atomic.Bool
+	mockRepo := &mockImageRepository{
+		healthFunc: func(ctx context.Context) error {
+			if healthy.Load() {
+				return nil
+			}
+			return errors.New("redis starting")
+		},
+	}
+
+	cfg := testutil.TestConfig()
+	cfg.Health.ReadinessInterval = 10 * time.Millisecond
+	cfg.Health.ReadinessFailureThreshold = 1
+	service := NewHealthService(mockRepo, &mockStorageProvider{}, cfg, "1.0.0")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	service.StartReadinessChecks(ctx)
+
+	time.Sleep(30 * time.Millisecond)
+	assert.False(t, service.IsReady())
+
+	healthy.Store(true)
+	assert.Eventually(t, service.IsReady, time.Second, 5*time.Millisecond)
+
+	healthy.Store(false)
+	assert.Eventually(t, func() bool { return !service.IsReady() }, time.Second, 5*time.Millisecond)
+}
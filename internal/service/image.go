@@ -3,10 +3,19 @@ package service
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"math"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 	"time"
+	"unicode"
 
 	"resizr/internal/config"
 	"resizr/internal/models"
@@ -25,6 +34,10 @@ type ImageServiceImpl struct {
 	storage   storage.ImageStorage
 	processor ProcessorService
 	config    *config.Config
+	access    *accessBuffer
+	scanner   Scanner          // Checks uploads for malware before storage; nil disables scanning
+	queue     *ProcessingQueue // Generates upload resolutions in the background; nil generates them in the request
+	metaCache *metadataCache   // Recently read metadata; nil reads every lookup from the repository
 }
 
 // NewImageService creates a new image service
@@ -35,13 +48,23 @@ func NewImageService(
 	processor ProcessorService,
 	config *config.Config,
 ) ImageService {
-	return &ImageServiceImpl{
+	s := &ImageServiceImpl{
 		repo:      repo,
 		dedupRepo: dedupRepo,
 		storage:   storage,
 		processor: processor,
 		config:    config,
+		access:    newAccessBuffer(),
+		metaCache: newMetadataCache(config.Cache.MetadataSize, config.Cache.MetadataTTL),
 	}
+	if config.Scanner.URL != "" {
+		s.scanner = NewHTTPScanner(config.Scanner.URL, config.Scanner.Timeout)
+	}
+	if config.Queue.Enabled {
+		s.queue = NewProcessingQueue(config.Queue.Workers, config.Queue.MaxDepth)
+		activeQueue.Store(s.queue)
+	}
+	return s
 }
 
 // ProcessUpload handles the complete image upload workflow
@@ -61,7 +84,7 @@ func (s *ImageServiceImpl) ProcessUpload(ctx context.Context, input UploadInput)
 	}
 
 	// Validate input
-	if err := s.validateUploadInput(input); err != nil {
+	if err := s.validateUploadInput(&input); err != nil {
 		return nil, err
 	}
 
@@ -82,6 +105,29 @@ func (s *ImageServiceImpl) ProcessUpload(ctx context.Context, input UploadInput)
 		}
 	}
 
+	// The sniffed type is authoritative; an extension naming another type is flagged
+	mismatch := detectContentTypeMismatch(input.Filename, mimeType)
+	if mismatch != nil {
+		logger.WarnWithContext(ctx, "Upload extension does not match its content",
+			zap.String("filename", input.Filename),
+			zap.String("extension_mime_type", mismatch.ExtensionMimeType),
+			zap.String("detected_mime_type", mismatch.DetectedMimeType))
+		if s.config.Image.RejectExtensionMismatch {
+			return nil, models.ValidationError{
+				Field:   "file",
+				Message: fmt.Sprintf("File extension '%s' does not match the detected content type '%s'", mismatch.Extension, mismatch.DetectedMimeType),
+			}
+		}
+	}
+
+	// Enforce the configured input format allowlist when one is set
+	if len(s.config.Image.SupportedFormats) > 0 && !s.config.IsSupportedFormat(mimeType) {
+		return nil, models.ValidationError{
+			Field:   "file",
+			Message: fmt.Sprintf("Image format '%s' is not accepted. Accepted formats: %s", mimeType, strings.Join(s.config.Image.SupportedFormats, ", ")),
+		}
+	}
+
 	width, height, err := s.processor.GetDimensions(input.Data)
 	if err != nil {
 		return nil, models.ProcessingError{
@@ -90,18 +136,83 @@ func (s *ImageServiceImpl) ProcessUpload(ctx context.Context, input UploadInput)
 		}
 	}
 
-	// Calculate hash for deduplication
-	hash := models.CalculateImageHash(input.Data)
+	// Reject sources too small to produce sharp derivatives
+	if err := s.checkMinimumDimensions(width, height); err != nil {
+		return nil, err
+	}
+
+	// Enforce the configured aspect ratio allowlist when one is set
+	if err := s.checkAspectRatio(width, height); err != nil {
+		return nil, err
+	}
+
+	// Calculate hash for deduplication, scoped to the uploader's tenant
+	hash := models.CalculateImageHash(input.Data).WithNamespace(input.Namespace)
 
 	logger.InfoWithContext(ctx, "Calculated image hash for deduplication",
 		zap.String("hash", hash.String()),
 		zap.Int64("size", hash.Size),
 		zap.String("filename", input.Filename))
 
-	// Check for deduplication (Stage 1: Hash comparison)
-	existingDedupInfo, err := s.dedupRepo.FindImageByHash(ctx, hash)
-	var metadata *models.ImageMetadata
-	// ...existing code...
+	// Reject corrupted uploads before anything is stored
+	if err := verifyUploadChecksum(input.ExpectedChecksum, hash); err != nil {
+		logger.WarnWithContext(ctx, "Upload checksum mismatch",
+			zap.String("expected", input.ExpectedChecksum),
+			zap.String("actual", hash.Value),
+			zap.String("filename", input.Filename))
+		return nil, err
+	}
+
+	// Scan for malware before anything is stored, including duplicates of stored content
+	if err := s.scanUpload(ctx, input.Filename, input.Data); err != nil {
+		return nil, err
+	}
+
+	// The checks above apply to the uploaded bytes; storage and deduplication use the canonical ones
+	canonicalType, converted, err := s.canonicalizeOriginal(ctx, &input, mimeType)
+	if err != nil {
+		return nil, err
+	}
+	if converted {
+		mimeType = canonicalType
+		hash = models.CalculateImageHash(input.Data).WithNamespace(input.Namespace)
+	}
+
+	// With the processing queue a full queue turns the upload away before anything is stored
+	submitted := false
+	if s.queue != nil {
+		if !s.queue.Reserve() {
+			return nil, models.QueueFullError{Capacity: s.queue.Stats().Capacity}
+		}
+		defer func() {
+			if !submitted {
+				s.queue.Release()
+			}
+		}()
+	}
+
+	var (
+		metadata          *models.ImageMetadata
+		existingDedupInfo *models.DeduplicationInfo
+	)
+
+	// Unwatermarked derivatives differ from watermarked ones, so such uploads are stored in isolation
+	dedupEnabled := s.config.Image.DeduplicationEnabled && !input.DisableDedup && !input.DisableWatermark
+	if dedupEnabled {
+		// Check for deduplication (Stage 1: Hash comparison)
+		existingDedupInfo, err = s.dedupRepo.FindImageByHash(ctx, hash)
+		if err != nil && !isDedupNotFound(err) {
+			if failErr := s.handleDedupFailure(ctx, "find_dedup_info", imageID, err); failErr != nil {
+				return nil, failErr
+			}
+			// An existing record may just be unreadable, so this copy must not create or share one
+			dedupEnabled = false
+		}
+	} else {
+		logger.InfoWithContext(ctx, "Deduplication disabled for upload, storing isolated copy",
+			zap.String("image_id", imageID),
+			zap.String("hash", hash.String()))
+	}
 
 	logger.InfoWithContext(ctx, "Deduplication lookup result",
 		zap.String("hash", hash.String()),
@@ -198,8 +309,21 @@ func (s *ImageServiceImpl) ProcessUpload(ctx context.Context, input UploadInput)
 	} else {
 		// No existing deduplication found, create metadata for new image
 		metadata = models.NewImageMetadataWithHash(imageID, input.Filename, mimeType, input.Size, width, height, hash)
+		// The hash is kept for statistics, but isolated images are never shared
+		metadata.DedupDisabled = !dedupEnabled
+		metadata.WatermarkDisabled = input.DisableWatermark
+	}
+
+	// Deduplicated uploads add no files, so only uploads needing new storage count against the limit
+	if metadata != nil && !metadata.IsDeduped {
+		if err := s.checkCapacity(ctx); err != nil {
+			return nil, err
+		}
 	}
 
+	// Storage keys written by this upload, removed again if the upload cannot complete
+	uploadedKeys := []string{}
+
 	if metadata != nil && !metadata.IsDeduped {
 		// New unique image - store file
 
@@ -213,9 +337,15 @@ func (s *ImageServiceImpl) ProcessUpload(ctx context.Context, input UploadInput)
 			}
 		}
 
+		uploadedKeys = append(uploadedKeys, originalKey)
+
 		logger.InfoWithContext(ctx, "Original image uploaded successfully",
 			zap.String("image_id", imageID),
 			zap.String("storage_key", originalKey))
+	}
+
+	if metadata != nil && metadata.UsesDeduplication() && !metadata.IsDeduped {
+		originalKey := metadata.GetStorageKey("original")
 
 		// Create deduplication info for this new image
 		dedupInfo := models.NewDeduplicationInfo(hash, imageID, originalKey)
@@ -229,11 +359,12 @@ func (s *ImageServiceImpl) ProcessUpload(ctx context.Context, input UploadInput)
 			zap.Int("reference_count", dedupInfo.ReferenceCount))
 
 		if err := s.dedupRepo.StoreDeduplicationInfo(ctx, dedupInfo); err != nil {
-			// Log warning but don't fail the upload
-			logger.WarnWithContext(ctx, "Failed to store deduplication info",
-				zap.String("image_id", imageID),
-				zap.String("hash", hash.String()),
-				zap.Error(err))
+			if failErr := s.handleDedupFailure(ctx, "store_dedup_info", imageID, err); failErr != nil {
+				s.cleanupUploadedImages(ctx, imageID, uploadedKeys)
+				return nil, failErr
+			}
+			// Without a record nothing can share this copy, so it is kept isolated
+			metadata.DedupDisabled = true
 		} else {
 			logger.InfoWithContext(ctx, "Deduplication info created successfully",
 				zap.String("image_id", imageID),
@@ -244,14 +375,21 @@ func (s *ImageServiceImpl) ProcessUpload(ctx context.Context, input UploadInput)
 
 	// Process requested resolutions
 	processedResolutions := []string{}
+	failedResolutions := []string{}
 	processedSizes := make(map[string]int64)
 
-	// Add predefined resolutions based on configuration
-	var allResolutions []string
-	if s.config.Image.GenerateDefaultResolutions {
-		allResolutions = append([]string{"thumbnail"}, input.Resolutions...)
-	} else {
-		allResolutions = input.Resolutions
+	// Add the default resolutions configured for the detected format
+	allResolutions := append(append([]string{}, s.config.DefaultResolutionsFor(mimeType)...), input.Resolutions...)
+
+	// Queued uploads store only the original now; workers generate the resolutions
+	var queuedResolutions []string
+	if s.queue != nil {
+		for _, resolutionName := range allResolutions {
+			if !metadata.HasResolution(resolutionName) && !slices.Contains(queuedResolutions, resolutionName) {
+				queuedResolutions = append(queuedResolutions, resolutionName)
+			}
+		}
+		allResolutions = nil
 	}
 
 	for _, resolutionName := range allResolutions {
@@ -261,12 +399,14 @@ func (s *ImageServiceImpl) ProcessUpload(ctx context.Context, input UploadInput)
 		}
 
 		var shouldProcess = true
+		var dedupInfo *models.DeduplicationInfo
 
 		// For deduplicated images, check if resolution already exists in shared storage
 		if metadata != nil && metadata.IsDeduped {
 			// Get deduplication info to check per-resolution references
-			dedupInfo, err := s.dedupRepo.GetDeduplicationInfo(ctx, metadata.Hash)
-			if err == nil {
+			var dedupErr error
+			dedupInfo, dedupErr = s.dedupRepo.GetDeduplicationInfo(ctx, metadata.Hash)
+			if dedupErr == nil {
 				// Ensure ResolutionRefs is initialized (for backward compatibility)
 				if dedupInfo.ResolutionRefs == nil {
 					dedupInfo.ResolutionRefs = make(map[string]*models.ResolutionReference)
@@ -286,19 +426,51 @@ func (s *ImageServiceImpl) ProcessUpload(ctx context.Context, input UploadInput)
 
 		var processingSucceeded = true
 		if shouldProcess {
-			if err := s.processResolutionWithMetadata(ctx, imageID, resolutionName, input.Data, mimeType, metadata); err != nil {
+			storageKeys, err := s.processResolutionWithMetadata(ctx, imageID, resolutionName, input.Data, mimeType, metadata)
+			if err != nil {
 				logger.ErrorWithContext(ctx, "Failed to process resolution",
 					zap.String("image_id", imageID),
 					zap.String("resolution", resolutionName),
 					zap.Error(err))
+				if s.config.Image.UploadPartialFailureMode == "fail" {
+					s.rollbackUpload(ctx, metadata, uploadedKeys)
+					return nil, models.ProcessingError{
+						Operation: "process_resolution",
+						Reason:    fmt.Sprintf("resolution %s: %v", resolutionName, err),
+					}
+				}
 				// Continue with other resolutions instead of failing completely
 				processingSucceeded = false
+				failedResolutions = append(failedResolutions, resolutionName)
+			} else {
+				uploadedKeys = append(uploadedKeys, storageKeys...)
+			}
+		} else {
+			// The master generated it from identical content, so it has the same alias and variants
+			servesOriginal := s.servesOriginal(metadata, resolutionName)
+			metadata.SetServesOriginal(resolutionName, servesOriginal)
+			if !servesOriginal {
+				metadata.SetDerivativeSource(resolutionName, s.sharedDerivativeSource(ctx, dedupInfo, resolutionName))
+				derivativeMimeType := models.GetDerivativeMimeType(mimeType)
+				if format := s.sharedResolutionFormat(ctx, metadata.SharedImageID, resolutionName); format != "" {
+					metadata.SetResolutionFormat(resolutionName, format)
+					derivativeMimeType = models.GetMimeTypeFromFormat(format)
+				}
+				metadata.SetFormatVariants(resolutionName, s.formatVariants(derivativeMimeType))
 			}
 		}
 
 		// Only add to metadata and processed list if processing succeeded (or wasn't needed)
 		if processingSucceeded {
-			metadata.AddResolution(resolutionName)
+			if err := metadata.AddResolution(resolutionName); err != nil {
+				// Aliases are checked up front in validateUploadInput, so this should not happen
+				logger.WarnWithContext(ctx, "Skipping resolution with conflicting alias",
+					zap.String("image_id", imageID),
+					zap.String("resolution", resolutionName),
+					zap.Error(err))
+				continue
+			}
+			metadata.SetResolutionDimensions(resolutionName, s.storedResolutionDimensions(metadata, resolutionName))
 			processedResolutions = append(processedResolutions, resolutionName)
 		} else {
 			// Skip adding to deduplication tracking if processing failed
@@ -306,38 +478,13 @@ func (s *ImageServiceImpl) ProcessUpload(ctx context.Context, input UploadInput)
 		}
 
 		// Add resolution reference for deduplication tracking
-		if metadata.IsDeduped {
-			dedupInfo, err := s.dedupRepo.GetDeduplicationInfo(ctx, metadata.Hash)
-			if err == nil {
-				dedupInfo.AddResolutionReference(resolutionName, imageID)
-				if updateErr := s.dedupRepo.UpdateDeduplicationInfo(ctx, dedupInfo); updateErr != nil {
-					logger.WarnWithContext(ctx, "Failed to update resolution reference",
-						zap.String("image_id", imageID),
-						zap.String("resolution", resolutionName),
-						zap.Error(updateErr))
-				}
-			}
-		} else {
-			// For non-deduplicated images, also track resolution references
-			dedupInfo, err := s.dedupRepo.GetDeduplicationInfo(ctx, metadata.Hash)
-			if err == nil {
-				dedupInfo.AddResolutionReference(resolutionName, imageID)
-				if updateErr := s.dedupRepo.UpdateDeduplicationInfo(ctx, dedupInfo); updateErr != nil {
-					logger.WarnWithContext(ctx, "Failed to update resolution reference",
-						zap.String("image_id", imageID),
-						zap.String("resolution", resolutionName),
-						zap.Error(updateErr))
-				}
-			}
-		}
-
-		// ...existing code...
+		s.addResolutionReference(ctx, metadata, resolutionName)
 	}
 
 	// Store metadata in repository
 	if err := s.repo.Store(ctx, metadata); err != nil {
 		// If metadata storage fails, cleanup uploaded images
-		s.cleanupUploadedImages(ctx, imageID, append(processedResolutions, "original"))
+		s.cleanupUploadedImages(ctx, imageID, uploadedKeys)
 		return nil, models.StorageError{
 			Operation: "store_metadata",
 			Backend:   "Redis",
@@ -345,19 +492,126 @@ func (s *ImageServiceImpl) ProcessUpload(ctx context.Context, input UploadInput)
 		}
 	}
 
+	// The stored metadata is what workers add the resolutions to
+	if len(queuedResolutions) > 0 {
+		s.queue.Submit(imageID, func(ctx context.Context) error {
+			return s.processQueuedResolutions(ctx, imageID, queuedResolutions)
+		})
+		submitted = true
+	}
+
 	logger.InfoWithContext(ctx, "Image upload processing completed",
 		zap.String("image_id", imageID),
 		zap.Strings("processed_resolutions", processedResolutions),
+		zap.Strings("failed_resolutions", failedResolutions),
+		zap.Strings("queued_resolutions", queuedResolutions),
 		zap.Int("total_resolutions", len(processedResolutions)))
 
 	return &UploadResult{
 		ImageID:              imageID,
 		ProcessedResolutions: processedResolutions,
+		FailedResolutions:    failedResolutions,
+		QueuedResolutions:    queuedResolutions,
 		OriginalSize:         input.Size,
 		ProcessedSizes:       processedSizes,
+		ContentTypeMismatch:  mismatch,
 	}, nil
 }
 
+// canonicalizeOriginal re-encodes the upload in CANONICAL_ORIGINAL_FORMAT, updating
+// its data, size and filename extension, and reports the new MIME type. Uploads
+// already in that format and animated ones are left as they are.
+func (s *ImageServiceImpl) canonicalizeOriginal(ctx context.Context, input *UploadInput, mimeType string) (string, bool, error) {
+	format := s.config.Image.CanonicalOriginalFormat
+	canonicalType := models.GetMimeTypeFromFormat(format)
+	if format == "" || mimeType == canonicalType {
+		return mimeType, false, nil
+	}
+
+	data, err := s.processor.ConvertFormat(input.Data, ResizeConfig{
+		Format:          format,
+		Quality:         s.config.Image.Quality,
+		JPEGSubsampling: s.config.Image.JPEGSubsampling,
+	})
+	if errors.Is(err, ErrAnimatedSource) {
+		logger.InfoWithContext(ctx, "Keeping animated original in its uploaded format",
+			zap.String("filename", input.Filename),
+			zap.String("mime_type", mimeType))
+		return mimeType, false, nil
+	}
+	if err != nil {
+		return "", false, models.ProcessingError{
+			Operation: "canonical_format",
+			Reason:    err.Error(),
+		}
+	}
+
+	filename := strings.TrimSuffix(input.Filename, filepath.Ext(input.Filename)) + "." + models.GetExtensionFromMimeType(canonicalType)
+	logger.InfoWithContext(ctx, "Converted original to the canonical format",
+		zap.String("filename", filename),
+		zap.String("from", mimeType),
+		zap.String("to", canonicalType),
+		zap.Int64("uploaded_size", input.Size),
+		zap.Int("stored_size", len(data)))
+
+	input.Data = data
+	input.Size = int64(len(data))
+	input.Filename = filename
+	return canonicalType, true, nil
+}
+
+// addResolutionReference records that imageID uses resolution of its content hash,
+// so shared files are only deleted once no image references them
+func (s *ImageServiceImpl) addResolutionReference(ctx context.Context, metadata *models.ImageMetadata, resolution string) {
+	if !metadata.IsDeduped && !metadata.UsesDeduplication() {
+		return
+	}
+
+	dedupInfo, err := s.dedupRepo.GetDeduplicationInfo(ctx, metadata.Hash)
+	if err != nil {
+		return
+	}
+	dedupInfo.AddResolutionReference(resolution, metadata.ID)
+	if updateErr := s.dedupRepo.UpdateDeduplicationInfo(ctx, dedupInfo); updateErr != nil {
+		logger.WarnWithContext(ctx, "Failed to update resolution reference",
+			zap.String("image_id", metadata.ID),
+			zap.String("resolution", resolution),
+			zap.Error(updateErr))
+	}
+}
+
+// processQueuedResolutions generates the resolutions of a queued upload one by one.
+// A failed resolution does not stop the others; the failures are returned together.
+func (s *ImageServiceImpl) processQueuedResolutions(ctx context.Context, imageID string, resolutions []string) error {
+	var errs []error
+	for _, resolution := range resolutions {
+		if err := s.ProcessResolution(ctx, imageID, resolution, false); err != nil {
+			logger.WarnWithContext(ctx, "Failed to process queued resolution",
+				zap.String("image_id", imageID),
+				zap.String("resolution", resolution),
+				zap.Error(err))
+			errs = append(errs, fmt.Errorf("resolution %s: %w", resolution, err))
+			continue
+		}
+
+		metadata, err := s.GetMetadata(ctx, imageID)
+		if err == nil {
+			s.addResolutionReference(ctx, metadata, resolution)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// StartProcessingQueue launches the processing queue workers until ctx is
+// cancelled. It does nothing when uploads are processed in the request.
+func (s *ImageServiceImpl) StartProcessingQueue(ctx context.Context) {
+	if s.queue == nil {
+		logger.Debug("Processing queue disabled, resolutions are generated in the request")
+		return
+	}
+	s.queue.Start(ctx)
+}
+
 // GetMetadata retrieves image metadata by ID
 func (s *ImageServiceImpl) GetMetadata(ctx context.Context, imageID string) (*models.ImageMetadata, error) {
 	logger.DebugWithContext(ctx, "Retrieving image metadata",
@@ -371,10 +625,15 @@ func (s *ImageServiceImpl) GetMetadata(ctx context.Context, imageID string) (*mo
 		}
 	}
 
+	if metadata, ok := s.metaCache.get(imageID); ok {
+		return metadata, nil
+	}
+
 	metadata, err := s.repo.Get(ctx, imageID)
 	if err != nil {
-		if _, ok := err.(models.NotFoundError); ok {
-			return nil, err // Pass through not found errors
+		switch err.(type) {
+		case models.NotFoundError, models.CorruptMetadataError:
+			return nil, err // Pass through not found and corrupt record errors
 		}
 		return nil, models.StorageError{
 			Operation: "get_metadata",
@@ -383,6 +642,72 @@ func (s *ImageServiceImpl) GetMetadata(ctx context.Context, imageID string) (*mo
 		}
 	}
 
+	s.metaCache.put(metadata)
+	return metadata, nil
+}
+
+// PurgeImageCache drops the in-process metadata of an image and the presigned URLs
+// the cache backend holds for it. Images without cached entries purge successfully.
+func (s *ImageServiceImpl) PurgeImageCache(ctx context.Context, imageID string) error {
+	if _, err := uuid.Parse(imageID); err != nil {
+		return models.ValidationError{
+			Field:   "image_id",
+			Message: "Invalid UUID format",
+		}
+	}
+
+	s.metaCache.invalidate(imageID)
+
+	if cache, ok := s.repo.(repository.CacheRepository); ok {
+		if err := cache.DeleteAllCachedURLs(ctx, imageID); err != nil {
+			return models.StorageError{
+				Operation: "purge_cached_urls",
+				Backend:   "Repository",
+				Reason:    err.Error(),
+			}
+		}
+	}
+
+	logger.InfoWithContext(ctx, "Image cache purged",
+		zap.String("image_id", imageID))
+	return nil
+}
+
+// UpdateFilename renames an image without touching its stored files
+func (s *ImageServiceImpl) UpdateFilename(ctx context.Context, imageID, filename string) (*models.ImageMetadata, error) {
+	logger.InfoWithContext(ctx, "Updating image filename",
+		zap.String("image_id", imageID),
+		zap.String("filename", filename))
+
+	metadata, err := s.GetMetadata(ctx, imageID)
+	if err != nil {
+		return nil, err
+	}
+
+	filename, err = s.validateFilename(filename, metadata.GetFileExtension())
+	if err != nil {
+		return nil, err
+	}
+
+	// Storage keys are UUID-based and derive their extension from the filename,
+	// which validateFilename keeps unchanged
+	metadata.Filename = filename
+	metadata.UpdatedAt = time.Now()
+
+	err = s.repo.Update(ctx, metadata)
+	s.metaCache.invalidate(metadata.ID)
+	if err != nil {
+		return nil, models.StorageError{
+			Operation: "update_metadata",
+			Backend:   "Redis",
+			Reason:    err.Error(),
+		}
+	}
+
+	logger.InfoWithContext(ctx, "Image filename updated successfully",
+		zap.String("image_id", imageID),
+		zap.String("filename", filename))
+
 	return metadata, nil
 }
 
@@ -420,11 +745,136 @@ func (s *ImageServiceImpl) GetImageStream(ctx context.Context, imageID, resoluti
 	return stream, metadata, nil
 }
 
-// ProcessResolution generates a specific resolution for an existing image
-func (s *ImageServiceImpl) ProcessResolution(ctx context.Context, imageID, resolution string) error {
+// deleteFormatVariants removes the format variant files of a resolution. Failures are
+// only logged; a leftover variant does not block removing the resolution.
+func (s *ImageServiceImpl) deleteFormatVariants(ctx context.Context, metadata *models.ImageMetadata, resolution string) {
+	for _, format := range metadata.GetFormatVariants(resolution) {
+		storageKey := metadata.GetVariantStorageKey(resolution, format)
+		if err := s.storage.Delete(ctx, storageKey); err != nil {
+			logger.WarnWithContext(ctx, "Failed to delete format variant from storage",
+				zap.String("image_id", metadata.ID),
+				zap.String("resolution", resolution),
+				zap.String("storage_key", storageKey),
+				zap.Error(err))
+		}
+	}
+}
+
+// GetImageVariantStream retrieves a resolution in one of its format variants as a stream.
+// Asking for the resolution's own format is the same as GetImageStream.
+func (s *ImageServiceImpl) GetImageVariantStream(ctx context.Context, imageID, resolution, format string) (io.ReadCloser, *models.ImageMetadata, error) {
+	logger.DebugWithContext(ctx, "Retrieving image variant stream",
+		zap.String("image_id", imageID),
+		zap.String("resolution", resolution),
+		zap.String("format", format))
+
+	if models.GetMimeTypeFromFormat(format) == "" {
+		return nil, nil, models.ValidationError{
+			Field:   "format",
+			Message: fmt.Sprintf("Unsupported format '%s', must be one of: jpeg, png, gif, webp", format),
+		}
+	}
+
+	metadata, err := s.GetMetadata(ctx, imageID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if resolution != "original" && !metadata.HasResolution(resolution) {
+		return nil, nil, models.NotFoundError{
+			Resource: "resolution",
+			ID:       fmt.Sprintf("%s/%s", imageID, resolution),
+		}
+	}
+
+	storageKey := metadata.GetActualStorageKey(resolution)
+	if models.GetMimeTypeFromFormat(format) != metadata.GetContentType(resolution) {
+		if !metadata.HasFormatVariant(resolution, format) {
+			return nil, nil, models.NotFoundError{
+				Resource: "format variant",
+				ID:       fmt.Sprintf("%s/%s.%s", imageID, resolution, format),
+			}
+		}
+		storageKey = metadata.GetVariantStorageKey(resolution, format)
+	}
+
+	stream, err := s.storage.Download(ctx, storageKey)
+	if err != nil {
+		return nil, nil, models.StorageError{
+			Operation: "download",
+			Backend:   "S3",
+			Reason:    err.Error(),
+		}
+	}
+
+	return stream, metadata, nil
+}
+
+// GetImageFrame decodes frame n of a stored resolution and returns it as a still
+// image in the resolution's stored format (TIFF originals are served as PNG),
+// together with its content type
+func (s *ImageServiceImpl) GetImageFrame(ctx context.Context, imageID, resolution string, frame int) ([]byte, string, *models.ImageMetadata, error) {
+	if frame < 0 {
+		return nil, "", nil, models.ValidationError{
+			Field:   "frame",
+			Message: "Frame index must be a non-negative integer",
+		}
+	}
+
+	stream, metadata, err := s.GetImageStream(ctx, imageID, resolution)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	defer func() {
+		if err := stream.Close(); err != nil {
+			logger.WarnWithContext(ctx, "Failed to close stream", zap.String("error", err.Error()))
+		}
+	}()
+
+	data, err := io.ReadAll(stream)
+	if err != nil {
+		return nil, "", nil, models.StorageError{
+			Operation: "download",
+			Backend:   "S3",
+			Reason:    err.Error(),
+		}
+	}
+
+	contentType := models.GetDerivativeMimeType(metadata.GetContentType(resolution))
+	frameData, err := s.processor.ExtractFrame(data, frame, ResizeConfig{
+		Quality:         s.config.Image.Quality,
+		Format:          processorFormat(contentType),
+		JPEGSubsampling: s.config.Image.JPEGSubsampling,
+	})
+	if err != nil {
+		var validationErr models.ValidationError
+		if errors.As(err, &validationErr) {
+			return nil, "", nil, validationErr
+		}
+		return nil, "", nil, models.ProcessingError{
+			Operation: "extract_frame",
+			Reason:    err.Error(),
+		}
+	}
+
+	return frameData, contentType, metadata, nil
+}
+
+// ProcessResolution generates a specific resolution for an existing image.
+// Existing resolutions are left untouched unless force is set, in which case the
+// derivative is regenerated from the original and overwritten in place.
+func (s *ImageServiceImpl) ProcessResolution(ctx context.Context, imageID, resolution string, force bool) error {
+	resolution = models.NormalizeResolution(resolution)
 	logger.InfoWithContext(ctx, "Processing additional resolution",
 		zap.String("image_id", imageID),
-		zap.String("resolution", resolution))
+		zap.String("resolution", resolution),
+		zap.Bool("force", force))
+
+	if rc, err := models.ParseResolution(resolution); err == nil {
+		if err := s.checkResolutionArea("resolution", resolution, rc); err != nil {
+			return err
+		}
+	}
 
 	// Get metadata
 	metadata, err := s.GetMetadata(ctx, imageID)
@@ -432,11 +882,23 @@ func (s *ImageServiceImpl) ProcessResolution(ctx context.Context, imageID, resol
 		return err
 	}
 
-	// Check if resolution already exists
-	if metadata.HasResolution(resolution) {
+	// Reject aliases that already point at different dimensions on this image
+	if err := metadata.CheckAliasConflict(resolution); err != nil {
+		return err
+	}
+
+	// Check if resolution already exists (including the same dimensions and alias)
+	exists := metadata.ContainsResolution(resolution)
+	if exists && !force {
 		return nil // Already exists, no need to process
 	}
 
+	// Regenerate existing resolutions by their stored dimensions so aliases resolve to the same file
+	processName := resolution
+	if exists && metadata.HasResolution(resolution) {
+		processName = metadata.ResolveToDimensions(resolution)
+	}
+
 	// Download original image data
 	originalStream, _, err := s.GetImageStream(ctx, imageID, "original")
 	if err != nil {
@@ -457,30 +919,665 @@ func (s *ImageServiceImpl) ProcessResolution(ctx context.Context, imageID, resol
 		}
 	}
 
-	// Process the resolution
-	if err := s.processResolution(ctx, imageID, resolution, originalData, metadata.MimeType); err != nil {
+	// Process the resolution (deduplicated images write to the master's shared storage)
+	if _, err := s.processResolutionWithMetadata(ctx, imageID, processName, originalData, metadata.MimeType, metadata); err != nil {
 		return err
 	}
 
 	// Update metadata
-	metadata.AddResolution(resolution)
-	return s.repo.Update(ctx, metadata)
+	if !exists {
+		if err := metadata.AddResolution(resolution); err != nil {
+			return err
+		}
+	} else {
+		logger.InfoWithContext(ctx, "Existing resolution regenerated",
+			zap.String("image_id", imageID),
+			zap.String("resolution", resolution),
+			zap.String("storage_key", metadata.GetActualStorageKey(processName)))
+	}
+	metadata.SetResolutionDimensions(processName, s.storedResolutionDimensions(metadata, processName))
+	metadata.UpdatedAt = time.Now()
+	err = s.repo.Update(ctx, metadata)
+	s.metaCache.invalidate(metadata.ID)
+	return err
 }
 
-// DeleteImage removes an image and all its resolutions
-func (s *ImageServiceImpl) DeleteImage(ctx context.Context, imageID string) error {
-	logger.InfoWithContext(ctx, "Deleting image",
-		zap.String("image_id", imageID))
+// ProcessResolutionWithinSize generates resolution encoded at the highest quality
+// whose output fits in maxBytes, and stores it as its own resolution so the search
+// runs once per budget. Only JPEG and WebP derivatives can be fitted.
+func (s *ImageServiceImpl) ProcessResolutionWithinSize(ctx context.Context, imageID, resolution string, maxBytes int64, force bool) (string, error) {
+	logger.InfoWithContext(ctx, "Processing size-capped resolution",
+		zap.String("image_id", imageID),
+		zap.String("resolution", resolution),
+		zap.Int64("max_bytes", maxBytes),
+		zap.Bool("force", force))
 
-	// Get metadata to know what to delete
-	metadata, err := s.GetMetadata(ctx, imageID)
-	if err != nil {
+	if maxBytes < MinTargetSizeBytes || maxBytes > s.config.Image.MaxFileSize {
+		return "", models.ValidationError{
+			Field:   "max_bytes",
+			Message: fmt.Sprintf("max_bytes must be between %d and %d", MinTargetSizeBytes, s.config.Image.MaxFileSize),
+		}
+	}
+	if strings.Contains(resolution, ":") {
+		return "", models.ValidationError{
+			Field:   "resolution",
+			Message: "Aliases are not supported together with max_bytes",
+		}
+	}
+	resolutionConfig, err := models.ParseResolution(resolution)
+	if err != nil {
+		return "", models.ValidationError{
+			Field:   "resolution",
+			Message: err.Error(),
+		}
+	}
+
+	metadata, err := s.GetMetadata(ctx, imageID)
+	if err != nil {
+		return "", err
+	}
+
+	derivativeMimeType := models.GetDerivativeMimeType(metadata.MimeType)
+	format := processorFormat(derivativeMimeType)
+	if format != "jpeg" && format != "webp" {
+		return "", models.ValidationError{
+			Field:   "max_bytes",
+			Message: fmt.Sprintf("A size budget needs JPEG or WebP output, but this image is stored as %s", derivativeMimeType),
+		}
+	}
+
+	name := models.SizeBudgetResolutionName(resolution, maxBytes)
+	exists := metadata.ContainsResolution(name)
+	if exists && !force {
+		return name, nil
+	}
+
+	originalStream, _, err := s.GetImageStream(ctx, imageID, "original")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := originalStream.Close(); err != nil {
+			logger.WarnWithContext(ctx, "Failed to close original stream", zap.String("error", err.Error()))
+		}
+	}()
+
+	originalData, err := io.ReadAll(originalStream)
+	if err != nil {
+		return "", models.ProcessingError{
+			Operation: "read_original",
+			Reason:    err.Error(),
+		}
+	}
+
+	processedData, err := s.processImageWithTimeout(ctx, originalData, ResizeConfig{
+		Width:           resolutionConfig.Width,
+		Height:          resolutionConfig.Height,
+		Quality:         s.config.QualityFor(resolution),
+		Format:          format,
+		Mode:            ResizeMode(s.config.Image.ResizeMode),
+		BackgroundColor: s.config.Canvas.BackgroundColor,
+		Filter:          s.config.Image.ResampleFilter,
+		JPEGSubsampling: s.config.Image.JPEGSubsampling,
+		MaxBytes:        maxBytes,
+		Watermark:       s.textWatermark(metadata),
+	})
+	if err != nil {
+		return "", models.ProcessingError{
+			Operation: "resize",
+			Reason:    err.Error(),
+		}
+	}
+
+	// Deduplicated images write to the master's shared storage, like other resolutions
+	storageKey := metadata.GetActualStorageKey(name)
+	if err := s.storage.Upload(ctx, storageKey, bytes.NewReader(processedData), int64(len(processedData)), derivativeMimeType); err != nil {
+		return "", models.StorageError{
+			Operation: "upload_processed",
+			Backend:   "S3",
+			Reason:    err.Error(),
+		}
+	}
+
+	if err := metadata.AddResolution(name); err != nil {
+		return "", err
+	}
+	metadata.SetResolutionDimensions(name, s.resolutionOutputDimensions(metadata, resolution))
+	metadata.UpdatedAt = time.Now()
+	err = s.repo.Update(ctx, metadata)
+	s.metaCache.invalidate(metadata.ID)
+	if err != nil {
+		if !exists {
+			s.cleanupUploadedImages(ctx, imageID, []string{storageKey})
+		}
+		return "", models.StorageError{
+			Operation: "update_metadata",
+			Backend:   "Repository",
+			Reason:    err.Error(),
+		}
+	}
+
+	logger.InfoWithContext(ctx, "Size-capped resolution processed successfully",
+		zap.String("image_id", imageID),
+		zap.String("resolution", name),
+		zap.String("storage_key", storageKey),
+		zap.Int("processed_size", len(processedData)))
+
+	return name, nil
+}
+
+// ProcessFormatVariant makes a resolution available in format. A missing resolution is
+// generated first; the variant is then encoded from the original and recorded like the
+// GENERATE_FORMAT_VARIANTS ones, so later requests are served from storage.
+func (s *ImageServiceImpl) ProcessFormatVariant(ctx context.Context, imageID, resolution, format string) error {
+	variantMimeType := models.GetMimeTypeFromFormat(format)
+	if variantMimeType == "" {
+		return models.ValidationError{
+			Field:   "format",
+			Message: fmt.Sprintf("Unsupported format '%s', must be one of: jpeg, png, gif, webp", format),
+		}
+	}
+
+	metadata, err := s.GetMetadata(ctx, imageID)
+	if err != nil {
+		return err
+	}
+	if !metadata.HasResolution(resolution) {
+		if err := s.ProcessResolution(ctx, imageID, resolution, false); err != nil {
+			return err
+		}
+		if metadata, err = s.GetMetadata(ctx, imageID); err != nil {
+			return err
+		}
+	}
+
+	if variantMimeType == metadata.GetContentType(resolution) || metadata.HasFormatVariant(resolution, format) {
+		return nil
+	}
+
+	logger.InfoWithContext(ctx, "Generating format variant on request",
+		zap.String("image_id", imageID),
+		zap.String("resolution", resolution),
+		zap.String("format", format))
+
+	resolutionConfig, err := models.ParseResolution(metadata.ResolveToDimensions(resolution))
+	if err != nil {
+		return models.ValidationError{
+			Field:   "resolution",
+			Message: err.Error(),
+		}
+	}
+	// A resolution served from the original is converted at the original's size
+	if metadata.ServesOriginal(resolution) {
+		resolutionConfig.Width, resolutionConfig.Height = metadata.Width, metadata.Height
+	}
+
+	originalStream, _, err := s.GetImageStream(ctx, imageID, "original")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := originalStream.Close(); err != nil {
+			logger.WarnWithContext(ctx, "Failed to close original stream", zap.String("error", err.Error()))
+		}
+	}()
+
+	originalData, err := io.ReadAll(originalStream)
+	if err != nil {
+		return models.ProcessingError{
+			Operation: "read_original",
+			Reason:    err.Error(),
+		}
+	}
+
+	variantData, err := s.processImageWithTimeout(ctx, originalData, ResizeConfig{
+		Width:           resolutionConfig.Width,
+		Height:          resolutionConfig.Height,
+		Quality:         s.config.QualityFor(resolution),
+		Format:          format,
+		Mode:            ResizeMode(s.config.Image.ResizeMode),
+		BackgroundColor: s.config.Canvas.BackgroundColor,
+		Filter:          s.config.Image.ResampleFilter,
+		JPEGSubsampling: s.config.Image.JPEGSubsampling,
+		Watermark:       s.textWatermark(metadata),
+	})
+	if err != nil {
+		return models.ProcessingError{
+			Operation: "resize_variant",
+			Reason:    fmt.Sprintf("%s: %v", format, err),
+		}
+	}
+
+	// Deduplicated images write to the master's shared storage, like their other variants
+	variantKey := metadata.GetVariantStorageKey(resolution, format)
+	if err := s.storage.Upload(ctx, variantKey, bytes.NewReader(variantData), int64(len(variantData)), variantMimeType); err != nil {
+		return models.StorageError{
+			Operation: "upload_variant",
+			Backend:   "S3",
+			Reason:    err.Error(),
+		}
+	}
+
+	metadata.SetFormatVariants(resolution, append(metadata.GetFormatVariants(resolution), format))
+	metadata.UpdatedAt = time.Now()
+	err = s.repo.Update(ctx, metadata)
+	s.metaCache.invalidate(metadata.ID)
+	if err != nil {
+		s.cleanupUploadedImages(ctx, imageID, []string{variantKey})
+		return models.StorageError{
+			Operation: "update_metadata",
+			Backend:   "Repository",
+			Reason:    err.Error(),
+		}
+	}
+
+	logger.InfoWithContext(ctx, "Format variant processed successfully",
+		zap.String("image_id", imageID),
+		zap.String("resolution", resolution),
+		zap.String("storage_key", variantKey),
+		zap.Int("processed_size", len(variantData)))
+
+	return nil
+}
+
+// FindByHash looks up stored content by its SHA256 checksum so clients can skip
+// uploading files that are already present. Only deduplicated content is indexed
+// by hash, so isolated copies are never reported.
+func (s *ImageServiceImpl) FindByHash(ctx context.Context, checksum string, size int64, namespace string) (*models.ExistsResponse, error) {
+	value, ok := models.ParseSHA256Hex(checksum)
+	if !ok {
+		return nil, models.ValidationError{
+			Field:   "hash",
+			Message: "Hash must be a 64-character hex-encoded SHA256 digest",
+		}
+	}
+	if size < 0 {
+		return nil, models.ValidationError{
+			Field:   "size",
+			Message: "Size must not be negative",
+		}
+	}
+
+	hash := models.ImageHash{Algorithm: "SHA256", Value: value, Size: size, Namespace: namespace}
+	response := &models.ExistsResponse{Hash: value}
+
+	dedupInfo, err := s.dedupRepo.FindImageByHash(ctx, hash)
+	if err != nil {
+		var notFound models.NotFoundError
+		if errors.As(err, &notFound) {
+			return response, nil
+		}
+		return nil, models.StorageError{
+			Operation: "find_by_hash",
+			Backend:   "Repository",
+			Reason:    err.Error(),
+		}
+	}
+
+	if size > 0 && dedupInfo.Hash.Size != size {
+		logger.DebugWithContext(ctx, "Hash found with a different size",
+			zap.String("hash", hash.String()),
+			zap.Int64("requested_size", size),
+			zap.Int64("stored_size", dedupInfo.Hash.Size))
+		return response, nil
+	}
+
+	// The master may have been deleted while other images still share the content
+	for _, imageID := range append([]string{dedupInfo.MasterImageID}, dedupInfo.ReferencingIDs...) {
+		exists, err := s.repo.Exists(ctx, imageID)
+		if err != nil {
+			return nil, models.StorageError{
+				Operation: "find_by_hash",
+				Backend:   "Repository",
+				Reason:    err.Error(),
+			}
+		}
+		if exists {
+			response.Exists = true
+			response.ImageID = imageID
+			break
+		}
+	}
+
+	logger.DebugWithContext(ctx, "Looked up content by hash",
+		zap.String("hash", hash.String()),
+		zap.Bool("exists", response.Exists),
+		zap.String("image_id", response.ImageID))
+
+	return response, nil
+}
+
+// FindByFilename looks images up by their original filename through the filename
+// index. Several uploads may share a filename, so every match is returned; a
+// filename without matches is reported as not found.
+func (s *ImageServiceImpl) FindByFilename(ctx context.Context, filename string) (*models.FilenameLookupResponse, error) {
+	if strings.TrimSpace(filename) == "" {
+		return nil, models.ValidationError{
+			Field:   "filename",
+			Message: "Filename is required",
+		}
+	}
+
+	ids, err := s.repo.FindByFilename(ctx, filename)
+	if err != nil {
+		return nil, models.StorageError{
+			Operation: "find_by_filename",
+			Backend:   "Repository",
+			Reason:    err.Error(),
+		}
+	}
+	if len(ids) == 0 {
+		return nil, models.NotFoundError{
+			Resource: "image",
+			ID:       filename,
+		}
+	}
+
+	logger.DebugWithContext(ctx, "Looked up images by filename",
+		zap.String("filename", filename),
+		zap.Int("matches", len(ids)))
+
+	return &models.FilenameLookupResponse{Filename: filename, ImageIDs: ids}, nil
+}
+
+// GetStorageKeys resolves the object keys of an image the same way reads and writes
+// do: deduplicated images point at the shared master's files, and with
+// S3_KEY_HASH_PREFIX the keys carry their hash partition
+func (s *ImageServiceImpl) GetStorageKeys(ctx context.Context, imageID string) (*models.StorageKeysResponse, error) {
+	metadata, err := s.GetMetadata(ctx, imageID)
+	if err != nil {
+		return nil, err
+	}
+
+	objectKey := func(resolution string) string {
+		key := metadata.GetActualStorageKey(resolution)
+		if s.config.S3.KeyHashPrefix {
+			key = storage.HashedKey(key)
+		}
+		return key
+	}
+
+	response := &models.StorageKeysResponse{
+		ID:           metadata.ID,
+		Bucket:       s.config.S3.Bucket,
+		Deduplicated: metadata.IsDeduped && metadata.SharedImageID != "",
+		Original:     objectKey("original"),
+		Resolutions:  make(map[string]string, len(metadata.Resolutions)),
+	}
+	if response.Deduplicated {
+		response.SharedImageID = metadata.SharedImageID
+	}
+	// Stored entries may carry an alias; the file is named after the dimensions alone
+	for _, resolution := range metadata.Resolutions {
+		response.Resolutions[resolution] = objectKey(models.ExtractDimensions(resolution))
+	}
+
+	return response, nil
+}
+
+// VerifyImage checks every file the metadata expects against storage. A storage
+// error while checking is reported as a problem on that file rather than failing
+// the whole verification; only a missing image or unreadable metadata does.
+func (s *ImageServiceImpl) VerifyImage(ctx context.Context, imageID string, checkHash bool) (*models.VerificationResponse, error) {
+	metadata, err := s.GetMetadata(ctx, imageID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.VerificationResponse{
+		ID:          metadata.ID,
+		Intact:      true,
+		Resolutions: make(map[string]models.FileVerification, len(metadata.Resolutions)),
+	}
+
+	check := func(resolution string) models.FileVerification {
+		key := metadata.GetActualStorageKey(resolution)
+		result := models.FileVerification{Key: key}
+		if s.config.S3.KeyHashPrefix {
+			result.Key = storage.HashedKey(key)
+		}
+
+		exists, err := s.storage.Exists(ctx, key)
+		switch {
+		case err != nil:
+			result.Problem = fmt.Sprintf("existence check failed: %v", err)
+		case !exists:
+			result.Problem = "file is missing from storage"
+		default:
+			result.Exists = true
+		}
+		if result.Problem != "" {
+			response.Intact = false
+		}
+		return result
+	}
+
+	response.Original = check("original")
+	for _, resolution := range metadata.Resolutions {
+		if resolution == "original" {
+			continue
+		}
+		response.Resolutions[resolution] = check(models.ExtractDimensions(resolution))
+	}
+
+	if checkHash && response.Original.Exists && metadata.Hash.Value != "" {
+		response.HashChecked = true
+		if problem := s.verifyOriginalHash(ctx, metadata); problem != "" {
+			response.Original.Problem = problem
+			response.Intact = false
+		}
+	}
+
+	logger.InfoWithContext(ctx, "Image verification completed",
+		zap.String("image_id", imageID),
+		zap.Bool("intact", response.Intact),
+		zap.Bool("hash_checked", response.HashChecked))
+
+	return response, nil
+}
+
+// verifyOriginalHash streams the original through SHA256 and describes any
+// mismatch with the recorded hash; it returns "" when they agree
+func (s *ImageServiceImpl) verifyOriginalHash(ctx context.Context, metadata *models.ImageMetadata) string {
+	reader, err := s.storage.Download(ctx, metadata.GetActualStorageKey("original"))
+	if err != nil {
+		return fmt.Sprintf("download failed: %v", err)
+	}
+	defer func() { _ = reader.Close() }()
+
+	hasher := sha256.New()
+	size, err := io.Copy(hasher, reader)
+	if err != nil {
+		return fmt.Sprintf("download failed: %v", err)
+	}
+
+	if value := hex.EncodeToString(hasher.Sum(nil)); value != metadata.Hash.Value {
+		return fmt.Sprintf("hash mismatch: expected %s, computed %s", metadata.Hash.Value, value)
+	}
+	if metadata.Hash.Size > 0 && size != metadata.Hash.Size {
+		return fmt.Sprintf("size mismatch: expected %d bytes, read %d", metadata.Hash.Size, size)
+	}
+	return ""
+}
+
+// CropImage extracts a rectangle of the original and stores it as a new resolution.
+// Cropping the same rectangle again returns the existing resolution.
+func (s *ImageServiceImpl) CropImage(ctx context.Context, imageID string, rect models.CropRect) (string, error) {
+	logger.InfoWithContext(ctx, "Cropping image region",
+		zap.String("image_id", imageID),
+		zap.String("rect", rect.String()))
+
+	metadata, err := s.GetMetadata(ctx, imageID)
+	if err != nil {
+		return "", err
+	}
+
+	if rect.X < 0 || rect.Y < 0 || rect.Width <= 0 || rect.Height <= 0 {
+		return "", models.ValidationError{
+			Field:   "crop",
+			Message: "x and y must not be negative and width and height must be positive",
+		}
+	}
+	if rect.X+rect.Width > metadata.Width || rect.Y+rect.Height > metadata.Height {
+		return "", models.ValidationError{
+			Field:   "crop",
+			Message: fmt.Sprintf("Crop rectangle %s lies outside the %dx%d source image", rect, metadata.Width, metadata.Height),
+		}
+	}
+	if rect.Width > s.config.Image.MaxWidth || rect.Height > s.config.Image.MaxHeight {
+		return "", models.ValidationError{
+			Field:   "crop",
+			Message: fmt.Sprintf("Crop size %dx%d exceeds maximum configured %dx%d", rect.Width, rect.Height, s.config.Image.MaxWidth, s.config.Image.MaxHeight),
+		}
+	}
+
+	resolution := rect.ResolutionName()
+	if metadata.ContainsResolution(resolution) {
+		return resolution, nil
+	}
+
+	originalStream, _, err := s.GetImageStream(ctx, imageID, "original")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := originalStream.Close(); err != nil {
+			logger.WarnWithContext(ctx, "Failed to close original stream", zap.String("error", err.Error()))
+		}
+	}()
+
+	originalData, err := io.ReadAll(originalStream)
+	if err != nil {
+		return "", models.ProcessingError{
+			Operation: "read_original",
+			Reason:    err.Error(),
+		}
+	}
+
+	derivativeMimeType := models.GetDerivativeMimeType(metadata.MimeType)
+	processedData, err := s.processImageWithTimeout(ctx, originalData, ResizeConfig{
+		Quality:         s.config.Image.Quality,
+		Format:          processorFormat(derivativeMimeType),
+		BackgroundColor: s.config.Canvas.BackgroundColor,
+		JPEGSubsampling: s.config.Image.JPEGSubsampling,
+		Crop:            &rect,
+		Watermark:       s.textWatermark(metadata),
+	})
+	if err != nil {
+		return "", models.ProcessingError{
+			Operation: "crop",
+			Reason:    err.Error(),
+		}
+	}
+
+	// Deduplicated images write to the master's shared storage, like other resolutions
+	storageKey := metadata.GetActualStorageKey(resolution)
+	if err := s.storage.Upload(ctx, storageKey, bytes.NewReader(processedData), int64(len(processedData)), derivativeMimeType); err != nil {
+		return "", models.StorageError{
+			Operation: "upload_processed",
+			Backend:   "S3",
+			Reason:    err.Error(),
+		}
+	}
+
+	if err := metadata.AddResolution(resolution); err != nil {
+		return "", err
+	}
+	metadata.SetResolutionDimensions(resolution, models.DimensionInfo{Width: rect.Width, Height: rect.Height})
+	metadata.UpdatedAt = time.Now()
+	err = s.repo.Update(ctx, metadata)
+	s.metaCache.invalidate(metadata.ID)
+	if err != nil {
+		s.cleanupUploadedImages(ctx, imageID, []string{storageKey})
+		return "", models.StorageError{
+			Operation: "update_metadata",
+			Backend:   "Repository",
+			Reason:    err.Error(),
+		}
+	}
+
+	logger.InfoWithContext(ctx, "Image region cropped successfully",
+		zap.String("image_id", imageID),
+		zap.String("resolution", resolution),
+		zap.String("storage_key", storageKey))
+
+	return resolution, nil
+}
+
+// EstimateResize predicts the output geometry of a resolution without generating or storing anything
+func (s *ImageServiceImpl) EstimateResize(ctx context.Context, imageID, resolution, mode string) (*models.EstimateResponse, error) {
+	resolutionConfig, err := models.ParseResolution(resolution)
+	if err != nil {
+		return nil, models.ValidationError{
+			Field:   "resolution",
+			Message: err.Error(),
+		}
+	}
+	if resolutionConfig.Width > s.config.Image.MaxWidth || resolutionConfig.Height > s.config.Image.MaxHeight {
+		return nil, models.ValidationError{
+			Field:   "resolution",
+			Message: fmt.Sprintf("Requested resolution '%s' exceeds maximum configured %dx%d", resolution, s.config.Image.MaxWidth, s.config.Image.MaxHeight),
+		}
+	}
+	if err := s.checkResolutionArea("resolution", resolution, resolutionConfig); err != nil {
+		return nil, err
+	}
+
+	resizeMode := ResizeMode(s.config.Image.ResizeMode)
+	if mode != "" {
+		resizeMode = ResizeMode(mode)
+	}
+	if !resizeMode.IsValid() {
+		return nil, models.ValidationError{
+			Field:   "mode",
+			Message: fmt.Sprintf("Invalid resize mode '%s': must be one of smart_fit, crop, stretch", mode),
+		}
+	}
+
+	metadata, err := s.GetMetadata(ctx, imageID)
+	if err != nil {
+		return nil, err
+	}
+
+	geometry := CalculateResizeGeometry(metadata.Width, metadata.Height, resolutionConfig.Width, resolutionConfig.Height, resizeMode)
+
+	logger.DebugWithContext(ctx, "Estimated resize geometry",
+		zap.String("image_id", imageID),
+		zap.String("resolution", resolution),
+		zap.String("mode", string(resizeMode)),
+		zap.String("aspect_handling", geometry.AspectHandling))
+
+	return &models.EstimateResponse{
+		ImageID:        imageID,
+		Resolution:     resolution,
+		Mode:           string(resizeMode),
+		Source:         models.DimensionInfo{Width: metadata.Width, Height: metadata.Height},
+		Output:         models.DimensionInfo{Width: geometry.OutputWidth, Height: geometry.OutputHeight},
+		Content:        models.DimensionInfo{Width: geometry.ResizedWidth, Height: geometry.ResizedHeight},
+		AspectHandling: geometry.AspectHandling,
+	}, nil
+}
+
+// DeleteImage removes an image and all its resolutions
+func (s *ImageServiceImpl) DeleteImage(ctx context.Context, imageID string) error {
+	logger.InfoWithContext(ctx, "Deleting image",
+		zap.String("image_id", imageID))
+
+	// Get metadata to know what to delete
+	metadata, err := s.GetMetadata(ctx, imageID)
+	if err != nil {
 		return err
 	}
 
 	// Handle deduplication cleanup
-	if metadata.Hash.Value != "" {
+	if metadata.UsesDeduplication() {
 		dedupInfo, err := s.dedupRepo.GetDeduplicationInfo(ctx, metadata.Hash)
+		if err != nil && !isDedupNotFound(err) {
+			// Nothing is deleted yet, so failing here leaves the image intact
+			if failErr := s.handleDedupFailure(ctx, "get_dedup_info", imageID, err); failErr != nil {
+				return failErr
+			}
+		}
 		if err == nil {
 			// Ensure ResolutionRefs is initialized (for backward compatibility)
 			if dedupInfo.ResolutionRefs == nil {
@@ -510,13 +1607,19 @@ func (s *ImageServiceImpl) DeleteImage(ctx context.Context, imageID string) erro
 				// Check if this resolution should be physically deleted
 				shouldDeletePhysicalFile := dedupInfo.GetResolutionReferenceCount(resolution) == 0
 
+				// Aliases of the original have no file of their own; the original's entry covers it
+				if metadata.ServesOriginal(resolution) {
+					shouldDeletePhysicalFile = false
+				}
+
 				// Double-check by manually verifying remaining images (for robustness)
 				if shouldDeletePhysicalFile && len(dedupInfo.ReferencingIDs) > 0 {
 					for _, otherImageID := range dedupInfo.ReferencingIDs {
 						if otherImageID != imageID {
 							otherMetadata, err := s.GetMetadata(ctx, otherImageID)
 							if err == nil {
-								if resolution == "original" || otherMetadata.HasResolution(resolution) {
+								fileDimensions := models.ExtractDimensions(metadata.DerivativeFileDimensions(resolution))
+								if resolution == "original" || otherMetadata.HasResolution(resolution) || otherMetadata.UsesDerivativeFile(fileDimensions) {
 									shouldDeletePhysicalFile = false
 									logger.InfoWithContext(ctx, "Resolution still used by other image",
 										zap.String("image_id", imageID),
@@ -566,9 +1669,15 @@ func (s *ImageServiceImpl) DeleteImage(ctx context.Context, imageID string) erro
 			// Remove general image reference
 			dedupInfo.RemoveReference(imageID)
 
-			// Delete physical files before updating deduplication info
+			// Delete physical files before updating deduplication info; resolutions with
+			// byte-identical output share a file, which is deleted once
+			deletedKeys := make(map[string]bool, len(resolutionsToDelete))
 			for resolution := range resolutionsToDelete {
 				storageKey := metadata.GetActualStorageKey(resolution)
+				if deletedKeys[storageKey] {
+					continue
+				}
+				deletedKeys[storageKey] = true
 				if err := s.storage.Delete(ctx, storageKey); err != nil {
 					logger.WarnWithContext(ctx, "Failed to delete resolution from storage",
 						zap.String("image_id", imageID),
@@ -581,6 +1690,7 @@ func (s *ImageServiceImpl) DeleteImage(ctx context.Context, imageID string) erro
 						zap.String("resolution", resolution),
 						zap.String("storage_key", storageKey))
 				}
+				s.deleteFormatVariants(ctx, metadata, resolution)
 			}
 
 			// Update or delete deduplication info
@@ -729,7 +1839,9 @@ func (s *ImageServiceImpl) DeleteImage(ctx context.Context, imageID string) erro
 	}
 
 	// Delete metadata from repository
-	if err := s.repo.Delete(ctx, imageID); err != nil {
+	err = s.repo.Delete(ctx, imageID)
+	s.metaCache.invalidate(imageID)
+	if err != nil {
 		return models.StorageError{
 			Operation: "delete_metadata",
 			Backend:   "Repository",
@@ -764,20 +1876,27 @@ func (s *ImageServiceImpl) DeleteResolution(ctx context.Context, imageID, resolu
 		return err
 	}
 
-	// Check if resolution exists
+	// A resolution no longer listed may still have a file left by an earlier,
+	// partially failed delete
 	if !metadata.HasResolution(resolution) {
-		return models.NotFoundError{
-			Resource: "resolution",
-			ID:       fmt.Sprintf("%s/%s", imageID, resolution),
-		}
+		return s.deleteUnlistedResolution(ctx, metadata, resolution)
 	}
 
 	// Check if other images are using this resolution (works for both deduplicated and non-deduplicated)
 	shouldDeletePhysicalFile := true
+	fileDimensions := models.ExtractDimensions(metadata.DerivativeFileDimensions(resolution))
 
 	// Get deduplication info to check per-resolution references
-	dedupInfo, err := s.dedupRepo.GetDeduplicationInfo(ctx, metadata.Hash)
-	if err == nil {
+	var dedupInfo *models.DeduplicationInfo
+	if metadata.UsesDeduplication() {
+		dedupInfo, err = s.dedupRepo.GetDeduplicationInfo(ctx, metadata.Hash)
+	}
+	if !metadata.UsesDeduplication() {
+		// Isolated images (or images without a hash) own all of their files
+		logger.DebugWithContext(ctx, "Image does not share storage, deleting resolution directly",
+			zap.String("image_id", imageID),
+			zap.String("resolution", resolution))
+	} else if err == nil {
 		// Ensure ResolutionRefs is initialized (for backward compatibility)
 		if dedupInfo.ResolutionRefs == nil {
 			dedupInfo.ResolutionRefs = make(map[string]*models.ResolutionReference)
@@ -809,7 +1928,7 @@ func (s *ImageServiceImpl) DeleteResolution(ctx context.Context, imageID, resolu
 			for _, otherImageID := range dedupInfo.ReferencingIDs {
 				if otherImageID != imageID {
 					otherMetadata, err := s.GetMetadata(ctx, otherImageID)
-					if err == nil && otherMetadata.HasResolution(resolution) {
+					if err == nil && (otherMetadata.HasResolution(resolution) || otherMetadata.UsesDerivativeFile(fileDimensions)) {
 						shouldDeletePhysicalFile = false
 						logger.InfoWithContext(ctx, "Resolution still used by other image (fallback check)",
 							zap.String("image_id", imageID),
@@ -850,6 +1969,20 @@ func (s *ImageServiceImpl) DeleteResolution(ctx context.Context, imageID, resolu
 		}
 	}
 
+	// Aliases of the original have no file of their own
+	if metadata.ServesOriginal(resolution) {
+		shouldDeletePhysicalFile = false
+	}
+
+	// Resolutions with byte-identical output are served from the same file
+	if metadata.SharesDerivativeFile(resolution) {
+		shouldDeletePhysicalFile = false
+		logger.InfoWithContext(ctx, "Resolution file is shared with an identical resolution, keeping it",
+			zap.String("image_id", imageID),
+			zap.String("resolution", resolution),
+			zap.String("storage_key", metadata.GetActualStorageKey(resolution)))
+	}
+
 	// Delete physical file if no other images need it
 	if shouldDeletePhysicalFile {
 		storageKey := metadata.GetActualStorageKey(resolution)
@@ -884,6 +2017,7 @@ func (s *ImageServiceImpl) DeleteResolution(ctx context.Context, imageID, resolu
 					zap.String("storage_key", storageKey))
 			}
 		}
+		s.deleteFormatVariants(ctx, metadata, resolution)
 	} else {
 		logger.InfoWithContext(ctx, "Resolution removed virtually (physical file kept for other images)",
 			zap.String("image_id", imageID),
@@ -891,17 +2025,12 @@ func (s *ImageServiceImpl) DeleteResolution(ctx context.Context, imageID, resolu
 	}
 
 	// Remove resolution from metadata
-	newResolutions := []string{}
-	for _, res := range metadata.Resolutions {
-		if res != resolution {
-			newResolutions = append(newResolutions, res)
-		}
-	}
-	metadata.Resolutions = newResolutions
-	metadata.UpdatedAt = time.Now()
+	metadata.RemoveResolution(resolution)
 
 	// Update metadata in repository
-	if err := s.repo.Update(ctx, metadata); err != nil {
+	err = s.repo.Update(ctx, metadata)
+	s.metaCache.invalidate(metadata.ID)
+	if err != nil {
 		return models.StorageError{
 			Operation: "update_metadata",
 			Backend:   "Repository",
@@ -917,17 +2046,118 @@ func (s *ImageServiceImpl) DeleteResolution(ctx context.Context, imageID, resolu
 	return nil
 }
 
-// ListImages retrieves paginated list of images
-func (s *ImageServiceImpl) ListImages(ctx context.Context, offset, limit int) ([]*models.ImageMetadata, int, error) {
+// deleteUnlistedResolution completes a retried DeleteResolution whose first attempt
+// updated the metadata but failed to delete the file. The leftover file is removed
+// unless another image sharing the storage still lists the resolution; without a
+// file to remove the resolution is reported as not found. Aliases are resolved
+// through the metadata, so leftovers can only be addressed by their dimensions.
+func (s *ImageServiceImpl) deleteUnlistedResolution(ctx context.Context, metadata *models.ImageMetadata, resolution string) error {
+	notFound := models.NotFoundError{
+		Resource: "resolution",
+		ID:       fmt.Sprintf("%s/%s", metadata.ID, resolution),
+	}
+
+	if resolution != "thumbnail" && !models.IsValidDimensionFormat(resolution) {
+		return notFound
+	}
+
+	storageKey := metadata.GetActualStorageKey(resolution)
+	exists, err := s.storage.Exists(ctx, storageKey)
+	if err != nil {
+		logger.WarnWithContext(ctx, "Failed to check for a leftover resolution file",
+			zap.String("image_id", metadata.ID),
+			zap.String("resolution", resolution),
+			zap.String("storage_key", storageKey),
+			zap.Error(err))
+		return notFound
+	}
+	if !exists {
+		return notFound
+	}
+
+	// A stored resolution with byte-identical output may still be served from the file
+	if metadata.UsesDerivativeFile(resolution) || s.resolutionSharedByOthers(ctx, metadata, resolution) {
+		logger.InfoWithContext(ctx, "Leftover resolution file is still in use, keeping it",
+			zap.String("image_id", metadata.ID),
+			zap.String("resolution", resolution),
+			zap.String("storage_key", storageKey))
+		return notFound
+	}
+
+	if err := s.storage.Delete(ctx, storageKey); err != nil {
+		return models.StorageError{
+			Operation: "delete_resolution",
+			Backend:   "S3",
+			Reason:    err.Error(),
+		}
+	}
+
+	logger.InfoWithContext(ctx, "Leftover resolution file deleted",
+		zap.String("image_id", metadata.ID),
+		zap.String("resolution", resolution),
+		zap.String("storage_key", storageKey))
+
+	return nil
+}
+
+// resolutionSharedByOthers reports whether another image sharing the storage of
+// metadata still lists the resolution. When the sharing images cannot be checked
+// the file is treated as shared, so nothing another image needs is deleted.
+func (s *ImageServiceImpl) resolutionSharedByOthers(ctx context.Context, metadata *models.ImageMetadata, resolution string) bool {
+	if !metadata.UsesDeduplication() {
+		// Isolated images own all of their files
+		return false
+	}
+
+	dedupInfo, err := s.dedupRepo.GetDeduplicationInfo(ctx, metadata.Hash)
+	if err != nil {
+		logger.WarnWithContext(ctx, "Failed to get deduplication info, keeping leftover resolution file",
+			zap.String("image_id", metadata.ID),
+			zap.String("resolution", resolution),
+			zap.Error(err))
+		return true
+	}
+
+	for _, otherImageID := range dedupInfo.ReferencingIDs {
+		if otherImageID == metadata.ID {
+			continue
+		}
+		otherMetadata, err := s.GetMetadata(ctx, otherImageID)
+		if err != nil {
+			if _, ok := err.(models.NotFoundError); ok {
+				continue
+			}
+			return true
+		}
+		if otherMetadata.HasResolution(resolution) || otherMetadata.UsesDerivativeFile(models.ExtractDimensions(resolution)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ListImages retrieves paginated list of images. An empty sortBy keeps the
+// repository order; the models.ListSort* orders load every image before paginating.
+func (s *ImageServiceImpl) ListImages(ctx context.Context, offset, limit int, sortBy string) ([]*models.ImageMetadata, int, error) {
 	logger.DebugWithContext(ctx, "Listing images",
 		zap.Int("offset", offset),
-		zap.Int("limit", limit))
+		zap.Int("limit", limit),
+		zap.String("sort", sortBy))
+
+	limit, sortBy = s.listDefaults(limit, sortBy)
 
-	if limit <= 0 || limit > 100 {
-		limit = 50 // Default limit
+	less, err := listSortOrder(sortBy)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	images, err := s.repo.List(ctx, offset, limit)
+	repoOffset, repoLimit := offset, limit
+	if less != nil {
+		repoOffset, repoLimit = 0, math.MaxInt32
+	}
+
+	images, err := s.repo.List(ctx, repoOffset, repoLimit)
 	if err != nil {
 		return nil, 0, models.StorageError{
 			Operation: "list_images",
@@ -936,11 +2166,103 @@ func (s *ImageServiceImpl) ListImages(ctx context.Context, offset, limit int) ([
 		}
 	}
 
+	if less != nil {
+		images = sortAndPage(images, less, offset, limit)
+	}
+
 	// Get total count (this could be cached for better performance)
 	// For now, return -1 to indicate total is unknown
 	total := -1
 
-	return images, total, nil
+	return images, total, nil
+}
+
+// ListImagesByDedupStatus retrieves a page of the images matching a models.ListDedup*
+// filter, optionally ordered by a models.ListSort* order, with the counts of every
+// image per deduplication status
+func (s *ImageServiceImpl) ListImagesByDedupStatus(ctx context.Context, filter string, offset, limit int, sortBy string) (*models.ImageList, error) {
+	logger.DebugWithContext(ctx, "Listing images by dedup status",
+		zap.String("filter", filter),
+		zap.Int("offset", offset),
+		zap.Int("limit", limit),
+		zap.String("sort", sortBy))
+
+	switch filter {
+	case models.ListDedupAll, models.ListDedupUnique, models.ListDedupDeduped:
+	case "":
+		filter = models.ListDedupAll
+	default:
+		return nil, models.ValidationError{
+			Field:   "dedup",
+			Message: fmt.Sprintf("dedup must be %s, %s or %s", models.ListDedupUnique, models.ListDedupDeduped, models.ListDedupAll),
+		}
+	}
+
+	limit, sortBy = s.listDefaults(limit, sortBy)
+
+	less, err := listSortOrder(sortBy)
+	if err != nil {
+		return nil, err
+	}
+
+	repoOffset, repoLimit := offset, limit
+	if less != nil {
+		repoOffset, repoLimit = 0, math.MaxInt32
+	}
+
+	images, counts, err := s.repo.ListByDedupStatus(ctx, filter, repoOffset, repoLimit)
+	if err != nil {
+		return nil, models.StorageError{
+			Operation: "list_images",
+			Backend:   "Redis",
+			Reason:    err.Error(),
+		}
+	}
+
+	if less != nil {
+		images = sortAndPage(images, less, offset, limit)
+	}
+
+	return &models.ImageList{Images: images, Counts: counts}, nil
+}
+
+// listDefaults applies the configured page size to omitted or out-of-range limits
+// and the configured order to an omitted sortBy
+func (s *ImageServiceImpl) listDefaults(limit int, sortBy string) (int, string) {
+	if limit <= 0 || limit > s.config.ListMaxLimit() {
+		limit = s.config.ListDefaultLimit()
+	}
+	if sortBy == "" {
+		sortBy = s.config.Image.ListDefaultSort
+	}
+	return limit, sortBy
+}
+
+// listSortOrder returns the comparison for a models.ListSort* order, or nil to keep
+// the repository order when sortBy is empty
+func listSortOrder(sortBy string) (func(a, b *models.ImageMetadata) bool, error) {
+	switch sortBy {
+	case "":
+		return nil, nil
+	case models.ListSortLastAccessed:
+		return func(a, b *models.ImageMetadata) bool { return a.LastAccessedAt.Before(b.LastAccessedAt) }, nil
+	case models.ListSortLastAccessedDesc:
+		return func(a, b *models.ImageMetadata) bool { return a.LastAccessedAt.After(b.LastAccessedAt) }, nil
+	default:
+		return nil, models.ValidationError{
+			Field:   "sort",
+			Message: fmt.Sprintf("sort must be %s or %s", models.ListSortLastAccessed, models.ListSortLastAccessedDesc),
+		}
+	}
+}
+
+// sortAndPage orders every listed image and returns the requested page
+func sortAndPage(images []*models.ImageMetadata, less func(a, b *models.ImageMetadata) bool, offset, limit int) []*models.ImageMetadata {
+	sort.SliceStable(images, func(i, j int) bool { return less(images[i], images[j]) })
+	if offset >= len(images) {
+		return []*models.ImageMetadata{}
+	}
+	return images[offset:min(offset+limit, len(images))]
 }
 
 // GeneratePresignedURL generates a pre-signed URL for direct access to storage
@@ -1013,31 +2335,182 @@ func (s *ImageServiceImpl) generateUniqueImageID(ctx context.Context) (string, e
 	return "", fmt.Errorf("failed to generate unique UUID after %d attempts", maxAttempts)
 }
 
-// validateUploadInput validates the upload input
-func (s *ImageServiceImpl) validateUploadInput(input UploadInput) error {
-	if input.Filename == "" {
+// verifyUploadChecksum compares a client-provided SHA256 against the computed hash.
+// An empty expected value skips the check; a "sha256:" prefix is accepted.
+func verifyUploadChecksum(expected string, hash models.ImageHash) error {
+	if strings.TrimSpace(expected) == "" {
+		return nil
+	}
+
+	expected, ok := models.ParseSHA256Hex(expected)
+	if !ok {
 		return models.ValidationError{
-			Field:   "filename",
-			Message: "Filename is required",
+			Field:   "checksum",
+			Message: "Checksum must be a 64-character hex-encoded SHA256 digest",
 		}
 	}
 
-	if len(input.Data) == 0 {
+	if expected != hash.Value {
 		return models.ValidationError{
-			Field:   "data",
-			Message: "Image data is required",
+			Field:   "checksum",
+			Message: fmt.Sprintf("Checksum mismatch: expected %s, computed %s", expected, hash.Value),
+		}
+	}
+
+	return nil
+}
+
+// scanUpload runs the configured malware scanner over an upload. Infected content
+// is rejected; a scanner that cannot reach a verdict rejects the upload too, so
+// nothing unscanned is stored.
+func (s *ImageServiceImpl) scanUpload(ctx context.Context, filename string, data []byte) error {
+	if s.scanner == nil {
+		return nil
+	}
+
+	result, err := s.scanner.Scan(ctx, data)
+	if err != nil {
+		logger.ErrorWithContext(ctx, "Malware scan failed",
+			zap.String("filename", filename),
+			zap.Error(err))
+		return models.StorageError{
+			Operation: "scan",
+			Backend:   "Scanner",
+			Reason:    err.Error(),
+		}
+	}
+
+	if result.Infected {
+		logger.WarnWithContext(ctx, "Malware detected in upload",
+			zap.String("filename", filename),
+			zap.String("signature", result.Signature))
+		return models.MalwareDetectedError{Signature: result.Signature}
+	}
+
+	return nil
+}
+
+// checkCapacity rejects an upload once MAX_TOTAL_IMAGES images are stored
+func (s *ImageServiceImpl) checkCapacity(ctx context.Context) error {
+	limit := s.config.Image.MaxTotalImages
+	if limit <= 0 {
+		return nil
+	}
+
+	count, err := s.repo.Count(ctx)
+	if err != nil {
+		return models.StorageError{
+			Operation: "count_images",
+			Backend:   "Repository",
+			Reason:    err.Error(),
+		}
+	}
+	if count >= limit {
+		logger.WarnWithContext(ctx, "Image capacity reached",
+			zap.Int64("count", count),
+			zap.Int64("limit", limit))
+		return models.CapacityExceededError{Limit: limit}
+	}
+	return nil
+}
+
+// checkMinimumDimensions rejects originals smaller than IMAGE_MIN_WIDTH x IMAGE_MIN_HEIGHT
+func (s *ImageServiceImpl) checkMinimumDimensions(width, height int) error {
+	minWidth, minHeight := s.config.Image.MinSourceWidth, s.config.Image.MinSourceHeight
+	if width >= minWidth && height >= minHeight {
+		return nil
+	}
+
+	return models.ValidationError{
+		Field:   "file",
+		Message: fmt.Sprintf("Image dimensions %dx%d are below the minimum of %dx%d", width, height, minWidth, minHeight),
+	}
+}
+
+// checkAspectRatio rejects originals whose shape is not within the configured tolerance
+// of one of IMAGE_ALLOWED_ASPECT_RATIOS. An empty list accepts any aspect ratio.
+func (s *ImageServiceImpl) checkAspectRatio(width, height int) error {
+	allowed := s.config.Image.AllowedAspectRatios
+	if len(allowed) == 0 || width <= 0 || height <= 0 {
+		return nil
+	}
+
+	actual := float64(width) / float64(height)
+	for _, ratio := range allowed {
+		target, err := config.ParseAspectRatio(ratio)
+		if err != nil {
+			continue // rejected by config validation at startup
 		}
+		if math.Abs(actual/target-1) <= s.config.Image.AspectRatioTolerance {
+			return nil
+		}
+	}
+
+	return models.ValidationError{
+		Field:   "file",
+		Message: fmt.Sprintf("Image aspect ratio %dx%d (%.3f) is not accepted. Accepted aspect ratios: %s", width, height, actual, strings.Join(allowed, ", ")),
+	}
+}
+
+// detectContentTypeMismatch compares the image type named by the filename extension
+// with the type sniffed from the bytes and describes the difference, or returns nil
+// when they agree. Extensions that name no known image type are not a mismatch.
+func detectContentTypeMismatch(filename, detectedMimeType string) *models.ContentTypeMismatch {
+	extensionMimeType := models.GetMimeTypeFromExtension(filename)
+	if extensionMimeType == "" || extensionMimeType == detectedMimeType {
+		return nil
 	}
+	return &models.ContentTypeMismatch{
+		Extension:         strings.ToLower(filepath.Ext(filename)),
+		ExtensionMimeType: extensionMimeType,
+		DetectedMimeType:  detectedMimeType,
+	}
+}
 
-	if input.Size != int64(len(input.Data)) {
+// checkResolutionArea rejects a resolution whose pixel count exceeds the configured
+// budget, even when each dimension is within the width and height maximums
+func (s *ImageServiceImpl) checkResolutionArea(field, resolution string, rc models.ResolutionConfig) error {
+	budget := s.config.Image.MaxResolutionPixels
+	if budget <= 0 {
+		return nil
+	}
+	if area := int64(rc.Width) * int64(rc.Height); area > int64(budget) {
 		return models.ValidationError{
+			Field:   field,
+			Message: fmt.Sprintf("Requested resolution '%s' has %d pixels, exceeding the maximum of %d", resolution, area, budget),
+		}
+	}
+	return nil
+}
+
+// validateUploadInput validates the upload input and normalizes its requested resolutions in place
+func (s *ImageServiceImpl) validateUploadInput(input *UploadInput) error {
+	// Every problem is collected so clients can fix them all at once
+	var fieldErrors []models.ValidationError
+
+	if input.Filename == "" {
+		fieldErrors = append(fieldErrors, models.ValidationError{
+			Field:   "filename",
+			Message: "Filename is required",
+		})
+	}
+
+	if len(input.Data) == 0 {
+		fieldErrors = append(fieldErrors, models.ValidationError{
+			Field:   "data",
+			Message: "Image data is required",
+		})
+	} else if input.Size != int64(len(input.Data)) {
+		fieldErrors = append(fieldErrors, models.ValidationError{
 			Field:   "size",
 			Message: "Size mismatch with actual data length",
-		}
+		})
 	}
 
 	// Validate requested resolutions - support comma-separated values
 	validatedResolutions := []string{}
+	requested := &models.ImageMetadata{} // tracks aliases within this request
+
 	for _, resolution := range input.Resolutions {
 		// Handle comma-separated resolutions in a single field
 		resolutions := strings.Split(resolution, ",")
@@ -1046,36 +2519,214 @@ func (s *ImageServiceImpl) validateUploadInput(input UploadInput) error {
 			if res == "" {
 				continue // Skip empty strings
 			}
-			if rc, err := models.ParseResolution(res); err != nil {
-				return models.ValidationError{
+			rc, err := models.ParseResolution(res)
+			if err != nil {
+				fieldErrors = append(fieldErrors, models.ValidationError{
 					Field:   "resolutions",
 					Message: fmt.Sprintf("Invalid resolution format '%s': %s", res, err.Error()),
+				})
+				continue
+			}
+
+			// Enforce configured maximums for requested resolutions
+			if rc.Width > s.config.Image.MaxWidth || rc.Height > s.config.Image.MaxHeight {
+				fieldErrors = append(fieldErrors, models.ValidationError{
+					Field:   "resolutions",
+					Message: fmt.Sprintf("Requested resolution '%s' exceeds maximum configured %dx%d", res, s.config.Image.MaxWidth, s.config.Image.MaxHeight),
+				})
+				continue
+			}
+
+			// Normalize "WxH : Alias" / "WxH:" into canonical "WxH:alias" / "WxH"
+			res = models.NormalizeResolution(res)
+
+			// The area budget applies, and the same alias must not be requested for different dimensions
+			err = s.checkResolutionArea("resolutions", res, rc)
+			if err == nil {
+				err = requested.AddResolution(res)
+			}
+			if err != nil {
+				var validationErr models.ValidationError
+				if !errors.As(err, &validationErr) {
+					return err
 				}
-			} else {
-				// Enforce configured maximums for requested resolutions
-				if rc.Width > s.config.Image.MaxWidth || rc.Height > s.config.Image.MaxHeight {
-					return models.ValidationError{
-						Field:   "resolutions",
-						Message: fmt.Sprintf("Requested resolution '%s' exceeds maximum configured %dx%d", res, s.config.Image.MaxWidth, s.config.Image.MaxHeight),
-					}
-				}
+				fieldErrors = append(fieldErrors, validationErr)
+				continue
 			}
 			validatedResolutions = append(validatedResolutions, res)
 		}
 	}
+	if len(fieldErrors) > 0 {
+		return models.JoinValidationErrors(fieldErrors)
+	}
+
 	// Update input with parsed resolutions
 	input.Resolutions = validatedResolutions
 
 	return nil
 }
 
-// processResolution processes a single resolution
-func (s *ImageServiceImpl) processResolution(ctx context.Context, imageID, resolutionName string, originalData []byte, mimeType string) error {
-	return s.processResolutionWithMetadata(ctx, imageID, resolutionName, originalData, mimeType, nil)
+// validateFilename validates a new filename and keeps the current extension,
+// appending it when the new name has none
+func (s *ImageServiceImpl) validateFilename(filename, currentExt string) (string, error) {
+	filename = strings.TrimSpace(filename)
+	if filename == "" {
+		return "", models.ValidationError{
+			Field:   "filename",
+			Message: "Filename is required",
+		}
+	}
+
+	if len(filename) > 255 {
+		return "", models.ValidationError{
+			Field:   "filename",
+			Message: "Filename must not exceed 255 characters",
+		}
+	}
+
+	if strings.ContainsAny(filename, "/\\\"") || strings.IndexFunc(filename, unicode.IsControl) >= 0 {
+		return "", models.ValidationError{
+			Field:   "filename",
+			Message: "Filename must not contain path separators, quotes or control characters",
+		}
+	}
+
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), "."))
+	if ext == "" {
+		if currentExt == "" {
+			return filename, nil
+		}
+		return filename + "." + currentExt, nil
+	}
+
+	if ext != currentExt {
+		return "", models.ValidationError{
+			Field:   "filename",
+			Message: fmt.Sprintf("Filename extension must remain '.%s'", currentExt),
+		}
+	}
+
+	return filename, nil
+}
+
+// storedResolutionDimensions returns the size of the file a resolution is served from:
+// the original's for resolutions aliased to it, the generated output's otherwise
+func (s *ImageServiceImpl) storedResolutionDimensions(metadata *models.ImageMetadata, resolution string) models.DimensionInfo {
+	if metadata.ServesOriginal(resolution) {
+		return metadata.GetDimensions()
+	}
+	return s.resolutionOutputDimensions(metadata, resolution)
+}
+
+// resolutionOutputDimensions predicts the size of a generated resolution using the same
+// geometry as the processor, so smart_fit/crop outputs are recorded accurately
+func (s *ImageServiceImpl) resolutionOutputDimensions(metadata *models.ImageMetadata, resolution string) models.DimensionInfo {
+	resolutionConfig, err := models.ParseResolution(resolution)
+	if err != nil {
+		return models.DimensionInfo{}
+	}
+
+	geometry := CalculateResizeGeometry(metadata.Width, metadata.Height, resolutionConfig.Width, resolutionConfig.Height, ResizeMode(s.config.Image.ResizeMode))
+	return models.DimensionInfo{Width: geometry.OutputWidth, Height: geometry.OutputHeight}
+}
+
+// servesOriginal reports whether IMAGE_NO_UPSCALE lets a resolution be served from the
+// original: the source fits inside the requested size, so generating it would only
+// upscale. Crop mode always produces the exact requested frame and is never skipped, nor
+// are originals whose generated resolutions use another format (e.g. TIFF -> PNG).
+func (s *ImageServiceImpl) servesOriginal(metadata *models.ImageMetadata, resolution string) bool {
+	if !s.config.Image.NoUpscale || metadata == nil || ResizeMode(s.config.Image.ResizeMode) == ResizeModeCrop {
+		return false
+	}
+	if metadata.Width <= 0 || metadata.Height <= 0 || models.GetDerivativeMimeType(metadata.MimeType) != metadata.MimeType {
+		return false
+	}
+
+	resolutionConfig, err := models.ParseResolution(resolution)
+	if err != nil {
+		return false
+	}
+	return resolutionConfig.Width >= metadata.Width && resolutionConfig.Height >= metadata.Height
+}
+
+// textWatermark returns the WATERMARK_TEXT overlay for resolutions of an image, or nil
+// when no watermark is configured or the image was uploaded with watermark=false
+func (s *ImageServiceImpl) textWatermark(metadata *models.ImageMetadata) *TextWatermark {
+	if s.config.Watermark.Text == "" || (metadata != nil && metadata.WatermarkDisabled) {
+		return nil
+	}
+	return &TextWatermark{
+		Text:     s.config.Watermark.Text,
+		FontSize: s.config.Watermark.FontSize,
+		Color:    s.config.Watermark.Color,
+		Opacity:  s.config.Watermark.Opacity,
+		Position: s.config.Watermark.Position,
+	}
+}
+
+// formatVariants returns the GENERATE_FORMAT_VARIANTS formats a resolution generated as
+// derivativeMimeType is additionally stored in
+func (s *ImageServiceImpl) formatVariants(derivativeMimeType string) []string {
+	var formats []string
+	for _, format := range s.config.Image.FormatVariants {
+		if models.GetMimeTypeFromFormat(format) == derivativeMimeType || slices.Contains(formats, format) {
+			continue
+		}
+		formats = append(formats, format)
+	}
+	return formats
+}
+
+// sharedResolutionFormat returns the format IMAGE_OUTPUT_FORMAT=auto chose when the master
+// image generated a shared resolution, or "" when it uses the derivative format
+func (s *ImageServiceImpl) sharedResolutionFormat(ctx context.Context, masterID, resolution string) string {
+	if s.config.Image.OutputFormat != "auto" || masterID == "" {
+		return ""
+	}
+	master, err := s.repo.Get(ctx, masterID)
+	if err != nil {
+		logger.WarnWithContext(ctx, "Failed to look up the format of a shared resolution",
+			zap.String("shared_with", masterID),
+			zap.String("resolution", resolution),
+			zap.Error(err))
+		return ""
+	}
+	return master.GetResolutionFormat(models.ExtractDimensions(resolution))
+}
+
+// sharedDerivativeSource returns the resolution whose file the images referencing a
+// shared resolution serve it from, see models.ImageMetadata.DerivativeSource, or ""
+// when it has a file of its own
+func (s *ImageServiceImpl) sharedDerivativeSource(ctx context.Context, dedupInfo *models.DeduplicationInfo, resolution string) string {
+	ref := dedupInfo.ResolutionRefs[resolution]
+	if ref == nil {
+		return ""
+	}
+	for _, imageID := range ref.ReferencingIDs {
+		other, err := s.GetMetadata(ctx, imageID)
+		if err != nil || !other.HasResolution(resolution) {
+			continue
+		}
+		return other.DerivativeSource(resolution)
+	}
+	return ""
+}
+
+// autoFormat reports whether IMAGE_OUTPUT_FORMAT=auto picks the format of a resolution.
+// Resolutions above IMAGE_AUTO_FORMAT_MAX_PIXELS keep the derivative format, bounding
+// the extra encodes to images where they are cheap.
+func (s *ImageServiceImpl) autoFormat(resolution models.ResolutionConfig) bool {
+	if s.config.Image.OutputFormat != "auto" || len(s.config.Image.AutoFormatCandidates) == 0 {
+		return false
+	}
+	maxPixels := s.config.Image.AutoFormatMaxPixels
+	return maxPixels <= 0 || resolution.Width*resolution.Height <= maxPixels
 }
 
 // processResolutionWithMetadata processes a single resolution with metadata context
-func (s *ImageServiceImpl) processResolutionWithMetadata(ctx context.Context, imageID, resolutionName string, originalData []byte, mimeType string, metadata *models.ImageMetadata) error {
+// and returns the storage keys the derivative and its format variants were written to.
+// Resolutions served from the original are only recorded as its alias and write nothing.
+func (s *ImageServiceImpl) processResolutionWithMetadata(ctx context.Context, imageID, resolutionName string, originalData []byte, mimeType string, metadata *models.ImageMetadata) ([]string, error) {
 	// Determine the storage image ID (use shared ID if deduplicated)
 	storageImageID := imageID
 	if metadata != nil && metadata.IsDeduped && metadata.SharedImageID != "" {
@@ -1084,87 +2735,306 @@ func (s *ImageServiceImpl) processResolutionWithMetadata(ctx context.Context, im
 	// Parse resolution configuration
 	resolutionConfig, err := models.ParseResolution(resolutionName)
 	if err != nil {
-		return models.ValidationError{
+		return nil, models.ValidationError{
 			Field:   "resolution",
 			Message: err.Error(),
 		}
 	}
 
-	// Convert MIME type to format string for processor
-	format := ""
-	switch mimeType {
-	case "image/jpeg":
-		format = "jpeg"
-	case "image/png":
-		format = "png"
-	case "image/gif":
-		format = "gif"
-	case "image/webp":
-		format = "webp"
-	default:
-		format = "jpeg" // fallback to JPEG
+	if metadata != nil {
+		served := s.servesOriginal(metadata, resolutionName)
+		metadata.SetServesOriginal(resolutionName, served)
+		if served {
+			logger.DebugWithContext(ctx, "Resolution not smaller than the source, serving the original",
+				zap.String("image_id", imageID),
+				zap.String("resolution", resolutionName),
+				zap.Int("source_width", metadata.Width),
+				zap.Int("source_height", metadata.Height))
+			metadata.SetFormatVariants(resolutionName, nil)
+			metadata.SetResolutionFormat(resolutionName, "")
+			return nil, nil
+		}
 	}
 
+	// Generated resolutions may use a different format than the original (e.g. TIFF -> PNG)
+	derivativeMimeType := models.GetDerivativeMimeType(mimeType)
+
 	// Configure resize parameters
 	resizeConfig := ResizeConfig{
 		Width:           resolutionConfig.Width,
 		Height:          resolutionConfig.Height,
-		Quality:         s.config.Image.Quality,
-		Format:          format,
+		Quality:         s.config.QualityFor(resolutionName),
+		Format:          processorFormat(derivativeMimeType),
 		Mode:            ResizeMode(s.config.Image.ResizeMode),
 		BackgroundColor: s.config.Canvas.BackgroundColor,
+		Filter:          s.config.Image.ResampleFilter,
+		JPEGSubsampling: s.config.Image.JPEGSubsampling,
+		Watermark:       s.textWatermark(metadata),
+	}
+	if s.autoFormat(resolutionConfig) {
+		resizeConfig.Format = OutputFormatAuto
+		resizeConfig.AutoFormats = s.config.Image.AutoFormatCandidates
 	}
 
 	// Process the image
-	processedData, err := s.processor.ProcessImage(originalData, resizeConfig)
+	processedData, err := s.processImageWithTimeout(ctx, originalData, resizeConfig)
 	if err != nil {
-		return models.ProcessingError{
+		return nil, models.ProcessingError{
 			Operation: "resize",
 			Reason:    err.Error(),
 		}
 	}
 
+	// The automatically chosen format is only known from the encoded bytes
+	if resizeConfig.Format == OutputFormatAuto {
+		if detected := http.DetectContentType(processedData); models.GetExtensionFromMimeType(detected) != "" {
+			derivativeMimeType = detected
+		}
+		resizeConfig.Format = processorFormat(derivativeMimeType)
+	}
+	if metadata != nil {
+		metadata.SetResolutionFormat(resolutionName, resizeConfig.Format)
+	}
+
 	// Upload processed image using dimensions-only storage key (no aliases)
 	// This ensures no duplicate files are stored and uses shared storage for deduplicated images
 	dimensions := models.ExtractDimensions(resolutionName)
-	storageKey := fmt.Sprintf("images/%s/%s.%s", storageImageID, dimensions, models.GetExtensionFromMimeType(mimeType))
-	if err := s.storage.Upload(ctx, storageKey, bytes.NewReader(processedData), int64(len(processedData)), mimeType); err != nil {
-		return models.StorageError{
+	if metadata != nil && s.reuseIdenticalDerivative(ctx, metadata, resolutionName, storageImageID, processedData) {
+		return nil, nil
+	}
+	storageKey := fmt.Sprintf("images/%s/%s.%s", storageImageID, dimensions, models.GetExtensionFromMimeType(derivativeMimeType))
+	if err := s.storage.Upload(ctx, storageKey, bytes.NewReader(processedData), int64(len(processedData)), derivativeMimeType); err != nil {
+		return nil, models.StorageError{
 			Operation: "upload_processed",
 			Backend:   "S3",
 			Reason:    err.Error(),
 		}
 	}
 
+	storageKeys := []string{storageKey}
+	var variants []string
+	for _, format := range s.formatVariants(derivativeMimeType) {
+		variantMimeType := models.GetMimeTypeFromFormat(format)
+		variantConfig := resizeConfig
+		variantConfig.Format = format
+
+		variantData, err := s.processImageWithTimeout(ctx, originalData, variantConfig)
+		if err != nil {
+			s.cleanupUploadedImages(ctx, imageID, storageKeys)
+			return nil, models.ProcessingError{
+				Operation: "resize_variant",
+				Reason:    fmt.Sprintf("%s: %v", format, err),
+			}
+		}
+
+		variantKey := fmt.Sprintf("images/%s/%s.%s", storageImageID, dimensions, models.GetExtensionFromMimeType(variantMimeType))
+		if err := s.storage.Upload(ctx, variantKey, bytes.NewReader(variantData), int64(len(variantData)), variantMimeType); err != nil {
+			s.cleanupUploadedImages(ctx, imageID, storageKeys)
+			return nil, models.StorageError{
+				Operation: "upload_variant",
+				Backend:   "S3",
+				Reason:    err.Error(),
+			}
+		}
+		storageKeys = append(storageKeys, variantKey)
+		variants = append(variants, format)
+	}
+	if metadata != nil {
+		metadata.SetFormatVariants(resolutionName, variants)
+	}
+
 	logger.DebugWithContext(ctx, "Resolution processed successfully",
 		zap.String("image_id", imageID),
 		zap.String("resolution", resolutionName),
 		zap.String("storage_key", storageKey),
+		zap.Strings("format_variants", variants),
 		zap.Int("processed_size", len(processedData)))
 
-	return nil
+	return storageKeys, nil
+}
+
+// reuseIdenticalDerivative records the SHA256 of a resolution generated into the image's
+// own storage and reports whether another stored resolution already has a file with the
+// same bytes. Such a resolution is served from that file, with its format variants,
+// instead of storing a copy. Files in another image's shared storage are never reused,
+// as that image manages their lifetime.
+func (s *ImageServiceImpl) reuseIdenticalDerivative(ctx context.Context, metadata *models.ImageMetadata, resolution, storageImageID string, data []byte) bool {
+	metadata.SetDerivativeSource(resolution, "")
+	if storageImageID != metadata.ID {
+		metadata.SetDerivativeHash(resolution, "")
+		return false
+	}
+
+	hash := models.CalculateImageHash(data).Value
+	source := metadata.FindDerivativeByHash(hash)
+	if source == "" || source == models.ExtractDimensions(resolution) {
+		metadata.SetDerivativeHash(resolution, hash)
+		return false
+	}
+
+	metadata.SetDerivativeHash(resolution, "")
+	metadata.SetDerivativeSource(resolution, source)
+	metadata.SetFormatVariants(resolution, metadata.GetFormatVariants(source))
+
+	logger.DebugWithContext(ctx, "Resolution output identical to a stored resolution, reusing its file",
+		zap.String("image_id", metadata.ID),
+		zap.String("resolution", resolution),
+		zap.String("source", source),
+		zap.String("storage_key", metadata.GetStorageKey(resolution)))
+	return true
+}
+
+// processorFormat converts a derivative MIME type to the processor's format name
+func processorFormat(mimeType string) string {
+	switch mimeType {
+	case "image/jpeg":
+		return "jpeg"
+	case "image/png":
+		return "png"
+	case "image/gif":
+		return "gif"
+	case "image/webp":
+		return "webp"
+	default:
+		return "jpeg" // fallback to JPEG
+	}
 }
 
-// ...existing code...
+// processImageWithTimeout runs the processor under IMAGE_PROCESSING_TIMEOUT so a
+// pathological image cannot hang the request. The decoder cannot be interrupted, so
+// on timeout the work finishes in the background and its result is discarded.
+func (s *ImageServiceImpl) processImageWithTimeout(ctx context.Context, data []byte, resizeConfig ResizeConfig) ([]byte, error) {
+	defer logger.TrackTiming(ctx, logger.TimingProcessing, time.Now())
 
-// cleanupUploadedImages cleans up images if upload fails
-func (s *ImageServiceImpl) cleanupUploadedImages(ctx context.Context, imageID string, resolutions []string) {
+	if s.config.Image.ProcessingTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.config.Image.ProcessingTimeout)
+		defer cancel()
+	}
+
+	// Don't start work that can no longer be used
+	if err := ctx.Err(); err != nil {
+		return nil, processingContextError(err, s.config.Image.ProcessingTimeout)
+	}
+
+	type processResult struct {
+		data []byte
+		err  error
+	}
+	done := make(chan processResult, 1) // buffered so the worker never blocks after a timeout
+
+	go func() {
+		processed, err := s.processor.ProcessImage(data, resizeConfig)
+		done <- processResult{data: processed, err: err}
+	}()
+
+	select {
+	case result := <-done:
+		return result.data, result.err
+	case <-ctx.Done():
+		logger.WarnWithContext(ctx, "Image processing aborted",
+			zap.Int("width", resizeConfig.Width),
+			zap.Int("height", resizeConfig.Height),
+			zap.Duration("timeout", s.config.Image.ProcessingTimeout),
+			zap.Error(ctx.Err()))
+		return nil, processingContextError(ctx.Err(), s.config.Image.ProcessingTimeout)
+	}
+}
+
+// processingContextError describes why processing stopped before completing
+func processingContextError(err error, timeout time.Duration) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		if timeout > 0 {
+			return fmt.Errorf("processing timed out after %s", timeout)
+		}
+		return fmt.Errorf("processing deadline exceeded")
+	}
+	return fmt.Errorf("processing canceled: %w", err)
+}
+
+// cleanupUploadedImages removes the objects an upload wrote if the upload fails.
+// Only keys actually written are passed in, so shared files of deduplicated images are never touched.
+func (s *ImageServiceImpl) cleanupUploadedImages(ctx context.Context, imageID string, storageKeys []string) {
 	logger.WarnWithContext(ctx, "Cleaning up uploaded images due to failure",
 		zap.String("image_id", imageID),
-		zap.Strings("resolutions", resolutions))
+		zap.Strings("storage_keys", storageKeys))
 
-	for _, resolution := range resolutions {
-		storageKey := fmt.Sprintf("images/%s/%s.jpg", imageID, resolution) // Simplified
+	for _, storageKey := range storageKeys {
 		if err := s.storage.Delete(ctx, storageKey); err != nil {
 			logger.ErrorWithContext(ctx, "Failed to cleanup uploaded image",
 				zap.String("image_id", imageID),
-				zap.String("resolution", resolution),
 				zap.String("storage_key", storageKey),
 				zap.Error(err))
 		}
 	}
 }
 
+// handleDedupFailure applies DEDUP_FAILURE_MODE to a deduplication repository
+// error. Under "fail" it returns the error to abort with; under "skip" it logs
+// and returns nil, and the caller carries on without deduplication.
+func (s *ImageServiceImpl) handleDedupFailure(ctx context.Context, operation, imageID string, err error) error {
+	if s.config.Image.DedupFailureMode == "fail" {
+		logger.ErrorWithContext(ctx, "Deduplication repository unavailable, aborting",
+			zap.String("operation", operation),
+			zap.String("image_id", imageID),
+			zap.Error(err))
+		return models.StorageError{
+			Operation: operation,
+			Backend:   "Repository",
+			Reason:    err.Error(),
+		}
+	}
+
+	logger.WarnWithContext(ctx, "Deduplication repository unavailable, continuing without deduplication",
+		zap.String("operation", operation),
+		zap.String("image_id", imageID),
+		zap.Error(err))
+	return nil
+}
+
+// isDedupNotFound reports whether err only means no deduplication record exists
+func isDedupNotFound(err error) bool {
+	var notFound models.NotFoundError
+	return errors.As(err, &notFound)
+}
+
+// rollbackUpload undoes an upload that is aborted after storage was written:
+// it removes the stored objects and the deduplication references the upload added
+func (s *ImageServiceImpl) rollbackUpload(ctx context.Context, metadata *models.ImageMetadata, storageKeys []string) {
+	s.cleanupUploadedImages(ctx, metadata.ID, storageKeys)
+
+	if !metadata.UsesDeduplication() {
+		return
+	}
+
+	dedupInfo, err := s.dedupRepo.GetDeduplicationInfo(ctx, metadata.Hash)
+	if err != nil {
+		return
+	}
+
+	if !metadata.IsDeduped {
+		// This upload created the deduplication entry, so nothing else refers to it
+		if err := s.dedupRepo.DeleteDeduplicationInfo(ctx, metadata.Hash); err != nil {
+			logger.WarnWithContext(ctx, "Failed to remove deduplication info of aborted upload",
+				zap.String("image_id", metadata.ID),
+				zap.String("hash", metadata.Hash.String()),
+				zap.Error(err))
+		}
+		return
+	}
+
+	dedupInfo.RemoveReference(metadata.ID)
+	for _, resolution := range append([]string{"original"}, metadata.Resolutions...) {
+		dedupInfo.RemoveResolutionReference(resolution, metadata.ID)
+	}
+	if err := s.dedupRepo.UpdateDeduplicationInfo(ctx, dedupInfo); err != nil {
+		logger.WarnWithContext(ctx, "Failed to remove references of aborted upload",
+			zap.String("image_id", metadata.ID),
+			zap.String("hash", metadata.Hash.String()),
+			zap.Error(err))
+	}
+}
+
 // verifyDuplicateByBytes performs byte-to-byte comparison to verify if images are truly identical
 // This is the second stage of deduplication verification to handle hash collisions
 func (s *ImageServiceImpl) verifyDuplicateByBytes(ctx context.Context, existingImageID string, newImageData []byte) (bool, error) {
@@ -1,9 +1,15 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"image"
 	"io"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -14,6 +20,8 @@ import (
 	"resizr/internal/testutil"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/tiff"
 )
 
 // Local mocks to avoid interface mismatches
@@ -74,15 +82,26 @@ func (m *mockDeduplicationRepositoryForImageService) GetHashStatistics(_ context
 }
 
 type mockImageRepositoryForImageService struct {
-	saveFunc     func(ctx context.Context, metadata *models.ImageMetadata) error
-	getByIDFunc  func(ctx context.Context, id string) (*models.ImageMetadata, error)
-	updateFunc   func(ctx context.Context, metadata *models.ImageMetadata) error
-	deleteFunc   func(ctx context.Context, id string) error
-	existsFunc   func(ctx context.Context, id string) (bool, error)
-	listFunc     func(ctx context.Context, offset, limit int) ([]*models.ImageMetadata, error)
-	healthFunc   func(ctx context.Context) error
-	closeFunc    func() error
-	getStatsFunc func(ctx context.Context) (*repository.RepositoryStats, error)
+	saveFunc        func(ctx context.Context, metadata *models.ImageMetadata) error
+	getByIDFunc     func(ctx context.Context, id string) (*models.ImageMetadata, error)
+	updateFunc      func(ctx context.Context, metadata *models.ImageMetadata) error
+	deleteFunc      func(ctx context.Context, id string) error
+	existsFunc      func(ctx context.Context, id string) (bool, error)
+	countFunc       func(ctx context.Context) (int64, error)
+	listFunc        func(ctx context.Context, offset, limit int) ([]*models.ImageMetadata, error)
+	listByDedupFunc func(ctx context.Context, filter string, offset, limit int) ([]*models.ImageMetadata, models.DedupStatusCounts, error)
+	healthFunc      func(ctx context.Context) error
+	closeFunc       func() error
+	getStatsFunc    func(ctx context.Context) (*repository.RepositoryStats, error)
+
+	findByFilenameFunc func(ctx context.Context, filename string) ([]string, error)
+	findCorruptFunc    func(ctx context.Context) ([]models.CorruptRecord, error)
+	deleteCorruptFunc  func(ctx context.Context, id string) error
+
+	incrementAccessCountsFunc func(ctx context.Context, id string, counts map[string]int64) error
+	getAccessCountsFunc       func(ctx context.Context, id string) (*models.AccessCounts, error)
+	getPopularImagesFunc      func(ctx context.Context, limit int) ([]models.PopularImage, error)
+	touchLastAccessedFunc     func(ctx context.Context, id string, at time.Time) error
 }
 
 func (m *mockImageRepositoryForImageService) Save(ctx context.Context, metadata *models.ImageMetadata) error {
@@ -135,6 +154,20 @@ func (m *mockImageRepositoryForImageService) List(ctx context.Context, offset, l
 	return nil, nil
 }
 
+func (m *mockImageRepositoryForImageService) Count(ctx context.Context) (int64, error) {
+	if m.countFunc != nil {
+		return m.countFunc(ctx)
+	}
+	return 0, nil
+}
+
+func (m *mockImageRepositoryForImageService) ListByDedupStatus(ctx context.Context, filter string, offset, limit int) ([]*models.ImageMetadata, models.DedupStatusCounts, error) {
+	if m.listByDedupFunc != nil {
+		return m.listByDedupFunc(ctx, filter, offset, limit)
+	}
+	return nil, models.DedupStatusCounts{}, nil
+}
+
 func (m *mockImageRepositoryForImageService) HealthCheck(ctx context.Context) error {
 	if m.healthFunc != nil {
 		return m.healthFunc(ctx)
@@ -184,10 +217,63 @@ func (m *mockImageRepositoryForImageService) GetImagesByTimeRange(_ context.Cont
 	return 0, nil
 }
 
+func (m *mockImageRepositoryForImageService) GetUploadCountsByDay(_ context.Context, _ time.Time, _ int) ([]models.DailyUploadCount, error) {
+	return []models.DailyUploadCount{}, nil
+}
+
 func (m *mockImageRepositoryForImageService) GetStorageUsageByResolution(_ context.Context) (map[string]int64, error) {
 	return nil, nil
 }
 
+func (m *mockImageRepositoryForImageService) FindByFilename(ctx context.Context, filename string) ([]string, error) {
+	if m.findByFilenameFunc != nil {
+		return m.findByFilenameFunc(ctx, filename)
+	}
+	return []string{}, nil
+}
+
+func (m *mockImageRepositoryForImageService) FindCorrupt(ctx context.Context) ([]models.CorruptRecord, error) {
+	if m.findCorruptFunc != nil {
+		return m.findCorruptFunc(ctx)
+	}
+	return nil, nil
+}
+
+func (m *mockImageRepositoryForImageService) DeleteCorrupt(ctx context.Context, id string) error {
+	if m.deleteCorruptFunc != nil {
+		return m.deleteCorruptFunc(ctx, id)
+	}
+	return nil
+}
+
+func (m *mockImageRepositoryForImageService) IncrementAccessCounts(ctx context.Context, id string, counts map[string]int64) error {
+	if m.incrementAccessCountsFunc != nil {
+		return m.incrementAccessCountsFunc(ctx, id, counts)
+	}
+	return nil
+}
+
+func (m *mockImageRepositoryForImageService) GetAccessCounts(ctx context.Context, id string) (*models.AccessCounts, error) {
+	if m.getAccessCountsFunc != nil {
+		return m.getAccessCountsFunc(ctx, id)
+	}
+	return &models.AccessCounts{}, nil
+}
+
+func (m *mockImageRepositoryForImageService) GetPopularImages(ctx context.Context, limit int) ([]models.PopularImage, error) {
+	if m.getPopularImagesFunc != nil {
+		return m.getPopularImagesFunc(ctx, limit)
+	}
+	return []models.PopularImage{}, nil
+}
+
+func (m *mockImageRepositoryForImageService) TouchLastAccessed(ctx context.Context, id string, at time.Time) error {
+	if m.touchLastAccessedFunc != nil {
+		return m.touchLastAccessedFunc(ctx, id, at)
+	}
+	return nil
+}
+
 type mockStorageProviderForImageService struct {
 	uploadFunc               func(ctx context.Context, key string, data io.Reader, size int64, contentType string) error
 	downloadFunc             func(ctx context.Context, key string) (io.ReadCloser, error)
@@ -287,6 +373,8 @@ func (m *mockStorageProviderForImageService) GetURL(key string) string {
 
 type mockProcessorServiceForImageService struct {
 	processImageFunc  func(data []byte, config ResizeConfig) ([]byte, error)
+	extractFrameFunc  func(data []byte, index int, config ResizeConfig) ([]byte, error)
+	convertFormatFunc func(data []byte, config ResizeConfig) ([]byte, error)
 	validateImageFunc func(data []byte, maxSize int64) error
 	detectFormatFunc  func(data []byte) (string, error)
 	getDimensionsFunc func(data []byte) (width, height int, err error)
@@ -299,6 +387,20 @@ func (m *mockProcessorServiceForImageService) ProcessImage(data []byte, config R
 	return nil, nil
 }
 
+func (m *mockProcessorServiceForImageService) ExtractFrame(data []byte, index int, config ResizeConfig) ([]byte, error) {
+	if m.extractFrameFunc != nil {
+		return m.extractFrameFunc(data, index, config)
+	}
+	return nil, nil
+}
+
+func (m *mockProcessorServiceForImageService) ConvertFormat(data []byte, config ResizeConfig) ([]byte, error) {
+	if m.convertFormatFunc != nil {
+		return m.convertFormatFunc(data, config)
+	}
+	return data, nil
+}
+
 func (m *mockProcessorServiceForImageService) ValidateImage(data []byte, maxSize int64) error {
 	if m.validateImageFunc != nil {
 		return m.validateImageFunc(data, maxSize)
@@ -382,6 +484,92 @@ func TestImageService_ProcessUpload_Success(t *testing.T) {
 	assert.Equal(t, input.Size, result.OriginalSize)
 }
 
+func TestImageService_ProcessUpload_ReusesIdenticalDerivatives(t *testing.T) {
+	var stored *models.ImageMetadata
+	mockRepo := &mockImageRepositoryForImageService{
+		saveFunc: func(ctx context.Context, metadata *models.ImageMetadata) error {
+			stored = metadata
+			return nil
+		},
+	}
+	var uploads []string
+	mockStorage := &mockStorageProviderForImageService{
+		uploadFunc: func(ctx context.Context, key string, data io.Reader, size int64, contentType string) error {
+			uploads = append(uploads, key)
+			return nil
+		},
+	}
+	// The source is 150x150, so every larger resolution yields the same bytes
+	mockProcessor := &mockProcessorServiceForImageService{
+		processImageFunc: func(data []byte, config ResizeConfig) ([]byte, error) {
+			return []byte(fmt.Sprintf("%dx%d", min(config.Width, 150), min(config.Height, 150))), nil
+		},
+	}
+
+	cfg := testutil.TestConfig()
+	service := NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, mockStorage, mockProcessor, cfg)
+
+	result, err := service.ProcessUpload(context.Background(), UploadInput{
+		Filename:    "test.jpg",
+		Data:        testutil.CreateTestImageData(),
+		Size:        int64(len(testutil.CreateTestImageData())),
+		Resolutions: []string{"150x150", "300x300:large", "100x100"},
+	})
+
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"thumbnail", "150x150", "300x300:large", "100x100"}, result.ProcessedResolutions)
+
+	prefix := "images/" + result.ImageID + "/"
+	assert.ElementsMatch(t, []string{prefix + "original.jpg", prefix + "thumbnail.jpg", prefix + "100x100.jpg"}, uploads,
+		"identical outputs are stored once")
+
+	require.NotNil(t, stored)
+	assert.Equal(t, "thumbnail", stored.DerivativeSource("150x150"))
+	assert.Equal(t, "thumbnail", stored.DerivativeSource("large"))
+	assert.Empty(t, stored.DerivativeSource("100x100"))
+	assert.Equal(t, prefix+"thumbnail.jpg", stored.GetActualStorageKey("large"))
+	assert.Equal(t, prefix+"100x100.jpg", stored.GetActualStorageKey("100x100"))
+}
+
+func TestImageService_DeleteResolution_SharedDerivative(t *testing.T) {
+	metadata := testutil.CreateTestImageMetadata()
+	metadata.Resolutions = []string{"thumbnail", "150x150"}
+	metadata.SetDerivativeHash("thumbnail", "abc123")
+	metadata.SetDerivativeSource("150x150", "thumbnail")
+
+	mockRepo := &mockImageRepositoryForImageService{
+		getByIDFunc: func(ctx context.Context, id string) (*models.ImageMetadata, error) {
+			return metadata, nil
+		},
+		updateFunc: func(ctx context.Context, metadata *models.ImageMetadata) error {
+			return nil
+		},
+	}
+	var deletes []string
+	mockStorage := &mockStorageProviderForImageService{
+		existsFunc: func(ctx context.Context, key string) (bool, error) {
+			return true, nil
+		},
+		deleteFunc: func(ctx context.Context, key string) error {
+			deletes = append(deletes, key)
+			return nil
+		},
+	}
+	service := NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, mockStorage, &mockProcessorServiceForImageService{}, testutil.TestConfig())
+	ctx := context.Background()
+	thumbnailKey := metadata.GetStorageKey("thumbnail")
+
+	// The file is kept while the identical resolution is served from it
+	require.NoError(t, service.DeleteResolution(ctx, metadata.ID, "thumbnail"))
+	assert.Empty(t, deletes)
+	assert.Equal(t, thumbnailKey, metadata.GetActualStorageKey("150x150"))
+
+	require.NoError(t, service.DeleteResolution(ctx, metadata.ID, "150x150"))
+	assert.Equal(t, []string{thumbnailKey}, deletes)
+	assert.Nil(t, metadata.DerivativeHashes)
+	assert.Nil(t, metadata.DerivativeSources)
+}
+
 func TestImageService_ProcessUpload_ValidationError(t *testing.T) {
 	service := NewImageService(&mockImageRepositoryForImageService{}, &mockDeduplicationRepositoryForImageService{}, &mockStorageProviderForImageService{}, &mockProcessorServiceForImageService{}, testutil.TestConfig())
 
@@ -395,7 +583,7 @@ func TestImageService_ProcessUpload_ValidationError(t *testing.T) {
 			input: UploadInput{
 				Filename: "",
 				Data:     testutil.CreateTestImageData(),
-				Size:     100,
+				Size:     int64(len(testutil.CreateTestImageData())),
 			},
 			wantErr: "filename",
 		},
@@ -441,6 +629,28 @@ func TestImageService_ProcessUpload_ValidationError(t *testing.T) {
 	}
 }
 
+func TestImageService_ProcessUpload_ReportsAllValidationErrors(t *testing.T) {
+	service := NewImageService(&mockImageRepositoryForImageService{}, &mockDeduplicationRepositoryForImageService{}, &mockStorageProviderForImageService{}, &mockProcessorServiceForImageService{}, testutil.TestConfig())
+
+	_, err := service.ProcessUpload(context.Background(), UploadInput{
+		Data:        testutil.CreateTestImageData(),
+		Size:        int64(len(testutil.CreateTestImageData())),
+		Resolutions: []string{"800x600", "bogus", "9000x9000"},
+	})
+
+	var multiErr models.MultiValidationError
+	require.ErrorAs(t, err, &multiErr)
+	require.Len(t, multiErr.Errors, 3)
+	assert.Equal(t, "filename", multiErr.Errors[0].Field)
+	assert.Contains(t, multiErr.Errors[1].Message, "'bogus'")
+	assert.Contains(t, multiErr.Errors[2].Message, "'9000x9000' exceeds maximum")
+
+	// The first field error stays reachable for callers expecting a single one
+	var validationErr models.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "filename", validationErr.Field)
+}
+
 func TestImageService_ProcessUpload_ProcessorError(t *testing.T) {
 	mockProcessor := &mockProcessorServiceForImageService{
 		validateImageFunc: func(data []byte, maxSize int64) error {
@@ -490,6 +700,61 @@ func TestImageService_ProcessUpload_StorageError(t *testing.T) {
 	assert.IsType(t, models.StorageError{}, err)
 }
 
+func TestImageService_ProcessUpload_Checksum(t *testing.T) {
+	data := testutil.CreateTestImageData()
+	checksum := models.CalculateImageHash(data).Value
+
+	tests := []struct {
+		name      string
+		checksum  string
+		expectErr bool
+	}{
+		{"matching checksum", checksum, false},
+		{"matching checksum with prefix and uppercase", "sha256:" + strings.ToUpper(checksum), false},
+		{"mismatching checksum", strings.Repeat("0", 64), true},
+		{"malformed checksum", "not-a-checksum", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uploads := 0
+			mockStorage := &mockStorageProviderForImageService{
+				uploadFunc: func(ctx context.Context, key string, data io.Reader, size int64, contentType string) error {
+					uploads++
+					return nil
+				},
+			}
+			mockProcessor := &mockProcessorServiceForImageService{
+				processImageFunc: func(data []byte, config ResizeConfig) ([]byte, error) {
+					return testutil.CreateTestImageData(), nil
+				},
+			}
+			service := NewImageService(&mockImageRepositoryForImageService{}, &mockDeduplicationRepositoryForImageService{}, mockStorage, mockProcessor, testutil.TestConfig())
+
+			result, err := service.ProcessUpload(context.Background(), UploadInput{
+				Filename:         "test.jpg",
+				Data:             data,
+				Size:             int64(len(data)),
+				ExpectedChecksum: tt.checksum,
+			})
+
+			if tt.expectErr {
+				require.Error(t, err)
+				var validationErr models.ValidationError
+				require.ErrorAs(t, err, &validationErr)
+				assert.Equal(t, "checksum", validationErr.Field)
+				assert.Equal(t, models.ErrorCodeChecksumMismatch, models.ErrorCodeFor(err))
+				assert.Nil(t, result)
+				assert.Zero(t, uploads, "nothing should be stored on checksum mismatch")
+			} else {
+				require.NoError(t, err)
+				assert.NotEmpty(t, result.ImageID)
+				assert.NotZero(t, uploads)
+			}
+		})
+	}
+}
+
 func TestImageService_GetMetadata_Success(t *testing.T) {
 	expectedMetadata := testutil.CreateTestImageMetadata()
 	mockRepo := &mockImageRepositoryForImageService{
@@ -534,6 +799,92 @@ func TestImageService_GetMetadata_NotFound(t *testing.T) {
 	assert.IsType(t, models.NotFoundError{}, err)
 }
 
+func TestImageService_UpdateFilename(t *testing.T) {
+	t.Run("rename persists and keeps storage keys", func(t *testing.T) {
+		stored := testutil.CreateTestImageMetadata()
+		originalKey := stored.GetStorageKey("original")
+		previousUpdate := stored.UpdatedAt
+
+		mockRepo := &mockImageRepositoryForImageService{
+			getByIDFunc: func(ctx context.Context, id string) (*models.ImageMetadata, error) {
+				copied := *stored
+				return &copied, nil
+			},
+			updateFunc: func(ctx context.Context, metadata *models.ImageMetadata) error {
+				stored = metadata
+				return nil
+			},
+		}
+
+		service := NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, &mockStorageProviderForImageService{}, &mockProcessorServiceForImageService{}, testutil.TestConfig())
+
+		ctx := context.Background()
+		metadata, err := service.UpdateFilename(ctx, testutil.ValidUUID, "  holiday.JPG ")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "holiday.JPG", metadata.Filename)
+		assert.True(t, metadata.UpdatedAt.After(previousUpdate))
+
+		persisted, err := service.GetMetadata(ctx, testutil.ValidUUID)
+		assert.NoError(t, err)
+		assert.Equal(t, "holiday.JPG", persisted.Filename)
+		assert.Equal(t, originalKey, persisted.GetStorageKey("original"))
+	})
+
+	t.Run("missing extension is preserved", func(t *testing.T) {
+		mockRepo := &mockImageRepositoryForImageService{
+			getByIDFunc: func(ctx context.Context, id string) (*models.ImageMetadata, error) {
+				return testutil.CreateTestImageMetadata(), nil
+			},
+		}
+
+		service := NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, &mockStorageProviderForImageService{}, &mockProcessorServiceForImageService{}, testutil.TestConfig())
+
+		metadata, err := service.UpdateFilename(context.Background(), testutil.ValidUUID, "holiday")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "holiday.jpg", metadata.Filename)
+	})
+
+	t.Run("invalid filenames", func(t *testing.T) {
+		mockRepo := &mockImageRepositoryForImageService{
+			getByIDFunc: func(ctx context.Context, id string) (*models.ImageMetadata, error) {
+				return testutil.CreateTestImageMetadata(), nil
+			},
+			updateFunc: func(ctx context.Context, metadata *models.ImageMetadata) error {
+				t.Fatal("update should not be called for invalid filenames")
+				return nil
+			},
+		}
+
+		service := NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, &mockStorageProviderForImageService{}, &mockProcessorServiceForImageService{}, testutil.TestConfig())
+
+		for _, filename := range []string{"", "   ", "holiday.png", "../etc/passwd.jpg", "bad\\name.jpg", "quote\".jpg", "new\nline.jpg", strings.Repeat("a", 252) + ".jpg"} {
+			_, err := service.UpdateFilename(context.Background(), testutil.ValidUUID, filename)
+			assert.Error(t, err, filename)
+			assert.IsType(t, models.ValidationError{}, err, filename)
+		}
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		mockRepo := &mockImageRepositoryForImageService{
+			getByIDFunc: func(ctx context.Context, id string) (*models.ImageMetadata, error) {
+				return testutil.CreateTestImageMetadata(), nil
+			},
+			updateFunc: func(ctx context.Context, metadata *models.ImageMetadata) error {
+				return errors.New("connection refused")
+			},
+		}
+
+		service := NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, &mockStorageProviderForImageService{}, &mockProcessorServiceForImageService{}, testutil.TestConfig())
+
+		_, err := service.UpdateFilename(context.Background(), testutil.ValidUUID, "holiday.jpg")
+
+		assert.Error(t, err)
+		assert.IsType(t, models.StorageError{}, err)
+	})
+}
+
 func TestImageService_GetImageStream_Success(t *testing.T) {
 	expectedMetadata := testutil.CreateTestImageMetadata()
 	testData := testutil.CreateTestImageData()
@@ -566,6 +917,32 @@ func TestImageService_GetImageStream_Success(t *testing.T) {
 	assert.NoError(t, stream.Close())
 }
 
+func TestImageService_GetImageStream_PassesRequestContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var downloadCtx context.Context
+	mockRepo := &mockImageRepositoryForImageService{
+		getByIDFunc: func(ctx context.Context, id string) (*models.ImageMetadata, error) {
+			return testutil.CreateTestImageMetadata(), nil
+		},
+	}
+	mockStorage := &mockStorageProviderForImageService{
+		downloadFunc: func(ctx context.Context, key string) (io.ReadCloser, error) {
+			downloadCtx = ctx
+			return testutil.NewMockReadCloser(testutil.CreateTestImageData()), nil
+		},
+	}
+	service := NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, mockStorage, &mockProcessorServiceForImageService{}, testutil.TestConfig())
+
+	_, _, err := service.GetImageStream(ctx, testutil.ValidUUID, "original")
+	require.NoError(t, err)
+
+	// Canceling the request must reach the storage download
+	require.NotNil(t, downloadCtx)
+	cancel()
+	assert.ErrorIs(t, downloadCtx.Err(), context.Canceled)
+}
+
 func TestImageService_GetImageStream_ResolutionNotFound(t *testing.T) {
 	expectedMetadata := testutil.CreateTestImageMetadata()
 
@@ -584,6 +961,59 @@ func TestImageService_GetImageStream_ResolutionNotFound(t *testing.T) {
 	assert.IsType(t, models.NotFoundError{}, err)
 }
 
+func TestImageService_GetImageFrame(t *testing.T) {
+	animation := testutil.CreateTestImageMetadata()
+	animation.Filename = "spinner.gif"
+	animation.MimeType = "image/gif"
+
+	mockRepo := &mockImageRepositoryForImageService{
+		getByIDFunc: func(ctx context.Context, id string) (*models.ImageMetadata, error) {
+			return animation, nil
+		},
+	}
+	mockStorage := &mockStorageProviderForImageService{
+		downloadFunc: func(ctx context.Context, key string) (io.ReadCloser, error) {
+			return testutil.NewMockReadCloser([]byte("animated")), nil
+		},
+	}
+	var gotConfig ResizeConfig
+	mockProcessor := &mockProcessorServiceForImageService{
+		extractFrameFunc: func(data []byte, index int, config ResizeConfig) ([]byte, error) {
+			gotConfig = config
+			if index > 0 {
+				return nil, models.ValidationError{Field: "frame", Message: "Frame 1 is out of range; the image has 1 frame(s)"}
+			}
+			return []byte("still"), nil
+		},
+	}
+	service := NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, mockStorage, mockProcessor, testutil.TestConfig())
+	ctx := context.Background()
+
+	t.Run("first frame in the stored format", func(t *testing.T) {
+		data, contentType, metadata, err := service.GetImageFrame(ctx, testutil.ValidUUID, "original", 0)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("still"), data)
+		assert.Equal(t, "image/gif", contentType)
+		assert.Equal(t, "gif", gotConfig.Format)
+		assert.Equal(t, animation, metadata)
+	})
+
+	t.Run("out of range frame is a validation error", func(t *testing.T) {
+		_, _, _, err := service.GetImageFrame(ctx, testutil.ValidUUID, "original", 1)
+		assert.IsType(t, models.ValidationError{}, err)
+	})
+
+	t.Run("negative frame is rejected", func(t *testing.T) {
+		_, _, _, err := service.GetImageFrame(ctx, testutil.ValidUUID, "original", -1)
+		assert.IsType(t, models.ValidationError{}, err)
+	})
+
+	t.Run("unknown resolution", func(t *testing.T) {
+		_, _, _, err := service.GetImageFrame(ctx, testutil.ValidUUID, "1920x1080", 0)
+		assert.IsType(t, models.NotFoundError{}, err)
+	})
+}
+
 func TestImageService_GeneratePresignedURL_Success(t *testing.T) {
 	expectedURL := "https://example.com/presigned-url"
 	mockStorage := &mockStorageProviderForImageService{
@@ -660,7 +1090,7 @@ func TestImageService_ListImages_Success(t *testing.T) {
 	service := NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, &mockStorageProviderForImageService{}, &mockProcessorServiceForImageService{}, testutil.TestConfig())
 
 	ctx := context.Background()
-	images, total, err := service.ListImages(ctx, 0, 10)
+	images, total, err := service.ListImages(ctx, 0, 10, "")
 
 	assert.NoError(t, err)
 	assert.Equal(t, expectedImages, images)
@@ -681,16 +1111,174 @@ func TestImageService_ListImages_LimitValidation(t *testing.T) {
 	ctx := context.Background()
 
 	// Test invalid limits are adjusted to default
-	_, _, err := service.ListImages(ctx, 0, 0) // Zero limit
+	_, _, err := service.ListImages(ctx, 0, 0, "") // Zero limit
 	assert.NoError(t, err)
 
-	_, _, err = service.ListImages(ctx, 0, -1) // Negative limit
+	_, _, err = service.ListImages(ctx, 0, -1, "") // Negative limit
 	assert.NoError(t, err)
 
-	_, _, err = service.ListImages(ctx, 0, 200) // Excessive limit
+	_, _, err = service.ListImages(ctx, 0, 200, "") // Excessive limit
 	assert.NoError(t, err)
 }
 
+func TestImageService_ListImages_ConfiguredDefaults(t *testing.T) {
+	now := time.Now()
+	stored := make([]*models.ImageMetadata, 5)
+	for i := range stored {
+		stored[i] = testutil.CreateTestImageMetadata()
+		stored[i].ID = fmt.Sprintf("img-%d", i)
+		stored[i].LastAccessedAt = now.Add(time.Duration(i) * time.Hour)
+	}
+
+	var limits []int
+	mockRepo := &mockImageRepositoryForImageService{
+		listFunc: func(ctx context.Context, offset, limit int) ([]*models.ImageMetadata, error) {
+			limits = append(limits, limit)
+			return append([]*models.ImageMetadata{}, stored...), nil
+		},
+	}
+
+	cfg := testutil.TestConfig()
+	cfg.Image.ListDefaultLimit = 2
+	cfg.Image.ListMaxLimit = 4
+	cfg.Image.ListDefaultSort = models.ListSortLastAccessedDesc
+	service := NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, &mockStorageProviderForImageService{}, &mockProcessorServiceForImageService{}, cfg)
+	ctx := context.Background()
+
+	// Omitted limit and sort take the configured defaults
+	images, _, err := service.ListImages(ctx, 0, 0, "")
+	require.NoError(t, err)
+	require.Len(t, images, 2)
+	assert.Equal(t, "img-4", images[0].ID)
+	assert.Equal(t, "img-3", images[1].ID)
+
+	// Limits above the configured max fall back to the default
+	images, _, err = service.ListImages(ctx, 0, 5, "")
+	require.NoError(t, err)
+	assert.Len(t, images, 2)
+
+	images, _, err = service.ListImages(ctx, 0, 4, models.ListSortLastAccessed)
+	require.NoError(t, err)
+	require.Len(t, images, 4)
+	assert.Equal(t, "img-0", images[0].ID)
+
+	cfg.Image.ListDefaultSort = ""
+	_, _, err = service.ListImages(ctx, 0, 0, "")
+	require.NoError(t, err)
+	assert.Equal(t, 2, limits[len(limits)-1], "unsorted listings page in the repository")
+}
+
+func TestImageService_ListImages_SortByLastAccessed(t *testing.T) {
+	now := time.Now()
+	image := func(id string, lastAccessed time.Time) *models.ImageMetadata {
+		metadata := testutil.CreateTestImageMetadata()
+		metadata.ID = id
+		metadata.LastAccessedAt = lastAccessed
+		return metadata
+	}
+	stored := []*models.ImageMetadata{
+		image("recent", now),
+		image("never", time.Time{}),
+		image("stale", now.Add(-30*24*time.Hour)),
+		image("week", now.Add(-7*24*time.Hour)),
+	}
+
+	mockRepo := &mockImageRepositoryForImageService{
+		listFunc: func(ctx context.Context, offset, limit int) ([]*models.ImageMetadata, error) {
+			// Sorting needs every image, not just one page
+			assert.Zero(t, offset)
+			return append([]*models.ImageMetadata{}, stored...), nil
+		},
+	}
+	service := NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, &mockStorageProviderForImageService{}, &mockProcessorServiceForImageService{}, testutil.TestConfig())
+	ctx := context.Background()
+
+	ids := func(images []*models.ImageMetadata) []string {
+		result := make([]string, len(images))
+		for i, img := range images {
+			result[i] = img.ID
+		}
+		return result
+	}
+
+	images, _, err := service.ListImages(ctx, 0, 10, models.ListSortLastAccessed)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"never", "stale", "week", "recent"}, ids(images))
+
+	images, _, err = service.ListImages(ctx, 1, 2, models.ListSortLastAccessedDesc)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"week", "stale"}, ids(images))
+
+	images, _, err = service.ListImages(ctx, 10, 2, models.ListSortLastAccessed)
+	require.NoError(t, err)
+	assert.Empty(t, images)
+
+	_, _, err = service.ListImages(ctx, 0, 10, "filename")
+	var validationErr models.ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "sort", validationErr.Field)
+}
+
+func TestImageService_ListImagesByDedupStatus(t *testing.T) {
+	image := func(id string, deduped bool) *models.ImageMetadata {
+		metadata := testutil.CreateTestImageMetadata()
+		metadata.ID = id
+		metadata.IsDeduped = deduped
+		return metadata
+	}
+	stored := []*models.ImageMetadata{image("a", false), image("b", true), image("c", false), image("d", true), image("e", true)}
+
+	var filters []string
+	mockRepo := &mockImageRepositoryForImageService{
+		listByDedupFunc: func(ctx context.Context, filter string, offset, limit int) ([]*models.ImageMetadata, models.DedupStatusCounts, error) {
+			filters = append(filters, filter)
+			var counts models.DedupStatusCounts
+			var matched []*models.ImageMetadata
+			for _, img := range stored {
+				counts.Add(img)
+				if img.MatchesDedupFilter(filter) {
+					matched = append(matched, img)
+				}
+			}
+			return matched[min(offset, len(matched)):min(offset+limit, len(matched))], counts, nil
+		},
+	}
+	cfg := testutil.TestConfig()
+	service := NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, &mockStorageProviderForImageService{}, &mockProcessorServiceForImageService{}, cfg)
+	ctx := context.Background()
+	expectedCounts := models.DedupStatusCounts{Unique: 2, Deduped: 3, All: 5}
+
+	list, err := service.ListImagesByDedupStatus(ctx, models.ListDedupDeduped, 0, 10, "")
+	require.NoError(t, err)
+	assert.Len(t, list.Images, 3)
+	assert.Equal(t, expectedCounts, list.Counts)
+
+	list, err = service.ListImagesByDedupStatus(ctx, models.ListDedupUnique, 0, 10, "")
+	require.NoError(t, err)
+	require.Len(t, list.Images, 2)
+	assert.Equal(t, "a", list.Images[0].ID)
+	assert.Equal(t, "c", list.Images[1].ID)
+	assert.Equal(t, expectedCounts, list.Counts)
+
+	list, err = service.ListImagesByDedupStatus(ctx, "", 0, 10, "")
+	require.NoError(t, err)
+	assert.Len(t, list.Images, 5)
+	assert.Equal(t, []string{models.ListDedupDeduped, models.ListDedupUnique, models.ListDedupAll}, filters)
+
+	_, err = service.ListImagesByDedupStatus(ctx, "shared", 0, 10, "")
+	var validationErr models.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "dedup", validationErr.Field)
+
+	// An omitted limit takes the configured default
+	cfg.Image.ListDefaultLimit = 2
+	list, err = service.ListImagesByDedupStatus(ctx, "", 0, 0, "")
+	require.NoError(t, err)
+	require.Len(t, list.Images, 2)
+	assert.Equal(t, "a", list.Images[0].ID)
+	assert.Equal(t, "b", list.Images[1].ID)
+}
+
 func TestImageService_ValidateUploadInput(t *testing.T) {
 	cfg := testutil.TestConfig()
 	service := &ImageServiceImpl{config: cfg}
@@ -732,6 +1320,39 @@ func TestImageService_ValidateUploadInput(t *testing.T) {
 			wantErr: true,
 			errMsg:  "exceeds maximum configured",
 		},
+		{
+			name: "invalid alias characters",
+			input: UploadInput{
+				Filename:    "test.jpg",
+				Data:        testutil.CreateTestImageData(),
+				Size:        int64(len(testutil.CreateTestImageData())),
+				Resolutions: []string{"800x600:../etc"},
+			},
+			wantErr: true,
+			errMsg:  "invalid alias",
+		},
+		{
+			name: "alias reserved by a route",
+			input: UploadInput{
+				Filename:    "test.jpg",
+				Data:        testutil.CreateTestImageData(),
+				Size:        int64(len(testutil.CreateTestImageData())),
+				Resolutions: []string{"800x600:archive"},
+			},
+			wantErr: true,
+			errMsg:  "reserved",
+		},
+		{
+			name: "same alias for different dimensions",
+			input: UploadInput{
+				Filename:    "test.jpg",
+				Data:        testutil.CreateTestImageData(),
+				Size:        int64(len(testutil.CreateTestImageData())),
+				Resolutions: []string{"800x600:small,400x300:small"},
+			},
+			wantErr: true,
+			errMsg:  "Alias 'small' is already used",
+		},
 		{
 			name: "empty resolution after trim",
 			input: UploadInput{
@@ -746,7 +1367,7 @@ func TestImageService_ValidateUploadInput(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := service.validateUploadInput(tt.input)
+			err := service.validateUploadInput(&tt.input)
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -760,8 +1381,24 @@ func TestImageService_ValidateUploadInput(t *testing.T) {
 	}
 }
 
-func TestImageService_ProcessResolution_Success(t *testing.T) {
-	originalData := testutil.CreateTestImageData()
+func TestImageService_ValidateUploadInput_NormalizesResolutions(t *testing.T) {
+	service := &ImageServiceImpl{config: testutil.TestConfig()}
+
+	input := UploadInput{
+		Filename:    "test.jpg",
+		Data:        testutil.CreateTestImageData(),
+		Size:        int64(len(testutil.CreateTestImageData())),
+		Resolutions: []string{"800x600 : Small, 1200x900:", "thumbnail"},
+	}
+
+	err := service.validateUploadInput(&input)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"800x600:small", "1200x900", "thumbnail"}, input.Resolutions)
+}
+
+func TestImageService_ProcessResolution_Success(t *testing.T) {
+	originalData := testutil.CreateTestImageData()
 	expectedMetadata := testutil.CreateTestImageMetadata()
 
 	mockRepo := &mockImageRepositoryForImageService{
@@ -789,304 +1426,2433 @@ func TestImageService_ProcessResolution_Success(t *testing.T) {
 	service := NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, mockStorage, mockProcessor, testutil.TestConfig())
 
 	ctx := context.Background()
-	err := service.ProcessResolution(ctx, testutil.ValidUUID, "1024x768")
+	err := service.ProcessResolution(ctx, testutil.ValidUUID, "1024x768", false)
 
 	assert.NoError(t, err)
 }
 
-func TestImageService_ProcessResolution_AlreadyExists(t *testing.T) {
-	expectedMetadata := testutil.CreateTestImageMetadata()
-	// Add the resolution we're trying to process
-	expectedMetadata.Resolutions = append(expectedMetadata.Resolutions, "1024x768")
+func TestImageService_ProcessResolution_Timeout(t *testing.T) {
+	originalData := testutil.CreateTestImageData()
 
 	mockRepo := &mockImageRepositoryForImageService{
 		getByIDFunc: func(ctx context.Context, id string) (*models.ImageMetadata, error) {
-			return expectedMetadata, nil
+			return testutil.CreateTestImageMetadata(), nil
+		},
+		updateFunc: func(ctx context.Context, metadata *models.ImageMetadata) error {
+			t.Error("metadata must not be updated when processing times out")
+			return nil
+		},
+	}
+	uploaded := false
+	mockStorage := &mockStorageProviderForImageService{
+		downloadFunc: func(ctx context.Context, key string) (io.ReadCloser, error) {
+			return testutil.NewMockReadCloser(originalData), nil
+		},
+		uploadFunc: func(ctx context.Context, key string, data io.Reader, size int64, contentType string) error {
+			uploaded = true
+			return nil
+		},
+	}
+	release := make(chan struct{})
+	defer close(release)
+	mockProcessor := &mockProcessorServiceForImageService{
+		processImageFunc: func(data []byte, config ResizeConfig) ([]byte, error) {
+			// Simulates a pathological image that takes far longer than the limit
+			<-release
+			return testutil.CreateTestImageData(), nil
 		},
 	}
 
-	service := NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, &mockStorageProviderForImageService{}, &mockProcessorServiceForImageService{}, testutil.TestConfig())
+	cfg := testutil.TestConfig()
+	cfg.Image.ProcessingTimeout = 20 * time.Millisecond
+	service := NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, mockStorage, mockProcessor, cfg)
 
-	ctx := context.Background()
-	err := service.ProcessResolution(ctx, testutil.ValidUUID, "1024x768")
+	start := time.Now()
+	err := service.ProcessResolution(context.Background(), testutil.ValidUUID, "1024x768", false)
 
-	// Should succeed without doing anything
-	assert.NoError(t, err)
+	require.Error(t, err)
+	var processingErr models.ProcessingError
+	require.ErrorAs(t, err, &processingErr)
+	assert.Equal(t, "resize", processingErr.Operation)
+	assert.Contains(t, processingErr.Reason, "timed out")
+	assert.Less(t, time.Since(start), time.Second)
+	assert.False(t, uploaded)
 }
 
-func TestImageService_ResizeConfig(t *testing.T) {
-	cfg := &config.Config{
-		Image: config.ImageConfig{
-			Quality:    90,
-			ResizeMode: "crop",
+func TestImageService_ProcessImageWithTimeout(t *testing.T) {
+	mockProcessor := &mockProcessorServiceForImageService{
+		processImageFunc: func(data []byte, config ResizeConfig) ([]byte, error) {
+			time.Sleep(5 * time.Millisecond)
+			return []byte("processed"), nil
 		},
 	}
 
-	service := &ImageServiceImpl{config: cfg}
+	t.Run("completes within timeout", func(t *testing.T) {
+		cfg := testutil.TestConfig()
+		cfg.Image.ProcessingTimeout = time.Second
+		service := NewImageService(&mockImageRepositoryForImageService{}, &mockDeduplicationRepositoryForImageService{}, &mockStorageProviderForImageService{}, mockProcessor, cfg).(*ImageServiceImpl)
 
-	// Test the resize configuration creation
-	resolutionConfig := models.ResolutionConfig{Width: 800, Height: 600}
-	resizeConfig := ResizeConfig{
-		Width:   resolutionConfig.Width,
-		Height:  resolutionConfig.Height,
-		Quality: service.config.Image.Quality,
-		Format:  "image/jpeg",
-		Mode:    ResizeMode(service.config.Image.ResizeMode),
-	}
+		data, err := service.processImageWithTimeout(context.Background(), nil, ResizeConfig{})
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("processed"), data)
+	})
 
-	assert.Equal(t, 800, resizeConfig.Width)
-	assert.Equal(t, 600, resizeConfig.Height)
-	assert.Equal(t, 90, resizeConfig.Quality)
-	assert.Equal(t, "image/jpeg", resizeConfig.Format)
-	assert.Equal(t, ResizeMode("crop"), resizeConfig.Mode)
-}
+	t.Run("timeout disabled", func(t *testing.T) {
+		cfg := testutil.TestConfig()
+		cfg.Image.ProcessingTimeout = 0
+		service := NewImageService(&mockImageRepositoryForImageService{}, &mockDeduplicationRepositoryForImageService{}, &mockStorageProviderForImageService{}, mockProcessor, cfg).(*ImageServiceImpl)
 
-func TestUploadInput_Validation(t *testing.T) {
-	testData := testutil.CreateTestImageData()
+		data, err := service.processImageWithTimeout(context.Background(), nil, ResizeConfig{})
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("processed"), data)
+	})
 
-	validInput := UploadInput{
-		Filename:    "test.jpg",
-		Data:        testData,
-		Size:        int64(len(testData)),
-		Resolutions: []string{"800x600"},
-	}
+	t.Run("canceled request", func(t *testing.T) {
+		service := NewImageService(&mockImageRepositoryForImageService{}, &mockDeduplicationRepositoryForImageService{}, &mockStorageProviderForImageService{}, mockProcessor, testutil.TestConfig()).(*ImageServiceImpl)
 
-	// Test valid input structure
-	assert.Equal(t, "test.jpg", validInput.Filename)
-	assert.Equal(t, testData, validInput.Data)
-	assert.Equal(t, int64(len(testData)), validInput.Size)
-	assert.Equal(t, []string{"800x600"}, validInput.Resolutions)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := service.processImageWithTimeout(ctx, nil, ResizeConfig{})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
 }
 
-func TestUploadResult_Structure(t *testing.T) {
-	result := &UploadResult{
-		ImageID:              testutil.ValidUUID,
-		ProcessedResolutions: []string{"thumbnail", "800x600"},
-		OriginalSize:         102400,
-		ProcessedSizes: map[string]int64{
-			"thumbnail": 5000,
-			"800x600":   15000,
+func TestImageService_ProcessResolution_AlreadyExists(t *testing.T) {
+	expectedMetadata := testutil.CreateTestImageMetadata()
+	// Add the resolution we're trying to process
+	expectedMetadata.Resolutions = append(expectedMetadata.Resolutions, "1024x768")
+
+	mockRepo := &mockImageRepositoryForImageService{
+		getByIDFunc: func(ctx context.Context, id string) (*models.ImageMetadata, error) {
+			return expectedMetadata, nil
 		},
 	}
 
-	assert.Equal(t, testutil.ValidUUID, result.ImageID)
-	assert.Contains(t, result.ProcessedResolutions, "thumbnail")
-	assert.Equal(t, int64(102400), result.OriginalSize)
-	assert.Equal(t, int64(5000), result.ProcessedSizes["thumbnail"])
+	service := NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, &mockStorageProviderForImageService{}, &mockProcessorServiceForImageService{}, testutil.TestConfig())
+
+	ctx := context.Background()
+	err := service.ProcessResolution(ctx, testutil.ValidUUID, "1024x768", false)
+
+	// Should succeed without doing anything
+	assert.NoError(t, err)
 }
 
-func TestImageService_DeleteResolution(t *testing.T) {
-	t.Run("successful_deletion", func(t *testing.T) {
-		mockRepo := &testutil.MockImageRepository{
-			GetFunc: func(ctx context.Context, id string) (*models.ImageMetadata, error) {
-				return &models.ImageMetadata{
-					ID:          id,
-					MimeType:    "image/jpeg",
-					Resolutions: []string{"original", "800x600", "thumbnail"},
-				}, nil
-			},
-			UpdateFunc: func(ctx context.Context, metadata *models.ImageMetadata) error {
-				return nil
-			},
-		}
+func TestImageService_ResolutionAreaBudget(t *testing.T) {
+	cfg := testutil.TestConfig()
+	cfg.Image.MaxWidth = 4096
+	cfg.Image.MaxHeight = 4096
+	cfg.Image.MaxResolutionPixels = 10000000
 
-		mockDeduplicationRepo := &testutil.MockDeduplicationRepository{}
-		mockStorage := &testutil.MockStorageProvider{
-			DeleteFunc: func(ctx context.Context, key string) error {
-				return nil
-			},
+	t.Run("upload rejects resolution over the area budget", func(t *testing.T) {
+		service := &ImageServiceImpl{config: cfg}
+		input := UploadInput{
+			Filename:    "test.jpg",
+			Data:        testutil.CreateTestImageData(),
+			Size:        int64(len(testutil.CreateTestImageData())),
+			Resolutions: []string{"800x600", "4000x4000"},
 		}
-		mockProcessor := &testProcessorService{}
 
-		service := NewImageService(mockRepo, mockDeduplicationRepo, mockStorage, mockProcessor, testConfig())
+		err := service.validateUploadInput(&input)
 
-		err := service.DeleteResolution(context.Background(), testutil.ValidUUID, "800x600")
-		assert.NoError(t, err)
+		var validationErr models.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "resolutions", validationErr.Field)
+		assert.Contains(t, validationErr.Message, "exceeding the maximum of 10000000")
 	})
 
-	t.Run("image_not_found", func(t *testing.T) {
-		mockRepo := &testutil.MockImageRepository{
-			GetFunc: func(ctx context.Context, id string) (*models.ImageMetadata, error) {
-				return nil, models.NotFoundError{Resource: "image", ID: id}
-			},
+	t.Run("upload accepts resolution within the area budget", func(t *testing.T) {
+		service := &ImageServiceImpl{config: cfg}
+		input := UploadInput{
+			Filename:    "test.jpg",
+			Data:        testutil.CreateTestImageData(),
+			Size:        int64(len(testutil.CreateTestImageData())),
+			Resolutions: []string{"4000x2500"},
 		}
 
-		mockDeduplicationRepo := &testutil.MockDeduplicationRepository{}
-		mockStorage := &testutil.MockStorageProvider{}
-		mockProcessor := &testProcessorService{}
-
-		service := NewImageService(mockRepo, mockDeduplicationRepo, mockStorage, mockProcessor, testConfig())
-
-		err := service.DeleteResolution(context.Background(), testutil.ValidUUID, "800x600")
-		assert.Error(t, err)
-		var notFoundErr models.NotFoundError
-		assert.ErrorAs(t, err, &notFoundErr)
+		assert.NoError(t, service.validateUploadInput(&input))
 	})
 
-	t.Run("resolution_not_found", func(t *testing.T) {
-		mockRepo := &testutil.MockImageRepository{
-			GetFunc: func(ctx context.Context, id string) (*models.ImageMetadata, error) {
-				return &models.ImageMetadata{
-					ID:          id,
-					Resolutions: []string{"original", "thumbnail"},
-				}, nil
+	t.Run("process resolution rejects before touching storage", func(t *testing.T) {
+		mockRepo := &mockImageRepositoryForImageService{
+			getByIDFunc: func(ctx context.Context, id string) (*models.ImageMetadata, error) {
+				t.Fatal("metadata should not be loaded for a rejected resolution")
+				return nil, nil
 			},
 		}
+		service := NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, &mockStorageProviderForImageService{}, &mockProcessorServiceForImageService{}, cfg)
 
-		mockDeduplicationRepo := &testutil.MockDeduplicationRepository{}
-		mockStorage := &testutil.MockStorageProvider{}
-		mockProcessor := &testProcessorService{}
+		err := service.ProcessResolution(context.Background(), testutil.ValidUUID, "4000x4000:huge", false)
 
-		service := NewImageService(mockRepo, mockDeduplicationRepo, mockStorage, mockProcessor, testConfig())
+		var validationErr models.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "resolution", validationErr.Field)
+	})
 
-		err := service.DeleteResolution(context.Background(), testutil.ValidUUID, "800x600")
-		assert.Error(t, err)
-		var notFoundErr models.NotFoundError
-		assert.ErrorAs(t, err, &notFoundErr)
+	t.Run("zero budget disables the check", func(t *testing.T) {
+		unlimited := testutil.TestConfig()
+		unlimited.Image.MaxWidth = 4096
+		unlimited.Image.MaxHeight = 4096
+		unlimited.Image.MaxResolutionPixels = 0
+		service := &ImageServiceImpl{config: unlimited}
+		input := UploadInput{
+			Filename:    "test.jpg",
+			Data:        testutil.CreateTestImageData(),
+			Size:        int64(len(testutil.CreateTestImageData())),
+			Resolutions: []string{"4000x4000"},
+		}
+
+		assert.NoError(t, service.validateUploadInput(&input))
 	})
+}
 
-	t.Run("cannot_delete_original", func(t *testing.T) {
-		mockRepo := &testutil.MockImageRepository{
-			GetFunc: func(ctx context.Context, id string) (*models.ImageMetadata, error) {
-				return &models.ImageMetadata{
-					ID:          id,
-					Resolutions: []string{"original", "800x600"},
-				}, nil
+func TestImageService_ProcessResolution_AliasCollision(t *testing.T) {
+	newService := func(metadata *models.ImageMetadata, updated *bool) ImageService {
+		mockRepo := &mockImageRepositoryForImageService{
+			getByIDFunc: func(ctx context.Context, id string) (*models.ImageMetadata, error) {
+				return metadata, nil
+			},
+			updateFunc: func(ctx context.Context, metadata *models.ImageMetadata) error {
+				*updated = true
+				return nil
 			},
 		}
+		return NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, &mockStorageProviderForImageService{}, &mockProcessorServiceForImageService{}, testutil.TestConfig())
+	}
 
-		mockDeduplicationRepo := &testutil.MockDeduplicationRepository{}
-		mockStorage := &testutil.MockStorageProvider{}
-		mockProcessor := &testProcessorService{}
+	t.Run("alias mapped to different dimensions is rejected", func(t *testing.T) {
+		metadata := testutil.CreateTestImageMetadata()
+		metadata.Resolutions = []string{"thumbnail", "800x600:small"}
+		updated := false
 
-		service := NewImageService(mockRepo, mockDeduplicationRepo, mockStorage, mockProcessor, testConfig())
+		err := newService(metadata, &updated).ProcessResolution(context.Background(), testutil.ValidUUID, "400x300:small", false)
 
-		err := service.DeleteResolution(context.Background(), testutil.ValidUUID, "original")
-		assert.Error(t, err)
 		var validationErr models.ValidationError
 		assert.ErrorAs(t, err, &validationErr)
+		assert.Contains(t, validationErr.Message, "Alias 'small' is already used by resolution 800x600")
+		assert.False(t, updated)
+		assert.Equal(t, []string{"thumbnail", "800x600:small"}, metadata.Resolutions)
 	})
-}
 
-func TestImageService_GenerateUniqueImageID(t *testing.T) {
-	t.Run("generates unique UUID on first attempt", func(t *testing.T) {
-		mockRepo := &testutil.MockImageRepository{
-			ExistsFunc: func(ctx context.Context, id string) (bool, error) {
-				return false, nil // UUID doesn't exist, so it's unique
-			},
-		}
-
-		service := &ImageServiceImpl{repo: mockRepo}
+	t.Run("re-adding identical alias is a no-op", func(t *testing.T) {
+		metadata := testutil.CreateTestImageMetadata()
+		metadata.Resolutions = []string{"thumbnail", "800x600:small"}
+		updated := false
 
-		imageID, err := service.generateUniqueImageID(context.Background())
+		err := newService(metadata, &updated).ProcessResolution(context.Background(), testutil.ValidUUID, "800x600:small", false)
 
 		assert.NoError(t, err)
-		assert.NotEmpty(t, imageID)
-		assert.Len(t, imageID, 36) // Standard UUID length with hyphens
+		assert.False(t, updated)
+		assert.Equal(t, []string{"thumbnail", "800x600:small"}, metadata.Resolutions)
 	})
+}
 
-	t.Run("handles UUID collision and regenerates", func(t *testing.T) {
-		callCount := 0
-		mockRepo := &testutil.MockImageRepository{
-			ExistsFunc: func(ctx context.Context, id string) (bool, error) {
-				callCount++
-				if callCount <= 2 {
-					return true, nil // First two UUIDs collide
-				}
-				return false, nil // Third UUID is unique
+func TestImageService_ProcessResolution_Force(t *testing.T) {
+	type recorder struct {
+		uploads   []string
+		downloads []string
+		updated   *models.ImageMetadata
+	}
+
+	newService := func(metadata *models.ImageMetadata, rec *recorder) ImageService {
+		mockRepo := &mockImageRepositoryForImageService{
+			getByIDFunc: func(ctx context.Context, id string) (*models.ImageMetadata, error) {
+				return metadata, nil
+			},
+			updateFunc: func(ctx context.Context, metadata *models.ImageMetadata) error {
+				rec.updated = metadata
+				return nil
+			},
+		}
+		mockStorage := &mockStorageProviderForImageService{
+			downloadFunc: func(ctx context.Context, key string) (io.ReadCloser, error) {
+				rec.downloads = append(rec.downloads, key)
+				return testutil.NewMockReadCloser(testutil.CreateTestImageData()), nil
+			},
+			uploadFunc: func(ctx context.Context, key string, data io.Reader, size int64, contentType string) error {
+				rec.uploads = append(rec.uploads, key)
+				return nil
+			},
+		}
+		mockProcessor := &mockProcessorServiceForImageService{
+			processImageFunc: func(data []byte, config ResizeConfig) ([]byte, error) {
+				return testutil.CreateTestImageData(), nil
 			},
 		}
+		return NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, mockStorage, mockProcessor, testutil.TestConfig())
+	}
 
-		service := &ImageServiceImpl{repo: mockRepo}
+	t.Run("existing resolution without force is a no-op", func(t *testing.T) {
+		metadata := testutil.CreateTestImageMetadata()
+		rec := &recorder{}
 
-		imageID, err := service.generateUniqueImageID(context.Background())
+		err := newService(metadata, rec).ProcessResolution(context.Background(), testutil.ValidUUID, "800x600", false)
 
 		assert.NoError(t, err)
-		assert.NotEmpty(t, imageID)
-		assert.Equal(t, 3, callCount) // Should have tried 3 times
+		assert.Empty(t, rec.uploads)
+		assert.Nil(t, rec.updated)
 	})
 
-	t.Run("fails after max attempts", func(t *testing.T) {
-		mockRepo := &testutil.MockImageRepository{
-			ExistsFunc: func(ctx context.Context, id string) (bool, error) {
-				return true, nil // All UUIDs collide
-			},
+	t.Run("force overwrites the existing derivative", func(t *testing.T) {
+		metadata := testutil.CreateTestImageMetadata()
+		previousUpdate := metadata.UpdatedAt
+		rec := &recorder{}
+
+		err := newService(metadata, rec).ProcessResolution(context.Background(), testutil.ValidUUID, "800x600", true)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"images/" + metadata.ID + "/800x600.jpg"}, rec.uploads)
+		if assert.NotNil(t, rec.updated) {
+			assert.Equal(t, []string{"thumbnail", "800x600"}, rec.updated.Resolutions)
+			assert.True(t, rec.updated.UpdatedAt.After(previousUpdate))
 		}
+	})
 
-		service := &ImageServiceImpl{repo: mockRepo}
+	t.Run("force by alias regenerates the aliased dimensions", func(t *testing.T) {
+		metadata := testutil.CreateTestImageMetadata()
+		metadata.Resolutions = []string{"thumbnail", "640x480:small"}
+		rec := &recorder{}
 
-		imageID, err := service.generateUniqueImageID(context.Background())
+		err := newService(metadata, rec).ProcessResolution(context.Background(), testutil.ValidUUID, "small", true)
 
-		assert.Error(t, err)
-		assert.Empty(t, imageID)
-		assert.Contains(t, err.Error(), "failed to generate unique UUID after 10 attempts")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"images/" + metadata.ID + "/640x480.jpg"}, rec.uploads)
+		assert.Equal(t, []string{"thumbnail", "640x480:small"}, rec.updated.Resolutions)
 	})
 
-	t.Run("handles repository error", func(t *testing.T) {
-		mockRepo := &testutil.MockImageRepository{
-			ExistsFunc: func(ctx context.Context, id string) (bool, error) {
-				return false, errors.New("database connection failed")
-			},
-		}
+	t.Run("force on deduplicated image regenerates against the master", func(t *testing.T) {
+		masterID := "a1b2c3d4-0000-4000-8000-000000000001"
+		metadata := testutil.CreateTestImageMetadata()
+		metadata.IsDeduped = true
+		metadata.SharedImageID = masterID
+		rec := &recorder{}
 
-		service := &ImageServiceImpl{repo: mockRepo}
+		err := newService(metadata, rec).ProcessResolution(context.Background(), testutil.ValidUUID, "800x600", true)
 
-		imageID, err := service.generateUniqueImageID(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"images/" + masterID + "/original.jpg"}, rec.downloads)
+		assert.Equal(t, []string{"images/" + masterID + "/800x600.jpg"}, rec.uploads)
+	})
+}
 
-		assert.Error(t, err)
+func TestImageService_ProcessResolution_NoUpscale(t *testing.T) {
+	type recorder struct {
+		uploads   []string
+		deletes   []string
+		processed int
+		updated   *models.ImageMetadata
+	}
+
+	newService := func(metadata *models.ImageMetadata, rec *recorder, configure func(*config.Config)) ImageService {
+		mockRepo := &mockImageRepositoryForImageService{
+			getByIDFunc: func(ctx context.Context, id string) (*models.ImageMetadata, error) {
+				return metadata, nil
+			},
+			updateFunc: func(ctx context.Context, metadata *models.ImageMetadata) error {
+				rec.updated = metadata
+				return nil
+			},
+		}
+		mockStorage := &mockStorageProviderForImageService{
+			downloadFunc: func(ctx context.Context, key string) (io.ReadCloser, error) {
+				return testutil.NewMockReadCloser(testutil.CreateTestImageData()), nil
+			},
+			uploadFunc: func(ctx context.Context, key string, data io.Reader, size int64, contentType string) error {
+				rec.uploads = append(rec.uploads, key)
+				return nil
+			},
+			existsFunc: func(ctx context.Context, key string) (bool, error) {
+				return true, nil
+			},
+			deleteFunc: func(ctx context.Context, key string) error {
+				rec.deletes = append(rec.deletes, key)
+				return nil
+			},
+		}
+		mockProcessor := &mockProcessorServiceForImageService{
+			processImageFunc: func(data []byte, config ResizeConfig) ([]byte, error) {
+				rec.processed++
+				return testutil.CreateTestImageData(), nil
+			},
+		}
+		cfg := testutil.TestConfig()
+		cfg.Image.NoUpscale = true
+		if configure != nil {
+			configure(cfg)
+		}
+		return NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, mockStorage, mockProcessor, cfg)
+	}
+
+	t.Run("resolution larger than the source reuses the original", func(t *testing.T) {
+		metadata := testutil.CreateTestImageMetadata()
+		rec := &recorder{}
+
+		err := newService(metadata, rec, nil).ProcessResolution(context.Background(), testutil.ValidUUID, "4000x3000:huge", false)
+
+		require.NoError(t, err)
+		assert.Zero(t, rec.processed)
+		assert.Empty(t, rec.uploads)
+		require.NotNil(t, rec.updated)
+		assert.True(t, rec.updated.HasResolution("huge"))
+		assert.True(t, rec.updated.ServesOriginal("huge"))
+		assert.Equal(t, rec.updated.GetStorageKey("original"), rec.updated.GetActualStorageKey("huge"))
+		assert.Equal(t, "image/jpeg", rec.updated.GetContentType("huge"))
+
+		dimensions, ok := rec.updated.GetResolutionDimensions("huge")
+		require.True(t, ok)
+		assert.Equal(t, models.DimensionInfo{Width: 1920, Height: 1080}, dimensions)
+	})
+
+	t.Run("resolution smaller than the source on one side is generated", func(t *testing.T) {
+		metadata := testutil.CreateTestImageMetadata()
+		rec := &recorder{}
+
+		err := newService(metadata, rec, nil).ProcessResolution(context.Background(), testutil.ValidUUID, "4000x1000", false)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, rec.processed)
+		assert.Equal(t, []string{"images/" + testutil.ValidUUID + "/4000x1000.jpg"}, rec.uploads)
+		assert.False(t, rec.updated.ServesOriginal("4000x1000"))
+	})
+
+	t.Run("crop mode still upscales", func(t *testing.T) {
+		metadata := testutil.CreateTestImageMetadata()
+		rec := &recorder{}
+
+		err := newService(metadata, rec, func(cfg *config.Config) {
+			cfg.Image.ResizeMode = "crop"
+		}).ProcessResolution(context.Background(), testutil.ValidUUID, "4000x3000", false)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, rec.processed)
+		assert.False(t, rec.updated.ServesOriginal("4000x3000"))
+	})
+
+	t.Run("disabled generates the resolution", func(t *testing.T) {
+		metadata := testutil.CreateTestImageMetadata()
+		rec := &recorder{}
+
+		err := newService(metadata, rec, func(cfg *config.Config) {
+			cfg.Image.NoUpscale = false
+		}).ProcessResolution(context.Background(), testutil.ValidUUID, "4000x3000", false)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, rec.processed)
+		assert.Equal(t, []string{"images/" + testutil.ValidUUID + "/4000x3000.jpg"}, rec.uploads)
+	})
+
+	t.Run("deleting the alias keeps the original", func(t *testing.T) {
+		metadata := testutil.CreateTestImageMetadata()
+		metadata.Resolutions = append(metadata.Resolutions, "4000x3000")
+		metadata.SetServesOriginal("4000x3000", true)
+		rec := &recorder{}
+
+		err := newService(metadata, rec, nil).DeleteResolution(context.Background(), testutil.ValidUUID, "4000x3000")
+
+		require.NoError(t, err)
+		assert.Empty(t, rec.deletes)
+		assert.False(t, rec.updated.HasResolution("4000x3000"))
+		assert.Empty(t, rec.updated.OriginalAliases)
+	})
+}
+
+func TestImageService_FormatVariants(t *testing.T) {
+	type recorder struct {
+		uploads   map[string]string
+		deletes   []string
+		downloads []string
+		formats   []string
+		updated   *models.ImageMetadata
+	}
+
+	newService := func(metadata *models.ImageMetadata, rec *recorder) ImageService {
+		rec.uploads = make(map[string]string)
+		mockRepo := &mockImageRepositoryForImageService{
+			getByIDFunc: func(ctx context.Context, id string) (*models.ImageMetadata, error) {
+				return metadata, nil
+			},
+			updateFunc: func(ctx context.Context, metadata *models.ImageMetadata) error {
+				rec.updated = metadata
+				return nil
+			},
+		}
+		mockStorage := &mockStorageProviderForImageService{
+			downloadFunc: func(ctx context.Context, key string) (io.ReadCloser, error) {
+				rec.downloads = append(rec.downloads, key)
+				return testutil.NewMockReadCloser(testutil.CreateTestImageData()), nil
+			},
+			uploadFunc: func(ctx context.Context, key string, data io.Reader, size int64, contentType string) error {
+				rec.uploads[key] = contentType
+				return nil
+			},
+			existsFunc: func(ctx context.Context, key string) (bool, error) {
+				return true, nil
+			},
+			deleteFunc: func(ctx context.Context, key string) error {
+				rec.deletes = append(rec.deletes, key)
+				return nil
+			},
+		}
+		mockProcessor := &mockProcessorServiceForImageService{
+			processImageFunc: func(data []byte, config ResizeConfig) ([]byte, error) {
+				rec.formats = append(rec.formats, config.Format)
+				return testutil.CreateTestImageData(), nil
+			},
+		}
+		cfg := testutil.TestConfig()
+		cfg.Image.FormatVariants = []string{"webp", "jpeg", "png"}
+		return NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, mockStorage, mockProcessor, cfg)
+	}
+
+	t.Run("each resolution is stored in every configured format", func(t *testing.T) {
+		metadata := testutil.CreateTestImageMetadata()
+		rec := &recorder{}
+
+		err := newService(metadata, rec).ProcessResolution(context.Background(), testutil.ValidUUID, "1024x768:large", false)
+
+		require.NoError(t, err)
+		prefix := "images/" + testutil.ValidUUID + "/1024x768."
+		assert.Equal(t, map[string]string{
+			prefix + "jpg":  "image/jpeg",
+			prefix + "webp": "image/webp",
+			prefix + "png":  "image/png",
+		}, rec.uploads)
+		assert.Equal(t, []string{"jpeg", "webp", "png"}, rec.formats, "the resolution's own format is not generated twice")
+
+		require.NotNil(t, rec.updated)
+		assert.Equal(t, []string{"webp", "png"}, rec.updated.GetFormatVariants("large"))
+		assert.Equal(t, map[string][]string{"1024x768:large": {"webp", "png"}}, rec.updated.ToInfoResponse().FormatVariants)
+	})
+
+	t.Run("variants are downloaded by format", func(t *testing.T) {
+		metadata := testutil.CreateTestImageMetadata()
+		metadata.SetFormatVariants("800x600", []string{"webp"})
+		rec := &recorder{}
+		svc := newService(metadata, rec)
+
+		stream, _, err := svc.GetImageVariantStream(context.Background(), testutil.ValidUUID, "800x600", "webp")
+		require.NoError(t, err)
+		require.NoError(t, stream.Close())
+
+		stream, _, err = svc.GetImageVariantStream(context.Background(), testutil.ValidUUID, "800x600", "jpeg")
+		require.NoError(t, err)
+		require.NoError(t, stream.Close())
+
+		assert.Equal(t, []string{
+			"images/" + testutil.ValidUUID + "/800x600.webp",
+			"images/" + testutil.ValidUUID + "/800x600.jpg",
+		}, rec.downloads)
+
+		_, _, err = svc.GetImageVariantStream(context.Background(), testutil.ValidUUID, "800x600", "png")
+		assert.IsType(t, models.NotFoundError{}, err)
+
+		_, _, err = svc.GetImageVariantStream(context.Background(), testutil.ValidUUID, "800x600", "avif")
+		assert.IsType(t, models.ValidationError{}, err)
+	})
+
+	t.Run("deleting a resolution removes its variants", func(t *testing.T) {
+		metadata := testutil.CreateTestImageMetadata()
+		metadata.SetFormatVariants("800x600", []string{"webp", "png"})
+		rec := &recorder{}
+
+		err := newService(metadata, rec).DeleteResolution(context.Background(), testutil.ValidUUID, "800x600")
+
+		require.NoError(t, err)
+		prefix := "images/" + testutil.ValidUUID + "/800x600."
+		assert.ElementsMatch(t, []string{prefix + "jpg", prefix + "webp", prefix + "png"}, rec.deletes)
+		assert.Empty(t, rec.updated.FormatVariants)
+	})
+}
+
+func TestImageService_AutoFormat(t *testing.T) {
+	type recorder struct {
+		uploads map[string]string
+		configs []ResizeConfig
+		updated *models.ImageMetadata
+	}
+
+	newService := func(metadata *models.ImageMetadata, output []byte, maxPixels int, rec *recorder) ImageService {
+		rec.uploads = make(map[string]string)
+		mockRepo := &mockImageRepositoryForImageService{
+			getByIDFunc: func(ctx context.Context, id string) (*models.ImageMetadata, error) {
+				return metadata, nil
+			},
+			updateFunc: func(ctx context.Context, metadata *models.ImageMetadata) error {
+				rec.updated = metadata
+				return nil
+			},
+		}
+		mockStorage := &mockStorageProviderForImageService{
+			downloadFunc: func(ctx context.Context, key string) (io.ReadCloser, error) {
+				return testutil.NewMockReadCloser(testutil.CreateTestImageData()), nil
+			},
+			uploadFunc: func(ctx context.Context, key string, data io.Reader, size int64, contentType string) error {
+				rec.uploads[key] = contentType
+				return nil
+			},
+		}
+		mockProcessor := &mockProcessorServiceForImageService{
+			processImageFunc: func(data []byte, config ResizeConfig) ([]byte, error) {
+				rec.configs = append(rec.configs, config)
+				return output, nil
+			},
+		}
+		cfg := testutil.TestConfig()
+		cfg.Image.OutputFormat = "auto"
+		cfg.Image.AutoFormatCandidates = []string{"jpeg", "png"}
+		cfg.Image.AutoFormatMaxPixels = maxPixels
+		return NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, mockStorage, mockProcessor, cfg)
+	}
+
+	t.Run("chosen format is stored and recorded", func(t *testing.T) {
+		rec := &recorder{}
+
+		err := newService(testutil.CreateTestImageMetadata(), testutil.CreateTestPNG(8, 8), 0, rec).ProcessResolution(context.Background(), testutil.ValidUUID, "800x600:small", false)
+
+		require.NoError(t, err)
+		require.Len(t, rec.configs, 1)
+		assert.Equal(t, OutputFormatAuto, rec.configs[0].Format)
+		assert.Equal(t, []string{"jpeg", "png"}, rec.configs[0].AutoFormats)
+		assert.Equal(t, map[string]string{"images/" + testutil.ValidUUID + "/800x600.png": "image/png"}, rec.uploads)
+
+		require.NotNil(t, rec.updated)
+		assert.Equal(t, "png", rec.updated.GetResolutionFormat("small"))
+		assert.Equal(t, "image/png", rec.updated.GetContentType("small"))
+		assert.Equal(t, "images/"+testutil.ValidUUID+"/800x600.png", rec.updated.GetActualStorageKey("small"))
+	})
+
+	t.Run("choosing the derivative format records nothing", func(t *testing.T) {
+		rec := &recorder{}
+
+		err := newService(testutil.CreateTestImageMetadata(), testutil.CreateTestImageData(), 0, rec).ProcessResolution(context.Background(), testutil.ValidUUID, "1024x768", false)
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"images/" + testutil.ValidUUID + "/1024x768.jpg": "image/jpeg"}, rec.uploads)
+		assert.Nil(t, rec.updated.ResolutionFormats)
+	})
+
+	t.Run("resolutions above the pixel cap keep the derivative format", func(t *testing.T) {
+		rec := &recorder{}
+
+		err := newService(testutil.CreateTestImageMetadata(), testutil.CreateTestImageData(), 640*480, rec).ProcessResolution(context.Background(), testutil.ValidUUID, "1024x768", false)
+
+		require.NoError(t, err)
+		require.Len(t, rec.configs, 1)
+		assert.Equal(t, "jpeg", rec.configs[0].Format)
+		assert.Empty(t, rec.configs[0].AutoFormats)
+	})
+}
+
+func TestImageService_TextWatermark(t *testing.T) {
+	newService := func(metadata *models.ImageMetadata, watermarks *[]*TextWatermark, saved **models.ImageMetadata) ImageService {
+		mockRepo := &mockImageRepositoryForImageService{
+			getByIDFunc: func(ctx context.Context, id string) (*models.ImageMetadata, error) {
+				return metadata, nil
+			},
+			saveFunc: func(ctx context.Context, metadata *models.ImageMetadata) error {
+				*saved = metadata
+				return nil
+			},
+			updateFunc: func(ctx context.Context, metadata *models.ImageMetadata) error {
+				return nil
+			},
+		}
+		mockStorage := &mockStorageProviderForImageService{
+			downloadFunc: func(ctx context.Context, key string) (io.ReadCloser, error) {
+				return testutil.NewMockReadCloser(testutil.CreateTestImageData()), nil
+			},
+		}
+		mockProcessor := &mockProcessorServiceForImageService{
+			processImageFunc: func(data []byte, config ResizeConfig) ([]byte, error) {
+				*watermarks = append(*watermarks, config.Watermark)
+				return testutil.CreateTestImageData(), nil
+			},
+		}
+		cfg := testutil.TestConfig()
+		cfg.Watermark = config.WatermarkConfig{Text: "PREVIEW", FontSize: 24, Color: "#FFFFFF", Opacity: 0.5, Position: "center"}
+		return NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, mockStorage, mockProcessor, cfg)
+	}
+
+	t.Run("generated resolutions carry the configured watermark", func(t *testing.T) {
+		var watermarks []*TextWatermark
+		var saved *models.ImageMetadata
+
+		err := newService(testutil.CreateTestImageMetadata(), &watermarks, &saved).ProcessResolution(context.Background(), testutil.ValidUUID, "1024x768", false)
+
+		require.NoError(t, err)
+		require.Len(t, watermarks, 1)
+		assert.Equal(t, &TextWatermark{Text: "PREVIEW", FontSize: 24, Color: "#FFFFFF", Opacity: 0.5, Position: "center"}, watermarks[0])
+	})
+
+	t.Run("images uploaded without watermark stay unmarked", func(t *testing.T) {
+		metadata := testutil.CreateTestImageMetadata()
+		metadata.WatermarkDisabled = true
+		var watermarks []*TextWatermark
+		var saved *models.ImageMetadata
+
+		err := newService(metadata, &watermarks, &saved).ProcessResolution(context.Background(), testutil.ValidUUID, "1024x768", false)
+
+		require.NoError(t, err)
+		require.Len(t, watermarks, 1)
+		assert.Nil(t, watermarks[0])
+	})
+
+	t.Run("watermark=false upload is stored unmarked and isolated", func(t *testing.T) {
+		var watermarks []*TextWatermark
+		var saved *models.ImageMetadata
+		data := testutil.CreateTestImageData()
+
+		_, err := newService(nil, &watermarks, &saved).ProcessUpload(context.Background(), UploadInput{
+			Filename:         "photo.jpg",
+			Data:             data,
+			Size:             int64(len(data)),
+			Resolutions:      []string{"800x600"},
+			DisableWatermark: true,
+		})
+
+		require.NoError(t, err)
+		require.NotEmpty(t, watermarks)
+		for _, watermark := range watermarks {
+			assert.Nil(t, watermark)
+		}
+		require.NotNil(t, saved)
+		assert.True(t, saved.WatermarkDisabled)
+		assert.True(t, saved.DedupDisabled)
+	})
+}
+
+func TestImageService_EstimateResize(t *testing.T) {
+	newService := func(t *testing.T) ImageService {
+		mockRepo := &mockImageRepositoryForImageService{
+			getByIDFunc: func(ctx context.Context, id string) (*models.ImageMetadata, error) {
+				return testutil.CreateTestImageMetadata(), nil // 1920x1080
+			},
+			updateFunc: func(ctx context.Context, metadata *models.ImageMetadata) error {
+				t.Fatal("estimate must not modify metadata")
+				return nil
+			},
+		}
+		mockStorage := &mockStorageProviderForImageService{
+			uploadFunc: func(ctx context.Context, key string, data io.Reader, size int64, contentType string) error {
+				t.Fatal("estimate must not upload anything")
+				return nil
+			},
+		}
+		mockProcessor := &mockProcessorServiceForImageService{
+			processImageFunc: func(data []byte, config ResizeConfig) ([]byte, error) {
+				t.Fatal("estimate must not process images")
+				return nil, nil
+			},
+		}
+		return NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, mockStorage, mockProcessor, testutil.TestConfig())
+	}
+
+	tests := []struct {
+		name           string
+		resolution     string
+		mode           string
+		expectedMode   string
+		output         models.DimensionInfo
+		content        models.DimensionInfo
+		aspectHandling string
+	}{
+		{"smart_fit pads", "800x800", "smart_fit", "smart_fit", models.DimensionInfo{Width: 800, Height: 800}, models.DimensionInfo{Width: 800, Height: 450}, "padded"},
+		{"crop trims", "800x800", "crop", "crop", models.DimensionInfo{Width: 800, Height: 800}, models.DimensionInfo{Width: 1422, Height: 800}, "cropped"},
+		{"stretch distorts", "800x800", "stretch", "stretch", models.DimensionInfo{Width: 800, Height: 800}, models.DimensionInfo{Width: 800, Height: 800}, "stretched"},
+		{"default mode from config", "960x540", "", "smart_fit", models.DimensionInfo{Width: 960, Height: 540}, models.DimensionInfo{Width: 960, Height: 540}, "exact"},
+		{"aliased resolution", "800x800:square", "crop", "crop", models.DimensionInfo{Width: 800, Height: 800}, models.DimensionInfo{Width: 1422, Height: 800}, "cropped"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newService(t)
+
+			estimate, err := service.EstimateResize(context.Background(), testutil.ValidUUID, tt.resolution, tt.mode)
+
+			assert.NoError(t, err)
+			assert.Equal(t, testutil.ValidUUID, estimate.ImageID)
+			assert.Equal(t, tt.expectedMode, estimate.Mode)
+			assert.Equal(t, models.DimensionInfo{Width: 1920, Height: 1080}, estimate.Source)
+			assert.Equal(t, tt.output, estimate.Output)
+			assert.Equal(t, tt.content, estimate.Content)
+			assert.Equal(t, tt.aspectHandling, estimate.AspectHandling)
+		})
+	}
+
+	t.Run("invalid inputs", func(t *testing.T) {
+		service := newService(t)
+
+		for _, input := range []struct{ resolution, mode string }{
+			{"not-a-resolution", ""},
+			{"9000x9000", ""},
+			{"800x600", "zoom"},
+		} {
+			_, err := service.EstimateResize(context.Background(), testutil.ValidUUID, input.resolution, input.mode)
+			assert.Error(t, err)
+			assert.IsType(t, models.ValidationError{}, err)
+		}
+	})
+}
+
+func TestImageService_ResizeConfig(t *testing.T) {
+	cfg := &config.Config{
+		Image: config.ImageConfig{
+			Quality:    90,
+			ResizeMode: "crop",
+		},
+	}
+
+	service := &ImageServiceImpl{config: cfg}
+
+	// Test the resize configuration creation
+	resolutionConfig := models.ResolutionConfig{Width: 800, Height: 600}
+	resizeConfig := ResizeConfig{
+		Width:   resolutionConfig.Width,
+		Height:  resolutionConfig.Height,
+		Quality: service.config.Image.Quality,
+		Format:  "image/jpeg",
+		Mode:    ResizeMode(service.config.Image.ResizeMode),
+	}
+
+	assert.Equal(t, 800, resizeConfig.Width)
+	assert.Equal(t, 600, resizeConfig.Height)
+	assert.Equal(t, 90, resizeConfig.Quality)
+	assert.Equal(t, "image/jpeg", resizeConfig.Format)
+	assert.Equal(t, ResizeMode("crop"), resizeConfig.Mode)
+}
+
+func TestUploadInput_Validation(t *testing.T) {
+	testData := testutil.CreateTestImageData()
+
+	validInput := UploadInput{
+		Filename:    "test.jpg",
+		Data:        testData,
+		Size:        int64(len(testData)),
+		Resolutions: []string{"800x600"},
+	}
+
+	// Test valid input structure
+	assert.Equal(t, "test.jpg", validInput.Filename)
+	assert.Equal(t, testData, validInput.Data)
+	assert.Equal(t, int64(len(testData)), validInput.Size)
+	assert.Equal(t, []string{"800x600"}, validInput.Resolutions)
+}
+
+func TestUploadResult_Structure(t *testing.T) {
+	result := &UploadResult{
+		ImageID:              testutil.ValidUUID,
+		ProcessedResolutions: []string{"thumbnail", "800x600"},
+		OriginalSize:         102400,
+		ProcessedSizes: map[string]int64{
+			"thumbnail": 5000,
+			"800x600":   15000,
+		},
+	}
+
+	assert.Equal(t, testutil.ValidUUID, result.ImageID)
+	assert.Contains(t, result.ProcessedResolutions, "thumbnail")
+	assert.Equal(t, int64(102400), result.OriginalSize)
+	assert.Equal(t, int64(5000), result.ProcessedSizes["thumbnail"])
+}
+
+func TestImageService_DeleteResolution(t *testing.T) {
+	t.Run("successful_deletion", func(t *testing.T) {
+		mockRepo := &testutil.MockImageRepository{
+			GetFunc: func(ctx context.Context, id string) (*models.ImageMetadata, error) {
+				return &models.ImageMetadata{
+					ID:          id,
+					MimeType:    "image/jpeg",
+					Resolutions: []string{"original", "800x600", "thumbnail"},
+				}, nil
+			},
+			UpdateFunc: func(ctx context.Context, metadata *models.ImageMetadata) error {
+				return nil
+			},
+		}
+
+		mockDeduplicationRepo := &testutil.MockDeduplicationRepository{}
+		mockStorage := &testutil.MockStorageProvider{
+			DeleteFunc: func(ctx context.Context, key string) error {
+				return nil
+			},
+		}
+		mockProcessor := &testProcessorService{}
+
+		service := NewImageService(mockRepo, mockDeduplicationRepo, mockStorage, mockProcessor, testConfig())
+
+		err := service.DeleteResolution(context.Background(), testutil.ValidUUID, "800x600")
+		assert.NoError(t, err)
+	})
+
+	t.Run("image_not_found", func(t *testing.T) {
+		mockRepo := &testutil.MockImageRepository{
+			GetFunc: func(ctx context.Context, id string) (*models.ImageMetadata, error) {
+				return nil, models.NotFoundError{Resource: "image", ID: id}
+			},
+		}
+
+		mockDeduplicationRepo := &testutil.MockDeduplicationRepository{}
+		mockStorage := &testutil.MockStorageProvider{}
+		mockProcessor := &testProcessorService{}
+
+		service := NewImageService(mockRepo, mockDeduplicationRepo, mockStorage, mockProcessor, testConfig())
+
+		err := service.DeleteResolution(context.Background(), testutil.ValidUUID, "800x600")
+		assert.Error(t, err)
+		var notFoundErr models.NotFoundError
+		assert.ErrorAs(t, err, &notFoundErr)
+	})
+
+	t.Run("resolution_not_found", func(t *testing.T) {
+		mockRepo := &testutil.MockImageRepository{
+			GetFunc: func(ctx context.Context, id string) (*models.ImageMetadata, error) {
+				return &models.ImageMetadata{
+					ID:          id,
+					Resolutions: []string{"original", "thumbnail"},
+				}, nil
+			},
+		}
+
+		mockDeduplicationRepo := &testutil.MockDeduplicationRepository{}
+		mockStorage := &testutil.MockStorageProvider{}
+		mockProcessor := &testProcessorService{}
+
+		service := NewImageService(mockRepo, mockDeduplicationRepo, mockStorage, mockProcessor, testConfig())
+
+		err := service.DeleteResolution(context.Background(), testutil.ValidUUID, "800x600")
+		assert.Error(t, err)
+		var notFoundErr models.NotFoundError
+		assert.ErrorAs(t, err, &notFoundErr)
+	})
+
+	t.Run("cannot_delete_original", func(t *testing.T) {
+		mockRepo := &testutil.MockImageRepository{
+			GetFunc: func(ctx context.Context, id string) (*models.ImageMetadata, error) {
+				return &models.ImageMetadata{
+					ID:          id,
+					Resolutions: []string{"original", "800x600"},
+				}, nil
+			},
+		}
+
+		mockDeduplicationRepo := &testutil.MockDeduplicationRepository{}
+		mockStorage := &testutil.MockStorageProvider{}
+		mockProcessor := &testProcessorService{}
+
+		service := NewImageService(mockRepo, mockDeduplicationRepo, mockStorage, mockProcessor, testConfig())
+
+		err := service.DeleteResolution(context.Background(), testutil.ValidUUID, "original")
+		assert.Error(t, err)
+		var validationErr models.ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+	})
+
+	t.Run("retry_after_storage_delete_failed", func(t *testing.T) {
+		stored := &models.ImageMetadata{
+			ID:          testutil.ValidUUID,
+			MimeType:    "image/jpeg",
+			Resolutions: []string{"original", "800x600", "thumbnail"},
+		}
+		fileKey := stored.GetStorageKey("800x600")
+		files := map[string]bool{fileKey: true}
+		storageDown := true
+
+		mockRepo := &testutil.MockImageRepository{
+			GetFunc: func(ctx context.Context, id string) (*models.ImageMetadata, error) {
+				copied := *stored
+				copied.Resolutions = append([]string(nil), stored.Resolutions...)
+				return &copied, nil
+			},
+			UpdateFunc: func(ctx context.Context, metadata *models.ImageMetadata) error {
+				stored = metadata
+				return nil
+			},
+		}
+		mockStorage := &testutil.MockStorageProvider{
+			ExistsFunc: func(ctx context.Context, key string) (bool, error) {
+				return files[key], nil
+			},
+			DeleteFunc: func(ctx context.Context, key string) error {
+				if storageDown {
+					return errors.New("storage unavailable")
+				}
+				delete(files, key)
+				return nil
+			},
+		}
+
+		service := NewImageService(mockRepo, &testutil.MockDeduplicationRepository{}, mockStorage, &testProcessorService{}, testConfig())
+
+		// First attempt updates the metadata but leaves the file behind
+		require.NoError(t, service.DeleteResolution(context.Background(), testutil.ValidUUID, "800x600"))
+		assert.NotContains(t, stored.Resolutions, "800x600")
+		assert.True(t, files[fileKey])
+
+		// The retry cleans up the leftover file
+		storageDown = false
+		require.NoError(t, service.DeleteResolution(context.Background(), testutil.ValidUUID, "800x600"))
+		assert.Empty(t, files)
+
+		// With nothing left to delete the resolution is not found
+		err := service.DeleteResolution(context.Background(), testutil.ValidUUID, "800x600")
+		var notFoundErr models.NotFoundError
+		assert.ErrorAs(t, err, &notFoundErr)
+	})
+
+	t.Run("retry_keeps_file_listed_by_sharing_image", func(t *testing.T) {
+		const otherID = "a1b2c3d4-0000-4000-8000-000000000001"
+		hash := models.ImageHash{Algorithm: "SHA256", Value: "abc123", Size: 2048}
+		images := map[string]*models.ImageMetadata{
+			testutil.ValidUUID: {ID: testutil.ValidUUID, MimeType: "image/jpeg", Hash: hash, Resolutions: []string{"original"}},
+			otherID:            {ID: otherID, MimeType: "image/jpeg", Hash: hash, Resolutions: []string{"original", "800x600"}},
+		}
+
+		mockRepo := &testutil.MockImageRepository{
+			GetFunc: func(ctx context.Context, id string) (*models.ImageMetadata, error) {
+				return images[id], nil
+			},
+		}
+		mockDedupRepo := &testutil.MockDeduplicationRepository{
+			GetDeduplicationInfoFunc: func(ctx context.Context, h models.ImageHash) (*models.DeduplicationInfo, error) {
+				return &models.DeduplicationInfo{Hash: hash, ReferencingIDs: []string{otherID, testutil.ValidUUID}}, nil
+			},
+		}
+		deleted := false
+		mockStorage := &testutil.MockStorageProvider{
+			ExistsFunc: func(ctx context.Context, key string) (bool, error) {
+				return true, nil
+			},
+			DeleteFunc: func(ctx context.Context, key string) error {
+				deleted = true
+				return nil
+			},
+		}
+
+		service := NewImageService(mockRepo, mockDedupRepo, mockStorage, &testProcessorService{}, testConfig())
+
+		err := service.DeleteResolution(context.Background(), testutil.ValidUUID, "800x600")
+		var notFoundErr models.NotFoundError
+		assert.ErrorAs(t, err, &notFoundErr)
+		assert.False(t, deleted)
+	})
+
+	t.Run("retry_with_unlisted_alias_is_not_found", func(t *testing.T) {
+		mockRepo := &testutil.MockImageRepository{
+			GetFunc: func(ctx context.Context, id string) (*models.ImageMetadata, error) {
+				return &models.ImageMetadata{ID: id, MimeType: "image/jpeg", Resolutions: []string{"original"}}, nil
+			},
+		}
+		mockStorage := &testutil.MockStorageProvider{
+			ExistsFunc: func(ctx context.Context, key string) (bool, error) {
+				t.Fatalf("unexpected storage lookup for %s", key)
+				return false, nil
+			},
+		}
+
+		service := NewImageService(mockRepo, &testutil.MockDeduplicationRepository{}, mockStorage, &testProcessorService{}, testConfig())
+
+		err := service.DeleteResolution(context.Background(), testutil.ValidUUID, "small")
+		var notFoundErr models.NotFoundError
+		assert.ErrorAs(t, err, &notFoundErr)
+	})
+}
+
+func TestImageService_GenerateUniqueImageID(t *testing.T) {
+	t.Run("generates unique UUID on first attempt", func(t *testing.T) {
+		mockRepo := &testutil.MockImageRepository{
+			ExistsFunc: func(ctx context.Context, id string) (bool, error) {
+				return false, nil // UUID doesn't exist, so it's unique
+			},
+		}
+
+		service := &ImageServiceImpl{repo: mockRepo}
+
+		imageID, err := service.generateUniqueImageID(context.Background())
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, imageID)
+		assert.Len(t, imageID, 36) // Standard UUID length with hyphens
+	})
+
+	t.Run("handles UUID collision and regenerates", func(t *testing.T) {
+		callCount := 0
+		mockRepo := &testutil.MockImageRepository{
+			ExistsFunc: func(ctx context.Context, id string) (bool, error) {
+				callCount++
+				if callCount <= 2 {
+					return true, nil // First two UUIDs collide
+				}
+				return false, nil // Third UUID is unique
+			},
+		}
+
+		service := &ImageServiceImpl{repo: mockRepo}
+
+		imageID, err := service.generateUniqueImageID(context.Background())
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, imageID)
+		assert.Equal(t, 3, callCount) // Should have tried 3 times
+	})
+
+	t.Run("fails after max attempts", func(t *testing.T) {
+		mockRepo := &testutil.MockImageRepository{
+			ExistsFunc: func(ctx context.Context, id string) (bool, error) {
+				return true, nil // All UUIDs collide
+			},
+		}
+
+		service := &ImageServiceImpl{repo: mockRepo}
+
+		imageID, err := service.generateUniqueImageID(context.Background())
+
+		assert.Error(t, err)
+		assert.Empty(t, imageID)
+		assert.Contains(t, err.Error(), "failed to generate unique UUID after 10 attempts")
+	})
+
+	t.Run("handles repository error", func(t *testing.T) {
+		mockRepo := &testutil.MockImageRepository{
+			ExistsFunc: func(ctx context.Context, id string) (bool, error) {
+				return false, errors.New("database connection failed")
+			},
+		}
+
+		service := &ImageServiceImpl{repo: mockRepo}
+
+		imageID, err := service.generateUniqueImageID(context.Background())
+
+		assert.Error(t, err)
 		assert.Empty(t, imageID)
 		assert.Contains(t, err.Error(), "failed to check UUID existence")
 	})
-}
+}
+
+func TestImageService_ProcessUpload_WithUUIDCollisionDetection(t *testing.T) {
+	t.Run("upload succeeds with UUID collision detection", func(t *testing.T) {
+		callCount := 0
+		mockRepo := &testutil.MockImageRepository{
+			ExistsFunc: func(ctx context.Context, id string) (bool, error) {
+				callCount++
+				if callCount == 1 {
+					return true, nil // First UUID collides
+				}
+				return false, nil // Second UUID is unique
+			},
+			StoreFunc: func(ctx context.Context, img *models.ImageMetadata) error {
+				return nil
+			},
+		}
+
+		mockDeduplicationRepo := &testutil.MockDeduplicationRepository{
+			FindImageByHashFunc: func(ctx context.Context, hash models.ImageHash) (*models.DeduplicationInfo, error) {
+				return nil, models.NotFoundError{Resource: "deduplication_info", ID: hash.String()}
+			},
+			StoreDeduplicationInfoFunc: func(ctx context.Context, info *models.DeduplicationInfo) error {
+				return nil
+			},
+		}
+
+		mockStorage := &testutil.MockStorageProvider{
+			UploadFunc: func(ctx context.Context, key string, data io.Reader, contentType string) error {
+				return nil
+			},
+		}
+
+		mockProcessor := &testProcessorService{}
+
+		service := NewImageService(mockRepo, mockDeduplicationRepo, mockStorage, mockProcessor, testConfig())
+
+		testData := testutil.CreateTestImageData()
+		input := UploadInput{
+			Filename:    "test.jpg",
+			Data:        testData,
+			Size:        int64(len(testData)),
+			Resolutions: []string{},
+		}
+
+		result, err := service.ProcessUpload(context.Background(), input)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, result)
+		assert.NotEmpty(t, result.ImageID)
+		assert.Equal(t, 2, callCount) // Should have checked existence twice due to collision
+	})
+}
+
+func TestImageService_ProcessUpload_SourceAndResolutionCaps(t *testing.T) {
+	cfg := testutil.TestConfig()
+	cfg.Image.GenerateDefaultResolutions = false
+	cfg.Image.MaxWidth = 100
+	cfg.Image.MaxHeight = 100
+	cfg.Image.MaxSourceWidth = 400
+	cfg.Image.MaxSourceHeight = 400
+	cfg.Canvas.BackgroundColor = "#FFFFFF"
+
+	var saved *models.ImageMetadata
+	newService := func() ImageService {
+		return NewImageService(
+			&mockImageRepositoryForImageService{
+				saveFunc: func(ctx context.Context, metadata *models.ImageMetadata) error {
+					saved = metadata
+					return nil
+				},
+			},
+			&mockDeduplicationRepositoryForImageService{},
+			&mockStorageProviderForImageService{},
+			NewProcessorService(cfg.Image.MaxWidth, cfg.Image.MaxHeight, cfg.Image.MaxSourceWidth, cfg.Image.MaxSourceHeight),
+			cfg,
+		)
+	}
+
+	// Original larger than the resolution cap, within the source cap
+	largeOriginal := testutil.CreateTestPNG(300, 200)
+
+	t.Run("large original accepted", func(t *testing.T) {
+		result, err := newService().ProcessUpload(context.Background(), UploadInput{
+			Filename:    "large.png",
+			Data:        largeOriginal,
+			Size:        int64(len(largeOriginal)),
+			Resolutions: []string{"80x80"},
+		})
+
+		assert.NoError(t, err)
+		assert.NotNil(t, result)
+		assert.Contains(t, result.ProcessedResolutions, "80x80")
+		if assert.NotNil(t, saved) {
+			assert.Equal(t, 300, saved.Width)
+			assert.Equal(t, 200, saved.Height)
+		}
+	})
+
+	t.Run("oversized requested resolution rejected", func(t *testing.T) {
+		_, err := newService().ProcessUpload(context.Background(), UploadInput{
+			Filename:    "large.png",
+			Data:        largeOriginal,
+			Size:        int64(len(largeOriginal)),
+			Resolutions: []string{"200x200"},
+		})
+
+		var validationErr models.ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "resolutions", validationErr.Field)
+	})
+
+	t.Run("original above source cap rejected", func(t *testing.T) {
+		tooLarge := testutil.CreateTestPNG(500, 100)
+		_, err := newService().ProcessUpload(context.Background(), UploadInput{
+			Filename: "huge.png",
+			Data:     tooLarge,
+			Size:     int64(len(tooLarge)),
+		})
+
+		var processingErr models.ProcessingError
+		assert.ErrorAs(t, err, &processingErr)
+		assert.Contains(t, processingErr.Reason, "exceed maximum allowed 400x400")
+	})
+}
+
+func TestImageService_ProcessUpload_FormatNotAccepted(t *testing.T) {
+	cfg := testutil.TestConfig()
+	cfg.Image.SupportedFormats = []string{"image/png"}
+
+	mockProcessor := &mockProcessorServiceForImageService{
+		detectFormatFunc: func(data []byte) (string, error) {
+			return "image/tiff", nil
+		},
+	}
+	service := NewImageService(&mockImageRepositoryForImageService{}, &mockDeduplicationRepositoryForImageService{}, &mockStorageProviderForImageService{}, mockProcessor, cfg)
+
+	_, err := service.ProcessUpload(context.Background(), UploadInput{
+		Filename: "scan.tif",
+		Data:     testutil.CreateTestImageData(),
+		Size:     int64(len(testutil.CreateTestImageData())),
+	})
+
+	var validationErr models.ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "file", validationErr.Field)
+	assert.Contains(t, validationErr.Message, "image/tiff")
+}
+
+func TestImageService_ProcessUpload_ExtensionMismatch(t *testing.T) {
+	pngData := testutil.CreateTestPNG(320, 240)
+
+	tests := []struct {
+		name             string
+		filename         string
+		reject           bool
+		expectRejected   bool
+		expectedMismatch *models.ContentTypeMismatch
+	}{
+		{
+			name:     "mismatch is flagged",
+			filename: "photo.JPG",
+			expectedMismatch: &models.ContentTypeMismatch{
+				Extension:         ".jpg",
+				ExtensionMimeType: "image/jpeg",
+				DetectedMimeType:  "image/png",
+			},
+		},
+		{
+			name:     "matching extension",
+			filename: "photo.png",
+		},
+		{
+			name:     "unknown extension",
+			filename: "photo.bin",
+			reject:   true,
+		},
+		{
+			name:           "mismatch is rejected when configured",
+			filename:       "photo.jpg",
+			reject:         true,
+			expectRejected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testutil.TestConfig()
+			cfg.Image.GenerateDefaultResolutions = false
+			cfg.Image.RejectExtensionMismatch = tt.reject
+
+			var originalContentType string
+			mockStorage := &mockStorageProviderForImageService{
+				uploadFunc: func(ctx context.Context, key string, data io.Reader, size int64, contentType string) error {
+					if strings.Contains(key, "/original.") {
+						originalContentType = contentType
+					}
+					return nil
+				},
+			}
+			service := NewImageService(&mockImageRepositoryForImageService{}, &mockDeduplicationRepositoryForImageService{}, mockStorage, NewProcessorService(4096, 4096, 8192, 8192), cfg)
+
+			result, err := service.ProcessUpload(context.Background(), UploadInput{
+				Filename: tt.filename,
+				Data:     pngData,
+				Size:     int64(len(pngData)),
+			})
+
+			if tt.expectRejected {
+				var validationErr models.ValidationError
+				require.ErrorAs(t, err, &validationErr)
+				assert.Equal(t, "file", validationErr.Field)
+				assert.Contains(t, validationErr.Message, "image/png")
+				assert.Empty(t, originalContentType, "nothing is stored")
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedMismatch, result.ContentTypeMismatch)
+			assert.Equal(t, "image/png", originalContentType, "the sniffed type is stored")
+		})
+	}
+}
+
+func TestImageService_ProcessUpload_MinimumDimensions(t *testing.T) {
+	tests := []struct {
+		name    string
+		width   int
+		height  int
+		wantErr bool
+	}{
+		{"at the minimum", 200, 100, false},
+		{"above the minimum", 1920, 1080, false},
+		{"width below the minimum", 199, 100, true},
+		{"height below the minimum", 200, 99, true},
+		{"postage stamp", 16, 16, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testutil.TestConfig()
+			cfg.Image.MinSourceWidth = 200
+			cfg.Image.MinSourceHeight = 100
+
+			mockProcessor := &mockProcessorServiceForImageService{
+				getDimensionsFunc: func(data []byte) (int, int, error) {
+					return tt.width, tt.height, nil
+				},
+				processImageFunc: func(data []byte, config ResizeConfig) ([]byte, error) {
+					return testutil.CreateTestImageData(), nil
+				},
+			}
+			mockRepo := &testutil.MockImageRepository{
+				StoreFunc: func(ctx context.Context, metadata *models.ImageMetadata) error {
+					return nil
+				},
+			}
+			service := NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, &mockStorageProviderForImageService{}, mockProcessor, cfg)
+
+			_, err := service.ProcessUpload(context.Background(), UploadInput{
+				Filename: "test.jpg",
+				Data:     testutil.CreateTestImageData(),
+				Size:     int64(len(testutil.CreateTestImageData())),
+			})
+
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+			var validationErr models.ValidationError
+			assert.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, "file", validationErr.Field)
+			assert.Contains(t, validationErr.Message, "below the minimum of 200x100")
+		})
+	}
+}
+
+func TestImageService_ProcessUpload_AspectRatios(t *testing.T) {
+	tests := []struct {
+		name      string
+		ratios    []string
+		tolerance float64
+		width     int
+		height    int
+		wantErr   bool
+	}{
+		{"no allowlist accepts anything", nil, 0.01, 1000, 333, false},
+		{"exact square", []string{"1:1", "16:9"}, 0.01, 800, 800, false},
+		{"exact 16:9", []string{"1:1", "16:9"}, 0.01, 1920, 1080, false},
+		{"16:9 within tolerance", []string{"16:9"}, 0.01, 1366, 768, false},
+		{"portrait does not match landscape ratio", []string{"4:3"}, 0.01, 600, 800, true},
+		{"outside tolerance", []string{"16:9"}, 0.01, 1900, 1000, true},
+		{"wider tolerance accepts it", []string{"16:9"}, 0.10, 1900, 1000, false},
+		{"zero tolerance rejects near miss", []string{"16:9"}, 0, 1366, 768, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testutil.TestConfig()
+			cfg.Image.AllowedAspectRatios = tt.ratios
+			cfg.Image.AspectRatioTolerance = tt.tolerance
+
+			mockProcessor := &mockProcessorServiceForImageService{
+				getDimensionsFunc: func(data []byte) (int, int, error) {
+					return tt.width, tt.height, nil
+				},
+				processImageFunc: func(data []byte, config ResizeConfig) ([]byte, error) {
+					return testutil.CreateTestImageData(), nil
+				},
+			}
+			mockRepo := &testutil.MockImageRepository{
+				StoreFunc: func(ctx context.Context, metadata *models.ImageMetadata) error {
+					return nil
+				},
+			}
+			service := NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, &mockStorageProviderForImageService{}, mockProcessor, cfg)
+
+			_, err := service.ProcessUpload(context.Background(), UploadInput{
+				Filename: "test.jpg",
+				Data:     testutil.CreateTestImageData(),
+				Size:     int64(len(testutil.CreateTestImageData())),
+			})
+
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+			var validationErr models.ValidationError
+			assert.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, "file", validationErr.Field)
+			assert.Contains(t, validationErr.Message, "aspect ratio")
+			assert.Contains(t, validationErr.Message, strings.Join(tt.ratios, ", "))
+		})
+	}
+}
+
+func TestImageService_ProcessUpload_TIFFStoresPNGDerivatives(t *testing.T) {
+	cfg := testutil.TestConfig()
+	cfg.Image.GenerateDefaultResolutions = false
+	cfg.Canvas.BackgroundColor = "#FFFFFF"
+
+	var tiffBuf bytes.Buffer
+	assert.NoError(t, tiff.Encode(&tiffBuf, image.NewRGBA(image.Rect(0, 0, 320, 240)), nil))
+	tiffData := tiffBuf.Bytes()
+
+	uploads := make(map[string]string)
+	mockStorage := &mockStorageProviderForImageService{
+		uploadFunc: func(ctx context.Context, key string, data io.Reader, size int64, contentType string) error {
+			uploads[key] = contentType
+			return nil
+		},
+	}
+	service := NewImageService(&mockImageRepositoryForImageService{}, &mockDeduplicationRepositoryForImageService{}, mockStorage, NewProcessorService(4096, 4096, 8192, 8192), cfg)
+
+	result, err := service.ProcessUpload(context.Background(), UploadInput{
+		Filename:    "scan.tif",
+		Data:        tiffData,
+		Size:        int64(len(tiffData)),
+		Resolutions: []string{"160x120"},
+	})
+
+	assert.NoError(t, err)
+	assert.Contains(t, result.ProcessedResolutions, "160x120")
+	assert.Equal(t, "image/tiff", uploads[fmt.Sprintf("images/%s/original.tif", result.ImageID)])
+	assert.Equal(t, "image/png", uploads[fmt.Sprintf("images/%s/160x120.png", result.ImageID)])
+}
+
+func TestImageService_ProcessUpload_RecordsResolutionDimensions(t *testing.T) {
+	cfg := testutil.TestConfig()
+	cfg.Image.GenerateDefaultResolutions = false
+	cfg.Image.ResizeMode = string(ResizeModeSmartFit)
+	cfg.Canvas.BackgroundColor = "#FFFFFF"
+
+	processor := NewProcessorService(4096, 4096, 8192, 8192)
+	uploads := make(map[string][]byte)
+	mockStorage := &mockStorageProviderForImageService{
+		uploadFunc: func(ctx context.Context, key string, data io.Reader, size int64, contentType string) error {
+			body, err := io.ReadAll(data)
+			uploads[key] = body
+			return err
+		},
+	}
+	var saved *models.ImageMetadata
+	mockRepo := &testutil.MockImageRepository{
+		StoreFunc: func(ctx context.Context, metadata *models.ImageMetadata) error {
+			saved = metadata
+			return nil
+		},
+	}
+	service := NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, mockStorage, processor, cfg)
+
+	source := testutil.CreateTestPNG(300, 100)
+	result, err := service.ProcessUpload(context.Background(), UploadInput{
+		Filename:    "wide.png",
+		Data:        source,
+		Size:        int64(len(source)),
+		Resolutions: []string{"120x90:card", "50x200"},
+	})
+
+	assert.NoError(t, err)
+	if !assert.NotNil(t, saved) {
+		return
+	}
+
+	for _, resolution := range []string{"120x90:card", "50x200"} {
+		dimensions := models.ExtractDimensions(resolution)
+		width, height, err := processor.GetDimensions(uploads[fmt.Sprintf("images/%s/%s.png", result.ImageID, dimensions)])
+		assert.NoError(t, err)
+
+		recorded, ok := saved.GetResolutionDimensions(resolution)
+		assert.True(t, ok)
+		assert.Equal(t, models.DimensionInfo{Width: width, Height: height}, recorded)
+		assert.Equal(t, recorded, saved.ResolutionDimensions[dimensions])
+	}
+}
+
+func TestImageService_ProcessUpload_PerResolutionQuality(t *testing.T) {
+	cfg := testutil.TestConfig()
+	cfg.Image.Quality = 85
+	cfg.Image.GenerateDefaultResolutions = true
+	cfg.Image.DefaultResolutions = map[string]config.ResolutionConfig{
+		"thumbnail": {Width: 150, Height: 150, Quality: 70},
+	}
+	cfg.Image.ResolutionQuality = map[string]int{"1920x1080": 90}
+
+	var mu sync.Mutex
+	qualities := make(map[string]int)
+	mockProcessor := &mockProcessorServiceForImageService{
+		processImageFunc: func(data []byte, config ResizeConfig) ([]byte, error) {
+			mu.Lock()
+			qualities[fmt.Sprintf("%dx%d", config.Width, config.Height)] = config.Quality
+			mu.Unlock()
+			return testutil.CreateTestImageData(), nil
+		},
+	}
+	mockRepo := &testutil.MockImageRepository{
+		StoreFunc: func(ctx context.Context, metadata *models.ImageMetadata) error {
+			return nil
+		},
+	}
+	service := NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, &mockStorageProviderForImageService{}, mockProcessor, cfg)
+
+	_, err := service.ProcessUpload(context.Background(), UploadInput{
+		Filename:    "test.jpg",
+		Data:        testutil.CreateTestImageData(),
+		Size:        int64(len(testutil.CreateTestImageData())),
+		Resolutions: []string{"1920x1080:hero", "800x600"},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{
+		"150x150":   70,
+		"1920x1080": 90,
+		"800x600":   85,
+	}, qualities)
+}
+
+func TestImageService_ProcessUpload_DefaultResolutionsByType(t *testing.T) {
+	tests := []struct {
+		name     string
+		mimeType string
+		filename string
+		want     []string
+	}{
+		{name: "jpeg gets the thumbnail and a preview", mimeType: "image/jpeg", filename: "photo.jpg", want: []string{"thumbnail", "800x600", "1200x900"}},
+		{name: "png skips the thumbnail", mimeType: "image/png", filename: "diagram.png", want: []string{"1200x900"}},
+		{name: "unlisted gif falls back to the global flag", mimeType: "image/gif", filename: "anim.gif", want: []string{"thumbnail", "1200x900"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testutil.TestConfig()
+			cfg.Image.GenerateDefaultResolutions = true
+			cfg.Image.DefaultResolutionsByType = map[string][]string{
+				"image/jpeg": {"thumbnail", "800x600"},
+				"image/png":  {},
+			}
+
+			mockProcessor := &mockProcessorServiceForImageService{
+				detectFormatFunc: func(data []byte) (string, error) {
+					return tt.mimeType, nil
+				},
+				processImageFunc: func(data []byte, config ResizeConfig) ([]byte, error) {
+					return testutil.CreateTestImageData(), nil
+				},
+			}
+			mockRepo := &testutil.MockImageRepository{
+				StoreFunc: func(ctx context.Context, metadata *models.ImageMetadata) error {
+					return nil
+				},
+			}
+			service := NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, &mockStorageProviderForImageService{}, mockProcessor, cfg)
+
+			result, err := service.ProcessUpload(context.Background(), UploadInput{
+				Filename:    tt.filename,
+				Data:        testutil.CreateTestImageData(),
+				Size:        int64(len(testutil.CreateTestImageData())),
+				Resolutions: []string{"1200x900"},
+			})
+
+			require.NoError(t, err)
+			assert.ElementsMatch(t, tt.want, result.ProcessedResolutions)
+		})
+	}
+}
+
+func TestImageService_ProcessUpload_MetadataFailureCleansUpWrittenObjects(t *testing.T) {
+	cfg := testutil.TestConfig()
+	cfg.Canvas.BackgroundColor = "#FFFFFF"
+
+	var uploaded, deleted []string
+	mockStorage := &mockStorageProviderForImageService{
+		uploadFunc: func(ctx context.Context, key string, data io.Reader, size int64, contentType string) error {
+			uploaded = append(uploaded, key)
+			return nil
+		},
+		deleteFunc: func(ctx context.Context, key string) error {
+			deleted = append(deleted, key)
+			return nil
+		},
+	}
+	mockRepo := &testutil.MockImageRepository{
+		StoreFunc: func(ctx context.Context, metadata *models.ImageMetadata) error {
+			return errors.New("redis unavailable")
+		},
+	}
+	service := NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, mockStorage, NewProcessorService(4096, 4096, 8192, 8192), cfg)
+
+	source := testutil.CreateTestPNG(200, 100)
+	_, err := service.ProcessUpload(context.Background(), UploadInput{
+		Filename:    "banner.png",
+		Data:        source,
+		Size:        int64(len(source)),
+		Resolutions: []string{"80x60:small"},
+	})
+
+	var storageErr models.StorageError
+	assert.ErrorAs(t, err, &storageErr)
+	assert.Equal(t, "store_metadata", storageErr.Operation)
+
+	if assert.Len(t, uploaded, 3) {
+		imageID := strings.Split(uploaded[0], "/")[1]
+		assert.ElementsMatch(t, []string{
+			fmt.Sprintf("images/%s/original.png", imageID),
+			fmt.Sprintf("images/%s/thumbnail.png", imageID),
+			fmt.Sprintf("images/%s/80x60.png", imageID),
+		}, uploaded)
+	}
+	assert.ElementsMatch(t, uploaded, deleted)
+}
+
+func TestImageService_ProcessUpload_PartialFailureModes(t *testing.T) {
+	failingProcessor := func() *mockProcessorServiceForImageService {
+		return &mockProcessorServiceForImageService{
+			processImageFunc: func(data []byte, config ResizeConfig) ([]byte, error) {
+				if config.Width == 80 {
+					return nil, errors.New("encoder crashed")
+				}
+				return testutil.CreateTestImageData(), nil
+			},
+		}
+	}
+	input := UploadInput{
+		Filename:    "test.jpg",
+		Data:        testutil.CreateTestImageData(),
+		Size:        int64(len(testutil.CreateTestImageData())),
+		Resolutions: []string{"800x600", "80x60"},
+	}
+
+	t.Run("continue reports failed resolutions", func(t *testing.T) {
+		cfg := testutil.TestConfig()
+		cfg.Image.UploadPartialFailureMode = "continue"
+
+		var saved *models.ImageMetadata
+		mockRepo := &testutil.MockImageRepository{
+			StoreFunc: func(ctx context.Context, metadata *models.ImageMetadata) error {
+				saved = metadata
+				return nil
+			},
+		}
+		service := NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, &mockStorageProviderForImageService{}, failingProcessor(), cfg)
+
+		result, err := service.ProcessUpload(context.Background(), input)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"80x60"}, result.FailedResolutions)
+		assert.Contains(t, result.ProcessedResolutions, "800x600")
+		assert.NotContains(t, result.ProcessedResolutions, "80x60")
+		require.NotNil(t, saved)
+		assert.False(t, saved.HasResolution("80x60"))
+	})
 
-func TestImageService_ProcessUpload_WithUUIDCollisionDetection(t *testing.T) {
-	t.Run("upload succeeds with UUID collision detection", func(t *testing.T) {
-		callCount := 0
+	t.Run("fail aborts and cleans up the upload", func(t *testing.T) {
+		cfg := testutil.TestConfig()
+		cfg.Image.UploadPartialFailureMode = "fail"
+
+		var uploaded, deleted []string
+		mockStorage := &mockStorageProviderForImageService{
+			uploadFunc: func(ctx context.Context, key string, data io.Reader, size int64, contentType string) error {
+				uploaded = append(uploaded, key)
+				return nil
+			},
+			deleteFunc: func(ctx context.Context, key string) error {
+				deleted = append(deleted, key)
+				return nil
+			},
+		}
+		stored := false
 		mockRepo := &testutil.MockImageRepository{
-			ExistsFunc: func(ctx context.Context, id string) (bool, error) {
-				callCount++
-				if callCount == 1 {
-					return true, nil // First UUID collides
+			StoreFunc: func(ctx context.Context, metadata *models.ImageMetadata) error {
+				stored = true
+				return nil
+			},
+		}
+		var dedupInfo *models.DeduplicationInfo
+		dedupDeleted := false
+		dedupRepo := &testutil.MockDeduplicationRepository{
+			StoreDeduplicationInfoFunc: func(ctx context.Context, info *models.DeduplicationInfo) error {
+				dedupInfo = info
+				return nil
+			},
+			GetDeduplicationInfoFunc: func(ctx context.Context, hash models.ImageHash) (*models.DeduplicationInfo, error) {
+				if dedupInfo == nil {
+					return nil, models.NotFoundError{Resource: "deduplication_info", ID: hash.String()}
 				}
-				return false, nil // Second UUID is unique
+				return dedupInfo, nil
 			},
-			StoreFunc: func(ctx context.Context, img *models.ImageMetadata) error {
+			FindImageByHashFunc: func(ctx context.Context, hash models.ImageHash) (*models.DeduplicationInfo, error) {
+				return nil, models.NotFoundError{Resource: "deduplication_info", ID: hash.String()}
+			},
+			DeleteDeduplicationInfoFunc: func(ctx context.Context, hash models.ImageHash) error {
+				dedupDeleted = true
 				return nil
 			},
 		}
+		service := NewImageService(mockRepo, dedupRepo, mockStorage, failingProcessor(), cfg)
 
-		mockDeduplicationRepo := &testutil.MockDeduplicationRepository{
-			FindImageByHashFunc: func(ctx context.Context, hash models.ImageHash) (*models.DeduplicationInfo, error) {
-				return nil, models.NotFoundError{Resource: "deduplication_info", ID: hash.String()}
+		result, err := service.ProcessUpload(context.Background(), input)
+
+		assert.Nil(t, result)
+		var processingErr models.ProcessingError
+		require.ErrorAs(t, err, &processingErr)
+		assert.Equal(t, "process_resolution", processingErr.Operation)
+		assert.Contains(t, processingErr.Reason, "80x60")
+		assert.False(t, stored)
+		assert.NotEmpty(t, uploaded)
+		assert.ElementsMatch(t, uploaded, deleted)
+		assert.True(t, dedupDeleted)
+	})
+}
+
+func TestImageService_CropImage(t *testing.T) {
+	type recorder struct {
+		uploads []string
+		configs []ResizeConfig
+		updated *models.ImageMetadata
+	}
+
+	newService := func(metadata *models.ImageMetadata, rec *recorder) ImageService {
+		mockRepo := &mockImageRepositoryForImageService{
+			getByIDFunc: func(ctx context.Context, id string) (*models.ImageMetadata, error) {
+				return metadata, nil
 			},
-			StoreDeduplicationInfoFunc: func(ctx context.Context, info *models.DeduplicationInfo) error {
+			updateFunc: func(ctx context.Context, metadata *models.ImageMetadata) error {
+				rec.updated = metadata
+				return nil
+			},
+		}
+		mockStorage := &mockStorageProviderForImageService{
+			downloadFunc: func(ctx context.Context, key string) (io.ReadCloser, error) {
+				return testutil.NewMockReadCloser(testutil.CreateTestImageData()), nil
+			},
+			uploadFunc: func(ctx context.Context, key string, data io.Reader, size int64, contentType string) error {
+				rec.uploads = append(rec.uploads, key)
 				return nil
 			},
 		}
+		mockProcessor := &mockProcessorServiceForImageService{
+			processImageFunc: func(data []byte, config ResizeConfig) ([]byte, error) {
+				rec.configs = append(rec.configs, config)
+				return testutil.CreateTestImageData(), nil
+			},
+		}
+		return NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, mockStorage, mockProcessor, testutil.TestConfig())
+	}
 
-		mockStorage := &testutil.MockStorageProvider{
-			UploadFunc: func(ctx context.Context, key string, data io.Reader, contentType string) error {
+	t.Run("stores the region as a new resolution", func(t *testing.T) {
+		metadata := testutil.CreateTestImageMetadata()
+		rec := &recorder{}
+		rect := models.CropRect{X: 100, Y: 50, Width: 640, Height: 480}
+
+		resolution, err := newService(metadata, rec).CropImage(context.Background(), testutil.ValidUUID, rect)
+
+		require.NoError(t, err)
+		assert.Equal(t, "crop-100-50-640x480", resolution)
+		assert.Equal(t, []string{"images/" + metadata.ID + "/crop-100-50-640x480.jpg"}, rec.uploads)
+		if assert.Len(t, rec.configs, 1) {
+			assert.Equal(t, &rect, rec.configs[0].Crop)
+			assert.Equal(t, "jpeg", rec.configs[0].Format)
+		}
+		if assert.NotNil(t, rec.updated) {
+			assert.True(t, rec.updated.HasResolution(resolution))
+			dimensions, ok := rec.updated.GetResolutionDimensions(resolution)
+			assert.True(t, ok)
+			assert.Equal(t, models.DimensionInfo{Width: 640, Height: 480}, dimensions)
+		}
+	})
+
+	t.Run("existing crop is returned without processing", func(t *testing.T) {
+		metadata := testutil.CreateTestImageMetadata()
+		metadata.Resolutions = append(metadata.Resolutions, "crop-0-0-100x100")
+		rec := &recorder{}
+
+		resolution, err := newService(metadata, rec).CropImage(context.Background(), testutil.ValidUUID, models.CropRect{Width: 100, Height: 100})
+
+		require.NoError(t, err)
+		assert.Equal(t, "crop-0-0-100x100", resolution)
+		assert.Empty(t, rec.uploads)
+		assert.Nil(t, rec.updated)
+	})
+
+	for _, rect := range []models.CropRect{
+		{X: 1800, Y: 0, Width: 200, Height: 200},
+		{X: 0, Y: 1000, Width: 200, Height: 100},
+		{X: -10, Y: 0, Width: 200, Height: 200},
+		{X: 0, Y: 0, Width: 0, Height: 200},
+	} {
+		t.Run("rejects "+rect.String(), func(t *testing.T) {
+			rec := &recorder{}
+
+			_, err := newService(testutil.CreateTestImageMetadata(), rec).CropImage(context.Background(), testutil.ValidUUID, rect)
+
+			var validationErr models.ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, "crop", validationErr.Field)
+			assert.Empty(t, rec.uploads)
+			assert.Nil(t, rec.updated)
+		})
+	}
+}
+
+func TestImageService_ProcessResolutionWithinSize(t *testing.T) {
+	type recorder struct {
+		uploads []string
+		configs []ResizeConfig
+		updated *models.ImageMetadata
+	}
+
+	newService := func(metadata *models.ImageMetadata, rec *recorder) ImageService {
+		mockRepo := &mockImageRepositoryForImageService{
+			getByIDFunc: func(ctx context.Context, id string) (*models.ImageMetadata, error) {
+				return metadata, nil
+			},
+			updateFunc: func(ctx context.Context, metadata *models.ImageMetadata) error {
+				rec.updated = metadata
+				return nil
+			},
+		}
+		mockStorage := &mockStorageProviderForImageService{
+			downloadFunc: func(ctx context.Context, key string) (io.ReadCloser, error) {
+				return testutil.NewMockReadCloser(testutil.CreateTestImageData()), nil
+			},
+			uploadFunc: func(ctx context.Context, key string, data io.Reader, size int64, contentType string) error {
+				rec.uploads = append(rec.uploads, key)
 				return nil
 			},
 		}
+		mockProcessor := &mockProcessorServiceForImageService{
+			processImageFunc: func(data []byte, config ResizeConfig) ([]byte, error) {
+				rec.configs = append(rec.configs, config)
+				return testutil.CreateTestImageData(), nil
+			},
+		}
+		return NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, mockStorage, mockProcessor, testutil.TestConfig())
+	}
 
-		mockProcessor := &testProcessorService{}
+	t.Run("stores the capped variant as its own resolution", func(t *testing.T) {
+		metadata := testutil.CreateTestImageMetadata()
+		rec := &recorder{}
 
-		service := NewImageService(mockRepo, mockDeduplicationRepo, mockStorage, mockProcessor, testConfig())
+		resolution, err := newService(metadata, rec).ProcessResolutionWithinSize(context.Background(), testutil.ValidUUID, "800x600", 102400, false)
 
-		testData := testutil.CreateTestImageData()
-		input := UploadInput{
-			Filename:    "test.jpg",
-			Data:        testData,
-			Size:        int64(len(testData)),
-			Resolutions: []string{},
+		require.NoError(t, err)
+		assert.Equal(t, "800x600-max102400", resolution)
+		assert.Equal(t, []string{"images/" + metadata.ID + "/800x600-max102400.jpg"}, rec.uploads)
+		if assert.Len(t, rec.configs, 1) {
+			assert.Equal(t, int64(102400), rec.configs[0].MaxBytes)
+			assert.Equal(t, 800, rec.configs[0].Width)
+			assert.Equal(t, 600, rec.configs[0].Height)
+		}
+		if assert.NotNil(t, rec.updated) {
+			assert.True(t, rec.updated.HasResolution(resolution))
+		}
+	})
+
+	t.Run("existing variant is returned without processing", func(t *testing.T) {
+		metadata := testutil.CreateTestImageMetadata()
+		metadata.Resolutions = append(metadata.Resolutions, "800x600-max102400")
+		rec := &recorder{}
+
+		resolution, err := newService(metadata, rec).ProcessResolutionWithinSize(context.Background(), testutil.ValidUUID, "800x600", 102400, false)
+
+		require.NoError(t, err)
+		assert.Equal(t, "800x600-max102400", resolution)
+		assert.Empty(t, rec.configs)
+		assert.Nil(t, rec.updated)
+	})
+
+	tests := []struct {
+		name       string
+		resolution string
+		maxBytes   int64
+		mimeType   string
+		field      string
+	}{
+		{name: "budget too small", resolution: "800x600", maxBytes: 100, field: "max_bytes"},
+		{name: "budget above max file size", resolution: "800x600", maxBytes: 1 << 40, field: "max_bytes"},
+		{name: "alias", resolution: "800x600:hero", maxBytes: 102400, field: "resolution"},
+		{name: "invalid resolution", resolution: "huge", maxBytes: 102400, field: "resolution"},
+		{name: "lossless derivative", resolution: "800x600", maxBytes: 102400, mimeType: "image/png", field: "max_bytes"},
+	}
+	for _, tt := range tests {
+		t.Run("rejects "+tt.name, func(t *testing.T) {
+			metadata := testutil.CreateTestImageMetadata()
+			if tt.mimeType != "" {
+				metadata.MimeType = tt.mimeType
+			}
+			rec := &recorder{}
+
+			_, err := newService(metadata, rec).ProcessResolutionWithinSize(context.Background(), testutil.ValidUUID, tt.resolution, tt.maxBytes, false)
+
+			var validationErr models.ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tt.field, validationErr.Field)
+			assert.Empty(t, rec.uploads)
+		})
+	}
+}
+
+func TestImageService_GetStorageKeys(t *testing.T) {
+	const masterID = "650e8400-e29b-41d4-a716-446655440000"
+
+	newService := func(metadata *models.ImageMetadata, hashKeys bool) ImageService {
+		repo := &mockImageRepositoryForImageService{
+			getByIDFunc: func(ctx context.Context, id string) (*models.ImageMetadata, error) {
+				return metadata, nil
+			},
+		}
+		cfg := testutil.TestConfig()
+		cfg.S3.KeyHashPrefix = hashKeys
+		return NewImageService(repo, &mockDeduplicationRepositoryForImageService{}, &mockStorageProviderForImageService{}, &mockProcessorServiceForImageService{}, cfg)
+	}
+
+	t.Run("standalone image", func(t *testing.T) {
+		metadata := testutil.CreateTestImageMetadata()
+		metadata.Resolutions = []string{"thumbnail", "800x600:small"}
+
+		keys, err := newService(metadata, false).GetStorageKeys(context.Background(), metadata.ID)
+
+		require.NoError(t, err)
+		assert.Equal(t, metadata.ID, keys.ID)
+		assert.False(t, keys.Deduplicated)
+		assert.Empty(t, keys.SharedImageID)
+		assert.Equal(t, "images/"+metadata.ID+"/original.jpg", keys.Original)
+		assert.Equal(t, map[string]string{
+			"thumbnail":     "images/" + metadata.ID + "/thumbnail.jpg",
+			"800x600:small": "images/" + metadata.ID + "/800x600.jpg",
+		}, keys.Resolutions)
+	})
+
+	t.Run("deduplicated image points at the master files", func(t *testing.T) {
+		metadata := testutil.CreateTestImageMetadata()
+		metadata.IsDeduped = true
+		metadata.SharedImageID = masterID
+		metadata.Resolutions = []string{"thumbnail"}
+
+		keys, err := newService(metadata, false).GetStorageKeys(context.Background(), metadata.ID)
+
+		require.NoError(t, err)
+		assert.True(t, keys.Deduplicated)
+		assert.Equal(t, masterID, keys.SharedImageID)
+		assert.Equal(t, "images/"+masterID+"/original.jpg", keys.Original)
+		assert.Equal(t, "images/"+masterID+"/thumbnail.jpg", keys.Resolutions["thumbnail"])
+	})
+
+	t.Run("hashed keys carry the partition", func(t *testing.T) {
+		metadata := testutil.CreateTestImageMetadata()
+		metadata.Resolutions = []string{"thumbnail"}
+
+		keys, err := newService(metadata, true).GetStorageKeys(context.Background(), metadata.ID)
+
+		require.NoError(t, err)
+		assert.Equal(t, storage.HashedKey("images/"+metadata.ID+"/original.jpg"), keys.Original)
+		assert.Equal(t, storage.HashedKey("images/"+metadata.ID+"/thumbnail.jpg"), keys.Resolutions["thumbnail"])
+		assert.NotEqual(t, "images/"+metadata.ID+"/original.jpg", keys.Original)
+	})
+}
+
+func TestImageService_VerifyImage(t *testing.T) {
+	original := []byte("original image bytes")
+
+	newService := func(metadata *models.ImageMetadata, missing ...string) ImageService {
+		repo := &mockImageRepositoryForImageService{
+			getByIDFunc: func(ctx context.Context, id string) (*models.ImageMetadata, error) {
+				return metadata, nil
+			},
+		}
+		storageMock := &mockStorageProviderForImageService{
+			existsFunc: func(ctx context.Context, key string) (bool, error) {
+				return !slices.Contains(missing, key), nil
+			},
+			downloadFunc: func(ctx context.Context, key string) (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(original)), nil
+			},
+		}
+		return NewImageService(repo, &mockDeduplicationRepositoryForImageService{}, storageMock, &mockProcessorServiceForImageService{}, testutil.TestConfig())
+	}
+
+	t.Run("intact image", func(t *testing.T) {
+		metadata := testutil.CreateTestImageMetadata()
+		metadata.Hash = models.CalculateImageHash(original)
+
+		report, err := newService(metadata).VerifyImage(context.Background(), metadata.ID, true)
+
+		require.NoError(t, err)
+		assert.True(t, report.Intact)
+		assert.True(t, report.HashChecked)
+		assert.True(t, report.Original.Exists)
+		assert.Empty(t, report.Original.Problem)
+		assert.Len(t, report.Resolutions, 2)
+		for _, file := range report.Resolutions {
+			assert.True(t, file.Exists)
+			assert.Empty(t, file.Problem)
+		}
+	})
+
+	t.Run("missing derivative", func(t *testing.T) {
+		metadata := testutil.CreateTestImageMetadata()
+		missingKey := "images/" + metadata.ID + "/800x600.jpg"
+
+		report, err := newService(metadata, missingKey).VerifyImage(context.Background(), metadata.ID, false)
+
+		require.NoError(t, err)
+		assert.False(t, report.Intact)
+		assert.False(t, report.HashChecked)
+		assert.True(t, report.Original.Exists)
+		assert.True(t, report.Resolutions["thumbnail"].Exists)
+		assert.Equal(t, models.FileVerification{Key: missingKey, Problem: "file is missing from storage"}, report.Resolutions["800x600"])
+	})
+
+	t.Run("original no longer matches its hash", func(t *testing.T) {
+		metadata := testutil.CreateTestImageMetadata()
+		metadata.Hash = models.CalculateImageHash([]byte("different bytes"))
+
+		report, err := newService(metadata).VerifyImage(context.Background(), metadata.ID, true)
+
+		require.NoError(t, err)
+		assert.False(t, report.Intact)
+		assert.True(t, report.HashChecked)
+		assert.Contains(t, report.Original.Problem, "hash mismatch")
+	})
+}
+
+func TestImageService_FindByFilename(t *testing.T) {
+	secondID := "650e8400-e29b-41d4-a716-446655440000"
+	index := map[string][]string{
+		"banner.jpg": {testutil.ValidUUID},
+		"photo.jpg":  {testutil.ValidUUID, secondID},
+	}
+	repo := &mockImageRepositoryForImageService{
+		findByFilenameFunc: func(ctx context.Context, filename string) ([]string, error) {
+			if filename == "broken.jpg" {
+				return nil, errors.New("connection refused")
+			}
+			return index[filename], nil
+		},
+	}
+	service := NewImageService(repo, &mockDeduplicationRepositoryForImageService{}, &mockStorageProviderForImageService{}, &mockProcessorServiceForImageService{}, testutil.TestConfig())
+	ctx := context.Background()
+
+	result, err := service.FindByFilename(ctx, "banner.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, &models.FilenameLookupResponse{Filename: "banner.jpg", ImageIDs: []string{testutil.ValidUUID}}, result)
+
+	result, err = service.FindByFilename(ctx, "photo.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, []string{testutil.ValidUUID, secondID}, result.ImageIDs)
+
+	_, err = service.FindByFilename(ctx, "missing.jpg")
+	var notFound models.NotFoundError
+	assert.ErrorAs(t, err, &notFound)
+
+	_, err = service.FindByFilename(ctx, " ")
+	var validationErr models.ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "filename", validationErr.Field)
+
+	_, err = service.FindByFilename(ctx, "broken.jpg")
+	var storageErr models.StorageError
+	assert.ErrorAs(t, err, &storageErr)
+}
+
+func TestImageService_FindByHash(t *testing.T) {
+	data := testutil.CreateTestImageData()
+	stored := models.CalculateImageHash(data)
+	masterID := testutil.ValidUUID
+	copyID := "6ba7b810-9dad-41d1-80b4-00c04fd430c8"
+
+	newService := func(existing map[string]bool) ImageService {
+		dedupRepo := &testutil.MockDeduplicationRepository{
+			FindImageByHashFunc: func(ctx context.Context, hash models.ImageHash) (*models.DeduplicationInfo, error) {
+				if hash.Value != stored.Value || hash.Namespace != "" {
+					return nil, models.NotFoundError{Resource: "deduplication_info", ID: hash.String()}
+				}
+				info := models.NewDeduplicationInfo(stored, masterID, "images/"+masterID+"/original.jpg")
+				info.AddReference(copyID)
+				return info, nil
+			},
+		}
+		mockRepo := &mockImageRepositoryForImageService{
+			existsFunc: func(ctx context.Context, id string) (bool, error) {
+				return existing[id], nil
+			},
+		}
+		return NewImageService(mockRepo, dedupRepo, &mockStorageProviderForImageService{}, &mockProcessorServiceForImageService{}, testutil.TestConfig())
+	}
+
+	tests := []struct {
+		name       string
+		checksum   string
+		size       int64
+		namespace  string
+		existing   map[string]bool
+		wantExists bool
+		wantID     string
+	}{
+		{"present hash", stored.Value, 0, "", map[string]bool{masterID: true}, true, masterID},
+		{"present hash with prefix and matching size", "sha256:" + strings.ToUpper(stored.Value), stored.Size, "", map[string]bool{masterID: true}, true, masterID},
+		{"present hash with different size", stored.Value, stored.Size + 1, "", map[string]bool{masterID: true}, false, ""},
+		{"deleted master falls back to a remaining copy", stored.Value, 0, "", map[string]bool{copyID: true}, true, copyID},
+		{"absent hash", strings.Repeat("0", 64), 0, "", map[string]bool{masterID: true}, false, ""},
+		{"other namespace", stored.Value, 0, "tenant-a", map[string]bool{masterID: true}, false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := newService(tt.existing).FindByHash(context.Background(), tt.checksum, tt.size, tt.namespace)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantExists, result.Exists)
+			assert.Equal(t, tt.wantID, result.ImageID)
+		})
+	}
+
+	t.Run("malformed hash", func(t *testing.T) {
+		_, err := newService(nil).FindByHash(context.Background(), "abc", 0, "")
+
+		var validationErr models.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "hash", validationErr.Field)
+	})
+}
+
+func TestImageService_ProcessUpload_ProcessingQueue(t *testing.T) {
+	newService := func(maxDepth int, saved **models.ImageMetadata, uploads *int) *ImageServiceImpl {
+		cfg := testutil.TestConfig()
+		cfg.Image.GenerateDefaultResolutions = false
+		cfg.Queue = config.QueueConfig{Enabled: true, Workers: 1, MaxDepth: maxDepth}
+
+		mockRepo := &mockImageRepositoryForImageService{
+			saveFunc: func(ctx context.Context, metadata *models.ImageMetadata) error {
+				*saved = metadata
+				return nil
+			},
+			getByIDFunc: func(ctx context.Context, id string) (*models.ImageMetadata, error) {
+				return *saved, nil
+			},
+		}
+		mockStorage := &mockStorageProviderForImageService{
+			uploadFunc: func(ctx context.Context, key string, data io.Reader, size int64, contentType string) error {
+				*uploads++
+				return nil
+			},
+			downloadFunc: func(ctx context.Context, key string) (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(testutil.CreateTestImageData())), nil
+			},
+		}
+		mockProcessor := &mockProcessorServiceForImageService{
+			processImageFunc: func(data []byte, config ResizeConfig) ([]byte, error) {
+				return testutil.CreateTestImageData(), nil
+			},
 		}
 
+		return NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, mockStorage, mockProcessor, cfg).(*ImageServiceImpl)
+	}
+	data := testutil.CreateTestImageData()
+	input := UploadInput{Filename: "photo.jpg", Data: data, Size: int64(len(data)), Resolutions: []string{"800x600", "800x600"}}
+
+	t.Run("upload returns before resolutions are processed", func(t *testing.T) {
+		var saved *models.ImageMetadata
+		uploads := 0
+		service := newService(4, &saved, &uploads)
+
 		result, err := service.ProcessUpload(context.Background(), input)
 
-		assert.NoError(t, err)
-		assert.NotNil(t, result)
-		assert.NotEmpty(t, result.ImageID)
-		assert.Equal(t, 2, callCount) // Should have checked existence twice due to collision
+		require.NoError(t, err)
+		assert.Equal(t, []string{"800x600"}, result.QueuedResolutions)
+		assert.Empty(t, result.ProcessedResolutions)
+		assert.Equal(t, 1, uploads, "only the original should be stored in the request")
+		assert.Equal(t, 1, service.queue.Stats().Depth)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		service.StartProcessingQueue(ctx)
+
+		require.Eventually(t, func() bool { return service.queue.Stats().Processed == 1 }, time.Second, time.Millisecond)
+		assert.Equal(t, 0, service.queue.Stats().Depth)
+		assert.True(t, saved.HasResolution("800x600"))
+	})
+
+	t.Run("full queue rejects the upload before storing anything", func(t *testing.T) {
+		var saved *models.ImageMetadata
+		uploads := 0
+		service := newService(1, &saved, &uploads)
+		require.True(t, service.queue.Reserve())
+
+		_, err := service.ProcessUpload(context.Background(), input)
+
+		var queueErr models.QueueFullError
+		require.ErrorAs(t, err, &queueErr)
+		assert.Equal(t, 1, queueErr.Capacity)
+		assert.Nil(t, saved)
+		assert.Zero(t, uploads)
+		assert.Equal(t, int64(1), service.queue.Stats().Rejected)
+	})
+
+	t.Run("upload with nothing to queue frees its slot", func(t *testing.T) {
+		var saved *models.ImageMetadata
+		uploads := 0
+		service := newService(1, &saved, &uploads)
+
+		result, err := service.ProcessUpload(context.Background(), UploadInput{Filename: "photo.jpg", Data: data, Size: int64(len(data))})
+
+		require.NoError(t, err)
+		assert.Empty(t, result.QueuedResolutions)
+		assert.Equal(t, 0, service.queue.Stats().Depth)
+	})
+}
+
+func TestImageService_ProcessUpload_CanonicalOriginalFormat(t *testing.T) {
+	newService := func(format string, saved **models.ImageMetadata, stored map[string][]byte) *ImageServiceImpl {
+		cfg := testutil.TestConfig()
+		cfg.Image.GenerateDefaultResolutions = false
+		cfg.Image.CanonicalOriginalFormat = format
+
+		mockRepo := &mockImageRepositoryForImageService{
+			saveFunc: func(ctx context.Context, metadata *models.ImageMetadata) error {
+				*saved = metadata
+				return nil
+			},
+		}
+		mockStorage := &mockStorageProviderForImageService{
+			uploadFunc: func(ctx context.Context, key string, data io.Reader, size int64, contentType string) error {
+				content, err := io.ReadAll(data)
+				stored[key] = content
+				return err
+			},
+		}
+		processor := NewProcessorService(4096, 4096, 8192, 8192)
+
+		return NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, mockStorage, processor, cfg).(*ImageServiceImpl)
+	}
+	pngData := testutil.CreateTestPNG(320, 240)
+	input := UploadInput{Filename: "photo.png", Data: pngData, Size: int64(len(pngData))}
+
+	t.Run("png upload is stored as webp", func(t *testing.T) {
+		var saved *models.ImageMetadata
+		stored := map[string][]byte{}
+		service := newService("webp", &saved, stored)
+
+		result, err := service.ProcessUpload(context.Background(), input)
+
+		require.NoError(t, err)
+		data, ok := stored[fmt.Sprintf("images/%s/original.webp", result.ImageID)]
+		require.True(t, ok, "original should be stored with a .webp key")
+		assert.Equal(t, "RIFF", string(data[:4]))
+		assert.Equal(t, "WEBP", string(data[8:12]))
+
+		width, height, err := service.processor.GetDimensions(data)
+		require.NoError(t, err)
+		assert.Equal(t, 320, width)
+		assert.Equal(t, 240, height)
+
+		require.NotNil(t, saved)
+		assert.Equal(t, "image/webp", saved.MimeType)
+		assert.Equal(t, "photo.webp", saved.Filename)
+		assert.Equal(t, int64(len(data)), saved.Size)
+	})
+
+	t.Run("unset stores the upload as-is", func(t *testing.T) {
+		var saved *models.ImageMetadata
+		stored := map[string][]byte{}
+		service := newService("", &saved, stored)
+
+		result, err := service.ProcessUpload(context.Background(), input)
+
+		require.NoError(t, err)
+		assert.Equal(t, pngData, stored[fmt.Sprintf("images/%s/original.png", result.ImageID)])
+		assert.Equal(t, "image/png", saved.MimeType)
+		assert.Equal(t, "photo.png", saved.Filename)
+	})
+}
+
+func TestImageService_ProcessFormatVariant(t *testing.T) {
+	source := testutil.CreateTestPNG(320, 240)
+	newMetadata := func() *models.ImageMetadata {
+		return models.NewImageMetadata(testutil.ValidUUID, "photo.png", "image/png", int64(len(source)), 320, 240)
+	}
+	metadata := newMetadata()
+
+	originalKey := fmt.Sprintf("images/%s/original.png", testutil.ValidUUID)
+	stored := map[string][]byte{originalKey: source}
+	contentTypes := map[string]string{}
+	uploads, originalReads := 0, 0
+	mockRepo := &mockImageRepositoryForImageService{
+		getByIDFunc: func(ctx context.Context, id string) (*models.ImageMetadata, error) {
+			return metadata.Clone(), nil
+		},
+		updateFunc: func(ctx context.Context, updated *models.ImageMetadata) error {
+			metadata = updated.Clone()
+			return nil
+		},
+	}
+	mockStorage := &mockStorageProviderForImageService{
+		uploadFunc: func(ctx context.Context, key string, data io.Reader, size int64, contentType string) error {
+			uploads++
+			content, err := io.ReadAll(data)
+			stored[key] = content
+			contentTypes[key] = contentType
+			return err
+		},
+		downloadFunc: func(ctx context.Context, key string) (io.ReadCloser, error) {
+			if key == originalKey {
+				originalReads++
+			}
+			data, ok := stored[key]
+			if !ok {
+				return nil, fmt.Errorf("no object at %s", key)
+			}
+			return io.NopCloser(bytes.NewReader(data)), nil
+		},
+	}
+	cfg := testutil.TestConfig()
+	cfg.Canvas.BackgroundColor = "#FFFFFF"
+	processor := NewProcessorService(4096, 4096, 8192, 8192)
+	service := NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, mockStorage, processor, cfg)
+	ctx := context.Background()
+
+	t.Run("missing thumbnail is generated", func(t *testing.T) {
+		require.False(t, metadata.HasResolution("thumbnail"))
+
+		require.NoError(t, service.ProcessFormatVariant(ctx, testutil.ValidUUID, "thumbnail", "gif"))
+
+		assert.True(t, metadata.HasResolution("thumbnail"))
+		assert.True(t, metadata.HasFormatVariant("thumbnail", "gif"))
+		assert.NotEmpty(t, stored[fmt.Sprintf("images/%s/thumbnail.gif", testutil.ValidUUID)])
+	})
+
+	for _, tt := range []struct {
+		format      string
+		key         string
+		contentType string
+	}{
+		{"gif", "thumbnail.gif", "image/gif"},
+		{"jpeg", "thumbnail.jpg", "image/jpeg"},
+	} {
+		t.Run(tt.format, func(t *testing.T) {
+			require.NoError(t, service.ProcessFormatVariant(ctx, testutil.ValidUUID, "thumbnail", tt.format))
+
+			key := fmt.Sprintf("images/%s/%s", testutil.ValidUUID, tt.key)
+			data := stored[key]
+			require.NotEmpty(t, data)
+			assert.Equal(t, tt.contentType, contentTypes[key])
+			assert.True(t, metadata.HasFormatVariant("thumbnail", tt.format))
+
+			detected, err := processor.DetectFormat(data)
+			require.NoError(t, err)
+			assert.Equal(t, tt.contentType, detected)
+
+			stream, _, err := service.GetImageVariantStream(ctx, testutil.ValidUUID, "thumbnail", tt.format)
+			require.NoError(t, err)
+			served, err := io.ReadAll(stream)
+			require.NoError(t, err)
+			assert.Equal(t, data, served)
+		})
+	}
+
+	t.Run("second request is served from storage", func(t *testing.T) {
+		uploadsBefore, readsBefore := uploads, originalReads
+		key := fmt.Sprintf("images/%s/thumbnail.jpg", testutil.ValidUUID)
+		first := stored[key]
+
+		require.NoError(t, service.ProcessFormatVariant(ctx, testutil.ValidUUID, "thumbnail", "jpeg"))
+		stream, _, err := service.GetImageVariantStream(ctx, testutil.ValidUUID, "thumbnail", "jpeg")
+		require.NoError(t, err)
+		served, err := io.ReadAll(stream)
+		require.NoError(t, err)
+
+		assert.Equal(t, first, served)
+		assert.Equal(t, uploadsBefore, uploads, "nothing is stored again")
+		assert.Equal(t, readsBefore, originalReads, "the original is not converted again")
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		err := service.ProcessFormatVariant(ctx, testutil.ValidUUID, "thumbnail", "tiff")
+		var validationErr models.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "format", validationErr.Field)
+	})
+
+	t.Run("unknown image", func(t *testing.T) {
+		mockRepo.getByIDFunc = func(ctx context.Context, id string) (*models.ImageMetadata, error) {
+			return nil, models.NotFoundError{Resource: "image", ID: id}
+		}
+
+		err := service.ProcessFormatVariant(ctx, testutil.ValidUUID, "thumbnail", "png")
+		var notFound models.NotFoundError
+		require.ErrorAs(t, err, &notFound)
 	})
 }
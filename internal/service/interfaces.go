@@ -16,11 +16,89 @@ type ImageService interface {
 	// GetMetadata retrieves image metadata by ID
 	GetMetadata(ctx context.Context, imageID string) (*models.ImageMetadata, error)
 
+	// UpdateFilename renames an image without touching its stored files
+	UpdateFilename(ctx context.Context, imageID, filename string) (*models.ImageMetadata, error)
+
 	// GetImageStream retrieves image data as a stream
 	GetImageStream(ctx context.Context, imageID, resolution string) (io.ReadCloser, *models.ImageMetadata, error)
 
-	// ProcessResolution generates a specific resolution for an existing image
-	ProcessResolution(ctx context.Context, imageID, resolution string) error
+	// GetImageVariantStream retrieves a resolution in one of its format variants as a stream
+	GetImageVariantStream(ctx context.Context, imageID, resolution, format string) (io.ReadCloser, *models.ImageMetadata, error)
+
+	// GetImageFrame returns a single frame of a resolution as a still image and its content type
+	GetImageFrame(ctx context.Context, imageID, resolution string, frame int) ([]byte, string, *models.ImageMetadata, error)
+
+	// ProcessResolution generates a specific resolution for an existing image; force regenerates an existing one
+	ProcessResolution(ctx context.Context, imageID, resolution string, force bool) error
+
+	// ProcessResolutionWithinSize generates a resolution encoded to fit in maxBytes and returns its name
+	ProcessResolutionWithinSize(ctx context.Context, imageID, resolution string, maxBytes int64, force bool) (string, error)
+
+	// ProcessFormatVariant makes a resolution available in format, generating the resolution
+	// and the format variant when missing, so GetImageVariantStream can serve it
+	ProcessFormatVariant(ctx context.Context, imageID, resolution, format string) error
+
+	// AddResolutionToAll generates a resolution for every stored image that lacks it
+	AddResolutionToAll(ctx context.Context, resolution string) (*models.BulkResolutionResult, error)
+
+	// ExportMetadata writes every metadata record to w in the given format and returns how many were written
+	ExportMetadata(ctx context.Context, w io.Writer, format string) (int, error)
+
+	// ImportMetadata restores metadata records from NDJSON without touching stored files
+	ImportMetadata(ctx context.Context, r io.Reader) (*models.MetadataImportResult, error)
+
+	// FindCorruptMetadata reports the metadata records that cannot be decoded
+	FindCorruptMetadata(ctx context.Context) (*models.CorruptMetadataReport, error)
+
+	// RemoveCorruptMetadata deletes every metadata record that cannot be decoded
+	RemoveCorruptMetadata(ctx context.Context) (*models.CorruptMetadataReport, error)
+
+	// FindOrphanedHashes reports the deduplication records that no image references
+	FindOrphanedHashes(ctx context.Context) (*models.OrphanedHashReport, error)
+
+	// RemoveOrphanedHashes deletes every orphaned deduplication record and its lingering files
+	RemoveOrphanedHashes(ctx context.Context) (*models.OrphanedHashReport, error)
+
+	// StartOrphanCleanup runs RemoveOrphanedHashes periodically until ctx is cancelled
+	StartOrphanCleanup(ctx context.Context)
+
+	// RecordAccess counts a successful download of a resolution
+	RecordAccess(ctx context.Context, imageID, resolution string)
+
+	// GetAccessCounts returns the download counters of an image
+	GetAccessCounts(ctx context.Context, imageID string) (*models.AccessCounts, error)
+
+	// FlushAccessCounts persists the download counts buffered by RecordAccess
+	FlushAccessCounts(ctx context.Context)
+
+	// StartAccessCountFlush runs FlushAccessCounts periodically until ctx is cancelled
+	StartAccessCountFlush(ctx context.Context)
+
+	// StartProcessingQueue runs the upload processing workers until ctx is cancelled
+	StartProcessingQueue(ctx context.Context)
+
+	// FindByHash reports whether content with the SHA256 checksum is already stored in the namespace
+	FindByHash(ctx context.Context, checksum string, size int64, namespace string) (*models.ExistsResponse, error)
+
+	// FindByFilename returns the IDs of images uploaded under the original filename
+	FindByFilename(ctx context.Context, filename string) (*models.FilenameLookupResponse, error)
+
+	// GetStorageKeys returns the object keys the image's files are stored under
+	GetStorageKeys(ctx context.Context, imageID string) (*models.StorageKeysResponse, error)
+
+	// PurgeImageCache drops the cached metadata and presigned URLs of an image, so
+	// changes made to its storage directly are picked up
+	PurgeImageCache(ctx context.Context, imageID string) error
+
+	// VerifyImage checks that the image's stored files exist and, when checkHash is set,
+	// that the original still matches the recorded hash
+	VerifyImage(ctx context.Context, imageID string, checkHash bool) (*models.VerificationResponse, error)
+
+	// CropImage stores a region of the original as a new resolution and returns its name
+	CropImage(ctx context.Context, imageID string, rect models.CropRect) (string, error)
+
+	// EstimateResize predicts the output geometry of a resolution without generating it
+	EstimateResize(ctx context.Context, imageID, resolution, mode string) (*models.EstimateResponse, error)
 
 	// DeleteImage removes an image and all its resolutions
 	DeleteImage(ctx context.Context, imageID string) error
@@ -28,13 +106,30 @@ type ImageService interface {
 	// DeleteResolution removes a specific resolution from an image (except original)
 	DeleteResolution(ctx context.Context, imageID, resolution string) error
 
-	// ListImages retrieves paginated list of images
-	ListImages(ctx context.Context, offset, limit int) ([]*models.ImageMetadata, int, error)
+	// ListImages retrieves paginated list of images, optionally ordered by a models.ListSort* order
+	ListImages(ctx context.Context, offset, limit int, sortBy string) ([]*models.ImageMetadata, int, error)
+
+	// ListImagesByDedupStatus retrieves a page of the images matching a models.ListDedup* filter
+	// ("" lists every image), with the counts of every image per deduplication status
+	ListImagesByDedupStatus(ctx context.Context, filter string, offset, limit int, sortBy string) (*models.ImageList, error)
+
+	// TouchLastAccessed records that an image was accessed, throttled per image
+	TouchLastAccessed(ctx context.Context, imageID string)
 
 	// GeneratePresignedURL generates a pre-signed URL for direct access to storage
 	GeneratePresignedURL(ctx context.Context, storageKey string, duration time.Duration) (string, error)
 }
 
+// AuditService records write and admin operations for compliance
+type AuditService interface {
+	// Record logs an audit entry and stores it when persistence is enabled; failures
+	// to store are logged, never returned, so auditing cannot fail a request
+	Record(ctx context.Context, entry models.AuditEntry)
+
+	// List returns up to limit stored entries, newest first
+	List(ctx context.Context, limit int) (*models.AuditLogResponse, error)
+}
+
 // HealthService defines the interface for health checking
 type HealthService interface {
 	// CheckHealth performs comprehensive health check
@@ -42,6 +137,18 @@ type HealthService interface {
 
 	// GetMetrics retrieves system metrics
 	GetMetrics(ctx context.Context) (map[string]interface{}, error)
+
+	// ResetCacheStats zeroes the cache hit and miss counters behind cache_hit_ratio
+	ResetCacheStats(ctx context.Context) error
+
+	// IsReady reports whether dependencies have been confirmed healthy for traffic
+	IsReady() bool
+
+	// CheckReadiness runs the dependency checks once and updates the readiness state
+	CheckReadiness(ctx context.Context) bool
+
+	// StartReadinessChecks runs CheckReadiness periodically until ctx is cancelled
+	StartReadinessChecks(ctx context.Context)
 }
 
 // ProcessorService defines the interface for image processing
@@ -55,6 +162,12 @@ type ProcessorService interface {
 	// ProcessImage resizes image to specified resolution
 	ProcessImage(data []byte, config ResizeConfig) ([]byte, error)
 
+	// ExtractFrame encodes a single frame of an animated image as a still image
+	ExtractFrame(data []byte, index int, config ResizeConfig) ([]byte, error)
+
+	// ConvertFormat re-encodes a still image in another format at its original dimensions
+	ConvertFormat(data []byte, config ResizeConfig) ([]byte, error)
+
 	// ValidateImage checks if image data is valid
 	ValidateImage(data []byte, maxSize int64) error
 }
@@ -67,26 +180,87 @@ type UploadInput struct {
 	Data        []byte   `json:"-"`
 	Size        int64    `json:"size"`
 	Resolutions []string `json:"resolutions"`
+	// ExpectedChecksum is an optional client-provided SHA256 of Data (hex)
+	ExpectedChecksum string `json:"expected_checksum,omitempty"`
+	// DisableDedup stores an isolated copy instead of sharing identical content
+	DisableDedup bool `json:"disable_dedup,omitempty"`
+	// DisableWatermark skips the WATERMARK_TEXT overlay on every resolution of the image
+	DisableWatermark bool `json:"disable_watermark,omitempty"`
+	// Namespace scopes deduplication to a tenant; empty means global
+	Namespace string `json:"namespace,omitempty"`
 }
 
 // UploadResult represents the result of image upload
 type UploadResult struct {
 	ImageID              string           `json:"image_id"`
 	ProcessedResolutions []string         `json:"processed_resolutions"`
+	FailedResolutions    []string         `json:"failed_resolutions,omitempty"` // Resolutions skipped under the continue failure mode
+	QueuedResolutions    []string         `json:"queued_resolutions,omitempty"` // Resolutions left to the processing queue
 	OriginalSize         int64            `json:"original_size"`
 	ProcessedSizes       map[string]int64 `json:"processed_sizes"`
+
+	// ContentTypeMismatch is set when the filename extension disagrees with the sniffed content
+	ContentTypeMismatch *models.ContentTypeMismatch `json:"content_type_mismatch,omitempty"`
 }
 
 // ResizeConfig represents image resizing configuration
 type ResizeConfig struct {
-	Width           int        `json:"width"`
-	Height          int        `json:"height"`
-	Quality         int        `json:"quality"`
-	Format          string     `json:"format"`
-	Mode            ResizeMode `json:"mode"`
-	BackgroundColor string     `json:"background_color"`
+	Width           int              `json:"width"`
+	Height          int              `json:"height"`
+	Quality         int              `json:"quality"`
+	Format          string           `json:"format"`
+	Mode            ResizeMode       `json:"mode"`
+	BackgroundColor string           `json:"background_color"`
+	Filter          string           `json:"filter"`                 // Resampling filter (defaults to lanczos)
+	JPEGSubsampling string           `json:"jpeg_subsampling"`       // Chroma subsampling for JPEG output (defaults to 420)
+	Crop            *models.CropRect `json:"crop,omitempty"`         // Source region to extract at native size instead of resizing
+	MaxBytes        int64            `json:"max_bytes,omitempty"`    // Largest acceptable output; lowers JPEG/WebP quality to fit
+	Watermark       *TextWatermark   `json:"watermark,omitempty"`    // Text drawn onto the output; nil draws nothing
+	AutoFormats     []string         `json:"auto_formats,omitempty"` // Candidates encoded when Format is OutputFormatAuto
 }
 
+// OutputFormatAuto as ResizeConfig.Format encodes every AutoFormats candidate and keeps the smallest
+const OutputFormatAuto = "auto"
+
+// TextWatermark describes a text overlay drawn onto a processed image
+type TextWatermark struct {
+	Text     string  `json:"text"`
+	FontSize float64 `json:"font_size"` // Text height in pixels; shrunk to fit narrow outputs
+	Color    string  `json:"color"`     // HEX color of the text
+	Opacity  float64 `json:"opacity"`   // 0 (invisible) to 1 (opaque)
+	Position string  `json:"position"`  // One of the WatermarkPosition constants (defaults to bottom-right)
+}
+
+// Placements accepted in TextWatermark.Position
+const (
+	WatermarkPositionCenter      = "center"
+	WatermarkPositionTopLeft     = "top-left"
+	WatermarkPositionTopRight    = "top-right"
+	WatermarkPositionBottomLeft  = "bottom-left"
+	WatermarkPositionBottomRight = "bottom-right"
+)
+
+// Bounds of a resolution size budget (ResizeConfig.MaxBytes)
+const (
+	MinTargetSizeQuality = 10   // Lowest quality tried before giving up
+	MinTargetSizeBytes   = 1024 // Smallest budget accepted from clients
+)
+
+// Resampling filters accepted in ResizeConfig.Filter
+const (
+	ResampleFilterLanczos    = "lanczos"    // Highest quality, slowest
+	ResampleFilterBilinear   = "bilinear"   // Balanced quality and speed
+	ResampleFilterNearest    = "nearest"    // Fastest, blocky edges
+	ResampleFilterCatmullRom = "catmullrom" // Sharp cubic, faster than Lanczos
+)
+
+// Chroma subsampling modes accepted in ResizeConfig.JPEGSubsampling
+const (
+	JPEGSubsampling444 = "444" // Full chroma resolution, sharpest color edges
+	JPEGSubsampling422 = "422" // Chroma halved horizontally
+	JPEGSubsampling420 = "420" // Chroma halved in both directions, smallest files
+)
+
 // ResizeMode defines how image should be resized
 type ResizeMode string
 
@@ -96,6 +270,35 @@ const (
 	ResizeModeStretch  ResizeMode = "stretch"   // Stretch to exact dimensions
 )
 
+// IsValid reports whether the mode is one of the supported resize modes
+func (m ResizeMode) IsValid() bool {
+	switch m {
+	case ResizeModeSmartFit, ResizeModeCrop, ResizeModeStretch:
+		return true
+	default:
+		return false
+	}
+}
+
+// Aspect handling outcomes reported by ResizeGeometry
+const (
+	AspectHandlingExact     = "exact"     // Source aspect ratio matches the target
+	AspectHandlingPadded    = "padded"    // Fitted inside the target with background padding
+	AspectHandlingCropped   = "cropped"   // Scaled to cover the target and center-cropped
+	AspectHandlingStretched = "stretched" // Scaled to the target, distorting the aspect ratio
+)
+
+// ResizeGeometry describes how a source image maps onto a resize target
+type ResizeGeometry struct {
+	OutputWidth    int    // Final image width
+	OutputHeight   int    // Final image height
+	ResizedWidth   int    // Width of the scaled source before padding/cropping
+	ResizedHeight  int    // Height of the scaled source before padding/cropping
+	OffsetX        int    // Horizontal position of the scaled source in the output
+	OffsetY        int    // Vertical position of the scaled source in the output
+	AspectHandling string // One of the AspectHandling* values
+}
+
 // HealthStatus represents system health status
 type HealthStatus struct {
 	Services map[string]string `json:"services"`
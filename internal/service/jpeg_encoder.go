@@ -0,0 +1,389 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"math"
+	"math/bits"
+)
+
+// Baseline JPEG encoder with selectable chroma subsampling.
+//
+// image/jpeg always writes 4:2:0, which smears saturated edges on detailed
+// images. 4:2:0 output still goes through the standard library; 4:4:4 and
+// 4:2:2 are written here with the same Annex K quantization and Huffman tables.
+
+// jpegUnzig maps a zig-zag coefficient index to its natural (row-major) index
+var jpegUnzig = [64]int{
+	0, 1, 8, 16, 9, 2, 3, 10,
+	17, 24, 32, 25, 18, 11, 4, 5,
+	12, 19, 26, 33, 40, 48, 41, 34,
+	27, 20, 13, 6, 7, 14, 21, 28,
+	35, 42, 49, 56, 57, 50, 43, 36,
+	29, 22, 15, 23, 30, 37, 44, 51,
+	58, 59, 52, 45, 38, 31, 39, 46,
+	53, 60, 61, 54, 47, 55, 62, 63,
+}
+
+// jpegUnscaledQuant holds the Annex K.1 luminance and chrominance tables in zig-zag order
+var jpegUnscaledQuant = [2][64]byte{
+	{
+		16, 11, 12, 14, 12, 10, 16, 14,
+		13, 14, 18, 17, 16, 19, 24, 40,
+		26, 24, 22, 22, 24, 49, 35, 37,
+		29, 40, 58, 51, 61, 60, 57, 51,
+		56, 55, 64, 72, 92, 78, 64, 68,
+		87, 69, 55, 56, 80, 109, 81, 87,
+		95, 98, 103, 104, 103, 62, 77, 113,
+		121, 112, 100, 120, 92, 101, 103, 99,
+	},
+	{
+		17, 18, 18, 24, 21, 24, 47, 26,
+		26, 47, 99, 66, 56, 66, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+	},
+}
+
+// jpegHuffmanSpec is a DHT table: counts of codes per length and the coded values
+type jpegHuffmanSpec struct {
+	class byte // 0 = DC, 1 = AC
+	id    byte
+	count [16]byte
+	value []byte
+}
+
+// jpegHuffmanSpecs are the Annex K.3 tables: luminance DC/AC, chrominance DC/AC
+var jpegHuffmanSpecs = [4]jpegHuffmanSpec{
+	{0, 0, [16]byte{0, 1, 5, 1, 1, 1, 1, 1, 1, 0, 0, 0, 0, 0, 0, 0},
+		[]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}},
+	{1, 0, [16]byte{0, 2, 1, 3, 3, 2, 4, 3, 5, 5, 4, 4, 0, 0, 1, 125},
+		[]byte{
+			0x01, 0x02, 0x03, 0x00, 0x04, 0x11, 0x05, 0x12, 0x21, 0x31, 0x41, 0x06, 0x13, 0x51, 0x61, 0x07,
+			0x22, 0x71, 0x14, 0x32, 0x81, 0x91, 0xa1, 0x08, 0x23, 0x42, 0xb1, 0xc1, 0x15, 0x52, 0xd1, 0xf0,
+			0x24, 0x33, 0x62, 0x72, 0x82, 0x09, 0x0a, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x25, 0x26, 0x27, 0x28,
+			0x29, 0x2a, 0x34, 0x35, 0x36, 0x37, 0x38, 0x39, 0x3a, 0x43, 0x44, 0x45, 0x46, 0x47, 0x48, 0x49,
+			0x4a, 0x53, 0x54, 0x55, 0x56, 0x57, 0x58, 0x59, 0x5a, 0x63, 0x64, 0x65, 0x66, 0x67, 0x68, 0x69,
+			0x6a, 0x73, 0x74, 0x75, 0x76, 0x77, 0x78, 0x79, 0x7a, 0x83, 0x84, 0x85, 0x86, 0x87, 0x88, 0x89,
+			0x8a, 0x92, 0x93, 0x94, 0x95, 0x96, 0x97, 0x98, 0x99, 0x9a, 0xa2, 0xa3, 0xa4, 0xa5, 0xa6, 0xa7,
+			0xa8, 0xa9, 0xaa, 0xb2, 0xb3, 0xb4, 0xb5, 0xb6, 0xb7, 0xb8, 0xb9, 0xba, 0xc2, 0xc3, 0xc4, 0xc5,
+			0xc6, 0xc7, 0xc8, 0xc9, 0xca, 0xd2, 0xd3, 0xd4, 0xd5, 0xd6, 0xd7, 0xd8, 0xd9, 0xda, 0xe1, 0xe2,
+			0xe3, 0xe4, 0xe5, 0xe6, 0xe7, 0xe8, 0xe9, 0xea, 0xf1, 0xf2, 0xf3, 0xf4, 0xf5, 0xf6, 0xf7, 0xf8,
+			0xf9, 0xfa,
+		}},
+	{0, 1, [16]byte{0, 3, 1, 1, 1, 1, 1, 1, 1, 1, 1, 0, 0, 0, 0, 0},
+		[]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}},
+	{1, 1, [16]byte{0, 2, 1, 2, 4, 4, 3, 4, 7, 5, 4, 4, 0, 1, 2, 119},
+		[]byte{
+			0x00, 0x01, 0x02, 0x03, 0x11, 0x04, 0x05, 0x21, 0x31, 0x06, 0x12, 0x41, 0x51, 0x07, 0x61, 0x71,
+			0x13, 0x22, 0x32, 0x81, 0x08, 0x14, 0x42, 0x91, 0xa1, 0xb1, 0xc1, 0x09, 0x23, 0x33, 0x52, 0xf0,
+			0x15, 0x62, 0x72, 0xd1, 0x0a, 0x16, 0x24, 0x34, 0xe1, 0x25, 0xf1, 0x17, 0x18, 0x19, 0x1a, 0x26,
+			0x27, 0x28, 0x29, 0x2a, 0x35, 0x36, 0x37, 0x38, 0x39, 0x3a, 0x43, 0x44, 0x45, 0x46, 0x47, 0x48,
+			0x49, 0x4a, 0x53, 0x54, 0x55, 0x56, 0x57, 0x58, 0x59, 0x5a, 0x63, 0x64, 0x65, 0x66, 0x67, 0x68,
+			0x69, 0x6a, 0x73, 0x74, 0x75, 0x76, 0x77, 0x78, 0x79, 0x7a, 0x82, 0x83, 0x84, 0x85, 0x86, 0x87,
+			0x88, 0x89, 0x8a, 0x92, 0x93, 0x94, 0x95, 0x96, 0x97, 0x98, 0x99, 0x9a, 0xa2, 0xa3, 0xa4, 0xa5,
+			0xa6, 0xa7, 0xa8, 0xa9, 0xaa, 0xb2, 0xb3, 0xb4, 0xb5, 0xb6, 0xb7, 0xb8, 0xb9, 0xba, 0xc2, 0xc3,
+			0xc4, 0xc5, 0xc6, 0xc7, 0xc8, 0xc9, 0xca, 0xd2, 0xd3, 0xd4, 0xd5, 0xd6, 0xd7, 0xd8, 0xd9, 0xda,
+			0xe2, 0xe3, 0xe4, 0xe5, 0xe6, 0xe7, 0xe8, 0xe9, 0xea, 0xf2, 0xf3, 0xf4, 0xf5, 0xf6, 0xf7, 0xf8,
+			0xf9, 0xfa,
+		}},
+}
+
+// jpegDCTBasis[k][n] is the scaled cosine basis of the separable 8x8 forward DCT
+var jpegDCTBasis = func() (basis [8][8]float64) {
+	for k := 0; k < 8; k++ {
+		scale := 0.5
+		if k == 0 {
+			scale = 0.5 / math.Sqrt2
+		}
+		for n := 0; n < 8; n++ {
+			basis[k][n] = scale * math.Cos(float64(2*n+1)*float64(k)*math.Pi/16)
+		}
+	}
+	return basis
+}()
+
+// jpegSamplingFactors returns the luma sampling factors for a subsampling mode;
+// chroma is always sampled once per MCU
+func jpegSamplingFactors(subsampling string) (h, v int, err error) {
+	switch subsampling {
+	case JPEGSubsampling444:
+		return 1, 1, nil
+	case JPEGSubsampling422:
+		return 2, 1, nil
+	case JPEGSubsampling420, "":
+		return 2, 2, nil
+	default:
+		return 0, 0, fmt.Errorf("unsupported JPEG subsampling: %s", subsampling)
+	}
+}
+
+// encodeJPEG writes img as a baseline JPEG with the requested chroma subsampling.
+// An empty subsampling means the 4:2:0 default.
+func encodeJPEG(w io.Writer, img image.Image, quality int, subsampling string) error {
+	h, v, err := jpegSamplingFactors(subsampling)
+	if err != nil {
+		return err
+	}
+	// Grayscale images have no chroma to subsample and are written as one channel
+	if _, gray := img.(*image.Gray); gray || (h == 2 && v == 2) {
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+	}
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= 0 || height <= 0 || width >= 1<<16 || height >= 1<<16 {
+		return fmt.Errorf("invalid JPEG dimensions: %dx%d", width, height)
+	}
+
+	e := &jpegEncoder{}
+	e.scaleQuant(quality)
+	for i, spec := range jpegHuffmanSpecs {
+		e.huff[i] = newJPEGHuffmanLUT(spec)
+	}
+
+	planes := jpegYCbCrPlanes(img)
+	e.writeHeaders(width, height, h, v)
+
+	mcuW, mcuH := 8*h, 8*v
+	var block [64]float64
+	var prevDC [3]int
+	for my := 0; my < height; my += mcuH {
+		for mx := 0; mx < width; mx += mcuW {
+			for by := 0; by < v; by++ {
+				for bx := 0; bx < h; bx++ {
+					jpegSampleBlock(&block, planes[0], width, height, mx+8*bx, my+8*by, 1, 1)
+					prevDC[0] = e.writeBlock(&block, 0, prevDC[0])
+				}
+			}
+			for c := 1; c < 3; c++ {
+				jpegSampleBlock(&block, planes[c], width, height, mx, my, h, v)
+				prevDC[c] = e.writeBlock(&block, 1, prevDC[c])
+			}
+		}
+	}
+	// Pad the final byte with 1 bits, then end the image
+	e.emit(0x7f, 7)
+	e.buf.Write([]byte{0xff, 0xd9})
+
+	_, err = w.Write(e.buf.Bytes())
+	return err
+}
+
+// jpegEncoder accumulates the entropy-coded stream for a single image
+type jpegEncoder struct {
+	buf   bytes.Buffer
+	bits  uint32
+	nBits uint
+	quant [2][64]byte // zig-zag order
+	huff  [4][]uint32 // code length << 24 | code, indexed by value
+}
+
+// scaleQuant scales the Annex K tables with the libjpeg quality formula
+func (e *jpegEncoder) scaleQuant(quality int) {
+	if quality < 1 {
+		quality = 1
+	} else if quality > 100 {
+		quality = 100
+	}
+	scale := 200 - quality*2
+	if quality < 50 {
+		scale = 5000 / quality
+	}
+	for i := range e.quant {
+		for j := range e.quant[i] {
+			x := (int(jpegUnscaledQuant[i][j])*scale + 50) / 100
+			if x < 1 {
+				x = 1
+			} else if x > 255 {
+				x = 255
+			}
+			e.quant[i][j] = byte(x)
+		}
+	}
+}
+
+// newJPEGHuffmanLUT assigns canonical codes to a DHT table
+func newJPEGHuffmanLUT(spec jpegHuffmanSpec) []uint32 {
+	lut := make([]uint32, 256)
+	code, k := uint32(0), 0
+	for i, n := range spec.count {
+		for j := 0; j < int(n); j++ {
+			lut[spec.value[k]] = uint32(i+1)<<24 | code
+			code++
+			k++
+		}
+		code <<= 1
+	}
+	return lut
+}
+
+// writeHeaders writes SOI, DQT, SOF0, DHT and SOS for a three-component image
+func (e *jpegEncoder) writeHeaders(width, height, h, v int) {
+	e.buf.Write([]byte{0xff, 0xd8})
+
+	e.writeMarker(0xdb, 2*65)
+	for i := range e.quant {
+		e.buf.WriteByte(byte(i))
+		e.buf.Write(e.quant[i][:])
+	}
+
+	e.writeMarker(0xc0, 15)
+	e.buf.Write([]byte{8, byte(height >> 8), byte(height), byte(width >> 8), byte(width), 3})
+	e.buf.Write([]byte{1, byte(h<<4 | v), 0})
+	e.buf.Write([]byte{2, 0x11, 1})
+	e.buf.Write([]byte{3, 0x11, 1})
+
+	dhtLen := 0
+	for _, spec := range jpegHuffmanSpecs {
+		dhtLen += 17 + len(spec.value)
+	}
+	e.writeMarker(0xc4, dhtLen)
+	for _, spec := range jpegHuffmanSpecs {
+		e.buf.WriteByte(spec.class<<4 | spec.id)
+		e.buf.Write(spec.count[:])
+		e.buf.Write(spec.value)
+	}
+
+	e.writeMarker(0xda, 10)
+	e.buf.Write([]byte{3, 1, 0x00, 2, 0x11, 3, 0x11, 0, 63, 0})
+}
+
+// writeMarker writes a marker and the segment length covering payloadLen bytes
+func (e *jpegEncoder) writeMarker(marker byte, payloadLen int) {
+	n := payloadLen + 2
+	e.buf.Write([]byte{0xff, marker, byte(n >> 8), byte(n)})
+}
+
+// emit appends the low n bits of value, stuffing a zero after every 0xff byte
+func (e *jpegEncoder) emit(value uint32, n uint) {
+	e.nBits += n
+	e.bits |= value << (32 - e.nBits)
+	for e.nBits >= 8 {
+		c := byte(e.bits >> 24)
+		e.buf.WriteByte(c)
+		if c == 0xff {
+			e.buf.WriteByte(0)
+		}
+		e.bits <<= 8
+		e.nBits -= 8
+	}
+}
+
+// emitHuff writes the Huffman code for value from table t
+func (e *jpegEncoder) emitHuff(t int, value byte) {
+	x := e.huff[t][value]
+	e.emit(x&(1<<24-1), uint(x>>24))
+}
+
+// emitHuffRLE writes a run/size symbol followed by the magnitude bits of value
+func (e *jpegEncoder) emitHuffRLE(t int, run, value int) {
+	a, b := value, value
+	if a < 0 {
+		a, b = -value, value-1
+	}
+	n := bits.Len(uint(a))
+	e.emitHuff(t, byte(run<<4|n))
+	if n > 0 {
+		e.emit(uint32(b)&(1<<n-1), uint(n))
+	}
+}
+
+// writeBlock transforms, quantizes and entropy-codes one level-shifted block,
+// returning its DC coefficient for the next block's prediction
+func (e *jpegEncoder) writeBlock(block *[64]float64, table, prevDC int) int {
+	var tmp, coeffs [64]float64
+	for y := 0; y < 8; y++ {
+		for k := 0; k < 8; k++ {
+			sum := 0.0
+			for x := 0; x < 8; x++ {
+				sum += jpegDCTBasis[k][x] * block[y*8+x]
+			}
+			tmp[y*8+k] = sum
+		}
+	}
+	for k := 0; k < 8; k++ {
+		for u := 0; u < 8; u++ {
+			sum := 0.0
+			for y := 0; y < 8; y++ {
+				sum += jpegDCTBasis[k][y] * tmp[y*8+u]
+			}
+			coeffs[k*8+u] = sum
+		}
+	}
+
+	dcTable, acTable := 2*table, 2*table+1
+	dc := int(math.Round(coeffs[0] / float64(e.quant[table][0])))
+	e.emitHuffRLE(dcTable, 0, dc-prevDC)
+
+	run := 0
+	for zig := 1; zig < 64; zig++ {
+		ac := int(math.Round(coeffs[jpegUnzig[zig]] / float64(e.quant[table][zig])))
+		// Baseline AC coefficients are limited to 10 magnitude bits
+		if ac > 1023 {
+			ac = 1023
+		} else if ac < -1023 {
+			ac = -1023
+		}
+		if ac == 0 {
+			run++
+			continue
+		}
+		for run > 15 {
+			e.emitHuff(acTable, 0xf0)
+			run -= 16
+		}
+		e.emitHuffRLE(acTable, run, ac)
+		run = 0
+	}
+	if run > 0 {
+		e.emitHuff(acTable, 0x00)
+	}
+	return dc
+}
+
+// jpegYCbCrPlanes converts img to full-resolution Y, Cb and Cr planes
+func jpegYCbCrPlanes(img image.Image) [3][]float64 {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	var planes [3][]float64
+	for i := range planes {
+		planes[i] = make([]float64, width*height)
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r, g, b, _ := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			rf, gf, bf := float64(r>>8), float64(g>>8), float64(b>>8)
+			i := y*width + x
+			planes[0][i] = 0.299*rf + 0.587*gf + 0.114*bf
+			planes[1][i] = -0.168736*rf - 0.331264*gf + 0.5*bf + 128
+			planes[2][i] = 0.5*rf - 0.418688*gf - 0.081312*bf + 128
+		}
+	}
+	return planes
+}
+
+// jpegSampleBlock fills block with the level-shifted 8x8 samples starting at
+// (x0, y0), averaging sx*sy pixels per sample and clamping at the image edge
+func jpegSampleBlock(block *[64]float64, plane []float64, width, height, x0, y0, sx, sy int) {
+	for by := 0; by < 8; by++ {
+		for bx := 0; bx < 8; bx++ {
+			sum := 0.0
+			for dy := 0; dy < sy; dy++ {
+				y := min(y0+by*sy+dy, height-1)
+				for dx := 0; dx < sx; dx++ {
+					x := min(x0+bx*sx+dx, width-1)
+					sum += plane[y*width+x]
+				}
+			}
+			block[by*8+bx] = sum/float64(sx*sy) - 128
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jpegLumaSampling returns the luma sampling byte from the SOF0 segment
+func jpegLumaSampling(t *testing.T, data []byte) byte {
+	t.Helper()
+	for i := 2; i+4 <= len(data); {
+		require.Equal(t, byte(0xff), data[i], "expected marker at offset %d", i)
+		marker := data[i+1]
+		length := int(data[i+2])<<8 | int(data[i+3])
+		if marker == 0xc0 {
+			// precision(1) height(2) width(2) components(1), then id, sampling, table
+			return data[i+4+7]
+		}
+		i += 2 + length
+	}
+	t.Fatal("SOF0 segment not found")
+	return 0
+}
+
+func TestEncodeJPEG_Subsampling(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 37, 21))
+	rng := rand.New(rand.NewSource(1))
+	for y := 0; y < 21; y++ {
+		for x := 0; x < 37; x++ {
+			src.Set(x, y, color.RGBA{uint8(x * 6), uint8(y * 12), uint8(rng.Intn(32) + 100), 255})
+		}
+	}
+
+	tests := []struct {
+		subsampling string
+		sampling    byte
+	}{
+		{JPEGSubsampling444, 0x11},
+		{JPEGSubsampling422, 0x21},
+		{JPEGSubsampling420, 0x22},
+		{"", 0x22},
+	}
+
+	for _, tt := range tests {
+		t.Run("subsampling_"+tt.subsampling, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, encodeJPEG(&buf, src, 90, tt.subsampling))
+			assert.Equal(t, tt.sampling, jpegLumaSampling(t, buf.Bytes()))
+
+			decoded, err := jpeg.Decode(bytes.NewReader(buf.Bytes()))
+			require.NoError(t, err)
+			assert.Equal(t, src.Bounds(), decoded.Bounds())
+
+			// Smooth gradients survive lossy coding within a small tolerance
+			for _, p := range []image.Point{{0, 0}, {18, 10}, {36, 20}} {
+				want := src.RGBAAt(p.X, p.Y)
+				r, g, b, _ := decoded.At(p.X, p.Y).RGBA()
+				assert.InDelta(t, float64(want.R), float64(r>>8), 24, "red at %v", p)
+				assert.InDelta(t, float64(want.G), float64(g>>8), 24, "green at %v", p)
+				assert.InDelta(t, float64(want.B), float64(b>>8), 24, "blue at %v", p)
+			}
+		})
+	}
+}
+
+func TestEncodeJPEG_HighQualityRoundTrip(t *testing.T) {
+	// Saturated noise at quality 100 exercises the largest DC differences and AC magnitudes
+	src := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	rng := rand.New(rand.NewSource(2))
+	rng.Read(src.Pix)
+	for i := 3; i < len(src.Pix); i += 4 {
+		src.Pix[i] = 255
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, encodeJPEG(&buf, src, 100, JPEGSubsampling444))
+	_, err := jpeg.Decode(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+}
+
+func TestEncodeJPEG_InvalidSubsampling(t *testing.T) {
+	var buf bytes.Buffer
+	err := encodeJPEG(&buf, image.NewRGBA(image.Rect(0, 0, 8, 8)), 85, "411")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported JPEG subsampling")
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"resizr/internal/models"
+)
+
+// metadataCacheEntry is a cached metadata record and when it stops being served
+type metadataCacheEntry struct {
+	imageID   string
+	metadata  *models.ImageMetadata
+	expiresAt time.Time
+}
+
+// metadataCache keeps recently read image metadata in process so hot images do not
+// cost a repository read on every download. Entries expire after a short TTL and are
+// dropped whenever the service changes the record, so only changes made by other
+// instances can be served stale, and only until the TTL runs out. A nil cache is
+// disabled.
+type metadataCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	order   *list.List // Most recently used first, holding *metadataCacheEntry
+	entries map[string]*list.Element
+	now     func() time.Time
+}
+
+// newMetadataCache creates a cache of at most size records, each served for ttl. It
+// returns nil, a disabled cache, when either is not positive.
+func newMetadataCache(size int, ttl time.Duration) *metadataCache {
+	if size <= 0 || ttl <= 0 {
+		return nil
+	}
+	return &metadataCache{
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+		now:     time.Now,
+	}
+}
+
+// get returns a copy of the cached metadata of an image while its entry is fresh
+func (mc *metadataCache) get(imageID string) (*models.ImageMetadata, bool) {
+	if mc == nil {
+		return nil, false
+	}
+
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	elem, ok := mc.entries[imageID]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*metadataCacheEntry)
+	if !mc.now().Before(entry.expiresAt) {
+		mc.remove(elem)
+		return nil, false
+	}
+	mc.order.MoveToFront(elem)
+	return entry.metadata.Clone(), true
+}
+
+// put caches a copy of metadata, evicting the least recently used record when full
+func (mc *metadataCache) put(metadata *models.ImageMetadata) {
+	if mc == nil {
+		return
+	}
+
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	entry := &metadataCacheEntry{
+		imageID:   metadata.ID,
+		metadata:  metadata.Clone(),
+		expiresAt: mc.now().Add(mc.ttl),
+	}
+	if elem, ok := mc.entries[metadata.ID]; ok {
+		elem.Value = entry
+		mc.order.MoveToFront(elem)
+		return
+	}
+
+	mc.entries[metadata.ID] = mc.order.PushFront(entry)
+	if mc.order.Len() > mc.size {
+		mc.remove(mc.order.Back())
+	}
+}
+
+// invalidate drops the cached metadata of an image after it changed
+func (mc *metadataCache) invalidate(imageID string) {
+	if mc == nil {
+		return
+	}
+
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	if elem, ok := mc.entries[imageID]; ok {
+		mc.remove(elem)
+	}
+}
+
+// remove drops an entry; the caller holds mu
+func (mc *metadataCache) remove(elem *list.Element) {
+	mc.order.Remove(elem)
+	delete(mc.entries, elem.Value.(*metadataCacheEntry).imageID)
+}
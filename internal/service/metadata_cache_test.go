@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"resizr/internal/models"
+	"resizr/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImageService_GetMetadata_Cache(t *testing.T) {
+	newService := func() (*ImageServiceImpl, *int) {
+		stored := testutil.CreateTestImageMetadata()
+		gets := 0
+		mockRepo := &mockImageRepositoryForImageService{
+			getByIDFunc: func(ctx context.Context, id string) (*models.ImageMetadata, error) {
+				gets++
+				if stored == nil {
+					return nil, models.NotFoundError{Resource: "image", ID: id}
+				}
+				return stored.Clone(), nil
+			},
+			updateFunc: func(ctx context.Context, metadata *models.ImageMetadata) error {
+				stored = metadata.Clone()
+				return nil
+			},
+			deleteFunc: func(ctx context.Context, id string) error {
+				stored = nil
+				return nil
+			},
+		}
+		mockStorage := &mockStorageProviderForImageService{
+			deleteFunc: func(ctx context.Context, key string) error { return nil },
+		}
+
+		cfg := testutil.TestConfig()
+		cfg.Cache.MetadataSize = 10
+		cfg.Cache.MetadataTTL = time.Minute
+		service := NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, mockStorage, &mockProcessorServiceForImageService{}, cfg).(*ImageServiceImpl)
+		return service, &gets
+	}
+	ctx := context.Background()
+
+	t.Run("second read within the TTL skips the repository", func(t *testing.T) {
+		service, gets := newService()
+
+		first, err := service.GetMetadata(ctx, testutil.ValidUUID)
+		require.NoError(t, err)
+		second, err := service.GetMetadata(ctx, testutil.ValidUUID)
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, *gets)
+		assert.Equal(t, first, second)
+
+		// Callers get their own copy, so changing one does not leak into the cache
+		second.Filename = "changed.jpg"
+		third, err := service.GetMetadata(ctx, testutil.ValidUUID)
+		require.NoError(t, err)
+		assert.Equal(t, first.Filename, third.Filename)
+	})
+
+	t.Run("expired entries are read again", func(t *testing.T) {
+		service, gets := newService()
+		now := time.Now()
+		service.metaCache.now = func() time.Time { return now }
+
+		_, err := service.GetMetadata(ctx, testutil.ValidUUID)
+		require.NoError(t, err)
+		now = now.Add(time.Minute)
+		_, err = service.GetMetadata(ctx, testutil.ValidUUID)
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, *gets)
+	})
+
+	t.Run("rename invalidates", func(t *testing.T) {
+		service, gets := newService()
+
+		_, err := service.GetMetadata(ctx, testutil.ValidUUID)
+		require.NoError(t, err)
+		_, err = service.UpdateFilename(ctx, testutil.ValidUUID, "renamed.jpg")
+		require.NoError(t, err)
+
+		metadata, err := service.GetMetadata(ctx, testutil.ValidUUID)
+		require.NoError(t, err)
+		assert.Equal(t, "renamed.jpg", metadata.Filename)
+		assert.Equal(t, 2, *gets) // The rename itself read the cached record
+	})
+
+	t.Run("delete invalidates", func(t *testing.T) {
+		service, _ := newService()
+
+		_, err := service.GetMetadata(ctx, testutil.ValidUUID)
+		require.NoError(t, err)
+		require.NoError(t, service.DeleteImage(ctx, testutil.ValidUUID))
+
+		_, err = service.GetMetadata(ctx, testutil.ValidUUID)
+		assert.IsType(t, models.NotFoundError{}, err)
+	})
+
+	t.Run("disabled cache reads every time", func(t *testing.T) {
+		service, gets := newService()
+		service.metaCache = nil
+
+		for i := 0; i < 3; i++ {
+			_, err := service.GetMetadata(ctx, testutil.ValidUUID)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 3, *gets)
+	})
+}
+
+func TestMetadataCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newMetadataCache(2, time.Minute)
+	for _, id := range []string{"a", "b"} {
+		cache.put(&models.ImageMetadata{ID: id})
+	}
+
+	_, ok := cache.get("a") // a is now more recent than b
+	require.True(t, ok)
+	cache.put(&models.ImageMetadata{ID: "c"})
+
+	_, ok = cache.get("b")
+	assert.False(t, ok)
+	_, ok = cache.get("a")
+	assert.True(t, ok)
+	_, ok = cache.get("c")
+	assert.True(t, ok)
+
+	assert.Nil(t, newMetadataCache(0, time.Minute))
+	assert.Nil(t, newMetadataCache(10, 0))
+}
+
+// cachingRepository adds the cache layer to the image repository mock
+type cachingRepository struct {
+	*mockImageRepositoryForImageService
+	deleteAllCachedURLsFunc func(ctx context.Context, imageID string) error
+}
+
+func (r *cachingRepository) SetCachedURL(ctx context.Context, imageID, resolution, url string, ttl time.Duration) error {
+	return nil
+}
+func (r *cachingRepository) GetCachedURL(ctx context.Context, imageID, resolution string) (string, error) {
+	return "", nil
+}
+func (r *cachingRepository) DeleteCachedURL(ctx context.Context, imageID, resolution string) error {
+	return nil
+}
+func (r *cachingRepository) DeleteAllCachedURLs(ctx context.Context, imageID string) error {
+	return r.deleteAllCachedURLsFunc(ctx, imageID)
+}
+func (r *cachingRepository) SetCache(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	return nil
+}
+func (r *cachingRepository) GetCache(ctx context.Context, key string) (string, error) {
+	return "", nil
+}
+func (r *cachingRepository) DeleteCache(ctx context.Context, key string) error {
+	return nil
+}
+func (r *cachingRepository) CacheHealth(ctx context.Context) error {
+	return nil
+}
+
+func TestImageService_PurgeImageCache(t *testing.T) {
+	ctx := context.Background()
+	gets := 0
+	var purged []string
+	repo := &cachingRepository{
+		mockImageRepositoryForImageService: &mockImageRepositoryForImageService{
+			getByIDFunc: func(ctx context.Context, id string) (*models.ImageMetadata, error) {
+				gets++
+				return testutil.CreateTestImageMetadata(), nil
+			},
+		},
+		deleteAllCachedURLsFunc: func(ctx context.Context, imageID string) error {
+			purged = append(purged, imageID)
+			return nil
+		},
+	}
+
+	cfg := testutil.TestConfig()
+	cfg.Cache.MetadataSize = 10
+	cfg.Cache.MetadataTTL = time.Minute
+	service := NewImageService(repo, &mockDeduplicationRepositoryForImageService{}, &mockStorageProviderForImageService{}, &mockProcessorServiceForImageService{}, cfg).(*ImageServiceImpl)
+
+	_, err := service.GetMetadata(ctx, testutil.ValidUUID)
+	require.NoError(t, err)
+	require.NoError(t, service.PurgeImageCache(ctx, testutil.ValidUUID))
+	_, err = service.GetMetadata(ctx, testutil.ValidUUID)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{testutil.ValidUUID}, purged)
+	assert.Equal(t, 2, gets, "the purged metadata is read from the repository again")
+
+	t.Run("invalid id", func(t *testing.T) {
+		err := service.PurgeImageCache(ctx, "not-a-uuid")
+		assert.IsType(t, models.ValidationError{}, err)
+		assert.Len(t, purged, 1)
+	})
+
+	t.Run("cache backend failure", func(t *testing.T) {
+		repo.deleteAllCachedURLsFunc = func(ctx context.Context, imageID string) error {
+			return assert.AnError
+		}
+		err := service.PurgeImageCache(ctx, testutil.ValidUUID)
+		assert.IsType(t, models.StorageError{}, err)
+	})
+}
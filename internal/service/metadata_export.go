@@ -0,0 +1,162 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"resizr/internal/models"
+	"resizr/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// metadataExportPageSize is how many records are listed per repository page
+	metadataExportPageSize = 100
+
+	// maxImportLineSize bounds a single NDJSON record in an import
+	maxImportLineSize = 4 << 20
+)
+
+// ExportMetadata writes every metadata record to w, listing the repository page by
+// page so the full set is never held in memory. It returns the number of records
+// written; a failure part-way leaves w with the records written so far.
+func (s *ImageServiceImpl) ExportMetadata(ctx context.Context, w io.Writer, format string) (int, error) {
+	if format != models.ExportFormatNDJSON && format != models.ExportFormatJSON {
+		return 0, models.ValidationError{
+			Field:   "format",
+			Message: fmt.Sprintf("Export format must be %s or %s", models.ExportFormatNDJSON, models.ExportFormatJSON),
+		}
+	}
+
+	asArray := format == models.ExportFormatJSON
+	count := 0
+
+	for offset := 0; ; offset += metadataExportPageSize {
+		page, err := s.repo.List(ctx, offset, metadataExportPageSize)
+		if err != nil {
+			return count, models.StorageError{
+				Operation: "list_images",
+				Backend:   "Repository",
+				Reason:    err.Error(),
+			}
+		}
+
+		for _, metadata := range page {
+			record, err := json.Marshal(metadata)
+			if err != nil {
+				return count, fmt.Errorf("failed to encode metadata of %s: %w", metadata.ID, err)
+			}
+
+			// NDJSON ends every record with a newline; a JSON array separates them with commas
+			prefix := ""
+			if asArray {
+				prefix = ",\n"
+				if count == 0 {
+					prefix = "[\n"
+				}
+			}
+			if _, err := io.WriteString(w, prefix); err != nil {
+				return count, err
+			}
+			if _, err := w.Write(record); err != nil {
+				return count, err
+			}
+			if !asArray {
+				if _, err := io.WriteString(w, "\n"); err != nil {
+					return count, err
+				}
+			}
+			count++
+		}
+
+		if len(page) < metadataExportPageSize {
+			break
+		}
+	}
+
+	if asArray {
+		closing := "\n]\n"
+		if count == 0 {
+			closing = "[]\n"
+		}
+		if _, err := io.WriteString(w, closing); err != nil {
+			return count, err
+		}
+	}
+
+	logger.InfoWithContext(ctx, "Metadata export completed",
+		zap.String("format", format),
+		zap.Int("records", count))
+
+	return count, nil
+}
+
+// ImportMetadata restores metadata records from NDJSON, one record per line, without
+// touching stored files. Existing records with the same ID are overwritten. Records
+// that cannot be decoded or fail validation are reported in the result rather than
+// aborting the import.
+func (s *ImageServiceImpl) ImportMetadata(ctx context.Context, r io.Reader) (*models.MetadataImportResult, error) {
+	result := &models.MetadataImportResult{}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxImportLineSize)
+
+	for line := 1; scanner.Scan(); line++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		raw := scanner.Bytes()
+		if len(raw) == 0 {
+			continue
+		}
+		result.Total++
+
+		var metadata models.ImageMetadata
+		err := json.Unmarshal(raw, &metadata)
+		if err == nil {
+			err = metadata.Validate()
+		}
+		if err == nil {
+			err = s.repo.Store(ctx, &metadata)
+			s.metaCache.invalidate(metadata.ID)
+		}
+		if err != nil {
+			logger.WarnWithContext(ctx, "Failed to import metadata record",
+				zap.Int("line", line),
+				zap.String("image_id", metadata.ID),
+				zap.Error(err))
+			result.Failed++
+			result.Failures = append(result.Failures, models.MetadataImportFailure{
+				Line:    line,
+				ImageID: metadata.ID,
+				Error:   err.Error(),
+			})
+			continue
+		}
+		result.Imported++
+	}
+
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, models.ValidationError{
+				Field:   "body",
+				Message: fmt.Sprintf("Failed to read import data: %v", err),
+			}
+		}
+		// Read errors, such as a body over the size limit, are left for the caller to map
+		return nil, fmt.Errorf("failed to read import data: %w", err)
+	}
+
+	logger.InfoWithContext(ctx, "Metadata import completed",
+		zap.Int("total", result.Total),
+		zap.Int("imported", result.Imported),
+		zap.Int("failed", result.Failed))
+
+	return result, nil
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"resizr/internal/models"
+	"resizr/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pagedImages serves images the way a repository List does
+func pagedImages(images []*models.ImageMetadata) func(ctx context.Context, offset, limit int) ([]*models.ImageMetadata, error) {
+	return func(ctx context.Context, offset, limit int) ([]*models.ImageMetadata, error) {
+		if offset >= len(images) {
+			return nil, nil
+		}
+		return images[offset:min(offset+limit, len(images))], nil
+	}
+}
+
+func TestImageService_ExportImportMetadata_RoundTrip(t *testing.T) {
+	// More than one export page, with the optional fields populated
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	var images []*models.ImageMetadata
+	for i := 0; i < metadataExportPageSize+25; i++ {
+		metadata := testutil.CreateTestImageMetadata()
+		metadata.ID = fmt.Sprintf("a1b2c3d4-0000-4000-8000-%012d", i)
+		metadata.OriginalKey = "images/" + metadata.ID + "/original.jpg"
+		metadata.CreatedAt = created.Add(time.Duration(i) * time.Minute)
+		metadata.UpdatedAt = metadata.CreatedAt
+		metadata.Hash = models.CalculateImageHash([]byte(metadata.ID))
+		if i%2 == 0 {
+			metadata.ResolutionDimensions = map[string]models.DimensionInfo{"800x600": {Width: 800, Height: 450}}
+			metadata.FormatVariants = map[string][]string{"800x600": {"webp"}}
+			metadata.LastAccessedAt = created.Add(time.Hour)
+		}
+		images = append(images, metadata)
+	}
+
+	source := NewImageService(&mockImageRepositoryForImageService{listFunc: pagedImages(images)},
+		&mockDeduplicationRepositoryForImageService{}, &mockStorageProviderForImageService{}, &mockProcessorServiceForImageService{}, testutil.TestConfig())
+
+	var export bytes.Buffer
+	count, err := source.ExportMetadata(context.Background(), &export, models.ExportFormatNDJSON)
+	require.NoError(t, err)
+	assert.Equal(t, len(images), count)
+	assert.Equal(t, len(images), strings.Count(export.String(), "\n"))
+
+	restored := make(map[string]*models.ImageMetadata)
+	target := NewImageService(&mockImageRepositoryForImageService{
+		saveFunc: func(ctx context.Context, metadata *models.ImageMetadata) error {
+			restored[metadata.ID] = metadata
+			return nil
+		},
+	}, &mockDeduplicationRepositoryForImageService{}, &mockStorageProviderForImageService{}, &mockProcessorServiceForImageService{}, testutil.TestConfig())
+
+	result, err := target.ImportMetadata(context.Background(), &export)
+	require.NoError(t, err)
+	assert.Equal(t, len(images), result.Total)
+	assert.Equal(t, len(images), result.Imported)
+	assert.Zero(t, result.Failed)
+
+	require.Len(t, restored, len(images))
+	for _, original := range images {
+		assert.Equal(t, original, restored[original.ID])
+	}
+}
+
+func TestImageService_ExportMetadata_JSONArray(t *testing.T) {
+	images := []*models.ImageMetadata{testutil.CreateTestImageMetadata(), testutil.CreateTestImageMetadata()}
+	images[1].ID = testutil.ValidUUID
+
+	newService := func(images []*models.ImageMetadata) ImageService {
+		return NewImageService(&mockImageRepositoryForImageService{listFunc: pagedImages(images)},
+			&mockDeduplicationRepositoryForImageService{}, &mockStorageProviderForImageService{}, &mockProcessorServiceForImageService{}, testutil.TestConfig())
+	}
+
+	var export bytes.Buffer
+	count, err := newService(images).ExportMetadata(context.Background(), &export, models.ExportFormatJSON)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	var decoded []models.ImageMetadata
+	require.NoError(t, json.Unmarshal(export.Bytes(), &decoded))
+	assert.Len(t, decoded, 2)
+
+	export.Reset()
+	_, err = newService(nil).ExportMetadata(context.Background(), &export, models.ExportFormatJSON)
+	require.NoError(t, err)
+	assert.Equal(t, "[]\n", export.String())
+
+	_, err = newService(nil).ExportMetadata(context.Background(), &export, "csv")
+	assert.IsType(t, models.ValidationError{}, err)
+}
+
+func TestImageService_ExportMetadata_ListFailure(t *testing.T) {
+	service := NewImageService(&mockImageRepositoryForImageService{
+		listFunc: func(ctx context.Context, offset, limit int) ([]*models.ImageMetadata, error) {
+			return nil, errors.New("connection refused")
+		},
+	}, &mockDeduplicationRepositoryForImageService{}, &mockStorageProviderForImageService{}, &mockProcessorServiceForImageService{}, testutil.TestConfig())
+
+	var export bytes.Buffer
+	count, err := service.ExportMetadata(context.Background(), &export, models.ExportFormatNDJSON)
+
+	assert.Zero(t, count)
+	assert.IsType(t, models.StorageError{}, err)
+	assert.Empty(t, export.String())
+}
+
+func TestImageService_ImportMetadata_ReportsBadRecords(t *testing.T) {
+	valid, err := json.Marshal(testutil.CreateTestImageMetadata())
+	require.NoError(t, err)
+	input := strings.Join([]string{
+		string(valid),
+		"",
+		`{"id": "not-json"`,
+		`{"id": "` + testutil.ValidUUID + `"}`,
+	}, "\n")
+
+	stored := 0
+	service := NewImageService(&mockImageRepositoryForImageService{
+		saveFunc: func(ctx context.Context, metadata *models.ImageMetadata) error {
+			stored++
+			return nil
+		},
+	}, &mockDeduplicationRepositoryForImageService{}, &mockStorageProviderForImageService{}, &mockProcessorServiceForImageService{}, testutil.TestConfig())
+
+	result, err := service.ImportMetadata(context.Background(), strings.NewReader(input))
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Total)
+	assert.Equal(t, 1, result.Imported)
+	assert.Equal(t, 2, result.Failed)
+	assert.Equal(t, 1, stored)
+	require.Len(t, result.Failures, 2)
+	assert.Equal(t, 3, result.Failures[0].Line)
+	assert.Equal(t, 4, result.Failures[1].Line)
+	assert.Equal(t, testutil.ValidUUID, result.Failures[1].ImageID)
+}
+
+func TestImageService_ImportMetadata_ReadErrors(t *testing.T) {
+	service := NewImageService(&mockImageRepositoryForImageService{}, &mockDeduplicationRepositoryForImageService{}, &mockStorageProviderForImageService{}, &mockProcessorServiceForImageService{}, testutil.TestConfig())
+
+	// An over-long record is the client's fault
+	_, err := service.ImportMetadata(context.Background(), strings.NewReader(strings.Repeat("a", maxImportLineSize+1)))
+	var validationErr models.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "body", validationErr.Field)
+
+	// Other read errors stay visible to the caller, which maps a body over the size limit to 413
+	readErr := errors.New("body cut off")
+	_, err = service.ImportMetadata(context.Background(), io.MultiReader(strings.NewReader("{}\n"), iotest.ErrReader(readErr)))
+	assert.ErrorIs(t, err, readErr)
+	assert.False(t, errors.As(err, &validationErr))
+}
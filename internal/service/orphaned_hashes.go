@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"resizr/internal/models"
+	"resizr/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// FindOrphanedHashes reports the deduplication records that no image references
+func (s *ImageServiceImpl) FindOrphanedHashes(ctx context.Context) (*models.OrphanedHashReport, error) {
+	hashes, err := s.dedupRepo.GetOrphanedHashes(ctx)
+	if err != nil {
+		return nil, models.StorageError{
+			Operation: "find_orphaned_hashes",
+			Backend:   "Repository",
+			Reason:    err.Error(),
+		}
+	}
+
+	orphans := make([]models.OrphanedHash, 0, len(hashes))
+	for _, hash := range hashes {
+		orphan := models.OrphanedHash{Hash: hash}
+		if info, err := s.dedupRepo.GetDeduplicationInfo(ctx, hash); err == nil {
+			orphan.MasterImageID = info.MasterImageID
+			orphan.StorageKey = info.StorageKey
+		}
+		orphans = append(orphans, orphan)
+	}
+
+	return &models.OrphanedHashReport{Count: len(orphans), Hashes: orphans}, nil
+}
+
+// RemoveOrphanedHashes deletes every orphaned deduplication record together with
+// the files left under its master image. Files are kept when the master image
+// still has metadata, since they then belong to a live image. References are
+// re-checked before anything is deleted, so a record that an upload started
+// sharing in the meantime is skipped.
+func (s *ImageServiceImpl) RemoveOrphanedHashes(ctx context.Context) (*models.OrphanedHashReport, error) {
+	report, err := s.FindOrphanedHashes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, orphan := range report.Hashes {
+		if s.removeOrphanedHash(ctx, orphan.Hash) {
+			report.Removed++
+		} else {
+			report.Skipped++
+		}
+	}
+
+	logger.InfoWithContext(ctx, "Orphaned deduplication records removed",
+		zap.Int("found", report.Count),
+		zap.Int("removed", report.Removed),
+		zap.Int("skipped", report.Skipped))
+
+	return report, nil
+}
+
+// StartOrphanCleanup runs RemoveOrphanedHashes on every DedupOrphanCleanupInterval
+// until ctx is cancelled. It does nothing when the interval is disabled.
+func (s *ImageServiceImpl) StartOrphanCleanup(ctx context.Context) {
+	interval := s.config.Image.DedupOrphanCleanupInterval
+	if interval <= 0 {
+		logger.Debug("Orphaned deduplication cleanup disabled")
+		return
+	}
+
+	logger.Info("Starting orphaned deduplication cleanup",
+		zap.Duration("interval", interval))
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				logger.Info("Orphaned deduplication cleanup stopped")
+				return
+			case <-ticker.C:
+				if _, err := s.RemoveOrphanedHashes(ctx); err != nil {
+					logger.Warn("Orphaned deduplication cleanup failed", zap.Error(err))
+				}
+			}
+		}
+	}()
+}
+
+// removeOrphanedHash deletes one orphaned record and its files. It reports false
+// when the record was left in place: it gained a reference, it is already gone, or
+// its files could not be removed and the next sweep should retry.
+func (s *ImageServiceImpl) removeOrphanedHash(ctx context.Context, hash models.ImageHash) bool {
+	info, orphaned := s.stillOrphaned(ctx, hash)
+	if !orphaned {
+		return false
+	}
+
+	if info.MasterImageID != "" && !s.purgeOrphanedFiles(ctx, info) {
+		return false
+	}
+
+	// An upload may have started sharing the content while the files were purged;
+	// it re-uploads a missing original, so only its record has to be kept
+	if _, orphaned := s.stillOrphaned(ctx, hash); !orphaned {
+		return false
+	}
+
+	if err := s.dedupRepo.DeleteDeduplicationInfo(ctx, hash); err != nil {
+		logger.WarnWithContext(ctx, "Failed to remove orphaned deduplication record",
+			zap.String("hash", hash.String()),
+			zap.Error(err))
+		return false
+	}
+	return true
+}
+
+// stillOrphaned re-reads a deduplication record and reports whether it still has
+// no references
+func (s *ImageServiceImpl) stillOrphaned(ctx context.Context, hash models.ImageHash) (*models.DeduplicationInfo, bool) {
+	info, err := s.dedupRepo.GetDeduplicationInfo(ctx, hash)
+	if err != nil {
+		logger.DebugWithContext(ctx, "Orphaned deduplication record no longer readable",
+			zap.String("hash", hash.String()),
+			zap.Error(err))
+		return nil, false
+	}
+	if !info.IsOrphaned() {
+		logger.InfoWithContext(ctx, "Sparing deduplication record that gained a reference",
+			zap.String("hash", hash.String()),
+			zap.Int("reference_count", info.ReferenceCount))
+		return info, false
+	}
+	return info, true
+}
+
+// purgeOrphanedFiles deletes the storage folder of an orphan's master image
+// unless that image still exists. It reports whether the record can be removed:
+// the master is alive, or its folder is verified empty.
+func (s *ImageServiceImpl) purgeOrphanedFiles(ctx context.Context, info *models.DeduplicationInfo) bool {
+	exists, err := s.repo.Exists(ctx, info.MasterImageID)
+	if err != nil {
+		logger.WarnWithContext(ctx, "Failed to check master image of orphaned deduplication record",
+			zap.String("hash", info.Hash.String()),
+			zap.String("master_id", info.MasterImageID),
+			zap.Error(err))
+		return false
+	}
+	if exists {
+		logger.InfoWithContext(ctx, "Keeping files of orphaned deduplication record",
+			zap.String("hash", info.Hash.String()),
+			zap.String("master_id", info.MasterImageID))
+		return true
+	}
+
+	folderPrefix := fmt.Sprintf("images/%s", info.MasterImageID)
+	if err := s.storage.DeleteFolder(ctx, folderPrefix); err != nil {
+		logger.WarnWithContext(ctx, "Failed to delete files of orphaned deduplication record",
+			zap.String("hash", info.Hash.String()),
+			zap.String("folder", folderPrefix),
+			zap.Error(err))
+		return false
+	}
+
+	remaining, err := s.storage.ListObjects(ctx, folderPrefix+"/", 1)
+	if err != nil || len(remaining) > 0 {
+		logger.WarnWithContext(ctx, "Files of orphaned deduplication record remain after purge",
+			zap.String("hash", info.Hash.String()),
+			zap.String("folder", folderPrefix),
+			zap.Int("remaining", len(remaining)),
+			zap.Error(err))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"resizr/internal/models"
+	"resizr/internal/storage"
+	"resizr/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImageService_OrphanedHashes(t *testing.T) {
+	const (
+		goneMaster = "a1b2c3d4-0000-4000-8000-000000000001"
+		liveMaster = "a1b2c3d4-0000-4000-8000-000000000002"
+	)
+	gone := models.ImageHash{Algorithm: "SHA256", Value: "aaaa", Size: 10}
+	live := models.ImageHash{Algorithm: "SHA256", Value: "bbbb", Size: 20}
+	infos := map[string]*models.DeduplicationInfo{
+		gone.String(): {Hash: gone, MasterImageID: goneMaster, StorageKey: "images/" + goneMaster + "/original.jpg"},
+		live.String(): {Hash: live, MasterImageID: liveMaster, StorageKey: "images/" + liveMaster + "/original.jpg"},
+	}
+
+	var deletedHashes []models.ImageHash
+	dedupRepo := &testutil.MockDeduplicationRepository{
+		GetOrphanedHashesFunc: func(ctx context.Context) ([]models.ImageHash, error) {
+			return []models.ImageHash{gone, live}, nil
+		},
+		GetDeduplicationInfoFunc: func(ctx context.Context, hash models.ImageHash) (*models.DeduplicationInfo, error) {
+			return infos[hash.String()], nil
+		},
+		DeleteDeduplicationInfoFunc: func(ctx context.Context, hash models.ImageHash) error {
+			deletedHashes = append(deletedHashes, hash)
+			return nil
+		},
+	}
+	mockRepo := &mockImageRepositoryForImageService{
+		existsFunc: func(ctx context.Context, id string) (bool, error) {
+			return id == liveMaster, nil
+		},
+	}
+	var deletedFolders []string
+	mockStorage := &mockStorageProviderForImageService{
+		deleteFolderFunc: func(ctx context.Context, prefix string) error {
+			deletedFolders = append(deletedFolders, prefix)
+			return nil
+		},
+	}
+	service := NewImageService(mockRepo, dedupRepo, mockStorage, &mockProcessorServiceForImageService{}, testutil.TestConfig())
+
+	t.Run("lists orphans with their master image", func(t *testing.T) {
+		report, err := service.FindOrphanedHashes(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, report.Count)
+		assert.Equal(t, models.OrphanedHash{Hash: gone, MasterImageID: goneMaster, StorageKey: "images/" + goneMaster + "/original.jpg"}, report.Hashes[0])
+		assert.Zero(t, report.Removed)
+		assert.Empty(t, deletedHashes, "listing must not delete anything")
+	})
+
+	t.Run("purges records and the files of removed masters", func(t *testing.T) {
+		report, err := service.RemoveOrphanedHashes(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, report.Removed)
+		assert.Zero(t, report.Skipped)
+		assert.Equal(t, []models.ImageHash{gone, live}, deletedHashes)
+		assert.Equal(t, []string{"images/" + goneMaster}, deletedFolders, "files of a master that still exists are kept")
+	})
+
+	t.Run("scan failure", func(t *testing.T) {
+		failing := NewImageService(mockRepo, &testutil.MockDeduplicationRepository{
+			GetOrphanedHashesFunc: func(ctx context.Context) ([]models.ImageHash, error) {
+				return nil, errors.New("connection refused")
+			},
+		}, mockStorage, &mockProcessorServiceForImageService{}, testutil.TestConfig())
+
+		_, err := failing.RemoveOrphanedHashes(context.Background())
+
+		assert.IsType(t, models.StorageError{}, err)
+	})
+}
+
+func TestImageService_RemoveOrphanedHashes_RechecksReferences(t *testing.T) {
+	const uploadID = "a1b2c3d4-0000-4000-8000-0000000000ff"
+	orphaned := func(hash models.ImageHash, master string) *models.DeduplicationInfo {
+		info := models.NewDeduplicationInfo(hash, master, "images/"+master+"/original.jpg")
+		info.RemoveReference(master)
+		return info
+	}
+
+	t.Run("record referenced after the scan is spared", func(t *testing.T) {
+		hash := models.ImageHash{Algorithm: "SHA256", Value: "cccc", Size: 30}
+		referenced := orphaned(hash, "a1b2c3d4-0000-4000-8000-000000000003")
+		referenced.AddReference(uploadID)
+
+		var deleted int
+		dedupRepo := &testutil.MockDeduplicationRepository{
+			GetOrphanedHashesFunc: func(ctx context.Context) ([]models.ImageHash, error) {
+				return []models.ImageHash{hash}, nil
+			},
+			GetDeduplicationInfoFunc: func(ctx context.Context, h models.ImageHash) (*models.DeduplicationInfo, error) {
+				return referenced, nil
+			},
+			DeleteDeduplicationInfoFunc: func(ctx context.Context, h models.ImageHash) error {
+				deleted++
+				return nil
+			},
+		}
+		mockStorage := &mockStorageProviderForImageService{
+			deleteFolderFunc: func(ctx context.Context, prefix string) error {
+				t.Errorf("files of a referenced record must not be deleted, got %s", prefix)
+				return nil
+			},
+		}
+		service := NewImageService(&mockImageRepositoryForImageService{}, dedupRepo, mockStorage, &mockProcessorServiceForImageService{}, testutil.TestConfig())
+
+		report, err := service.RemoveOrphanedHashes(context.Background())
+
+		require.NoError(t, err)
+		assert.Zero(t, report.Removed)
+		assert.Equal(t, 1, report.Skipped)
+		assert.Zero(t, deleted)
+	})
+
+	t.Run("record referenced during the file purge is kept", func(t *testing.T) {
+		hash := models.ImageHash{Algorithm: "SHA256", Value: "dddd", Size: 40}
+		info := orphaned(hash, "a1b2c3d4-0000-4000-8000-000000000004")
+
+		var deleted int
+		dedupRepo := &testutil.MockDeduplicationRepository{
+			GetOrphanedHashesFunc: func(ctx context.Context) ([]models.ImageHash, error) {
+				return []models.ImageHash{hash}, nil
+			},
+			GetDeduplicationInfoFunc: func(ctx context.Context, h models.ImageHash) (*models.DeduplicationInfo, error) {
+				copied := *info
+				return &copied, nil
+			},
+			DeleteDeduplicationInfoFunc: func(ctx context.Context, h models.ImageHash) error {
+				deleted++
+				return nil
+			},
+		}
+		mockStorage := &mockStorageProviderForImageService{
+			deleteFolderFunc: func(ctx context.Context, prefix string) error {
+				// A concurrent upload shares the content while its files are purged
+				info.AddReference(uploadID)
+				return nil
+			},
+		}
+		service := NewImageService(&mockImageRepositoryForImageService{}, dedupRepo, mockStorage, &mockProcessorServiceForImageService{}, testutil.TestConfig())
+
+		report, err := service.RemoveOrphanedHashes(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.Skipped)
+		assert.Zero(t, deleted)
+	})
+
+	t.Run("record is kept while files remain", func(t *testing.T) {
+		hash := models.ImageHash{Algorithm: "SHA256", Value: "eeee", Size: 50}
+		info := orphaned(hash, "a1b2c3d4-0000-4000-8000-000000000005")
+
+		var deleted int
+		dedupRepo := &testutil.MockDeduplicationRepository{
+			GetOrphanedHashesFunc: func(ctx context.Context) ([]models.ImageHash, error) {
+				return []models.ImageHash{hash}, nil
+			},
+			GetDeduplicationInfoFunc: func(ctx context.Context, h models.ImageHash) (*models.DeduplicationInfo, error) {
+				return info, nil
+			},
+			DeleteDeduplicationInfoFunc: func(ctx context.Context, h models.ImageHash) error {
+				deleted++
+				return nil
+			},
+		}
+		mockStorage := &mockStorageProviderForImageService{
+			listObjectsFunc: func(ctx context.Context, prefix string, maxKeys int) ([]storage.ObjectInfo, error) {
+				return []storage.ObjectInfo{{Key: prefix + "original.jpg"}}, nil
+			},
+		}
+		service := NewImageService(&mockImageRepositoryForImageService{}, dedupRepo, mockStorage, &mockProcessorServiceForImageService{}, testutil.TestConfig())
+
+		report, err := service.RemoveOrphanedHashes(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.Skipped)
+		assert.Zero(t, deleted)
+	})
+}
+
+func TestImageService_StartOrphanCleanup(t *testing.T) {
+	hash := models.ImageHash{Algorithm: "SHA256", Value: "ffff", Size: 60}
+	info := models.NewDeduplicationInfo(hash, "a1b2c3d4-0000-4000-8000-000000000006", "images/a1b2c3d4-0000-4000-8000-000000000006/original.jpg")
+	info.RemoveReference(info.MasterImageID)
+
+	deleted := make(chan models.ImageHash, 10)
+	dedupRepo := &testutil.MockDeduplicationRepository{
+		GetOrphanedHashesFunc: func(ctx context.Context) ([]models.ImageHash, error) {
+			return []models.ImageHash{hash}, nil
+		},
+		GetDeduplicationInfoFunc: func(ctx context.Context, h models.ImageHash) (*models.DeduplicationInfo, error) {
+			return info, nil
+		},
+		DeleteDeduplicationInfoFunc: func(ctx context.Context, h models.ImageHash) error {
+			deleted <- h
+			return nil
+		},
+	}
+	cfg := testutil.TestConfig()
+	cfg.Image.DedupOrphanCleanupInterval = 10 * time.Millisecond
+	service := NewImageService(&mockImageRepositoryForImageService{}, dedupRepo, &mockStorageProviderForImageService{}, &mockProcessorServiceForImageService{}, cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	service.StartOrphanCleanup(ctx)
+
+	select {
+	case got := <-deleted:
+		assert.Equal(t, hash, got)
+	case <-time.After(2 * time.Second):
+		t.Fatal("orphan cleanup did not run")
+	}
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// processingSizeBuckets bound the resolution label: a target is labeled by the
+// first bucket its longest side fits in, so label cardinality stays fixed
+var processingSizeBuckets = []struct {
+	label   string
+	maxSide int
+}{
+	{"0-256", 256},
+	{"257-512", 512},
+	{"513-1024", 1024},
+	{"1025-2048", 2048},
+	{"2049+", 0},
+}
+
+// processingSizeBucket returns the size bucket label for a target resolution
+func processingSizeBucket(width, height int) string {
+	side := max(width, height)
+	for _, bucket := range processingSizeBuckets[:len(processingSizeBuckets)-1] {
+		if side <= bucket.maxSide {
+			return bucket.label
+		}
+	}
+	return processingSizeBuckets[len(processingSizeBuckets)-1].label
+}
+
+// processingMetricKey labels a duration sample by output format and size bucket
+type processingMetricKey struct {
+	format string
+	bucket string
+}
+
+// ProcessingDurationSummary aggregates the processing durations of one label pair
+type ProcessingDurationSummary struct {
+	Count   int64   `json:"count"`
+	TotalMs float64 `json:"total_ms"`
+	AvgMs   float64 `json:"avg_ms"`
+	MaxMs   float64 `json:"max_ms"`
+}
+
+// processingMetrics collects ProcessImage durations since startup
+type processingMetrics struct {
+	mu      sync.Mutex
+	samples map[processingMetricKey]*processingDurationSample
+}
+
+type processingDurationSample struct {
+	count int64
+	total time.Duration
+	max   time.Duration
+}
+
+// processingDurations is the process-wide recorder used by ProcessImage
+var processingDurations = &processingMetrics{samples: make(map[processingMetricKey]*processingDurationSample)}
+
+// record adds one processing duration for the output format and target size
+func (m *processingMetrics) record(format string, width, height int, duration time.Duration) {
+	key := processingMetricKey{format: format, bucket: processingSizeBucket(width, height)}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sample, ok := m.samples[key]
+	if !ok {
+		sample = &processingDurationSample{}
+		m.samples[key] = sample
+	}
+	sample.count++
+	sample.total += duration
+	if duration > sample.max {
+		sample.max = duration
+	}
+}
+
+// snapshot returns the summaries keyed by format, then size bucket
+func (m *processingMetrics) snapshot() map[string]map[string]ProcessingDurationSummary {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := make(map[string]map[string]ProcessingDurationSummary)
+	for key, sample := range m.samples {
+		byBucket, ok := result[key.format]
+		if !ok {
+			byBucket = make(map[string]ProcessingDurationSummary)
+			result[key.format] = byBucket
+		}
+		total := durationMillis(sample.total)
+		byBucket[key.bucket] = ProcessingDurationSummary{
+			Count:   sample.count,
+			TotalMs: total,
+			AvgMs:   total / float64(sample.count),
+			MaxMs:   durationMillis(sample.max),
+		}
+	}
+	return result
+}
+
+// ProcessingDurations returns processing duration summaries by output format and size bucket
+func ProcessingDurations() map[string]map[string]ProcessingDurationSummary {
+	return processingDurations.snapshot()
+}
+
+func durationMillis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
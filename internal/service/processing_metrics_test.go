@@ -0,0 +1,45 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessingSizeBucket(t *testing.T) {
+	tests := []struct {
+		width, height int
+		expected      string
+	}{
+		{150, 150, "0-256"},
+		{256, 100, "0-256"},
+		{257, 100, "257-512"},
+		{800, 600, "513-1024"},
+		{600, 1920, "1025-2048"},
+		{4096, 4096, "2049+"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, processingSizeBucket(tt.width, tt.height), "%dx%d", tt.width, tt.height)
+	}
+}
+
+func TestProcessingMetrics_RecordAndSnapshot(t *testing.T) {
+	m := &processingMetrics{samples: make(map[processingMetricKey]*processingDurationSample)}
+
+	m.record("jpeg", 800, 600, 10*time.Millisecond)
+	m.record("jpeg", 1024, 768, 30*time.Millisecond)
+	m.record("png", 150, 150, 5*time.Millisecond)
+
+	snapshot := m.snapshot()
+
+	jpeg := snapshot["jpeg"]["513-1024"]
+	assert.Equal(t, int64(2), jpeg.Count)
+	assert.Equal(t, 40.0, jpeg.TotalMs)
+	assert.Equal(t, 20.0, jpeg.AvgMs)
+	assert.Equal(t, 30.0, jpeg.MaxMs)
+
+	assert.Equal(t, int64(1), snapshot["png"]["0-256"].Count)
+	assert.Len(t, snapshot, 2)
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"resizr/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// ProcessingQueueStats is a snapshot of the processing queue for metrics
+type ProcessingQueueStats struct {
+	Depth     int   `json:"depth"`     // Uploads queued or being processed
+	Capacity  int   `json:"capacity"`  // Depth at which new uploads are rejected
+	Workers   int   `json:"workers"`   // Workers draining the queue
+	Processed int64 `json:"processed"` // Jobs finished without error
+	Failed    int64 `json:"failed"`    // Jobs that returned an error
+	Rejected  int64 `json:"rejected"`  // Uploads turned away because the queue was full
+}
+
+// processingJob is one upload's deferred resolution work
+type processingJob struct {
+	imageID string
+	run     func(ctx context.Context) error
+}
+
+// ProcessingQueue runs upload resolution jobs on a fixed pool of workers. Callers
+// Reserve a slot before storing anything, so a full queue rejects the upload up
+// front, then Submit the job or Release the slot. A slot is held until its job
+// finishes, so Depth counts queued and running jobs. Jobs are kept in memory and
+// are lost on restart.
+type ProcessingQueue struct {
+	jobs    chan processingJob
+	slots   chan struct{}
+	workers int
+
+	startOnce sync.Once
+	processed atomic.Int64
+	failed    atomic.Int64
+	rejected  atomic.Int64
+}
+
+// NewProcessingQueue creates a queue holding at most maxDepth jobs, drained by workers once started
+func NewProcessingQueue(workers, maxDepth int) *ProcessingQueue {
+	return &ProcessingQueue{
+		jobs:    make(chan processingJob, maxDepth),
+		slots:   make(chan struct{}, maxDepth),
+		workers: workers,
+	}
+}
+
+// Reserve claims a slot for a job, reporting false when the queue is full
+func (q *ProcessingQueue) Reserve() bool {
+	select {
+	case q.slots <- struct{}{}:
+		return true
+	default:
+		q.rejected.Add(1)
+		return false
+	}
+}
+
+// Release gives back a reserved slot whose job will not be submitted
+func (q *ProcessingQueue) Release() {
+	<-q.slots
+}
+
+// Submit queues a job in a previously reserved slot. It never blocks.
+func (q *ProcessingQueue) Submit(imageID string, run func(ctx context.Context) error) {
+	q.jobs <- processingJob{imageID: imageID, run: run}
+}
+
+// Start launches the workers; they stop when ctx is cancelled. Jobs still queued
+// at that point are dropped. Calling Start again has no effect.
+func (q *ProcessingQueue) Start(ctx context.Context) {
+	q.startOnce.Do(func() {
+		logger.Info("Starting processing queue",
+			zap.Int("workers", q.workers),
+			zap.Int("max_depth", cap(q.slots)))
+
+		for i := 0; i < q.workers; i++ {
+			go q.work(ctx)
+		}
+	})
+}
+
+// work runs jobs until ctx is cancelled
+func (q *ProcessingQueue) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-q.jobs:
+			if err := job.run(ctx); err != nil {
+				q.failed.Add(1)
+				logger.WarnWithContext(ctx, "Queued processing job failed",
+					zap.String("image_id", job.imageID),
+					zap.Error(err))
+			} else {
+				q.processed.Add(1)
+			}
+			<-q.slots
+		}
+	}
+}
+
+// Stats returns the current depth and counters
+func (q *ProcessingQueue) Stats() ProcessingQueueStats {
+	return ProcessingQueueStats{
+		Depth:     len(q.slots),
+		Capacity:  cap(q.slots),
+		Workers:   q.workers,
+		Processed: q.processed.Load(),
+		Failed:    q.failed.Load(),
+		Rejected:  q.rejected.Load(),
+	}
+}
+
+// activeQueue is the queue of the running image service, reported in metrics
+var activeQueue atomic.Pointer[ProcessingQueue]
+
+// ProcessingQueueMetrics returns the stats of the active processing queue, or
+// false when uploads are processed in the request
+func ProcessingQueueMetrics() (ProcessingQueueStats, bool) {
+	q := activeQueue.Load()
+	if q == nil {
+		return ProcessingQueueStats{}, false
+	}
+	return q.Stats(), true
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessingQueue_ProcessesSubmittedJobs(t *testing.T) {
+	queue := NewProcessingQueue(2, 4)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan string, 2)
+	for _, id := range []string{"first", "second"} {
+		require.True(t, queue.Reserve())
+		queue.Submit(id, func(ctx context.Context) error {
+			done <- id
+			return nil
+		})
+	}
+	assert.Equal(t, 2, queue.Stats().Depth)
+
+	queue.Start(ctx)
+
+	assert.ElementsMatch(t, []string{"first", "second"}, []string{<-done, <-done})
+	require.Eventually(t, func() bool { return queue.Stats().Processed == 2 }, time.Second, time.Millisecond)
+	assert.Equal(t, 0, queue.Stats().Depth)
+}
+
+func TestProcessingQueue_CountsFailedJobs(t *testing.T) {
+	queue := NewProcessingQueue(1, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	queue.Start(ctx)
+
+	require.True(t, queue.Reserve())
+	queue.Submit("broken", func(ctx context.Context) error {
+		return errors.New("resize failed")
+	})
+
+	require.Eventually(t, func() bool { return queue.Stats().Failed == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, int64(0), queue.Stats().Processed)
+	assert.True(t, queue.Reserve(), "the failed job's slot should be freed")
+}
+
+func TestProcessingQueue_RejectsWhenFull(t *testing.T) {
+	queue := NewProcessingQueue(1, 2)
+
+	assert.True(t, queue.Reserve())
+	assert.True(t, queue.Reserve())
+	assert.False(t, queue.Reserve())
+
+	stats := queue.Stats()
+	assert.Equal(t, 2, stats.Depth)
+	assert.Equal(t, 2, stats.Capacity)
+	assert.Equal(t, int64(1), stats.Rejected)
+
+	queue.Release()
+	assert.True(t, queue.Reserve(), "a released slot should be reusable")
+}
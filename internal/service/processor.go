@@ -5,42 +5,102 @@ import (
 	"fmt"
 	"image"
 	"image/color"
+	"image/draw"
 	"image/gif"
-	"image/jpeg"
-	"image/png"
 	"net/http"
+	"time"
 
+	"resizr/internal/models"
 	"resizr/pkg/logger"
 
 	"github.com/disintegration/imaging"
 	"github.com/icza/gox/imagex/colorx"
 	"go.uber.org/zap"
+	"golang.org/x/image/font/opentype"
+	_ "golang.org/x/image/tiff" // registers TIFF with image.Decode (first page only for multi-page files)
 	"golang.org/x/image/webp"
 )
 
 // ProcessorServiceImpl implements the ProcessorService interface
 type ProcessorServiceImpl struct {
-	maxWidth  int // Maximum allowed image width
-	maxHeight int // Maximum allowed image height
+	maxWidth        int // Maximum allowed target width
+	maxHeight       int // Maximum allowed target height
+	maxSourceWidth  int // Maximum allowed source image width
+	maxSourceHeight int // Maximum allowed source image height
+	buffers         *bufferPool
+	watermarkFont   *opentype.Font // Font of text watermarks; nil uses the embedded default
+	defaultType     string         // MIME type assumed for decodable data whose format cannot be detected
+	convertToSRGB   bool           // Convert sources with a non-sRGB ICC profile to sRGB before resizing
+	stepRatio       float64        // Downscales beyond this factor are pre-shrunk in halving steps; 0 disables
+	preserveGray    bool           // Encode outputs without meaningful color as grayscale
 }
 
-// NewProcessorService creates a new image processor service
-func NewProcessorService(maxWidth, maxHeight int) ProcessorService {
+// NewProcessorService creates a new image processor service.
+// maxWidth/maxHeight cap generated resolutions; maxSourceWidth/maxSourceHeight cap uploaded originals.
+func NewProcessorService(maxWidth, maxHeight, maxSourceWidth, maxSourceHeight int, opts ...ProcessorOption) ProcessorService {
 	if maxWidth <= 0 {
 		maxWidth = 4096 // Default maximum width
 	}
 	if maxHeight <= 0 {
 		maxHeight = 4096 // Default maximum height
 	}
+	if maxSourceWidth <= 0 {
+		maxSourceWidth = 8192 // Default maximum source width
+	}
+	if maxSourceHeight <= 0 {
+		maxSourceHeight = 8192 // Default maximum source height
+	}
+
+	processor := &ProcessorServiceImpl{
+		maxWidth:        maxWidth,
+		maxHeight:       maxHeight,
+		maxSourceWidth:  maxSourceWidth,
+		maxSourceHeight: maxSourceHeight,
+		buffers:         newBufferPool(DefaultBufferPoolMaxSize),
+	}
+	for _, opt := range opts {
+		opt(processor)
+	}
+	return processor
+}
 
-	return &ProcessorServiceImpl{
-		maxWidth:  maxWidth,
-		maxHeight: maxHeight,
+// WithDefaultContentType makes DetectFormat report mimeType for data whose format cannot
+// be detected but which still decodes as an image; empty keeps rejecting such data
+func WithDefaultContentType(mimeType string) ProcessorOption {
+	return func(p *ProcessorServiceImpl) {
+		p.defaultType = mimeType
 	}
 }
 
-// DetectFormat detects image format from data
+// WithMultiStepDownscale pre-shrinks sources in halving steps with a box filter
+// while a resize would shrink them by more than ratio on either axis, so the final
+// resampling pass starts close to the target size. Large ratios in a single pass
+// alias with the faster filters. A ratio of 0 disables it.
+func WithMultiStepDownscale(ratio float64) ProcessorOption {
+	return func(p *ProcessorServiceImpl) {
+		p.stepRatio = ratio
+	}
+}
+
+// DetectFormat detects image format from data. When detection is inconclusive and a
+// default content type is configured, data that fully decodes is reported as that type.
 func (p *ProcessorServiceImpl) DetectFormat(data []byte) (string, error) {
+	mimeType, err := p.detectFormat(data)
+	if err == nil || p.defaultType == "" || len(data) == 0 {
+		return mimeType, err
+	}
+
+	if _, _, decodeErr := p.GetDimensions(data); decodeErr != nil {
+		return "", err
+	}
+	logger.Debug("Format detection inconclusive, using the default content type",
+		zap.String("default_content_type", p.defaultType),
+		zap.String("detection_error", err.Error()))
+	return p.defaultType, nil
+}
+
+// detectFormat sniffs the image format from its leading bytes
+func (p *ProcessorServiceImpl) detectFormat(data []byte) (string, error) {
 	if len(data) < 512 {
 		return "", fmt.Errorf("insufficient data for format detection")
 	}
@@ -92,11 +152,24 @@ func (p *ProcessorServiceImpl) detectFormatByHeader(data []byte) (string, error)
 		}
 	}
 
+	// TIFF: little-endian "II*\x00" or big-endian "MM\x00*"
+	if bytes.HasPrefix(data, []byte{0x49, 0x49, 0x2A, 0x00}) || bytes.HasPrefix(data, []byte{0x4D, 0x4D, 0x00, 0x2A}) {
+		return "image/tiff", nil
+	}
+
 	return "", fmt.Errorf("unsupported image format")
 }
 
-// GetDimensions extracts image dimensions
+// GetDimensions extracts image dimensions, enforcing the source dimension caps
 func (p *ProcessorServiceImpl) GetDimensions(data []byte) (width, height int, err error) {
+	// Check header dimensions before decoding pixels to reject decompression bombs cheaply
+	if cfg, cfgErr := p.decodeConfig(data); cfgErr == nil {
+		if cfg.Width > p.maxSourceWidth || cfg.Height > p.maxSourceHeight {
+			return 0, 0, fmt.Errorf("image dimensions %dx%d exceed maximum allowed %dx%d",
+				cfg.Width, cfg.Height, p.maxSourceWidth, p.maxSourceHeight)
+		}
+	}
+
 	// Decode image to get dimensions
 	img, _, err := p.decodeImage(data)
 	if err != nil {
@@ -112,9 +185,9 @@ func (p *ProcessorServiceImpl) GetDimensions(data []byte) (width, height int, er
 		return 0, 0, fmt.Errorf("invalid image dimensions: %dx%d", width, height)
 	}
 
-	if width > p.maxWidth || height > p.maxHeight {
+	if width > p.maxSourceWidth || height > p.maxSourceHeight {
 		return 0, 0, fmt.Errorf("image dimensions %dx%d exceed maximum allowed %dx%d",
-			width, height, p.maxWidth, p.maxHeight)
+			width, height, p.maxSourceWidth, p.maxSourceHeight)
 	}
 
 	return width, height, nil
@@ -122,11 +195,14 @@ func (p *ProcessorServiceImpl) GetDimensions(data []byte) (width, height int, er
 
 // ProcessImage resizes image to specified resolution
 func (p *ProcessorServiceImpl) ProcessImage(data []byte, config ResizeConfig) ([]byte, error) {
+	start := time.Now()
+
 	logger.Debug("Processing image",
 		zap.Int("target_width", config.Width),
 		zap.Int("target_height", config.Height),
 		zap.String("mode", string(config.Mode)),
 		zap.Int("quality", config.Quality),
+		zap.String("jpeg_subsampling", config.JPEGSubsampling),
 		zap.String("background_color", config.BackgroundColor))
 
 	// Decode original image
@@ -135,6 +211,16 @@ func (p *ProcessorServiceImpl) ProcessImage(data []byte, config ResizeConfig) ([
 		return nil, fmt.Errorf("failed to decode source image: %w", err)
 	}
 
+	// Outputs carry no ICC profile, so wide-gamut sources are brought into sRGB first
+	if p.convertToSRGB {
+		srcImage = convertColorProfile(data, format, srcImage)
+	}
+
+	// An explicit crop keeps the region at its native size
+	if config.Crop != nil {
+		config.Width, config.Height = config.Crop.Width, config.Crop.Height
+	}
+
 	// Validate target dimensions
 	if config.Width <= 0 || config.Height <= 0 {
 		return nil, fmt.Errorf("invalid target dimensions: %dx%d", config.Width, config.Height)
@@ -151,31 +237,69 @@ func (p *ProcessorServiceImpl) ProcessImage(data []byte, config ResizeConfig) ([
 		return nil, fmt.Errorf("failed to parse background color HEX: %w", err)
 	}
 
-	// Apply resize based on mode
-	var resizedImage image.Image
-
-	switch config.Mode {
-	case ResizeModeSmartFit:
-		resizedImage = p.smartFitResize(srcImage, config.Width, config.Height, backgroundColor)
-	case ResizeModeCrop:
-		resizedImage = p.cropResize(srcImage, config.Width, config.Height)
-	case ResizeModeStretch:
-		resizedImage = imaging.Resize(srcImage, config.Width, config.Height, imaging.Lanczos)
-	default:
-		// Default to smart fit
-		resizedImage = p.smartFitResize(srcImage, config.Width, config.Height, backgroundColor)
-	}
+	filter := resampleFilter(config.Filter)
 
 	// Encode the processed image using the specified output format
 	outputFormat := config.Format
 	if outputFormat == "" {
 		outputFormat = format // Fall back to input format if not specified
 	}
-	processedData, err := p.encodeImage(resizedImage, outputFormat, config.Quality)
+	if outputFormat == "tiff" {
+		outputFormat = "png" // TIFF is accepted as input only; derivatives use a web format
+	}
+
+	// Animated GIF sources keep their animation when converted to WebP; a size
+	// budget needs the quality-searchable static encoder instead
+	if outputFormat == "webp" && format == "gif" && config.Crop == nil && config.MaxBytes <= 0 {
+		animated, err := p.processAnimatedWebP(data, config, backgroundColor, filter)
+		if err != nil {
+			logger.Warn("Animated WebP encoding failed, falling back to static first frame",
+				zap.Error(err))
+		} else if animated != nil {
+			logger.Debug("Animated image processing completed",
+				zap.Int("original_size", len(data)),
+				zap.Int("processed_size", len(animated)))
+			processingDurations.record(outputFormat, config.Width, config.Height, time.Since(start))
+			return animated, nil
+		}
+	}
+
+	// Apply resize based on mode, or extract the requested region
+	var resizedImage image.Image
+	if config.Crop != nil {
+		resizedImage, err = cropRect(srcImage, *config.Crop)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		resizedImage = p.resize(srcImage, config, backgroundColor, filter)
+	}
+
+	if config.Watermark != nil {
+		resizedImage, err = p.drawTextWatermark(resizedImage, *config.Watermark)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if p.preserveGray && grayscaleFormat(outputFormat) {
+		resizedImage = toGrayscale(resizedImage)
+	}
+
+	var processedData []byte
+	if outputFormat == OutputFormatAuto {
+		processedData, outputFormat, err = p.encodeSmallest(resizedImage, config.AutoFormats, config.Quality, config.JPEGSubsampling)
+	} else if config.MaxBytes > 0 {
+		processedData, err = p.encodeWithinSize(resizedImage, outputFormat, config.Quality, config.JPEGSubsampling, config.MaxBytes)
+	} else {
+		processedData, err = p.encodeImage(resizedImage, outputFormat, config.Quality, config.JPEGSubsampling)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to encode processed image: %w", err)
 	}
 
+	processingDurations.record(outputFormat, config.Width, config.Height, time.Since(start))
+
 	logger.Debug("Image processing completed",
 		zap.Int("original_size", len(data)),
 		zap.Int("processed_size", len(processedData)),
@@ -220,7 +344,8 @@ func (p *ProcessorServiceImpl) ValidateImage(data []byte, maxSize int64) error {
 
 // Helper methods
 
-// decodeImage decodes image data into image.Image
+// decodeImage decodes image data into image.Image.
+// Multi-page TIFFs decode to their first page.
 func (p *ProcessorServiceImpl) decodeImage(data []byte) (image.Image, string, error) {
 	reader := bytes.NewReader(data)
 
@@ -238,107 +363,361 @@ func (p *ProcessorServiceImpl) decodeImage(data []byte) (image.Image, string, er
 	return img, format, nil
 }
 
-// encodeImage encodes image.Image to bytes
-func (p *ProcessorServiceImpl) encodeImage(img image.Image, format string, quality int) ([]byte, error) {
-	var buf bytes.Buffer
+// decodeConfig reads image dimensions from the header without decoding pixel data
+func (p *ProcessorServiceImpl) decodeConfig(data []byte) (image.Config, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		// Try WebP specifically (not in standard library)
+		if webpCfg, webpErr := webp.DecodeConfig(bytes.NewReader(data)); webpErr == nil {
+			return webpCfg, nil
+		}
+		return image.Config{}, err
+	}
+	return cfg, nil
+}
+
+// encodeImage encodes image.Image to bytes. Encoding goes through a pooled
+// buffer; the result is copied out so the buffer can be reused.
+func (p *ProcessorServiceImpl) encodeImage(img image.Image, format string, quality int, subsampling string) ([]byte, error) {
+	buf := p.buffers.get()
+	defer p.buffers.put(buf)
 
 	switch format {
 	case "jpeg":
-		options := &jpeg.Options{Quality: quality}
-		if err := jpeg.Encode(&buf, img, options); err != nil {
+		if err := encodeJPEG(buf, img, quality, subsampling); err != nil {
 			return nil, err
 		}
 	case "png":
-		encoder := &png.Encoder{CompressionLevel: png.DefaultCompression}
-		if err := encoder.Encode(&buf, img); err != nil {
+		if err := p.buffers.pngEncoder().Encode(buf, img); err != nil {
 			return nil, err
 		}
 	case "gif":
 		options := &gif.Options{NumColors: 256}
-		if err := gif.Encode(&buf, img, options); err != nil {
+		if err := gif.Encode(buf, img, options); err != nil {
 			return nil, err
 		}
 	case "webp":
-		// For WebP, we'll fall back to JPEG for now
-		// (WebP encoding requires additional libraries)
-		options := &jpeg.Options{Quality: quality}
-		if err := jpeg.Encode(&buf, img, options); err != nil {
+		// Static WebP output falls back to JPEG; only animated sources use the
+		// built-in lossless encoder (see processAnimatedWebP)
+		if err := encodeJPEG(buf, img, quality, subsampling); err != nil {
 			return nil, err
 		}
 	default:
 		return nil, fmt.Errorf("unsupported output format: %s", format)
 	}
 
-	return buf.Bytes(), nil
+	return bytes.Clone(buf.Bytes()), nil
 }
 
-// smartFitResize implements smart fit algorithm
-func (p *ProcessorServiceImpl) smartFitResize(src image.Image, targetWidth, targetHeight int, backgroundColor color.Color) image.Image {
-	srcBounds := src.Bounds()
-	srcWidth := srcBounds.Dx()
-	srcHeight := srcBounds.Dy()
+// encodeSmallest encodes img in every candidate format and returns the smallest output
+// with its format; earlier candidates win ties. JPEG and the JPEG-backed static WebP
+// cannot keep transparency, so images with transparent pixels skip them and fall back
+// to PNG when no other candidate is left.
+func (p *ProcessorServiceImpl) encodeSmallest(img image.Image, candidates []string, quality int, subsampling string) ([]byte, string, error) {
+	opaque := isOpaque(img)
+
+	var best []byte
+	var bestFormat string
+	for _, format := range candidates {
+		if !opaque && (format == "jpeg" || format == "webp") {
+			continue
+		}
+		encoded, err := p.encodeImage(img, format, quality, subsampling)
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %w", format, err)
+		}
+		if best == nil || len(encoded) < len(best) {
+			best, bestFormat = encoded, format
+		}
+	}
 
-	// Calculate aspect ratios
-	srcAspect := float64(srcWidth) / float64(srcHeight)
-	targetAspect := float64(targetWidth) / float64(targetHeight)
+	if best == nil {
+		encoded, err := p.encodeImage(img, "png", quality, subsampling)
+		return encoded, "png", err
+	}
 
-	var resizedWidth, resizedHeight int
+	logger.Debug("Selected smallest output format",
+		zap.Strings("candidates", candidates),
+		zap.String("format", bestFormat),
+		zap.Int("size", len(best)))
+	return best, bestFormat, nil
+}
 
-	// Determine resize dimensions to fit inside target while maintaining aspect ratio
-	if srcAspect > targetAspect {
-		// Source is wider - fit by width
-		resizedWidth = targetWidth
-		resizedHeight = int(float64(targetWidth) / srcAspect)
-	} else {
-		// Source is taller - fit by height
-		resizedHeight = targetHeight
-		resizedWidth = int(float64(targetHeight) * srcAspect)
+// isOpaque reports whether every pixel of img is fully opaque
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
+
+// encodeWithinSize encodes img at the highest quality, up to quality, whose output
+// fits in maxBytes. Quality is binary searched down to MinTargetSizeQuality; only
+// the lossy JPEG and WebP outputs can be fitted this way.
+func (p *ProcessorServiceImpl) encodeWithinSize(img image.Image, format string, quality int, subsampling string, maxBytes int64) ([]byte, error) {
+	if format != "jpeg" && format != "webp" {
+		return nil, fmt.Errorf("a target size requires jpeg or webp output, got %s", format)
+	}
+
+	best, err := p.encodeImage(img, format, quality, subsampling)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(best)) <= maxBytes {
+		return best, nil
+	}
+
+	best = nil
+	low, high := MinTargetSizeQuality, quality-1
+	for low <= high {
+		mid := (low + high) / 2
+		encoded, err := p.encodeImage(img, format, mid, subsampling)
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(encoded)) <= maxBytes {
+			best = encoded
+			low = mid + 1
+		} else {
+			high = mid - 1
+		}
 	}
 
+	if best == nil {
+		return nil, fmt.Errorf("output does not fit in %d bytes even at quality %d", maxBytes, MinTargetSizeQuality)
+	}
+	return best, nil
+}
+
+// resize applies the configured resize mode to a single frame
+func (p *ProcessorServiceImpl) resize(src image.Image, config ResizeConfig, backgroundColor color.Color, filter imaging.ResampleFilter) image.Image {
+	switch config.Mode {
+	case ResizeModeSmartFit:
+		return p.smartFitResize(src, config.Width, config.Height, backgroundColor, filter)
+	case ResizeModeCrop:
+		return p.cropResize(src, config.Width, config.Height, filter)
+	case ResizeModeStretch:
+		return p.scale(src, config.Width, config.Height, filter)
+	default:
+		// Default to smart fit
+		return p.smartFitResize(src, config.Width, config.Height, backgroundColor, filter)
+	}
+}
+
+// cropRect extracts rect from src, rejecting rectangles that leave the source bounds
+func cropRect(src image.Image, rect models.CropRect) (image.Image, error) {
+	bounds := src.Bounds()
+	if rect.X < 0 || rect.Y < 0 || rect.Width <= 0 || rect.Height <= 0 ||
+		rect.X+rect.Width > bounds.Dx() || rect.Y+rect.Height > bounds.Dy() {
+		return nil, fmt.Errorf("crop rectangle %s is outside the source bounds %dx%d", rect, bounds.Dx(), bounds.Dy())
+	}
+
+	region := image.Rect(rect.X, rect.Y, rect.X+rect.Width, rect.Y+rect.Height).Add(bounds.Min)
+	return imaging.Crop(src, region), nil
+}
+
+// processAnimatedWebP resizes every frame of an animated GIF and encodes them as an animated WebP.
+// It returns nil data without error when the GIF has a single frame.
+func (p *ProcessorServiceImpl) processAnimatedWebP(data []byte, config ResizeConfig, backgroundColor color.Color, filter imaging.ResampleFilter) ([]byte, error) {
+	anim, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode GIF frames: %w", err)
+	}
+	if len(anim.Image) < 2 {
+		return nil, nil
+	}
+
+	// Compose frames onto a full canvas so each output frame is self-contained
+	frames := make([]image.Image, 0, len(anim.Image))
+	delays := make([]int, 0, len(anim.Image))
+	composeGIFFrames(anim, func(i int, canvas *image.NRGBA) bool {
+		frames = append(frames, p.resize(imaging.Clone(canvas), config, backgroundColor, filter))
+
+		delay := 0
+		if i < len(anim.Delay) {
+			delay = anim.Delay[i] * 10 // GIF delays are in hundredths of a second
+		}
+		delays = append(delays, delay)
+		return true
+	})
+
+	return encodeAnimatedWebP(frames, delays, webpLoopCount(anim.LoopCount))
+}
+
+// composeGIFFrames draws the frames of anim in order onto a full canvas, applying
+// each frame's disposal, and calls visit with the canvas after every frame is drawn.
+// The canvas is reused between calls; visit must copy it to keep it. Returning
+// false from visit stops the composition.
+func composeGIFFrames(anim *gif.GIF, visit func(i int, canvas *image.NRGBA) bool) {
+	canvas := image.NewNRGBA(image.Rect(0, 0, anim.Config.Width, anim.Config.Height))
+	for i, frame := range anim.Image {
+		disposal := byte(gif.DisposalNone)
+		if i < len(anim.Disposal) {
+			disposal = anim.Disposal[i]
+		}
+		var previous *image.NRGBA
+		if disposal == gif.DisposalPrevious {
+			previous = image.NewNRGBA(canvas.Rect)
+			copy(previous.Pix, canvas.Pix)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+		if !visit(i, canvas) {
+			return
+		}
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+}
+
+// webpLoopCount maps a GIF loop count (0 forever, -1 play once, n extra repeats)
+// to a WebP loop count (0 forever, n total plays)
+func webpLoopCount(gifLoopCount int) int {
+	switch {
+	case gifLoopCount == 0:
+		return 0
+	case gifLoopCount < 0:
+		return 1
+	default:
+		return gifLoopCount + 1
+	}
+}
+
+// smartFitResize implements smart fit algorithm
+func (p *ProcessorServiceImpl) smartFitResize(src image.Image, targetWidth, targetHeight int, backgroundColor color.Color, filter imaging.ResampleFilter) image.Image {
+	srcBounds := src.Bounds()
+	geometry := CalculateResizeGeometry(srcBounds.Dx(), srcBounds.Dy(), targetWidth, targetHeight, ResizeModeSmartFit)
+
 	// Resize the image maintaining aspect ratio
-	resized := imaging.Resize(src, resizedWidth, resizedHeight, imaging.Lanczos)
+	resized := p.scale(src, geometry.ResizedWidth, geometry.ResizedHeight, filter)
 
 	// Create target canvas and center the resized image
 	canvas := imaging.New(targetWidth, targetHeight, backgroundColor)
 
-	// Calculate position to center the image
-	x := (targetWidth - resizedWidth) / 2
-	y := (targetHeight - resizedHeight) / 2
-
 	// Paste the resized image onto the canvas
-	result := imaging.Paste(canvas, resized, image.Pt(x, y))
+	result := imaging.Paste(canvas, resized, image.Pt(geometry.OffsetX, geometry.OffsetY))
 
 	return result
 }
 
 // cropResize implements crop resize algorithm
-func (p *ProcessorServiceImpl) cropResize(src image.Image, targetWidth, targetHeight int) image.Image {
+func (p *ProcessorServiceImpl) cropResize(src image.Image, targetWidth, targetHeight int, filter imaging.ResampleFilter) image.Image {
 	srcBounds := src.Bounds()
-	srcWidth := srcBounds.Dx()
-	srcHeight := srcBounds.Dy()
-
-	// Calculate aspect ratios
-	srcAspect := float64(srcWidth) / float64(srcHeight)
-	targetAspect := float64(targetWidth) / float64(targetHeight)
-
-	var resizedWidth, resizedHeight int
-
-	// Determine resize dimensions to fill target area
-	if srcAspect > targetAspect {
-		// Source is wider - fit by height, crop width
-		resizedHeight = targetHeight
-		resizedWidth = int(float64(targetHeight) * srcAspect)
-	} else {
-		// Source is taller - fit by width, crop height
-		resizedWidth = targetWidth
-		resizedHeight = int(float64(targetWidth) / srcAspect)
-	}
+	geometry := CalculateResizeGeometry(srcBounds.Dx(), srcBounds.Dy(), targetWidth, targetHeight, ResizeModeCrop)
 
 	// Resize the image
-	resized := imaging.Resize(src, resizedWidth, resizedHeight, imaging.Lanczos)
+	resized := p.scale(src, geometry.ResizedWidth, geometry.ResizedHeight, filter)
 
 	// Crop to target size from center
 	cropped := imaging.CropCenter(resized, targetWidth, targetHeight)
 
 	return cropped
 }
+
+// scale resizes src to width x height, first stepping down large downscales when
+// WithMultiStepDownscale is set
+func (p *ProcessorServiceImpl) scale(src image.Image, width, height int, filter imaging.ResampleFilter) image.Image {
+	bounds := src.Bounds()
+	for _, step := range downscaleSteps(bounds.Dx(), bounds.Dy(), width, height, p.stepRatio) {
+		src = imaging.Resize(src, step.X, step.Y, imaging.Box)
+	}
+	return imaging.Resize(src, width, height, filter)
+}
+
+// downscaleSteps returns the intermediate sizes a srcWidth x srcHeight source is
+// halved through before its final resize to width x height: none when ratio is
+// below 2 or the source is at most ratio times the target on both axes. No step
+// goes below the target size.
+func downscaleSteps(srcWidth, srcHeight, width, height int, ratio float64) []image.Point {
+	if ratio < 2 || width <= 0 || height <= 0 {
+		return nil
+	}
+
+	var steps []image.Point
+	for float64(srcWidth) > ratio*float64(width) || float64(srcHeight) > ratio*float64(height) {
+		srcWidth, srcHeight = max(srcWidth/2, width), max(srcHeight/2, height)
+		steps = append(steps, image.Pt(srcWidth, srcHeight))
+	}
+	return steps
+}
+
+// resampleFilter maps a configured filter name to its imaging filter, defaulting to Lanczos
+func resampleFilter(name string) imaging.ResampleFilter {
+	switch name {
+	case ResampleFilterBilinear:
+		return imaging.Linear
+	case ResampleFilterNearest:
+		return imaging.NearestNeighbor
+	case ResampleFilterCatmullRom:
+		return imaging.CatmullRom
+	default:
+		return imaging.Lanczos
+	}
+}
+
+// CalculateResizeGeometry computes how a source of srcWidth x srcHeight is scaled and
+// placed onto a targetWidth x targetHeight output for the given mode, without touching pixels
+func CalculateResizeGeometry(srcWidth, srcHeight, targetWidth, targetHeight int, mode ResizeMode) ResizeGeometry {
+	geometry := ResizeGeometry{
+		OutputWidth:    targetWidth,
+		OutputHeight:   targetHeight,
+		ResizedWidth:   targetWidth,
+		ResizedHeight:  targetHeight,
+		AspectHandling: AspectHandlingExact,
+	}
+
+	if srcWidth <= 0 || srcHeight <= 0 || targetWidth <= 0 || targetHeight <= 0 {
+		return geometry
+	}
+
+	// Calculate aspect ratios
+	srcAspect := float64(srcWidth) / float64(srcHeight)
+	targetAspect := float64(targetWidth) / float64(targetHeight)
+
+	switch mode {
+	case ResizeModeStretch:
+		if srcWidth*targetHeight != srcHeight*targetWidth {
+			geometry.AspectHandling = AspectHandlingStretched
+		}
+	case ResizeModeCrop:
+		// Determine resize dimensions to fill target area
+		if srcAspect > targetAspect {
+			// Source is wider - fit by height, crop width
+			geometry.ResizedHeight = targetHeight
+			geometry.ResizedWidth = int(float64(targetHeight) * srcAspect)
+		} else {
+			// Source is taller - fit by width, crop height
+			geometry.ResizedWidth = targetWidth
+			geometry.ResizedHeight = int(float64(targetWidth) / srcAspect)
+		}
+		geometry.OffsetX = -(geometry.ResizedWidth - targetWidth) / 2
+		geometry.OffsetY = -(geometry.ResizedHeight - targetHeight) / 2
+		if geometry.ResizedWidth != targetWidth || geometry.ResizedHeight != targetHeight {
+			geometry.AspectHandling = AspectHandlingCropped
+		}
+	default:
+		// Smart fit: determine resize dimensions to fit inside target while maintaining aspect ratio
+		if srcAspect > targetAspect {
+			// Source is wider - fit by width
+			geometry.ResizedWidth = targetWidth
+			geometry.ResizedHeight = int(float64(targetWidth) / srcAspect)
+		} else {
+			// Source is taller - fit by height
+			geometry.ResizedHeight = targetHeight
+			geometry.ResizedWidth = int(float64(targetHeight) * srcAspect)
+		}
+		// Calculate position to center the image
+		geometry.OffsetX = (targetWidth - geometry.ResizedWidth) / 2
+		geometry.OffsetY = (targetHeight - geometry.ResizedHeight) / 2
+		if geometry.ResizedWidth != targetWidth || geometry.ResizedHeight != targetHeight {
+			geometry.AspectHandling = AspectHandlingPadded
+		}
+	}
+
+	return geometry
+}
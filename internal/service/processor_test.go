@@ -2,21 +2,34 @@ package service
 
 import (
 	"bytes"
+	"encoding/binary"
 	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
 	"image/jpeg"
 	"image/png"
+	"math"
+	"math/rand"
+	"net/http"
 	"testing"
 
+	"resizr/internal/models"
+	"resizr/internal/testutil"
+
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/tiff"
+	"golang.org/x/image/webp"
 )
 
 func TestNewProcessorService(t *testing.T) {
-	processor := NewProcessorService(4096, 4096)
+	processor := NewProcessorService(4096, 4096, 8192, 8192)
 	assert.NotNil(t, processor)
 }
 
 func TestProcessorService_DetectFormat(t *testing.T) {
-	processor := NewProcessorService(4096, 4096)
+	processor := NewProcessorService(4096, 4096, 8192, 8192)
 
 	t.Run("detect_jpeg", func(t *testing.T) {
 		// Create a proper JPEG with sufficient data (minimum 512 bytes)
@@ -63,7 +76,7 @@ func TestProcessorService_DetectFormat(t *testing.T) {
 }
 
 func TestProcessorService_GetDimensions(t *testing.T) {
-	processor := NewProcessorService(4096, 4096)
+	processor := NewProcessorService(4096, 4096, 8192, 8192)
 
 	t.Run("get_jpeg_dimensions", func(t *testing.T) {
 		// Create a simple test image
@@ -102,7 +115,7 @@ func TestProcessorService_GetDimensions(t *testing.T) {
 }
 
 func TestProcessorService_ValidateImage(t *testing.T) {
-	processor := NewProcessorService(4096, 4096)
+	processor := NewProcessorService(4096, 4096, 8192, 8192)
 
 	t.Run("valid_image_size", func(t *testing.T) {
 		// Create a small test image
@@ -135,7 +148,7 @@ func TestProcessorService_ValidateImage(t *testing.T) {
 }
 
 func TestProcessorService_ProcessImage(t *testing.T) {
-	processor := NewProcessorService(4096, 4096)
+	processor := NewProcessorService(4096, 4096, 8192, 8192)
 
 	t.Run("resize_jpeg", func(t *testing.T) {
 		// Create a test image
@@ -247,8 +260,50 @@ func TestProcessorService_ProcessImage(t *testing.T) {
 	})
 }
 
+func TestProcessorService_DetectFormat_DefaultContentType(t *testing.T) {
+	// A tiny PNG is valid but shorter than the 512 bytes content sniffing needs
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, 2, 2))))
+	ambiguous := buf.Bytes()
+	require.Less(t, len(ambiguous), 512)
+
+	t.Run("ambiguous image is rejected without a default", func(t *testing.T) {
+		processor := NewProcessorService(4096, 4096, 8192, 8192)
+
+		_, err := processor.DetectFormat(ambiguous)
+		assert.ErrorContains(t, err, "insufficient data")
+	})
+
+	t.Run("ambiguous but decodable image uses the default", func(t *testing.T) {
+		processor := NewProcessorService(4096, 4096, 8192, 8192, WithDefaultContentType("image/png"))
+
+		format, err := processor.DetectFormat(ambiguous)
+		require.NoError(t, err)
+		assert.Equal(t, "image/png", format)
+		assert.NoError(t, processor.ValidateImage(ambiguous, 1024))
+	})
+
+	t.Run("undecodable data is still rejected", func(t *testing.T) {
+		processor := NewProcessorService(4096, 4096, 8192, 8192, WithDefaultContentType("image/png"))
+
+		for _, data := range [][]byte{[]byte("not an image"), make([]byte, 600), ambiguous[:len(ambiguous)/2]} {
+			format, err := processor.DetectFormat(data)
+			assert.Error(t, err)
+			assert.Empty(t, format)
+		}
+	})
+
+	t.Run("detected formats ignore the default", func(t *testing.T) {
+		processor := NewProcessorService(4096, 4096, 8192, 8192, WithDefaultContentType("image/gif"))
+
+		format, err := processor.DetectFormat(testutil.CreateTestPNG(100, 100))
+		require.NoError(t, err)
+		assert.Equal(t, "image/png", format)
+	})
+}
+
 func TestProcessorService_DetectFormat_Additional(t *testing.T) {
-	processor := NewProcessorService(4096, 4096)
+	processor := NewProcessorService(4096, 4096, 8192, 8192)
 
 	t.Run("detect_gif", func(t *testing.T) {
 		// Create proper GIF with sufficient data (minimum 512 bytes)
@@ -290,7 +345,7 @@ func TestProcessorService_DetectFormat_Additional(t *testing.T) {
 }
 
 func TestProcessorService_ProcessImage_AdditionalFormats(t *testing.T) {
-	processor := NewProcessorService(4096, 4096)
+	processor := NewProcessorService(4096, 4096, 8192, 8192)
 
 	t.Run("process_gif_format", func(t *testing.T) {
 		// Create a test image
@@ -386,3 +441,749 @@ func TestProcessorService_ProcessImage_AdditionalFormats(t *testing.T) {
 		assert.Contains(t, err.Error(), "invalid")
 	})
 }
+
+func TestProcessorService_SourceDimensionCap(t *testing.T) {
+	processor := NewProcessorService(100, 100, 400, 400)
+
+	t.Run("source above resize cap accepted", func(t *testing.T) {
+		width, height, err := processor.GetDimensions(testutil.CreateTestPNG(300, 200))
+		assert.NoError(t, err)
+		assert.Equal(t, 300, width)
+		assert.Equal(t, 200, height)
+	})
+
+	t.Run("source above source cap rejected", func(t *testing.T) {
+		_, _, err := processor.GetDimensions(testutil.CreateTestPNG(401, 10))
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "exceed maximum allowed 400x400")
+	})
+
+	t.Run("target above resize cap rejected", func(t *testing.T) {
+		_, err := processor.ProcessImage(testutil.CreateTestPNG(300, 200), ResizeConfig{
+			Width:           200,
+			Height:          200,
+			Quality:         85,
+			Format:          "png",
+			Mode:            ResizeModeSmartFit,
+			BackgroundColor: "#FFFFFF",
+		})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "exceed maximum allowed 100x100")
+	})
+}
+
+func TestProcessorService_TIFF(t *testing.T) {
+	processor := NewProcessorService(4096, 4096, 8192, 8192)
+
+	var buf bytes.Buffer
+	err := tiff.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 320, 240)), nil)
+	assert.NoError(t, err)
+	tiffData := buf.Bytes()
+
+	t.Run("detect_tiff", func(t *testing.T) {
+		format, err := processor.DetectFormat(tiffData)
+		assert.NoError(t, err)
+		assert.Equal(t, "image/tiff", format)
+	})
+
+	t.Run("detect_big_endian_tiff", func(t *testing.T) {
+		data := make([]byte, 512)
+		copy(data, []byte{0x4D, 0x4D, 0x00, 0x2A})
+		format, err := processor.DetectFormat(data)
+		assert.NoError(t, err)
+		assert.Equal(t, "image/tiff", format)
+	})
+
+	t.Run("tiff_dimensions", func(t *testing.T) {
+		width, height, err := processor.GetDimensions(tiffData)
+		assert.NoError(t, err)
+		assert.Equal(t, 320, width)
+		assert.Equal(t, 240, height)
+	})
+
+	t.Run("tiff_resized_to_png", func(t *testing.T) {
+		processed, err := processor.ProcessImage(tiffData, ResizeConfig{
+			Width:           160,
+			Height:          120,
+			Quality:         85,
+			Format:          "png",
+			Mode:            ResizeModeSmartFit,
+			BackgroundColor: "#FFFFFF",
+		})
+		assert.NoError(t, err)
+
+		img, format, err := image.Decode(bytes.NewReader(processed))
+		assert.NoError(t, err)
+		assert.Equal(t, "png", format)
+		assert.Equal(t, 160, img.Bounds().Dx())
+		assert.Equal(t, 120, img.Bounds().Dy())
+	})
+
+	t.Run("tiff_without_output_format_falls_back_to_png", func(t *testing.T) {
+		processed, err := processor.ProcessImage(tiffData, ResizeConfig{
+			Width:           32,
+			Height:          24,
+			Quality:         85,
+			Mode:            ResizeModeStretch,
+			BackgroundColor: "#FFFFFF",
+		})
+		assert.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(processed, []byte{0x89, 0x50, 0x4E, 0x47}))
+	})
+}
+
+func TestProcessorService_AnimatedGIFToWebP(t *testing.T) {
+	processor := NewProcessorService(4096, 4096, 8192, 8192)
+
+	palette := color.Palette{color.Transparent, color.RGBA{255, 0, 0, 255}, color.RGBA{0, 255, 0, 255}, color.RGBA{0, 0, 255, 255}}
+	anim := &gif.GIF{LoopCount: 0}
+	for i := 1; i <= 3; i++ {
+		frame := image.NewPaletted(image.Rect(0, 0, 40, 20), palette)
+		for j := range frame.Pix {
+			frame.Pix[j] = uint8(i)
+		}
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, i*10)
+		anim.Disposal = append(anim.Disposal, gif.DisposalNone)
+	}
+	var buf bytes.Buffer
+	require.NoError(t, gif.EncodeAll(&buf, anim))
+	gifData := buf.Bytes()
+
+	t.Run("frames_and_timing_preserved", func(t *testing.T) {
+		processed, err := processor.ProcessImage(gifData, ResizeConfig{
+			Width:           20,
+			Height:          10,
+			Quality:         85,
+			Format:          "webp",
+			Mode:            ResizeModeStretch,
+			BackgroundColor: "#FFFFFF",
+		})
+		require.NoError(t, err)
+
+		chunks := parseWebPFile(t, processed)
+		require.GreaterOrEqual(t, len(chunks), 2)
+		assert.Equal(t, "VP8X", chunks[0].fourCC)
+		assert.NotZero(t, chunks[0].payload[0]&0x02, "animation flag must be set")
+		assert.Equal(t, "ANIM", chunks[1].fourCC)
+		assert.Equal(t, uint16(0), binary.LittleEndian.Uint16(chunks[1].payload[4:]), "GIF infinite loop maps to WebP infinite loop")
+
+		var frames []webpChunk
+		for _, chunk := range chunks[2:] {
+			if chunk.fourCC == "ANMF" {
+				frames = append(frames, chunk)
+			}
+		}
+		require.Len(t, frames, len(anim.Image))
+
+		for i, frame := range frames {
+			assert.Equal(t, anim.Delay[i]*10, readUint24(frame.payload[12:]), "frame %d duration", i)
+
+			sub := parseWebPChunks(t, frame.payload[16:])
+			require.Len(t, sub, 1)
+			decoded, err := webp.Decode(bytes.NewReader(wrapVP8LForTest(sub[0].payload)))
+			require.NoError(t, err)
+			assert.Equal(t, 20, decoded.Bounds().Dx())
+			assert.Equal(t, 10, decoded.Bounds().Dy())
+
+			r, g, b, _ := decoded.At(10, 5).RGBA()
+			want := palette[i+1].(color.RGBA)
+			assert.Equal(t, []uint32{uint32(want.R), uint32(want.G), uint32(want.B)}, []uint32{r >> 8, g >> 8, b >> 8}, "frame %d color", i)
+		}
+	})
+
+	t.Run("single_frame_gif_uses_static_path", func(t *testing.T) {
+		var single bytes.Buffer
+		require.NoError(t, gif.Encode(&single, anim.Image[0], nil))
+
+		processed, err := processor.ProcessImage(single.Bytes(), ResizeConfig{
+			Width:           20,
+			Height:          10,
+			Quality:         85,
+			Format:          "webp",
+			Mode:            ResizeModeStretch,
+			BackgroundColor: "#FFFFFF",
+		})
+		require.NoError(t, err)
+		assert.False(t, bytes.HasPrefix(processed, []byte("RIFF")))
+	})
+
+	t.Run("gif_output_stays_static", func(t *testing.T) {
+		processed, err := processor.ProcessImage(gifData, ResizeConfig{
+			Width:           20,
+			Height:          10,
+			Quality:         85,
+			Format:          "gif",
+			Mode:            ResizeModeStretch,
+			BackgroundColor: "#FFFFFF",
+		})
+		require.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(processed, []byte("GIF")))
+	})
+}
+
+func TestCalculateResizeGeometry(t *testing.T) {
+	tests := []struct {
+		name           string
+		srcW, srcH     int
+		targetW        int
+		targetH        int
+		mode           ResizeMode
+		resizedW       int
+		resizedH       int
+		offsetX        int
+		offsetY        int
+		aspectHandling string
+	}{
+		{"smart_fit wide source pads vertically", 1920, 1080, 800, 800, ResizeModeSmartFit, 800, 450, 0, 175, AspectHandlingPadded},
+		{"smart_fit tall source pads horizontally", 1080, 1920, 800, 800, ResizeModeSmartFit, 450, 800, 175, 0, AspectHandlingPadded},
+		{"smart_fit matching aspect", 1600, 900, 800, 450, ResizeModeSmartFit, 800, 450, 0, 0, AspectHandlingExact},
+		{"crop wide source trims width", 1920, 1080, 800, 800, ResizeModeCrop, 1422, 800, -311, 0, AspectHandlingCropped},
+		{"crop tall source trims height", 1080, 1920, 800, 800, ResizeModeCrop, 800, 1422, 0, -311, AspectHandlingCropped},
+		{"crop matching aspect", 1600, 900, 800, 450, ResizeModeCrop, 800, 450, 0, 0, AspectHandlingExact},
+		{"stretch distorts", 1920, 1080, 800, 800, ResizeModeStretch, 800, 800, 0, 0, AspectHandlingStretched},
+		{"stretch matching aspect", 1600, 900, 800, 450, ResizeModeStretch, 800, 450, 0, 0, AspectHandlingExact},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			geometry := CalculateResizeGeometry(tt.srcW, tt.srcH, tt.targetW, tt.targetH, tt.mode)
+
+			assert.Equal(t, tt.targetW, geometry.OutputWidth)
+			assert.Equal(t, tt.targetH, geometry.OutputHeight)
+			assert.Equal(t, tt.resizedW, geometry.ResizedWidth)
+			assert.Equal(t, tt.resizedH, geometry.ResizedHeight)
+			assert.Equal(t, tt.offsetX, geometry.OffsetX)
+			assert.Equal(t, tt.offsetY, geometry.OffsetY)
+			assert.Equal(t, tt.aspectHandling, geometry.AspectHandling)
+		})
+	}
+}
+
+func TestCalculateResizeGeometry_MatchesProcessImage(t *testing.T) {
+	processor := NewProcessorService(4096, 4096, 8192, 8192)
+	source := testutil.CreateTestPNG(300, 100)
+
+	for _, mode := range []ResizeMode{ResizeModeSmartFit, ResizeModeCrop, ResizeModeStretch} {
+		t.Run(string(mode), func(t *testing.T) {
+			geometry := CalculateResizeGeometry(300, 100, 120, 90, mode)
+
+			output, err := processor.ProcessImage(source, ResizeConfig{
+				Width:           120,
+				Height:          90,
+				Quality:         85,
+				Format:          "png",
+				Mode:            mode,
+				BackgroundColor: "#FFFFFF",
+			})
+			assert.NoError(t, err)
+
+			width, height, err := processor.GetDimensions(output)
+			assert.NoError(t, err)
+			assert.Equal(t, geometry.OutputWidth, width)
+			assert.Equal(t, geometry.OutputHeight, height)
+		})
+	}
+}
+
+func TestProcessorService_ResampleFilters(t *testing.T) {
+	processor := NewProcessorService(4096, 4096, 8192, 8192)
+	source := testutil.CreateTestPNG(120, 80)
+
+	resize := func(filter string) []byte {
+		output, err := processor.ProcessImage(source, ResizeConfig{
+			Width:           37,
+			Height:          29,
+			Quality:         85,
+			Format:          "png",
+			Mode:            ResizeModeStretch,
+			BackgroundColor: "#FFFFFF",
+			Filter:          filter,
+		})
+		assert.NoError(t, err)
+		return output
+	}
+
+	filters := []string{ResampleFilterLanczos, ResampleFilterBilinear, ResampleFilterNearest, ResampleFilterCatmullRom}
+	outputs := make(map[string][]byte, len(filters))
+	for _, filter := range filters {
+		outputs[filter] = resize(filter)
+	}
+
+	for i, a := range filters {
+		for _, b := range filters[i+1:] {
+			assert.NotEqual(t, outputs[a], outputs[b], "%s and %s should produce different pixels", a, b)
+		}
+	}
+
+	// Unset filter keeps the previous Lanczos behaviour
+	assert.Equal(t, outputs[ResampleFilterLanczos], resize(""))
+}
+
+func BenchmarkProcessorService_ResampleFilters(b *testing.B) {
+	processor := NewProcessorService(4096, 4096, 8192, 8192)
+	source := testutil.CreateTestPNG(1024, 768)
+
+	for _, filter := range []string{ResampleFilterLanczos, ResampleFilterBilinear, ResampleFilterNearest, ResampleFilterCatmullRom} {
+		b.Run(filter, func(b *testing.B) {
+			config := ResizeConfig{
+				Width:           320,
+				Height:          240,
+				Quality:         85,
+				Format:          "jpeg",
+				Mode:            ResizeModeStretch,
+				BackgroundColor: "#FFFFFF",
+				Filter:          filter,
+			}
+			for i := 0; i < b.N; i++ {
+				if _, err := processor.ProcessImage(source, config); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessorService_MultiStepDownscale(t *testing.T) {
+	// A one-pixel checkerboard averages to mid grey; sampling it in one step aliases to black or white
+	checker := image.NewGray(image.Rect(0, 0, 1600, 1600))
+	for y := 0; y < 1600; y++ {
+		for x := 0; x < 1600; x++ {
+			if (x+y)%2 == 0 {
+				checker.Pix[y*checker.Stride+x] = 255
+			}
+		}
+	}
+	var encoded bytes.Buffer
+	require.NoError(t, png.Encode(&encoded, checker))
+
+	resize := func(processor ProcessorService) []byte {
+		output, err := processor.ProcessImage(encoded.Bytes(), ResizeConfig{
+			Width:           20,
+			Height:          20,
+			Quality:         85,
+			Format:          "png",
+			Mode:            ResizeModeStretch,
+			BackgroundColor: "#FFFFFF",
+			Filter:          ResampleFilterNearest,
+		})
+		require.NoError(t, err)
+		return output
+	}
+	centerGrey := func(data []byte) uint8 {
+		img, err := png.Decode(bytes.NewReader(data))
+		require.NoError(t, err)
+		return color.GrayModel.Convert(img.At(10, 10)).(color.Gray).Y
+	}
+
+	single := centerGrey(resize(NewProcessorService(4096, 4096, 8192, 8192)))
+	multi := centerGrey(resize(NewProcessorService(4096, 4096, 8192, 8192, WithMultiStepDownscale(4))))
+
+	assert.True(t, single == 0 || single == 255, "single step should alias, got %d", single)
+	assert.InDelta(t, 128, multi, 2)
+}
+
+func TestDownscaleSteps(t *testing.T) {
+	t.Run("selected above the ratio", func(t *testing.T) {
+		steps := downscaleSteps(8000, 6000, 150, 150, 4)
+
+		require.NotEmpty(t, steps)
+		last := steps[len(steps)-1]
+		assert.LessOrEqual(t, float64(last.X), 4*150.0)
+		assert.LessOrEqual(t, float64(last.Y), 4*150.0)
+		assert.Equal(t, image.Pt(4000, 3000), steps[0])
+	})
+
+	t.Run("not selected within the ratio", func(t *testing.T) {
+		assert.Empty(t, downscaleSteps(600, 600, 150, 150, 4))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		assert.Empty(t, downscaleSteps(8000, 6000, 150, 150, 0))
+	})
+
+	t.Run("steps never go below the target", func(t *testing.T) {
+		for _, step := range downscaleSteps(8000, 200, 100, 150, 2) {
+			assert.GreaterOrEqual(t, step.X, 100)
+			assert.GreaterOrEqual(t, step.Y, 150)
+		}
+	})
+}
+
+func TestProcessorService_JPEGSubsampling(t *testing.T) {
+	processor := NewProcessorService(4096, 4096, 8192, 8192)
+
+	// Alternating one-pixel red and blue columns: a chroma edge on every pixel
+	// with little luma contrast for subsampled chroma to hide behind
+	red, blue := color.RGBA{220, 0, 0, 255}, color.RGBA{0, 0, 220, 255}
+	src := image.NewRGBA(image.Rect(0, 0, 32, 32))
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 32; x++ {
+			if x%2 == 0 {
+				src.Set(x, y, red)
+			} else {
+				src.Set(x, y, blue)
+			}
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, src))
+
+	encode := func(subsampling string) []byte {
+		processed, err := processor.ProcessImage(buf.Bytes(), ResizeConfig{
+			Width:           32,
+			Height:          32,
+			Quality:         90,
+			Format:          "jpeg",
+			Mode:            ResizeModeStretch,
+			BackgroundColor: "#FFFFFF",
+			JPEGSubsampling: subsampling,
+		})
+		require.NoError(t, err)
+		return processed
+	}
+
+	// colorError sums the per-channel distance between the decoded output and the source
+	colorError := func(data []byte) float64 {
+		decoded, err := jpeg.Decode(bytes.NewReader(data))
+		require.NoError(t, err)
+		total := 0.0
+		for y := 0; y < 32; y++ {
+			for x := 0; x < 32; x++ {
+				want := src.RGBAAt(x, y)
+				r, g, b, _ := decoded.At(x, y).RGBA()
+				total += math.Abs(float64(want.R)-float64(r>>8)) +
+					math.Abs(float64(want.G)-float64(g>>8)) +
+					math.Abs(float64(want.B)-float64(b>>8))
+			}
+		}
+		return total
+	}
+
+	full := encode(JPEGSubsampling444)
+	half := encode(JPEGSubsampling422)
+	quarter := encode(JPEGSubsampling420)
+
+	assert.NotEqual(t, full, half)
+	assert.NotEqual(t, full, quarter)
+	assert.NotEqual(t, half, quarter)
+	assert.Equal(t, quarter, encode(""), "empty subsampling keeps the 4:2:0 default")
+
+	fullErr, halfErr, quarterErr := colorError(full), colorError(half), colorError(quarter)
+	assert.Less(t, fullErr, halfErr, "4:4:4 must preserve more color detail than 4:2:2")
+	assert.Less(t, fullErr, quarterErr, "4:4:4 must preserve more color detail than 4:2:0")
+	assert.Less(t, fullErr*2, quarterErr, "4:2:0 should blur the columns into purple")
+}
+
+func TestProcessorService_RecordsProcessingDuration(t *testing.T) {
+	processor := NewProcessorService(4096, 4096, 8192, 8192)
+
+	src := image.NewRGBA(image.Rect(0, 0, 64, 48))
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, src))
+
+	before := ProcessingDurations()["png"]["257-512"].Count
+
+	_, err := processor.ProcessImage(buf.Bytes(), ResizeConfig{
+		Width:           400,
+		Height:          300,
+		Quality:         85,
+		Format:          "png",
+		Mode:            ResizeModeStretch,
+		BackgroundColor: "#FFFFFF",
+	})
+	require.NoError(t, err)
+
+	summary := ProcessingDurations()["png"]["257-512"]
+	assert.Equal(t, before+1, summary.Count)
+	assert.Greater(t, summary.TotalMs, 0.0)
+	assert.GreaterOrEqual(t, summary.MaxMs, summary.AvgMs)
+}
+
+func TestProcessorService_MaxBytes(t *testing.T) {
+	processor := NewProcessorService(4096, 4096, 8192, 8192)
+
+	// Noise compresses poorly, so quality has a large effect on the output size
+	src := image.NewRGBA(image.Rect(0, 0, 320, 240))
+	rng := rand.New(rand.NewSource(3))
+	for y := 0; y < 240; y++ {
+		for x := 0; x < 320; x++ {
+			src.Set(x, y, color.RGBA{uint8(rng.Intn(256)), uint8(x), uint8(y), 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, src))
+
+	resize := func(format string, maxBytes int64) ([]byte, error) {
+		return processor.ProcessImage(buf.Bytes(), ResizeConfig{
+			Width:           320,
+			Height:          240,
+			Quality:         95,
+			Format:          format,
+			Mode:            ResizeModeSmartFit,
+			BackgroundColor: "#FFFFFF",
+			MaxBytes:        maxBytes,
+		})
+	}
+
+	unbounded, err := resize("jpeg", 0)
+	require.NoError(t, err)
+
+	for _, format := range []string{"jpeg", "webp"} {
+		t.Run(format+" output fits the budget", func(t *testing.T) {
+			budget := int64(len(unbounded) / 3)
+			out, err := resize(format, budget)
+			require.NoError(t, err)
+			assert.LessOrEqual(t, int64(len(out)), budget)
+
+			decoded, err := jpeg.Decode(bytes.NewReader(out))
+			require.NoError(t, err)
+			assert.Equal(t, image.Rect(0, 0, 320, 240), decoded.Bounds())
+		})
+	}
+
+	t.Run("output already within budget keeps the configured quality", func(t *testing.T) {
+		out, err := resize("jpeg", int64(len(unbounded)))
+		require.NoError(t, err)
+		assert.Equal(t, unbounded, out)
+	})
+
+	t.Run("budget below the quality floor fails", func(t *testing.T) {
+		_, err := resize("jpeg", MinTargetSizeBytes)
+		assert.ErrorContains(t, err, "does not fit")
+	})
+
+	t.Run("lossless output cannot be fitted", func(t *testing.T) {
+		_, err := resize("png", int64(len(unbounded)))
+		assert.ErrorContains(t, err, "requires jpeg or webp output")
+	})
+}
+
+func TestProcessorService_CropRect(t *testing.T) {
+	processor := NewProcessorService(4096, 4096, 8192, 8192)
+
+	src := image.NewRGBA(image.Rect(0, 0, 64, 48))
+	red := color.RGBA{R: 255, A: 255}
+	for y := 10; y < 30; y++ {
+		for x := 20; x < 50; x++ {
+			src.Set(x, y, red)
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, src))
+
+	t.Run("extracts the region at native size", func(t *testing.T) {
+		out, err := processor.ProcessImage(buf.Bytes(), ResizeConfig{
+			Format:          "png",
+			BackgroundColor: "#FFFFFF",
+			Crop:            &models.CropRect{X: 20, Y: 10, Width: 30, Height: 20},
+		})
+		require.NoError(t, err)
+
+		cropped, err := png.Decode(bytes.NewReader(out))
+		require.NoError(t, err)
+		assert.Equal(t, 30, cropped.Bounds().Dx())
+		assert.Equal(t, 20, cropped.Bounds().Dy())
+		for _, p := range []image.Point{{0, 0}, {29, 19}, {15, 10}} {
+			r, g, b, _ := cropped.At(p.X, p.Y).RGBA()
+			assert.Equal(t, [3]uint32{0xffff, 0, 0}, [3]uint32{r, g, b}, "pixel %v", p)
+		}
+	})
+
+	t.Run("rejects rectangles outside the source", func(t *testing.T) {
+		for _, rect := range []models.CropRect{
+			{X: 40, Y: 0, Width: 30, Height: 10},
+			{X: 0, Y: 40, Width: 10, Height: 10},
+			{X: -1, Y: 0, Width: 10, Height: 10},
+		} {
+			_, err := processor.ProcessImage(buf.Bytes(), ResizeConfig{
+				Format:          "png",
+				BackgroundColor: "#FFFFFF",
+				Crop:            &rect,
+			})
+			assert.ErrorContains(t, err, "outside the source bounds", "rect %s", rect)
+		}
+	})
+}
+
+func TestProcessorService_ExtractFrame(t *testing.T) {
+	processor := NewProcessorService(4096, 4096, 8192, 8192)
+
+	palette := color.Palette{color.Transparent, color.RGBA{255, 0, 0, 255}, color.RGBA{0, 255, 0, 255}, color.RGBA{0, 0, 255, 255}}
+	anim := &gif.GIF{}
+	for i := 1; i <= 3; i++ {
+		frame := image.NewPaletted(image.Rect(0, 0, 40, 20), palette)
+		for j := range frame.Pix {
+			frame.Pix[j] = uint8(i)
+		}
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, 10)
+		anim.Disposal = append(anim.Disposal, gif.DisposalNone)
+	}
+	var buf bytes.Buffer
+	require.NoError(t, gif.EncodeAll(&buf, anim))
+	gifData := buf.Bytes()
+
+	centerColor := func(t *testing.T, img image.Image) []uint32 {
+		r, g, b, _ := img.At(20, 10).RGBA()
+		return []uint32{r >> 8, g >> 8, b >> 8}
+	}
+
+	t.Run("gif_frames_as_still_gif", func(t *testing.T) {
+		for i, want := range [][]uint32{{255, 0, 0}, {0, 255, 0}, {0, 0, 255}} {
+			data, err := processor.ExtractFrame(gifData, i, ResizeConfig{Format: "gif"})
+			require.NoError(t, err)
+
+			still, err := gif.DecodeAll(bytes.NewReader(data))
+			require.NoError(t, err)
+			require.Len(t, still.Image, 1)
+			assert.Equal(t, 40, still.Config.Width)
+			assert.Equal(t, want, centerColor(t, still.Image[0]), "frame %d", i)
+		}
+	})
+
+	t.Run("gif_frame_out_of_range", func(t *testing.T) {
+		_, err := processor.ExtractFrame(gifData, 3, ResizeConfig{Format: "gif"})
+		var validationErr models.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "frame", validationErr.Field)
+		assert.Contains(t, validationErr.Message, "3 frame(s)")
+	})
+
+	t.Run("animated_webp_frame_as_still_webp", func(t *testing.T) {
+		animated, err := processor.ProcessImage(gifData, ResizeConfig{
+			Width:           40,
+			Height:          20,
+			Quality:         85,
+			Format:          "webp",
+			Mode:            ResizeModeStretch,
+			BackgroundColor: "#FFFFFF",
+		})
+		require.NoError(t, err)
+
+		data, err := processor.ExtractFrame(animated, 1, ResizeConfig{Format: "webp"})
+		require.NoError(t, err)
+		chunks := parseWebPFile(t, data)
+		require.Len(t, chunks, 1)
+		assert.Equal(t, "VP8L", chunks[0].fourCC)
+
+		still, err := webp.Decode(bytes.NewReader(data))
+		require.NoError(t, err)
+		assert.Equal(t, []uint32{0, 255, 0}, centerColor(t, still))
+
+		_, err = processor.ExtractFrame(animated, 3, ResizeConfig{Format: "webp"})
+		assert.ErrorAs(t, err, &models.ValidationError{})
+	})
+
+	t.Run("still_image_has_one_frame", func(t *testing.T) {
+		var pngData bytes.Buffer
+		require.NoError(t, png.Encode(&pngData, anim.Image[0]))
+
+		data, err := processor.ExtractFrame(pngData.Bytes(), 0, ResizeConfig{Format: "png"})
+		require.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(data, []byte{0x89, 0x50, 0x4E, 0x47}))
+
+		_, err = processor.ExtractFrame(pngData.Bytes(), 1, ResizeConfig{Format: "png"})
+		assert.ErrorAs(t, err, &models.ValidationError{})
+	})
+}
+
+func TestProcessorService_ConvertFormat(t *testing.T) {
+	processor := NewProcessorService(4096, 4096, 8192, 8192)
+	pngData := testutil.CreateTestPNG(120, 80)
+
+	t.Run("png_to_jpeg_keeps_dimensions", func(t *testing.T) {
+		data, err := processor.ConvertFormat(pngData, ResizeConfig{Format: "jpeg", Quality: 85})
+		require.NoError(t, err)
+
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 120, 80), img.Bounds())
+	})
+
+	t.Run("png_to_webp_keeps_dimensions", func(t *testing.T) {
+		data, err := processor.ConvertFormat(pngData, ResizeConfig{Format: "webp", Quality: 85})
+		require.NoError(t, err)
+
+		img, err := webp.Decode(bytes.NewReader(data))
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 120, 80), img.Bounds())
+	})
+
+	t.Run("animated_gif_is_rejected", func(t *testing.T) {
+		palette := color.Palette{color.Black, color.White}
+		anim := &gif.GIF{}
+		for i := 0; i < 2; i++ {
+			anim.Image = append(anim.Image, image.NewPaletted(image.Rect(0, 0, 10, 10), palette))
+			anim.Delay = append(anim.Delay, 10)
+		}
+		var buf bytes.Buffer
+		require.NoError(t, gif.EncodeAll(&buf, anim))
+
+		_, err := processor.ConvertFormat(buf.Bytes(), ResizeConfig{Format: "png"})
+		assert.ErrorIs(t, err, ErrAnimatedSource)
+	})
+}
+
+func TestProcessorService_AutoFormat(t *testing.T) {
+	processor := NewProcessorService(4096, 4096, 8192, 8192)
+
+	noise := image.NewRGBA(image.Rect(0, 0, 160, 120))
+	rng := rand.New(rand.NewSource(5))
+	for y := 0; y < 120; y++ {
+		for x := 0; x < 160; x++ {
+			noise.Set(x, y, color.RGBA{uint8(rng.Intn(256)), uint8(rng.Intn(256)), uint8(rng.Intn(256)), 255})
+		}
+	}
+	flat := image.NewRGBA(image.Rect(0, 0, 160, 120))
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(color.RGBA{40, 90, 160, 255}), image.Point{}, draw.Src)
+
+	encodeSource := func(src image.Image) []byte {
+		var buf bytes.Buffer
+		require.NoError(t, png.Encode(&buf, src))
+		return buf.Bytes()
+	}
+	process := func(data []byte, format string, candidates ...string) []byte {
+		out, err := processor.ProcessImage(data, ResizeConfig{
+			Width:           160,
+			Height:          120,
+			Quality:         85,
+			Format:          format,
+			Mode:            ResizeModeStretch,
+			BackgroundColor: "#FFFFFF",
+			AutoFormats:     candidates,
+		})
+		require.NoError(t, err)
+		return out
+	}
+
+	tests := []struct {
+		name     string
+		src      image.Image
+		expected string
+	}{
+		{"noisy source picks jpeg", noise, "image/jpeg"},
+		{"flat source picks png", flat, "image/png"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := encodeSource(tt.src)
+			jpegOut := process(data, "jpeg")
+			pngOut := process(data, "png")
+
+			out := process(data, OutputFormatAuto, "jpeg", "png")
+
+			assert.Equal(t, tt.expected, http.DetectContentType(out))
+			assert.Equal(t, min(len(jpegOut), len(pngOut)), len(out))
+		})
+	}
+
+	t.Run("transparent source never picks jpeg", func(t *testing.T) {
+		transparent := image.NewNRGBA(image.Rect(0, 0, 160, 120))
+		transparent.Set(10, 10, color.NRGBA{255, 0, 0, 255})
+
+		out := process(encodeSource(transparent), OutputFormatAuto, "jpeg", "webp")
+
+		assert.Equal(t, "image/png", http.DetectContentType(out))
+	})
+}
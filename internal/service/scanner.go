@@ -0,0 +1,70 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Scanner inspects upload content for malware before anything is stored
+type Scanner interface {
+	// Scan returns the verdict for data; an error means no verdict could be reached
+	Scan(ctx context.Context, data []byte) (*ScanResult, error)
+}
+
+// ScanResult is a scanner's verdict on an upload
+type ScanResult struct {
+	Infected  bool   `json:"infected"`
+	Signature string `json:"signature,omitempty"` // Name of the detected threat, when reported
+}
+
+// maxScanResponseSize bounds how much of a scanner response is read
+const maxScanResponseSize = 64 << 10
+
+// HTTPScanner sends uploads to a scanning service over HTTP. The raw file is POSTed
+// as application/octet-stream and the service answers 200 with a JSON ScanResult.
+type HTTPScanner struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPScanner creates a scanner posting to url, each request bounded by timeout
+func NewHTTPScanner(url string, timeout time.Duration) *HTTPScanner {
+	return &HTTPScanner{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Scan implements Scanner
+func (s *HTTPScanner) Scan(ctx context.Context, data []byte) (*ScanResult, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build scan request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("scan request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxScanResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scan response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("scanner returned status %d", resp.StatusCode)
+	}
+
+	var result ScanResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("invalid scan response: %w", err)
+	}
+	return &result, nil
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"resizr/internal/models"
+	"resizr/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockScanner returns a fixed verdict and records what it was asked to scan
+type mockScanner struct {
+	result  *ScanResult
+	err     error
+	scanned [][]byte
+}
+
+func (m *mockScanner) Scan(ctx context.Context, data []byte) (*ScanResult, error) {
+	m.scanned = append(m.scanned, data)
+	return m.result, m.err
+}
+
+func TestImageService_ProcessUpload_Scanner(t *testing.T) {
+	data := testutil.CreateTestImageData()
+	upload := UploadInput{Filename: "photo.jpg", Data: data, Size: int64(len(data)), Resolutions: []string{"800x600"}}
+
+	newService := func(scanner Scanner) (ImageService, *int, *int) {
+		saves, uploads := 0, 0
+		mockRepo := &mockImageRepositoryForImageService{
+			saveFunc: func(ctx context.Context, metadata *models.ImageMetadata) error {
+				saves++
+				return nil
+			},
+		}
+		mockStorage := &mockStorageProviderForImageService{
+			uploadFunc: func(ctx context.Context, key string, data io.Reader, size int64, contentType string) error {
+				uploads++
+				return nil
+			},
+		}
+		mockProcessor := &mockProcessorServiceForImageService{
+			processImageFunc: func(data []byte, config ResizeConfig) ([]byte, error) {
+				return testutil.CreateTestImageData(), nil
+			},
+		}
+		service := NewImageService(mockRepo, &mockDeduplicationRepositoryForImageService{}, mockStorage, mockProcessor, testutil.TestConfig()).(*ImageServiceImpl)
+		service.scanner = scanner
+		return service, &saves, &uploads
+	}
+
+	t.Run("clean upload is stored", func(t *testing.T) {
+		scanner := &mockScanner{result: &ScanResult{}}
+		service, saves, uploads := newService(scanner)
+
+		result, err := service.ProcessUpload(context.Background(), upload)
+
+		require.NoError(t, err)
+		assert.NotEmpty(t, result.ImageID)
+		assert.Equal(t, [][]byte{data}, scanner.scanned)
+		assert.Equal(t, 1, *saves)
+		assert.Positive(t, *uploads)
+	})
+
+	t.Run("infected upload is rejected before storage", func(t *testing.T) {
+		scanner := &mockScanner{result: &ScanResult{Infected: true, Signature: "Eicar-Test-Signature"}}
+		service, saves, uploads := newService(scanner)
+
+		_, err := service.ProcessUpload(context.Background(), upload)
+
+		assert.Equal(t, models.MalwareDetectedError{Signature: "Eicar-Test-Signature"}, err)
+		assert.Zero(t, *saves)
+		assert.Zero(t, *uploads)
+	})
+
+	t.Run("scanner failure rejects the upload", func(t *testing.T) {
+		scanner := &mockScanner{err: errors.New("connection refused")}
+		service, saves, uploads := newService(scanner)
+
+		_, err := service.ProcessUpload(context.Background(), upload)
+
+		var storageErr models.StorageError
+		require.ErrorAs(t, err, &storageErr)
+		assert.Equal(t, "scan", storageErr.Operation)
+		assert.Zero(t, *saves)
+		assert.Zero(t, *uploads)
+	})
+}
+
+func TestHTTPScanner_Scan(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		expected *ScanResult
+		wantErr  bool
+	}{
+		{name: "clean", status: http.StatusOK, body: `{"infected": false}`, expected: &ScanResult{}},
+		{
+			name:     "infected",
+			status:   http.StatusOK,
+			body:     `{"infected": true, "signature": "Eicar-Test-Signature"}`,
+			expected: &ScanResult{Infected: true, Signature: "Eicar-Test-Signature"},
+		},
+		{name: "scanner error status", status: http.StatusInternalServerError, body: `{}`, wantErr: true},
+		{name: "malformed verdict", status: http.StatusOK, body: `not json`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received []byte
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Equal(t, "application/octet-stream", r.Header.Get("Content-Type"))
+				received, _ = io.ReadAll(r.Body)
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			result, err := NewHTTPScanner(server.URL, time.Second).Scan(context.Background(), []byte("file bytes"))
+
+			assert.Equal(t, []byte("file bytes"), received)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"runtime"
 	"sync"
 	"time"
@@ -230,6 +231,79 @@ func (s *StatisticsServiceImpl) GetDeduplicationStatistics() (*models.Deduplicat
 	}, nil
 }
 
+// GetUploadHistogram returns per-day upload counts for the trailing number of days, ending today (UTC)
+func (s *StatisticsServiceImpl) GetUploadHistogram(days int) (*models.UploadHistogram, error) {
+	if days < 1 || days > models.MaxUploadHistogramDays {
+		return nil, models.ValidationError{
+			Field:   "days",
+			Message: fmt.Sprintf("days must be between 1 and %d", models.MaxUploadHistogramDays),
+		}
+	}
+
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	todayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	start := todayStart.AddDate(0, 0, -(days - 1))
+
+	counts, err := s.imageRepo.GetUploadCountsByDay(ctx, start, days)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.UploadHistogram{
+		Days:   days,
+		Start:  start,
+		End:    todayStart.AddDate(0, 0, 1),
+		Counts: counts,
+	}, nil
+}
+
+// GetHashInfo returns the deduplication record for a content hash, optionally scoped to a tenant namespace
+func (s *StatisticsServiceImpl) GetHashInfo(hash, namespace string) (*models.HashInfo, error) {
+	value, ok := models.ParseSHA256Hex(hash)
+	if !ok {
+		return nil, models.ValidationError{
+			Field:   "hash",
+			Message: "hash must be a 64-character hex-encoded SHA256 digest",
+		}
+	}
+
+	ctx := context.Background()
+
+	imageHash := models.ImageHash{Algorithm: "SHA256", Value: value}.WithNamespace(namespace)
+	info, err := s.deduplicationRepo.GetDeduplicationInfo(ctx, imageHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.HashInfo{
+		DeduplicationInfo: info,
+		BytesSaved:        info.BytesSaved(),
+	}, nil
+}
+
+// GetPopularImages returns the most downloaded images, most downloaded first. Counts
+// reflect the last flush of buffered downloads.
+func (s *StatisticsServiceImpl) GetPopularImages(limit int) (*models.PopularImages, error) {
+	if limit < 1 || limit > models.MaxPopularImagesLimit {
+		return nil, models.ValidationError{
+			Field:   "limit",
+			Message: fmt.Sprintf("limit must be between 1 and %d", models.MaxPopularImagesLimit),
+		}
+	}
+
+	images, err := s.imageRepo.GetPopularImages(context.Background(), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PopularImages{
+		Limit:  limit,
+		Images: images,
+	}, nil
+}
+
 // getSystemStatistics returns system-level statistics
 func (s *StatisticsServiceImpl) getSystemStatistics() models.SystemStatistics {
 	var memStats runtime.MemStats
@@ -268,6 +342,38 @@ func (s *StatisticsServiceImpl) RefreshStatistics() error {
 	return nil
 }
 
+// StartBackgroundRefresh periodically regenerates and caches comprehensive statistics
+// until ctx is cancelled. It does nothing when caching or the refresh interval is disabled.
+func (s *StatisticsServiceImpl) StartBackgroundRefresh(ctx context.Context) {
+	interval := s.config.Statistics.RefreshInterval
+	if !s.config.Statistics.CacheEnabled || interval <= 0 {
+		logger.Debug("Background statistics refresh disabled",
+			zap.Bool("cache_enabled", s.config.Statistics.CacheEnabled),
+			zap.Duration("interval", interval))
+		return
+	}
+
+	logger.Info("Starting background statistics refresh",
+		zap.Duration("interval", interval))
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				logger.Info("Background statistics refresh stopped")
+				return
+			case <-ticker.C:
+				// Replace the cache in one step so readers never see it empty
+				s.setCachedStatistics(s.generateStatistics(nil))
+				logger.Debug("Statistics cache refreshed in background")
+			}
+		}
+	}()
+}
+
 // getCachedStatistics returns cached statistics if valid and not expired
 func (s *StatisticsServiceImpl) getCachedStatistics() *models.ResizrStatistics {
 	s.cache.mu.RLock()
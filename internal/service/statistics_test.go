@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"io"
+	"strings"
 	"testing"
 	"time"
 
@@ -46,11 +47,21 @@ func (m *MockImageRepository) Exists(ctx context.Context, id string) (bool, erro
 	return args.Bool(0), args.Error(1)
 }
 
+func (m *MockImageRepository) Count(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 func (m *MockImageRepository) List(ctx context.Context, offset, limit int) ([]*models.ImageMetadata, error) {
 	args := m.Called(ctx, offset, limit)
 	return args.Get(0).([]*models.ImageMetadata), args.Error(1)
 }
 
+func (m *MockImageRepository) ListByDedupStatus(ctx context.Context, filter string, offset, limit int) ([]*models.ImageMetadata, models.DedupStatusCounts, error) {
+	args := m.Called(ctx, filter, offset, limit)
+	return args.Get(0).([]*models.ImageMetadata), args.Get(1).(models.DedupStatusCounts), args.Error(2)
+}
+
 func (m *MockImageRepository) UpdateResolutions(ctx context.Context, id string, resolutions []string) error {
 	args := m.Called(ctx, id, resolutions)
 	return args.Error(0)
@@ -95,11 +106,66 @@ func (m *MockImageRepository) GetImagesByTimeRange(ctx context.Context, start, e
 	return args.Get(0).(int64), args.Error(1)
 }
 
+func (m *MockImageRepository) GetUploadCountsByDay(ctx context.Context, start time.Time, days int) ([]models.DailyUploadCount, error) {
+	args := m.Called(ctx, start, days)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.DailyUploadCount), args.Error(1)
+}
+
 func (m *MockImageRepository) GetStorageUsageByResolution(ctx context.Context) (map[string]int64, error) {
 	args := m.Called(ctx)
 	return args.Get(0).(map[string]int64), args.Error(1)
 }
 
+func (m *MockImageRepository) FindByFilename(ctx context.Context, filename string) ([]string, error) {
+	args := m.Called(ctx, filename)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockImageRepository) FindCorrupt(ctx context.Context) ([]models.CorruptRecord, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.CorruptRecord), args.Error(1)
+}
+
+func (m *MockImageRepository) DeleteCorrupt(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockImageRepository) IncrementAccessCounts(ctx context.Context, id string, counts map[string]int64) error {
+	args := m.Called(ctx, id, counts)
+	return args.Error(0)
+}
+
+func (m *MockImageRepository) GetAccessCounts(ctx context.Context, id string) (*models.AccessCounts, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AccessCounts), args.Error(1)
+}
+
+func (m *MockImageRepository) GetPopularImages(ctx context.Context, limit int) ([]models.PopularImage, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.PopularImage), args.Error(1)
+}
+
+func (m *MockImageRepository) TouchLastAccessed(ctx context.Context, id string, at time.Time) error {
+	args := m.Called(ctx, id, at)
+	return args.Error(0)
+}
+
 func (m *MockImageRepository) Health(ctx context.Context) error {
 	args := m.Called(ctx)
 	return args.Error(0)
@@ -511,6 +577,98 @@ func TestRefreshStatistics_WithCacheDisabled(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestStartBackgroundRefresh_WarmsCache(t *testing.T) {
+	service, mockImageRepo, mockDedupRepo, _ := createTestService()
+	service.config.Statistics.RefreshInterval = 20 * time.Millisecond
+
+	mockImageRepo.On("GetImageStatistics", mock.Anything).Return(&models.ImageStatistics{TotalImages: 42}, nil)
+	mockImageRepo.On("GetStorageStatistics", mock.Anything).Return(&models.StorageStatistics{}, nil)
+	mockDedupRepo.On("GetDeduplicationStatistics", mock.Anything).Return(&models.DeduplicationStatistics{}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Cache starts cold
+	assert.Nil(t, service.getCachedStatistics())
+
+	service.StartBackgroundRefresh(ctx)
+
+	// After an interval tick the cache should be populated without any request
+	assert.Eventually(t, func() bool {
+		cached := service.getCachedStatistics()
+		return cached != nil && cached.Images.TotalImages == 42
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestStartBackgroundRefresh_SkippedWhenDisabled(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*StatisticsServiceImpl)
+	}{
+		{
+			name: "cache disabled",
+			modify: func(s *StatisticsServiceImpl) {
+				s.config.Statistics.CacheEnabled = false
+				s.config.Statistics.RefreshInterval = 10 * time.Millisecond
+			},
+		},
+		{
+			name: "no interval configured",
+			modify: func(s *StatisticsServiceImpl) {
+				s.config.Statistics.RefreshInterval = 0
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// No repository expectations: any refresh would fail the mock
+			service, mockImageRepo, mockDedupRepo, _ := createTestService()
+			tt.modify(service)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			service.StartBackgroundRefresh(ctx)
+			time.Sleep(50 * time.Millisecond)
+
+			assert.Nil(t, service.getCachedStatistics())
+			mockImageRepo.AssertNotCalled(t, "GetImageStatistics", mock.Anything)
+			mockDedupRepo.AssertNotCalled(t, "GetDeduplicationStatistics", mock.Anything)
+		})
+	}
+}
+
+func TestStartBackgroundRefresh_StopsOnCancel(t *testing.T) {
+	service, mockImageRepo, mockDedupRepo, _ := createTestService()
+	service.config.Statistics.RefreshInterval = 10 * time.Millisecond
+
+	mockImageRepo.On("GetImageStatistics", mock.Anything).Return(&models.ImageStatistics{}, nil)
+	mockImageRepo.On("GetStorageStatistics", mock.Anything).Return(&models.StorageStatistics{}, nil)
+	mockDedupRepo.On("GetDeduplicationStatistics", mock.Anything).Return(&models.DeduplicationStatistics{}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	service.StartBackgroundRefresh(ctx)
+
+	assert.Eventually(t, func() bool {
+		return service.getCachedStatistics() != nil
+	}, time.Second, 5*time.Millisecond)
+
+	cancel()
+	time.Sleep(30 * time.Millisecond) // Let the goroutine observe cancellation
+	refreshedAt := cacheTimestamp(service)
+	time.Sleep(50 * time.Millisecond)
+
+	// No further refreshes after shutdown
+	assert.Equal(t, refreshedAt, cacheTimestamp(service))
+}
+
+func cacheTimestamp(s *StatisticsServiceImpl) time.Time {
+	s.cache.mu.RLock()
+	defer s.cache.mu.RUnlock()
+	return s.cache.timestamp
+}
+
 func TestGetComprehensiveStatistics_WithOptions(t *testing.T) {
 	service, mockImageRepo, mockDedupRepo, _ := createTestService()
 	service.config.Statistics.CacheEnabled = false // Disable cache for this test
@@ -570,3 +728,137 @@ func TestGetComprehensiveStatistics_HandleErrors(t *testing.T) {
 	mockImageRepo.AssertExpectations(t)
 	mockDedupRepo.AssertExpectations(t)
 }
+
+func TestGetUploadHistogram_Success(t *testing.T) {
+	service, mockImageRepo, _, _ := createTestService()
+
+	counts := []models.DailyUploadCount{
+		{Date: "2024-01-01", Count: 2},
+		{Date: "2024-01-02", Count: 0},
+		{Date: "2024-01-03", Count: 5},
+	}
+	mockImageRepo.On("GetUploadCountsByDay", mock.Anything, mock.AnythingOfType("time.Time"), 3).Return(counts, nil)
+
+	result, err := service.GetUploadHistogram(3)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, result.Days)
+	assert.Equal(t, counts, result.Counts)
+	assert.Equal(t, 72*time.Hour, result.End.Sub(result.Start))
+	assert.True(t, result.End.After(time.Now()))
+
+	mockImageRepo.AssertExpectations(t)
+}
+
+func TestGetUploadHistogram_InvalidDays(t *testing.T) {
+	service, mockImageRepo, _, _ := createTestService()
+
+	for _, days := range []int{0, -1, models.MaxUploadHistogramDays + 1} {
+		result, err := service.GetUploadHistogram(days)
+
+		assert.Nil(t, result)
+		var validationErr models.ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "days", validationErr.Field)
+	}
+
+	mockImageRepo.AssertNotCalled(t, "GetUploadCountsByDay", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestGetPopularImages_Success(t *testing.T) {
+	service, mockImageRepo, _, _ := createTestService()
+
+	images := []models.PopularImage{
+		{ImageID: "img-1", AccessCounts: models.AccessCounts{Total: 9, ByResolution: map[string]int64{"thumbnail": 9}}},
+		{ImageID: "img-2", AccessCounts: models.AccessCounts{Total: 4, ByResolution: map[string]int64{"original": 4}}},
+	}
+	mockImageRepo.On("GetPopularImages", mock.Anything, 5).Return(images, nil)
+
+	result, err := service.GetPopularImages(5)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 5, result.Limit)
+	assert.Equal(t, images, result.Images)
+	mockImageRepo.AssertExpectations(t)
+}
+
+func TestGetPopularImages_InvalidLimit(t *testing.T) {
+	service, mockImageRepo, _, _ := createTestService()
+
+	for _, limit := range []int{0, -1, models.MaxPopularImagesLimit + 1} {
+		result, err := service.GetPopularImages(limit)
+
+		assert.Nil(t, result)
+		var validationErr models.ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "limit", validationErr.Field)
+	}
+
+	mockImageRepo.AssertNotCalled(t, "GetPopularImages", mock.Anything, mock.Anything)
+}
+
+func TestGetHashInfo_SharedHash(t *testing.T) {
+	service, _, mockDedupRepo, _ := createTestService()
+	hash := strings.Repeat("0f", 32)
+
+	info := &models.DeduplicationInfo{
+		Hash:           models.ImageHash{Algorithm: "SHA256", Value: hash, Size: 4096},
+		MasterImageID:  "img-1",
+		ReferenceCount: 3,
+		StorageKey:     "images/img-1/original.png",
+		ReferencingIDs: []string{"img-1", "img-2", "img-3"},
+	}
+	mockDedupRepo.On("GetDeduplicationInfo", mock.Anything, models.ImageHash{Algorithm: "SHA256", Value: hash}).Return(info, nil)
+
+	// Prefixed, upper-case input is normalized before lookup
+	result, err := service.GetHashInfo("sha256:"+strings.ToUpper(hash), "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, result.ReferenceCount)
+	assert.Equal(t, []string{"img-1", "img-2", "img-3"}, result.ReferencingIDs)
+	assert.Equal(t, int64(2*4096), result.BytesSaved)
+	mockDedupRepo.AssertExpectations(t)
+}
+
+func TestGetHashInfo_Namespaced(t *testing.T) {
+	service, _, mockDedupRepo, _ := createTestService()
+	hash := strings.Repeat("0f", 32)
+
+	expectedHash := models.ImageHash{Algorithm: "SHA256", Value: hash, Namespace: "tenant-a"}
+	mockDedupRepo.On("GetDeduplicationInfo", mock.Anything, expectedHash).
+		Return(&models.DeduplicationInfo{Hash: expectedHash, ReferenceCount: 1, ReferencingIDs: []string{"img-1"}}, nil)
+
+	result, err := service.GetHashInfo(hash, "tenant-a")
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), result.BytesSaved)
+	mockDedupRepo.AssertExpectations(t)
+}
+
+func TestGetHashInfo_InvalidHash(t *testing.T) {
+	service, _, mockDedupRepo, _ := createTestService()
+
+	for _, hash := range []string{"", "abc", strings.Repeat("zz", 32), strings.Repeat("0f", 33)} {
+		result, err := service.GetHashInfo(hash, "")
+
+		assert.Nil(t, result)
+		var validationErr models.ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "hash", validationErr.Field)
+	}
+
+	mockDedupRepo.AssertNotCalled(t, "GetDeduplicationInfo", mock.Anything, mock.Anything)
+}
+
+func TestGetHashInfo_NotFound(t *testing.T) {
+	service, _, mockDedupRepo, _ := createTestService()
+	hash := strings.Repeat("0f", 32)
+
+	notFound := models.NotFoundError{Resource: "deduplication_info", ID: "SHA256:" + hash}
+	mockDedupRepo.On("GetDeduplicationInfo", mock.Anything, mock.Anything).Return((*models.DeduplicationInfo)(nil), notFound)
+
+	result, err := service.GetHashInfo(hash, "")
+
+	assert.Nil(t, result)
+	assert.Equal(t, notFound, err)
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"sync"
+
+	"resizr/pkg/logger"
+
+	"github.com/icza/gox/imagex/colorx"
+	"go.uber.org/zap"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// minWatermarkFontSize is the smallest size a watermark is shrunk to on narrow outputs
+const minWatermarkFontSize = 6
+
+var (
+	defaultFontOnce sync.Once
+	defaultFont     *opentype.Font
+	defaultFontErr  error
+)
+
+// defaultWatermarkFont returns the embedded Go Regular font, parsed once
+func defaultWatermarkFont() (*opentype.Font, error) {
+	defaultFontOnce.Do(func() {
+		defaultFont, defaultFontErr = opentype.Parse(goregular.TTF)
+	})
+	return defaultFont, defaultFontErr
+}
+
+// WithWatermarkFont draws text watermarks with the TrueType/OpenType font at path.
+// A font that cannot be read or parsed is logged and the embedded default is kept,
+// so a bad font path never stops the service from starting.
+func WithWatermarkFont(path string) ProcessorOption {
+	return func(p *ProcessorServiceImpl) {
+		if path == "" {
+			return
+		}
+
+		data, err := os.ReadFile(path)
+		if err == nil {
+			var parsed *opentype.Font
+			if parsed, err = opentype.Parse(data); err == nil {
+				p.watermarkFont = parsed
+				return
+			}
+		}
+		logger.Warn("Failed to load watermark font, using the embedded default",
+			zap.String("path", path),
+			zap.Error(err))
+	}
+}
+
+// drawTextWatermark returns a copy of img with the watermark text drawn at its position.
+// Text wider than the image is shrunk to fit, down to minWatermarkFontSize.
+func (p *ProcessorServiceImpl) drawTextWatermark(img image.Image, wm TextWatermark) (image.Image, error) {
+	if wm.Text == "" || wm.Opacity <= 0 {
+		return img, nil
+	}
+
+	fnt := p.watermarkFont
+	if fnt == nil {
+		var err error
+		if fnt, err = defaultWatermarkFont(); err != nil {
+			return nil, fmt.Errorf("failed to load watermark font: %w", err)
+		}
+	}
+
+	textColor, err := colorx.ParseHexColor(wm.Color)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse watermark color HEX: %w", err)
+	}
+	opacity := min(wm.Opacity, 1)
+	textColor.A = uint8(opacity*255 + 0.5)
+
+	bounds := img.Bounds()
+	size := wm.FontSize
+	face, err := opentype.NewFace(fnt, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create watermark font face: %w", err)
+	}
+
+	margin := max(int(size/2), 2)
+	textWidth := font.MeasureString(face, wm.Text).Ceil()
+	if available := bounds.Dx() - 2*margin; textWidth > available && size > minWatermarkFontSize {
+		size = max(size*float64(available)/float64(textWidth), minWatermarkFontSize)
+		_ = face.Close()
+		if face, err = opentype.NewFace(fnt, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull}); err != nil {
+			return nil, fmt.Errorf("failed to create watermark font face: %w", err)
+		}
+		margin = max(int(size/2), 2)
+		textWidth = font.MeasureString(face, wm.Text).Ceil()
+	}
+	defer func() { _ = face.Close() }()
+
+	metrics := face.Metrics()
+	ascent, descent := metrics.Ascent.Ceil(), metrics.Descent.Ceil()
+
+	// x is the left edge of the text, y its baseline
+	var x, y int
+	switch wm.Position {
+	case WatermarkPositionCenter:
+		x = bounds.Min.X + (bounds.Dx()-textWidth)/2
+		y = bounds.Min.Y + (bounds.Dy()+ascent-descent)/2
+	case WatermarkPositionTopLeft:
+		x = bounds.Min.X + margin
+		y = bounds.Min.Y + margin + ascent
+	case WatermarkPositionTopRight:
+		x = bounds.Max.X - margin - textWidth
+		y = bounds.Min.Y + margin + ascent
+	case WatermarkPositionBottomLeft:
+		x = bounds.Min.X + margin
+		y = bounds.Max.Y - margin - descent
+	default:
+		x = bounds.Max.X - margin - textWidth
+		y = bounds.Max.Y - margin - descent
+	}
+
+	canvas := image.NewRGBA(bounds)
+	draw.Draw(canvas, bounds, img, bounds.Min, draw.Src)
+
+	drawer := &font.Drawer{
+		Dst:  canvas,
+		Src:  image.NewUniform(color.NRGBA(textColor)),
+		Face: face,
+		Dot:  fixed.P(x, y),
+	}
+	drawer.DrawString(wm.Text)
+
+	return canvas, nil
+}
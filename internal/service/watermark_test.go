@@ -0,0 +1,126 @@
+package service
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// changedPixels returns the pixels of b that differ from a, which must be the same size
+func changedPixels(t *testing.T, a, b []byte) []image.Point {
+	t.Helper()
+
+	imgA, err := png.Decode(bytes.NewReader(a))
+	require.NoError(t, err)
+	imgB, err := png.Decode(bytes.NewReader(b))
+	require.NoError(t, err)
+	require.Equal(t, imgA.Bounds(), imgB.Bounds())
+
+	var changed []image.Point
+	bounds := imgA.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r1, g1, b1, _ := imgA.At(x, y).RGBA()
+			r2, g2, b2, _ := imgB.At(x, y).RGBA()
+			if r1 != r2 || g1 != g2 || b1 != b2 {
+				changed = append(changed, image.Point{X: x, Y: y})
+			}
+		}
+	}
+	return changed
+}
+
+func TestProcessorService_TextWatermark(t *testing.T) {
+	processor := NewProcessorService(4096, 4096, 8192, 8192)
+
+	src := image.NewRGBA(image.Rect(0, 0, 400, 200))
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, src))
+
+	process := func(width, height int, watermark *TextWatermark) []byte {
+		out, err := processor.ProcessImage(buf.Bytes(), ResizeConfig{
+			Width:           width,
+			Height:          height,
+			Format:          "png",
+			Mode:            ResizeModeStretch,
+			BackgroundColor: "#000000",
+			Watermark:       watermark,
+		})
+		require.NoError(t, err)
+		return out
+	}
+	plain := process(400, 200, nil)
+
+	t.Run("output differs with the watermark enabled", func(t *testing.T) {
+		marked := process(400, 200, &TextWatermark{Text: "PREVIEW", FontSize: 24, Color: "#FFFFFF", Opacity: 1})
+
+		changed := changedPixels(t, plain, marked)
+		require.NotEmpty(t, changed)
+		for _, p := range changed {
+			assert.True(t, p.X >= 200 && p.Y >= 100, "bottom-right watermark drew at %v", p)
+		}
+	})
+
+	t.Run("positions place the text", func(t *testing.T) {
+		tests := []struct {
+			position string
+			region   image.Rectangle
+		}{
+			{WatermarkPositionTopLeft, image.Rect(0, 0, 200, 100)},
+			{WatermarkPositionTopRight, image.Rect(200, 0, 400, 100)},
+			{WatermarkPositionBottomLeft, image.Rect(0, 100, 200, 200)},
+			{WatermarkPositionCenter, image.Rect(100, 50, 300, 150)},
+		}
+		for _, tt := range tests {
+			marked := process(400, 200, &TextWatermark{Text: "PREVIEW", FontSize: 24, Color: "#FFFFFF", Opacity: 1, Position: tt.position})
+
+			changed := changedPixels(t, plain, marked)
+			require.NotEmpty(t, changed, tt.position)
+			for _, p := range changed {
+				assert.True(t, p.In(tt.region), "%s watermark drew at %v", tt.position, p)
+			}
+		}
+	})
+
+	t.Run("opacity zero draws nothing", func(t *testing.T) {
+		marked := process(400, 200, &TextWatermark{Text: "PREVIEW", FontSize: 24, Color: "#FFFFFF", Opacity: 0})
+
+		assert.Empty(t, changedPixels(t, plain, marked))
+	})
+
+	t.Run("text is shrunk to fit narrow outputs", func(t *testing.T) {
+		narrowPlain := process(60, 40, nil)
+		marked := process(60, 40, &TextWatermark{Text: "PREVIEW ONLY", FontSize: 48, Color: "#FFFFFF", Opacity: 1})
+
+		changed := changedPixels(t, narrowPlain, marked)
+		require.NotEmpty(t, changed)
+		for _, p := range changed {
+			assert.GreaterOrEqual(t, p.Y, 20, "shrunk text stays in the bottom half, drew at %v", p)
+		}
+	})
+
+	t.Run("invalid color fails processing", func(t *testing.T) {
+		_, err := processor.ProcessImage(buf.Bytes(), ResizeConfig{
+			Width:           100,
+			Height:          50,
+			Format:          "png",
+			BackgroundColor: "#000000",
+			Watermark:       &TextWatermark{Text: "PREVIEW", FontSize: 24, Color: "white", Opacity: 1},
+		})
+		assert.ErrorContains(t, err, "watermark color")
+	})
+}
+
+func TestWithWatermarkFont_FallsBackToEmbeddedFont(t *testing.T) {
+	processor := NewProcessorService(4096, 4096, 8192, 8192, WithWatermarkFont("/nonexistent/font.ttf")).(*ProcessorServiceImpl)
+	assert.Nil(t, processor.watermarkFont)
+
+	src := image.NewRGBA(image.Rect(0, 0, 200, 100))
+	marked, err := processor.drawTextWatermark(src, TextWatermark{Text: "PREVIEW", FontSize: 20, Color: "#FFFFFF", Opacity: 1})
+	require.NoError(t, err)
+	assert.NotEqual(t, src.Pix, marked.(*image.RGBA).Pix)
+}
@@ -0,0 +1,375 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/draw"
+	"sort"
+)
+
+// Lossless WebP (VP8L) encoder used for animated WebP output.
+//
+// The encoder writes literal ARGB pixels with one Huffman group and no
+// transforms, color cache or LZ77 back-references. Output is larger than a
+// tuned libwebp encode but is decodable by every WebP reader and needs no cgo.
+
+const (
+	vp8lSignature      = 0x2f
+	vp8lMaxDimension   = 1 << 14
+	vp8lMaxCodeLength  = 15
+	vp8lCodeLengthMax  = 7
+	vp8lGreenAlphabet  = 256 + 24 // literals + LZ77 length prefixes
+	vp8lColorAlphabet  = 256
+	webpMaxFrameMillis = 1<<24 - 1
+)
+
+// vp8lCodeLengthOrder is the order code-length code lengths are written in
+var vp8lCodeLengthOrder = [19]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// bitWriter writes values least-significant bit first, as VP8L expects
+type bitWriter struct {
+	buf   []byte
+	acc   uint64
+	nBits uint
+}
+
+func (w *bitWriter) write(value uint32, n uint) {
+	w.acc |= uint64(value&(1<<n-1)) << w.nBits
+	w.nBits += n
+	for w.nBits >= 8 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc >>= 8
+		w.nBits -= 8
+	}
+}
+
+// writeCode writes a canonical Huffman code MSB first
+func (w *bitWriter) writeCode(code uint32, length uint8) {
+	for i := int(length) - 1; i >= 0; i-- {
+		w.write(code>>uint(i), 1)
+	}
+}
+
+func (w *bitWriter) bytes() []byte {
+	if w.nBits > 0 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc, w.nBits = 0, 0
+	}
+	return w.buf
+}
+
+// huffmanCode holds the code lengths and canonical codes of one alphabet
+type huffmanCode struct {
+	lengths []uint8
+	codes   []uint32
+	used    []int // symbols with a non-zero count, ascending
+}
+
+// newHuffmanCode builds a length-limited canonical Huffman code from symbol counts
+func newHuffmanCode(counts []int, maxLength uint8) huffmanCode {
+	h := huffmanCode{
+		lengths: make([]uint8, len(counts)),
+		codes:   make([]uint32, len(counts)),
+	}
+	for symbol, count := range counts {
+		if count > 0 {
+			h.used = append(h.used, symbol)
+		}
+	}
+	if len(h.used) < 2 {
+		return h
+	}
+
+	// Flatten the distribution until the tree fits within maxLength
+	scaled := make([]int, len(counts))
+	copy(scaled, counts)
+	for shift := uint(0); ; shift++ {
+		for _, symbol := range h.used {
+			scaled[symbol] = counts[symbol] >> shift
+			if scaled[symbol] == 0 {
+				scaled[symbol] = 1
+			}
+		}
+		if huffmanLengths(scaled, h.used, h.lengths) <= maxLength {
+			break
+		}
+	}
+
+	// Assign canonical codes (shorter codes first, ties by symbol order)
+	var histogram [vp8lMaxCodeLength + 1]uint32
+	for _, symbol := range h.used {
+		histogram[h.lengths[symbol]]++
+	}
+	var next [vp8lMaxCodeLength + 1]uint32
+	code := uint32(0)
+	for length := 1; length <= vp8lMaxCodeLength; length++ {
+		code = (code + histogram[length-1]) << 1
+		next[length] = code
+	}
+	for _, symbol := range h.used {
+		length := h.lengths[symbol]
+		h.codes[symbol] = next[length]
+		next[length]++
+	}
+	return h
+}
+
+// huffmanLengths fills lengths for the used symbols and returns the longest one
+func huffmanLengths(counts []int, used []int, lengths []uint8) uint8 {
+	type node struct {
+		weight      int
+		symbol      int // -1 for internal nodes
+		left, right int
+	}
+	nodes := make([]node, 0, 2*len(used))
+	for _, symbol := range used {
+		nodes = append(nodes, node{weight: counts[symbol], symbol: symbol, left: -1, right: -1})
+	}
+	active := make([]int, len(nodes))
+	for i := range active {
+		active[i] = i
+	}
+	for len(active) > 1 {
+		sort.SliceStable(active, func(i, j int) bool {
+			return nodes[active[i]].weight < nodes[active[j]].weight
+		})
+		a, b := active[0], active[1]
+		nodes = append(nodes, node{weight: nodes[a].weight + nodes[b].weight, symbol: -1, left: a, right: b})
+		active = append(active[2:], len(nodes)-1)
+	}
+
+	var maxLength uint8
+	var walk func(index int, depth uint8)
+	walk = func(index int, depth uint8) {
+		n := nodes[index]
+		if n.symbol >= 0 {
+			lengths[n.symbol] = depth
+			if depth > maxLength {
+				maxLength = depth
+			}
+			return
+		}
+		walk(n.left, depth+1)
+		walk(n.right, depth+1)
+	}
+	walk(active[0], 0)
+	return maxLength
+}
+
+// writeSymbol emits a symbol; single-symbol codes take zero bits
+func (h huffmanCode) writeSymbol(w *bitWriter, symbol int) {
+	if len(h.used) > 1 {
+		w.writeCode(h.codes[symbol], h.lengths[symbol])
+	}
+}
+
+// writeHuffmanCode writes the code definition to the bitstream
+func writeHuffmanCode(w *bitWriter, h huffmanCode) {
+	if len(h.used) <= 1 {
+		// Simple code with a single symbol
+		symbol := 0
+		if len(h.used) == 1 {
+			symbol = h.used[0]
+		}
+		w.write(1, 1) // simple code
+		w.write(0, 1) // one symbol
+		if symbol < 2 {
+			w.write(0, 1)
+			w.write(uint32(symbol), 1)
+		} else {
+			w.write(1, 1)
+			w.write(uint32(symbol), 8)
+		}
+		return
+	}
+
+	// Normal code: code lengths are themselves Huffman coded
+	counts := make([]int, len(vp8lCodeLengthOrder))
+	for _, length := range h.lengths {
+		counts[length]++
+	}
+	lengthCode := newHuffmanCode(counts, vp8lCodeLengthMax)
+	if len(lengthCode.used) == 1 {
+		// A lone code-length symbol is written with length 1 and read with zero bits
+		lengthCode.lengths[lengthCode.used[0]] = 1
+	}
+
+	w.write(0, 1)                                  // normal code
+	w.write(uint32(len(vp8lCodeLengthOrder)-4), 4) // number of code-length codes
+	for _, symbol := range vp8lCodeLengthOrder {
+		w.write(uint32(lengthCode.lengths[symbol]), 3)
+	}
+	w.write(0, 1) // max_symbol equals the alphabet size
+	for _, length := range h.lengths {
+		lengthCode.writeSymbol(w, int(length))
+	}
+}
+
+// encodeVP8L encodes img as a raw VP8L bitstream
+func encodeVP8L(img image.Image) ([]byte, bool, error) {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= 0 || height <= 0 || width > vp8lMaxDimension || height > vp8lMaxDimension {
+		return nil, false, fmt.Errorf("webp dimensions %dx%d out of range", width, height)
+	}
+
+	nrgba, ok := img.(*image.NRGBA)
+	if !ok || nrgba.Rect.Min != (image.Point{}) {
+		nrgba = image.NewNRGBA(image.Rect(0, 0, width, height))
+		draw.Draw(nrgba, nrgba.Rect, img, bounds.Min, draw.Src)
+	}
+
+	green := make([]int, vp8lGreenAlphabet)
+	red := make([]int, vp8lColorAlphabet)
+	blue := make([]int, vp8lColorAlphabet)
+	alpha := make([]int, vp8lColorAlphabet)
+	hasAlpha := false
+	for y := 0; y < height; y++ {
+		row := nrgba.Pix[y*nrgba.Stride : y*nrgba.Stride+4*width]
+		for x := 0; x < len(row); x += 4 {
+			red[row[x]]++
+			green[row[x+1]]++
+			blue[row[x+2]]++
+			alpha[row[x+3]]++
+			if row[x+3] != 0xff {
+				hasAlpha = true
+			}
+		}
+	}
+	codes := [5]huffmanCode{
+		newHuffmanCode(green, vp8lMaxCodeLength),
+		newHuffmanCode(red, vp8lMaxCodeLength),
+		newHuffmanCode(blue, vp8lMaxCodeLength),
+		newHuffmanCode(alpha, vp8lMaxCodeLength),
+		newHuffmanCode(make([]int, 40), vp8lMaxCodeLength), // distance codes are unused
+	}
+
+	w := &bitWriter{}
+	w.write(vp8lSignature, 8)
+	w.write(uint32(width-1), 14)
+	w.write(uint32(height-1), 14)
+	if hasAlpha {
+		w.write(1, 1)
+	} else {
+		w.write(0, 1)
+	}
+	w.write(0, 3) // version
+	w.write(0, 1) // no transforms
+	w.write(0, 1) // no color cache
+	w.write(0, 1) // no meta Huffman codes
+	for _, code := range codes {
+		writeHuffmanCode(w, code)
+	}
+	for y := 0; y < height; y++ {
+		row := nrgba.Pix[y*nrgba.Stride : y*nrgba.Stride+4*width]
+		for x := 0; x < len(row); x += 4 {
+			codes[0].writeSymbol(w, int(row[x+1]))
+			codes[1].writeSymbol(w, int(row[x]))
+			codes[2].writeSymbol(w, int(row[x+2]))
+			codes[3].writeSymbol(w, int(row[x+3]))
+		}
+	}
+	return w.bytes(), hasAlpha, nil
+}
+
+// writeRIFFChunk appends a chunk with its header and padding byte
+func writeRIFFChunk(buf *bytes.Buffer, fourCC string, payload []byte) {
+	buf.WriteString(fourCC)
+	_ = binary.Write(buf, binary.LittleEndian, uint32(len(payload)))
+	buf.Write(payload)
+	if len(payload)%2 == 1 {
+		buf.WriteByte(0)
+	}
+}
+
+// wrapRIFF wraps chunk data in a RIFF/WEBP container
+func wrapRIFF(chunks []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(4+len(chunks)))
+	buf.WriteString("WEBP")
+	buf.Write(chunks)
+	return buf.Bytes()
+}
+
+func putUint24(b []byte, v uint32) {
+	b[0], b[1], b[2] = byte(v), byte(v>>8), byte(v>>16)
+}
+
+// encodeWebPLossless encodes a single image as a lossless WebP file
+func encodeWebPLossless(img image.Image) ([]byte, error) {
+	bitstream, _, err := encodeVP8L(img)
+	if err != nil {
+		return nil, err
+	}
+	var chunks bytes.Buffer
+	writeRIFFChunk(&chunks, "VP8L", bitstream)
+	return wrapRIFF(chunks.Bytes()), nil
+}
+
+// encodeAnimatedWebP encodes full-canvas frames as an animated WebP.
+// delays are in milliseconds; loopCount 0 means loop forever.
+func encodeAnimatedWebP(frames []image.Image, delays []int, loopCount int) ([]byte, error) {
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("animated webp requires at least one frame")
+	}
+	if len(delays) != len(frames) {
+		return nil, fmt.Errorf("got %d delays for %d frames", len(delays), len(frames))
+	}
+	canvas := frames[0].Bounds()
+	width, height := canvas.Dx(), canvas.Dy()
+	if loopCount < 0 || loopCount > 0xffff {
+		loopCount = 0
+	}
+
+	var body bytes.Buffer
+	hasAlpha := false
+	for i, frame := range frames {
+		if frame.Bounds().Dx() != width || frame.Bounds().Dy() != height {
+			return nil, fmt.Errorf("frame %d is %dx%d, expected %dx%d", i, frame.Bounds().Dx(), frame.Bounds().Dy(), width, height)
+		}
+		bitstream, frameAlpha, err := encodeVP8L(frame)
+		if err != nil {
+			return nil, fmt.Errorf("frame %d: %w", i, err)
+		}
+		hasAlpha = hasAlpha || frameAlpha
+
+		delay := delays[i]
+		if delay < 0 {
+			delay = 0
+		}
+		if delay > webpMaxFrameMillis {
+			delay = webpMaxFrameMillis
+		}
+
+		var frameData bytes.Buffer
+		header := make([]byte, 16)
+		// X/2 and Y/2 offsets stay zero: every frame covers the whole canvas
+		putUint24(header[6:], uint32(width-1))
+		putUint24(header[9:], uint32(height-1))
+		putUint24(header[12:], uint32(delay))
+		header[15] = 0x02 // do not blend, no disposal
+		frameData.Write(header)
+		writeRIFFChunk(&frameData, "VP8L", bitstream)
+		writeRIFFChunk(&body, "ANMF", frameData.Bytes())
+	}
+
+	vp8x := make([]byte, 10)
+	vp8x[0] = 0x02 // animation
+	if hasAlpha {
+		vp8x[0] |= 0x10
+	}
+	putUint24(vp8x[4:], uint32(width-1))
+	putUint24(vp8x[7:], uint32(height-1))
+
+	anim := make([]byte, 6)
+	binary.LittleEndian.PutUint16(anim[4:], uint16(loopCount)) // background color stays transparent black
+
+	var chunks bytes.Buffer
+	writeRIFFChunk(&chunks, "VP8X", vp8x)
+	writeRIFFChunk(&chunks, "ANIM", anim)
+	chunks.Write(body.Bytes())
+	return wrapRIFF(chunks.Bytes()), nil
+}
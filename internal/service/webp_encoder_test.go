@@ -0,0 +1,149 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/webp"
+)
+
+// parseWebPChunks splits a RIFF/WEBP file (or an ANMF payload) into chunks
+func parseWebPChunks(t *testing.T, data []byte) []webpChunk {
+	t.Helper()
+	var chunks []webpChunk
+	for len(data) >= 8 {
+		size := int(binary.LittleEndian.Uint32(data[4:8]))
+		require.LessOrEqual(t, 8+size, len(data), "chunk %q overruns data", data[:4])
+		chunks = append(chunks, webpChunk{fourCC: string(data[:4]), payload: data[8 : 8+size]})
+		data = data[8+size+size%2:]
+	}
+	return chunks
+}
+
+// parseWebPFile validates the RIFF header and returns the top-level chunks
+func parseWebPFile(t *testing.T, data []byte) []webpChunk {
+	t.Helper()
+	require.GreaterOrEqual(t, len(data), 12)
+	require.Equal(t, "RIFF", string(data[:4]))
+	require.Equal(t, "WEBP", string(data[8:12]))
+	require.Equal(t, len(data)-8, int(binary.LittleEndian.Uint32(data[4:8])))
+	return parseWebPChunks(t, data[12:])
+}
+
+func readUint24(b []byte) int {
+	return int(b[0]) | int(b[1])<<8 | int(b[2])<<16
+}
+
+func TestEncodeWebPLossless_RoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	noisy := image.NewNRGBA(image.Rect(0, 0, 37, 23))
+	rng.Read(noisy.Pix)
+
+	gradient := image.NewNRGBA(image.Rect(0, 0, 64, 48))
+	for y := 0; y < 48; y++ {
+		for x := 0; x < 64; x++ {
+			gradient.SetNRGBA(x, y, color.NRGBA{R: uint8(x * 4), G: uint8(y * 5), B: 128, A: 255})
+		}
+	}
+
+	solid := image.NewNRGBA(image.Rect(0, 0, 5, 5))
+	for i := range solid.Pix {
+		solid.Pix[i] = 0xff
+	}
+
+	tests := []struct {
+		name string
+		img  *image.NRGBA
+	}{
+		{name: "random_pixels_with_alpha", img: noisy},
+		{name: "gradient", img: gradient},
+		{name: "single_color", img: solid},
+		{name: "one_pixel", img: image.NewNRGBA(image.Rect(0, 0, 1, 1))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := encodeWebPLossless(tt.img)
+			require.NoError(t, err)
+
+			decoded, err := webp.Decode(bytes.NewReader(encoded))
+			require.NoError(t, err)
+			require.Equal(t, tt.img.Bounds(), decoded.Bounds())
+
+			nrgba, ok := decoded.(*image.NRGBA)
+			require.True(t, ok)
+			assert.Equal(t, tt.img.Pix, nrgba.Pix)
+		})
+	}
+}
+
+func TestEncodeWebPLossless_RejectsOversizedImage(t *testing.T) {
+	_, err := encodeWebPLossless(image.NewNRGBA(image.Rect(0, 0, vp8lMaxDimension+1, 1)))
+	assert.Error(t, err)
+}
+
+func TestEncodeAnimatedWebP(t *testing.T) {
+	frames := []image.Image{
+		image.NewUniform(color.White),
+	}
+	_, err := encodeAnimatedWebP(frames, []int{100, 200}, 0)
+	assert.Error(t, err, "mismatched delays must be rejected")
+
+	red := image.NewNRGBA(image.Rect(0, 0, 8, 6))
+	blue := image.NewNRGBA(image.Rect(0, 0, 8, 6))
+	for i := 0; i < len(red.Pix); i += 4 {
+		copy(red.Pix[i:], []byte{255, 0, 0, 255})
+		copy(blue.Pix[i:], []byte{0, 0, 255, 128})
+	}
+
+	encoded, err := encodeAnimatedWebP([]image.Image{red, blue}, []int{100, 250}, 3)
+	require.NoError(t, err)
+
+	chunks := parseWebPFile(t, encoded)
+	require.Len(t, chunks, 4)
+
+	assert.Equal(t, "VP8X", chunks[0].fourCC)
+	assert.Equal(t, byte(0x02|0x10), chunks[0].payload[0], "animation and alpha flags")
+	assert.Equal(t, 7, readUint24(chunks[0].payload[4:]))
+	assert.Equal(t, 5, readUint24(chunks[0].payload[7:]))
+
+	assert.Equal(t, "ANIM", chunks[1].fourCC)
+	assert.Equal(t, uint16(3), binary.LittleEndian.Uint16(chunks[1].payload[4:]))
+
+	for i, want := range []struct {
+		delay int
+		img   *image.NRGBA
+	}{{100, red}, {250, blue}} {
+		frame := chunks[2+i]
+		assert.Equal(t, "ANMF", frame.fourCC)
+		assert.Equal(t, want.delay, readUint24(frame.payload[12:]))
+
+		sub := parseWebPChunks(t, frame.payload[16:])
+		require.Len(t, sub, 1)
+		assert.Equal(t, "VP8L", sub[0].fourCC)
+
+		decoded, err := webp.Decode(bytes.NewReader(wrapVP8LForTest(sub[0].payload)))
+		require.NoError(t, err)
+		assert.Equal(t, want.img.Pix, decoded.(*image.NRGBA).Pix)
+	}
+}
+
+func TestWebPLoopCount(t *testing.T) {
+	assert.Equal(t, 0, webpLoopCount(0))
+	assert.Equal(t, 1, webpLoopCount(-1))
+	assert.Equal(t, 3, webpLoopCount(2))
+}
+
+// wrapVP8LForTest turns an ANMF frame bitstream into a standalone WebP file
+func wrapVP8LForTest(bitstream []byte) []byte {
+	var chunks bytes.Buffer
+	writeRIFFChunk(&chunks, "VP8L", bitstream)
+	return wrapRIFF(chunks.Bytes())
+}
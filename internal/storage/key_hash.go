@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// imageKeyPrefix is the top-level prefix of image objects; only keys under it are hashed
+const imageKeyPrefix = "images/"
+
+// keyHashLength is the number of hex characters of the partition segment
+const keyHashLength = 2
+
+// HashedKey spreads image keys across S3 partitions: "images/{id}/..." becomes
+// "images/{hh}/{id}/..." where hh is the start of the hex SHA-256 of the image ID.
+// The mapping is deterministic, so every reader and writer derives the same key.
+// Keys outside images/, and "images/" itself, are returned unchanged.
+func HashedKey(key string) string {
+	rest, ok := strings.CutPrefix(key, imageKeyPrefix)
+	if !ok {
+		return key
+	}
+	id, _, _ := strings.Cut(rest, "/")
+	if id == "" {
+		return key
+	}
+	return imageKeyPrefix + keyHash(id) + "/" + rest
+}
+
+// UnhashedKey reverses HashedKey. Keys without a matching hash segment are
+// returned unchanged.
+func UnhashedKey(key string) string {
+	rest, ok := strings.CutPrefix(key, imageKeyPrefix)
+	if !ok {
+		return key
+	}
+	hash, inner, ok := strings.Cut(rest, "/")
+	if !ok || len(hash) != keyHashLength {
+		return key
+	}
+	id, _, _ := strings.Cut(inner, "/")
+	if id == "" || keyHash(id) != hash {
+		return key
+	}
+	return imageKeyPrefix + inner
+}
+
+// keyHash returns the partition segment of an image ID
+func keyHash(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:])[:keyHashLength]
+}
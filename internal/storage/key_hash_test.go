@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"resizr/internal/config"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashedKey(t *testing.T) {
+	const id = "f47ac10b-58cc-4372-a567-0e02b2c3d479"
+	original := "images/" + id + "/original.jpg"
+
+	hashed := HashedKey(original)
+	assert.Equal(t, hashed, HashedKey(original), "hashing must be deterministic")
+	assert.Regexp(t, `^images/[0-9a-f]{2}/`+id+`/original\.jpg$`, hashed)
+
+	// Every key of an image shares the same partition
+	assert.Equal(t, strings.TrimSuffix(hashed, "original.jpg")+"800x600.jpg", HashedKey("images/"+id+"/800x600.jpg"))
+	assert.Equal(t, strings.TrimSuffix(hashed, "/original.jpg"), HashedKey("images/"+id))
+
+	for _, key := range []string{original, "images/" + id + "/800x600.jpg", "images/" + id + "/", "images/" + id} {
+		assert.Equal(t, key, UnhashedKey(HashedKey(key)), "round trip of %s", key)
+	}
+
+	// Keys outside images/ are left alone
+	for _, key := range []string{"health-check/probe", "images/", "other/" + id + "/original.jpg"} {
+		assert.Equal(t, key, HashedKey(key))
+		assert.Equal(t, key, UnhashedKey(key))
+	}
+
+	// Unhashed keys and mismatched hash segments are not rewritten
+	assert.Equal(t, original, UnhashedKey(original))
+	assert.Equal(t, "images/zz/"+id+"/original.jpg", UnhashedKey("images/zz/"+id+"/original.jpg"))
+}
+
+// keyRecordingS3Client records the object keys and prefixes requested through it
+type keyRecordingS3Client struct {
+	recordingS3Client
+	keys []string
+}
+
+func (k *keyRecordingS3Client) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	k.keys = append(k.keys, aws.ToString(params.Key))
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (k *keyRecordingS3Client) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	k.keys = append(k.keys, aws.ToString(params.Key))
+	return k.recordingS3Client.GetObject(ctx, params, optFns...)
+}
+
+func (k *keyRecordingS3Client) ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	k.keys = append(k.keys, aws.ToString(params.Prefix))
+	var contents []s3types.Object
+	seen := map[string]bool{}
+	for _, key := range k.keys {
+		if strings.HasPrefix(key, aws.ToString(params.Prefix)) && strings.HasSuffix(key, ".jpg") && !seen[key] {
+			seen[key] = true
+			contents = append(contents, s3types.Object{Key: aws.String(key)})
+		}
+	}
+	return &s3.ListObjectsV2Output{Contents: contents}, nil
+}
+
+func TestS3Storage_HashedKeys(t *testing.T) {
+	const id = "f47ac10b-58cc-4372-a567-0e02b2c3d479"
+	key := "images/" + id + "/original.jpg"
+
+	client := &keyRecordingS3Client{recordingS3Client: recordingS3Client{payers: map[string]s3types.RequestPayer{}}}
+	storage := &S3Storage{
+		client:   client,
+		config:   &config.S3Config{},
+		bucket:   "test-bucket",
+		hashKeys: true,
+	}
+	ctx := context.Background()
+
+	require.NoError(t, storage.Upload(ctx, key, strings.NewReader("data"), 4, "image/jpeg"))
+	body, err := storage.Download(ctx, key)
+	require.NoError(t, err)
+	_ = body.Close()
+
+	// Writes and reads agree on the hashed key
+	require.Len(t, client.keys, 2)
+	assert.Equal(t, HashedKey(key), client.keys[0])
+	assert.Equal(t, client.keys[0], client.keys[1])
+
+	// Listings take logical prefixes and return logical keys
+	objects, err := storage.ListObjects(ctx, "images/"+id+"/", 10)
+	require.NoError(t, err)
+	assert.Equal(t, HashedKey("images/"+id+"/"), client.keys[2])
+	require.Len(t, objects, 1)
+	assert.Equal(t, key, objects[0].Key)
+
+	results, err := storage.BatchDelete(ctx, []BatchDeleteOperation{{Key: key}})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, key, results[0].Key)
+	assert.True(t, results[0].Success)
+}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"resizr/internal/config"
@@ -19,16 +20,31 @@ import (
 	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
 	"go.uber.org/zap"
 )
 
 // S3Storage implements ImageStorage interface for AWS S3 and S3-compatible storage
 type S3Storage struct {
-	client     *s3.Client
-	uploader   *manager.Uploader
-	downloader *manager.Downloader
-	config     *config.S3Config
-	bucket     string
+	client       s3API
+	presigner    *s3.PresignClient
+	uploader     *manager.Uploader
+	downloader   *manager.Downloader
+	config       *config.S3Config
+	bucket       string             // Write bucket: uploads, deletes, copies and listings
+	readBucket   string             // Downloads, existence checks and presigned URLs
+	hashKeys     bool               // Store image keys under a hash partition (see HashedKey)
+	requestPayer types.RequestPayer // "requester" on requester-pays buckets, empty otherwise
+}
+
+// s3API is the subset of the S3 client used by S3Storage
+type s3API interface {
+	healthObjectAPI
+	deleteObjectsAPI
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error)
+	CopyObject(ctx context.Context, params *s3.CopyObjectInput, optFns ...func(*s3.Options)) (*s3.CopyObjectOutput, error)
 }
 
 // NewS3Storage creates a new S3 storage instance
@@ -37,7 +53,10 @@ func NewS3Storage(cfg *config.S3Config) (ImageStorage, error) {
 		zap.String("endpoint", cfg.Endpoint),
 		zap.String("region", cfg.Region),
 		zap.String("bucket", cfg.Bucket),
-		zap.Bool("use_ssl", cfg.UseSSL))
+		zap.String("read_bucket", cfg.ReadBucket),
+		zap.Bool("use_ssl", cfg.UseSSL),
+		zap.Bool("requester_pays", cfg.RequesterPays),
+		zap.Bool("key_hash_prefix", cfg.KeyHashPrefix))
 
 	// Create AWS config
 	awsConfig, err := createAWSConfig(cfg)
@@ -45,13 +64,13 @@ func NewS3Storage(cfg *config.S3Config) (ImageStorage, error) {
 		return nil, fmt.Errorf("failed to create AWS config: %w", err)
 	}
 
+	requestHeaders, err := parseRequestHeaders(cfg.RequestHeaders)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create S3 client
-	client := s3.NewFromConfig(awsConfig, func(o *s3.Options) {
-		if cfg.Endpoint != "https://s3.amazonaws.com" {
-			o.BaseEndpoint = aws.String(cfg.Endpoint)
-			o.UsePathStyle = true // Required for MinIO and custom endpoints
-		}
-	})
+	client := s3.NewFromConfig(awsConfig, clientOptions(cfg, requestHeaders))
 
 	// Create upload/download managers
 	uploader := manager.NewUploader(client, func(u *manager.Uploader) {
@@ -66,10 +85,19 @@ func NewS3Storage(cfg *config.S3Config) (ImageStorage, error) {
 
 	storage := &S3Storage{
 		client:     client,
+		presigner:  s3.NewPresignClient(client),
 		uploader:   uploader,
 		downloader: downloader,
 		config:     cfg,
 		bucket:     cfg.Bucket,
+		readBucket: cfg.ReadBucket,
+		hashKeys:   cfg.KeyHashPrefix,
+	}
+	if storage.readBucket == "" {
+		storage.readBucket = cfg.Bucket
+	}
+	if cfg.RequesterPays {
+		storage.requestPayer = types.RequestPayerRequester
 	}
 
 	// Test connection
@@ -83,6 +111,8 @@ func NewS3Storage(cfg *config.S3Config) (ImageStorage, error) {
 
 // Upload uploads a file to S3
 func (s *S3Storage) Upload(ctx context.Context, key string, reader io.Reader, size int64, contentType string) error {
+	defer logger.TrackTiming(ctx, logger.TimingStorage, time.Now())
+
 	logger.DebugWithContext(ctx, "Uploading file to S3",
 		zap.String("key", key),
 		zap.Int64("size", size),
@@ -90,10 +120,11 @@ func (s *S3Storage) Upload(ctx context.Context, key string, reader io.Reader, si
 
 	// Prepare upload input
 	uploadInput := &s3.PutObjectInput{
-		Bucket:      aws.String(s.bucket),
-		Key:         aws.String(key),
-		Body:        reader,
-		ContentType: aws.String(contentType),
+		Bucket:       aws.String(s.bucket),
+		Key:          aws.String(s.objectKey(key)),
+		Body:         reader,
+		ContentType:  aws.String(contentType),
+		RequestPayer: s.requestPayer,
 	}
 
 	// Set content length if known
@@ -115,6 +146,7 @@ func (s *S3Storage) Upload(ctx context.Context, key string, reader io.Reader, si
 			ContentType:   uploadInput.ContentType,
 			ContentLength: uploadInput.ContentLength,
 			CacheControl:  uploadInput.CacheControl,
+			RequestPayer:  uploadInput.RequestPayer,
 		})
 		if err != nil {
 			logger.ErrorWithContext(ctx, "Failed to upload large file to S3",
@@ -144,13 +176,16 @@ func (s *S3Storage) Upload(ctx context.Context, key string, reader io.Reader, si
 
 // Download downloads a file from S3 as a stream
 func (s *S3Storage) Download(ctx context.Context, key string) (io.ReadCloser, error) {
+	defer logger.TrackTiming(ctx, logger.TimingStorage, time.Now())
+
 	logger.DebugWithContext(ctx, "Downloading file from S3",
 		zap.String("key", key))
 
 	// Get object from S3
 	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
-		Bucket: aws.String(s.bucket),
-		Key:    aws.String(key),
+		Bucket:       aws.String(s.readBucket),
+		Key:          aws.String(s.objectKey(key)),
+		RequestPayer: s.requestPayer,
 	})
 	if err != nil {
 		logger.ErrorWithContext(ctx, "Failed to download file from S3",
@@ -172,12 +207,15 @@ func (s *S3Storage) Download(ctx context.Context, key string) (io.ReadCloser, er
 
 // Delete removes a file from S3
 func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	defer logger.TrackTiming(ctx, logger.TimingStorage, time.Now())
+
 	logger.DebugWithContext(ctx, "Deleting file from S3",
 		zap.String("key", key))
 
 	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
-		Bucket: aws.String(s.bucket),
-		Key:    aws.String(key),
+		Bucket:       aws.String(s.bucket),
+		Key:          aws.String(s.objectKey(key)),
+		RequestPayer: s.requestPayer,
 	})
 	if err != nil {
 		logger.ErrorWithContext(ctx, "Failed to delete file from S3",
@@ -214,7 +252,7 @@ func (s *S3Storage) DeleteFolder(ctx context.Context, prefix string) error {
 
 	// Add query parameters
 	params := url.Values{}
-	params.Add("prefix", prefix)
+	params.Add("prefix", s.objectKey(prefix))
 	params.Add("all_versions", "false")
 	params.Add("bypass", "false")
 	params.Add("recursive", "true")
@@ -276,9 +314,12 @@ func (s *S3Storage) DeleteFolder(ctx context.Context, prefix string) error {
 
 // Exists checks if a file exists in S3
 func (s *S3Storage) Exists(ctx context.Context, key string) (bool, error) {
+	defer logger.TrackTiming(ctx, logger.TimingStorage, time.Now())
+
 	_, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
-		Bucket: aws.String(s.bucket),
-		Key:    aws.String(key),
+		Bucket:       aws.String(s.readBucket),
+		Key:          aws.String(s.objectKey(key)),
+		RequestPayer: s.requestPayer,
 	})
 	if err != nil {
 		if isNotFoundError(err) {
@@ -302,12 +343,15 @@ func (s *S3Storage) Exists(ctx context.Context, key string) (bool, error) {
 
 // GetMetadata retrieves file metadata
 func (s *S3Storage) GetMetadata(ctx context.Context, key string) (*FileMetadata, error) {
+	defer logger.TrackTiming(ctx, logger.TimingStorage, time.Now())
+
 	logger.DebugWithContext(ctx, "Getting file metadata from S3",
 		zap.String("key", key))
 
 	result, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
-		Bucket: aws.String(s.bucket),
-		Key:    aws.String(key),
+		Bucket:       aws.String(s.readBucket),
+		Key:          aws.String(s.objectKey(key)),
+		RequestPayer: s.requestPayer,
 	})
 	if err != nil {
 		if isNotFoundError(err) {
@@ -339,13 +383,11 @@ func (s *S3Storage) GeneratePresignedURL(ctx context.Context, key string, expira
 		zap.String("key", key),
 		zap.Duration("expiration", expiration))
 
-	// Create pre-sign client
-	presignClient := s3.NewPresignClient(s.client)
-
-	// Generate pre-signed GET request
-	presignResult, err := presignClient.PresignGetObject(ctx, &s3.GetObjectInput{
-		Bucket: aws.String(s.bucket),
-		Key:    aws.String(key),
+	// Generate pre-signed GET request; the request payer is signed into the URL
+	presignResult, err := s.presigner.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket:       aws.String(s.readBucket),
+		Key:          aws.String(s.objectKey(key)),
+		RequestPayer: s.requestPayer,
 	}, func(opts *s3.PresignOptions) {
 		opts.Expires = expiration
 	})
@@ -356,29 +398,93 @@ func (s *S3Storage) GeneratePresignedURL(ctx context.Context, key string, expira
 		return "", fmt.Errorf("failed to generate pre-signed URL: %w", err)
 	}
 
+	signedURL := presignResult.URL
+	if s.config.CDNPresignedURLs && s.config.CDNBaseURL != "" {
+		signedURL, err = s.cdnPresignedURL(signedURL)
+		if err != nil {
+			return "", err
+		}
+	}
+
 	logger.DebugWithContext(ctx, "Pre-signed URL generated successfully",
 		zap.String("key", key),
 		zap.Duration("expiration", expiration))
 
-	return presignResult.URL, nil
+	return signedURL, nil
+}
+
+// clientOptions points the S3 client at a custom endpoint and picks path-style or
+// virtual-hosted addressing from S3_FORCE_PATH_STYLE
+func clientOptions(cfg *config.S3Config, requestHeaders http.Header) func(*s3.Options) {
+	return func(o *s3.Options) {
+		if cfg.Endpoint != "https://s3.amazonaws.com" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+		}
+		o.UsePathStyle = cfg.ForcePathStyle
+		o.APIOptions = append(o.APIOptions, requestHeaderOptions(requestHeaders)...)
+	}
+}
+
+// cdnPresignedURL moves a presigned URL onto the CDN host, keeping the object path and
+// the signature query. The CDN must forward the request to S3 with the original Host
+// header, which the signature covers.
+func (s *S3Storage) cdnPresignedURL(signedURL string) (string, error) {
+	parsed, err := url.Parse(signedURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse pre-signed URL: %w", err)
+	}
+
+	// Path-style URLs carry the bucket as the first path segment
+	path := parsed.EscapedPath()
+	if s.config.ForcePathStyle {
+		path = strings.TrimPrefix(path, "/"+s.readBucket)
+	}
+	return strings.TrimRight(s.config.CDNBaseURL, "/") + path + "?" + parsed.RawQuery, nil
 }
 
-// ListObjects lists objects with a given prefix
+// ListObjects lists objects with a given prefix. Write-probe health objects are
+// left out unless the prefix itself points into the health check prefix. With
+// hashed keys a prefix below images/ must name a whole image ID.
 func (s *S3Storage) ListObjects(ctx context.Context, prefix string, maxKeys int) ([]ObjectInfo, error) {
+	objects, err := listObjects(ctx, s.client, s.bucket, s.requestPayer, s.objectKey(prefix), s.healthPrefix(), maxKeys)
+	if err != nil || !s.hashKeys {
+		return objects, err
+	}
+	for i := range objects {
+		objects[i].Key = UnhashedKey(objects[i].Key)
+	}
+	return objects, nil
+}
+
+// objectKey maps a logical storage key to the key the object is stored under
+func (s *S3Storage) objectKey(key string) string {
+	if s.hashKeys {
+		return HashedKey(key)
+	}
+	return key
+}
+
+// listObjectsAPI is the subset of the S3 client used to list objects
+type listObjectsAPI interface {
+	ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error)
+}
+
+func listObjects(ctx context.Context, client listObjectsAPI, bucket string, requestPayer types.RequestPayer, prefix, healthPrefix string, maxKeys int) ([]ObjectInfo, error) {
 	logger.DebugWithContext(ctx, "Listing objects from S3",
 		zap.String("prefix", prefix),
 		zap.Int("max_keys", maxKeys))
 
 	input := &s3.ListObjectsV2Input{
-		Bucket: aws.String(s.bucket),
-		Prefix: aws.String(prefix),
+		Bucket:       aws.String(bucket),
+		Prefix:       aws.String(prefix),
+		RequestPayer: requestPayer,
 	}
 
 	if maxKeys > 0 {
 		input.MaxKeys = aws.Int32(int32(maxKeys))
 	}
 
-	result, err := s.client.ListObjectsV2(ctx, input)
+	result, err := client.ListObjectsV2(ctx, input)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list objects: %w", err)
 	}
@@ -392,6 +498,9 @@ func (s *S3Storage) ListObjects(ctx context.Context, prefix string, maxKeys int)
 			ETag:         aws.ToString(obj.ETag),
 		}
 	}
+	if !strings.HasPrefix(prefix, healthPrefix) {
+		objects = excludeHealthObjects(objects, healthPrefix)
+	}
 
 	logger.DebugWithContext(ctx, "Objects listed successfully",
 		zap.String("prefix", prefix),
@@ -402,16 +511,19 @@ func (s *S3Storage) ListObjects(ctx context.Context, prefix string, maxKeys int)
 
 // CopyObject copies an object to a new location
 func (s *S3Storage) CopyObject(ctx context.Context, sourceKey, destKey string) error {
+	defer logger.TrackTiming(ctx, logger.TimingStorage, time.Now())
+
 	logger.DebugWithContext(ctx, "Copying object in S3",
 		zap.String("source_key", sourceKey),
 		zap.String("dest_key", destKey))
 
-	copySource := fmt.Sprintf("%s/%s", s.bucket, sourceKey)
+	copySource := fmt.Sprintf("%s/%s", s.bucket, s.objectKey(sourceKey))
 
 	_, err := s.client.CopyObject(ctx, &s3.CopyObjectInput{
-		Bucket:     aws.String(s.bucket),
-		CopySource: aws.String(copySource),
-		Key:        aws.String(destKey),
+		Bucket:       aws.String(s.bucket),
+		CopySource:   aws.String(copySource),
+		Key:          aws.String(s.objectKey(destKey)),
+		RequestPayer: s.requestPayer,
 	})
 	if err != nil {
 		logger.ErrorWithContext(ctx, "Failed to copy object",
@@ -428,59 +540,169 @@ func (s *S3Storage) CopyObject(ctx context.Context, sourceKey, destKey string) e
 	return nil
 }
 
-// GetURL returns the public URL for an object
+// GetURL returns the public URL for an object, on CDN_BASE_URL when one is configured
 func (s *S3Storage) GetURL(key string) string {
-	if s.config.UseSSL {
-		if s.config.Endpoint == "https://s3.amazonaws.com" {
-			return fmt.Sprintf("https://%s.s3.amazonaws.com/%s", s.bucket, key)
+	key = s.objectKey(key)
+	if s.config.CDNBaseURL != "" {
+		return fmt.Sprintf("%s/%s", strings.TrimRight(s.config.CDNBaseURL, "/"), key)
+	}
+	if !s.config.ForcePathStyle {
+		if endpoint, err := url.Parse(s.config.Endpoint); err == nil && endpoint.Host != "" {
+			return fmt.Sprintf("%s://%s.%s/%s", endpoint.Scheme, s.readBucket, endpoint.Host, key)
 		}
-		return fmt.Sprintf("%s/%s/%s", s.config.Endpoint, s.bucket, key)
+	}
+	if s.config.UseSSL {
+		return fmt.Sprintf("%s/%s/%s", s.config.Endpoint, s.readBucket, key)
 	}
 
 	return fmt.Sprintf("http://%s/%s/%s",
-		strings.TrimPrefix(s.config.Endpoint, "http://"), s.bucket, key)
+		strings.TrimPrefix(s.config.Endpoint, "http://"), s.readBucket, key)
 }
 
 // Health checks storage service health
 func (s *S3Storage) Health(ctx context.Context) error {
 	// Check if we can list bucket (basic connectivity test)
 	_, err := s.client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
-		Bucket:  aws.String(s.bucket),
-		MaxKeys: aws.Int32(1),
+		Bucket:       aws.String(s.bucket),
+		MaxKeys:      aws.Int32(1),
+		RequestPayer: s.requestPayer,
 	})
 	if err != nil {
 		return fmt.Errorf("S3 health check failed: %w", err)
 	}
+	if s.readBucket != s.bucket {
+		_, err := s.client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+			Bucket:       aws.String(s.readBucket),
+			MaxKeys:      aws.Int32(1),
+			RequestPayer: s.requestPayer,
+		})
+		if err != nil {
+			return fmt.Errorf("S3 health check failed for read bucket %s: %w", s.readBucket, err)
+		}
+	}
+
+	if s.config.HealthWriteProbeDisabled {
+		return nil
+	}
 
-	// Test write permissions with a health check object
-	healthKey := fmt.Sprintf("health-check/%d", time.Now().Unix())
+	now := time.Now()
+	if err := writeHealthProbe(ctx, s.client, s.bucket, s.requestPayer, s.healthPrefix(), now); err != nil {
+		return err
+	}
+	cleanupStaleHealthObjects(ctx, s.client, s.bucket, s.requestPayer, s.healthPrefix(), now)
+
+	return nil
+}
+
+// DefaultHealthCheckPrefix is where write-probe objects go when no prefix is configured
+const DefaultHealthCheckPrefix = "health-check/"
+
+// staleHealthObjectAge is how old a probe object must be before a health check
+// treats it as a leftover of a failed cleanup and removes it
+const staleHealthObjectAge = 10 * time.Minute
+
+// healthObjectAPI is the subset of the S3 client used by the write probe
+type healthObjectAPI interface {
+	listObjectsAPI
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)
+}
+
+// healthPrefix returns the configured health check prefix, always ending in "/"
+func (s *S3Storage) healthPrefix() string {
+	prefix := s.config.HealthCheckPrefix
+	if prefix == "" {
+		return DefaultHealthCheckPrefix
+	}
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return prefix
+}
+
+// excludeHealthObjects drops write-probe objects so bucket scans never see them
+func excludeHealthObjects(objects []ObjectInfo, healthPrefix string) []ObjectInfo {
+	filtered := objects[:0]
+	for _, obj := range objects {
+		if !strings.HasPrefix(obj.Key, healthPrefix) {
+			filtered = append(filtered, obj)
+		}
+	}
+	return filtered
+}
 
-	// Try to put a small test object
-	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
-		Bucket:      aws.String(s.bucket),
-		Key:         aws.String(healthKey),
-		Body:        strings.NewReader("health-check"),
-		ContentType: aws.String("text/plain"),
+// writeHealthProbe tests write permissions by putting and removing a small object
+func writeHealthProbe(ctx context.Context, client healthObjectAPI, bucket string, requestPayer types.RequestPayer, prefix string, now time.Time) error {
+	healthKey := fmt.Sprintf("%s%d", prefix, now.UnixNano())
+
+	_, err := client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:       aws.String(bucket),
+		Key:          aws.String(healthKey),
+		Body:         strings.NewReader("health-check"),
+		ContentType:  aws.String("text/plain"),
+		RequestPayer: requestPayer,
 	})
 	if err != nil {
 		return fmt.Errorf("S3 write test failed: %w", err)
 	}
 
-	// Clean up test object
-	_, err = s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
-		Bucket: aws.String(s.bucket),
-		Key:    aws.String(healthKey),
+	_, err = client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket:       aws.String(bucket),
+		Key:          aws.String(healthKey),
+		RequestPayer: requestPayer,
 	})
 	if err != nil {
 		logger.WarnWithContext(ctx, "Failed to cleanup health check object",
 			zap.String("key", healthKey),
 			zap.Error(err))
-		// Not a critical error for health check
+		// Not a critical error for health check; a later check removes it as stale
 	}
 
 	return nil
 }
 
+// cleanupStaleHealthObjects removes probe objects older than staleHealthObjectAge
+// and returns how many were deleted. Failures are logged, never returned.
+func cleanupStaleHealthObjects(ctx context.Context, client healthObjectAPI, bucket string, requestPayer types.RequestPayer, prefix string, now time.Time) int {
+	result, err := client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+		Bucket:       aws.String(bucket),
+		Prefix:       aws.String(prefix),
+		RequestPayer: requestPayer,
+	})
+	if err != nil {
+		logger.WarnWithContext(ctx, "Failed to list stale health check objects",
+			zap.String("prefix", prefix),
+			zap.Error(err))
+		return 0
+	}
+
+	removed := 0
+	for _, obj := range result.Contents {
+		if now.Sub(aws.ToTime(obj.LastModified)) < staleHealthObjectAge {
+			continue
+		}
+		key := aws.ToString(obj.Key)
+		if _, err := client.DeleteObject(ctx, &s3.DeleteObjectInput{
+			Bucket:       aws.String(bucket),
+			Key:          aws.String(key),
+			RequestPayer: requestPayer,
+		}); err != nil {
+			logger.WarnWithContext(ctx, "Failed to delete stale health check object",
+				zap.String("key", key),
+				zap.Error(err))
+			continue
+		}
+		removed++
+	}
+
+	if removed > 0 {
+		logger.InfoWithContext(ctx, "Removed stale health check objects",
+			zap.String("prefix", prefix),
+			zap.Int("count", removed))
+	}
+	return removed
+}
+
 // Helper functions
 
 // createAWSConfig creates AWS configuration
@@ -504,6 +726,30 @@ func createAWSConfig(cfg *config.S3Config) (aws.Config, error) {
 	return awsConfig, nil
 }
 
+// parseRequestHeaders turns "Name: Value" entries into headers sent on every S3 request
+func parseRequestHeaders(entries []string) (http.Header, error) {
+	headers := make(http.Header, len(entries))
+	for _, entry := range entries {
+		name, value, ok := strings.Cut(entry, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid S3 request header %q: expected Name: Value", entry)
+		}
+		headers.Set(name, strings.TrimSpace(value))
+	}
+	return headers, nil
+}
+
+// requestHeaderOptions returns client middleware that injects headers into every request,
+// for gateways and bucket policies that need headers the SDK does not model
+func requestHeaderOptions(headers http.Header) []func(*middleware.Stack) error {
+	options := make([]func(*middleware.Stack) error, 0, len(headers))
+	for name := range headers {
+		options = append(options, smithyhttp.SetHeaderValue(name, headers.Get(name)))
+	}
+	return options
+}
+
 // isNotFoundError checks if the error is a "not found" error
 func isNotFoundError(err error) bool {
 	if err == nil {
@@ -528,42 +774,140 @@ func isNotFoundError(err error) bool {
 		strings.Contains(err.Error(), "Not Found")
 }
 
+// maxDeleteObjectsPerRequest is the S3 limit on keys per DeleteObjects call
+const maxDeleteObjectsPerRequest = 1000
+
+// deleteObjectsAPI is the subset of the S3 client used by batch deletes
+type deleteObjectsAPI interface {
+	DeleteObjects(ctx context.Context, params *s3.DeleteObjectsInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectsOutput, error)
+}
+
 // BatchDelete implements batch delete operations
 func (s *S3Storage) BatchDelete(ctx context.Context, operations []BatchDeleteOperation) ([]BatchResult, error) {
+	if !s.hashKeys {
+		return batchDelete(ctx, s.client, s.bucket, s.requestPayer, operations, s.config.BatchDeleteConcurrency)
+	}
+
+	hashed := make([]BatchDeleteOperation, len(operations))
+	for i, op := range operations {
+		hashed[i] = BatchDeleteOperation{Key: HashedKey(op.Key)}
+	}
+	results, err := batchDelete(ctx, s.client, s.bucket, s.requestPayer, hashed, s.config.BatchDeleteConcurrency)
+	for i := range results {
+		results[i].Key = UnhashedKey(results[i].Key)
+	}
+	return results, err
+}
+
+// batchDelete splits operations into chunks that fit a single DeleteObjects
+// call and runs up to concurrency chunks at a time. Results keep the input
+// order; an error is returned only when every chunk request failed.
+func batchDelete(ctx context.Context, client deleteObjectsAPI, bucket string, requestPayer types.RequestPayer, operations []BatchDeleteOperation, concurrency int) ([]BatchResult, error) {
 	if len(operations) == 0 {
 		return []BatchResult{}, nil
 	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	chunkCount := (len(operations) + maxDeleteObjectsPerRequest - 1) / maxDeleteObjectsPerRequest
 
 	logger.DebugWithContext(ctx, "Batch deleting objects from S3",
-		zap.Int("count", len(operations)))
+		zap.Int("count", len(operations)),
+		zap.Int("chunks", chunkCount),
+		zap.Int("concurrency", concurrency))
 
+	results := make([]BatchResult, len(operations))
+	chunkErrors := make([]error, chunkCount)
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+
+	for chunk := 0; chunk < chunkCount; chunk++ {
+		start := chunk * maxDeleteObjectsPerRequest
+		end := start + maxDeleteObjectsPerRequest
+		if end > len(operations) {
+			end = len(operations)
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(chunk, start, end int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			chunkErrors[chunk] = deleteChunk(ctx, client, bucket, requestPayer, operations[start:end], results[start:end])
+		}(chunk, start, end)
+	}
+	wg.Wait()
+
+	var (
+		failedChunks int
+		lastErr      error
+		successful   int
+	)
+	for _, err := range chunkErrors {
+		if err != nil {
+			failedChunks++
+			lastErr = err
+		}
+	}
+	for _, result := range results {
+		if result.Success {
+			successful++
+		}
+	}
+
+	if failedChunks == chunkCount {
+		logger.ErrorWithContext(ctx, "Batch delete failed",
+			zap.Error(lastErr))
+		return nil, fmt.Errorf("batch delete failed: %w", lastErr)
+	}
+
+	logger.DebugWithContext(ctx, "Batch delete completed",
+		zap.Int("total", len(operations)),
+		zap.Int("successful", successful),
+		zap.Int("failed", len(operations)-successful),
+		zap.Int("failed_chunks", failedChunks))
+
+	return results, nil
+}
+
+// deleteChunk issues a single DeleteObjects call and fills results for the
+// chunk. When the request itself fails every key in the chunk is marked
+// failed and the error is returned.
+func deleteChunk(ctx context.Context, client deleteObjectsAPI, bucket string, requestPayer types.RequestPayer, operations []BatchDeleteOperation, results []BatchResult) error {
 	// Prepare delete objects
-	var objectIdentifiers []types.ObjectIdentifier
+	objectIdentifiers := make([]types.ObjectIdentifier, 0, len(operations))
 	for _, op := range operations {
 		objectIdentifiers = append(objectIdentifiers, types.ObjectIdentifier{
 			Key: aws.String(op.Key),
 		})
 	}
 
-	// Execute batch delete
 	deleteInput := &s3.DeleteObjectsInput{
-		Bucket: aws.String(s.bucket),
+		Bucket: aws.String(bucket),
 		Delete: &types.Delete{
 			Objects: objectIdentifiers,
 			Quiet:   aws.Bool(false), // We want to know which failed
 		},
+		RequestPayer: requestPayer,
 	}
 
-	result, err := s.client.DeleteObjects(ctx, deleteInput)
+	result, err := client.DeleteObjects(ctx, deleteInput)
 	if err != nil {
-		logger.ErrorWithContext(ctx, "Batch delete failed",
+		logger.WarnWithContext(ctx, "Batch delete chunk failed",
+			zap.Int("count", len(operations)),
 			zap.Error(err))
-		return nil, fmt.Errorf("batch delete failed: %w", err)
+		for i, op := range operations {
+			results[i] = BatchResult{
+				Key:     op.Key,
+				Success: false,
+				Error:   err.Error(),
+			}
+		}
+		return err
 	}
 
-	// Process results
-	results := make([]BatchResult, len(operations))
-
 	// Mark successful deletions
 	deletedKeys := make(map[string]bool)
 	for _, deleted := range result.Deleted {
@@ -601,10 +945,5 @@ func (s *S3Storage) BatchDelete(ctx context.Context, operations []BatchDeleteOpe
 		}
 	}
 
-	logger.DebugWithContext(ctx, "Batch delete completed",
-		zap.Int("total", len(operations)),
-		zap.Int("successful", len(result.Deleted)),
-		zap.Int("failed", len(result.Errors)))
-
-	return results, nil
+	return nil
 }
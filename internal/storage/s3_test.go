@@ -3,12 +3,24 @@ package storage
 import (
 	"context"
 	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"resizr/internal/config"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestS3Storage_DeleteFolder(t *testing.T) {
@@ -219,3 +231,526 @@ func TestS3Storage_ConfigValidation(t *testing.T) {
 		}
 	})
 }
+
+// fakeDeleteObjectsClient records DeleteObjects calls and fails keys or whole
+// requests on demand.
+type fakeDeleteObjectsClient struct {
+	mu            sync.Mutex
+	calls         int
+	maxChunk      int
+	inFlight      int
+	maxInFlight   int
+	failKeys      map[string]bool
+	failRequestAt map[int]bool // chunk index (by first key) whose request fails
+}
+
+func (f *fakeDeleteObjectsClient) DeleteObjects(ctx context.Context, params *s3.DeleteObjectsInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectsOutput, error) {
+	f.mu.Lock()
+	f.calls++
+	f.inFlight++
+	if f.inFlight > f.maxInFlight {
+		f.maxInFlight = f.inFlight
+	}
+	if len(params.Delete.Objects) > f.maxChunk {
+		f.maxChunk = len(params.Delete.Objects)
+	}
+	f.mu.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+
+	f.mu.Lock()
+	f.inFlight--
+	f.mu.Unlock()
+
+	var first int
+	fmt.Sscanf(aws.ToString(params.Delete.Objects[0].Key), "images/%d", &first)
+	if f.failRequestAt[first/maxDeleteObjectsPerRequest] {
+		return nil, fmt.Errorf("request failed")
+	}
+
+	output := &s3.DeleteObjectsOutput{}
+	for _, obj := range params.Delete.Objects {
+		key := aws.ToString(obj.Key)
+		if f.failKeys[key] {
+			output.Errors = append(output.Errors, types.Error{
+				Key:     aws.String(key),
+				Message: aws.String("access denied"),
+			})
+			continue
+		}
+		output.Deleted = append(output.Deleted, types.DeletedObject{Key: aws.String(key)})
+	}
+	return output, nil
+}
+
+func testDeleteOperations(n int) []BatchDeleteOperation {
+	operations := make([]BatchDeleteOperation, n)
+	for i := range operations {
+		operations[i] = BatchDeleteOperation{Key: fmt.Sprintf("images/%d", i)}
+	}
+	return operations
+}
+
+func TestBatchDelete_ChunksLargeBatches(t *testing.T) {
+	client := &fakeDeleteObjectsClient{
+		failKeys: map[string]bool{"images/5": true, "images/2499": true},
+	}
+	operations := testDeleteOperations(2500)
+
+	results, err := batchDelete(context.Background(), client, "test-bucket", "", operations, 2)
+
+	require.NoError(t, err)
+	require.Len(t, results, len(operations))
+	assert.Equal(t, 3, client.calls)
+	assert.LessOrEqual(t, client.maxChunk, maxDeleteObjectsPerRequest)
+	assert.LessOrEqual(t, client.maxInFlight, 2)
+
+	for i, result := range results {
+		assert.Equal(t, operations[i].Key, result.Key)
+		if i == 5 || i == 2499 {
+			assert.False(t, result.Success)
+			assert.Equal(t, "access denied", result.Error)
+		} else {
+			assert.True(t, result.Success, "key %s", result.Key)
+		}
+	}
+}
+
+func TestBatchDelete_PartialChunkFailure(t *testing.T) {
+	client := &fakeDeleteObjectsClient{failRequestAt: map[int]bool{1: true}}
+	operations := testDeleteOperations(2500)
+
+	results, err := batchDelete(context.Background(), client, "test-bucket", "", operations, 3)
+
+	require.NoError(t, err)
+	require.Len(t, results, len(operations))
+	for i, result := range results {
+		assert.Equal(t, operations[i].Key, result.Key)
+		if i >= 1000 && i < 2000 {
+			assert.False(t, result.Success)
+			assert.Equal(t, "request failed", result.Error)
+		} else {
+			assert.True(t, result.Success)
+		}
+	}
+}
+
+func TestBatchDelete_AllChunksFail(t *testing.T) {
+	client := &fakeDeleteObjectsClient{failRequestAt: map[int]bool{0: true, 1: true}}
+
+	results, err := batchDelete(context.Background(), client, "test-bucket", "", testDeleteOperations(1500), 2)
+
+	assert.Error(t, err)
+	assert.Nil(t, results)
+}
+
+func TestBatchDelete_EmptyAndSequential(t *testing.T) {
+	client := &fakeDeleteObjectsClient{}
+
+	results, err := batchDelete(context.Background(), client, "test-bucket", "", nil, 2)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+	assert.Equal(t, 0, client.calls)
+
+	// A non-positive concurrency falls back to one request at a time
+	results, err = batchDelete(context.Background(), client, "test-bucket", "", testDeleteOperations(2001), 0)
+	require.NoError(t, err)
+	assert.Len(t, results, 2001)
+	assert.Equal(t, 3, client.calls)
+	assert.Equal(t, 1, client.maxInFlight)
+}
+
+// fakeHealthObjectClient is an in-memory bucket for write-probe and listing tests
+type fakeHealthObjectClient struct {
+	objects   map[string]time.Time
+	deleted   []string
+	failPut   bool
+	listCalls int
+}
+
+func (f *fakeHealthObjectClient) ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	f.listCalls++
+	prefix := aws.ToString(params.Prefix)
+	keys := make([]string, 0, len(f.objects))
+	for key := range f.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	out := &s3.ListObjectsV2Output{}
+	for _, key := range keys {
+		out.Contents = append(out.Contents, types.Object{
+			Key:          aws.String(key),
+			Size:         aws.Int64(12),
+			LastModified: aws.Time(f.objects[key]),
+		})
+	}
+	return out, nil
+}
+
+func (f *fakeHealthObjectClient) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	if f.failPut {
+		return nil, fmt.Errorf("access denied")
+	}
+	f.objects[aws.ToString(params.Key)] = time.Now()
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (f *fakeHealthObjectClient) DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	key := aws.ToString(params.Key)
+	delete(f.objects, key)
+	f.deleted = append(f.deleted, key)
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+func TestListObjects_ExcludesHealthObjects(t *testing.T) {
+	now := time.Now()
+	client := &fakeHealthObjectClient{objects: map[string]time.Time{
+		"health-check/1700000000":         now,
+		"images/a/original.jpg":           now,
+		"images/a/thumbnail.jpg":          now,
+		"images/b/original.png":           now,
+		"health-check/1700000001":         now,
+		"health-checker/not-a-probe.json": now,
+	}}
+
+	objects, err := listObjects(context.Background(), client, "test-bucket", "", "", DefaultHealthCheckPrefix, 0)
+	require.NoError(t, err)
+
+	var keys []string
+	for _, obj := range objects {
+		keys = append(keys, obj.Key)
+	}
+	assert.Equal(t, []string{"health-checker/not-a-probe.json", "images/a/original.jpg", "images/a/thumbnail.jpg", "images/b/original.png"}, keys)
+
+	// Listing the health prefix directly still returns the probe objects
+	objects, err = listObjects(context.Background(), client, "test-bucket", "", DefaultHealthCheckPrefix, DefaultHealthCheckPrefix, 0)
+	require.NoError(t, err)
+	assert.Len(t, objects, 2)
+}
+
+func TestWriteHealthProbe(t *testing.T) {
+	now := time.Now()
+
+	t.Run("probe object is written under the prefix and removed", func(t *testing.T) {
+		client := &fakeHealthObjectClient{objects: map[string]time.Time{}}
+
+		err := writeHealthProbe(context.Background(), client, "test-bucket", "", "ops/health/", now)
+
+		require.NoError(t, err)
+		require.Len(t, client.deleted, 1)
+		assert.True(t, strings.HasPrefix(client.deleted[0], "ops/health/"))
+		assert.Empty(t, client.objects)
+	})
+
+	t.Run("write failure fails the check", func(t *testing.T) {
+		client := &fakeHealthObjectClient{objects: map[string]time.Time{}, failPut: true}
+
+		err := writeHealthProbe(context.Background(), client, "test-bucket", "", DefaultHealthCheckPrefix, now)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "S3 write test failed")
+	})
+}
+
+func TestCleanupStaleHealthObjects(t *testing.T) {
+	now := time.Now()
+	client := &fakeHealthObjectClient{objects: map[string]time.Time{
+		"health-check/old":    now.Add(-time.Hour),
+		"health-check/recent": now.Add(-time.Minute),
+		"images/a/old.jpg":    now.Add(-24 * time.Hour),
+	}}
+
+	removed := cleanupStaleHealthObjects(context.Background(), client, "test-bucket", "", DefaultHealthCheckPrefix, now)
+
+	assert.Equal(t, 1, removed)
+	assert.Equal(t, []string{"health-check/old"}, client.deleted)
+	assert.Contains(t, client.objects, "health-check/recent")
+	assert.Contains(t, client.objects, "images/a/old.jpg", "objects outside the health prefix are never touched")
+}
+
+func TestS3Storage_HealthPrefix(t *testing.T) {
+	tests := []struct {
+		configured string
+		expected   string
+	}{
+		{"", DefaultHealthCheckPrefix},
+		{"ops/health/", "ops/health/"},
+		{"ops/health", "ops/health/"},
+	}
+
+	for _, tt := range tests {
+		storage := &S3Storage{config: &config.S3Config{HealthCheckPrefix: tt.configured}}
+		assert.Equal(t, tt.expected, storage.healthPrefix(), "configured %q", tt.configured)
+	}
+}
+
+// recordingS3Client records the bucket and request payer of every call made through it
+type recordingS3Client struct {
+	payers  map[string]types.RequestPayer
+	buckets map[string]string
+}
+
+func (r *recordingS3Client) record(op string, bucket *string, payer types.RequestPayer) {
+	r.payers[op] = payer
+	if r.buckets == nil {
+		r.buckets = map[string]string{}
+	}
+	r.buckets[op] = aws.ToString(bucket)
+}
+
+func (r *recordingS3Client) ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	r.record("ListObjectsV2", params.Bucket, params.RequestPayer)
+	return &s3.ListObjectsV2Output{}, nil
+}
+
+func (r *recordingS3Client) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	r.record("PutObject", params.Bucket, params.RequestPayer)
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (r *recordingS3Client) DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	r.record("DeleteObject", params.Bucket, params.RequestPayer)
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+func (r *recordingS3Client) DeleteObjects(ctx context.Context, params *s3.DeleteObjectsInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectsOutput, error) {
+	r.record("DeleteObjects", params.Bucket, params.RequestPayer)
+	out := &s3.DeleteObjectsOutput{}
+	for _, obj := range params.Delete.Objects {
+		out.Deleted = append(out.Deleted, types.DeletedObject{Key: obj.Key})
+	}
+	return out, nil
+}
+
+func (r *recordingS3Client) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	r.record("GetObject", params.Bucket, params.RequestPayer)
+	return &s3.GetObjectOutput{Body: io.NopCloser(strings.NewReader("data"))}, nil
+}
+
+func (r *recordingS3Client) HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+	r.record("HeadObject", params.Bucket, params.RequestPayer)
+	return &s3.HeadObjectOutput{}, nil
+}
+
+func (r *recordingS3Client) CopyObject(ctx context.Context, params *s3.CopyObjectInput, optFns ...func(*s3.Options)) (*s3.CopyObjectOutput, error) {
+	r.record("CopyObject", params.Bucket, params.RequestPayer)
+	return &s3.CopyObjectOutput{}, nil
+}
+
+func TestS3Storage_RequesterPays(t *testing.T) {
+	tests := []struct {
+		name     string
+		payer    types.RequestPayer
+		expected types.RequestPayer
+	}{
+		{"enabled", types.RequestPayerRequester, "requester"},
+		{"disabled", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &recordingS3Client{payers: map[string]types.RequestPayer{}}
+			storage := &S3Storage{
+				client:       client,
+				config:       &config.S3Config{},
+				bucket:       "test-bucket",
+				requestPayer: tt.payer,
+			}
+			ctx := context.Background()
+
+			require.NoError(t, storage.Upload(ctx, "images/a/original.jpg", strings.NewReader("data"), 4, "image/jpeg"))
+			body, err := storage.Download(ctx, "images/a/original.jpg")
+			require.NoError(t, err)
+			_ = body.Close()
+			_, err = storage.Exists(ctx, "images/a/original.jpg")
+			require.NoError(t, err)
+			require.NoError(t, storage.CopyObject(ctx, "images/a/original.jpg", "images/b/original.jpg"))
+			_, err = storage.ListObjects(ctx, "images/", 10)
+			require.NoError(t, err)
+			require.NoError(t, storage.Delete(ctx, "images/b/original.jpg"))
+			_, err = storage.BatchDelete(ctx, []BatchDeleteOperation{{Key: "images/a/original.jpg"}})
+			require.NoError(t, err)
+
+			for _, op := range []string{"PutObject", "GetObject", "HeadObject", "CopyObject", "ListObjectsV2", "DeleteObject", "DeleteObjects"} {
+				require.Contains(t, client.payers, op)
+				assert.Equal(t, tt.expected, client.payers[op], "request payer on %s", op)
+			}
+		})
+	}
+}
+
+func TestS3Storage_ReadWriteBuckets(t *testing.T) {
+	client := &recordingS3Client{payers: map[string]types.RequestPayer{}}
+	presignClient := s3.NewFromConfig(aws.Config{
+		Region:      "us-east-1",
+		Credentials: credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+	})
+	storage := &S3Storage{
+		client:     client,
+		presigner:  s3.NewPresignClient(presignClient),
+		config:     &config.S3Config{Endpoint: "https://s3.amazonaws.com", UseSSL: true, HealthWriteProbeDisabled: true},
+		bucket:     "primary-bucket",
+		readBucket: "replica-bucket",
+	}
+	ctx := context.Background()
+
+	require.NoError(t, storage.Upload(ctx, "images/a/original.jpg", strings.NewReader("data"), 4, "image/jpeg"))
+	require.NoError(t, storage.CopyObject(ctx, "images/a/original.jpg", "images/b/original.jpg"))
+	require.NoError(t, storage.Delete(ctx, "images/b/original.jpg"))
+	_, err := storage.BatchDelete(ctx, []BatchDeleteOperation{{Key: "images/a/original.jpg"}})
+	require.NoError(t, err)
+	for _, op := range []string{"PutObject", "CopyObject", "DeleteObject", "DeleteObjects"} {
+		assert.Equal(t, "primary-bucket", client.buckets[op], "bucket of %s", op)
+	}
+
+	body, err := storage.Download(ctx, "images/a/original.jpg")
+	require.NoError(t, err)
+	_ = body.Close()
+	assert.Equal(t, "replica-bucket", client.buckets["GetObject"])
+
+	_, err = storage.Exists(ctx, "images/a/original.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, "replica-bucket", client.buckets["HeadObject"])
+
+	signed, err := storage.GeneratePresignedURL(ctx, "images/a/original.jpg", time.Minute)
+	require.NoError(t, err)
+	parsed, err := url.Parse(signed)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(parsed.Host, "replica-bucket.s3."), parsed.Host)
+	assert.Equal(t, "/images/a/original.jpg", parsed.Path)
+	assert.NotContains(t, signed, "primary-bucket")
+	assert.Contains(t, storage.GetURL("images/a/original.jpg"), "replica-bucket")
+
+	// Health lists both buckets, the write bucket first
+	require.NoError(t, storage.Health(ctx))
+	assert.Equal(t, "replica-bucket", client.buckets["ListObjectsV2"])
+}
+
+func TestS3Storage_CDNBaseURL(t *testing.T) {
+	newStorage := func(cfg *config.S3Config) *S3Storage {
+		presignClient := s3.NewFromConfig(aws.Config{
+			Region:      "us-east-1",
+			Credentials: credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+		}, clientOptions(cfg, nil))
+		return &S3Storage{
+			presigner:  s3.NewPresignClient(presignClient),
+			config:     cfg,
+			bucket:     "images-bucket",
+			readBucket: "images-bucket",
+		}
+	}
+	ctx := context.Background()
+	key := "images/a/800x600.jpg"
+
+	t.Run("without a CDN URLs point at S3", func(t *testing.T) {
+		storage := newStorage(&config.S3Config{Endpoint: "https://s3.amazonaws.com", UseSSL: true})
+
+		assert.Equal(t, "https://images-bucket.s3.amazonaws.com/images/a/800x600.jpg", storage.GetURL(key))
+
+		signed, err := storage.GeneratePresignedURL(ctx, key, time.Minute)
+		require.NoError(t, err)
+		parsed, err := url.Parse(signed)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(parsed.Host, "images-bucket.s3."), parsed.Host)
+		assert.Equal(t, "/images/a/800x600.jpg", parsed.Path)
+	})
+
+	t.Run("public URLs use the CDN host", func(t *testing.T) {
+		storage := newStorage(&config.S3Config{Endpoint: "https://s3.amazonaws.com", UseSSL: true, CDNBaseURL: "https://cdn.example.com/media"})
+
+		assert.Equal(t, "https://cdn.example.com/media/images/a/800x600.jpg", storage.GetURL(key))
+
+		signed, err := storage.GeneratePresignedURL(ctx, key, time.Minute)
+		require.NoError(t, err)
+		parsed, err := url.Parse(signed)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(parsed.Host, "images-bucket.s3."), "presigned URLs stay on S3 without passthrough, got %s", parsed.Host)
+	})
+
+	t.Run("presigned URLs move to the CDN with passthrough", func(t *testing.T) {
+		storage := newStorage(&config.S3Config{Endpoint: "https://s3.amazonaws.com", UseSSL: true, CDNBaseURL: "https://cdn.example.com", CDNPresignedURLs: true})
+
+		signed, err := storage.GeneratePresignedURL(ctx, key, time.Minute)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(signed, "https://cdn.example.com/images/a/800x600.jpg?"), signed)
+		assert.Contains(t, signed, "X-Amz-Signature=")
+	})
+
+	t.Run("path-style endpoints drop the bucket from the CDN path", func(t *testing.T) {
+		storage := newStorage(&config.S3Config{Endpoint: "http://minio:9000", ForcePathStyle: true, CDNBaseURL: "https://cdn.example.com", CDNPresignedURLs: true})
+
+		assert.Equal(t, "https://cdn.example.com/images/a/800x600.jpg", storage.GetURL(key))
+
+		signed, err := storage.GeneratePresignedURL(ctx, key, time.Minute)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(signed, "https://cdn.example.com/images/a/800x600.jpg?"), signed)
+		assert.Contains(t, signed, "X-Amz-Signature=")
+	})
+}
+
+func TestClientOptions_PathStyle(t *testing.T) {
+	tests := []struct {
+		name           string
+		endpoint       string
+		forcePathStyle bool
+	}{
+		{name: "aws virtual-hosted", endpoint: "https://s3.amazonaws.com", forcePathStyle: false},
+		{name: "aws forced path-style", endpoint: "https://s3.amazonaws.com", forcePathStyle: true},
+		{name: "custom endpoint path-style", endpoint: "http://minio:9000", forcePathStyle: true},
+		{name: "custom endpoint virtual-hosted", endpoint: "https://storage.example.com", forcePathStyle: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var o s3.Options
+			clientOptions(&config.S3Config{Endpoint: tt.endpoint, ForcePathStyle: tt.forcePathStyle}, nil)(&o)
+			assert.Equal(t, tt.forcePathStyle, o.UsePathStyle)
+		})
+	}
+}
+
+func TestS3Storage_GetURL_AddressingStyle(t *testing.T) {
+	key := "images/a/original.jpg"
+
+	t.Run("path-style", func(t *testing.T) {
+		storage := &S3Storage{config: &config.S3Config{Endpoint: "http://minio:9000", ForcePathStyle: true}, readBucket: "images-bucket"}
+		assert.Equal(t, "http://minio:9000/images-bucket/images/a/original.jpg", storage.GetURL(key))
+	})
+
+	t.Run("virtual-hosted", func(t *testing.T) {
+		storage := &S3Storage{config: &config.S3Config{Endpoint: "https://storage.example.com", UseSSL: true}, readBucket: "images-bucket"}
+		assert.Equal(t, "https://images-bucket.storage.example.com/images/a/original.jpg", storage.GetURL(key))
+	})
+}
+
+func TestParseRequestHeaders(t *testing.T) {
+	headers, err := parseRequestHeaders([]string{"x-amz-expected-bucket-owner: 123456789012", "X-Gateway-Token:abc"})
+	require.NoError(t, err)
+	assert.Equal(t, "123456789012", headers.Get("X-Amz-Expected-Bucket-Owner"))
+	assert.Equal(t, "abc", headers.Get("X-Gateway-Token"))
+
+	// Run the injected middleware and capture the outgoing request
+	stack := middleware.NewStack("test", smithyhttp.NewStackRequest)
+	for _, option := range requestHeaderOptions(headers) {
+		require.NoError(t, option(stack))
+	}
+	var sent *smithyhttp.Request
+	handler := middleware.DecorateHandler(middleware.HandlerFunc(func(ctx context.Context, input interface{}) (interface{}, middleware.Metadata, error) {
+		sent = input.(*smithyhttp.Request)
+		return nil, middleware.Metadata{}, nil
+	}), stack)
+	_, _, err = handler.Handle(context.Background(), struct{}{})
+	require.NoError(t, err)
+	require.NotNil(t, sent)
+	assert.Equal(t, "123456789012", sent.Header.Get("X-Amz-Expected-Bucket-Owner"))
+	assert.Equal(t, "abc", sent.Header.Get("X-Gateway-Token"))
+
+	_, err = parseRequestHeaders([]string{"missing-separator"})
+	assert.Error(t, err)
+
+	_, err = parseRequestHeaders([]string{": value"})
+	assert.Error(t, err)
+}
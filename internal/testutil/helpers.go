@@ -3,6 +3,9 @@ package testutil
 import (
 	"bytes"
 	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -57,8 +60,12 @@ func ParseJSONResponse(resp *httptest.ResponseRecorder, target interface{}) erro
 func TestConfig() *config.Config {
 	return &config.Config{
 		Server: config.ServerConfig{
-			Port:    "8080",
-			GinMode: "test",
+			Port:               "8080",
+			GinMode:            "test",
+			MaxRequestBodySize: 11534336, // 11MB
+			TrustedProxies:     []string{},
+			CompressionEnabled: true,
+			CompressionMinSize: 1024,
 		},
 		Redis: config.RedisConfig{
 			URL:      "redis://localhost:6379",
@@ -72,20 +79,34 @@ func TestConfig() *config.Config {
 			Directory: "/tmp/test",
 		},
 		S3: config.S3Config{
-			Endpoint:  "http://localhost:9000",
-			AccessKey: "test",
-			SecretKey: "test",
-			Bucket:    "test-bucket",
-			Region:    "us-east-1",
-			UseSSL:    false,
+			Endpoint:               "http://localhost:9000",
+			AccessKey:              "test",
+			SecretKey:              "test",
+			Bucket:                 "test-bucket",
+			Region:                 "us-east-1",
+			UseSSL:                 false,
+			BatchDeleteConcurrency: 3,
+			HealthCheckPrefix:      "health-check/",
 		},
 		Image: config.ImageConfig{
 			MaxFileSize:                10485760, // 10MB
 			Quality:                    85,
 			GenerateDefaultResolutions: true,
+			DeduplicationEnabled:       true,
+			DedupNamespaceSource:       "none",
+			DedupNamespaceHeader:       "X-Tenant-ID",
+			DedupFailureMode:           "skip",
 			ResizeMode:                 "smart_fit",
+			ResampleFilter:             "lanczos",
+			JPEGSubsampling:            "420",
+			UploadPartialFailureMode:   "continue",
+			UploadFieldName:            "image",
 			MaxWidth:                   4096,
 			MaxHeight:                  4096,
+			MaxSourceWidth:             8192,
+			MaxSourceHeight:            8192,
+			AspectRatioTolerance:       0.01,
+			ProcessingTimeout:          30 * time.Second,
 		},
 		RateLimit: config.RateLimitConfig{
 			Upload:   10,
@@ -106,11 +127,15 @@ func TestConfig() *config.Config {
 			S3ChecksDisabled: false,
 			S3ChecksInterval: 30 * time.Second,
 			CheckInterval:    30 * time.Second,
+
+			ReadinessInterval:         5 * time.Second,
+			ReadinessFailureThreshold: 3,
 		},
 		Auth: config.AuthConfig{
 			Enabled:       false, // Default to disabled for tests
 			ReadWriteKeys: []string{},
 			ReadOnlyKeys:  []string{},
+			AdminKeys:     []string{},
 			KeyHeader:     "X-API-Key",
 		},
 	}
@@ -143,6 +168,23 @@ func CreateTestImageData() []byte {
 	}
 }
 
+// CreateTestPNG creates a decodable PNG of the given dimensions. Pixels follow a
+// non-uniform pattern so the encoded data is large enough for format detection.
+func CreateTestPNG(width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x * 7), G: uint8(y * 13), B: uint8(x ^ y), A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
 // CreateLargeTestImageData creates test image data that exceeds size limits
 func CreateLargeTestImageData(size int) []byte {
 	data := make([]byte, size)
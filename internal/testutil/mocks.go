@@ -50,10 +50,10 @@ type MockImageService struct {
 	ProcessUploadFunc        func(ctx context.Context, input interface{}) (interface{}, error)
 	GetMetadataFunc          func(ctx context.Context, imageID string) (*models.ImageMetadata, error)
 	GetImageStreamFunc       func(ctx context.Context, imageID, resolution string) (io.ReadCloser, *models.ImageMetadata, error)
-	ProcessResolutionFunc    func(ctx context.Context, imageID, resolution string) error
+	ProcessResolutionFunc    func(ctx context.Context, imageID, resolution string, force bool) error
 	GeneratePresignedURLFunc func(ctx context.Context, storageKey string, expiration time.Duration) (string, error)
 	DeleteImageFunc          func(ctx context.Context, imageID string) error
-	ListImagesFunc           func(ctx context.Context, offset, limit int) ([]*models.ImageMetadata, int, error)
+	ListImagesFunc           func(ctx context.Context, offset, limit int, sortBy string) ([]*models.ImageMetadata, int, error)
 }
 
 func (m *MockImageService) ProcessUpload(ctx context.Context, input interface{}) (interface{}, error) {
@@ -77,9 +77,9 @@ func (m *MockImageService) GetImageStream(ctx context.Context, imageID, resoluti
 	return nil, nil, nil
 }
 
-func (m *MockImageService) ProcessResolution(ctx context.Context, imageID, resolution string) error {
+func (m *MockImageService) ProcessResolution(ctx context.Context, imageID, resolution string, force bool) error {
 	if m.ProcessResolutionFunc != nil {
-		return m.ProcessResolutionFunc(ctx, imageID, resolution)
+		return m.ProcessResolutionFunc(ctx, imageID, resolution, force)
 	}
 	return nil
 }
@@ -98,9 +98,9 @@ func (m *MockImageService) DeleteImage(ctx context.Context, imageID string) erro
 	return nil
 }
 
-func (m *MockImageService) ListImages(ctx context.Context, offset, limit int) ([]*models.ImageMetadata, int, error) {
+func (m *MockImageService) ListImages(ctx context.Context, offset, limit int, sortBy string) ([]*models.ImageMetadata, int, error) {
 	if m.ListImagesFunc != nil {
-		return m.ListImagesFunc(ctx, offset, limit)
+		return m.ListImagesFunc(ctx, offset, limit, sortBy)
 	}
 	return nil, 0, nil
 }
@@ -139,10 +139,12 @@ type MockImageRepository struct {
 	UpdateFunc      func(ctx context.Context, metadata *models.ImageMetadata) error
 	DeleteFunc      func(ctx context.Context, id string) error
 	ExistsFunc      func(ctx context.Context, id string) (bool, error)
+	CountFunc       func(ctx context.Context) (int64, error)
 	HealthCheckFunc func(ctx context.Context) error
 	GetFunc         func(ctx context.Context, id string) (*models.ImageMetadata, error)
 	StoreFunc       func(ctx context.Context, metadata *models.ImageMetadata) error
 	ListFunc        func(ctx context.Context, offset, limit int) ([]*models.ImageMetadata, error)
+	ListByDedupFunc func(ctx context.Context, filter string, offset, limit int) ([]*models.ImageMetadata, models.DedupStatusCounts, error)
 	HealthFunc      func(ctx context.Context) error
 	CloseFunc       func() error
 	GetStatsFunc    func(ctx context.Context) (*repository.RepositoryStats, error)
@@ -203,6 +205,13 @@ func (m *MockImageRepository) Exists(ctx context.Context, id string) (bool, erro
 	return false, nil
 }
 
+func (m *MockImageRepository) Count(ctx context.Context) (int64, error) {
+	if m.CountFunc != nil {
+		return m.CountFunc(ctx)
+	}
+	return 0, nil
+}
+
 func (m *MockImageRepository) List(ctx context.Context, offset, limit int) ([]*models.ImageMetadata, error) {
 	if m.ListFunc != nil {
 		return m.ListFunc(ctx, offset, limit)
@@ -210,6 +219,13 @@ func (m *MockImageRepository) List(ctx context.Context, offset, limit int) ([]*m
 	return nil, nil
 }
 
+func (m *MockImageRepository) ListByDedupStatus(ctx context.Context, filter string, offset, limit int) ([]*models.ImageMetadata, models.DedupStatusCounts, error) {
+	if m.ListByDedupFunc != nil {
+		return m.ListByDedupFunc(ctx, filter, offset, limit)
+	}
+	return nil, models.DedupStatusCounts{}, nil
+}
+
 func (m *MockImageRepository) HealthCheck(ctx context.Context) error {
 	if m.HealthCheckFunc != nil {
 		return m.HealthCheckFunc(ctx)
@@ -264,10 +280,42 @@ func (m *MockImageRepository) GetImagesByTimeRange(ctx context.Context, start, e
 	return 0, nil
 }
 
+func (m *MockImageRepository) GetUploadCountsByDay(ctx context.Context, start time.Time, days int) ([]models.DailyUploadCount, error) {
+	return []models.DailyUploadCount{}, nil
+}
+
 func (m *MockImageRepository) GetStorageUsageByResolution(ctx context.Context) (map[string]int64, error) {
 	return map[string]int64{}, nil
 }
 
+func (m *MockImageRepository) FindByFilename(ctx context.Context, filename string) ([]string, error) {
+	return []string{}, nil
+}
+
+func (m *MockImageRepository) FindCorrupt(ctx context.Context) ([]models.CorruptRecord, error) {
+	return nil, nil
+}
+
+func (m *MockImageRepository) DeleteCorrupt(ctx context.Context, id string) error {
+	return nil
+}
+
+func (m *MockImageRepository) IncrementAccessCounts(ctx context.Context, id string, counts map[string]int64) error {
+	return nil
+}
+
+func (m *MockImageRepository) GetAccessCounts(ctx context.Context, id string) (*models.AccessCounts, error) {
+	return &models.AccessCounts{}, nil
+}
+
+func (m *MockImageRepository) GetPopularImages(ctx context.Context, limit int) ([]models.PopularImage, error) {
+	return []models.PopularImage{}, nil
+}
+
+func (m *MockImageRepository) TouchLastAccessed(ctx context.Context, id string, at time.Time) error {
+	return nil
+}
+
 // MockStorageProvider is a mock implementation of StorageProvider
 type MockStorageProvider struct {
 	UploadFunc               func(ctx context.Context, key string, data io.Reader, contentType string) error
@@ -362,6 +410,8 @@ func (m *MockStorageProvider) GetURL(key string) string {
 // MockProcessorService is a mock implementation of ProcessorService
 type MockProcessorService struct {
 	ProcessImageFunc  func(data []byte, config ResizeConfig) ([]byte, error)
+	ExtractFrameFunc  func(data []byte, index int, config ResizeConfig) ([]byte, error)
+	ConvertFormatFunc func(data []byte, config ResizeConfig) ([]byte, error)
 	ValidateImageFunc func(data []byte, maxSize int64) error
 	DetectFormatFunc  func(data []byte) (string, error)
 	GetDimensionsFunc func(data []byte) (width, height int, err error)
@@ -374,6 +424,20 @@ func (m *MockProcessorService) ProcessImage(data []byte, config interface{}) ([]
 	return nil, nil
 }
 
+func (m *MockProcessorService) ExtractFrame(data []byte, index int, config interface{}) ([]byte, error) {
+	if m.ExtractFrameFunc != nil {
+		return m.ExtractFrameFunc(data, index, config.(ResizeConfig))
+	}
+	return nil, nil
+}
+
+func (m *MockProcessorService) ConvertFormat(data []byte, config interface{}) ([]byte, error) {
+	if m.ConvertFormatFunc != nil {
+		return m.ConvertFormatFunc(data, config.(ResizeConfig))
+	}
+	return data, nil
+}
+
 func (m *MockProcessorService) ValidateImage(data []byte, maxSize int64) error {
 	if m.ValidateImageFunc != nil {
 		return m.ValidateImageFunc(data, maxSize)
@@ -0,0 +1,11 @@
+// Package resizr holds assets that are embedded into the server binary.
+package resizr
+
+import (
+	_ "embed"
+)
+
+// OpenAPISpec contains the OpenAPI specification served at /openapi.yaml
+//
+//go:embed openapi.yaml
+var OpenAPISpec []byte
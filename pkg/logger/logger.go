@@ -70,6 +70,13 @@ func GetLogger() *zap.Logger {
 	return globalLogger
 }
 
+// SetLogger replaces the global logger and returns the previous one (used by tests to capture output)
+func SetLogger(logger *zap.Logger) *zap.Logger {
+	previous := GetLogger()
+	globalLogger = logger
+	return previous
+}
+
 // Context-aware logging functions
 func WithContext(ctx context.Context) *zap.Logger {
 	logger := GetLogger()
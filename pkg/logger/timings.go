@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// TimingsKey is the context key of the per-request phase timings
+const TimingsKey contextKey = "timings"
+
+// Request phases recorded with TrackTiming
+const (
+	TimingProcessing = "processing"
+	TimingStorage    = "storage"
+)
+
+// Timings accumulates how long a request spent in each phase
+type Timings struct {
+	mu     sync.Mutex
+	phases map[string]time.Duration
+}
+
+// WithTimings attaches a new Timings recorder to ctx
+func WithTimings(ctx context.Context) (context.Context, *Timings) {
+	timings := &Timings{phases: make(map[string]time.Duration)}
+	return context.WithValue(ctx, TimingsKey, timings), timings
+}
+
+// TrackTiming adds the time since start to phase of the request's recorder, if any.
+// Use it deferred: defer logger.TrackTiming(ctx, logger.TimingStorage, time.Now())
+func TrackTiming(ctx context.Context, phase string, start time.Time) {
+	if ctx == nil {
+		return
+	}
+	timings, ok := ctx.Value(TimingsKey).(*Timings)
+	if !ok {
+		return
+	}
+
+	elapsed := time.Since(start)
+	timings.mu.Lock()
+	defer timings.mu.Unlock()
+	timings.phases[phase] += elapsed
+}
+
+// Fields returns one "<phase>_ms" field per recorded phase, sorted by phase
+func (t *Timings) Fields() []zap.Field {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	phases := make([]string, 0, len(t.phases))
+	for phase := range t.phases {
+		phases = append(phases, phase)
+	}
+	sort.Strings(phases)
+
+	fields := make([]zap.Field, 0, len(phases))
+	for _, phase := range phases {
+		fields = append(fields, zap.Float64(phase+"_ms", float64(t.phases[phase])/float64(time.Millisecond)))
+	}
+	return fields
+}
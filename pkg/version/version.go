@@ -0,0 +1,54 @@
+// Package version holds build information injected at link time, e.g.
+//
+//	go build -ldflags "-X resizr/pkg/version.Version=1.2.0 -X resizr/pkg/version.Commit=$(git rev-parse HEAD) -X resizr/pkg/version.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Build variables, overridden with -ldflags "-X resizr/pkg/version.<Name>=<value>"
+var (
+	Version   = "0.0.1"
+	Commit    = ""
+	BuildTime = ""
+)
+
+// unknown is reported for build details that were neither injected nor recorded by the toolchain
+const unknown = "unknown"
+
+// Info describes the running binary
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get returns the build information. The commit falls back to the VCS revision
+// the Go toolchain embeds when it was not injected via ldflags.
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+	}
+
+	if buildInfo, ok := debug.ReadBuildInfo(); ok && info.Commit == "" {
+		for _, setting := range buildInfo.Settings {
+			if setting.Key == "vcs.revision" {
+				info.Commit = setting.Value
+			}
+		}
+	}
+
+	if info.Commit == "" {
+		info.Commit = unknown
+	}
+	if info.BuildTime == "" {
+		info.BuildTime = unknown
+	}
+	return info
+}
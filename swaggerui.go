@@ -0,0 +1,18 @@
+package resizr
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed swagger-ui/swagger-ui-bundle.js swagger-ui/swagger-ui.css
+var swaggerUIFiles embed.FS
+
+// SwaggerUIAssets contains the pinned Swagger UI files served under /docs/assets
+var SwaggerUIAssets = func() fs.FS {
+	assets, err := fs.Sub(swaggerUIFiles, "swagger-ui")
+	if err != nil {
+		panic(err) // Only possible for an invalid directory name
+	}
+	return assets
+}()